@@ -18,6 +18,12 @@ type MetricMaker interface {
 	MakeMetric(metric telegraf.Metric) telegraf.Metric
 }
 
+// ErrorCounter is implemented by a MetricMaker that counts the errors of its
+// plugin.
+type ErrorCounter interface {
+	IncrErrors()
+}
+
 type accumulator struct {
 	maker     MetricMaker
 	metrics   chan<- telegraf.Metric
@@ -111,6 +117,9 @@ func (ac *accumulator) AddError(err error) {
 		return
 	}
 	NErrors.Incr(1)
+	if c, ok := ac.maker.(ErrorCounter); ok {
+		c.IncrErrors()
+	}
 	log.Printf("E! [%s]: Error in plugin: %v", ac.maker.Name(), err)
 }
 
@@ -3,12 +3,15 @@ package agent
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -69,6 +72,22 @@ func TestAccAddError(t *testing.T) {
 	assert.Contains(t, string(errs[2]), "baz")
 }
 
+func TestAccAddErrorCounted(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	maker := &countingMetricMaker{}
+	a := NewAccumulator(maker, metrics)
+
+	a.AddError(fmt.Errorf("foo"))
+	a.AddError(nil)
+	a.AddError(fmt.Errorf("bar"))
+
+	require.Equal(t, 2, maker.errors)
+}
+
 func TestSetPrecision(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -141,6 +160,35 @@ func TestAddTrackingMetricGroupEmpty(t *testing.T) {
 	}
 }
 
+func TestAddTrackingMetricNaming(t *testing.T) {
+	ch := make(chan telegraf.Metric, 10)
+	input := models.NewRunningInput(&testServiceInput{}, &models.InputConfig{
+		Name:              "test",
+		NameOverride:      "foobar",
+		MeasurementPrefix: "pre_",
+	})
+	acc := NewAccumulator(input, ch).WithTracking(1)
+
+	acc.AddTrackingMetric(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)))
+
+	m := <-ch
+	require.Equal(t, "pre_foobar", m.Name())
+	m.Accept()
+}
+
+// testServiceInput is a service input, its metrics are added from outside of
+// the gathers.
+type testServiceInput struct{}
+
+func (i *testServiceInput) Description() string                   { return "" }
+func (i *testServiceInput) SampleConfig() string                  { return "" }
+func (i *testServiceInput) Gather(acc telegraf.Accumulator) error { return nil }
+func (i *testServiceInput) Start(acc telegraf.Accumulator) error  { return nil }
+func (i *testServiceInput) Stop()                                 {}
+
 type TestMetricMaker struct {
 }
 
@@ -151,3 +199,12 @@ func (tm *TestMetricMaker) Name() string {
 func (tm *TestMetricMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	return metric
 }
+
+type countingMetricMaker struct {
+	TestMetricMaker
+	errors int
+}
+
+func (tm *countingMetricMaker) IncrErrors() {
+	tm.errors++
+}
@@ -0,0 +1,237 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	defaultAdminAddress      = "localhost:8090"
+	defaultAdminReadTimeout  = 10 * time.Second
+	defaultAdminWriteTimeout = 10 * time.Second
+)
+
+// AdminStatus is the response of the status endpoint of the admin API.
+type AdminStatus struct {
+	Version string              `json:"version"`
+	Inputs  []AdminInputStatus  `json:"inputs"`
+	Outputs []AdminOutputStatus `json:"outputs"`
+}
+
+// AdminInputStatus is the status of an input.
+type AdminInputStatus struct {
+	Name    string `json:"name"`
+	Alias   string `json:"alias,omitempty"`
+	Failing bool   `json:"failing"`
+}
+
+// AdminOutputStatus is the status of an output, Error is the error of its
+// most recent write.
+type AdminOutputStatus struct {
+	Name           string  `json:"name"`
+	Alias          string  `json:"alias,omitempty"`
+	Connected      bool    `json:"connected"`
+	Error          string  `json:"error,omitempty"`
+	BufferLength   int     `json:"buffer_length"`
+	BufferFullness float64 `json:"buffer_fullness"`
+}
+
+// AdminStat is an internal metric of the agent.
+type AdminStat struct {
+	Name   string                 `json:"name"`
+	Tags   map[string]string      `json:"tags"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// adminServer serves the admin API of the agent over HTTP.
+type adminServer struct {
+	agent  *Agent
+	config *config.AdminConfig
+	server *http.Server
+}
+
+func newAdminServer(a *Agent, conf *config.AdminConfig) (*adminServer, error) {
+	c := *conf
+	if c.Token == "" {
+		return nil, errors.New("admin API requires a token")
+	}
+	if c.ServiceAddress == "" {
+		c.ServiceAddress = defaultAdminAddress
+	}
+	if c.ReadTimeout.Duration == 0 {
+		c.ReadTimeout.Duration = defaultAdminReadTimeout
+	}
+	if c.WriteTimeout.Duration == 0 {
+		c.WriteTimeout.Duration = defaultAdminWriteTimeout
+	}
+	c.PathPrefix = strings.TrimRight(c.PathPrefix, "/")
+
+	s := &adminServer{
+		agent:  a,
+		config: &c,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(c.PathPrefix+"/api/v1/status", s.serveStatus)
+	mux.HandleFunc(c.PathPrefix+"/api/v1/stats", s.serveStats)
+	mux.HandleFunc(c.PathPrefix+"/api/v1/reload", s.serveReload)
+	s.server = &http.Server{
+		Addr:         c.ServiceAddress,
+		Handler:      s.authenticate(mux),
+		ReadTimeout:  c.ReadTimeout.Duration,
+		WriteTimeout: c.WriteTimeout.Duration,
+	}
+	return s, nil
+}
+
+// Start listens on the service address and serves requests in the
+// background.
+func (s *adminServer) Start() error {
+	listener, err := net.Listen("tcp", s.config.ServiceAddress)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := s.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving admin API: %v", err)
+		}
+	}()
+
+	log.Printf("I! [agent] Serving admin API on %s", listener.Addr())
+	return nil
+}
+
+// Stop stops the server.
+func (s *adminServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+}
+
+// authenticate requires the token of the config as a bearer token.
+func (s *adminServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(auth[len("Bearer "):]), []byte(s.config.Token)) != 1 {
+			res.Header().Set("WWW-Authenticate", `Bearer realm="telegraf"`)
+			http.Error(res, "Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+// Status returns the status of the plugins.
+func (s *adminServer) Status() *AdminStatus {
+	status := &AdminStatus{
+		Version: internal.Version(),
+		Inputs:  []AdminInputStatus{},
+		Outputs: []AdminOutputStatus{},
+	}
+	for _, input := range s.agent.Config.Inputs {
+		status.Inputs = append(status.Inputs, AdminInputStatus{
+			Name:    input.Name(),
+			Alias:   input.Config.Alias,
+			Failing: input.Failing(),
+		})
+	}
+	for _, output := range s.agent.Config.Outputs {
+		connected, err := output.Status()
+		o := AdminOutputStatus{
+			Name:           "outputs." + output.Name,
+			Alias:          output.Config.Alias,
+			Connected:      connected,
+			BufferLength:   output.BufferLength(),
+			BufferFullness: output.BufferFullness(),
+		}
+		if err != nil {
+			o.Error = err.Error()
+		}
+		status.Outputs = append(status.Outputs, o)
+	}
+	return status
+}
+
+func (s *adminServer) serveStatus(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(res, http.StatusOK, s.Status())
+}
+
+func (s *adminServer) serveStats(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats := []AdminStat{}
+	for _, m := range selfstat.Metrics() {
+		if m == nil {
+			continue
+		}
+		stats = append(stats, AdminStat{
+			Name:   m.Name(),
+			Tags:   m.Tags(),
+			Fields: m.Fields(),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Name != stats[j].Name {
+			return stats[i].Name < stats[j].Name
+		}
+		return tagsKey(stats[i].Tags) < tagsKey(stats[j].Tags)
+	})
+	writeJSON(res, http.StatusOK, stats)
+}
+
+// serveReload requests a reload of the config, the response is sent before
+// the agent restarts.  The config is kept if the new one is invalid.
+func (s *adminServer) serveReload(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if s.agent.Reload == nil {
+		http.Error(res, "Reload is not supported.", http.StatusNotImplemented)
+		return
+	}
+
+	// A reload already pending covers this request.
+	select {
+	case s.agent.Reload <- "requested by the admin API":
+	default:
+	}
+	res.WriteHeader(http.StatusAccepted)
+}
+
+func writeJSON(res http.ResponseWriter, code int, v interface{}) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(code)
+	json.NewEncoder(res).Encode(v)
+}
+
+func tagsKey(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k, v := range tags {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
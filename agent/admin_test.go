@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/stretchr/testify/require"
+)
+
+func adminRequest(s *adminServer, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.server.Handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	a, _, _ := newHealthAgent()
+	_, err := newAdminServer(a, &config.AdminConfig{})
+	require.Error(t, err)
+}
+
+func TestAdmin_Authenticate(t *testing.T) {
+	a, _, _ := newHealthAgent()
+	s, err := newAdminServer(a, &config.AdminConfig{Token: "secret"})
+	require.NoError(t, err)
+
+	rec := adminRequest(s, "GET", "/api/v1/status", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = adminRequest(s, "GET", "/api/v1/status", "wrong")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = adminRequest(s, "GET", "/api/v1/status", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdmin_Status(t *testing.T) {
+	a, _, _ := newHealthAgent()
+	s, err := newAdminServer(a, &config.AdminConfig{Token: "secret"})
+	require.NoError(t, err)
+	require.NoError(t, a.Config.Outputs[0].Connect())
+
+	rec := adminRequest(s, "GET", "/api/v1/status", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	status := &AdminStatus{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), status))
+	require.Equal(t, []AdminInputStatus{{Name: "inputs.test"}}, status.Inputs)
+	require.Equal(t, []AdminOutputStatus{{Name: "outputs.test", Connected: true}},
+		status.Outputs)
+
+	rec = adminRequest(s, "POST", "/api/v1/status", "secret")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestAdmin_Stats(t *testing.T) {
+	a, _, _ := newHealthAgent()
+	s, err := newAdminServer(a, &config.AdminConfig{Token: "secret"})
+	require.NoError(t, err)
+
+	stat := selfstat.Register("admin_test", "requests", map[string]string{"test": "stats"})
+	stat.Set(42)
+
+	rec := adminRequest(s, "GET", "/api/v1/stats", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var stats []AdminStat
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	found := false
+	for _, stat := range stats {
+		if stat.Name == "internal_admin_test" {
+			found = true
+			require.Equal(t, map[string]string{"test": "stats"}, stat.Tags)
+			require.Equal(t, map[string]interface{}{"requests": 42.0}, stat.Fields)
+		}
+	}
+	require.True(t, found)
+}
+
+func TestAdmin_Reload(t *testing.T) {
+	a, _, _ := newHealthAgent()
+	s, err := newAdminServer(a, &config.AdminConfig{
+		Token:      "secret",
+		PathPrefix: "/telegraf/",
+	})
+	require.NoError(t, err)
+
+	// Not supported without a reload channel
+	rec := adminRequest(s, "POST", "/telegraf/api/v1/reload", "secret")
+	require.Equal(t, http.StatusNotImplemented, rec.Code)
+
+	reload := make(chan string, 1)
+	a.Reload = reload
+
+	rec = adminRequest(s, "GET", "/telegraf/api/v1/reload", "secret")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = adminRequest(s, "POST", "/telegraf/api/v1/reload", "secret")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, reload, 1)
+
+	// A second request does not block while a reload is pending
+	rec = adminRequest(s, "POST", "/telegraf/api/v1/reload", "secret")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, reload, 1)
+
+	rec = adminRequest(s, "POST", "/api/v1/reload", "secret")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
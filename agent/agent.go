@@ -3,8 +3,12 @@ package agent
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,22 +16,244 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/persister"
+	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// serviceC receives the metrics of service inputs, it is shared with the
+	// next agent on reload as retained service inputs keep writing to it.
+	serviceC chan telegraf.Metric
+	// started holds the plugins taken over from the previous agent, these
+	// are already running.
+	started map[interface{}]bool
+	// retained holds the plugins taken over by the next agent, these are
+	// left running when the agent stops.
+	retained map[interface{}]bool
+	// retries tracks the service inputs waiting to be started again.
+	retries sync.WaitGroup
+
+	// Reload receives the reason when a reload of the config is requested by
+	// the admin API, reloads are not supported if it is nil.
+	Reload chan<- string
 }
 
 // NewAgent returns an Agent for the given Config.
 func NewAgent(config *config.Config) (*Agent, error) {
+	if err := groupOutputs(config); err != nil {
+		return nil, err
+	}
+	if err := checkRoutes(config); err != nil {
+		return nil, err
+	}
+
 	a := &Agent{
-		Config: config,
+		Config:   config,
+		serviceC: make(chan telegraf.Metric, 100),
+		started:  make(map[interface{}]bool),
+		retained: make(map[interface{}]bool),
 	}
 	return a, nil
 }
 
+// groupOutputs replaces the outputs of each output group with a single
+// output that writes to the group.  The group output uses the settings, such
+// as the filters and buffer, of the first output in the group.  Groups are
+// skipped when none of their outputs are selected by the output filter.
+func groupOutputs(config *config.Config) error {
+	for _, group := range config.OutputGroups {
+		var members []*models.RunningOutput
+		for _, name := range group.Outputs {
+			var member *models.RunningOutput
+			for _, output := range config.Outputs {
+				if output.Routed(name) {
+					member = output
+					break
+				}
+			}
+			if member == nil {
+				if len(config.OutputFilters) > 0 {
+					continue
+				}
+				return fmt.Errorf("output group %s has undefined output %q",
+					group.Name, name)
+			}
+			members = append(members, member)
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		var names, ids []string
+		var plugins []telegraf.Output
+		for _, member := range members {
+			names = append(names, member.Name)
+			if member.Config.Alias != "" {
+				names[len(names)-1] = member.Config.Alias
+			}
+			ids = append(ids, member.Fingerprint)
+			plugins = append(plugins, member.Output)
+		}
+
+		conf := *members[0].Config
+		conf.Name = group.Name
+		conf.Alias = ""
+		ro := models.NewRunningOutput(group.Name,
+			models.NewOutputGroup(group, names, plugins), &conf,
+			config.Agent.MetricBatchSize, config.Agent.MetricBufferLimit)
+		ro.Fingerprint = fmt.Sprintf("%v%s", *group, strings.Join(ids, ","))
+
+		outputs := []*models.RunningOutput{ro}
+		for _, output := range config.Outputs {
+			isMember := false
+			for _, member := range members {
+				if output == member {
+					isMember = true
+					break
+				}
+			}
+			if !isMember {
+				outputs = append(outputs, output)
+			}
+		}
+		config.Outputs = outputs
+	}
+	return nil
+}
+
+// checkRoutes verifies that the outputs named by the inputs exist.  Routes
+// are not checked when outputs are selected with a filter.
+func checkRoutes(config *config.Config) error {
+	if len(config.OutputFilters) > 0 {
+		return nil
+	}
+
+	for _, input := range config.Inputs {
+		for _, name := range input.Config.Outputs {
+			found := false
+			for _, output := range config.Outputs {
+				if output.Routed(name) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("input %s routes to undefined output %q",
+					input.Name(), name)
+			}
+		}
+	}
+	return nil
+}
+
+// Reuse takes over the running plugins of the previous agent whose settings
+// are unchanged, replacing the newly created instances.  These plugins keep
+// their state and, for outputs, their buffered metrics.  Plugins are only
+// taken over if the agent settings and global tags are unchanged.
+//
+// Reuse must be called before the previous agent is stopped.
+func (a *Agent) Reuse(prev *Agent) {
+	a.serviceC = prev.serviceC
+
+	if !reflect.DeepEqual(a.Config.Agent, prev.Config.Agent) ||
+		!reflect.DeepEqual(a.Config.Tags, prev.Config.Tags) {
+		log.Printf("I! [agent] Agent settings or global tags changed, restarting all plugins")
+		return
+	}
+
+	var prevIDs, ids []string
+	for _, input := range prev.Config.Inputs {
+		prevIDs = append(prevIDs, input.Fingerprint)
+	}
+	for _, input := range a.Config.Inputs {
+		ids = append(ids, input.Fingerprint)
+	}
+	for i, j := range matchFingerprints(prevIDs, ids) {
+		if j >= 0 {
+			a.Config.Inputs[i] = prev.Config.Inputs[j]
+			a.takeOver(prev, a.Config.Inputs[i], a.Config.Inputs[i].Name())
+		}
+	}
+
+	prevIDs, ids = nil, nil
+	for _, processor := range prev.Config.Processors {
+		prevIDs = append(prevIDs, processor.Fingerprint)
+	}
+	for _, processor := range a.Config.Processors {
+		ids = append(ids, processor.Fingerprint)
+	}
+	for i, j := range matchFingerprints(prevIDs, ids) {
+		if j < 0 {
+			continue
+		}
+		// Streaming processors run within the processor loop of an agent and
+		// are always restarted.
+		if _, ok := prev.Config.Processors[j].Processor.(telegraf.StreamingProcessor); !ok {
+			a.Config.Processors[i] = prev.Config.Processors[j]
+			a.takeOver(prev, a.Config.Processors[i], "processors."+a.Config.Processors[i].Name)
+		}
+	}
+
+	prevIDs, ids = nil, nil
+	for _, aggregator := range prev.Config.Aggregators {
+		prevIDs = append(prevIDs, aggregator.Fingerprint)
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		ids = append(ids, aggregator.Fingerprint)
+	}
+	for i, j := range matchFingerprints(prevIDs, ids) {
+		if j >= 0 {
+			a.Config.Aggregators[i] = prev.Config.Aggregators[j]
+			a.takeOver(prev, a.Config.Aggregators[i], a.Config.Aggregators[i].Name())
+		}
+	}
+
+	prevIDs, ids = nil, nil
+	for _, output := range prev.Config.Outputs {
+		prevIDs = append(prevIDs, output.Fingerprint)
+	}
+	for _, output := range a.Config.Outputs {
+		ids = append(ids, output.Fingerprint)
+	}
+	for i, j := range matchFingerprints(prevIDs, ids) {
+		if j >= 0 {
+			a.Config.Outputs[i] = prev.Config.Outputs[j]
+			a.takeOver(prev, a.Config.Outputs[i], "outputs."+a.Config.Outputs[i].Name)
+		}
+	}
+}
+
+// takeOver marks a plugin of the previous agent as running in this agent.
+func (a *Agent) takeOver(prev *Agent, plugin interface{}, name string) {
+	log.Printf("D! [agent] Keeping unchanged plugin %s running", name)
+	prev.retained[plugin] = true
+	a.started[plugin] = true
+}
+
+// matchFingerprints pairs each fingerprint in ids with an unused equal
+// fingerprint in prevIDs.  It returns the index into prevIDs for each entry
+// of ids, or -1 if there is no match.
+func matchFingerprints(prevIDs, ids []string) []int {
+	unused := make(map[string][]int)
+	for j, id := range prevIDs {
+		unused[id] = append(unused[id], j)
+	}
+
+	matches := make([]int, len(ids))
+	for i, id := range ids {
+		matches[i] = -1
+		if candidates := unused[id]; len(candidates) > 0 {
+			matches[i] = candidates[0]
+			unused[id] = candidates[1:]
+		}
+	}
+	return matches
+}
+
 // Run starts and runs the Agent until the context is done.
 func (a *Agent) Run(ctx context.Context) error {
 	log.Printf("I! [agent] Config: Interval:%s, Quiet:%#v, Hostname:%#v, "+
@@ -39,21 +265,97 @@ func (a *Agent) Run(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	if a.Config.Agent.Health != nil {
+		h, err := newHealthServer(a, a.Config.Agent.Health)
+		if err != nil {
+			return err
+		}
+		err = h.Start()
+		if err != nil {
+			return fmt.Errorf("could not start health check: %v", err)
+		}
+		defer h.Stop()
+	}
+
+	if a.Config.Agent.Admin != nil {
+		s, err := newAdminServer(a, a.Config.Agent.Admin)
+		if err != nil {
+			return err
+		}
+		err = s.Start()
+		if err != nil {
+			return fmt.Errorf("could not start admin API: %v", err)
+		}
+		defer s.Stop()
+	}
+
+	if a.Config.Agent.Pprof != nil {
+		p := newPprofServer(a.Config.Agent.Pprof)
+		err := p.Start()
+		if err != nil {
+			return fmt.Errorf("could not start pprof: %v", err)
+		}
+		defer p.Stop()
+	}
+
+	if a.Config.Agent.Metadata != nil {
+		err := a.startMetadata(ctx, a.Config.Agent.Metadata)
+		if err != nil {
+			return err
+		}
+	}
+
+	var p *persister.Persister
+	if a.Config.Agent.Statefile != "" {
+		log.Printf("D! [agent] Loading plugin state")
+		var err error
+		p, err = a.loadState()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := a.limitSeries()
+	if err != nil {
+		return err
+	}
+
 	log.Printf("D! [agent] Connecting outputs")
-	err := a.connectOutputs(ctx)
+	err = a.connectOutputs(ctx)
 	if err != nil {
 		return err
 	}
 
+	// Outputs that block when their buffer is full must not keep the agent
+	// from stopping.
+	for _, output := range a.Config.Outputs {
+		output.ReleaseBuffer(false)
+	}
+	go func() {
+		<-ctx.Done()
+		for _, output := range a.Config.Outputs {
+			output.ReleaseBuffer(true)
+		}
+	}()
+
 	inputC := make(chan telegraf.Metric, 100)
 	procC := make(chan telegraf.Metric, 100)
 	outputC := make(chan telegraf.Metric, 100)
 
 	startTime := time.Now()
 
+	log.Printf("D! [agent] Starting streaming processors")
+	streams, err := a.startStreamingProcessors()
+	if err != nil {
+		return err
+	}
+
 	log.Printf("D! [agent] Starting service inputs")
-	err = a.startServiceInputs(ctx, inputC)
+	err = a.startServiceInputs(ctx, a.serviceC)
 	if err != nil {
+		for _, s := range streams {
+			s.processor.Stop()
+		}
 		return err
 	}
 
@@ -66,14 +368,25 @@ func (a *Agent) Run(ctx context.Context) error {
 	go func(dst chan telegraf.Metric) {
 		defer wg.Done()
 
+		var fwg sync.WaitGroup
+		fwg.Add(1)
+		go func() {
+			defer fwg.Done()
+			a.forwardServiceMetrics(ctx, dst)
+		}()
+
 		err := a.runInputs(ctx, startTime, dst)
 		if err != nil {
 			log.Printf("E! [agent] Error running inputs: %v", err)
 		}
 
 		log.Printf("D! [agent] Stopping service inputs")
+		a.retries.Wait()
 		a.stopServiceInputs()
 
+		fwg.Wait()
+		a.drainServiceMetrics(dst)
+
 		close(dst)
 		log.Printf("D! [agent] Input channel closed")
 	}(dst)
@@ -87,7 +400,7 @@ func (a *Agent) Run(ctx context.Context) error {
 		go func(src, dst chan telegraf.Metric) {
 			defer wg.Done()
 
-			err := a.runProcessors(src, dst)
+			err := a.runProcessors(streams, src, dst)
 			if err != nil {
 				log.Printf("E! [agent] Error running processors: %v", err)
 			}
@@ -134,12 +447,44 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	if p != nil {
+		log.Printf("D! [agent] Storing plugin state")
+		err = p.Store()
+		if err != nil {
+			log.Printf("E! [agent] Error storing plugin state: %v", err)
+		}
+	}
+
 	log.Printf("D! [agent] Stopped Successfully")
 	return nil
 }
 
+// TestConfig configures a test run of the inputs.
+type TestConfig struct {
+	// Once gathers all inputs even if some fail, the number of inputs that
+	// failed is returned as error.  Errors reported by the inputs through
+	// the accumulator are counted as failures as well.
+	Once bool
+
+	// GatherTimeout overrides the gather timeout of the inputs when set.
+	GatherTimeout time.Duration
+
+	// Serializer formats the metrics, when nil metrics are printed in line
+	// protocol prefixed with "> ".
+	Serializer serializers.Serializer
+
+	// Output receives the metrics, defaults to stdout.
+	Output io.Writer
+}
+
 // Test runs the inputs once and prints the output to stdout in line protocol.
 func (a *Agent) Test(ctx context.Context) error {
+	return a.RunTest(ctx, TestConfig{})
+}
+
+// RunTest runs the inputs once, writing the metrics to the output of the
+// config.  Service inputs are skipped.
+func (a *Agent) RunTest(ctx context.Context, config TestConfig) error {
 	var wg sync.WaitGroup
 	metricC := make(chan telegraf.Metric)
 	nulC := make(chan telegraf.Metric)
@@ -149,18 +494,31 @@ func (a *Agent) Test(ctx context.Context) error {
 		wg.Wait()
 	}()
 
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		s := influx.NewSerializer()
-		s.SetFieldSortOrder(influx.SortFields)
+		s := config.Serializer
+		prefix := ""
+		if s == nil {
+			influxSerializer := influx.NewSerializer()
+			influxSerializer.SetFieldSortOrder(influx.SortFields)
+			s = influxSerializer
+			prefix = "> "
+		}
 		for metric := range metricC {
+			metric.RemoveTag(models.RouteTag)
 			octets, err := s.Serialize(metric)
-			if err == nil {
-				fmt.Print("> ", string(octets))
-
+			if err != nil {
+				log.Printf("E! [agent] Could not serialize metric: %v", err)
+				continue
 			}
+			fmt.Fprint(output, prefix, string(octets))
 		}
 	}()
 
@@ -171,45 +529,96 @@ func (a *Agent) Test(ctx context.Context) error {
 		}
 	}()
 
+	var failed int
 	for _, input := range a.Config.Inputs {
 		select {
 		case <-ctx.Done():
 			return nil
 		default:
-			if _, ok := input.Input.(telegraf.ServiceInput); ok {
-				log.Printf("W!: [agent] skipping plugin [[%s]]: service inputs not supported in --test mode",
-					input.Name())
-				continue
-			}
+		}
 
-			acc := NewAccumulator(input, metricC)
-			acc.SetPrecision(a.Config.Agent.Precision.Duration,
-				a.Config.Agent.Interval.Duration)
-			input.SetDefaultTags(a.Config.Tags)
-
-			// Special instructions for some inputs. cpu, for example, needs to be
-			// run twice in order to return cpu usage percentages.
-			switch input.Name() {
-			case "inputs.cpu", "inputs.mongodb", "inputs.procstat":
-				nulAcc := NewAccumulator(input, nulC)
-				nulAcc.SetPrecision(a.Config.Agent.Precision.Duration,
-					a.Config.Agent.Interval.Duration)
-				if err := input.Input.Gather(nulAcc); err != nil {
-					return err
-				}
+		if _, ok := input.Input.(telegraf.ServiceInput); ok {
+			log.Printf("W!: [agent] skipping plugin [[%s]]: service inputs not supported in --test mode",
+				input.Name())
+			continue
+		}
 
-				time.Sleep(500 * time.Millisecond)
-				if err := input.Input.Gather(acc); err != nil {
-					return err
-				}
-			default:
-				if err := input.Input.Gather(acc); err != nil {
-					return err
-				}
+		if config.GatherTimeout > 0 {
+			input.Config.GatherTimeout = config.GatherTimeout
+		}
+
+		errorsBefore := input.GatherErrors.Get()
+		err := a.testInput(ctx, input, metricC, nulC)
+		if !config.Once {
+			if err != nil {
+				return err
 			}
+			continue
+		}
+
+		if err != nil {
+			log.Printf("E! [%s] Error in plugin: %v", input.Name(), err)
+		}
+		if err != nil || input.GatherErrors.Get() > errorsBefore {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inputs failed", failed, len(a.Config.Inputs))
+	}
+	return nil
+}
+
+// testInput gathers the input once for a test run.
+func (a *Agent) testInput(
+	ctx context.Context,
+	input *models.RunningInput,
+	metricC chan<- telegraf.Metric,
+	nulC chan<- telegraf.Metric,
+) error {
+	precision := a.Config.Agent.Precision.Duration
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
+	}
+
+	acc := NewAccumulator(input, metricC)
+	acc.SetPrecision(precision, a.Config.Agent.Interval.Duration)
+	input.SetDefaultTags(a.Config.Tags)
+
+	// Special instructions for some inputs. cpu, for example, needs to be
+	// run twice in order to return cpu usage percentages.
+	switch input.Name() {
+	case "inputs.cpu", "inputs.mongodb", "inputs.procstat":
+		nulAcc := NewAccumulator(input, nulC)
+		nulAcc.SetPrecision(precision, a.Config.Agent.Interval.Duration)
+		if _, err := a.gatherOnce(ctx, nulAcc, input, a.Config.Agent.Interval.Duration); err != nil {
+			return err
 		}
+
+		time.Sleep(500 * time.Millisecond)
 	}
 
+	_, err := a.gatherOnce(ctx, acc, input, a.Config.Agent.Interval.Duration)
+	return err
+}
+
+// limitSeries sets the series limit of each input, inputs without a limit
+// of their own use the limit of the agent.
+func (a *Agent) limitSeries() error {
+	for _, input := range a.Config.Inputs {
+		limit := a.Config.Agent.MaxSeries
+		overflow := a.Config.Agent.SeriesOverflow
+		if input.Config.MaxSeries != 0 {
+			limit = input.Config.MaxSeries
+		}
+		if input.Config.SeriesOverflow != "" {
+			overflow = input.Config.SeriesOverflow
+		}
+		if err := input.LimitSeries(limit, overflow); err != nil {
+			return fmt.Errorf("could not limit series of %s: %v", input.Name(), err)
+		}
+	}
 	return nil
 }
 
@@ -232,6 +641,12 @@ func (a *Agent) runInputs(
 		if input.Config.Interval != 0 {
 			interval = input.Config.Interval
 		}
+		if input.Config.Precision != 0 {
+			precision = input.Config.Precision
+		}
+		if input.Config.CollectionJitter != 0 {
+			jitter = input.Config.CollectionJitter
+		}
 
 		acc := NewAccumulator(input, dst)
 		acc.SetPrecision(precision, interval)
@@ -270,15 +685,29 @@ func (a *Agent) gatherOnInterval(
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	// running is set while a gather that timed out has not returned.
+	var running <-chan error
 	for {
 		err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
 		if err != nil {
 			return
 		}
 
-		err = a.gatherOnce(acc, input, interval)
-		if err != nil {
-			acc.AddError(err)
+		if running != nil {
+			select {
+			case <-running:
+				running = nil
+			default:
+				log.Printf("W! [agent] input %q is still running a gather that timed out, skipping",
+					input.Name())
+			}
+		}
+
+		if running == nil && input.Started() {
+			running, err = a.gatherOnce(ctx, acc, input, interval)
+			if err != nil {
+				acc.AddError(err)
+			}
 		}
 
 		select {
@@ -291,51 +720,254 @@ func (a *Agent) gatherOnInterval(
 }
 
 // gatherOnce runs the input's Gather function once, logging a warning each
-// interval it fails to complete before.
+// interval it fails to complete before.  If the input has a gather timeout,
+// the gather is abandoned once it expires and the returned channel receives
+// when the gather completes.
 func (a *Agent) gatherOnce(
+	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
-	timeout time.Duration,
-) error {
-	ticker := time.NewTicker(timeout)
+	interval time.Duration,
+) (<-chan error, error) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	done := make(chan error)
+	var timeout <-chan time.Time
+	var expiring *expiringAccumulator
+	if input.Config.GatherTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, input.Config.GatherTimeout)
+		defer cancel()
+
+		timer := time.NewTimer(input.Config.GatherTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+
+		expiring = &expiringAccumulator{Accumulator: acc}
+		acc = expiring
+	}
+
+	done := make(chan error, 1)
 	go func() {
-		done <- input.Gather(acc)
+		done <- input.GatherContext(ctx, acc)
 	}()
 
 	for {
 		select {
 		case err := <-done:
-			return err
+			return nil, err
 		case <-ticker.C:
 			log.Printf("W! [agent] input %q did not complete within its interval",
 				input.Name())
+		case <-timeout:
+			// Metrics added by the gather from now on are dropped.
+			expiring.expire()
+			return done, fmt.Errorf("gather did not complete within timeout of %s",
+				input.Config.GatherTimeout)
 		}
 	}
 }
 
+// expiringAccumulator drops all metrics once expired, so that a gather that
+// timed out can not add metrics after the agent has given up on it.
+type expiringAccumulator struct {
+	telegraf.Accumulator
+
+	mu      sync.RWMutex
+	expired bool
+}
+
+func (ac *expiringAccumulator) expire() {
+	ac.mu.Lock()
+	ac.expired = true
+	ac.mu.Unlock()
+}
+
+func (ac *expiringAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if !ac.expired {
+		ac.Accumulator.AddFields(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *expiringAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if !ac.expired {
+		ac.Accumulator.AddGauge(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *expiringAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if !ac.expired {
+		ac.Accumulator.AddCounter(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *expiringAccumulator) AddSummary(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if !ac.expired {
+		ac.Accumulator.AddSummary(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *expiringAccumulator) AddHistogram(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if !ac.expired {
+		ac.Accumulator.AddHistogram(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *expiringAccumulator) AddMetric(m telegraf.Metric) {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+	if !ac.expired {
+		ac.Accumulator.AddMetric(m)
+	} else {
+		m.Drop()
+	}
+}
+
+// processorStream is a started streaming processor.
+type processorStream struct {
+	// index is the position of the processor in the processor list
+	index     int
+	processor telegraf.StreamingProcessor
+	metrics   chan telegraf.Metric
+}
+
+// streamedMetric is a metric emitted by a streaming processor, next is the
+// index of the processor to apply next.
+type streamedMetric struct {
+	next   int
+	metric telegraf.Metric
+}
+
+// processorMaker is the MetricMaker of streaming processors, the metrics
+// they emit are passed on unchanged.
+type processorMaker struct {
+	processor *models.RunningProcessor
+}
+
+func (p processorMaker) Name() string {
+	return "processors." + p.processor.Name
+}
+
+func (p processorMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
+	return metric
+}
+
+// startStreamingProcessors starts all streaming processors.
+func (a *Agent) startStreamingProcessors() ([]processorStream, error) {
+	var streams []processorStream
+	for i, processor := range a.Config.Processors {
+		sp, ok := processor.Processor.(telegraf.StreamingProcessor)
+		if !ok {
+			continue
+		}
+
+		metrics := make(chan telegraf.Metric, 100)
+		err := sp.Start(NewAccumulator(processorMaker{processor}, metrics))
+		if err != nil {
+			log.Printf("E! [agent] Processor %s failed to start: %v",
+				processor.Name, err)
+
+			for _, s := range streams {
+				s.processor.Stop()
+			}
+
+			return nil, err
+		}
+
+		streams = append(streams, processorStream{i, sp, metrics})
+	}
+	return streams, nil
+}
+
 // runProcessors applies processors to metrics.
+//
+// Metrics emitted by streaming processors continue with the processors
+// following them.  When src is closed the streaming processors are stopped
+// in order, so that the metrics they emit while stopping are processed.
 func (a *Agent) runProcessors(
+	streams []processorStream,
 	src <-chan telegraf.Metric,
 	agg chan<- telegraf.Metric,
 ) error {
-	for metric := range src {
-		metrics := a.applyProcessors(metric)
-
-		for _, metric := range metrics {
+	send := func(m telegraf.Metric, next int) {
+		for _, metric := range a.applyProcessors(m, next, true) {
 			agg <- metric
 		}
 	}
 
+	streamC := make(chan streamedMetric, 100)
+	done := make([]chan struct{}, len(streams))
+	for i, s := range streams {
+		done[i] = make(chan struct{})
+		go func(s processorStream, done chan struct{}) {
+			defer close(done)
+			for metric := range s.metrics {
+				streamC <- streamedMetric{s.index + 1, metric}
+			}
+		}(s, done[i])
+	}
+
+	for src != nil {
+		select {
+		case metric, ok := <-src:
+			if !ok {
+				src = nil
+				break
+			}
+			send(metric, 0)
+		case sm := <-streamC:
+			send(sm.metric, sm.next)
+		}
+	}
+
+	for i, s := range streams {
+		go func(s processorStream) {
+			s.processor.Stop()
+			close(s.metrics)
+		}(s)
+
+	wait:
+		for {
+			select {
+			case sm := <-streamC:
+				send(sm.metric, sm.next)
+			case <-done[i]:
+				break wait
+			}
+		}
+
+		for len(streamC) > 0 {
+			sm := <-streamC
+			send(sm.metric, sm.next)
+		}
+	}
+
 	return nil
 }
 
-// applyProcessors applies all processors to a metric.
-func (a *Agent) applyProcessors(m telegraf.Metric) []telegraf.Metric {
+// applyProcessors applies the processors, starting with the one at index
+// start, to a metric.  Streaming processors are skipped unless streaming is
+// set, as their output is only handled by runProcessors.
+func (a *Agent) applyProcessors(
+	m telegraf.Metric,
+	start int,
+	streaming bool,
+) []telegraf.Metric {
 	metrics := []telegraf.Metric{m}
-	for _, processor := range a.Config.Processors {
+	for _, processor := range a.Config.Processors[start:] {
+		if _, ok := processor.Processor.(telegraf.StreamingProcessor); ok && !streaming {
+			continue
+		}
 		metrics = processor.Apply(metrics...)
 	}
 
@@ -399,7 +1031,7 @@ func (a *Agent) runAggregators(
 	}
 
 	for metric := range aggregations {
-		metrics := a.applyProcessors(metric)
+		metrics := a.applyProcessors(metric, 0, false)
 		for _, metric := range metrics {
 			dst <- metric
 		}
@@ -471,8 +1103,14 @@ func (a *Agent) runOutputs(
 	}
 
 	for metric := range src {
-		for i, output := range a.Config.Outputs {
-			if i == len(a.Config.Outputs)-1 {
+		outputs := a.routeOutputs(metric)
+		if len(outputs) == 0 {
+			metric.Drop()
+			continue
+		}
+
+		for i, output := range outputs {
+			if i == len(outputs)-1 {
 				output.AddMetric(metric)
 			} else {
 				output.AddMetric(metric.Copy())
@@ -487,6 +1125,24 @@ func (a *Agent) runOutputs(
 	return nil
 }
 
+// routeOutputs returns the outputs the metric is routed to and removes the
+// route from the metric.  Metrics without a route go to all outputs.
+func (a *Agent) routeOutputs(metric telegraf.Metric) []*models.RunningOutput {
+	route, ok := metric.GetTag(models.RouteTag)
+	if !ok {
+		return a.Config.Outputs
+	}
+	metric.RemoveTag(models.RouteTag)
+
+	var outputs []*models.RunningOutput
+	for _, output := range a.Config.Outputs {
+		if output.Routed(route) {
+			outputs = append(outputs, output)
+		}
+	}
+	return outputs
+}
+
 // flush runs an output's flush function periodically until the context is
 // done.
 func (a *Agent) flush(
@@ -510,7 +1166,7 @@ func (a *Agent) flush(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			a.flushShutdown(output, interval)
 			return
 		default:
 		}
@@ -527,12 +1183,71 @@ func (a *Agent) flush(
 				logError(a.flushOnce(output, interval, output.WriteBatch))
 			}
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			a.flushShutdown(output, interval)
 			return
 		}
 	}
 }
 
+// flushShutdown writes the metrics left in the output's buffer when the agent
+// stops.  With a shutdown_timeout failed writes are retried until it expires,
+// a write in progress is still waited for so that the output is not closed
+// while writing.  The number of metrics flushed and dropped is logged.
+func (a *Agent) flushShutdown(output *models.RunningOutput, interval time.Duration) {
+	timeout := a.Config.Agent.ShutdownTimeout.Duration
+	before := output.BufferLength()
+
+	if timeout == 0 {
+		err := a.flushOnce(output, interval, output.Write)
+		if err != nil {
+			log.Printf("E! [agent] Error writing to output [%s]: %v", output.Name, err)
+		}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				err := output.Write()
+				if err == nil {
+					return
+				}
+				log.Printf("E! [agent] Error writing to output [%s]: %v", output.Name, err)
+				if internal.SleepContext(ctx, time.Second) != nil {
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			log.Printf("W! [agent] output %q did not flush within the shutdown timeout",
+				output.Name)
+			<-done
+		}
+	}
+
+	remaining := output.BufferLength()
+	flushed := before - remaining
+	if flushed < 0 {
+		flushed = 0
+	}
+	switch {
+	case remaining == 0:
+		log.Printf("I! [agent] Flushed %d metrics to output %q on shutdown",
+			flushed, output.Name)
+	case output.Config.BufferDirectory != "":
+		log.Printf("W! [agent] Flushed %d metrics to output %q on shutdown, "+
+			"%d metrics are kept in the disk buffer", flushed, output.Name, remaining)
+	default:
+		log.Printf("W! [agent] Flushed %d metrics to output %q on shutdown, "+
+			"dropped %d metrics", flushed, output.Name, remaining)
+	}
+}
+
 // flushOnce runs the output's Write function once, logging a warning each
 // interval it fails to complete before.
 func (a *Agent) flushOnce(
@@ -562,12 +1277,68 @@ func (a *Agent) flushOnce(
 
 }
 
-// connectOutputs connects to all outputs.
+// forwardServiceMetrics passes the metrics of service inputs on to dst until
+// the context is done.
+func (a *Agent) forwardServiceMetrics(
+	ctx context.Context,
+	dst chan<- telegraf.Metric,
+) {
+	for {
+		select {
+		case metric := <-a.serviceC:
+			dst <- metric
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drainServiceMetrics passes the metrics queued by service inputs on to dst.
+// Retained service inputs may continue to queue metrics, these are handled
+// by the next agent.
+func (a *Agent) drainServiceMetrics(dst chan<- telegraf.Metric) {
+	for {
+		select {
+		case metric := <-a.serviceC:
+			dst <- metric
+		default:
+			return
+		}
+	}
+}
+
+// connectOutputs connects to all outputs.  Outputs that fail to connect are
+// handled according to their startup error behavior.
 func (a *Agent) connectOutputs(ctx context.Context) error {
+	outputs := a.Config.Outputs[:0]
 	for _, output := range a.Config.Outputs {
-		log.Printf("D! [agent] Attempting connection to output: %s\n", output.Name)
-		err := output.Output.Connect()
+		if a.started[output] {
+			outputs = append(outputs, output)
+			continue
+		}
+
+		err := output.OpenBuffer()
 		if err != nil {
+			return fmt.Errorf("could not open buffer of output %s: %v", output.Name, err)
+		}
+
+		log.Printf("D! [agent] Attempting connection to output: %s\n", output.Name)
+		err = output.Connect()
+		switch {
+		case err == nil:
+		case output.Config.StartupErrorBehavior == models.StartupErrorBehaviorRetry:
+			log.Printf("E! [agent] Failed to connect to output %s, retrying on "+
+				"each write: %v", output.Name, err)
+			outputs = append(outputs, output)
+			continue
+		case output.Config.StartupErrorBehavior == models.StartupErrorBehaviorIgnore:
+			log.Printf("E! [agent] Failed to connect to output %s, ignoring "+
+				"the output: %v", output.Name, err)
+			if err := output.CloseBuffer(); err != nil {
+				log.Printf("E! [agent] Error closing buffer of output %s: %v", output.Name, err)
+			}
+			continue
+		default:
 			log.Printf("E! [agent] Failed to connect to output %s, retrying in 15s, "+
 				"error was '%s' \n", output.Name, err)
 
@@ -576,13 +1347,15 @@ func (a *Agent) connectOutputs(ctx context.Context) error {
 				return err
 			}
 
-			err = output.Output.Connect()
+			err = output.Connect()
 			if err != nil {
 				return err
 			}
 		}
 		log.Printf("D! [agent] Successfully connected to output: %s\n", output.Name)
+		outputs = append(outputs, output)
 	}
+	a.Config.Outputs = outputs
 	return nil
 }
 
@@ -590,53 +1363,195 @@ func (a *Agent) connectOutputs(ctx context.Context) error {
 func (a *Agent) closeOutputs() error {
 	var err error
 	for _, output := range a.Config.Outputs {
-		err = output.Output.Close()
+		if a.retained[output] {
+			continue
+		}
+		err = output.Close()
+
+		if e := output.CloseBuffer(); e != nil {
+			log.Printf("E! [agent] Error closing buffer of output %s: %v", output.Name, e)
+		}
 	}
 	return err
 }
 
-// startServiceInputs starts all service inputs.
+// startServiceInputs starts all service inputs.  Inputs that fail to start
+// are handled according to their startup error behavior, inputs to retry are
+// started again in the background until the context is done.
 func (a *Agent) startServiceInputs(
 	ctx context.Context,
 	dst chan<- telegraf.Metric,
 ) error {
-	started := []telegraf.ServiceInput{}
+	started := []*models.RunningInput{}
+	retry := map[*models.RunningInput]telegraf.Accumulator{}
 
+	inputs := a.Config.Inputs[:0]
 	for _, input := range a.Config.Inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
-			// Service input plugins are not subject to timestamp rounding.
-			// This only applies to the accumulator passed to Start(), the
-			// Gather() accumulator does apply rounding according to the
-			// precision agent setting.
-			acc := NewAccumulator(input, dst)
-			acc.SetPrecision(time.Nanosecond, 0)
-
-			err := si.Start(acc)
-			if err != nil {
-				log.Printf("E! [agent] Service for input %s failed to start: %v",
-					input.Name(), err)
+		// Inputs taken over from the previous agent may still be waiting
+		// for a retry.
+		if _, ok := input.Input.(telegraf.ServiceInput); !ok || input.Started() {
+			inputs = append(inputs, input)
+			continue
+		}
 
-				for _, si := range started {
-					si.Stop()
-				}
+		// Service input plugins are not subject to timestamp rounding,
+		// unless the input has a precision of its own.
+		// This only applies to the accumulator passed to Start(), the
+		// Gather() accumulator does apply rounding according to the
+		// precision agent setting.
+		acc := NewAccumulator(input, dst)
+		acc.SetPrecision(time.Nanosecond, 0)
+		if input.Config.Precision != 0 {
+			acc.SetPrecision(input.Config.Precision, 0)
+		}
 
-				return err
+		err := input.Start(acc)
+		switch {
+		case err == nil:
+			started = append(started, input)
+		case input.Config.StartupErrorBehavior == models.StartupErrorBehaviorRetry:
+			log.Printf("E! [agent] Service for input %s failed to start, retrying "+
+				"in 15s: %v", input.Name(), err)
+			retry[input] = acc
+		case input.Config.StartupErrorBehavior == models.StartupErrorBehaviorIgnore:
+			log.Printf("E! [agent] Service for input %s failed to start, ignoring "+
+				"the input: %v", input.Name(), err)
+			continue
+		default:
+			log.Printf("E! [agent] Service for input %s failed to start: %v",
+				input.Name(), err)
+
+			for _, input := range started {
+				input.Stop()
 			}
 
-			started = append(started, si)
+			return err
 		}
+		inputs = append(inputs, input)
+	}
+	a.Config.Inputs = inputs
+
+	// The retries are only started once all inputs are started, so that
+	// none is left running when an input fails.
+	for input, acc := range retry {
+		a.retries.Add(1)
+		go func(input *models.RunningInput, acc telegraf.Accumulator) {
+			defer a.retries.Done()
+			a.retryServiceInput(ctx, input, acc)
+		}(input, acc)
 	}
 
 	return nil
 }
 
+// retryServiceInput starts the input every 15s until it is running or the
+// context is done.
+func (a *Agent) retryServiceInput(
+	ctx context.Context,
+	input *models.RunningInput,
+	acc telegraf.Accumulator,
+) {
+	for {
+		if err := internal.SleepContext(ctx, 15*time.Second); err != nil {
+			return
+		}
+
+		err := input.Start(acc)
+		if err == nil {
+			// The agent may have stopped its inputs while this one was
+			// starting.
+			if ctx.Err() != nil {
+				input.Stop()
+				return
+			}
+			log.Printf("I! [agent] Service for input %s started", input.Name())
+			return
+		}
+		log.Printf("E! [agent] Service for input %s failed to start, retrying "+
+			"in 15s: %v", input.Name(), err)
+	}
+}
+
 // stopServiceInputs stops all service inputs.
 func (a *Agent) stopServiceInputs() {
 	for _, input := range a.Config.Inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
-			si.Stop()
+		if a.retained[input] {
+			continue
+		}
+		input.Stop()
+	}
+}
+
+// statefulPlugin is a plugin implementing telegraf.StatefulPlugin, its state
+// is identified by the fingerprint of the plugin settings.
+type statefulPlugin struct {
+	id     string
+	name   string
+	model  interface{}
+	plugin telegraf.StatefulPlugin
+}
+
+// statefulPlugins returns all plugins that implement telegraf.StatefulPlugin.
+func (a *Agent) statefulPlugins() []statefulPlugin {
+	var plugins []statefulPlugin
+	for _, input := range a.Config.Inputs {
+		if p, ok := input.Input.(telegraf.StatefulPlugin); ok {
+			plugins = append(plugins, statefulPlugin{
+				input.Fingerprint, input.Name(), input, p})
+		}
+	}
+	for _, processor := range a.Config.Processors {
+		if p, ok := processor.Processor.(telegraf.StatefulPlugin); ok {
+			plugins = append(plugins, statefulPlugin{
+				processor.Fingerprint, "processors." + processor.Name, processor, p})
+		}
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		if p, ok := aggregator.Aggregator.(telegraf.StatefulPlugin); ok {
+			plugins = append(plugins, statefulPlugin{
+				aggregator.Fingerprint, aggregator.Name(), aggregator, p})
+		}
+	}
+	for _, output := range a.Config.Outputs {
+		if p, ok := output.Output.(telegraf.StatefulPlugin); ok {
+			plugins = append(plugins, statefulPlugin{
+				output.Fingerprint, "outputs." + output.Name, output, p})
+		}
+	}
+	return plugins
+}
+
+// loadState restores the state of the stateful plugins from the state file
+// and returns the persister used to store their state when the agent stops.
+// Plugins taken over from the previous agent keep their current state.  As
+// the state is identified by the plugin settings, it is discarded when the
+// settings of a plugin change.
+func (a *Agent) loadState() (*persister.Persister, error) {
+	p := persister.NewPersister(a.Config.Agent.Statefile)
+	register := func(sp statefulPlugin) {
+		if err := p.Register(sp.id, sp.plugin); err != nil {
+			log.Printf("W! [agent] Not saving state of %s, another plugin has identical settings",
+				sp.name)
+		}
+	}
+
+	plugins := a.statefulPlugins()
+	for _, sp := range plugins {
+		if !a.started[sp.model] {
+			register(sp)
+		}
+	}
+
+	if err := p.Load(); err != nil {
+		return nil, err
+	}
+
+	for _, sp := range plugins {
+		if a.started[sp.model] {
+			register(sp)
 		}
 	}
+	return p, nil
 }
 
 // panicRecover displays an error if an input panics.
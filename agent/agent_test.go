@@ -1,16 +1,29 @@
 package agent
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/testutil"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	// needing to load the outputs
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
+	// needing to load the processors
+	_ "github.com/influxdata/telegraf/plugins/processors/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +122,527 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+func TestAgent_Reuse(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfig("../internal/config/testdata/reload.toml")
+	assert.NoError(t, err)
+	prev, _ := NewAgent(c)
+
+	c = config.NewConfig()
+	err = c.LoadConfig("../internal/config/testdata/reload_changed.toml")
+	assert.NoError(t, err)
+	a, _ := NewAgent(c)
+	a.Reuse(prev)
+
+	// exec is unchanged, memcached has new servers
+	assert.Equal(t, 2, len(a.Config.Inputs))
+	prevExec, prevMemcached := findInput(prev, "inputs.exec"), findInput(prev, "inputs.memcached")
+	exec, memcached := findInput(a, "inputs.exec"), findInput(a, "inputs.memcached")
+	assert.True(t, exec == prevExec)
+	assert.True(t, memcached != prevMemcached)
+	assert.True(t, a.Config.Outputs[0] == prev.Config.Outputs[0])
+
+	assert.True(t, prev.retained[prevExec])
+	assert.False(t, prev.retained[prevMemcached])
+	assert.True(t, prev.retained[prev.Config.Outputs[0]])
+	assert.True(t, a.started[exec])
+	assert.False(t, a.started[memcached])
+}
+
+func TestAgent_ReuseAgentChanged(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfig("../internal/config/testdata/reload.toml")
+	assert.NoError(t, err)
+	prev, _ := NewAgent(c)
+
+	c = config.NewConfig()
+	c.Tags["dc"] = "us-east-1"
+	err = c.LoadConfig("../internal/config/testdata/reload.toml")
+	assert.NoError(t, err)
+	a, _ := NewAgent(c)
+	a.Reuse(prev)
+
+	assert.Empty(t, prev.retained)
+	assert.True(t, a.Config.Outputs[0] != prev.Config.Outputs[0])
+}
+
+func TestAgent_RouteOutputs(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfig("../internal/config/testdata/routes.toml")
+	require.NoError(t, err)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := findInput(a, "inputs.memcached")
+	require.NotNil(t, input)
+
+	m := input.MakeMetric(testutil.MustMetric("memcached",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)))
+	outputs := a.routeOutputs(m)
+	require.Len(t, outputs, 1)
+	assert.Equal(t, "db", outputs[0].Config.Alias)
+	assert.False(t, m.HasTag(models.RouteTag))
+
+	m = testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0))
+	assert.Len(t, a.routeOutputs(m), 2)
+}
+
+func TestAgent_RouteThroughProcessors(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfig("../internal/config/testdata/routes_processors.toml")
+	require.NoError(t, err)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := findInput(a, "inputs.memcached")
+	require.NotNil(t, input)
+
+	m := input.MakeMetric(testutil.MustMetric("memcached",
+		map[string]string{"host": "localhost", "server": "localhost"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)))
+	metrics := a.applyProcessors(m, 0, false)
+	require.Len(t, metrics, 1)
+	assert.Equal(t, map[string]string{
+		"host":          "localhost",
+		models.RouteTag: "db",
+	}, metrics[0].Tags())
+
+	outputs := a.routeOutputs(metrics[0])
+	require.Len(t, outputs, 1)
+	assert.Equal(t, "db", outputs[0].Config.Alias)
+}
+
+func TestAgent_UndefinedRoute(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfig("../internal/config/testdata/routes.toml")
+	require.NoError(t, err)
+	c.Inputs[0].Config.Outputs = []string{"missing"}
+
+	_, err = NewAgent(c)
+	require.Error(t, err)
+}
+
+func TestAgent_OutputGroups(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfig("../internal/config/testdata/output_groups.toml")
+	require.NoError(t, err)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	require.Len(t, a.Config.Outputs, 2)
+
+	var group *models.RunningOutput
+	for _, output := range a.Config.Outputs {
+		if output.Name == "influx" {
+			group = output
+		}
+	}
+	require.NotNil(t, group)
+	require.IsType(t, &models.OutputGroup{}, group.Output)
+	assert.Equal(t, []string{"primary", "secondary"},
+		group.Output.(*models.OutputGroup).Healthy())
+
+	input := findInput(a, "inputs.memcached")
+	m := input.MakeMetric(testutil.MustMetric("memcached",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)))
+	assert.Equal(t, []*models.RunningOutput{group}, a.routeOutputs(m))
+}
+
+func TestAgent_OutputGroupUndefinedOutput(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfig("../internal/config/testdata/output_groups.toml")
+	require.NoError(t, err)
+	c.OutputGroups[0].Outputs = append(c.OutputGroups[0].Outputs, "missing")
+
+	_, err = NewAgent(c)
+	require.Error(t, err)
+}
+
+func TestMatchFingerprints(t *testing.T) {
+	matches := matchFingerprints(
+		[]string{"a", "b", "a"},
+		[]string{"a", "c", "a", "a", "b"})
+	assert.Equal(t, []int{0, -1, 2, -1, 1}, matches)
+}
+
+func findInput(a *Agent, name string) *models.RunningInput {
+	for _, input := range a.Config.Inputs {
+		if input.Name() == name {
+			return input
+		}
+	}
+	return nil
+}
+
+type blockingInput struct {
+	release chan struct{}
+}
+
+func (i *blockingInput) Description() string  { return "" }
+func (i *blockingInput) SampleConfig() string { return "" }
+func (i *blockingInput) Gather(acc telegraf.Accumulator) error {
+	<-i.release
+	acc.AddFields("blocking", map[string]interface{}{"value": 1}, nil)
+	return nil
+}
+
+type contextInput struct{}
+
+func (i *contextInput) Description() string                   { return "" }
+func (i *contextInput) SampleConfig() string                  { return "" }
+func (i *contextInput) Gather(acc telegraf.Accumulator) error { return nil }
+func (i *contextInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestAgent_GatherTimeout(t *testing.T) {
+	a, _ := NewAgent(config.NewConfig())
+
+	input := &blockingInput{release: make(chan struct{})}
+	ri := models.NewRunningInput(input, &models.InputConfig{
+		Name:          "blocking",
+		GatherTimeout: 10 * time.Millisecond,
+	})
+	metrics := make(chan telegraf.Metric, 10)
+	acc := NewAccumulator(ri, metrics)
+
+	running, err := a.gatherOnce(context.Background(), acc, ri, time.Minute)
+	require.Error(t, err)
+	require.NotNil(t, running)
+
+	// Metrics of the abandoned gather are dropped
+	close(input.release)
+	require.NoError(t, <-running)
+	require.Len(t, metrics, 0)
+}
+
+func TestAgent_GatherTimeoutCancelsContext(t *testing.T) {
+	a, _ := NewAgent(config.NewConfig())
+
+	ri := models.NewRunningInput(&contextInput{}, &models.InputConfig{
+		Name:          "context",
+		GatherTimeout: 10 * time.Millisecond,
+	})
+	acc := NewAccumulator(ri, make(chan telegraf.Metric, 10))
+
+	running, err := a.gatherOnce(context.Background(), acc, ri, time.Minute)
+	require.Error(t, err)
+	if running != nil {
+		require.Error(t, <-running)
+	}
+}
+
+type testInput struct {
+	err error
+}
+
+func (i *testInput) Description() string  { return "" }
+func (i *testInput) SampleConfig() string { return "" }
+func (i *testInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("test", map[string]interface{}{"value": 1}, nil, time.Unix(0, 0))
+	acc.AddError(i.err)
+	return nil
+}
+
+func TestAgent_RunTestOnce(t *testing.T) {
+	c := config.NewConfig()
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(&testInput{}, &models.InputConfig{Name: "ok"}),
+		models.NewRunningInput(&testInput{err: errors.New("failed")},
+			&models.InputConfig{Name: "failing"}),
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	serializer, err := json.NewSerializer(time.Second)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = a.RunTest(context.Background(), TestConfig{
+		Once:       true,
+		Serializer: serializer,
+		Output:     &buf,
+	})
+	require.EqualError(t, err, "1 of 2 inputs failed")
+	assert.Equal(t, 2, strings.Count(buf.String(), `{"fields":{"value":1},"name":"test"`))
+}
+
+type timeInput struct{}
+
+func (i *timeInput) Description() string  { return "" }
+func (i *timeInput) SampleConfig() string { return "" }
+func (i *timeInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddFields("test", map[string]interface{}{"value": 1}, nil, time.Unix(0, 1600000))
+	return nil
+}
+
+func TestAgent_RunTestInputPrecision(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.Interval.Duration = 10 * time.Second
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(&timeInput{}, &models.InputConfig{Name: "agent"}),
+		models.NewRunningInput(&timeInput{},
+			&models.InputConfig{Name: "precise", Precision: time.Millisecond}),
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	serializer, err := json.NewSerializer(time.Nanosecond)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = a.RunTest(context.Background(), TestConfig{
+		Once:       true,
+		Serializer: serializer,
+		Output:     &buf,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(buf.String(), `"timestamp":0}`))
+	assert.Equal(t, 1, strings.Count(buf.String(), `"timestamp":2000000}`))
+}
+
+func TestAgent_RunTestGatherTimeout(t *testing.T) {
+	input := &blockingInput{release: make(chan struct{})}
+	defer close(input.release)
+
+	c := config.NewConfig()
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(input, &models.InputConfig{Name: "blocking"}),
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = a.RunTest(context.Background(), TestConfig{
+		Once:          true,
+		GatherTimeout: 10 * time.Millisecond,
+		Output:        &buf,
+	})
+	require.Error(t, err)
+	assert.Empty(t, buf.String())
+}
+
+type failingServiceInput struct {
+	sync.Mutex
+	fail    bool
+	started bool
+}
+
+func (i *failingServiceInput) Description() string                   { return "" }
+func (i *failingServiceInput) SampleConfig() string                  { return "" }
+func (i *failingServiceInput) Gather(acc telegraf.Accumulator) error { return nil }
+func (i *failingServiceInput) Start(acc telegraf.Accumulator) error {
+	i.Lock()
+	defer i.Unlock()
+	if i.fail {
+		return errors.New("failed to start")
+	}
+	i.started = true
+	return nil
+}
+func (i *failingServiceInput) Stop() {
+	i.Lock()
+	defer i.Unlock()
+	i.started = false
+}
+
+func TestAgent_StartupErrorBehaviorInputs(t *testing.T) {
+	c := config.NewConfig()
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(&failingServiceInput{fail: true}, &models.InputConfig{
+			Name:                 "retry",
+			StartupErrorBehavior: models.StartupErrorBehaviorRetry,
+		}),
+		models.NewRunningInput(&failingServiceInput{fail: true}, &models.InputConfig{
+			Name:                 "ignore",
+			StartupErrorBehavior: models.StartupErrorBehaviorIgnore,
+		}),
+		models.NewRunningInput(&failingServiceInput{}, &models.InputConfig{
+			Name: "ok",
+		}),
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, a.startServiceInputs(ctx, make(chan telegraf.Metric, 10)))
+
+	require.Len(t, a.Config.Inputs, 2)
+	require.False(t, findInput(a, "inputs.retry").Started())
+	require.Nil(t, findInput(a, "inputs.ignore"))
+	require.True(t, findInput(a, "inputs.ok").Started())
+
+	// The retry ends with the context.
+	cancel()
+	a.retries.Wait()
+	require.False(t, findInput(a, "inputs.retry").Started())
+
+	a.stopServiceInputs()
+	require.False(t, findInput(a, "inputs.ok").Started())
+}
+
+func TestAgent_StartupErrorBehaviorInputsError(t *testing.T) {
+	ok := &failingServiceInput{}
+	c := config.NewConfig()
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(ok, &models.InputConfig{Name: "ok"}),
+		models.NewRunningInput(&failingServiceInput{fail: true}, &models.InputConfig{
+			Name: "error",
+		}),
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	err = a.startServiceInputs(context.Background(), make(chan telegraf.Metric, 10))
+	require.Error(t, err)
+	require.False(t, ok.started)
+}
+
+type failingOutput struct{}
+
+func (o *failingOutput) Description() string                   { return "" }
+func (o *failingOutput) SampleConfig() string                  { return "" }
+func (o *failingOutput) Connect() error                        { return errors.New("failed to connect") }
+func (o *failingOutput) Close() error                          { return nil }
+func (o *failingOutput) Write(metrics []telegraf.Metric) error { return nil }
+
+func TestAgent_StartupErrorBehaviorOutputs(t *testing.T) {
+	c := config.NewConfig()
+	c.Outputs = []*models.RunningOutput{
+		models.NewRunningOutput("retry", &failingOutput{}, &models.OutputConfig{
+			Name:                 "retry",
+			StartupErrorBehavior: models.StartupErrorBehaviorRetry,
+		}, 0, 0),
+		models.NewRunningOutput("ignore", &failingOutput{}, &models.OutputConfig{
+			Name:                 "ignore",
+			StartupErrorBehavior: models.StartupErrorBehaviorIgnore,
+		}, 0, 0),
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	require.NoError(t, a.connectOutputs(context.Background()))
+	require.Len(t, a.Config.Outputs, 1)
+	require.Equal(t, "retry", a.Config.Outputs[0].Name)
+
+	connected, _ := a.Config.Outputs[0].Status()
+	require.False(t, connected)
+	require.NoError(t, a.closeOutputs())
+}
+
+type writeOutput struct {
+	err     error
+	written int
+}
+
+func (o *writeOutput) Description() string  { return "" }
+func (o *writeOutput) SampleConfig() string { return "" }
+func (o *writeOutput) Connect() error       { return nil }
+func (o *writeOutput) Close() error         { return nil }
+func (o *writeOutput) Write(metrics []telegraf.Metric) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.written += len(metrics)
+	return nil
+}
+
+func TestAgent_FlushShutdown(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.ShutdownTimeout.Duration = time.Second
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	o := &writeOutput{}
+	output := models.NewRunningOutput("test", o, &models.OutputConfig{Name: "test"}, 0, 0)
+	output.AddMetric(testutil.TestMetric(1))
+	output.AddMetric(testutil.TestMetric(2))
+
+	a.flushShutdown(output, time.Second)
+	require.Equal(t, 2, o.written)
+	require.Equal(t, 0, output.BufferLength())
+}
+
+func TestAgent_FlushShutdownTimeout(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.ShutdownTimeout.Duration = 50 * time.Millisecond
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	o := &writeOutput{err: errors.New("failed to write")}
+	output := models.NewRunningOutput("test", o, &models.OutputConfig{Name: "test"}, 0, 0)
+	output.AddMetric(testutil.TestMetric(1))
+
+	start := time.Now()
+	a.flushShutdown(output, time.Second)
+	require.True(t, time.Since(start) < time.Second)
+	require.Equal(t, 0, o.written)
+	require.Equal(t, 1, output.BufferLength())
+}
+
+type slowOutput struct {
+	writeOutput
+	delay time.Duration
+}
+
+func (o *slowOutput) Write(metrics []telegraf.Metric) error {
+	time.Sleep(o.delay)
+	return o.writeOutput.Write(metrics)
+}
+
+func TestAgent_FlushShutdownWaitsForWrite(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.ShutdownTimeout.Duration = 50 * time.Millisecond
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	o := &slowOutput{delay: 200 * time.Millisecond}
+	output := models.NewRunningOutput("test", o, &models.OutputConfig{Name: "test"}, 0, 0)
+	output.AddMetric(testutil.TestMetric(1))
+
+	// The write outlasting the timeout completes before returning.
+	a.flushShutdown(output, time.Second)
+	require.Equal(t, 1, o.written)
+	require.Equal(t, 0, output.BufferLength())
+}
+
+func TestAgent_Metadata(t *testing.T) {
+	c := config.NewConfig()
+	c.Tags["os"] = "custom"
+	c.Tags["dc"] = "us-east-1"
+	c.Inputs = []*models.RunningInput{
+		models.NewRunningInput(&testInput{}, &models.InputConfig{Name: "test"}),
+	}
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err = a.startMetadata(ctx, &config.MetadataConfig{Providers: []string{"os"}})
+	require.NoError(t, err)
+
+	m := a.Config.Inputs[0].MakeMetric(testutil.TestMetric(1))
+	require.Equal(t, "custom", m.Tags()["os"])
+	require.Equal(t, "us-east-1", m.Tags()["dc"])
+	require.NotEmpty(t, m.Tags()["arch"])
+}
+
+func TestAgent_MetadataUnknownProvider(t *testing.T) {
+	c := config.NewConfig()
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	err = a.startMetadata(context.Background(),
+		&config.MetadataConfig{Providers: []string{"unknown"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/config"
+)
+
+const (
+	defaultHealthAddress         = ":8080"
+	defaultHealthReadTimeout     = 10 * time.Second
+	defaultHealthWriteTimeout    = 10 * time.Second
+	defaultHealthBufferThreshold = 0.9
+)
+
+// healthCheck reports the plugins that failed a check.
+type healthCheck func(a *Agent, conf *config.HealthConfig) []string
+
+var healthChecks = map[string]healthCheck{
+	"outputs": checkOutputs,
+	"buffers": checkBuffers,
+	"inputs":  checkInputs,
+}
+
+// HealthStatus is the response of the health check endpoint.
+type HealthStatus struct {
+	Healthy bool                   `json:"healthy"`
+	Checks  map[string]CheckStatus `json:"checks"`
+}
+
+// CheckStatus is the result of a single check, Failing lists the plugins
+// that failed the check.
+type CheckStatus struct {
+	Healthy bool     `json:"healthy"`
+	Failing []string `json:"failing,omitempty"`
+}
+
+// healthServer serves the health of the agent over HTTP.
+type healthServer struct {
+	agent  *Agent
+	config *config.HealthConfig
+	server *http.Server
+}
+
+func newHealthServer(a *Agent, conf *config.HealthConfig) (*healthServer, error) {
+	c := *conf
+	if c.ServiceAddress == "" {
+		c.ServiceAddress = defaultHealthAddress
+	}
+	if c.ReadTimeout.Duration == 0 {
+		c.ReadTimeout.Duration = defaultHealthReadTimeout
+	}
+	if c.WriteTimeout.Duration == 0 {
+		c.WriteTimeout.Duration = defaultHealthWriteTimeout
+	}
+	if len(c.Checks) == 0 {
+		c.Checks = []string{"outputs", "buffers", "inputs"}
+	}
+	for _, name := range c.Checks {
+		if _, ok := healthChecks[name]; !ok {
+			return nil, fmt.Errorf("unknown health check %q", name)
+		}
+	}
+	if c.BufferThreshold == 0 {
+		c.BufferThreshold = defaultHealthBufferThreshold
+	}
+	if c.StatusCodeHealthy == 0 {
+		c.StatusCodeHealthy = http.StatusOK
+	}
+	if c.StatusCodeUnhealthy == 0 {
+		c.StatusCodeUnhealthy = http.StatusServiceUnavailable
+	}
+
+	h := &healthServer{
+		agent:  a,
+		config: &c,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.serveHealth)
+	h.server = &http.Server{
+		Addr:         c.ServiceAddress,
+		Handler:      mux,
+		ReadTimeout:  c.ReadTimeout.Duration,
+		WriteTimeout: c.WriteTimeout.Duration,
+	}
+	return h, nil
+}
+
+// Start listens on the service address and serves requests in the
+// background.
+func (h *healthServer) Start() error {
+	listener, err := net.Listen("tcp", h.config.ServiceAddress)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := h.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving health check: %v", err)
+		}
+	}()
+
+	log.Printf("I! [agent] Serving health check on %s", listener.Addr())
+	return nil
+}
+
+// Stop stops the server.
+func (h *healthServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	h.server.Shutdown(ctx)
+}
+
+// Status runs the configured checks.
+func (h *healthServer) Status() *HealthStatus {
+	status := &HealthStatus{
+		Healthy: true,
+		Checks:  make(map[string]CheckStatus, len(h.config.Checks)),
+	}
+	for _, name := range h.config.Checks {
+		failing := healthChecks[name](h.agent, h.config)
+		status.Checks[name] = CheckStatus{
+			Healthy: len(failing) == 0,
+			Failing: failing,
+		}
+		if len(failing) > 0 {
+			status.Healthy = false
+		}
+	}
+	return status
+}
+
+func (h *healthServer) serveHealth(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := h.Status()
+	code := h.config.StatusCodeHealthy
+	if !status.Healthy {
+		code = h.config.StatusCodeUnhealthy
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(code)
+	json.NewEncoder(res).Encode(status)
+}
+
+func checkOutputs(a *Agent, conf *config.HealthConfig) []string {
+	var failing []string
+	for _, output := range a.Config.Outputs {
+		connected, err := output.Status()
+		if !connected || err != nil {
+			failing = append(failing, "outputs."+output.Name)
+		}
+	}
+	return failing
+}
+
+func checkBuffers(a *Agent, conf *config.HealthConfig) []string {
+	var failing []string
+	for _, output := range a.Config.Outputs {
+		if output.BufferFullness() >= conf.BufferThreshold {
+			failing = append(failing, "outputs."+output.Name)
+		}
+	}
+	return failing
+}
+
+func checkInputs(a *Agent, conf *config.HealthConfig) []string {
+	var failing []string
+	for _, input := range a.Config.Inputs {
+		if input.Failing() {
+			failing = append(failing, input.Name())
+		}
+	}
+	return failing
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type healthOutput struct {
+	connectErr error
+	writeErr   error
+}
+
+func (o *healthOutput) Connect() error                  { return o.connectErr }
+func (o *healthOutput) Close() error                    { return nil }
+func (o *healthOutput) Description() string             { return "" }
+func (o *healthOutput) SampleConfig() string            { return "" }
+func (o *healthOutput) Write(_ []telegraf.Metric) error { return o.writeErr }
+
+type healthInput struct {
+	err error
+}
+
+func (i *healthInput) Description() string  { return "" }
+func (i *healthInput) SampleConfig() string { return "" }
+func (i *healthInput) Gather(acc telegraf.Accumulator) error {
+	acc.AddError(i.err)
+	return nil
+}
+
+func newHealthAgent() (*Agent, *healthOutput, *healthInput) {
+	output := &healthOutput{}
+	input := &healthInput{}
+
+	c := config.NewConfig()
+	c.Outputs = append(c.Outputs, models.NewRunningOutput("test", output,
+		&models.OutputConfig{Name: "test"}, 1, 10))
+	c.Inputs = append(c.Inputs, models.NewRunningInput(input,
+		&models.InputConfig{Name: "test"}))
+
+	a, _ := NewAgent(c)
+	return a, output, input
+}
+
+func getHealth(t *testing.T, h *healthServer) (int, *HealthStatus) {
+	rec := httptest.NewRecorder()
+	h.serveHealth(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	status := &HealthStatus{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), status))
+	return rec.Code, status
+}
+
+func TestHealth_Outputs(t *testing.T) {
+	a, output, _ := newHealthAgent()
+	h, err := newHealthServer(a, &config.HealthConfig{Checks: []string{"outputs"}})
+	require.NoError(t, err)
+
+	// Not connected yet
+	code, status := getHealth(t, h)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, []string{"outputs.test"}, status.Checks["outputs"].Failing)
+
+	ro := a.Config.Outputs[0]
+	require.NoError(t, ro.Connect())
+	code, status = getHealth(t, h)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, status.Healthy)
+
+	output.writeErr = errors.New("write failed")
+	ro.AddMetric(testutil.TestMetric(1))
+	require.Error(t, ro.Write())
+	code, _ = getHealth(t, h)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+
+	output.writeErr = nil
+	require.NoError(t, ro.Write())
+	code, _ = getHealth(t, h)
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestHealth_Buffers(t *testing.T) {
+	a, output, _ := newHealthAgent()
+	h, err := newHealthServer(a, &config.HealthConfig{
+		Checks:          []string{"buffers"},
+		BufferThreshold: 0.5,
+	})
+	require.NoError(t, err)
+
+	ro := a.Config.Outputs[0]
+	output.writeErr = errors.New("write failed")
+	for i := 0; i < 4; i++ {
+		ro.AddMetric(testutil.TestMetric(i))
+	}
+	code, _ := getHealth(t, h)
+	require.Equal(t, http.StatusOK, code)
+
+	ro.AddMetric(testutil.TestMetric(5))
+	code, status := getHealth(t, h)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, []string{"outputs.test"}, status.Checks["buffers"].Failing)
+}
+
+func TestHealth_Inputs(t *testing.T) {
+	a, _, input := newHealthAgent()
+	h, err := newHealthServer(a, &config.HealthConfig{
+		Checks:              []string{"inputs"},
+		StatusCodeUnhealthy: http.StatusInternalServerError,
+	})
+	require.NoError(t, err)
+
+	ri := a.Config.Inputs[0]
+	acc := NewAccumulator(ri, make(chan telegraf.Metric, 10))
+
+	input.err = errors.New("gather failed")
+	require.NoError(t, ri.Gather(acc))
+	code, status := getHealth(t, h)
+	require.Equal(t, http.StatusInternalServerError, code)
+	require.Equal(t, []string{"inputs.test"}, status.Checks["inputs"].Failing)
+
+	// Errors of the previous gather are still reported
+	input.err = nil
+	require.NoError(t, ri.Gather(acc))
+	code, _ = getHealth(t, h)
+	require.Equal(t, http.StatusInternalServerError, code)
+
+	require.NoError(t, ri.Gather(acc))
+	code, _ = getHealth(t, h)
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestHealth_UnknownCheck(t *testing.T) {
+	a, _, _ := newHealthAgent()
+	_, err := newHealthServer(a, &config.HealthConfig{Checks: []string{"disks"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/metadata"
+)
+
+const defaultMetadataTimeout = 2 * time.Second
+
+// startMetadata resolves the metadata of the host and adds it to the global
+// tags of the inputs, then refreshes it in the background until the context
+// is done.
+func (a *Agent) startMetadata(ctx context.Context, conf *config.MetadataConfig) error {
+	timeout := conf.Timeout.Duration
+	if timeout == 0 {
+		timeout = defaultMetadataTimeout
+	}
+	resolver, err := metadata.NewResolver(conf.Providers, timeout)
+	if err != nil {
+		return err
+	}
+
+	a.resolveMetadata(ctx, resolver)
+
+	if conf.RefreshInterval.Duration == 0 {
+		return nil
+	}
+	go func() {
+		ticker := time.NewTicker(conf.RefreshInterval.Duration)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.resolveMetadata(ctx, resolver)
+			}
+		}
+	}()
+	return nil
+}
+
+// resolveMetadata sets the global tags of the inputs to the metadata of the
+// host, overridden by the global tags of the config.
+func (a *Agent) resolveMetadata(ctx context.Context, resolver *metadata.Resolver) {
+	tags, errs := resolver.Resolve(ctx)
+	for _, err := range errs {
+		log.Printf("D! [agent] Could not resolve host metadata: %v", err)
+	}
+
+	for k, v := range a.Config.Tags {
+		tags[k] = v
+	}
+	for _, input := range a.Config.Inputs {
+		input.SetDefaultTags(tags)
+	}
+	log.Printf("D! [agent] Resolved host metadata: %v", tags)
+}
@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	runtimepprof "runtime/pprof"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/config"
+)
+
+const defaultPprofAddress = "localhost:6060"
+
+// pprofServer serves the runtime profiling data of the agent over HTTP.
+type pprofServer struct {
+	config *config.PprofConfig
+	server *http.Server
+}
+
+func newPprofServer(conf *config.PprofConfig) *pprofServer {
+	c := *conf
+	if c.ServiceAddress == "" {
+		c.ServiceAddress = defaultPprofAddress
+	}
+
+	p := &pprofServer{
+		config: &c,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	p.server = &http.Server{
+		Addr:    c.ServiceAddress,
+		Handler: p.authenticate(mux),
+	}
+	return p
+}
+
+// Start listens on the service address and serves requests in the
+// background.
+func (p *pprofServer) Start() error {
+	listener, err := net.Listen("tcp", p.config.ServiceAddress)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := p.server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving pprof: %v", err)
+		}
+	}()
+
+	log.Printf("I! [agent] Serving pprof on http://%s/debug/pprof", listener.Addr())
+	return nil
+}
+
+// Stop stops the server.
+func (p *pprofServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	p.server.Shutdown(ctx)
+}
+
+// authenticate requires the basic auth credentials of the config, if set.
+func (p *pprofServer) authenticate(next http.Handler) http.Handler {
+	if p.config.Username == "" && p.config.Password == "" {
+		return next
+	}
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		username, password, ok := req.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(p.config.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(p.config.Password)) != 1 {
+			res.Header().Set("WWW-Authenticate", `Basic realm="telegraf"`)
+			http.Error(res, "Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+// WriteDiagnostics writes the stacks of all goroutines and a heap profile to
+// files in the directory and returns their paths.
+func WriteDiagnostics(dir string) ([]string, error) {
+	now := time.Now().Format("20060102T150405")
+	dumps := []struct {
+		profile string
+		file    string
+		debug   int
+	}{
+		{"goroutine", "telegraf-goroutines-" + now + ".txt", 2},
+		{"heap", "telegraf-heap-" + now + ".pprof", 0},
+	}
+
+	var paths []string
+	for _, dump := range dumps {
+		path := filepath.Join(dir, dump.file)
+		f, err := os.Create(path)
+		if err != nil {
+			return paths, err
+		}
+		err = runtimepprof.Lookup(dump.profile).WriteTo(f, dump.debug)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return paths, fmt.Errorf("could not write %s profile: %v", dump.profile, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
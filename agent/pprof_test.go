@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPprofServer(t *testing.T) {
+	p := newPprofServer(&config.PprofConfig{})
+	require.Equal(t, defaultPprofAddress, p.config.ServiceAddress)
+
+	rec := httptest.NewRecorder()
+	p.server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/pprof/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPprofServerAuth(t *testing.T) {
+	p := newPprofServer(&config.PprofConfig{
+		Username: "telegraf",
+		Password: "secret",
+	})
+
+	rec := httptest.NewRecorder()
+	p.server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/pprof/", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest("GET", "/debug/pprof/", nil)
+	req.SetBasicAuth("telegraf", "wrong")
+	rec = httptest.NewRecorder()
+	p.server.Handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest("GET", "/debug/pprof/", nil)
+	req.SetBasicAuth("telegraf", "secret")
+	rec = httptest.NewRecorder()
+	p.server.Handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestWriteDiagnostics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	paths, err := WriteDiagnostics(dir)
+	require.NoError(t, err)
+	require.Len(t, paths, 2)
+
+	goroutines, err := ioutil.ReadFile(paths[0])
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(goroutines), "TestWriteDiagnostics"))
+
+	info, err := os.Stat(paths[1])
+	require.NoError(t, err)
+	require.NotZero(t, info.Size())
+}
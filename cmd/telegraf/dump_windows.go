@@ -0,0 +1,12 @@
+// +build windows
+
+package main
+
+import (
+	"os"
+)
+
+// notifyDump does nothing, there is no signal to request a diagnostics dump
+// on Windows.
+func notifyDump(c chan<- os.Signal) {
+}
@@ -10,9 +10,12 @@ import (
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
 	"os"
 	"os/signal"
+	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal"
@@ -24,6 +27,8 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/all"
+	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/kardianos/service"
 )
 
@@ -34,9 +39,29 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
+var fOnce = flag.Bool("once", false,
+	"gather metrics once from all inputs, print them out, and exit non-zero if any input failed")
+var fTestFormat = flag.String("test-format", "",
+	"data format of the metrics printed by --test and --once, ie, 'json'")
+var fGatherTimeout = flag.Duration("gather-timeout", 0,
+	"abandon the gather of an input after this duration in --test and --once mode")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fStrictConfig = flag.Bool("strict-config", false,
+	"fail on unknown or invalid plugin options instead of ignoring them")
+var fWatchConfig = flag.Duration("watch-config", 0,
+	"check the config files for changes at this interval and reload on change")
+var fConfigTLSCA = flag.String("config-tls-ca", "",
+	"CA certificate used to verify remote config servers")
+var fConfigTLSCert = flag.String("config-tls-cert", "",
+	"client certificate used to load remote config")
+var fConfigTLSKey = flag.String("config-tls-key", "",
+	"private key of the client certificate used to load remote config")
+var fConfigInsecureSkipVerify = flag.Bool("config-insecure-skip-verify", false,
+	"skip verification of the certificates of remote config servers")
+var fConfigCacheDirectory = flag.String("config-cache-directory", "",
+	"directory to cache remote config in, used when a source is unavailable")
 var fVersion = flag.Bool("version", false, "display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -53,11 +78,17 @@ var fAggregatorFilters = flag.String("aggregator-filter", "",
 	"filter the aggregators to enable, separator is :")
 var fProcessorFilters = flag.String("processor-filter", "",
 	"filter the processors to enable, separator is :")
+var fDeprecationList = flag.Bool("deprecation-list", false,
+	"print the deprecated plugins and options used in the config and exit")
+var fPrintConfig = flag.Bool("print-config", false,
+	"print the effective configuration, with secrets redacted, and exit")
 var fUsage = flag.String("usage", "",
 	"print usage for a plugin, ie, 'telegraf --usage mysql'")
 var fService = flag.String("service", "",
 	"operate on the service (windows only)")
 var fServiceName = flag.String("service-name", "telegraf", "service name (windows only)")
+var fServiceDisplayName = flag.String("service-display-name", "Telegraf Data Collector Service",
+	"service display name (windows only)")
 var fRunAsConsole = flag.Bool("console", false, "run as console application (windows only)")
 
 var (
@@ -75,92 +106,161 @@ func reloadLoop(
 	aggregatorFilters []string,
 	processorFilters []string,
 ) {
-	reload := make(chan bool, 1)
-	reload <- true
-	for <-reload {
-		reload <- false
+	// Setup default logging. This may need to change after reading the config
+	// file, but we can configure it to use our logger implementation now.
+	logger.SetupLogging(logger.LogConfig{})
+	log.Printf("I! Starting Telegraf %s", version)
+
+	ag, err := loadAgent(inputFilters, outputFilters)
+	if err != nil {
+		log.Fatalf("E! [telegraf] Error running agent: %v", err)
+	}
+
+	dumps := make(chan os.Signal, 1)
+	notifyDump(dumps)
+	defer signal.Stop(dumps)
+	go func() {
+		for range dumps {
+			paths, err := agent.WriteDiagnostics(os.TempDir())
+			if err != nil {
+				log.Printf("E! [telegraf] Error writing diagnostics: %v", err)
+				continue
+			}
+			log.Printf("I! [telegraf] Wrote diagnostics to %s", strings.Join(paths, ", "))
+		}
+	}()
 
+	for ag != nil {
 		ctx, cancel := context.WithCancel(context.Background())
 
-		signals := make(chan os.Signal)
+		signals := make(chan os.Signal, 1)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
-		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
+		changed := make(chan string, 1)
+		next := make(chan *agent.Agent, 1)
+		go func(ag *agent.Agent) {
+			for {
+				select {
+				case sig := <-signals:
+					if sig != syscall.SIGHUP {
+						cancel()
+						return
+					}
 					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+				case reason := <-changed:
+					log.Printf("I! Reloading Telegraf config, %s", reason)
+				case <-stop:
+					cancel()
+					return
 				}
+
+				// Only stop the running agent once the new config is valid.
+				n, err := loadAgent(inputFilters, outputFilters)
+				if err != nil {
+					log.Printf("E! [telegraf] Error reloading config, keeping the current config: %v", err)
+					continue
+				}
+				n.Reuse(ag)
+				next <- n
 				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
-		}()
+		}(ag)
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		err := runAgent(ctx, ag, changed)
 		if err != nil {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
+		signal.Stop(signals)
+
+		select {
+		case ag = <-next:
+		default:
+			ag = nil
+		}
 	}
 }
 
-func runAgent(ctx context.Context,
-	inputFilters []string,
-	outputFilters []string,
-) error {
-	// Setup default logging. This may need to change after reading the config
-	// file, but we can configure it to use our logger implementation now.
-	logger.SetupLogging(false, false, "")
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+// loadConfig loads the config files given on the command line.
+func loadConfig(inputFilters []string, outputFilters []string) (*config.Config, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
-	err := c.LoadConfig(*fConfig)
-	if err != nil {
-		return err
-	}
-
+	c.Remote.TLSCA = *fConfigTLSCA
+	c.Remote.TLSCert = *fConfigTLSCert
+	c.Remote.TLSKey = *fConfigTLSKey
+	c.Remote.InsecureSkipVerify = *fConfigInsecureSkipVerify
+	c.Remote.CacheDirectory = *fConfigCacheDirectory
+	c.Agent.StrictConfig = *fStrictConfig
+
+	// The files are loaded together so that the secret stores of any file
+	// can be referenced from all of them.
+	paths := []string{*fConfig}
 	if *fConfigDirectory != "" {
-		err = c.LoadDirectory(*fConfigDirectory)
+		files, err := config.DirectoryFiles(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		paths = append(paths, files...)
+	}
+
+	if err := c.LoadConfigs(paths); err != nil {
+		return nil, err
 	}
-	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
+	return c, nil
+}
+
+// loadAgent loads the config files and creates a new agent for them.
+func loadAgent(inputFilters []string, outputFilters []string) (*agent.Agent, error) {
+	// If no other options are specified, load the config file and run.
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	if !*fTest && !*fOnce && len(c.Outputs) == 0 {
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
 	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
 			c.Agent.Interval.Duration)
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
 			c.Agent.Interval.Duration)
 	}
 
-	ag, err := agent.NewAgent(c)
-	if err != nil {
-		return err
-	}
+	return agent.NewAgent(c)
+}
+
+func runAgent(ctx context.Context,
+	ag *agent.Agent,
+	changed chan<- string,
+) error {
+	c := ag.Config
 
 	// Setup logging as configured.
-	logger.SetupLogging(
-		ag.Config.Agent.Debug || *fDebug,
-		ag.Config.Agent.Quiet || *fQuiet,
-		ag.Config.Agent.Logfile,
-	)
+	logConfig := logger.LogConfig{
+		Debug:               ag.Config.Agent.Debug || *fDebug,
+		Quiet:               ag.Config.Agent.Quiet || *fQuiet,
+		Logfile:             ag.Config.Agent.Logfile,
+		RotationInterval:    ag.Config.Agent.LogfileRotationInterval,
+		RotationMaxSize:     ag.Config.Agent.LogfileRotationMaxSize,
+		RotationMaxArchives: ag.Config.Agent.LogfileRotationMaxArchives,
+	}
+
+	logger.SetupLogging(logConfig)
+	if err := logger.SetFormat(ag.Config.Agent.LogFormat); err != nil {
+		return err
+	}
 
-	if *fTest {
-		return ag.Test(ctx)
+	if *fTest || *fOnce {
+		return runTest(ctx, ag)
 	}
 
 	log.Printf("I! Loaded inputs: %s", strings.Join(c.InputNames(), " "))
@@ -169,6 +269,14 @@ func runAgent(ctx context.Context,
 	log.Printf("I! Loaded outputs: %s", strings.Join(c.OutputNames(), " "))
 	log.Printf("I! Tags enabled: %s", c.ListTags())
 
+	if c.Agent.SecretRefreshInterval.Duration > 0 && len(c.SecretStores) > 0 {
+		go watchSecrets(ctx, c, c.Agent.SecretRefreshInterval.Duration, changed)
+	}
+
+	if *fWatchConfig > 0 {
+		go watchConfig(ctx, c, *fWatchConfig, changed)
+	}
+
 	if *fPidfile != "" {
 		f, err := os.OpenFile(*fPidfile, os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
@@ -187,14 +295,139 @@ func runAgent(ctx context.Context,
 		}
 	}
 
+	ag.Reload = changed
 	return ag.Run(ctx)
 }
 
+// runTest gathers the inputs once and prints the metrics in the format
+// selected on the command line.
+func runTest(ctx context.Context, ag *agent.Agent) error {
+	testConfig := agent.TestConfig{
+		Once:          *fOnce,
+		GatherTimeout: *fGatherTimeout,
+	}
+
+	if *fTestFormat != "" {
+		serializer, err := serializers.NewSerializer(&serializers.Config{
+			DataFormat:       *fTestFormat,
+			InfluxSortFields: true,
+			TimestampUnits:   time.Second,
+		})
+		if err != nil {
+			return err
+		}
+		testConfig.Serializer = serializer
+	}
+
+	return ag.RunTest(ctx, testConfig)
+}
+
+// watchSecrets periodically checks if the secrets referenced by the config
+// have changed and signals the reload loop when they have.  It keeps watching
+// after signalling, so a change is still picked up if the reload is rejected.
+func watchSecrets(ctx context.Context, c *config.Config, interval time.Duration, changed chan<- string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.SecretsChanged() {
+				select {
+				case changed <- "secrets have changed":
+				default:
+				}
+			}
+		}
+	}
+}
+
+// watchConfig periodically checks the config files for modifications and
+// signals the reload loop when a file was modified, added or removed.  It
+// keeps watching after signalling, so fixing a file after a rejected reload
+// is picked up as well.
+func watchConfig(ctx context.Context, c *config.Config, interval time.Duration, changed chan<- string) {
+	last := configModTimes(c)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reason := ""
+			if current := configModTimes(c); !reflect.DeepEqual(last, current) {
+				last = current
+				reason = "config files have changed"
+			} else if ok, err := c.RemoteChanged(); err != nil {
+				log.Printf("W! [telegraf] %v", err)
+			} else if ok {
+				reason = "remote config has changed"
+			}
+
+			if reason != "" {
+				select {
+				case changed <- reason:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// configModTimes returns the modification times of the loaded config files
+// and of all config files currently in the config directory.
+func configModTimes(c *config.Config) map[string]time.Time {
+	modTimes := make(map[string]time.Time)
+	for _, path := range c.Files {
+		if info, err := os.Stat(path); err == nil {
+			modTimes[path] = info.ModTime()
+		}
+	}
+
+	if *fConfigDirectory != "" {
+		filepath.Walk(*fConfigDirectory, func(path string, info os.FileInfo, _ error) error {
+			if info != nil && !info.IsDir() && strings.HasSuffix(info.Name(), ".conf") {
+				modTimes[path] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return modTimes
+}
+
 func usageExit(rc int) {
 	fmt.Println(internal.Usage)
 	os.Exit(rc)
 }
 
+// printPlugins prints the available plugins as JSON, the arguments select the
+// kinds of plugins.
+func printPlugins(args []string) {
+	fs := flag.NewFlagSet("plugins", flag.ExitOnError)
+	fInputs := fs.Bool("inputs", false, "list the input plugins")
+	fOutputs := fs.Bool("outputs", false, "list the output plugins")
+	fParsers := fs.Bool("parsers", false, "list the parsers")
+	fs.Usage = func() { usageExit(1) }
+	fs.Parse(args)
+
+	var kinds []string
+	if *fInputs {
+		kinds = append(kinds, config.PluginKindInput)
+	}
+	if *fOutputs {
+		kinds = append(kinds, config.PluginKindOutput)
+	}
+	if *fParsers {
+		kinds = append(kinds, config.PluginKindParser)
+	}
+	if err := config.PrintPlugins(os.Stdout, kinds...); err != nil {
+		log.Fatalf("E! %s", err)
+	}
+}
+
 type program struct {
 	inputFilters      []string
 	outputFilters     []string
@@ -221,6 +454,39 @@ func (p *program) Stop(s service.Service) error {
 	return nil
 }
 
+// serviceArguments returns the arguments the service is installed with, the
+// service name is included so several services can run side by side, and
+// paths are made absolute as services do not start in the working directory.
+func serviceArguments() ([]string, error) {
+	configPath := "C:\\Program Files\\Telegraf\\telegraf.conf"
+	if *fConfig != "" {
+		configPath = *fConfig
+	}
+	if !strings.Contains(configPath, "://") {
+		path, err := filepath.Abs(configPath)
+		if err != nil {
+			return nil, err
+		}
+		configPath = path
+	}
+	args := []string{"--config", configPath}
+
+	if *fConfigDirectory != "" {
+		path, err := filepath.Abs(*fConfigDirectory)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--config-directory", path)
+	}
+	if *fWatchConfig != 0 {
+		args = append(args, "--watch-config", fWatchConfig.String())
+	}
+	if *fServiceName != "telegraf" {
+		args = append(args, "--service-name", *fServiceName)
+	}
+	return args, nil
+}
+
 func formatFullVersion() string {
 	var parts = []string{"Telegraf"}
 
@@ -295,6 +561,9 @@ func main() {
 				processorFilters,
 			)
 			return
+		case "plugins":
+			printPlugins(args[1:])
+			return
 		}
 	}
 
@@ -330,6 +599,29 @@ func main() {
 			log.Fatalf("E! %s and %s", err, err2)
 		}
 		return
+	case *fDeprecationList:
+		c, err := loadConfig(inputFilters, outputFilters)
+		if err != nil {
+			log.Fatalf("E! %s", err)
+		}
+		if len(c.Deprecations) == 0 {
+			fmt.Println("No deprecated plugins or options found in the config")
+			return
+		}
+		fmt.Println("Deprecated plugins and options found in the config:")
+		for _, d := range c.Deprecations {
+			fmt.Printf("  %s\n", d)
+		}
+		return
+	case *fPrintConfig:
+		c, err := loadConfig(inputFilters, outputFilters)
+		if err != nil {
+			log.Fatalf("E! %s", err)
+		}
+		if err := c.PrintConfig(os.Stdout); err != nil {
+			log.Fatalf("E! %s", err)
+		}
+		return
 	}
 
 	shortVersion := version
@@ -345,7 +637,7 @@ func main() {
 	if runtime.GOOS == "windows" && !(*fRunAsConsole) {
 		svcConfig := &service.Config{
 			Name:        *fServiceName,
-			DisplayName: "Telegraf Data Collector Service",
+			DisplayName: *fServiceDisplayName,
 			Description: "Collects data using a series of plugins and publishes it to" +
 				"another series of plugins.",
 			Arguments: []string{"--config", "C:\\Program Files\\Telegraf\\telegraf.conf"},
@@ -364,11 +656,11 @@ func main() {
 		// Handle the --service flag here to prevent any issues with tooling that
 		// may not have an interactive session, e.g. installing from Ansible.
 		if *fService != "" {
-			if *fConfig != "" {
-				(*svcConfig).Arguments = []string{"--config", *fConfig}
-			}
-			if *fConfigDirectory != "" {
-				(*svcConfig).Arguments = append((*svcConfig).Arguments, "--config-directory", *fConfigDirectory)
+			if *fService == "install" {
+				svcConfig.Arguments, err = serviceArguments()
+				if err != nil {
+					log.Fatal("E! " + err.Error())
+				}
 			}
 			err := service.Control(s, *fService)
 			if err != nil {
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchConfigAfterRejectedReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch_config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte("[agent]\n"), 0644))
+
+	c := config.NewConfig()
+	require.NoError(t, c.LoadConfig(path))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan string, 1)
+	go watchConfig(ctx, c, 10*time.Millisecond, changed)
+
+	// An invalid edit is signalled, the reload loop rejects it and keeps
+	// the running config.
+	require.NoError(t, ioutil.WriteFile(path, []byte("[agent\n"), 0644))
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid edit was not signalled")
+	}
+	require.Error(t, config.NewConfig().LoadConfig(path))
+
+	// Fixing the file is signalled again.
+	require.NoError(t, ioutil.WriteFile(path, []byte("[agent]\n"), 0644))
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("valid edit after a rejected reload was not signalled")
+	}
+	require.NoError(t, config.NewConfig().LoadConfig(path))
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "context"
+
 type Input interface {
 	// SampleConfig returns the default configuration of the Input
 	SampleConfig() string
@@ -22,3 +24,12 @@ type ServiceInput interface {
 	// Stop stops the services and closes any necessary channels and connections
 	Stop()
 }
+
+// ContextInput is an Input that can be interrupted.  The agent calls
+// GatherContext instead of Gather, the context is cancelled when the gather
+// timeout of the input expires or the agent stops.
+type ContextInput interface {
+	Input
+
+	GatherContext(ctx context.Context, acc Accumulator) error
+}
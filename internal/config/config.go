@@ -2,12 +2,14 @@ package config
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -26,6 +28,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/secretstores"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/toml"
 	"github.com/influxdata/toml/ast"
@@ -39,8 +42,12 @@ var (
 	// Default output plugins
 	outputDefaults = []string{"influxdb"}
 
-	// envVarRe is a regex to find environment variables in the config file
-	envVarRe = regexp.MustCompile(`\$\w+`)
+	// envVarRe is a regex to find environment variables in the config file,
+	// either $VAR, ${VAR}, ${VAR:-default} or ${VAR:?error}
+	envVarRe = regexp.MustCompile(`\$\{(\w+)(?:(:[-?])([^}]*))?\}|\$(\w+)`)
+
+	// secretRefRe is a regex to find secret store references in config strings
+	secretRefRe = regexp.MustCompile(`@\{(\w+):([^{}]+)\}`)
 
 	envVarEscaper = strings.NewReplacer(
 		`"`, `\"`,
@@ -60,8 +67,28 @@ type Config struct {
 	Inputs      []*models.RunningInput
 	Outputs     []*models.RunningOutput
 	Aggregators []*models.RunningAggregator
+	// OutputGroups combine outputs for failover or load balancing, the
+	// agent replaces the outputs of a group with a single output.
+	OutputGroups []*models.OutputGroupConfig
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// SecretStores are the configured secret stores indexed by their id
+	SecretStores map[string]telegraf.SecretStore
+	// secrets holds the resolved value of every secret reference
+	secrets map[string]string
+
+	// Files are the paths or URLs of all loaded config files
+	Files []string
+
+	// Remote configures how config files are loaded from URLs.
+	Remote RemoteConfig
+
+	// sources are the versions of the loaded remote config files
+	sources map[string]*remoteSource
+
+	// Deprecations are the deprecated plugins and options in the config.
+	Deprecations []Deprecation
 }
 
 func NewConfig() *Config {
@@ -71,9 +98,14 @@ func NewConfig() *Config {
 			Interval:      internal.Duration{Duration: 10 * time.Second},
 			RoundInterval: true,
 			FlushInterval: internal.Duration{Duration: 10 * time.Second},
+
+			LogfileRotationMaxArchives: 5,
 		},
 
 		Tags:          make(map[string]string),
+		SecretStores:  make(map[string]telegraf.SecretStore),
+		secrets:       make(map[string]string),
+		sources:       make(map[string]*remoteSource),
 		Inputs:        make([]*models.RunningInput, 0),
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
@@ -114,10 +146,20 @@ type AgentConfig struct {
 	// ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
 	FlushJitter internal.Duration
 
+	// ShutdownTimeout is how long the outputs keep trying to write their
+	// buffered metrics when the agent stops.  When 0, each output makes a
+	// single attempt without a deadline.
+	ShutdownTimeout internal.Duration
+
 	// MetricBatchSize is the maximum number of metrics that is wrote to an
 	// output plugin in one call.
 	MetricBatchSize int
 
+	// MetricBatchBytes is the maximum size of the metrics written to an
+	// output plugin in one call, measured in the data format of the output.
+	// There is no limit if it is 0.
+	MetricBatchBytes internal.Size
+
 	// MetricBufferLimit is the max number of metrics that each output plugin
 	// will cache. The buffer is cleared when a successful write occurs. When
 	// full, the oldest metrics will be overwritten. This number should be a
@@ -125,6 +167,13 @@ type AgentConfig struct {
 	// not be less than 2 times MetricBatchSize.
 	MetricBufferLimit int
 
+	// MaxSeries is the number of distinct series, combinations of
+	// measurement and tags, each input may produce.  The metrics of new
+	// series past the limit are handled according to SeriesOverflow, one of
+	// the models.SeriesOverflow constants.  There is no limit if it is 0.
+	MaxSeries      int
+	SeriesOverflow string
+
 	// FlushBufferWhenFull tells Telegraf to flush the metric buffer whenever
 	// it fills up, regardless of FlushInterval. Setting this option to true
 	// does _not_ deactivate FlushInterval.
@@ -141,10 +190,116 @@ type AgentConfig struct {
 	// Logfile specifies the file to send logs to
 	Logfile string
 
+	// LogFormat is the format of log messages, either text or json.
+	LogFormat string
+
+	// The file will be rotated after the time interval specified.  When set
+	// to 0 no time based rotation is performed.
+	LogfileRotationInterval internal.Duration `toml:"logfile_rotation_interval"`
+
+	// The file will be rotated when it becomes larger than the specified
+	// size.  When set to 0 no size based rotation is performed.
+	LogfileRotationMaxSize internal.Size `toml:"logfile_rotation_max_size"`
+
+	// Maximum number of rotated archives to keep, any older logs are deleted.
+	// If set to -1, no archives are removed.
+	LogfileRotationMaxArchives int `toml:"logfile_rotation_max_archives"`
+
 	// Quiet is the option for running in quiet mode
 	Quiet        bool
 	Hostname     string
 	OmitHostname bool
+
+	// SecretRefreshInterval is the interval at which secret references are
+	// resolved again, the config is reloaded when a secret has changed.
+	SecretRefreshInterval internal.Duration
+
+	// Statefile is the file used to save the state of stateful plugins
+	// between restarts, state is not saved if it is empty.
+	Statefile string
+
+	// StrictConfig fails loading the config on unknown options, options of
+	// the wrong kind and parser or serializer options not used by the
+	// data_format, instead of ignoring them.
+	StrictConfig bool
+
+	// Health enables the health check endpoint when set.
+	Health *HealthConfig
+
+	// TLS are the TLS settings inherited by the plugins.
+	TLS *TLSConfig
+
+	// Pprof enables the pprof endpoint when set.
+	Pprof *PprofConfig
+
+	// Admin enables the admin API when set.
+	Admin *AdminConfig
+
+	// Metadata adds the metadata of the host to the global tags when set.
+	Metadata *MetadataConfig
+}
+
+// HealthConfig configures the health check endpoint of the agent.
+type HealthConfig struct {
+	ServiceAddress string
+	ReadTimeout    internal.Duration
+	WriteTimeout   internal.Duration
+
+	// Checks are the names of the checks to run, all checks are run if it
+	// is empty.
+	Checks []string
+
+	// BufferThreshold is the buffer fullness, between 0 and 1, at which the
+	// buffers check fails.
+	BufferThreshold float64
+
+	StatusCodeHealthy   int
+	StatusCodeUnhealthy int
+}
+
+// PprofConfig configures the pprof endpoint of the agent, basic auth is
+// required when the username or password is set.
+type PprofConfig struct {
+	ServiceAddress string
+	Username       string
+	Password       string
+}
+
+// AdminConfig configures the admin API of the agent, requests must carry the
+// token as a bearer token.
+type AdminConfig struct {
+	ServiceAddress string
+	ReadTimeout    internal.Duration
+	WriteTimeout   internal.Duration
+
+	// PathPrefix is prepended to the paths of the API, for serving it on a
+	// sub path of a reverse proxy.
+	PathPrefix string
+
+	Token string
+}
+
+// MetadataConfig configures the host metadata added to the global tags.
+type MetadataConfig struct {
+	// Providers are the names of the metadata providers, all providers are
+	// used if it is empty.
+	Providers []string
+
+	// RefreshInterval is how often the metadata is resolved again, it is
+	// only resolved on startup if it is 0.
+	RefreshInterval internal.Duration
+
+	// Timeout limits each request to a cloud metadata service.
+	Timeout internal.Duration
+}
+
+// TLSConfig are the TLS settings of the [agent.tls] table, they are used by
+// the plugins for the settings they don't set themselves.
+type TLSConfig struct {
+	TLSCA         string `toml:"tls_ca"`
+	TLSCert       string `toml:"tls_cert"`
+	TLSKey        string `toml:"tls_key"`
+	TLSMinVersion string `toml:"tls_min_version"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -234,12 +389,25 @@ var header = `# Telegraf Configuration
   ## This controls the size of writes that Telegraf sends to output plugins.
   metric_batch_size = 1000
 
+  ## Limits the size in bytes of the metrics sent in one write to each
+  ## output, in its data format, for outputs with a maximum request size.
+  ## Can be set per output.  A metric larger than the limit is written alone.
+  # metric_batch_bytes = "1MB"
+
   ## For failed writes, telegraf will cache metric_buffer_limit metrics for each
   ## output, and will flush this buffer on a successful write. Oldest metrics
   ## are dropped first when this buffer fills.
   ## This buffer only fills when writes fail to output plugin(s).
   metric_buffer_limit = 10000
 
+  ## Maximum number of distinct series, combinations of measurement and tags,
+  ## each input may produce, to protect the outputs from runaway cardinality.
+  ## Metrics of new series past the limit are dropped with series_overflow
+  ## "drop", with "hash" the values of the tags set by the plugin are
+  ## replaced by one of 10 overflow buckets instead.  0 means no limit.
+  # max_series = 0
+  # series_overflow = "drop"
+
   ## Collection jitter is used to jitter the collection by a random amount.
   ## Each plugin will sleep for a random time within jitter before collecting.
   ## This can be used to avoid many plugins querying things like sysfs at the
@@ -254,6 +422,11 @@ var header = `# Telegraf Configuration
   ## ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
   flush_jitter = "0s"
 
+  ## Time the outputs keep trying to write their buffered metrics on
+  ## shutdown, failed writes are retried until it expires.  When "0s", each
+  ## output makes a single attempt that may take as long as it needs.
+  # shutdown_timeout = "0s"
+
   ## By default or when set to "0s", precision will be set to the same
   ## timestamp order as the collection interval, with the maximum being 1s.
   ##   ie, when interval = "10s", precision will be "1s"
@@ -270,12 +443,86 @@ var header = `# Telegraf Configuration
   quiet = false
   ## Specify the log file name. The empty string means to log to stderr.
   logfile = ""
+  ## Format of log messages, either "text" or "json".
+  # log_format = "text"
+
+  ## The logfile will be rotated after the time interval specified.  When set
+  ## to 0 no time based rotation is performed.
+  # logfile_rotation_interval = "0h"
+
+  ## The logfile will be rotated when it becomes larger than the specified
+  ## size.  When set to 0 no size based rotation is performed.
+  # logfile_rotation_max_size = "0MB"
+
+  ## Maximum number of rotated archives to keep, any older logs are deleted.
+  ## If set to -1, no archives are removed.
+  # logfile_rotation_max_archives = 5
 
   ## Override default hostname, if empty use os.Hostname()
   hostname = ""
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Interval at which secret store references are resolved again, the
+  ## config is reloaded when a secret has changed.  Disabled when "0s".
+  # secret_refresh_interval = "0s"
+
+  ## File used to save the state of plugins, such as the read position of
+  ## tailed files, so it survives restarts.  State is not saved if unset.
+  # statefile = ""
+
+  ## Fail on unknown plugin options, options with a value of the wrong type
+  ## and parser or serializer options not used by the data_format, instead
+  ## of ignoring them.
+  # strict_config = false
+
+  ## Serve a health check on /healthz, such as for liveness and readiness
+  ## probes.  The "outputs" check fails if an output is not connected or its
+  ## last write failed, "buffers" if an output buffer is fuller than
+  ## buffer_threshold and "inputs" if an input reported errors during its
+  ## last two gathers.
+  # [agent.health]
+  #   service_address = ":8080"
+  #   checks = ["outputs", "buffers", "inputs"]
+  #   buffer_threshold = 0.9
+  #   status_code_healthy = 200
+  #   status_code_unhealthy = 503
+
+  ## TLS settings used by all plugins for the settings they don't set
+  ## themselves.  Client certificates and CAs are only used by plugins
+  ## connecting to servers, listeners only use the minimum version.
+  # [agent.tls]
+  #   tls_ca = "/etc/telegraf/ca.pem"
+  #   tls_cert = "/etc/telegraf/cert.pem"
+  #   tls_key = "/etc/telegraf/key.pem"
+  #   tls_min_version = "1.2"
+
+  ## Serve the runtime profiling data of Telegraf on /debug/pprof, for use
+  ## with "go tool pprof".  Basic auth is required if a username or password
+  ## is set.
+  # [agent.pprof]
+  #   service_address = "localhost:6060"
+  #   username = ""
+  #   password = ""
+
+  ## Serve an admin API reporting the status of the plugins and the internal
+  ## stats, and reloading the config on request.  Requests must carry the
+  ## token in an "Authorization: Bearer <token>" header.  Set path_prefix when
+  ## a reverse proxy serves the API on a sub path.
+  # [agent.admin]
+  #   service_address = "localhost:8090"
+  #   path_prefix = ""
+  #   token = ""
+
+  ## Add the metadata of the host to the global tags: the cloud provider,
+  ## instance id, instance type, region and availability zone of EC2, Azure
+  ## and GCP instances, and the os, arch, platform, platform version and
+  ## kernel version.  Global tags set in the config take precedence.
+  # [agent.metadata]
+  #   providers = ["ec2", "azure", "gcp", "os"]
+  #   refresh_interval = "1h"
+  #   timeout = "2s"
+
 
 ###############################################################################
 #                            OUTPUT PLUGINS                                   #
@@ -531,7 +778,20 @@ func PrintOutputConfig(name string) error {
 	return nil
 }
 
+// LoadDirectory loads all .conf files in the directory and its
+// subdirectories.
 func (c *Config) LoadDirectory(path string) error {
+	paths, err := DirectoryFiles(path)
+	if err != nil {
+		return err
+	}
+	return c.LoadConfigs(paths)
+}
+
+// DirectoryFiles returns the paths of all .conf files in the directory and
+// its subdirectories in lexical order.
+func DirectoryFiles(path string) ([]string, error) {
+	var paths []string
 	walkfn := func(thispath string, info os.FileInfo, _ error) error {
 		if info == nil {
 			log.Printf("W! Telegraf is not permitted to read %s", thispath)
@@ -550,13 +810,13 @@ func (c *Config) LoadDirectory(path string) error {
 		if len(name) < 6 || name[len(name)-5:] != ".conf" {
 			return nil
 		}
-		err := c.LoadConfig(thispath)
-		if err != nil {
-			return err
-		}
+		paths = append(paths, thispath)
 		return nil
 	}
-	return filepath.Walk(path, walkfn)
+	if err := filepath.Walk(path, walkfn); err != nil {
+		return nil, err
+	}
+	return paths, nil
 }
 
 // Try to find a default config file at these locations (in order):
@@ -585,20 +845,84 @@ func getDefaultConfigPath() (string, error) {
 
 // LoadConfig loads the given config file and applies it to c
 func (c *Config) LoadConfig(path string) error {
-	var err error
-	if path == "" {
-		if path, err = getDefaultConfigPath(); err != nil {
+	return c.LoadConfigs([]string{path})
+}
+
+// LoadConfigs loads the given config files in order and applies them to c.
+// The secret stores of all files are added before any other table is parsed,
+// so secrets can be referenced from every file regardless of the order.
+func (c *Config) LoadConfigs(paths []string) error {
+	type file struct {
+		path string
+		data []byte
+		tbl  *ast.Table
+	}
+
+	files := make([]file, 0, len(paths))
+	for _, path := range paths {
+		var err error
+		if path == "" {
+			if path, err = getDefaultConfigPath(); err != nil {
+				return err
+			}
+		}
+		data, tbl, err := c.loadConfig(path)
+		if err != nil {
+			return err
+		}
+		if err = c.addSecretStores(path, tbl); err != nil {
 			return err
 		}
+		files = append(files, file{path: path, data: data, tbl: tbl})
 	}
-	data, err := loadConfig(path)
-	if err != nil {
-		return fmt.Errorf("Error loading %s, %s", path, err)
+
+	for _, f := range files {
+		if err := c.loadTable(f.path, f.data, f.tbl); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	tbl, err := parseConfig(data)
-	if err != nil {
-		return fmt.Errorf("Error parsing %s, %s", path, err)
+// addSecretStores adds the secret stores defined in the config file.
+func (c *Config) addSecretStores(path string, tbl *ast.Table) error {
+	val, ok := tbl.Fields["secretstores"]
+	if !ok {
+		return nil
+	}
+	subTable, ok := val.(*ast.Table)
+	if !ok {
+		return fmt.Errorf("%s: invalid configuration", path)
+	}
+	for pluginName, pluginVal := range subTable.Fields {
+		switch pluginSubTable := pluginVal.(type) {
+		case []*ast.Table:
+			for _, t := range pluginSubTable {
+				if err := c.addSecretStore(pluginName, t); err != nil {
+					return fmt.Errorf("Error parsing %s, %s", path, err)
+				}
+			}
+		default:
+			return fmt.Errorf("Unsupported config format: %s, file %s",
+				pluginName, path)
+		}
+	}
+	return nil
+}
+
+// loadTable applies the parsed config file to c, its secret stores must
+// already be added.
+func (c *Config) loadTable(path string, data []byte, tbl *ast.Table) error {
+	var err error
+	c.Files = append(c.Files, path)
+
+	for name, val := range tbl.Fields {
+		if name == "secretstores" {
+			continue
+		}
+		if err = c.resolveSecrets(val); err != nil {
+			return fmt.Errorf("Error parsing %s, %s", path, err)
+		}
 	}
 
 	// Parse tags tables first:
@@ -648,7 +972,18 @@ func (c *Config) LoadConfig(path string) error {
 		}
 
 		switch name {
-		case "agent", "global_tags", "tags":
+		case "agent", "global_tags", "tags", "secretstores":
+		case "output_groups":
+			for groupName, groupVal := range subTable.Fields {
+				groupTable, ok := groupVal.(*ast.Table)
+				if !ok {
+					return fmt.Errorf("Unsupported config format: %s, file %s",
+						groupName, path)
+				}
+				if err = c.addOutputGroup(groupName, groupTable); err != nil {
+					return fmt.Errorf("Error parsing %s, %s", path, err)
+				}
+			}
 		case "outputs":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
@@ -728,6 +1063,12 @@ func (c *Config) LoadConfig(path string) error {
 		sort.Sort(c.Processors)
 	}
 
+	if isRemote(path) {
+		if err := c.writeCache(path, data); err != nil {
+			log.Printf("W! Could not cache config %s: %v", path, err)
+		}
+	}
+
 	return nil
 }
 
@@ -743,42 +1084,64 @@ func escapeEnv(value string) string {
 	return envVarEscaper.Replace(value)
 }
 
-func loadConfig(config string) ([]byte, error) {
-	u, err := url.Parse(config)
-	if err != nil {
-		return nil, err
+// loadConfig reads and parses the config file at path.  If a remote config
+// can not be loaded the cached copy is used instead.
+func (c *Config) loadConfig(path string) ([]byte, *ast.Table, error) {
+	if !isRemote(path) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error loading %s, %s", path, err)
+		}
+
+		tbl, err := parseConfig(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error parsing %s, %s", path, err)
+		}
+		return data, tbl, nil
 	}
 
-	switch u.Scheme {
-	case "https", "http":
-		return fetchConfig(u)
-	default:
-		// If it isn't a https scheme, try it as a file.
+	data, tbl, err := c.loadRemote(path)
+	if err == nil {
+		return data, tbl, nil
 	}
-	return ioutil.ReadFile(config)
 
-}
+	cached, cerr := c.readCache(path)
+	if cerr != nil {
+		return nil, nil, err
+	}
+	log.Printf("W! %v, using cached copy", err)
 
-func fetchConfig(u *url.URL) ([]byte, error) {
-	v := os.Getenv("INFLUX_TOKEN")
+	tbl, cerr = parseConfig(cached)
+	if cerr != nil {
+		return nil, nil, err
+	}
+
+	// Keep checking the source so the config is reloaded once it recovers.
+	c.sources[path] = &remoteSource{sum: sha256.Sum256(cached)}
+	return cached, tbl, nil
+}
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+func (c *Config) loadRemote(path string) ([]byte, *ast.Table, error) {
+	u, err := url.Parse(path)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("Error loading %s, %s", path, err)
 	}
-	req.Header.Add("Authorization", "Token "+v)
-	req.Header.Add("Accept", "application/toml")
-	resp, err := http.DefaultClient.Do(req)
+
+	resp, err := c.fetchRemote(u, "")
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("Error loading %s, %s", path, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
+	tbl, err := parseConfig(resp.data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing %s, %s", path, err)
 	}
 
-	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
+	c.sources[path] = &remoteSource{
+		etag: resp.etag,
+		sum:  sha256.Sum256(resp.data),
+	}
+	return resp.data, tbl, nil
 }
 
 // parseConfig loads a TOML configuration from a provided path and
@@ -787,35 +1150,251 @@ func fetchConfig(u *url.URL) ([]byte, error) {
 func parseConfig(contents []byte) (*ast.Table, error) {
 	contents = trimBOM(contents)
 
-	env_vars := envVarRe.FindAll(contents, -1)
-	for _, env_var := range env_vars {
-		env_val, ok := os.LookupEnv(strings.TrimPrefix(string(env_var), "$"))
-		if ok {
-			env_val = escapeEnv(env_val)
-			contents = bytes.Replace(contents, env_var, []byte(env_val), 1)
-		}
+	contents, err := substituteEnvVars(contents)
+	if err != nil {
+		return nil, err
 	}
 
 	return toml.Parse(contents)
 }
 
+// substituteEnvVars replaces the environment variables in the config.
+// Variables that are not set are left unchanged unless a default or error is
+// given; the default is used and the error returned when the variable is not
+// set or empty.  Comment lines are skipped so that commented out options do
+// not require their variables, lines of multi-line strings are not comments
+// even when they start with a #.
+func substituteEnvVars(contents []byte) ([]byte, error) {
+	var err error
+	var multiline string
+	lines := bytes.SplitAfter(contents, []byte("\n"))
+	for i, line := range lines {
+		inString := multiline != ""
+		multiline = openMultiline(line, multiline)
+		if !inString && bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			continue
+		}
+
+		lines[i] = envVarRe.ReplaceAllFunc(line, func(match []byte) []byte {
+			sub := envVarRe.FindSubmatch(match)
+			if sub[4] != nil {
+				if value, ok := os.LookupEnv(string(sub[4])); ok {
+					return []byte(escapeEnv(value))
+				}
+				return match
+			}
+
+			name := string(sub[1])
+			value, ok := os.LookupEnv(name)
+			switch string(sub[2]) {
+			case ":-":
+				if value == "" {
+					return []byte(escapeEnv(string(sub[3])))
+				}
+			case ":?":
+				if value == "" {
+					if err == nil {
+						msg := string(sub[3])
+						if msg == "" {
+							msg = "not set"
+						}
+						err = fmt.Errorf("environment variable %s: %s", name, msg)
+					}
+					return match
+				}
+			default:
+				if !ok {
+					return match
+				}
+			}
+			return []byte(escapeEnv(value))
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Join(lines, nil), nil
+}
+
+// openMultiline returns the delimiter of the multi-line string still open at
+// the end of the line, delim is the one open at its start.
+func openMultiline(line []byte, delim string) string {
+	for i := 0; i < len(line); i++ {
+		if delim != "" {
+			if delim == `"""` && line[i] == '\\' {
+				i++
+			} else if bytes.HasPrefix(line[i:], []byte(delim)) {
+				i += len(delim) - 1
+				delim = ""
+			}
+			continue
+		}
+
+		switch {
+		case line[i] == '#':
+			return ""
+		case bytes.HasPrefix(line[i:], []byte(`"""`)), bytes.HasPrefix(line[i:], []byte(`'''`)):
+			delim = string(line[i : i+3])
+			i += 2
+		case line[i] == '"':
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case line[i] == '\'':
+			for i++; i < len(line) && line[i] != '\''; i++ {
+			}
+		}
+	}
+	return delim
+}
+
+func (c *Config) addSecretStore(name string, table *ast.Table) error {
+	creator, ok := secretstores.SecretStores[name]
+	if !ok {
+		return fmt.Errorf("Undefined but requested secret store: %s", name)
+	}
+	store := creator()
+
+	id := name
+	if node, ok := table.Fields["id"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				id = str.Value
+			}
+		}
+	}
+	delete(table.Fields, "id")
+
+	if _, ok := c.SecretStores[id]; ok {
+		return fmt.Errorf("Duplicate secret store id: %s", id)
+	}
+
+	if err := toml.UnmarshalTable(table, store); err != nil {
+		return err
+	}
+
+	c.SecretStores[id] = store
+	return nil
+}
+
+// resolveSecrets replaces the secret references in all strings below the
+// given table, key value or array.
+func (c *Config) resolveSecrets(node interface{}) error {
+	var err error
+	switch node := node.(type) {
+	case *ast.Table:
+		for _, val := range node.Fields {
+			if err = c.resolveSecrets(val); err != nil {
+				return err
+			}
+		}
+	case []*ast.Table:
+		for _, t := range node {
+			if err = c.resolveSecrets(t); err != nil {
+				return err
+			}
+		}
+	case *ast.KeyValue:
+		return c.resolveSecrets(node.Value)
+	case *ast.Array:
+		for _, elem := range node.Value {
+			if err = c.resolveSecrets(elem); err != nil {
+				return err
+			}
+		}
+	case *ast.String:
+		node.Value = secretRefRe.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			if err != nil {
+				return ref
+			}
+			var value string
+			value, err = c.lookupSecret(ref)
+			return value
+		})
+	}
+	return err
+}
+
+// lookupSecret returns the value of a secret reference, ie: @{vault:password}
+func (c *Config) lookupSecret(ref string) (string, error) {
+	match := secretRefRe.FindStringSubmatch(ref)
+	store, ok := c.SecretStores[match[1]]
+	if !ok {
+		return "", fmt.Errorf("Undefined secret store in reference %s", ref)
+	}
+
+	value, err := store.Get(match[2])
+	if err != nil {
+		return "", fmt.Errorf("Unable to resolve secret %s: %v", ref, err)
+	}
+	c.secrets[ref] = value
+	return value, nil
+}
+
+// SecretsChanged resolves all secret references used by the config again and
+// reports if any of the values differ from the ones the config was loaded
+// with or seen by the previous call.  Secrets that can not be resolved are
+// considered unchanged.
+func (c *Config) SecretsChanged() bool {
+	changed := false
+	for ref, value := range c.secrets {
+		match := secretRefRe.FindStringSubmatch(ref)
+		current, err := c.SecretStores[match[1]].Get(match[2])
+		if err != nil {
+			log.Printf("W! Unable to refresh secret %s: %v", ref, err)
+			continue
+		}
+		if current != value {
+			log.Printf("I! Secret %s has changed", ref)
+			c.secrets[ref] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
 func (c *Config) addAggregator(name string, table *ast.Table) error {
 	creator, ok := aggregators.Aggregators[name]
 	if !ok {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
 	aggregator := creator()
+	id := fingerprint(name, table)
+	options := tableOptions(table)
 
 	conf, err := buildAggregator(name, table)
 	if err != nil {
 		return err
 	}
 
+	log, err := buildLogger("aggregators", name, "", table)
+	if err != nil {
+		return err
+	}
+	models.SetLoggerOnPlugin(aggregator, log)
+
+	inheritTLS, err := buildInheritTLS(table)
+	if err != nil {
+		return err
+	}
+
+	c.checkDeprecations("aggregators."+name, nil, aggregator, table)
+	if err := c.checkStrict("aggregators."+name, aggregator, options, table); err != nil {
+		return err
+	}
 	if err := toml.UnmarshalTable(table, aggregator); err != nil {
 		return err
 	}
+	if inheritTLS {
+		c.inheritTLS(aggregator)
+	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	ra.Fingerprint = id
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -825,20 +1404,73 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
+	id := fingerprint(name, table)
+	options := tableOptions(table)
 
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
 		return err
 	}
 
+	// If the processor has SetParser and SetSerializer functions, then it
+	// passes the metrics through an external data format, so build the parser
+	// and serializer from the same options and set them.
+	serializerTable := &ast.Table{Fields: tableOptions(table)}
+	switch t := processor.(type) {
+	case parsers.ParserInput:
+		parser, err := buildParser(name, "", table)
+		if err != nil {
+			return err
+		}
+		t.SetParser(parser)
+	}
+
+	switch t := processor.(type) {
+	case serializers.SerializerOutput:
+		serializerConfig, err := buildSerializerConfig(name, serializerTable)
+		if err != nil {
+			return err
+		}
+		serializer, err := serializers.NewSerializer(serializerConfig)
+		if err != nil {
+			return err
+		}
+		t.SetSerializer(serializer)
+
+		for key := range table.Fields {
+			if _, ok := serializerTable.Fields[key]; !ok {
+				delete(table.Fields, key)
+			}
+		}
+	}
+
+	log, err := buildLogger("processors", name, "", table)
+	if err != nil {
+		return err
+	}
+	models.SetLoggerOnPlugin(processor, log)
+
+	inheritTLS, err := buildInheritTLS(table)
+	if err != nil {
+		return err
+	}
+
+	c.checkDeprecations("processors."+name, nil, processor, table)
+	if err := c.checkStrict("processors."+name, processor, options, table); err != nil {
+		return err
+	}
 	if err := toml.UnmarshalTable(table, processor); err != nil {
 		return err
 	}
+	if inheritTLS {
+		c.inheritTLS(processor)
+	}
 
 	rf := &models.RunningProcessor{
-		Name:      name,
-		Processor: processor,
-		Config:    processorConfig,
+		Name:        name,
+		Processor:   processor,
+		Config:      processorConfig,
+		Fingerprint: id,
 	}
 
 	c.Processors = append(c.Processors, rf)
@@ -854,12 +1486,20 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
+	id := fingerprint(name, table)
+	options := tableOptions(table)
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
+	var serializerConfig *serializers.Config
 	switch t := output.(type) {
 	case serializers.SerializerOutput:
-		serializer, err := buildSerializer(name, table)
+		var err error
+		serializerConfig, err = buildSerializerConfig(name, table)
+		if err != nil {
+			return err
+		}
+		serializer, err := serializers.NewSerializer(serializerConfig)
 		if err != nil {
 			return err
 		}
@@ -871,20 +1511,115 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, output); err != nil {
-		return err
+	if outputConfig.MaxParallelWrites > 1 {
+		if _, ok := output.(telegraf.ConcurrentOutput); !ok {
+			return fmt.Errorf("output %s does not support max_parallel_writes", name)
+		}
 	}
 
-	ro := models.NewRunningOutput(name, output, outputConfig,
-		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
-	c.Outputs = append(c.Outputs, ro)
-	return nil
-}
+	if outputConfig.BufferDirectory != "" {
+		for _, ro := range c.Outputs {
+			if ro.Config.BufferDirectory == outputConfig.BufferDirectory {
+				return fmt.Errorf("buffer_directory %q is already used by output %s",
+					outputConfig.BufferDirectory, ro.Name)
+			}
+		}
+	}
 
-func (c *Config) addInput(name string, table *ast.Table) error {
-	if len(c.InputFilters) > 0 && !sliceContains(name, c.InputFilters) {
+	log, err := buildLogger("outputs", name, outputConfig.Alias, table)
+	if err != nil {
+		return err
+	}
+	models.SetLoggerOnPlugin(output, log)
+
+	var deprecation *telegraf.DeprecationInfo
+	if info, ok := outputs.Deprecations[name]; ok {
+		deprecation = &info
+	}
+	inheritTLS, err := buildInheritTLS(table)
+	if err != nil {
+		return err
+	}
+
+	c.checkDeprecations("outputs."+name, deprecation, output, table)
+	if err := c.checkStrict("outputs."+name, output, options, table); err != nil {
+		return err
+	}
+	if err := toml.UnmarshalTable(table, output); err != nil {
+		return err
+	}
+	if inheritTLS {
+		c.inheritTLS(output)
+	}
+
+	if outputConfig.MetricBatchBytes == 0 {
+		outputConfig.MetricBatchBytes = c.Agent.MetricBatchBytes.Size
+	}
+	ro := models.NewRunningOutput(name, output, outputConfig,
+		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	ro.Fingerprint = id
+
+	// The batches are measured with a serializer of their own, the one of the
+	// output is not safe to share.
+	if serializerConfig != nil &&
+		(outputConfig.MetricBatchBytes > 0 || outputConfig.MaxBytesPerSecond > 0) {
+		serializer, err := serializers.NewSerializer(serializerConfig)
+		if err != nil {
+			return err
+		}
+		ro.SetSerializer(serializer)
+	}
+	c.Outputs = append(c.Outputs, ro)
+	return nil
+}
+
+func (c *Config) addOutputGroup(name string, table *ast.Table) error {
+	for _, group := range c.OutputGroups {
+		if group.Name == name {
+			return fmt.Errorf("Duplicate output group: %s", name)
+		}
+	}
+
+	var conf struct {
+		Mode             string
+		Outputs          []string
+		FailbackInterval internal.Duration
+	}
+	if err := toml.UnmarshalTable(table, &conf); err != nil {
+		return err
+	}
+
+	switch conf.Mode {
+	case "":
+		conf.Mode = models.GroupModeFailover
+	case models.GroupModeFailover, models.GroupModeRoundRobin:
+	default:
+		return fmt.Errorf("Invalid mode %q for output group %s", conf.Mode, name)
+	}
+	if len(conf.Outputs) == 0 {
+		return fmt.Errorf("Output group %s has no outputs", name)
+	}
+
+	c.OutputGroups = append(c.OutputGroups, &models.OutputGroupConfig{
+		Name:             name,
+		Mode:             conf.Mode,
+		Outputs:          conf.Outputs,
+		FailbackInterval: conf.FailbackInterval.Duration,
+	})
+	return nil
+}
+
+func (c *Config) addInput(name string, table *ast.Table) error {
+	if len(c.InputFilters) > 0 && !sliceContains(name, c.InputFilters) {
 		return nil
 	}
+	// Deprecations refer to the name used in the config.
+	var deprecation *telegraf.DeprecationInfo
+	if info, ok := inputs.Deprecations[name]; ok {
+		deprecation = &info
+	}
+	pluginName := "inputs." + name
+
 	// Legacy support renaming io input to diskio
 	if name == "io" {
 		name = "diskio"
@@ -895,12 +1630,19 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
 	input := creator()
+	id := fingerprint(name, table)
+	options := tableOptions(table)
+
+	pluginConfig, err := buildInput(name, table)
+	if err != nil {
+		return err
+	}
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
 	switch t := input.(type) {
 	case parsers.ParserInput:
-		parser, err := buildParser(name, table)
+		parser, err := buildParser(name, pluginConfig.Alias, table)
 		if err != nil {
 			return err
 		}
@@ -914,25 +1656,94 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 			return err
 		}
 		t.SetParserFunc(func() (parsers.Parser, error) {
-			return parsers.NewParser(config)
+			parser, err := parsers.NewParser(config)
+			if err != nil {
+				return nil, err
+			}
+			return parsers.NewStatsParser(parser, name, pluginConfig.Alias, config.DataFormat), nil
 		})
 	}
 
-	pluginConfig, err := buildInput(name, table)
+	log, err := buildLogger("inputs", name, pluginConfig.Alias, table)
 	if err != nil {
 		return err
 	}
+	models.SetLoggerOnPlugin(input, log)
 
+	inheritTLS, err := buildInheritTLS(table)
+	if err != nil {
+		return err
+	}
+
+	c.checkDeprecations(pluginName, deprecation, input, table)
+	if err := c.checkStrict(pluginName, input, options, table); err != nil {
+		return err
+	}
 	if err := toml.UnmarshalTable(table, input); err != nil {
 		return err
 	}
+	if inheritTLS {
+		c.inheritTLS(input)
+	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
 	rp.SetDefaultTags(c.Tags)
+	rp.Fingerprint = id
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
 
+// fingerprint returns a checksum of the plugin settings in the table.  It
+// only depends on the values of the settings, not on their order or position
+// in the file, and must be computed before any settings are removed from the
+// table.
+func fingerprint(name string, tbl *ast.Table) string {
+	h := sha256.New()
+	io.WriteString(h, name)
+	writeFingerprint(h, tbl)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeFingerprint(w io.Writer, node interface{}) {
+	switch node := node.(type) {
+	case *ast.Table:
+		keys := make([]string, 0, len(node.Fields))
+		for key := range node.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		io.WriteString(w, "{")
+		for _, key := range keys {
+			fmt.Fprintf(w, "%q=", key)
+			writeFingerprint(w, node.Fields[key])
+			io.WriteString(w, ";")
+		}
+		io.WriteString(w, "}")
+	case []*ast.Table:
+		io.WriteString(w, "[")
+		for _, t := range node {
+			writeFingerprint(w, t)
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "]")
+	case *ast.KeyValue:
+		writeFingerprint(w, node.Value)
+	case *ast.Array:
+		io.WriteString(w, "[")
+		for _, elem := range node.Value {
+			writeFingerprint(w, elem)
+			io.WriteString(w, ",")
+		}
+		io.WriteString(w, "]")
+	case *ast.String:
+		// Use the value as secret references are only replaced there.
+		fmt.Fprintf(w, "%q", node.Value)
+	case ast.Value:
+		io.WriteString(w, node.Source())
+	}
+}
+
 // buildAggregator parses Aggregator specific items from the ast.Table,
 // builds the filter and returns a
 // models.AggregatorConfig to be inserted into models.RunningAggregator
@@ -1087,7 +1898,7 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 		}
 	}
 
-	fields := []string{"pass", "fieldpass"}
+	fields := []string{"pass", "fieldpass", "fieldinclude"}
 	for _, field := range fields {
 		if node, ok := tbl.Fields[field]; ok {
 			if kv, ok := node.(*ast.KeyValue); ok {
@@ -1102,7 +1913,7 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 		}
 	}
 
-	fields = []string{"drop", "fielddrop"}
+	fields = []string{"drop", "fielddrop", "fieldexclude"}
 	for _, field := range fields {
 		if node, ok := tbl.Fields[field]; ok {
 			if kv, ok := node.(*ast.KeyValue); ok {
@@ -1184,6 +1995,8 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 	delete(tbl.Fields, "namepass")
 	delete(tbl.Fields, "fielddrop")
 	delete(tbl.Fields, "fieldpass")
+	delete(tbl.Fields, "fieldexclude")
+	delete(tbl.Fields, "fieldinclude")
 	delete(tbl.Fields, "drop")
 	delete(tbl.Fields, "pass")
 	delete(tbl.Fields, "tagdrop")
@@ -1211,6 +2024,70 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["collection_jitter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.CollectionJitter = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["gather_timeout"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.GatherTimeout = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["precision"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.Precision = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_series"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				cp.MaxSeries = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["series_overflow"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.SeriesOverflowDrop, models.SeriesOverflowHash:
+					cp.SeriesOverflow = str.Value
+				default:
+					return nil, fmt.Errorf("invalid series_overflow %q", str.Value)
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1235,6 +2112,26 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["outputs"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						cp.Outputs = append(cp.Outputs, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1244,12 +2141,24 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	var err error
+	cp.StartupErrorBehavior, err = buildStartupErrorBehavior(tbl)
+	if err != nil {
+		return nil, err
+	}
+
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "collection_jitter")
+	delete(tbl.Fields, "gather_timeout")
+	delete(tbl.Fields, "precision")
+	delete(tbl.Fields, "max_series")
+	delete(tbl.Fields, "series_overflow")
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "outputs")
 	delete(tbl.Fields, "tags")
-	var err error
 	cp.Filter, err = buildFilter(tbl)
 	if err != nil {
 		return cp, err
@@ -1257,15 +2166,57 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	return cp, nil
 }
 
+// buildStartupErrorBehavior parses the startup_error_behavior of a plugin.
+func buildStartupErrorBehavior(tbl *ast.Table) (string, error) {
+	var behavior string
+	if node, ok := tbl.Fields["startup_error_behavior"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.StartupErrorBehaviorError,
+					models.StartupErrorBehaviorRetry,
+					models.StartupErrorBehaviorIgnore:
+					behavior = str.Value
+				default:
+					return "", fmt.Errorf("invalid startup_error_behavior %q", str.Value)
+				}
+			}
+		}
+	}
+	delete(tbl.Fields, "startup_error_behavior")
+
+	return behavior, nil
+}
+
+// buildLogger parses the log_level of a plugin and returns the logger for
+// the plugin.
+func buildLogger(pluginType, name, alias string, tbl *ast.Table) (*models.Logger, error) {
+	var level string
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				level = str.Value
+			}
+		}
+	}
+	delete(tbl.Fields, "log_level")
+
+	return models.NewLogger(pluginType, name, alias, level)
+}
+
 // buildParser grabs the necessary entries from the ast.Table for creating
 // a parsers.Parser object, and creates it, which can then be added onto
 // an Input object.
-func buildParser(name string, tbl *ast.Table) (parsers.Parser, error) {
+func buildParser(name string, alias string, tbl *ast.Table) (parsers.Parser, error) {
 	config, err := getParserConfig(name, tbl)
 	if err != nil {
 		return nil, err
 	}
-	return parsers.NewParser(config)
+	parser, err := parsers.NewParser(config)
+	if err != nil {
+		return nil, err
+	}
+	return parsers.NewStatsParser(parser, name, alias, config.DataFormat), nil
 }
 
 func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
@@ -1642,6 +2593,117 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	//for regex data_format
+	if node, ok := tbl.Fields["regex_patterns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.RegexPatterns = append(c.RegexPatterns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	c.RegexTypes = make(map[string]string)
+	if node, ok := tbl.Fields["regex_types"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.RegexTypes[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["regex_timestamp_group"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.RegexTimestampGroup = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["regex_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.RegexTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["regex_timezone"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.RegexTimezone = str.Value
+			}
+		}
+	}
+
+	//for html data_format
+	if node, ok := tbl.Fields["html_table_selector"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.HTMLTableSelector = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["html_column_names"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.HTMLColumnNames = append(c.HTMLColumnNames, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["html_tag_columns"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.HTMLTagColumns = append(c.HTMLTagColumns, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["html_timestamp_column"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.HTMLTimestampColumn = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["html_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.HTMLTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["unit_fields"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.UnitFields = append(c.UnitFields, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
@@ -1683,14 +2745,25 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "csv_timestamp_column")
 	delete(tbl.Fields, "csv_timestamp_format")
 	delete(tbl.Fields, "csv_trim_space")
+	delete(tbl.Fields, "regex_patterns")
+	delete(tbl.Fields, "regex_types")
+	delete(tbl.Fields, "regex_timestamp_group")
+	delete(tbl.Fields, "regex_timestamp_format")
+	delete(tbl.Fields, "regex_timezone")
+	delete(tbl.Fields, "html_table_selector")
+	delete(tbl.Fields, "html_column_names")
+	delete(tbl.Fields, "html_tag_columns")
+	delete(tbl.Fields, "html_timestamp_column")
+	delete(tbl.Fields, "html_timestamp_format")
+	delete(tbl.Fields, "unit_fields")
 
 	return c, nil
 }
 
-// buildSerializer grabs the necessary entries from the ast.Table for creating
-// a serializers.Serializer object, and creates it, which can then be added onto
-// an Output object.
-func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error) {
+// buildSerializerConfig grabs the necessary entries from the ast.Table for
+// creating a serializers.Serializer object, which can then be added onto an
+// Output object.
+func buildSerializerConfig(name string, tbl *ast.Table) (*serializers.Config, error) {
 	c := &serializers.Config{TimestampUnits: time.Duration(1 * time.Second)}
 
 	if node, ok := tbl.Fields["data_format"]; ok {
@@ -1721,6 +2794,18 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["templates"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.Templates = append(c.Templates, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["influx_max_line_bytes"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if integer, ok := kv.Value.(*ast.Integer); ok {
@@ -1769,6 +2854,22 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["graphite_tag_sanitize_mode"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GraphiteTagSanitizeMode = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["graphite_separator"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.GraphiteSeparator = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["json_timestamp_units"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1785,6 +2886,38 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["json_timestamp_key"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimestampKey = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_nesting_separator"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONNestingSeparator = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["json_batch_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.JSONBatchFormat = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["splunkmetric_hec_routing"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Boolean); ok {
@@ -1797,16 +2930,84 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["splunkmetric_multimetric"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.SplunkmetricMultiMetric, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["carbon2_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.Carbon2Format = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["carbon2_meta_tags"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.Carbon2MetaTags = append(c.Carbon2MetaTags, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["wavefront_use_strict"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				c.WavefrontUseStrict, err = b.Boolean()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["wavefront_source_override"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.WavefrontSourceOverride = append(c.WavefrontSourceOverride, str.Value)
+					}
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
 	delete(tbl.Fields, "influx_uint_support")
 	delete(tbl.Fields, "graphite_tag_support")
+	delete(tbl.Fields, "graphite_tag_sanitize_mode")
+	delete(tbl.Fields, "graphite_separator")
 	delete(tbl.Fields, "data_format")
 	delete(tbl.Fields, "prefix")
 	delete(tbl.Fields, "template")
+	delete(tbl.Fields, "templates")
 	delete(tbl.Fields, "json_timestamp_units")
+	delete(tbl.Fields, "json_timestamp_key")
+	delete(tbl.Fields, "json_timestamp_format")
+	delete(tbl.Fields, "json_nesting_separator")
+	delete(tbl.Fields, "json_batch_format")
 	delete(tbl.Fields, "splunkmetric_hec_routing")
-	return serializers.NewSerializer(c)
+	delete(tbl.Fields, "splunkmetric_multimetric")
+	delete(tbl.Fields, "carbon2_format")
+	delete(tbl.Fields, "carbon2_meta_tags")
+	delete(tbl.Fields, "wavefront_use_strict")
+	delete(tbl.Fields, "wavefront_source_override")
+	return c, nil
 }
 
 // buildOutput parses output specific items from the ast.Table,
@@ -1832,6 +3033,14 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		oc.Filter.NamePass = oc.Filter.FieldPass
 	}
 
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.Alias = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["flush_interval"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1869,9 +3078,124 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["buffer_directory"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferDirectory = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["dead_letter_file"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.DeadLetterFile = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_max_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			err := size.UnmarshalTOML([]byte(kv.Value.Source()))
+			if err != nil {
+				return nil, fmt.Errorf("invalid buffer_max_size: %v", err)
+			}
+			oc.BufferMaxSize = size.Size
+		}
+	}
+
+	if node, ok := tbl.Fields["metric_batch_bytes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			err := size.UnmarshalTOML([]byte(kv.Value.Source()))
+			if err != nil {
+				return nil, fmt.Errorf("invalid metric_batch_bytes: %v", err)
+			}
+			oc.MetricBatchBytes = size.Size
+		}
+	}
+
+	if node, ok := tbl.Fields["max_parallel_writes"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				if v < 1 {
+					return nil, fmt.Errorf("invalid max_parallel_writes: %d", v)
+				}
+				oc.MaxParallelWrites = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_batches_per_second"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var v float64
+			switch n := kv.Value.(type) {
+			case *ast.Integer:
+				i, err := n.Int()
+				if err != nil {
+					return nil, err
+				}
+				v = float64(i)
+			case *ast.Float:
+				f, err := n.Float()
+				if err != nil {
+					return nil, err
+				}
+				v = f
+			}
+			if v < 0 {
+				return nil, fmt.Errorf("invalid max_batches_per_second: %v", v)
+			}
+			oc.MaxBatchesPerSecond = v
+		}
+	}
+
+	if node, ok := tbl.Fields["max_bytes_per_second"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			err := size.UnmarshalTOML([]byte(kv.Value.Source()))
+			if err != nil {
+				return nil, fmt.Errorf("invalid max_bytes_per_second: %v", err)
+			}
+			oc.MaxBytesPerSecond = size.Size
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_overflow"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				switch str.Value {
+				case models.OverflowDropOldest, models.OverflowDropNewest, models.OverflowBlock:
+					oc.BufferOverflow = str.Value
+				default:
+					return nil, fmt.Errorf("invalid buffer_overflow %q", str.Value)
+				}
+			}
+		}
+	}
+
+	delete(tbl.Fields, "alias")
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "metric_batch_bytes")
+	delete(tbl.Fields, "max_parallel_writes")
+	delete(tbl.Fields, "max_batches_per_second")
+	delete(tbl.Fields, "max_bytes_per_second")
+	delete(tbl.Fields, "buffer_directory")
+	delete(tbl.Fields, "buffer_max_size")
+	delete(tbl.Fields, "dead_letter_file")
+	delete(tbl.Fields, "buffer_overflow")
+
+	oc.StartupErrorBehavior, err = buildStartupErrorBehavior(tbl)
+	if err != nil {
+		return nil, err
+	}
 
 	return oc, nil
 }
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/toml/ast"
+)
+
+// Deprecation is a deprecated plugin, or deprecated option of a plugin, that
+// is used in the config.
+type Deprecation struct {
+	telegraf.DeprecationInfo
+
+	// Plugin is the name of the plugin, ie: "inputs.tcp_listener".
+	Plugin string
+	// Option is the deprecated option, empty if the plugin is deprecated.
+	Option string
+}
+
+func (d Deprecation) String() string {
+	var b strings.Builder
+	b.WriteString(d.Plugin)
+	if d.Option != "" {
+		fmt.Fprintf(&b, " option %q", d.Option)
+	}
+	fmt.Fprintf(&b, " is deprecated since %s", d.Since)
+	if d.RemovalIn != "" {
+		fmt.Fprintf(&b, " and will be removed in %s", d.RemovalIn)
+	}
+	if d.Notice != "" {
+		fmt.Fprintf(&b, ", %s", d.Notice)
+	}
+	return b.String()
+}
+
+// checkDeprecations records the plugin if it is deprecated along with the
+// deprecated options set in the table, the options are taken from the
+// `deprecated:"since;removal;notice"` tag of the fields of the plugin.
+func (c *Config) checkDeprecations(
+	plugin string,
+	info *telegraf.DeprecationInfo,
+	p interface{},
+	tbl *ast.Table,
+) {
+	if info != nil {
+		c.addDeprecation(Deprecation{DeprecationInfo: *info, Plugin: plugin})
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(p))
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for key := range tbl.Fields {
+		field, ok := findField(v.Type(), key)
+		if !ok {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("deprecated")
+		if !ok {
+			continue
+		}
+		c.addDeprecation(Deprecation{
+			DeprecationInfo: parseDeprecationTag(tag),
+			Plugin:          plugin,
+			Option:          key,
+		})
+	}
+}
+
+func (c *Config) addDeprecation(d Deprecation) {
+	log.Printf("W! DeprecationWarning: %s", d)
+	c.Deprecations = append(c.Deprecations, d)
+}
+
+// findField returns the field the TOML key is decoded into, matching the
+// names the same way as the TOML decoder.
+func findField(t reflect.Type, key string) (reflect.StructField, bool) {
+	norm := normFieldName(key)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if f, ok := findField(field.Type, key); ok {
+				return f, true
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == key || (name == "" && normFieldName(field.Name) == norm) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func normFieldName(name string) string {
+	return strings.ToLower(strings.Replace(name, "_", "", -1))
+}
+
+// parseDeprecationTag parses a tag of the form "since;removal;notice", the
+// removal version may be omitted.
+func parseDeprecationTag(tag string) telegraf.DeprecationInfo {
+	parts := strings.SplitN(tag, ";", 3)
+	switch len(parts) {
+	case 3:
+		return telegraf.DeprecationInfo{Since: parts[0], RemovalIn: parts[1], Notice: parts[2]}
+	case 2:
+		return telegraf.DeprecationInfo{Since: parts[0], Notice: parts[1]}
+	default:
+		return telegraf.DeprecationInfo{Since: parts[0]}
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+type deprecatedPlugin struct {
+	Server     string
+	OldName    string `deprecated:"1.1.0;use 'server' instead"`
+	Timeout    int    `toml:"read_timeout" deprecated:"1.2.0;2.0.0;use 'timeout' instead"`
+	Unaffected string
+}
+
+func TestFindField(t *testing.T) {
+	typ := reflect.TypeOf(deprecatedPlugin{})
+
+	field, ok := findField(typ, "old_name")
+	require.True(t, ok)
+	require.Equal(t, "OldName", field.Name)
+
+	field, ok = findField(typ, "read_timeout")
+	require.True(t, ok)
+	require.Equal(t, "Timeout", field.Name)
+
+	_, ok = findField(typ, "timeout")
+	require.False(t, ok)
+}
+
+func TestParseDeprecationTag(t *testing.T) {
+	require.Equal(t, telegraf.DeprecationInfo{
+		Since:  "1.1.0",
+		Notice: "use 'server' instead",
+	}, parseDeprecationTag("1.1.0;use 'server' instead"))
+
+	require.Equal(t, telegraf.DeprecationInfo{
+		Since:     "1.2.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'timeout' instead; or not",
+	}, parseDeprecationTag("1.2.0;2.0.0;use 'timeout' instead; or not"))
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Kinds of plugins listed by ListPlugins.
+const (
+	PluginKindInput  = "input"
+	PluginKindOutput = "output"
+	PluginKindParser = "parser"
+)
+
+// PluginInfo describes an available plugin.
+type PluginInfo struct {
+	Kind         string                    `json:"kind"`
+	Name         string                    `json:"name"`
+	Description  string                    `json:"description"`
+	SampleConfig string                    `json:"sample_config"`
+	Service      bool                      `json:"service,omitempty"`
+	Deprecation  *telegraf.DeprecationInfo `json:"deprecation,omitempty"`
+}
+
+// ListPlugins returns the available plugins of the kinds, or of all kinds if
+// none are given, sorted by kind and name.
+func ListPlugins(kinds ...string) ([]PluginInfo, error) {
+	if len(kinds) == 0 {
+		kinds = []string{PluginKindInput, PluginKindOutput, PluginKindParser}
+	}
+
+	var plugins []PluginInfo
+	for _, kind := range kinds {
+		switch kind {
+		case PluginKindInput:
+			for name, creator := range inputs.Inputs {
+				input := creator()
+				_, service := input.(telegraf.ServiceInput)
+				plugins = append(plugins, PluginInfo{
+					Kind:         kind,
+					Name:         name,
+					Description:  input.Description(),
+					SampleConfig: input.SampleConfig(),
+					Service:      service,
+					Deprecation:  deprecation(inputs.Deprecations, name),
+				})
+			}
+		case PluginKindOutput:
+			for name, creator := range outputs.Outputs {
+				output := creator()
+				plugins = append(plugins, PluginInfo{
+					Kind:         kind,
+					Name:         name,
+					Description:  output.Description(),
+					SampleConfig: output.SampleConfig(),
+					Deprecation:  deprecation(outputs.Deprecations, name),
+				})
+			}
+		case PluginKindParser:
+			for name, description := range parsers.DataFormats {
+				plugins = append(plugins, PluginInfo{
+					Kind:         kind,
+					Name:         name,
+					Description:  description,
+					SampleConfig: fmt.Sprintf("\n  data_format = %q\n", name),
+				})
+			}
+		default:
+			return nil, fmt.Errorf("unknown plugin kind %q", kind)
+		}
+	}
+
+	sort.SliceStable(plugins, func(i, j int) bool {
+		if plugins[i].Kind != plugins[j].Kind {
+			return plugins[i].Kind < plugins[j].Kind
+		}
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins, nil
+}
+
+// PrintPlugins writes the available plugins of the kinds as JSON.
+func PrintPlugins(w io.Writer, kinds ...string) error {
+	plugins, err := ListPlugins(kinds...)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plugins)
+}
+
+func deprecation(deprecations map[string]telegraf.DeprecationInfo, name string) *telegraf.DeprecationInfo {
+	info, ok := deprecations[name]
+	if !ok {
+		return nil
+	}
+	return &info
+}
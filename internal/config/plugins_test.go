@@ -0,0 +1,73 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func findPlugin(plugins []PluginInfo, kind, name string) (PluginInfo, bool) {
+	for _, p := range plugins {
+		if p.Kind == kind && p.Name == name {
+			return p, true
+		}
+	}
+	return PluginInfo{}, false
+}
+
+func TestListPlugins(t *testing.T) {
+	plugins, err := ListPlugins()
+	require.NoError(t, err)
+
+	memcached, ok := findPlugin(plugins, PluginKindInput, "memcached")
+	require.True(t, ok)
+	require.NotEmpty(t, memcached.Description)
+	require.Contains(t, memcached.SampleConfig, "servers")
+	require.False(t, memcached.Service)
+	require.Nil(t, memcached.Deprecation)
+
+	tcp, ok := findPlugin(plugins, PluginKindInput, "tcp_listener")
+	require.True(t, ok)
+	require.True(t, tcp.Service)
+	require.NotNil(t, tcp.Deprecation)
+	require.Equal(t, "1.3.0", tcp.Deprecation.Since)
+
+	_, ok = findPlugin(plugins, PluginKindOutput, "file")
+	require.True(t, ok)
+
+	parser, ok := findPlugin(plugins, PluginKindParser, "json")
+	require.True(t, ok)
+	require.Contains(t, parser.SampleConfig, `data_format = "json"`)
+}
+
+func TestListPluginsKinds(t *testing.T) {
+	plugins, err := ListPlugins(PluginKindParser)
+	require.NoError(t, err)
+	require.NotEmpty(t, plugins)
+	for _, p := range plugins {
+		require.Equal(t, PluginKindParser, p.Kind)
+	}
+
+	_, err = ListPlugins("processor")
+	require.Error(t, err)
+}
+
+func TestPrintPlugins(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, PrintPlugins(&buf, PluginKindInput))
+
+	var plugins []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &plugins))
+	require.NotEmpty(t, plugins)
+	for _, p := range plugins {
+		if p["name"] == "tcp_listener" {
+			require.Equal(t, map[string]interface{}{
+				"since":      "1.3.0",
+				"removal_in": "2.0.0",
+				"notice":     "use 'inputs.socket_listener' instead",
+			}, p["deprecation"])
+		}
+	}
+}
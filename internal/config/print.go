@@ -0,0 +1,400 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// redacted replaces the secrets in the printed config.
+const redacted = "<redacted>"
+
+// maxPrintDepth limits how deep nested tables are printed, to stop on
+// structs referencing themselves.
+const maxPrintDepth = 8
+
+// sensitiveKeys are parts of the option names whose values are always
+// redacted.
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey",
+	"access_key", "private_key", "credential", "authorization", "auth",
+	"cookie",
+}
+
+// sensitiveTables are parts of the names of tables, such as the HTTP header
+// maps, whose values are all redacted.
+var sensitiveTables = []string{
+	"header",
+}
+
+var (
+	durationType         = reflect.TypeOf(time.Duration(0))
+	internalDurationType = reflect.TypeOf(internal.Duration{})
+	internalSizeType     = reflect.TypeOf(internal.Size{})
+	timeType             = reflect.TypeOf(time.Time{})
+)
+
+// option is a key of a table along with its value.
+type option struct {
+	key   string
+	value reflect.Value
+}
+
+// PrintConfig writes the effective configuration, the options of each
+// plugin after defaults, environment variables and secrets were applied.
+// Secrets, the values of options with sensitive names and URL passwords are
+// redacted.
+func (c *Config) PrintConfig(w io.Writer) error {
+	p := &configPrinter{}
+	for _, secret := range c.secrets {
+		if secret != "" {
+			p.secrets = append(p.secrets, secret)
+		}
+	}
+
+	p.table("global_tags", false, false, nil, reflect.ValueOf(c.Tags), 0)
+	p.table("agent", false, false, nil, reflect.ValueOf(c.Agent), 0)
+	for _, output := range c.Outputs {
+		p.table("outputs."+output.Config.Name, true, false,
+			outputOptions(output.Config), reflect.ValueOf(output.Output), 0)
+	}
+	for _, processor := range c.Processors {
+		p.table("processors."+processor.Config.Name, true, false,
+			processorOptions(processor.Config), reflect.ValueOf(processor.Processor), 0)
+	}
+	for _, aggregator := range c.Aggregators {
+		p.table("aggregators."+aggregator.Config.Name, true, false,
+			aggregatorOptions(aggregator.Config), reflect.ValueOf(aggregator.Aggregator), 0)
+	}
+	for _, input := range c.Inputs {
+		p.table("inputs."+input.Config.Name, true, false,
+			inputOptions(input.Config), reflect.ValueOf(input.Input), 0)
+	}
+
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+type configPrinter struct {
+	buf     bytes.Buffer
+	secrets []string
+}
+
+// table prints the options followed by the fields of the value, which is a
+// struct or a map, as a table.  The options which are tables themselves are
+// printed after the others.  All values of sensitive tables are redacted.
+func (p *configPrinter) table(name string, array, sensitive bool, options []option, v reflect.Value, depth int) {
+	options = append(options, fields(v)...)
+
+	var tables []option
+	var lines []string
+	for _, opt := range options {
+		if isTable(opt.value) {
+			tables = append(tables, opt)
+			continue
+		}
+		if value, ok := p.value(opt.key, opt.value, sensitive); ok {
+			lines = append(lines, fmt.Sprintf("  %s = %s\n", quoteKey(opt.key), value))
+		}
+	}
+
+	if depth > 0 && len(lines) == 0 && len(tables) == 0 {
+		return
+	}
+	if array {
+		fmt.Fprintf(&p.buf, "\n[[%s]]\n", name)
+	} else {
+		fmt.Fprintf(&p.buf, "\n[%s]\n", name)
+	}
+	for _, line := range lines {
+		p.buf.WriteString(line)
+	}
+
+	if depth >= maxPrintDepth {
+		return
+	}
+	for _, t := range tables {
+		v := indirect(t.value)
+		s := sensitive || matches(t.key, sensitiveTables)
+		if v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				p.table(name+"."+quoteKey(t.key), true, s, nil, v.Index(i), depth+1)
+			}
+			continue
+		}
+		p.table(name+"."+quoteKey(t.key), false, s, nil, v, depth+1)
+	}
+}
+
+// quoteKey quotes the key unless it is a valid bare key.
+func quoteKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '_' || r == '-') {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
+
+// value returns the TOML representation of a value which is not a table, it
+// returns false for values which can not be printed.
+func (p *configPrinter) value(key string, v reflect.Value, sensitive bool) (string, bool) {
+	v = indirect(v)
+	if !v.IsValid() {
+		return "", false
+	}
+
+	// The values of fields of unexported embedded structs can not be
+	// converted to interfaces, so the values are read by kind.
+	switch v.Type() {
+	case durationType:
+		return strconv.Quote(time.Duration(v.Int()).String()), true
+	case internalDurationType:
+		return strconv.Quote(time.Duration(v.Field(0).Int()).String()), true
+	case internalSizeType:
+		return strconv.FormatInt(v.Field(0).Int(), 10), true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(p.redact(key, v.String(), sensitive)), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		s := strconv.FormatFloat(v.Float(), 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s, true
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := p.value(key, v.Index(i), sensitive)
+			if !ok {
+				return "", false
+			}
+			elems = append(elems, elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]", true
+	}
+	return "", false
+}
+
+// redact replaces the secrets in the value of the option, the whole value
+// is redacted if the option is sensitive.
+func (p *configPrinter) redact(key, value string, sensitive bool) string {
+	if value == "" {
+		return value
+	}
+	if sensitive || matches(key, sensitiveKeys) {
+		return redacted
+	}
+	for _, secret := range p.secrets {
+		value = strings.Replace(value, secret, redacted, -1)
+	}
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+			value = u.String()
+		}
+	}
+	return value
+}
+
+// matches returns true if the lower cased name contains one of the parts.
+func matches(name string, parts []string) bool {
+	lower := strings.ToLower(name)
+	for _, part := range parts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// fields returns the options of a struct, from its exported fields and
+// those of its embedded structs, or of a map, sorted by key.
+func fields(v reflect.Value) []option {
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+
+	var options []option
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			options = append(options, option{fmt.Sprint(key), v.MapIndex(key)})
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Anonymous {
+				options = append(options, fields(v.Field(i))...)
+				continue
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+
+			name := strings.Split(field.Tag.Get("toml"), ",")[0]
+			switch name {
+			case "-":
+				continue
+			case "":
+				name = internal.SnakeCase(field.Name)
+			}
+			options = append(options, option{name, v.Field(i)})
+		}
+	}
+	return options
+}
+
+// isTable returns true if the value is printed as a table.
+func isTable(v reflect.Value) bool {
+	v = indirect(v)
+	if !v.IsValid() {
+		return false
+	}
+	switch v.Type() {
+	case durationType, internalDurationType, internalSizeType, timeType:
+		return false
+	}
+
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice, reflect.Array:
+		elem := v.Type().Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		return elem.Kind() == reflect.Struct && elem != internalDurationType
+	}
+	return false
+}
+
+// indirect follows pointers and interfaces, it returns an invalid value for
+// nil ones.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// appendOption appends the option if it is set.
+func appendOption(options []option, key string, value interface{}) []option {
+	v := reflect.ValueOf(value)
+	if v.IsValid() && !isZero(v) {
+		options = append(options, option{key, v})
+	}
+	return options
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		return v.Len() == 0
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+func filterOptions(options []option, f models.Filter) []option {
+	options = appendOption(options, "namepass", f.NamePass)
+	options = appendOption(options, "namedrop", f.NameDrop)
+	options = appendOption(options, "fieldpass", f.FieldPass)
+	options = appendOption(options, "fielddrop", f.FieldDrop)
+	options = appendOption(options, "taginclude", f.TagInclude)
+	options = appendOption(options, "tagexclude", f.TagExclude)
+	options = appendOption(options, "tagpass", tagFilterMap(f.TagPass))
+	options = appendOption(options, "tagdrop", tagFilterMap(f.TagDrop))
+	return options
+}
+
+func tagFilterMap(filters []models.TagFilter) map[string][]string {
+	m := make(map[string][]string, len(filters))
+	for _, f := range filters {
+		m[f.Name] = f.Filter
+	}
+	return m
+}
+
+func inputOptions(c *models.InputConfig) []option {
+	var options []option
+	options = appendOption(options, "alias", c.Alias)
+	options = appendOption(options, "interval", c.Interval)
+	options = appendOption(options, "collection_jitter", c.CollectionJitter)
+	options = appendOption(options, "gather_timeout", c.GatherTimeout)
+	options = appendOption(options, "precision", c.Precision)
+	options = appendOption(options, "name_override", c.NameOverride)
+	options = appendOption(options, "name_prefix", c.MeasurementPrefix)
+	options = appendOption(options, "name_suffix", c.MeasurementSuffix)
+	options = appendOption(options, "outputs", c.Outputs)
+	options = appendOption(options, "startup_error_behavior", c.StartupErrorBehavior)
+	options = appendOption(options, "max_series", c.MaxSeries)
+	options = appendOption(options, "series_overflow", c.SeriesOverflow)
+	options = filterOptions(options, c.Filter)
+	return appendOption(options, "tags", c.Tags)
+}
+
+func outputOptions(c *models.OutputConfig) []option {
+	var options []option
+	options = appendOption(options, "alias", c.Alias)
+	options = appendOption(options, "flush_interval", c.FlushInterval)
+	options = appendOption(options, "metric_batch_size", c.MetricBatchSize)
+	options = appendOption(options, "metric_batch_bytes", c.MetricBatchBytes)
+	options = appendOption(options, "metric_buffer_limit", c.MetricBufferLimit)
+	options = appendOption(options, "max_parallel_writes", c.MaxParallelWrites)
+	options = appendOption(options, "max_batches_per_second", c.MaxBatchesPerSecond)
+	options = appendOption(options, "max_bytes_per_second", c.MaxBytesPerSecond)
+	options = appendOption(options, "buffer_directory", c.BufferDirectory)
+	options = appendOption(options, "buffer_max_size", c.BufferMaxSize)
+	options = appendOption(options, "buffer_overflow", c.BufferOverflow)
+	options = appendOption(options, "dead_letter_file", c.DeadLetterFile)
+	options = appendOption(options, "startup_error_behavior", c.StartupErrorBehavior)
+	return filterOptions(options, c.Filter)
+}
+
+func processorOptions(c *models.ProcessorConfig) []option {
+	var options []option
+	options = appendOption(options, "order", c.Order)
+	return filterOptions(options, c.Filter)
+}
+
+func aggregatorOptions(c *models.AggregatorConfig) []option {
+	var options []option
+	options = appendOption(options, "period", c.Period)
+	options = appendOption(options, "delay", c.Delay)
+	options = appendOption(options, "drop_original", c.DropOriginal)
+	options = appendOption(options, "name_override", c.NameOverride)
+	options = appendOption(options, "name_prefix", c.MeasurementPrefix)
+	options = appendOption(options, "name_suffix", c.MeasurementSuffix)
+	options = filterOptions(options, c.Filter)
+	return appendOption(options, "tags", c.Tags)
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/influxdata/telegraf/internal/tls"
+)
+
+const defaultRemoteTimeout = 30 * time.Second
+
+// RemoteConfig holds the settings used to load configuration from remote
+// sources, which are http, https and s3 URLs.
+//
+// Credentials are taken from the environment: INFLUX_TOKEN is sent as a
+// token, TELEGRAF_CONFIG_USERNAME and TELEGRAF_CONFIG_PASSWORD are sent as
+// basic auth, and s3 uses the default AWS credentials.
+type RemoteConfig struct {
+	tls.ClientConfig
+
+	// CacheDirectory keeps a copy of the last configuration that loaded
+	// successfully from each source, the copy is used when the source can
+	// not be reached or returns an invalid configuration.
+	CacheDirectory string
+
+	Timeout time.Duration
+}
+
+// remoteSource is the version of a remote configuration that was loaded.
+type remoteSource struct {
+	etag string
+	sum  [sha256.Size]byte
+}
+
+// remoteResponse is the result of fetching a remote configuration.
+type remoteResponse struct {
+	data        []byte
+	etag        string
+	notModified bool
+}
+
+// isRemote returns true if the path is a URL of a remote source.
+func isRemote(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+
+	switch u.Scheme {
+	case "http", "https", "s3":
+		return true
+	}
+	return false
+}
+
+// fetchRemote fetches the configuration at the URL, if the etag is set the
+// configuration is only returned if it has a different etag.
+func (c *Config) fetchRemote(u *url.URL, etag string) (*remoteResponse, error) {
+	switch u.Scheme {
+	case "s3":
+		return fetchS3(u, etag)
+	default:
+		return c.fetchHTTP(u, etag)
+	}
+}
+
+func (c *Config) fetchHTTP(u *url.URL, etag string) (*remoteResponse, error) {
+	tlsCfg, err := c.Remote.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := c.Remote.Timeout
+	if timeout == 0 {
+		timeout = defaultRemoteTimeout
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: timeout,
+	}
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := os.LookupEnv("INFLUX_TOKEN"); ok {
+		req.Header.Add("Authorization", "Token "+v)
+	}
+	if username, ok := os.LookupEnv("TELEGRAF_CONFIG_USERNAME"); ok {
+		req.SetBasicAuth(username, os.Getenv("TELEGRAF_CONFIG_PASSWORD"))
+	}
+	req.Header.Add("Accept", "application/toml")
+	if etag != "" {
+		req.Header.Add("If-None-Match", etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return &remoteResponse{etag: etag, notModified: true}, nil
+	default:
+		return nil, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteResponse{data: data, etag: resp.Header.Get("ETag")}, nil
+}
+
+// fetchS3 fetches the object of an s3://bucket/key URL, the region can be
+// set with the region query parameter.
+func fetchS3(u *url.URL, etag string) (*remoteResponse, error) {
+	cfg := aws.Config{}
+	if region := u.Query().Get("region"); region != "" {
+		cfg.Region = aws.String(region)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(u.Host),
+		Key:    aws.String(strings.TrimPrefix(u.Path, "/")),
+	}
+	if etag != "" {
+		input.IfNoneMatch = aws.String(etag)
+	}
+
+	out, err := s3.New(sess).GetObject(input)
+	if err, ok := err.(awserr.RequestFailure); ok && err.StatusCode() == http.StatusNotModified {
+		return &remoteResponse{etag: etag, notModified: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve remote config: %v", err)
+	}
+	defer out.Body.Close()
+
+	data, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteResponse{data: data, etag: aws.StringValue(out.ETag)}, nil
+}
+
+// RemoteChanged returns true if any of the loaded remote configurations
+// has changed since it was loaded or seen by the previous call.
+func (c *Config) RemoteChanged() (bool, error) {
+	changed := false
+	for path, source := range c.sources {
+		u, err := url.Parse(path)
+		if err != nil {
+			return false, err
+		}
+
+		resp, err := c.fetchRemote(u, source.etag)
+		if err != nil {
+			return false, fmt.Errorf("could not check %s for changes: %v", path, err)
+		}
+		if resp.notModified {
+			continue
+		}
+		if sum := sha256.Sum256(resp.data); sum != source.sum {
+			source.sum = sum
+			source.etag = resp.etag
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// cacheFile returns the path of the cached copy of a remote configuration.
+func (c *Config) cacheFile(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(c.Remote.CacheDirectory, hex.EncodeToString(sum[:])+".conf")
+}
+
+func (c *Config) readCache(path string) ([]byte, error) {
+	if c.Remote.CacheDirectory == "" {
+		return nil, fmt.Errorf("no cache directory")
+	}
+	return ioutil.ReadFile(c.cacheFile(path))
+}
+
+func (c *Config) writeCache(path string, data []byte) error {
+	if c.Remote.CacheDirectory == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(c.Remote.CacheDirectory, 0700)
+	if err != nil {
+		return err
+	}
+
+	// The configuration may hold credentials.
+	filename := c.cacheFile(path)
+	tmpfile := filename + ".tmp"
+	err = ioutil.WriteFile(tmpfile, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpfile, filename)
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type remoteServer struct {
+	sync.Mutex
+	config string
+	etag   string
+	fail   bool
+	auth   string
+}
+
+func (s *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s.auth = r.Header.Get("Authorization")
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Write([]byte(s.config))
+}
+
+func (s *remoteServer) set(config, etag string) {
+	s.Lock()
+	defer s.Unlock()
+	s.config = config
+	s.etag = etag
+}
+
+func TestConfig_LoadRemote(t *testing.T) {
+	s := &remoteServer{}
+	s.set("[agent]\n  interval = \"5s\"\n", `"1"`)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c := NewConfig()
+	err := c.LoadConfig(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, c.Agent.Interval.Duration)
+
+	changed, err := c.RemoteChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	s.set("[agent]\n  interval = \"10s\"\n", `"2"`)
+	changed, err = c.RemoteChanged()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// A change is only reported once.
+	changed, err = c.RemoteChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestConfig_LoadRemoteBasicAuth(t *testing.T) {
+	s := &remoteServer{}
+	s.set("[agent]\n", "")
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	os.Setenv("TELEGRAF_CONFIG_USERNAME", "telegraf")
+	os.Setenv("TELEGRAF_CONFIG_PASSWORD", "secret")
+	defer os.Unsetenv("TELEGRAF_CONFIG_USERNAME")
+	defer os.Unsetenv("TELEGRAF_CONFIG_PASSWORD")
+
+	c := NewConfig()
+	err := c.LoadConfig(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "Basic dGVsZWdyYWY6c2VjcmV0", s.auth)
+}
+
+func TestConfig_LoadRemoteCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote_config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &remoteServer{}
+	s.set("[agent]\n  interval = \"5s\"\n", "")
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c := NewConfig()
+	c.Remote.CacheDirectory = dir
+	err = c.LoadConfig(ts.URL)
+	require.NoError(t, err)
+
+	s.Lock()
+	s.fail = true
+	s.Unlock()
+
+	c = NewConfig()
+	c.Remote.CacheDirectory = dir
+	err = c.LoadConfig(ts.URL)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, c.Agent.Interval.Duration)
+
+	_, err = c.RemoteChanged()
+	assert.Error(t, err)
+}
+
+func TestConfig_LoadRemoteNoCache(t *testing.T) {
+	s := &remoteServer{fail: true}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c := NewConfig()
+	err := c.LoadConfig(ts.URL)
+	assert.Error(t, err)
+}
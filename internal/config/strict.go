@@ -0,0 +1,310 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/toml/ast"
+)
+
+// optionKinds are the kinds of values expected by the options handled by the
+// config itself instead of by the plugins.
+var optionKinds = map[string]string{
+	"alias":                           "string",
+	"buffer_directory":                "string",
+	"buffer_overflow":                 "string",
+	"collection_jitter":               "string",
+	"data_format":                     "string",
+	"data_type":                       "string",
+	"dead_letter_file":                "string",
+	"delay":                           "string",
+	"drop_original":                   "boolean",
+	"flush_interval":                  "string",
+	"gather_timeout":                  "string",
+	"inherit_tls":                     "boolean",
+	"interval":                        "string",
+	"log_level":                       "string",
+	"max_batches_per_second":          "number",
+	"max_bytes_per_second":            "size",
+	"max_parallel_writes":             "integer",
+	"max_series":                      "integer",
+	"metric_batch_size":               "integer",
+	"metric_buffer_limit":             "integer",
+	"name_override":                   "string",
+	"name_prefix":                     "string",
+	"name_suffix":                     "string",
+	"namedrop":                        "array",
+	"namepass":                        "array",
+	"order":                           "integer",
+	"period":                          "string",
+	"precision":                       "string",
+	"separator":                       "string",
+	"series_overflow":                 "string",
+	"startup_error_behavior":          "string",
+	"tag_keys":                        "array",
+	"tagdrop":                         "table",
+	"tagexclude":                      "array",
+	"taginclude":                      "array",
+	"tagpass":                         "table",
+	"tags":                            "table",
+	"templates":                       "array",
+	"carbon2_format":                  "string",
+	"carbon2_meta_tags":               "array",
+	"collectd_auth_file":              "string",
+	"collectd_parse_multivalue":       "string",
+	"collectd_security_level":         "string",
+	"collectd_typesdb":                "array",
+	"csv_column_names":                "array",
+	"csv_column_types":                "array",
+	"csv_comment":                     "string",
+	"csv_delimiter":                   "string",
+	"csv_header_row_count":            "integer",
+	"csv_measurement_column":          "string",
+	"csv_skip_columns":                "integer",
+	"csv_skip_rows":                   "integer",
+	"csv_tag_columns":                 "array",
+	"csv_timestamp_column":            "string",
+	"csv_timestamp_format":            "string",
+	"csv_trim_space":                  "boolean",
+	"dropwizard_metric_registry_path": "string",
+	"dropwizard_tag_paths":            "table",
+	"dropwizard_tags_path":            "string",
+	"dropwizard_time_format":          "string",
+	"dropwizard_time_path":            "string",
+	"graphite_separator":              "string",
+	"graphite_tag_sanitize_mode":      "string",
+	"graphite_tag_support":            "boolean",
+	"grok_custom_pattern_files":       "array",
+	"grok_custom_patterns":            "string",
+	"grok_named_patterns":             "array",
+	"grok_patterns":                   "array",
+	"grok_timezone":                   "string",
+	"grok_unique_timestamp":           "string",
+	"html_column_names":               "array",
+	"html_table_selector":             "string",
+	"html_tag_columns":                "array",
+	"html_timestamp_column":           "string",
+	"html_timestamp_format":           "string",
+	"influx_max_line_bytes":           "integer",
+	"influx_sort_fields":              "boolean",
+	"influx_uint_support":             "boolean",
+	"json_batch_format":               "string",
+	"json_name_key":                   "string",
+	"json_nesting_separator":          "string",
+	"json_query":                      "string",
+	"json_string_fields":              "array",
+	"json_time_format":                "string",
+	"json_time_key":                   "string",
+	"json_timestamp_format":           "string",
+	"json_timestamp_key":              "string",
+	"json_timestamp_units":            "string",
+	"json_timezone":                   "string",
+	"prefix":                          "string",
+	"regex_patterns":                  "array",
+	"regex_timestamp_format":          "string",
+	"regex_timestamp_group":           "string",
+	"regex_timezone":                  "string",
+	"regex_types":                     "table",
+	"splunkmetric_hec_routing":        "boolean",
+	"splunkmetric_multimetric":        "boolean",
+	"template":                        "string",
+	"unit_fields":                     "array",
+	"wavefront_source_override":       "array",
+	"wavefront_use_strict":            "boolean",
+}
+
+// formatPrefixes are the prefixes of the parser and serializer options that
+// are only used with one data_format.
+var formatPrefixes = []string{
+	"carbon2", "collectd", "csv", "dropwizard", "graphite", "grok", "html",
+	"influx", "json", "regex", "splunkmetric", "wavefront",
+}
+
+// tableOptions returns a copy of the options in the table, it must be taken
+// before the options handled by the config are removed from the table.
+func tableOptions(tbl *ast.Table) map[string]interface{} {
+	options := make(map[string]interface{}, len(tbl.Fields))
+	for key, val := range tbl.Fields {
+		options[key] = val
+	}
+	return options
+}
+
+// checkStrict validates the options of a plugin when strict_config is
+// enabled.  The options removed from the table since the copy was taken are
+// checked for their kind and data_format, the options left in the table must
+// be fields of the plugin.
+func (c *Config) checkStrict(
+	plugin string,
+	p interface{},
+	options map[string]interface{},
+	tbl *ast.Table,
+) error {
+	if !c.Agent.StrictConfig {
+		return nil
+	}
+
+	dataFormat := "influx"
+	if plugin == "inputs.exec" {
+		dataFormat = "json"
+	}
+	if kind, value := optionValue(options["data_format"]); kind == "string" {
+		dataFormat = value
+	}
+
+	var errs []string
+	for key, val := range options {
+		if _, ok := tbl.Fields[key]; ok {
+			continue
+		}
+		if want, ok := optionKinds[key]; ok {
+			if kind, _ := optionValue(val); !kindMatches(want, kind) {
+				errs = append(errs, fmt.Sprintf("option %q must be a %s, got %s",
+					key, want, kind))
+				continue
+			}
+		}
+		for _, prefix := range formatPrefixes {
+			if strings.HasPrefix(key, prefix+"_") && prefix != dataFormat {
+				errs = append(errs, fmt.Sprintf(
+					"option %q is only used with data_format %q", key, prefix))
+			}
+		}
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(p))
+	if v.Kind() == reflect.Struct {
+		for key := range tbl.Fields {
+			if _, ok := findField(v.Type(), key); ok {
+				continue
+			}
+			msg := fmt.Sprintf("unknown option %q", key)
+			if s := suggest(key, fieldNames(v.Type(), options)); s != "" {
+				msg += fmt.Sprintf(", did you mean %q?", s)
+			}
+			errs = append(errs, msg)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(errs)
+	return fmt.Errorf("%s: %s", plugin, strings.Join(errs, "; "))
+}
+
+// kindMatches returns true if a value of the kind is accepted where the want
+// kind is expected.  A number is an integer or a float, a size is an integer
+// or a string such as "1MB".
+func kindMatches(want, kind string) bool {
+	switch want {
+	case "number":
+		return kind == "integer" || kind == "float"
+	case "size":
+		return kind == "integer" || kind == "string"
+	}
+	return kind == want
+}
+
+// optionValue returns the kind of the option and its value if it is a
+// string.
+func optionValue(node interface{}) (string, string) {
+	switch node := node.(type) {
+	case *ast.Table, []*ast.Table:
+		return "table", ""
+	case *ast.KeyValue:
+		switch value := node.Value.(type) {
+		case *ast.String:
+			return "string", value.Value
+		case *ast.Integer:
+			return "integer", ""
+		case *ast.Float:
+			return "float", ""
+		case *ast.Boolean:
+			return "boolean", ""
+		case *ast.Array:
+			return "array", ""
+		case *ast.Datetime:
+			return "datetime", ""
+		}
+	}
+	return "invalid value", ""
+}
+
+// fieldNames returns the option names of the fields of the plugin, along
+// with the options handled by the config which were set.
+func fieldNames(t reflect.Type, options map[string]interface{}) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			names = append(names, fieldNames(field.Type, nil)...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = internal.SnakeCase(field.Name)
+		}
+		names = append(names, name)
+	}
+	for key := range optionKinds {
+		if _, ok := options[key]; !ok {
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+// suggest returns the name closest to the key, or an empty string if none
+// of the names is close enough to be a likely typo.
+func suggest(key string, names []string) string {
+	best, bestDist := "", len(key)/3+1
+	sort.Strings(names)
+	for _, name := range names {
+		if d := editDistance(key, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between the strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	names := []string{"exclude_query", "include_query", "servers", "timeout"}
+	require.Equal(t, "exclude_query", suggest("exclud_query", names))
+	require.Equal(t, "servers", suggest("server", names))
+	require.Equal(t, "timeout", suggest("timout", names))
+	require.Equal(t, "", suggest("database", names))
+}
+
+func TestEditDistance(t *testing.T) {
+	require.Equal(t, 0, editDistance("interval", "interval"))
+	require.Equal(t, 1, editDistance("intervl", "interval"))
+	require.Equal(t, 2, editDistance("tagpas", "tagpass_"))
+	require.Equal(t, 3, editDistance("", "abc"))
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/toml/ast"
+)
+
+var (
+	clientConfigType = reflect.TypeOf(tls.ClientConfig{})
+	serverConfigType = reflect.TypeOf(tls.ServerConfig{})
+)
+
+// secureSchemes are the URL schemes of TLS connections.
+var secureSchemes = []string{"https://", "tls://", "ssl://", "wss://", "amqps://"}
+
+// buildInheritTLS parses the inherit_tls option of a plugin, the TLS
+// settings of the agent are inherited unless it is set to false.
+func buildInheritTLS(tbl *ast.Table) (bool, error) {
+	inherit := true
+	if node, ok := tbl.Fields["inherit_tls"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				var err error
+				inherit, err = b.Boolean()
+				if err != nil {
+					return false, fmt.Errorf("invalid inherit_tls: %v", err)
+				}
+			}
+		}
+	}
+	delete(tbl.Fields, "inherit_tls")
+	return inherit, nil
+}
+
+// inheritTLS sets the TLS settings of the agent on the TLS configs of the
+// plugin, for the settings not set by the plugin.  Many plugins use TLS as
+// soon as a client setting is set, so client configs only inherit when the
+// plugin already uses TLS: a client setting is set or a URL of the plugin has
+// a TLS scheme.
+func (c *Config) inheritTLS(p interface{}) {
+	if c.Agent.TLS == nil {
+		return
+	}
+	client := &tls.ClientConfig{
+		TLSCA:         c.Agent.TLS.TLSCA,
+		TLSCert:       c.Agent.TLS.TLSCert,
+		TLSKey:        c.Agent.TLS.TLSKey,
+		TLSMinVersion: c.Agent.TLS.TLSMinVersion,
+	}
+	server := &tls.ServerConfig{
+		TLSMinVersion: c.Agent.TLS.TLSMinVersion,
+	}
+	secure := hasSecureURL(reflect.ValueOf(p))
+	inheritTLSValue(reflect.ValueOf(p), client, server, secure)
+}
+
+// inheritTLSValue walks the value looking for TLS configs, including those
+// of embedded and nested structs but not behind pointers.
+func inheritTLSValue(v reflect.Value, client *tls.ClientConfig, server *tls.ServerConfig, secure bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	switch v.Type() {
+	case clientConfigType:
+		if v.CanAddr() {
+			cc := v.Addr().Interface().(*tls.ClientConfig)
+			if secure || clientTLSSet(cc) {
+				cc.Inherit(client)
+			}
+		}
+		return
+	case serverConfigType:
+		if v.CanAddr() {
+			v.Addr().Interface().(*tls.ServerConfig).Inherit(server)
+		}
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).PkgPath != "" {
+			continue
+		}
+		if field := v.Field(i); field.Kind() == reflect.Struct {
+			inheritTLSValue(field, client, server, secure)
+		}
+	}
+}
+
+// clientTLSSet returns true if any setting of the client config is set.
+func clientTLSSet(c *tls.ClientConfig) bool {
+	return c.TLSCA != "" || c.TLSCert != "" || c.TLSKey != "" ||
+		c.TLSMinVersion != "" || c.InsecureSkipVerify ||
+		c.SSLCA != "" || c.SSLCert != "" || c.SSLKey != ""
+}
+
+// hasSecureURL returns true if a string of the value, including those of
+// slices and of embedded and nested structs, is a URL with a TLS scheme.
+func hasSecureURL(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return false
+		}
+		return hasSecureURL(v.Elem())
+	case reflect.String:
+		s := strings.ToLower(v.String())
+		for _, scheme := range secureSchemes {
+			if strings.HasPrefix(s, scheme) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if hasSecureURL(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if hasSecureURL(v.Field(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package httpconfig
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ProxyConfig represents the standard proxy config of HTTP plugins.
+type ProxyConfig struct {
+	HTTPProxyURL   string `toml:"http_proxy_url"`
+	UseSystemProxy bool   `toml:"use_system_proxy"`
+}
+
+// Proxy returns the proxy function to use in a http.Transport.  A configured
+// proxy URL overrides the system proxy, which is read from the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables.  The returned function is
+// nil, for no proxy, if neither is set.
+func (c *ProxyConfig) Proxy() (func(*http.Request) (*url.URL, error), error) {
+	if c.HTTPProxyURL != "" {
+		proxyURL, err := url.Parse(c.HTTPProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing http_proxy_url %q: %v", c.HTTPProxyURL, err)
+		}
+		return http.ProxyURL(proxyURL), nil
+	}
+
+	if c.UseSystemProxy {
+		return http.ProxyFromEnvironment, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,51 @@
+package httpconfig_test
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxy(t *testing.T) {
+	req := &http.Request{URL: &url.URL{Scheme: "http", Host: "example.com"}}
+
+	c := httpconfig.ProxyConfig{HTTPProxyURL: "http://proxy.example.com:3128"}
+	proxy, err := c.Proxy()
+	require.NoError(t, err)
+	u, err := proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy.example.com:3128", u.String())
+
+	c = httpconfig.ProxyConfig{}
+	proxy, err = c.Proxy()
+	require.NoError(t, err)
+	require.Nil(t, proxy)
+
+	c = httpconfig.ProxyConfig{UseSystemProxy: true}
+	proxy, err = c.Proxy()
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+
+	c = httpconfig.ProxyConfig{HTTPProxyURL: "://bad"}
+	_, err = c.Proxy()
+	require.Error(t, err)
+}
+
+func TestProxyOverridesSystemProxy(t *testing.T) {
+	os.Setenv("HTTP_PROXY", "http://system.example.com:3128")
+	defer os.Unsetenv("HTTP_PROXY")
+
+	c := httpconfig.ProxyConfig{
+		HTTPProxyURL:   "http://proxy.example.com:3128",
+		UseSystemProxy: true,
+	}
+	proxy, err := c.Proxy()
+	require.NoError(t, err)
+	u, err := proxy(&http.Request{URL: &url.URL{Scheme: "http", Host: "example.com"}})
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy.example.com:3128", u.String())
+}
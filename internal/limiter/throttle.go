@@ -0,0 +1,34 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle spaces out work so that on average no more than rate units are
+// done per second.  Unlike the rate limiter it has no goroutine and the units
+// of a single call may exceed the rate, the next calls then wait longer.
+type Throttle struct {
+	mu   sync.Mutex
+	rate float64
+	next time.Time // earliest time of the next call
+}
+
+// NewThrottle returns a throttle of rate units per second.
+func NewThrottle(rate float64) *Throttle {
+	return &Throttle{rate: rate}
+}
+
+// Wait blocks until n units can be done.
+func (t *Throttle) Wait(n float64) {
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	at := t.next
+	t.next = t.next.Add(time.Duration(n / t.rate * float64(time.Second)))
+	t.mu.Unlock()
+
+	time.Sleep(at.Sub(now))
+}
@@ -0,0 +1,250 @@
+// Package metadata resolves facts about the host Telegraf runs on, such as
+// the cloud instance and the operating system, to be used as tags.
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/host"
+)
+
+// Provider resolves the metadata tags of one source.
+type Provider func(ctx context.Context, client *http.Client) (map[string]string, error)
+
+// The endpoints of the cloud metadata services, they are variables so they
+// can be replaced in tests.
+var (
+	ec2TokenURL    = "http://169.254.169.254/latest/api/token"
+	ec2IdentityURL = "http://169.254.169.254/latest/dynamic/instance-identity/document"
+	azureURL       = "http://169.254.169.254/metadata/instance/compute?api-version=2019-06-01"
+	gcpURL         = "http://metadata.google.internal/computeMetadata/v1/instance/?recursive=true"
+)
+
+// Providers are the available metadata providers by name.
+var Providers = map[string]Provider{
+	"ec2":   EC2,
+	"azure": Azure,
+	"gcp":   GCP,
+	"os":    OS,
+}
+
+// cloudProviders are the providers of which only the first to succeed is
+// used, a host runs on a single cloud.
+var cloudProviders = map[string]bool{
+	"ec2":   true,
+	"azure": true,
+	"gcp":   true,
+}
+
+// DefaultProviders are the providers used when none are configured.
+var DefaultProviders = []string{"ec2", "azure", "gcp", "os"}
+
+// Resolver resolves the metadata of a list of providers.
+type Resolver struct {
+	providers []string
+	client    *http.Client
+}
+
+// NewResolver returns a resolver for the providers, each request to a
+// metadata service is limited to the timeout.
+func NewResolver(providers []string, timeout time.Duration) (*Resolver, error) {
+	if len(providers) == 0 {
+		providers = DefaultProviders
+	}
+	for _, name := range providers {
+		if _, ok := Providers[name]; !ok {
+			return nil, fmt.Errorf("unknown metadata provider %q", name)
+		}
+	}
+	return &Resolver{
+		providers: providers,
+		client: &http.Client{
+			Timeout: timeout,
+			// The metadata services are link-local, a proxy would only get
+			// in the way.
+			Transport: &http.Transport{Proxy: nil},
+		},
+	}, nil
+}
+
+// Resolve returns the tags of all providers that succeeded, along with the
+// errors of those that failed.  Once a cloud provider succeeded the others
+// are skipped.
+func (r *Resolver) Resolve(ctx context.Context) (map[string]string, []error) {
+	tags := make(map[string]string)
+	var errs []error
+	cloud := false
+	for _, name := range r.providers {
+		if cloud && cloudProviders[name] {
+			continue
+		}
+		t, err := Providers[name](ctx, r.client)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		if cloudProviders[name] {
+			cloud = true
+		}
+		for k, v := range t {
+			if v != "" {
+				tags[k] = v
+			}
+		}
+	}
+	return tags, errs
+}
+
+// EC2 resolves the instance identity of an Amazon EC2 instance, using
+// IMDSv2.
+func EC2(ctx context.Context, client *http.Client) (map[string]string, error) {
+	req, err := http.NewRequest("PUT", ec2TokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := get(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = http.NewRequest("GET", ec2IdentityURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	body, err := get(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"cloud_provider":    "ec2",
+		"instance_id":       doc.InstanceID,
+		"instance_type":     doc.InstanceType,
+		"region":            doc.Region,
+		"availability_zone": doc.AvailabilityZone,
+	}, nil
+}
+
+// Azure resolves the compute metadata of an Azure virtual machine.
+func Azure(ctx context.Context, client *http.Client) (map[string]string, error) {
+	req, err := http.NewRequest("GET", azureURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	body, err := get(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		VMID     string `json:"vmId"`
+		VMSize   string `json:"vmSize"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"cloud_provider":    "azure",
+		"instance_id":       doc.VMID,
+		"instance_type":     doc.VMSize,
+		"region":            doc.Location,
+		"availability_zone": doc.Zone,
+	}, nil
+}
+
+// GCP resolves the instance metadata of a Google Compute Engine instance.
+func GCP(ctx context.Context, client *http.Client) (map[string]string, error) {
+	req, err := http.NewRequest("GET", gcpURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	body, err := get(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		ID          json.Number `json:"id"`
+		MachineType string      `json:"machineType"`
+		Zone        string      `json:"zone"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	// The zone and machine type are resource paths such as
+	// "projects/123/zones/us-central1-a", the region is the zone without
+	// its last part.
+	zone := lastPart(doc.Zone)
+	region := ""
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+	return map[string]string{
+		"cloud_provider":    "gcp",
+		"instance_id":       doc.ID.String(),
+		"instance_type":     lastPart(doc.MachineType),
+		"region":            region,
+		"availability_zone": zone,
+	}, nil
+}
+
+// OS resolves the operating system of the host.
+func OS(ctx context.Context, client *http.Client) (map[string]string, error) {
+	tags := map[string]string{
+		"os":   runtime.GOOS,
+		"arch": runtime.GOARCH,
+	}
+	info, err := host.Info()
+	if err != nil {
+		return tags, nil
+	}
+	tags["platform"] = info.Platform
+	tags["platform_version"] = info.PlatformVersion
+	tags["kernel_version"] = info.KernelVersion
+	return tags, nil
+}
+
+// get does the request and returns the body of a successful response.
+func get(ctx context.Context, client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP status %s", req.URL, resp.Status)
+	}
+	return body, nil
+}
+
+func lastPart(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
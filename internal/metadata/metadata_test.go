@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEC2(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/api/token":
+			require.Equal(t, "PUT", r.Method)
+			w.Write([]byte("token"))
+		case "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"instanceId": "i-1234567890abcdef0", "instanceType": "t2.micro",
+				"region": "us-west-2", "availabilityZone": "us-west-2b"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	defer setURL(&ec2TokenURL, ts.URL+"/latest/api/token")()
+	defer setURL(&ec2IdentityURL, ts.URL+"/latest/dynamic/instance-identity/document")()
+
+	tags, err := EC2(context.Background(), ts.Client())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"cloud_provider":    "ec2",
+		"instance_id":       "i-1234567890abcdef0",
+		"instance_type":     "t2.micro",
+		"region":            "us-west-2",
+		"availability_zone": "us-west-2b",
+	}, tags)
+}
+
+func TestAzure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "true", r.Header.Get("Metadata"))
+		w.Write([]byte(`{"vmId": "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+			"vmSize": "Standard_A3", "location": "westus", "zone": ""}`))
+	}))
+	defer ts.Close()
+	defer setURL(&azureURL, ts.URL)()
+
+	tags, err := Azure(context.Background(), ts.Client())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"cloud_provider":    "azure",
+		"instance_id":       "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+		"instance_type":     "Standard_A3",
+		"region":            "westus",
+		"availability_zone": "",
+	}, tags)
+}
+
+func TestGCP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		w.Write([]byte(`{"id": 4520031799277581759,
+			"machineType": "projects/123/machineTypes/n1-standard-1",
+			"zone": "projects/123/zones/us-central1-a"}`))
+	}))
+	defer ts.Close()
+	defer setURL(&gcpURL, ts.URL)()
+
+	tags, err := GCP(context.Background(), ts.Client())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"cloud_provider":    "gcp",
+		"instance_id":       "4520031799277581759",
+		"instance_type":     "n1-standard-1",
+		"region":            "us-central1",
+		"availability_zone": "us-central1-a",
+	}, tags)
+}
+
+func TestResolveFirstCloud(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+	azure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"vmId": "vm", "vmSize": "Standard_A3", "location": "westus"}`))
+	}))
+	defer azure.Close()
+	gcpCalled := false
+	gcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gcpCalled = true
+	}))
+	defer gcp.Close()
+
+	defer setURL(&ec2TokenURL, failing.URL)()
+	defer setURL(&azureURL, azure.URL)()
+	defer setURL(&gcpURL, gcp.URL)()
+
+	r, err := NewResolver(nil, time.Second)
+	require.NoError(t, err)
+	tags, errs := r.Resolve(context.Background())
+	require.Len(t, errs, 1)
+	require.False(t, gcpCalled)
+	require.Equal(t, "azure", tags["cloud_provider"])
+	require.Equal(t, "vm", tags["instance_id"])
+	require.Equal(t, "westus", tags["region"])
+	require.Equal(t, runtime.GOOS, tags["os"])
+	_, ok := tags["availability_zone"]
+	require.False(t, ok)
+}
+
+func TestNewResolverUnknownProvider(t *testing.T) {
+	_, err := NewResolver([]string{"os", "digitalocean"}, time.Second)
+	require.Error(t, err)
+}
+
+func setURL(u *string, value string) func() {
+	prev := *u
+	*u = value
+	return func() { *u = prev }
+}
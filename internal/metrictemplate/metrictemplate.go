@@ -0,0 +1,138 @@
+// Package metrictemplate renders the options of plugins, such as topics or URLs,
+// that are text/template templates executed for each metric.  The templates
+// access the metric name with {{.Name}} and the value of a tag with
+// {{.Tag "tag_name"}}, which is empty if the metric does not have the tag.
+package metrictemplate
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Template is a parsed template.
+type Template struct {
+	text string
+	tmpl *template.Template
+}
+
+// New parses the template, text without actions is rendered as is.
+func New(name, text string) (*Template, error) {
+	t := &Template{text: text}
+	if !strings.Contains(text, "{{") {
+		return t, nil
+	}
+
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// Render renders the template for the metric.
+func (t *Template) Render(m telegraf.Metric) (string, error) {
+	return t.Execute(NewMetric(m))
+}
+
+// Execute renders the template with the data, plugins providing more fields
+// than those of Metric embed it in their data.
+func (t *Template) Execute(data interface{}) (string, error) {
+	if t.tmpl == nil {
+		return t.text, nil
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Tags returns the keys of the tags used by the template with a constant
+// key, ie: {{.Tag "host"}}.
+func (t *Template) Tags() []string {
+	if t.tmpl == nil || t.tmpl.Tree == nil {
+		return nil
+	}
+
+	var keys []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			if len(n.Args) == 2 {
+				field, ok := n.Args[0].(*parse.FieldNode)
+				key, isString := n.Args[1].(*parse.StringNode)
+				if ok && isString && len(field.Ident) == 1 && field.Ident[0] == "Tag" {
+					keys = append(keys, key.Text)
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		}
+	}
+	walk(t.tmpl.Tree.Root)
+	return keys
+}
+
+// Metric is the data of a metric in templates.
+type Metric struct {
+	metric telegraf.Metric
+}
+
+// NewMetric returns the template data of the metric, which may be nil.
+func NewMetric(m telegraf.Metric) Metric {
+	return Metric{metric: m}
+}
+
+// Name returns the name of the metric.
+func (d Metric) Name() string {
+	if d.metric == nil {
+		return ""
+	}
+	return d.metric.Name()
+}
+
+// Tag returns the value of the tag, or an empty string if the metric does
+// not have the tag.
+func (d Metric) Tag(key string) string {
+	if d.metric == nil {
+		return ""
+	}
+	value, _ := d.metric.GetTag(key)
+	return value
+}
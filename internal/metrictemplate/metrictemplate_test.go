@@ -0,0 +1,93 @@
+package metrictemplate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "a", "dc": "east"},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "constant",
+			text:     "telegraf",
+			expected: "telegraf",
+		},
+		{
+			name:     "name and tag",
+			text:     `telegraf.{{.Name}}.{{.Tag "host"}}`,
+			expected: "telegraf.cpu.a",
+		},
+		{
+			name:     "missing tag",
+			text:     `telegraf-{{.Tag "region"}}`,
+			expected: "telegraf-",
+		},
+		{
+			name:     "pipeline",
+			text:     `{{.Tag "dc" | printf "%s-1"}}`,
+			expected: "east-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := New("test", tt.text)
+			require.NoError(t, err)
+			actual, err := tmpl.Render(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New("test", `{{.Tag "host"`)
+	require.Error(t, err)
+}
+
+func TestExecuteEmbedded(t *testing.T) {
+	tmpl, err := New("test", `{{.Prefix}}/{{.Name}}`)
+	require.NoError(t, err)
+
+	data := struct {
+		Metric
+		Prefix string
+	}{
+		Metric: NewMetric(testutil.TestMetric(1)),
+		Prefix: "telegraf",
+	}
+	actual, err := tmpl.Execute(data)
+	require.NoError(t, err)
+	require.Equal(t, "telegraf/test1", actual)
+
+	// Without a metric the name and tags are empty.
+	actual, err = tmpl.Execute(struct {
+		Metric
+		Prefix string
+	}{Prefix: "telegraf"})
+	require.NoError(t, err)
+	require.Equal(t, "telegraf/", actual)
+}
+
+func TestTags(t *testing.T) {
+	tmpl, err := New("test", `{{.Tag "host"}}-{{if .Tag "dc"}}{{.Tag "dc" | printf "%s"}}{{end}}-{{printf "%s" (.Tag "region")}}`)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"host", "dc", "dc", "region"}, tmpl.Tags())
+
+	tmpl, err = New("test", "constant")
+	require.NoError(t, err)
+	require.Empty(t, tmpl.Tags())
+}
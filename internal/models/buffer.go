@@ -7,6 +7,16 @@ import (
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+// Policies for adding metrics to a full buffer.
+const (
+	// OverflowDropOldest drops the oldest metrics to make room.
+	OverflowDropOldest = "drop_oldest"
+	// OverflowDropNewest drops the metrics being added.
+	OverflowDropNewest = "drop_newest"
+	// OverflowBlock waits until there is room, which holds back the inputs.
+	OverflowBlock = "block"
+)
+
 var (
 	AgentMetricsWritten = selfstat.Register("agent", "metrics_written", map[string]string{})
 	AgentMetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
@@ -24,22 +34,29 @@ type Buffer struct {
 	batchFirst int // index of the first metric in the batch
 	batchSize  int // number of metrics currently in the batch
 
+	overflow string     // policy when the buffer is full
+	released bool       // true if adds no longer block
+	room     *sync.Cond // signalled when metrics are removed
+
+	bufferStats
+}
+
+// bufferStats holds the statistics shared by all buffer types.
+type bufferStats struct {
 	MetricsAdded   selfstat.Stat
 	MetricsWritten selfstat.Stat
 	MetricsDropped selfstat.Stat
 	BufferSize     selfstat.Stat
 	BufferLimit    selfstat.Stat
-}
 
-// NewBuffer returns a new empty Buffer with the given capacity.
-func NewBuffer(name string, capacity int) *Buffer {
-	b := &Buffer{
-		buf:   make([]telegraf.Metric, capacity),
-		first: 0,
-		last:  0,
-		size:  0,
-		cap:   capacity,
+	// Actions taken when metrics are added to a full buffer.
+	OverflowDroppedOldest selfstat.Stat
+	OverflowDroppedNewest selfstat.Stat
+	OverflowBlocked       selfstat.Stat
+}
 
+func newBufferStats(name string) bufferStats {
+	return bufferStats{
 		MetricsAdded: selfstat.Register(
 			"write",
 			"metrics_added",
@@ -65,12 +82,75 @@ func NewBuffer(name string, capacity int) *Buffer {
 			"buffer_limit",
 			map[string]string{"output": name},
 		),
+		OverflowDroppedOldest: selfstat.Register(
+			"write",
+			"overflow_dropped_oldest",
+			map[string]string{"output": name},
+		),
+		OverflowDroppedNewest: selfstat.Register(
+			"write",
+			"overflow_dropped_newest",
+			map[string]string{"output": name},
+		),
+		OverflowBlocked: selfstat.Register(
+			"write",
+			"overflow_blocked",
+			map[string]string{"output": name},
+		),
 	}
+}
+
+func (s *bufferStats) metricAdded() {
+	s.MetricsAdded.Incr(1)
+}
+
+func (s *bufferStats) metricWritten(metric telegraf.Metric) {
+	AgentMetricsWritten.Incr(1)
+	s.MetricsWritten.Incr(1)
+	metric.Accept()
+}
+
+func (s *bufferStats) metricDropped(metric telegraf.Metric) {
+	AgentMetricsDropped.Incr(1)
+	s.MetricsDropped.Incr(1)
+	metric.Reject()
+}
+
+// NewBuffer returns a new empty Buffer with the given capacity.
+func NewBuffer(name string, capacity int) *Buffer {
+	b := &Buffer{
+		buf:   make([]telegraf.Metric, capacity),
+		first: 0,
+		last:  0,
+		size:  0,
+		cap:   capacity,
+
+		overflow: OverflowDropOldest,
+
+		bufferStats: newBufferStats(name),
+	}
+	b.room = sync.NewCond(&b.Mutex)
 	b.BufferSize.Set(int64(0))
 	b.BufferLimit.Set(int64(capacity))
 	return b
 }
 
+// setOverflow sets the policy used when the buffer is full.
+func (b *Buffer) setOverflow(policy string) {
+	b.Lock()
+	defer b.Unlock()
+	b.overflow = policy
+}
+
+// release stops or restarts blocking adds, while released a full buffer
+// drops the oldest metrics.
+func (b *Buffer) release(released bool) {
+	b.Lock()
+	defer b.Unlock()
+	b.released = released
+	b.room.Broadcast()
+}
+
 // Len returns the number of metrics currently in the buffer.
 func (b *Buffer) Len() int {
 	b.Lock()
@@ -83,25 +163,26 @@ func (b *Buffer) length() int {
 	return min(b.size+b.batchSize, b.cap)
 }
 
-func (b *Buffer) metricAdded() {
-	b.MetricsAdded.Incr(1)
-}
-
-func (b *Buffer) metricWritten(metric telegraf.Metric) {
-	AgentMetricsWritten.Incr(1)
-	b.MetricsWritten.Incr(1)
-	metric.Accept()
-}
-
-func (b *Buffer) metricDropped(metric telegraf.Metric) {
-	AgentMetricsDropped.Incr(1)
-	b.MetricsDropped.Incr(1)
-	metric.Reject()
-}
-
 func (b *Buffer) add(m telegraf.Metric) {
+	if b.length() == b.cap {
+		switch b.overflow {
+		case OverflowDropNewest:
+			b.OverflowDroppedNewest.Incr(1)
+			b.metricDropped(m)
+			return
+		case OverflowBlock:
+			if !b.released {
+				b.OverflowBlocked.Incr(1)
+			}
+			for b.length() == b.cap && !b.released {
+				b.room.Wait()
+			}
+		}
+	}
+
 	// Check if Buffer is full
 	if b.size == b.cap {
+		b.OverflowDroppedOldest.Incr(1)
 		b.metricDropped(b.buf[b.last])
 
 		if b.last == b.batchFirst && b.batchSize > 0 {
@@ -174,6 +255,30 @@ func (b *Buffer) Accept(batch []telegraf.Metric) {
 
 	b.resetBatch()
 	b.BufferSize.Set(int64(b.length()))
+	b.room.Broadcast()
+}
+
+// AcceptPartial marks the metrics of the batch, acquired from Batch(), that
+// are written as successfully written and returns the others to the buffer.
+func (b *Buffer) AcceptPartial(batch []telegraf.Metric, written []bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	failed := make([]telegraf.Metric, 0, len(batch))
+	for i, m := range batch {
+		if written[i] {
+			b.metricWritten(m)
+		} else {
+			failed = append(failed, m)
+		}
+	}
+	if len(failed) > 0 {
+		b.restore(failed)
+	}
+
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+	b.room.Broadcast()
 }
 
 // Reject returns the batch, acquired from Batch(), to the buffer and marks it
@@ -186,6 +291,15 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 		return
 	}
 
+	b.restore(batch)
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+}
+
+// restore puts the metrics of the batch back in front of the metrics added
+// since the batch was taken, the oldest metrics are dropped if there is no
+// room left.
+func (b *Buffer) restore(batch []telegraf.Metric) {
 	older := b.dist(b.first, b.batchFirst)
 	free := b.cap - b.size
 	restore := min(len(batch), free+older)
@@ -220,9 +334,6 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 			b.metricDropped(batch[i])
 		}
 	}
-
-	b.resetBatch()
-	b.BufferSize.Set(int64(b.length()))
 }
 
 // dist returns the distance between two indexes.  Because this data structure
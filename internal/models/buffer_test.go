@@ -60,6 +60,9 @@ func setup(b *Buffer) *Buffer {
 	b.MetricsAdded.Set(0)
 	b.MetricsWritten.Set(0)
 	b.MetricsDropped.Set(0)
+	b.OverflowDroppedOldest.Set(0)
+	b.OverflowDroppedNewest.Set(0)
+	b.OverflowBlocked.Set(0)
 	return b
 }
 
@@ -231,6 +234,28 @@ func TestBuffer_RejectWithRoom(t *testing.T) {
 		}, batch)
 }
 
+func TestBuffer_AcceptPartial(t *testing.T) {
+	b := setup(NewBuffer("test", 5))
+	b.Add(MetricTime(1))
+	b.Add(MetricTime(2))
+	b.Add(MetricTime(3))
+	b.Add(MetricTime(4))
+	batch := b.Batch(3)
+	b.Add(MetricTime(5))
+	b.AcceptPartial(batch, []bool{true, false, true})
+
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+
+	batch = b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(5),
+			MetricTime(3),
+			MetricTime(1),
+		}, batch)
+}
+
 func TestBuffer_RejectNothingNewFull(t *testing.T) {
 	b := setup(NewBuffer("test", 5))
 	b.Add(MetricTime(1))
@@ -726,3 +751,79 @@ func TestBuffer_RejectEmptyBatch(t *testing.T) {
 		require.NotNil(t, m)
 	}
 }
+
+func TestBuffer_OverflowDropOldest(t *testing.T) {
+	b := setup(NewBuffer("test", 2))
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(3), MetricTime(2)}, batch)
+	require.Equal(t, int64(1), b.OverflowDroppedOldest.Get())
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+}
+
+func TestBuffer_OverflowDropNewest(t *testing.T) {
+	b := setup(NewBuffer("test", 2))
+	b.setOverflow(OverflowDropNewest)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(2), MetricTime(1)}, batch)
+	require.Equal(t, int64(1), b.OverflowDroppedNewest.Get())
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	// Metrics added while the batch is written do not replace it
+	b.Add(MetricTime(4))
+	b.Reject(batch)
+	require.Equal(t, int64(2), b.OverflowDroppedNewest.Get())
+	require.Equal(t, 2, b.Len())
+}
+
+func TestBuffer_OverflowBlock(t *testing.T) {
+	b := setup(NewBuffer("test", 2))
+	b.setOverflow(OverflowBlock)
+	b.Add(MetricTime(1), MetricTime(2))
+
+	added := make(chan struct{})
+	go func() {
+		b.Add(MetricTime(3))
+		close(added)
+	}()
+
+	select {
+	case <-added:
+		t.Fatal("add to full buffer did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Accept(b.Batch(1))
+	<-added
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(3), MetricTime(1)}, batch)
+	require.Equal(t, int64(1), b.OverflowBlocked.Get())
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+}
+
+func TestBuffer_OverflowBlockReleased(t *testing.T) {
+	b := setup(NewBuffer("test", 2))
+	b.setOverflow(OverflowBlock)
+	b.Add(MetricTime(1), MetricTime(2))
+
+	added := make(chan struct{})
+	go func() {
+		b.Add(MetricTime(3))
+		close(added)
+	}()
+
+	b.release(true)
+	<-added
+	require.Equal(t, int64(1), b.OverflowDroppedOldest.Get())
+
+	b.release(false)
+	b.Accept(b.Batch(2))
+	require.Equal(t, 0, b.Len())
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// DeadLetterFile appends the metrics rejected by an output to a file, in
+// line protocol after a comment with the time and the reason of the
+// rejection.  The file can be read back with the influx data format.
+type DeadLetterFile struct {
+	sync.Mutex
+	path       string
+	serializer *influx.Serializer
+}
+
+// NewDeadLetterFile returns a dead letter file at path, it is created on the
+// first write.
+func NewDeadLetterFile(path string) *DeadLetterFile {
+	return &DeadLetterFile{
+		path:       path,
+		serializer: influx.NewSerializer(),
+	}
+}
+
+// Write appends the metrics with the reason of their rejection.
+func (d *DeadLetterFile) Write(metrics []telegraf.Metric, reason string) error {
+	d.Lock()
+	defer d.Unlock()
+
+	var buf bytes.Buffer
+	buf.WriteString("# ")
+	buf.WriteString(time.Now().UTC().Format(time.RFC3339))
+	buf.WriteString(" ")
+	buf.WriteString(strings.Replace(reason, "\n", " ", -1))
+	buf.WriteString("\n")
+	for _, metric := range metrics {
+		octets, err := d.serializer.Serialize(metric)
+		if err != nil {
+			fmt.Fprintf(&buf, "# unserializable metric %q: %v\n", metric.Name(), err)
+			continue
+		}
+		buf.Write(octets)
+	}
+
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,848 @@
+package models
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// Default maximum size of a disk buffer in bytes.
+	DEFAULT_BUFFER_MAX_SIZE = 100 * 1024 * 1024
+
+	// Largest size of a single segment file, smaller segments are used if
+	// the buffer would have less than four of them.
+	maxSegmentSize = 1024 * 1024
+
+	// Largest record that is read back, anything larger is considered to be
+	// corruption.
+	maxRecordSize = 16 * 1024 * 1024
+
+	segmentExt   = ".seg"
+	headFilename = "head"
+	recordHeader = 8
+)
+
+var errCorrupt = errors.New("corrupt record")
+
+// DiskBuffer stores metrics in a write-ahead log on disk, so that metrics
+// survive restarts of the agent.
+//
+// The log is split into segment files, each metric is a record made of its
+// length, a checksum and the encoded metric.  Each metric is numbered with an
+// id; a segment is named after the id of its first metric and the id of the
+// oldest metric not yet written is kept in a separate file.  Segments are
+// removed once all of their metrics are written or, if the buffer grows
+// larger than the max size, the oldest segments are dropped.
+type DiskBuffer struct {
+	sync.Mutex
+	name    string
+	dir     string
+	maxSize int64
+
+	segments    []*segment
+	segmentSize int64
+	size        int64    // total size of all segments
+	file        *os.File // the newest segment, open for appending
+
+	head position // the oldest metric not yet written
+
+	batchStart uint64   // id of the first metric in the batch
+	batchEnd   position // one after the last metric in the batch
+	batchSize  int      // number of metrics currently in the batch
+
+	overflow string     // policy when the buffer is full
+	released bool       // true if adds no longer block
+	room     *sync.Cond // signalled when segments are removed
+
+	bufferStats
+}
+
+// segment is a single file of the log.
+type segment struct {
+	path  string
+	first uint64 // id of the first metric
+	count uint64 // number of metrics
+	size  int64  // size of the valid records in bytes
+}
+
+func (s *segment) end() uint64 {
+	return s.first + s.count
+}
+
+// position locates a metric by id and its byte offset in the segment.
+type position struct {
+	id     uint64
+	offset int64
+}
+
+// NewDiskBuffer returns a new DiskBuffer storing its segments in dir.  The
+// buffer must be opened before it is used.
+func NewDiskBuffer(name string, dir string, maxSize int64) *DiskBuffer {
+	segmentSize := int64(maxSegmentSize)
+	if maxSize/4 < segmentSize {
+		segmentSize = maxSize / 4
+	}
+
+	b := &DiskBuffer{
+		name:        name,
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: segmentSize,
+
+		overflow: OverflowDropOldest,
+
+		bufferStats: newBufferStats(name),
+	}
+	b.room = sync.NewCond(&b.Mutex)
+	b.BufferSize.Set(int64(0))
+	b.BufferLimit.Set(int64(0))
+	return b
+}
+
+// setOverflow sets the policy used when the buffer is full.
+func (b *DiskBuffer) setOverflow(policy string) {
+	b.Lock()
+	defer b.Unlock()
+	b.overflow = policy
+}
+
+// release stops or restarts blocking adds, while released a full buffer
+// drops the oldest metrics.
+func (b *DiskBuffer) release(released bool) {
+	b.Lock()
+	defer b.Unlock()
+	b.released = released
+	b.room.Broadcast()
+}
+
+// Open loads the segments left by a previous run.  Segments that are
+// corrupted are truncated at the last valid record.
+func (b *DiskBuffer) Open() error {
+	b.Lock()
+	defer b.Unlock()
+
+	err := os.MkdirAll(b.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	head, err := b.readHead()
+	if err != nil {
+		return err
+	}
+	b.head = position{id: head}
+
+	files, err := filepath.Glob(filepath.Join(b.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+
+	b.segments = b.segments[:0]
+	for _, path := range files {
+		first, err := strconv.ParseUint(strings.TrimSuffix(filepath.Base(path), segmentExt), 10, 64)
+		if err != nil {
+			log.Printf("W! [outputs.%s] Ignoring unknown file in buffer directory: %s", b.name, path)
+			continue
+		}
+		b.segments = append(b.segments, &segment{path: path, first: first})
+	}
+	sort.Slice(b.segments, func(i, j int) bool {
+		return b.segments[i].first < b.segments[j].first
+	})
+
+	b.size = 0
+	for i, seg := range b.segments {
+		err := b.scan(seg, i == len(b.segments)-1)
+		if err != nil {
+			return err
+		}
+		b.size += seg.size
+	}
+
+	if len(b.segments) > 0 && b.head.id < b.segments[0].first {
+		b.head = position{id: b.segments[0].first}
+	}
+	if n := len(b.segments); n > 0 && b.head.id > b.segments[n-1].end() {
+		b.head = position{id: b.segments[n-1].end(), offset: b.segments[n-1].size}
+	}
+
+	b.removeWritten()
+
+	if seg := b.newest(); seg != nil {
+		b.file, err = os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0640)
+		if err != nil {
+			return err
+		}
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+	return nil
+}
+
+// scan counts the valid records of the segment and finds the offset of the
+// head.  The newest segment is truncated after the last valid record so that
+// it can be appended to.
+func (b *DiskBuffer) scan(seg *segment, newest bool) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		if seg.first+seg.count == b.head.id {
+			b.head.offset = seg.size
+		}
+
+		n, _, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("E! [outputs.%s] Buffer segment %s is corrupted after %d metrics: %v",
+				b.name, seg.path, seg.count, err)
+			break
+		}
+		seg.count++
+		seg.size += n
+	}
+
+	if newest {
+		return os.Truncate(seg.path, seg.size)
+	}
+	return nil
+}
+
+// Len returns the number of metrics currently in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.length()
+}
+
+func (b *DiskBuffer) length() int {
+	var n uint64
+	for _, seg := range b.segments {
+		if seg.end() > b.head.id {
+			n += seg.end() - maxUint64(seg.first, b.head.id)
+		}
+	}
+	return int(n)
+}
+
+// Size returns the size of the buffer on disk in bytes.
+func (b *DiskBuffer) Size() int64 {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.size
+}
+
+// Add adds metrics to the buffer.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for i, m := range metrics {
+		err := b.add(m)
+		if err != nil {
+			log.Printf("E! [outputs.%s] Error writing to buffer, dropping %d metrics: %v",
+				b.name, len(metrics)-i, err)
+			for _, m := range metrics[i:] {
+				b.metricDropped(m)
+			}
+			break
+		}
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+}
+
+func (b *DiskBuffer) add(m telegraf.Metric) error {
+	record := encodeRecord(m)
+
+	if b.full(record) {
+		switch b.overflow {
+		case OverflowDropNewest:
+			b.OverflowDroppedNewest.Incr(1)
+			b.metricDropped(m)
+			return nil
+		case OverflowBlock:
+			if !b.released {
+				b.OverflowBlocked.Incr(1)
+			}
+			for b.full(record) && !b.released {
+				b.room.Wait()
+			}
+		}
+	}
+
+	err := b.append(record)
+	if err != nil {
+		return err
+	}
+	b.metricAdded()
+
+	// The metric is safely stored and no longer tracked.
+	m.Accept()
+	return nil
+}
+
+// append writes the record to the newest segment, the oldest segments are
+// dropped if the buffer grows larger than the max size.
+func (b *DiskBuffer) append(record []byte) error {
+	seg := b.newest()
+	if seg == nil || (seg.size > 0 && seg.size+int64(len(record)) > b.segmentSize) {
+		var err error
+		seg, err = b.roll()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := b.file.Write(record)
+	if err != nil {
+		// Discard any partial record so that the segment stays readable.
+		b.file.Truncate(seg.size)
+		return err
+	}
+
+	seg.count++
+	seg.size += int64(len(record))
+	b.size += int64(len(record))
+
+	for b.size > b.maxSize && len(b.segments) > 1 {
+		err = b.dropOldest()
+		if err != nil {
+			log.Printf("E! [outputs.%s] Error removing buffer segment: %v", b.name, err)
+		}
+	}
+	return nil
+}
+
+// full returns true if adding the record would exceed the max size.
+func (b *DiskBuffer) full(record []byte) bool {
+	return len(b.segments) > 1 && b.size+int64(len(record)) > b.maxSize
+}
+
+// roll starts a new segment for appending metrics.
+func (b *DiskBuffer) roll() (*segment, error) {
+	var first uint64
+	if seg := b.newest(); seg != nil {
+		first = seg.end()
+	} else {
+		first = b.head.id
+	}
+
+	if b.file != nil {
+		err := b.file.Close()
+		if err != nil {
+			return nil, err
+		}
+		b.file = nil
+	}
+
+	seg := &segment{
+		path:  filepath.Join(b.dir, fmt.Sprintf("%020d%s", first, segmentExt)),
+		first: first,
+	}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return nil, err
+	}
+	b.file = f
+	b.segments = append(b.segments, seg)
+	return seg, nil
+}
+
+// newest returns the segment that is appended to.
+func (b *DiskBuffer) newest() *segment {
+	if len(b.segments) == 0 {
+		return nil
+	}
+	return b.segments[len(b.segments)-1]
+}
+
+// dropOldest removes the oldest segment, all metrics in it that are not part
+// of the current batch are counted as dropped.
+func (b *DiskBuffer) dropOldest() error {
+	seg := b.segments[0]
+
+	from := maxUint64(seg.first, b.head.id)
+	if b.batchSize > 0 {
+		from = maxUint64(from, b.batchEnd.id)
+	}
+	if seg.end() > from {
+		dropped := int64(seg.end() - from)
+		AgentMetricsDropped.Incr(dropped)
+		b.MetricsDropped.Incr(dropped)
+		b.OverflowDroppedOldest.Incr(dropped)
+	}
+
+	if b.head.id < seg.end() {
+		b.head = position{id: b.segments[1].first}
+	}
+	return b.removeSegment()
+}
+
+// removeSegment deletes the oldest segment.
+func (b *DiskBuffer) removeSegment() error {
+	seg := b.segments[0]
+	b.segments = b.segments[1:]
+	b.size -= seg.size
+	return os.Remove(seg.path)
+}
+
+// removeWritten deletes the segments that only contain written metrics.  The
+// newest segment is kept for appending.
+func (b *DiskBuffer) removeWritten() {
+	for len(b.segments) > 1 && b.segments[0].end() <= b.head.id {
+		if b.head.id == b.segments[0].end() {
+			b.head = position{id: b.segments[1].first}
+		}
+
+		err := b.removeSegment()
+		if err != nil {
+			log.Printf("E! [outputs.%s] Error removing buffer segment: %v", b.name, err)
+		}
+	}
+}
+
+// Batch returns a slice containing up to batchSize of the oldest metrics in
+// the buffer.  Metrics are ordered from oldest to newest in the batch.
+func (b *DiskBuffer) Batch(batchSize int) []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	out := make([]telegraf.Metric, 0, min(b.length(), batchSize))
+	pos := b.head
+	for i := 0; i < len(b.segments) && len(out) < batchSize; i++ {
+		seg := b.segments[i]
+		if seg.end() <= pos.id {
+			continue
+		}
+		if pos.id <= seg.first {
+			pos = position{id: seg.first}
+		}
+
+		var err error
+		out, pos, err = b.read(seg, pos, out, batchSize)
+		if err != nil {
+			log.Printf("E! [outputs.%s] Buffer segment %s is corrupted after %d metrics: %v",
+				b.name, seg.path, pos.id-seg.first, err)
+
+			// Skip the remaining records of the segment.
+			dropped := int64(seg.end() - pos.id)
+			AgentMetricsDropped.Incr(dropped)
+			b.MetricsDropped.Incr(dropped)
+			seg.count = pos.id - seg.first
+		}
+	}
+
+	b.batchStart = b.head.id
+	b.batchEnd = pos
+	b.batchSize = len(out)
+	return out
+}
+
+// read appends the metrics of the segment starting at pos to out.
+func (b *DiskBuffer) read(
+	seg *segment,
+	pos position,
+	out []telegraf.Metric,
+	batchSize int,
+) ([]telegraf.Metric, position, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return out, pos, err
+	}
+	defer f.Close()
+
+	_, err = f.Seek(pos.offset, io.SeekStart)
+	if err != nil {
+		return out, pos, err
+	}
+
+	r := bufio.NewReader(f)
+	for pos.id < seg.end() && len(out) < batchSize {
+		n, payload, err := readRecord(r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return out, pos, err
+		}
+		pos.id++
+		pos.offset += n
+
+		m, err := decodeMetric(payload)
+		if err != nil {
+			log.Printf("E! [outputs.%s] Dropping metric that could not be decoded from buffer: %v",
+				b.name, err)
+			AgentMetricsDropped.Incr(1)
+			b.MetricsDropped.Incr(1)
+			continue
+		}
+		out = append(out, m)
+	}
+	return out, pos, nil
+}
+
+// Accept marks the batch, acquired from Batch(), as successfully written.
+func (b *DiskBuffer) Accept(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range batch {
+		b.metricWritten(m)
+	}
+
+	b.advanceHead()
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+}
+
+// AcceptPartial marks the metrics of the batch, acquired from Batch(), that
+// are written as successfully written.  The log can only be consumed from
+// its head, so the other metrics are appended to the buffer again, after the
+// metrics added since the batch was taken.
+func (b *DiskBuffer) AcceptPartial(batch []telegraf.Metric, written []bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	// Metrics dropped while the batch was being written are not kept.
+	var dropped uint64
+	if b.head.id > b.batchStart {
+		dropped = b.head.id - b.batchStart
+	}
+
+	var failed []telegraf.Metric
+	for i, m := range batch {
+		switch {
+		case written[i]:
+			b.metricWritten(m)
+		case uint64(i) < dropped:
+			b.metricDropped(m)
+		default:
+			failed = append(failed, m)
+		}
+	}
+
+	b.advanceHead()
+	b.resetBatch()
+
+	for i, m := range failed {
+		err := b.append(encodeRecord(m))
+		if err != nil {
+			log.Printf("E! [outputs.%s] Error writing to buffer, dropping %d metrics: %v",
+				b.name, len(failed)-i, err)
+			for _, m := range failed[i:] {
+				b.metricDropped(m)
+			}
+			break
+		}
+	}
+	b.BufferSize.Set(int64(b.length()))
+}
+
+// advanceHead moves the head past the batch and removes the segments that
+// are completely written.
+func (b *DiskBuffer) advanceHead() {
+	if b.batchSize > 0 && b.batchEnd.id > b.head.id {
+		b.head = b.batchEnd
+		b.removeWritten()
+		b.room.Broadcast()
+
+		err := b.writeHead()
+		if err != nil {
+			log.Printf("E! [outputs.%s] Error writing buffer head: %v", b.name, err)
+		}
+	}
+}
+
+// Reject marks the batch, acquired from Batch(), as unsent.  The metrics
+// remain in the buffer unless they were dropped while the batch was being
+// written.
+func (b *DiskBuffer) Reject(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.head.id > b.batchStart {
+		dropped := b.head.id - b.batchStart
+		for i := 0; i < len(batch) && uint64(i) < dropped; i++ {
+			b.metricDropped(batch[i])
+		}
+	}
+
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+}
+
+func (b *DiskBuffer) resetBatch() {
+	b.batchStart = 0
+	b.batchEnd = position{}
+	b.batchSize = 0
+}
+
+// Close closes the newest segment and stores the head.
+func (b *DiskBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.file != nil {
+		err := b.file.Close()
+		if err != nil {
+			return err
+		}
+		b.file = nil
+	}
+	return b.writeHead()
+}
+
+func (b *DiskBuffer) readHead() (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(b.dir, headFilename))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	head, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		log.Printf("E! [outputs.%s] Invalid buffer head, resending all metrics: %v", b.name, err)
+		return 0, nil
+	}
+	return head, nil
+}
+
+func (b *DiskBuffer) writeHead() error {
+	filename := filepath.Join(b.dir, headFilename)
+	tmpfile := filename + ".tmp"
+	err := ioutil.WriteFile(tmpfile, []byte(strconv.FormatUint(b.head.id, 10)), 0640)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpfile, filename)
+}
+
+// encodeRecord returns the metric encoded as a record of the log.
+func encodeRecord(m telegraf.Metric) []byte {
+	payload := encodeMetric(m)
+	record := make([]byte, recordHeader, recordHeader+len(payload))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	return append(record, payload...)
+}
+
+// readRecord reads a single record and returns its size and payload.
+func readRecord(r io.Reader) (int64, []byte, error) {
+	var header [recordHeader]byte
+	_, err := io.ReadFull(r, header[:])
+	if err == io.ErrUnexpectedEOF {
+		return 0, nil, errCorrupt
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.LittleEndian.Uint32(header[0:4])
+	if length > maxRecordSize {
+		return 0, nil, errCorrupt
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return 0, nil, errCorrupt
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+		return 0, nil, errCorrupt
+	}
+	return int64(recordHeader + len(payload)), payload, nil
+}
+
+// Field types of the encoded metrics.
+const (
+	fieldInt    = 'i'
+	fieldUint   = 'u'
+	fieldFloat  = 'f'
+	fieldString = 's'
+	fieldBool   = 'b'
+)
+
+func encodeMetric(m telegraf.Metric) []byte {
+	buf := make([]byte, 0, 128)
+	buf = appendString(buf, m.Name())
+	buf = append(buf, byte(m.Type()))
+	buf = appendVarint(buf, m.Time().UnixNano())
+
+	buf = appendUvarint(buf, uint64(len(m.TagList())))
+	for _, tag := range m.TagList() {
+		buf = appendString(buf, tag.Key)
+		buf = appendString(buf, tag.Value)
+	}
+
+	buf = appendUvarint(buf, uint64(len(m.FieldList())))
+	for _, field := range m.FieldList() {
+		buf = appendString(buf, field.Key)
+		switch v := field.Value.(type) {
+		case int64:
+			buf = append(buf, fieldInt)
+			buf = appendVarint(buf, v)
+		case uint64:
+			buf = append(buf, fieldUint)
+			buf = appendUvarint(buf, v)
+		case float64:
+			buf = append(buf, fieldFloat)
+			buf = appendUvarint(buf, math.Float64bits(v))
+		case string:
+			buf = append(buf, fieldString)
+			buf = appendString(buf, v)
+		case bool:
+			buf = append(buf, fieldBool)
+			if v {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		}
+	}
+	return buf
+}
+
+func decodeMetric(buf []byte) (telegraf.Metric, error) {
+	d := decoder{buf: buf}
+
+	name := d.string()
+	tp := telegraf.ValueType(d.byte())
+	tm := time.Unix(0, d.varint())
+
+	tags := make(map[string]string)
+	for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+		key := d.string()
+		tags[key] = d.string()
+	}
+
+	fields := make(map[string]interface{})
+	for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+		key := d.string()
+		switch d.byte() {
+		case fieldInt:
+			fields[key] = d.varint()
+		case fieldUint:
+			fields[key] = d.uvarint()
+		case fieldFloat:
+			fields[key] = math.Float64frombits(d.uvarint())
+		case fieldString:
+			fields[key] = d.string()
+		case fieldBool:
+			fields[key] = d.byte() == 1
+		default:
+			d.err = errCorrupt
+		}
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	return metric.New(name, tags, fields, tm, tp)
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+// decoder reads the values of an encoded metric, after the first error all
+// values are zero.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.buf) == 0 {
+		d.err = errCorrupt
+		return 0
+	}
+	v := d.buf[0]
+	d.buf = d.buf[1:]
+	return v
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = errCorrupt
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = errCorrupt
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if d.err != nil || uint64(len(d.buf)) < n {
+		d.err = errCorrupt
+		return ""
+	}
+	v := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return v
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,338 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, dir string, maxSize int64) *DiskBuffer {
+	b := NewDiskBuffer("test", dir, maxSize)
+	b.MetricsAdded.Set(0)
+	b.MetricsWritten.Set(0)
+	b.MetricsDropped.Set(0)
+	b.OverflowDroppedOldest.Set(0)
+	b.OverflowDroppedNewest.Set(0)
+	b.OverflowBlocked.Set(0)
+	require.NoError(t, b.Open())
+	return b
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "disk_buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func segments(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	return files
+}
+
+func TestDiskBuffer_BatchOldestFirst(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.Equal(t, 3, b.Len())
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2)}, batch)
+	b.Accept(batch)
+	require.Equal(t, 1, b.Len())
+
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(3)}, batch)
+	b.Accept(batch)
+	require.Equal(t, 0, b.Len())
+	require.Equal(t, int64(3), b.MetricsAdded.Get())
+	require.Equal(t, int64(3), b.MetricsWritten.Get())
+}
+
+func TestDiskBuffer_RejectKeepsMetrics(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2))
+	batch := b.Batch(2)
+	b.Reject(batch)
+	require.Equal(t, 2, b.Len())
+
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2)}, batch)
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+}
+
+func TestDiskBuffer_AcceptPartial(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(3)
+	b.Add(MetricTime(4))
+	b.AcceptPartial(batch, []bool{true, false, true})
+	require.Equal(t, 2, b.Len())
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+	require.Equal(t, int64(4), b.MetricsAdded.Get())
+
+	// The failed metric is written again after the newer metrics.
+	batch = b.Batch(3)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(4), MetricTime(2)}, batch)
+}
+
+func TestDiskBuffer_AddDuringBatch(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+
+	b.Add(MetricTime(1))
+	batch := b.Batch(2)
+	b.Add(MetricTime(2))
+	b.Accept(batch)
+	require.Equal(t, 1, b.Len())
+
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(2)}, batch)
+}
+
+func TestDiskBuffer_SurvivesRestart(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	b.Accept(b.Batch(1))
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+	require.Equal(t, 2, b.Len())
+
+	b.Add(MetricTime(4))
+	batch := b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(2), MetricTime(3), MetricTime(4)}, batch)
+}
+
+func TestDiskBuffer_MetricTypes(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+
+	m := testutil.MustMetric("test",
+		map[string]string{
+			"host": "localhost",
+			"dc":   "us-east-1",
+		},
+		map[string]interface{}{
+			"int":    int64(-42),
+			"uint":   uint64(42),
+			"float":  42.5,
+			"string": "forty two",
+			"bool":   true,
+		},
+		time.Unix(1565000000, 123456789),
+		telegraf.Counter,
+	)
+	b.Add(m)
+
+	batch := b.Batch(1)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, batch)
+	require.Equal(t, telegraf.Counter, batch[0].Type())
+}
+
+func TestDiskBuffer_MaxSizeDropsOldest(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	record := int64(len(encodeRecord(MetricTime(1))))
+
+	// Room for eight records, segments hold two records each.
+	b := newTestDiskBuffer(t, dir, 8*record)
+	defer b.Close()
+
+	for i := 1; i <= 16; i++ {
+		b.Add(MetricTime(int64(i)))
+	}
+
+	require.True(t, b.Size() <= 8*record)
+	require.Equal(t, 8, b.Len())
+	require.Equal(t, int64(8), b.MetricsDropped.Get())
+	require.Len(t, segments(t, dir), 4)
+
+	batch := b.Batch(1)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(9)}, batch)
+}
+
+func TestDiskBuffer_DropWhileBatching(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	record := int64(len(encodeRecord(MetricTime(1))))
+
+	b := newTestDiskBuffer(t, dir, 4*record)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4))
+	batch := b.Batch(2)
+
+	// Drops the segment holding the batch
+	b.Add(MetricTime(5), MetricTime(6))
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+
+	b.Reject(batch)
+	require.Equal(t, int64(2), b.MetricsDropped.Get())
+
+	batch = b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(3), MetricTime(4), MetricTime(5), MetricTime(6)}, batch)
+}
+
+func TestDiskBuffer_WrittenSegmentsRemoved(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	record := int64(len(encodeRecord(MetricTime(1))))
+
+	b := newTestDiskBuffer(t, dir, 8*record)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4), MetricTime(5))
+	require.Len(t, segments(t, dir), 3)
+
+	b.Accept(b.Batch(4))
+	require.Len(t, segments(t, dir), 1)
+	require.Equal(t, 1, b.Len())
+}
+
+func TestDiskBuffer_TruncatedSegment(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	// Simulate a partially written record
+	files := segments(t, dir)
+	require.Len(t, files, 1)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0640)
+	require.NoError(t, err)
+	_, err = f.Write(encodeRecord(MetricTime(3))[:10])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	b = newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+	require.Equal(t, 2, b.Len())
+
+	b.Add(MetricTime(4))
+	batch := b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2), MetricTime(4)}, batch)
+}
+
+func TestDiskBuffer_CorruptRecord(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	require.NoError(t, b.Close())
+
+	// Damage the payload of the second record
+	files := segments(t, dir)
+	require.Len(t, files, 1)
+	data, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	record := len(encodeRecord(MetricTime(1)))
+	data[record+recordHeader+2] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(files[0], data, 0640))
+
+	b = newTestDiskBuffer(t, dir, DEFAULT_BUFFER_MAX_SIZE)
+	defer b.Close()
+	require.Equal(t, 1, b.Len())
+
+	batch := b.Batch(10)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(1)}, batch)
+}
+
+func TestDiskBuffer_OverflowDropNewest(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	record := int64(len(encodeRecord(MetricTime(1))))
+
+	b := newTestDiskBuffer(t, dir, 4*record)
+	b.setOverflow(OverflowDropNewest)
+	defer b.Close()
+
+	for i := 1; i <= 6; i++ {
+		b.Add(MetricTime(int64(i)))
+	}
+
+	require.Equal(t, 4, b.Len())
+	require.Equal(t, int64(2), b.OverflowDroppedNewest.Get())
+	require.Equal(t, int64(0), b.OverflowDroppedOldest.Get())
+
+	batch := b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4)}, batch)
+}
+
+func TestDiskBuffer_OverflowBlock(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	record := int64(len(encodeRecord(MetricTime(1))))
+
+	b := newTestDiskBuffer(t, dir, 4*record)
+	b.setOverflow(OverflowBlock)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4))
+
+	added := make(chan struct{})
+	go func() {
+		b.Add(MetricTime(5))
+		close(added)
+	}()
+
+	select {
+	case <-added:
+		t.Fatal("add to full buffer did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Accept(b.Batch(2))
+	<-added
+
+	batch := b.Batch(10)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{MetricTime(3), MetricTime(4), MetricTime(5)}, batch)
+	require.Equal(t, int64(1), b.OverflowBlocked.Get())
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+}
@@ -235,10 +235,12 @@ func (f *Filter) filterFields(metric telegraf.Metric) {
 
 // filterTags removes tags according to taginclude/tagexclude.
 func (f *Filter) filterTags(metric telegraf.Metric) {
+	// The route is not a tag of the metric as far as the user is concerned,
+	// removing it would send the metric to all outputs.
 	filterKeys := []string{}
 	if f.tagInclude != nil {
 		for _, tag := range metric.TagList() {
-			if !f.tagInclude.Match(tag.Key) {
+			if !f.tagInclude.Match(tag.Key) && tag.Key != RouteTag {
 				filterKeys = append(filterKeys, tag.Key)
 			}
 		}
@@ -249,7 +251,7 @@ func (f *Filter) filterTags(metric telegraf.Metric) {
 
 	if f.tagExclude != nil {
 		for _, tag := range metric.TagList() {
-			if f.tagExclude.Match(tag.Key) {
+			if f.tagExclude.Match(tag.Key) && tag.Key != RouteTag {
 				filterKeys = append(filterKeys, tag.Key)
 			}
 		}
@@ -523,3 +523,22 @@ func BenchmarkFilter(b *testing.B) {
 		})
 	}
 }
+
+func TestFilter_FilterTagsKeepsRoute(t *testing.T) {
+	m, err := metric.New("m",
+		map[string]string{
+			"host":   "localhost",
+			RouteTag: "db",
+		},
+		map[string]interface{}{"value": int64(1)},
+		time.Now())
+	require.NoError(t, err)
+	f := Filter{
+		TagInclude: []string{"nomatch"},
+		TagExclude: []string{"*"},
+	}
+	require.NoError(t, f.Compile())
+
+	f.filterTags(m)
+	require.Equal(t, map[string]string{RouteTag: "db"}, m.Tags())
+}
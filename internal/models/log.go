@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
+)
+
+// logRepeatInterval is the interval at which a repeated warning or error is
+// logged again.
+const logRepeatInterval = time.Minute
+
+// Logger is the telegraf.Logger of a plugin.  Warnings and errors that are
+// repeated are logged once per interval along with the number of repeats.
+type Logger struct {
+	Name string
+
+	level    logger.Level
+	hasLevel bool
+
+	mu       sync.Mutex
+	last     string
+	lastLvl  logger.Level
+	lastTime time.Time
+	repeats  int
+
+	// now is the clock used to rate limit repeated messages.
+	now func() time.Time
+}
+
+// NewLogger returns a logger for the plugin, the level overrides the global
+// log level when it is not empty.
+func NewLogger(pluginType, name, alias, level string) (*Logger, error) {
+	l := &Logger{
+		Name: pluginType + "." + name,
+		now:  time.Now,
+	}
+	if alias != "" {
+		l.Name += "::" + alias
+	}
+
+	if level != "" {
+		lvl, err := logger.ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		l.level = lvl
+		l.hasLevel = true
+	}
+	return l, nil
+}
+
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.print(logger.LevelError, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Error(args ...interface{}) {
+	l.print(logger.LevelError, fmt.Sprint(args...))
+}
+
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.print(logger.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Warn(args ...interface{}) {
+	l.print(logger.LevelWarn, fmt.Sprint(args...))
+}
+
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.print(logger.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Info(args ...interface{}) {
+	l.print(logger.LevelInfo, fmt.Sprint(args...))
+}
+
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.print(logger.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Debug(args ...interface{}) {
+	l.print(logger.LevelDebug, fmt.Sprint(args...))
+}
+
+func (l *Logger) print(lvl logger.Level, msg string) {
+	min := logger.GlobalLevel()
+	if l.hasLevel {
+		min = l.level
+	}
+	if lvl < min {
+		return
+	}
+
+	if lvl >= logger.LevelWarn && l.repeated(lvl, msg) {
+		return
+	}
+	logger.Log(lvl, l.Name, msg)
+}
+
+// repeated returns true if the message was already logged within the repeat
+// interval.  When a different message is logged, or the interval has passed,
+// the number of suppressed repeats is logged first.
+func (l *Logger) repeated(lvl logger.Level, msg string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if msg == l.last && now.Sub(l.lastTime) < logRepeatInterval {
+		l.repeats++
+		return true
+	}
+
+	if l.repeats > 0 {
+		logger.Log(l.lastLvl, l.Name,
+			fmt.Sprintf("Last message repeated %d times", l.repeats))
+	}
+	l.last = msg
+	l.lastLvl = lvl
+	l.lastTime = now
+	l.repeats = 0
+	return false
+}
+
+// SetLoggerOnPlugin sets the Log field of the plugin, if it has one.
+func SetLoggerOnPlugin(plugin interface{}, log telegraf.Logger) {
+	v := reflect.ValueOf(plugin)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	field := v.Elem().FieldByName("Log")
+	if !field.IsValid() || !field.CanSet() {
+		return
+	}
+	if field.Type() == reflect.TypeOf((*telegraf.Logger)(nil)).Elem() {
+		field.Set(reflect.ValueOf(log))
+	}
+}
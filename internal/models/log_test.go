@@ -0,0 +1,109 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestLogger(t *testing.T) string {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	tmpfile.Close()
+	logger.SetupLogging(logger.LogConfig{Logfile: tmpfile.Name()})
+	return tmpfile.Name()
+}
+
+func readLog(t *testing.T, filename string) []string {
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		if line != "" {
+			lines = append(lines, line[21:])
+		}
+	}
+	return lines
+}
+
+func TestLogger_Name(t *testing.T) {
+	l, err := NewLogger("inputs", "cpu", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "inputs.cpu", l.Name)
+
+	l, err = NewLogger("inputs", "cpu", "custom", "")
+	require.NoError(t, err)
+	require.Equal(t, "inputs.cpu::custom", l.Name)
+}
+
+func TestLogger_InvalidLevel(t *testing.T) {
+	_, err := NewLogger("inputs", "cpu", "", "verbose")
+	require.Error(t, err)
+}
+
+func TestLogger_LevelOverride(t *testing.T) {
+	filename := setupTestLogger(t)
+	defer os.Remove(filename)
+
+	l, err := NewLogger("inputs", "cpu", "", "debug")
+	require.NoError(t, err)
+	l.Debugf("debug %d", 1)
+
+	quiet, err := NewLogger("inputs", "mem", "", "error")
+	require.NoError(t, err)
+	quiet.Warn("ignored")
+
+	defaults, err := NewLogger("inputs", "disk", "", "")
+	require.NoError(t, err)
+	defaults.Debug("ignored")
+	defaults.Info("info")
+
+	require.Equal(t, []string{
+		"D! [inputs.cpu] debug 1",
+		"I! [inputs.disk] info",
+	}, readLog(t, filename))
+}
+
+func TestLogger_Repeated(t *testing.T) {
+	filename := setupTestLogger(t)
+	defer os.Remove(filename)
+
+	l, err := NewLogger("outputs", "file", "", "")
+	require.NoError(t, err)
+	now := time.Unix(0, 0)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		l.Errorf("failed")
+	}
+	now = now.Add(time.Minute)
+	l.Errorf("failed")
+
+	require.Equal(t, []string{
+		"E! [outputs.file] failed",
+		"E! [outputs.file] Last message repeated 2 times",
+		"E! [outputs.file] failed",
+	}, readLog(t, filename))
+}
+
+type pluginWithLog struct {
+	Log telegraf.Logger
+}
+
+func TestSetLoggerOnPlugin(t *testing.T) {
+	l, err := NewLogger("inputs", "test", "", "")
+	require.NoError(t, err)
+
+	plugin := &pluginWithLog{}
+	SetLoggerOnPlugin(plugin, l)
+	require.Equal(t, l, plugin.Log)
+
+	// Plugins without a Log field are left alone
+	SetLoggerOnPlugin(&struct{}{}, l)
+}
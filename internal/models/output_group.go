@@ -0,0 +1,219 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// GroupModeFailover writes to the first healthy output of the group.
+	GroupModeFailover = "failover"
+	// GroupModeRoundRobin spreads the writes over all healthy outputs.
+	GroupModeRoundRobin = "round_robin"
+
+	// DEFAULT_FAILBACK_INTERVAL is how long a failed output is skipped.
+	DEFAULT_FAILBACK_INTERVAL = 30 * time.Second
+)
+
+// OutputGroupConfig is the config of an output group.
+type OutputGroupConfig struct {
+	Name string
+	Mode string
+	// Outputs are the names or aliases of the outputs in the group, in
+	// order of priority.
+	Outputs []string
+	// FailbackInterval is the time after which a failed output is tried
+	// again.
+	FailbackInterval time.Duration
+}
+
+// groupMember is an output of a group along with its health.
+type groupMember struct {
+	name      string
+	output    telegraf.Output
+	connected bool
+	healthy   bool
+	retryAt   time.Time
+}
+
+// OutputGroup is an output that writes to one of several outputs, either the
+// first healthy output in failover mode or each healthy output in turn in
+// round robin mode.  Outputs that fail are skipped for the failback interval,
+// after which they are tried again, so that a recovered primary output is
+// used again in failover mode.
+type OutputGroup struct {
+	Config *OutputGroupConfig
+
+	mu      sync.Mutex
+	members []*groupMember
+	active  int
+	next    int
+
+	// now is the clock used for the failback interval.
+	now func() time.Time
+}
+
+// NewOutputGroup returns a group of the outputs, the names are used in log
+// messages.
+func NewOutputGroup(
+	config *OutputGroupConfig,
+	names []string,
+	outputs []telegraf.Output,
+) *OutputGroup {
+	if config.Mode == "" {
+		config.Mode = GroupModeFailover
+	}
+	if config.FailbackInterval == 0 {
+		config.FailbackInterval = DEFAULT_FAILBACK_INTERVAL
+	}
+
+	g := &OutputGroup{
+		Config: config,
+		now:    time.Now,
+	}
+	for i, output := range outputs {
+		g.members = append(g.members, &groupMember{
+			name:    names[i],
+			output:  output,
+			healthy: true,
+		})
+	}
+	return g
+}
+
+func (g *OutputGroup) Description() string {
+	return "Write to one of a group of outputs"
+}
+
+func (g *OutputGroup) SampleConfig() string {
+	return ""
+}
+
+// Connect connects all outputs of the group, it fails only if none of the
+// outputs can be connected.
+func (g *OutputGroup) Connect() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var lastErr error
+	for _, m := range g.members {
+		if err := g.connect(m); err != nil {
+			lastErr = err
+		}
+	}
+	for _, m := range g.members {
+		if m.connected {
+			return nil
+		}
+	}
+	return lastErr
+}
+
+// Close closes all connected outputs of the group.
+func (g *OutputGroup) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var lastErr error
+	for _, m := range g.members {
+		if !m.connected {
+			continue
+		}
+		m.connected = false
+		if err := m.output.Close(); err != nil {
+			log.Printf("E! [output_groups.%s] Error closing output %s: %v",
+				g.Config.Name, m.name, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Write writes the metrics to a single output of the group, trying the
+// next output when a write fails.
+func (g *OutputGroup) Write(metrics []telegraf.Metric) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	start := 0
+	if g.Config.Mode == GroupModeRoundRobin {
+		start = g.next
+		g.next = (g.next + 1) % len(g.members)
+	}
+
+	var lastErr error
+	for i := range g.members {
+		idx := (start + i) % len(g.members)
+		m := g.members[idx]
+		if !m.healthy && g.now().Before(m.retryAt) {
+			continue
+		}
+
+		if !m.connected {
+			if err := g.connect(m); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+
+		if err := m.output.Write(metrics); err != nil {
+			log.Printf("E! [output_groups.%s] Error writing to output %s: %v",
+				g.Config.Name, m.name, err)
+			g.markFailed(m)
+			lastErr = err
+			continue
+		}
+
+		if !m.healthy {
+			log.Printf("I! [output_groups.%s] Output %s recovered",
+				g.Config.Name, m.name)
+			m.healthy = true
+		}
+		if g.Config.Mode == GroupModeFailover && idx != g.active {
+			log.Printf("I! [output_groups.%s] Switched to output %s",
+				g.Config.Name, m.name)
+			g.active = idx
+		}
+		return nil
+	}
+
+	if lastErr == nil {
+		return fmt.Errorf("no healthy outputs in group %s", g.Config.Name)
+	}
+	return fmt.Errorf("all outputs in group %s failed, last error: %v",
+		g.Config.Name, lastErr)
+}
+
+// Healthy returns the names of the outputs that are not failing.
+func (g *OutputGroup) Healthy() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var names []string
+	for _, m := range g.members {
+		if m.healthy {
+			names = append(names, m.name)
+		}
+	}
+	return names
+}
+
+func (g *OutputGroup) connect(m *groupMember) error {
+	if err := m.output.Connect(); err != nil {
+		log.Printf("E! [output_groups.%s] Error connecting to output %s: %v",
+			g.Config.Name, m.name, err)
+		g.markFailed(m)
+		return err
+	}
+	m.connected = true
+	return nil
+}
+
+func (g *OutputGroup) markFailed(m *groupMember) {
+	m.healthy = false
+	m.retryAt = g.now().Add(g.Config.FailbackInterval)
+}
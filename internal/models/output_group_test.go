@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOutputGroup(mode string, outputs ...*mockOutput) (*OutputGroup, *time.Time) {
+	var names []string
+	var plugins []telegraf.Output
+	for i, output := range outputs {
+		names = append(names, string('a'+rune(i)))
+		plugins = append(plugins, output)
+	}
+
+	now := time.Unix(0, 0)
+	g := NewOutputGroup(&OutputGroupConfig{
+		Name:             "test",
+		Mode:             mode,
+		FailbackInterval: time.Minute,
+	}, names, plugins)
+	g.now = func() time.Time { return now }
+	return g, &now
+}
+
+func TestOutputGroup_Failover(t *testing.T) {
+	primary := &mockOutput{}
+	secondary := &mockOutput{}
+	g, now := newTestOutputGroup(GroupModeFailover, primary, secondary)
+	require.NoError(t, g.Connect())
+
+	require.NoError(t, g.Write(first5))
+	require.Len(t, primary.Metrics(), 5)
+	require.Len(t, secondary.Metrics(), 0)
+
+	primary.failWrite = true
+	require.NoError(t, g.Write(next5))
+	require.Len(t, secondary.Metrics(), 5)
+	require.Equal(t, []string{"b"}, g.Healthy())
+
+	// The primary is skipped until the failback interval has passed
+	primary.failWrite = false
+	require.NoError(t, g.Write(first5))
+	require.Len(t, primary.Metrics(), 5)
+	require.Len(t, secondary.Metrics(), 10)
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, g.Write(next5))
+	require.Len(t, primary.Metrics(), 10)
+	require.Len(t, secondary.Metrics(), 10)
+	require.Equal(t, []string{"a", "b"}, g.Healthy())
+}
+
+func TestOutputGroup_RoundRobin(t *testing.T) {
+	a := &mockOutput{}
+	b := &mockOutput{}
+	g, _ := newTestOutputGroup(GroupModeRoundRobin, a, b)
+	require.NoError(t, g.Connect())
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, g.Write(first5))
+	}
+	require.Len(t, a.Metrics(), 10)
+	require.Len(t, b.Metrics(), 10)
+
+	b.failWrite = true
+	for i := 0; i < 4; i++ {
+		require.NoError(t, g.Write(first5))
+	}
+	require.Len(t, a.Metrics(), 30)
+	require.Len(t, b.Metrics(), 10)
+}
+
+func TestOutputGroup_AllFailed(t *testing.T) {
+	a := &mockOutput{failWrite: true}
+	b := &mockOutput{failWrite: true}
+	g, _ := newTestOutputGroup(GroupModeFailover, a, b)
+	require.NoError(t, g.Connect())
+
+	require.Error(t, g.Write(first5))
+	require.Error(t, g.Write(first5))
+	require.Len(t, g.Healthy(), 0)
+}
+
+func TestOutputGroup_RunningOutputRetries(t *testing.T) {
+	a := &mockOutput{failWrite: true}
+	b := &mockOutput{failWrite: true}
+	g, now := newTestOutputGroup(GroupModeFailover, a, b)
+
+	ro := NewRunningOutput("test", g, &OutputConfig{}, 1000, 10000)
+	require.NoError(t, ro.Connect())
+
+	for _, m := range first5 {
+		ro.AddMetric(m)
+	}
+	require.Error(t, ro.Write())
+
+	// Metrics stay buffered until an output recovers
+	b.failWrite = false
+	*now = now.Add(time.Minute)
+	require.NoError(t, ro.Write())
+	require.Len(t, b.Metrics(), 5)
+}
@@ -16,6 +16,10 @@ type RunningAggregator struct {
 	periodStart time.Time
 	periodEnd   time.Time
 
+	// Fingerprint is a checksum of the plugin settings, it is used to find
+	// unchanged plugins when the configuration is reloaded.
+	Fingerprint string
+
 	MetricsPushed   selfstat.Stat
 	MetricsFiltered selfstat.Stat
 	MetricsDropped  selfstat.Stat
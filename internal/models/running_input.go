@@ -1,6 +1,9 @@
 package models
 
 import (
+	"context"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -13,10 +16,26 @@ type RunningInput struct {
 	Input  telegraf.Input
 	Config *InputConfig
 
-	defaultTags map[string]string
+	// Fingerprint is a checksum of the plugin settings, it is used to find
+	// unchanged plugins when the configuration is reloaded.
+	Fingerprint string
+
+	// mu protects the settings that may change while the input is running.
+	mu            sync.RWMutex
+	defaultTags   map[string]string
+	seriesLimiter *SeriesLimiter
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+	GatherErrors    selfstat.Stat
+
+	errorsMu        sync.Mutex
+	errors          int64 // number of errors reported
+	cycleStart      int64 // number of errors when the last gather started
+	lastCycleErrors int64 // number of errors during the previous gather
+
+	startMu sync.Mutex
+	started bool // set when the service input is running
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -33,19 +52,51 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"gather_time_ns",
 			map[string]string{"input": config.Name},
 		),
+		GatherErrors: selfstat.Register(
+			"gather",
+			"errors",
+			map[string]string{"input": config.Name},
+		),
 	}
 }
 
+// RouteTag holds the outputs a metric is routed to while it passes through
+// the processors and aggregators, it is removed before the metric is added to
+// an output.
+const RouteTag = "_telegraf_route"
+
 // InputConfig is the common config for all inputs.
 type InputConfig struct {
 	Name     string
+	Alias    string
 	Interval time.Duration
 
+	// CollectionJitter overrides the collection jitter of the agent.
+	CollectionJitter time.Duration
+	// Precision overrides the precision of the agent, it also applies to
+	// the metrics of service inputs.
+	Precision time.Duration
+	// GatherTimeout is the time after which a gather is abandoned.
+	GatherTimeout time.Duration
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// Outputs limits the outputs that receive the metrics of the input, by
+	// name or alias.  All outputs receive the metrics when empty.
+	Outputs []string
+
+	// StartupErrorBehavior is what the agent does when a service input fails
+	// to start, one of the StartupErrorBehavior constants.
+	StartupErrorBehavior string
+
+	// MaxSeries overrides the series limit of the agent, SeriesOverflow
+	// overrides its series overflow policy.
+	MaxSeries      int
+	SeriesOverflow string
 }
 
 func (r *RunningInput) Name() string {
@@ -62,13 +113,17 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	r.mu.RLock()
+	defaultTags, limiter := r.defaultTags, r.seriesLimiter
+	r.mu.RUnlock()
+
 	m := makemetric(
 		metric,
 		r.Config.NameOverride,
 		r.Config.MeasurementPrefix,
 		r.Config.MeasurementSuffix,
 		r.Config.Tags,
-		r.defaultTags)
+		defaultTags)
 
 	r.Config.Filter.Modify(metric)
 	if len(metric.FieldList()) == 0 {
@@ -76,19 +131,133 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	if limiter != nil && !limiter.Apply(m, r.Config.Tags, defaultTags) {
+		r.metricFiltered(metric)
+		return nil
+	}
+
+	if len(r.Config.Outputs) > 0 {
+		m.AddTag(RouteTag, strings.Join(r.Config.Outputs, ","))
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
 }
 
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	return r.GatherContext(context.Background(), acc)
+}
+
+// GatherContext gathers the input, inputs implementing ContextInput stop
+// gathering when the context is done.
+func (r *RunningInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	r.errorsMu.Lock()
+	r.lastCycleErrors = r.errors - r.cycleStart
+	r.cycleStart = r.errors
+	r.errorsMu.Unlock()
+
 	start := time.Now()
-	err := r.Input.Gather(acc)
+	var err error
+	if input, ok := r.Input.(telegraf.ContextInput); ok {
+		err = input.GatherContext(ctx, acc)
+	} else {
+		err = r.Input.Gather(acc)
+	}
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
 	return err
 }
 
+// Start starts the input if it is a service input.
+func (r *RunningInput) Start(acc telegraf.Accumulator) error {
+	si, ok := r.Input.(telegraf.ServiceInput)
+	if !ok {
+		return nil
+	}
+
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+	if r.started {
+		return nil
+	}
+	if err := si.Start(acc); err != nil {
+		return err
+	}
+	r.started = true
+	return nil
+}
+
+// Stop stops the input if it is a running service input.
+func (r *RunningInput) Stop() {
+	si, ok := r.Input.(telegraf.ServiceInput)
+	if !ok {
+		return
+	}
+
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+	if r.started {
+		si.Stop()
+		r.started = false
+	}
+}
+
+// Started returns false for service inputs that are not running, they must
+// not be gathered.
+func (r *RunningInput) Started() bool {
+	if _, ok := r.Input.(telegraf.ServiceInput); !ok {
+		return true
+	}
+
+	r.startMu.Lock()
+	defer r.startMu.Unlock()
+	return r.started
+}
+
+// IncrErrors counts an error reported by the input.
+func (r *RunningInput) IncrErrors() {
+	r.errorsMu.Lock()
+	r.errors++
+	r.errorsMu.Unlock()
+	r.GatherErrors.Incr(1)
+}
+
+// Failing returns true if the input reported errors during its current or
+// previous gather.
+func (r *RunningInput) Failing() bool {
+	r.errorsMu.Lock()
+	defer r.errorsMu.Unlock()
+	return r.lastCycleErrors > 0 || r.errors > r.cycleStart
+}
+
+// LimitSeries limits the number of series of the input, a limit of 0 removes
+// the limit.  The series already seen are kept when the limit is unchanged.
+func (r *RunningInput) LimitSeries(limit int, overflow string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit <= 0 {
+		r.seriesLimiter = nil
+		return nil
+	}
+	limiter, err := NewSeriesLimiter(r.Config.Name, limit, overflow)
+	if err != nil {
+		return err
+	}
+	if prev := r.seriesLimiter; prev != nil && prev.limit == limiter.limit &&
+		prev.overflow == limiter.overflow {
+		return nil
+	}
+	r.seriesLimiter = limiter
+	return nil
+}
+
+// SetDefaultTags sets the global tags added to the metrics, it may be
+// called while the input is running.  The map must not be modified
+// afterwards.
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
+	r.mu.Lock()
 	r.defaultTags = tags
+	r.mu.Unlock()
 }
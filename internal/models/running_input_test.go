@@ -145,6 +145,25 @@ func TestMakeMetricFilteredOut(t *testing.T) {
 	assert.Nil(t, m)
 }
 
+func TestMakeMetricRouted(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:    "TestRunningInput",
+		Outputs: []string{"db", "file"},
+	})
+
+	m := testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		time.Unix(0, 0))
+	m = ri.MakeMetric(m)
+
+	route, ok := m.GetTag(RouteTag)
+	require.True(t, ok)
+	require.Equal(t, "db,file", route)
+}
+
 func TestMakeMetricWithDaemonTags(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&testInput{}, &InputConfig{
@@ -256,8 +275,73 @@ func TestMakeMetricNameSuffix(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricNameOverridePrefixSuffix(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:              "TestRunningInput",
+		NameOverride:      "foobar",
+		MeasurementPrefix: "pre_",
+		MeasurementSuffix: "_suf",
+	})
+
+	m := ri.MakeMetric(testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{"value": 101},
+		time.Unix(0, 0)))
+	require.Equal(t, "pre_foobar_suf", m.Name())
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
 func (t *testInput) SampleConfig() string                  { return "" }
 func (t *testInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func TestMakeMetricSeriesLimitDrop(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{Name: "TestRunningInput"})
+	require.NoError(t, ri.LimitSeries(2, ""))
+
+	now := time.Now()
+	for _, host := range []string{"a", "b", "a", "c"} {
+		m, err := metric.New("cpu", map[string]string{"host": host},
+			map[string]interface{}{"value": 42}, now)
+		require.NoError(t, err)
+
+		actual := ri.MakeMetric(m)
+		if host == "c" {
+			require.Nil(t, actual)
+		} else {
+			require.NotNil(t, actual)
+		}
+	}
+	require.Equal(t, int64(1), ri.seriesLimiter.SeriesDropped.Get())
+}
+
+func TestMakeMetricSeriesLimitHash(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name: "TestRunningInput",
+		Tags: map[string]string{"region": "us-east"},
+	})
+	require.NoError(t, ri.LimitSeries(1, SeriesOverflowHash))
+	ri.SetDefaultTags(map[string]string{"dc": "1"})
+
+	now := time.Now()
+	m, err := metric.New("cpu", map[string]string{"id": "1"},
+		map[string]interface{}{"value": 42}, now)
+	require.NoError(t, err)
+	require.Equal(t, "1", ri.MakeMetric(m).Tags()["id"])
+
+	m, err = metric.New("cpu", map[string]string{"id": "2"},
+		map[string]interface{}{"value": 42}, now)
+	require.NoError(t, err)
+	actual := ri.MakeMetric(m)
+	require.NotNil(t, actual)
+	require.Regexp(t, "^overflow_[0-9]$", actual.Tags()["id"])
+	require.Equal(t, "us-east", actual.Tags()["region"])
+	require.Equal(t, "1", actual.Tags()["dc"])
+	require.Equal(t, int64(1), ri.seriesLimiter.SeriesHashed.Get())
+}
+
+func TestLimitSeriesInvalidOverflow(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{Name: "TestRunningInput"})
+	require.Error(t, ri.LimitSeries(1, "block"))
+}
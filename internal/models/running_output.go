@@ -1,12 +1,17 @@
 package models
 
 import (
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/limiter"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -21,11 +26,57 @@ const (
 // OutputConfig containing name and filter
 type OutputConfig struct {
 	Name   string
+	Alias  string
 	Filter Filter
 
 	FlushInterval     time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+	// MetricBatchBytes limits the serialized size of a batch, there is no
+	// limit if it is 0.
+	MetricBatchBytes int64
+
+	// MaxParallelWrites is the number of batches written at the same time,
+	// one if it is 0.
+	MaxParallelWrites int
+	// MaxBatchesPerSecond and MaxBytesPerSecond limit the rate of the
+	// writes, there is no limit if they are 0.
+	MaxBatchesPerSecond float64
+	MaxBytesPerSecond   int64
+
+	// DeadLetterFile is where the metrics rejected by the output are written,
+	// they are dropped if it is empty.
+	DeadLetterFile string
+
+	// BufferDirectory enables the disk buffer when set.
+	BufferDirectory string
+	BufferMaxSize   int64
+	// BufferOverflow is the policy when the buffer is full, one of
+	// OverflowDropOldest, OverflowDropNewest or OverflowBlock.
+	BufferOverflow string
+
+	// StartupErrorBehavior is what the agent does when the output fails to
+	// connect, one of the StartupErrorBehavior constants.  With retry, the
+	// output is connected again before each write until it succeeds.
+	StartupErrorBehavior string
+}
+
+// metricBuffer holds the metrics of an output until they are written.
+type metricBuffer interface {
+	Len() int
+	Add(metrics ...telegraf.Metric)
+	Batch(batchSize int) []telegraf.Metric
+	Accept(batch []telegraf.Metric)
+	AcceptPartial(batch []telegraf.Metric, written []bool)
+	Reject(batch []telegraf.Metric)
+
+	setOverflow(policy string)
+	release(released bool)
+}
+
+// serializer measures the size of the metrics of a batch.
+type serializer interface {
+	Serialize(metric telegraf.Metric) ([]byte, error)
 }
 
 // RunningOutput contains the output configuration
@@ -39,14 +90,31 @@ type RunningOutput struct {
 	MetricBufferLimit int
 	MetricBatchSize   int
 
+	// Fingerprint is a checksum of the plugin settings, it is used to find
+	// unchanged plugins when the configuration is reloaded.
+	Fingerprint string
+
 	MetricsFiltered selfstat.Stat
+	MetricsRejected selfstat.Stat
 	WriteTime       selfstat.Stat
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
+	buffer metricBuffer
 
 	aggMutex sync.Mutex
+
+	serializerMu sync.Mutex
+	serializer   serializer
+
+	batchThrottle *limiter.Throttle
+	byteThrottle  *limiter.Throttle
+
+	deadLetters *DeadLetterFile
+
+	stateMu   sync.Mutex
+	connected bool
+	writeErr  error // error of the most recent write
 }
 
 func NewRunningOutput(
@@ -68,29 +136,147 @@ func NewRunningOutput(
 	if batchSize == 0 {
 		batchSize = DEFAULT_METRIC_BATCH_SIZE
 	}
+	var buffer metricBuffer
+	if conf.BufferDirectory != "" {
+		maxSize := conf.BufferMaxSize
+		if maxSize == 0 {
+			maxSize = DEFAULT_BUFFER_MAX_SIZE
+		}
+		buffer = NewDiskBuffer(name, conf.BufferDirectory, maxSize)
+	} else {
+		buffer = NewBuffer(name, bufferLimit)
+	}
+	if conf.BufferOverflow != "" {
+		buffer.setOverflow(conf.BufferOverflow)
+	}
 	ro := &RunningOutput{
 		Name:              name,
-		buffer:            NewBuffer(name, bufferLimit),
+		buffer:            buffer,
 		BatchReady:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            conf,
 		MetricBufferLimit: bufferLimit,
 		MetricBatchSize:   batchSize,
+		serializer:        influx.NewSerializer(),
 		MetricsFiltered: selfstat.Register(
 			"write",
 			"metrics_filtered",
 			map[string]string{"output": name},
 		),
+		MetricsRejected: selfstat.Register(
+			"write",
+			"metrics_rejected",
+			map[string]string{"output": name},
+		),
 		WriteTime: selfstat.RegisterTiming(
 			"write",
 			"write_time_ns",
 			map[string]string{"output": name},
 		),
 	}
+	if conf.MaxBatchesPerSecond > 0 {
+		ro.batchThrottle = limiter.NewThrottle(conf.MaxBatchesPerSecond)
+	}
+	if conf.MaxBytesPerSecond > 0 {
+		ro.byteThrottle = limiter.NewThrottle(float64(conf.MaxBytesPerSecond))
+	}
+	if conf.DeadLetterFile != "" {
+		ro.deadLetters = NewDeadLetterFile(conf.DeadLetterFile)
+	}
 
 	return ro
 }
 
+// SetSerializer sets the serializer used to measure the size of batches, it
+// should produce the data format of the output.  It is needed by the
+// MetricBatchBytes and MaxBytesPerSecond limits.
+func (ro *RunningOutput) SetSerializer(s serializer) {
+	ro.serializerMu.Lock()
+	ro.serializer = s
+	ro.serializerMu.Unlock()
+}
+
+// OpenBuffer loads the metrics left in the disk buffer by a previous run, it
+// must be called before metrics are added.
+func (ro *RunningOutput) OpenBuffer() error {
+	if b, ok := ro.buffer.(*DiskBuffer); ok {
+		return b.Open()
+	}
+	return nil
+}
+
+// CloseBuffer closes the disk buffer, metrics not yet written are kept for
+// the next run.
+func (ro *RunningOutput) CloseBuffer() error {
+	if b, ok := ro.buffer.(*DiskBuffer); ok {
+		return b.Close()
+	}
+	return nil
+}
+
+// ReleaseBuffer stops adds to a full buffer from blocking, the oldest
+// metrics are dropped instead.  It is used to drain the metrics of a stopping
+// agent and is reset with ReleaseBuffer(false).
+func (ro *RunningOutput) ReleaseBuffer(released bool) {
+	ro.buffer.release(released)
+}
+
+// Connect connects the output plugin.
+func (ro *RunningOutput) Connect() error {
+	err := ro.Output.Connect()
+
+	ro.stateMu.Lock()
+	ro.connected = err == nil
+	ro.stateMu.Unlock()
+	return err
+}
+
+// Close closes the output plugin.
+func (ro *RunningOutput) Close() error {
+	ro.stateMu.Lock()
+	connected := ro.connected
+	ro.connected = false
+	ro.stateMu.Unlock()
+
+	// An output still retrying to connect was never opened.
+	if !connected && ro.Config.StartupErrorBehavior == StartupErrorBehaviorRetry {
+		return nil
+	}
+	return ro.Output.Close()
+}
+
+// Status returns whether the output is connected and the error of the most
+// recent write.
+func (ro *RunningOutput) Status() (bool, error) {
+	ro.stateMu.Lock()
+	defer ro.stateMu.Unlock()
+	return ro.connected, ro.writeErr
+}
+
+// BufferFullness returns the fill level of the buffer between 0 and 1.
+func (ro *RunningOutput) BufferFullness() float64 {
+	if b, ok := ro.buffer.(*DiskBuffer); ok {
+		return float64(b.Size()) / float64(b.maxSize)
+	}
+	return float64(ro.buffer.Len()) / float64(ro.MetricBufferLimit)
+}
+
+// BufferLength returns the number of metrics in the buffer.
+func (ro *RunningOutput) BufferLength() int {
+	return ro.buffer.Len()
+}
+
+// Routed returns true if the route, the value of the RouteTag, names the
+// output or its alias.
+func (ro *RunningOutput) Routed(route string) bool {
+	for _, name := range strings.Split(route, ",") {
+		if name == ro.Config.Name || (ro.Config.Alias != "" && name == ro.Config.Alias) {
+			return true
+		}
+	}
+	return false
+}
+
 func (ro *RunningOutput) metricFiltered(metric telegraf.Metric) {
 	ro.MetricsFiltered.Incr(1)
 	metric.Drop()
@@ -146,37 +332,218 @@ func (ro *RunningOutput) Write() error {
 	// Only process the metrics in the buffer now.  Metrics added while we are
 	// writing will be sent on the next call.
 	nBuffer := ro.buffer.Len()
-	nBatches := nBuffer/ro.MetricBatchSize + 1
-	for i := 0; i < nBatches; i++ {
-		batch := ro.buffer.Batch(ro.MetricBatchSize)
-		if len(batch) == 0 {
-			break
-		}
-
-		err := ro.write(batch)
+	for nBuffer > 0 {
+		n, err := ro.writeBatches()
 		if err != nil {
-			ro.buffer.Reject(batch)
 			return err
 		}
-		ro.buffer.Accept(batch)
+		if n == 0 {
+			break
+		}
+		nBuffer -= n
 	}
 	return nil
 }
 
-// WriteBatch writes a single batch of metrics to the output.
+// WriteBatch writes the next batch of metrics to the output, or the next
+// MaxParallelWrites batches.
 func (ro *RunningOutput) WriteBatch() error {
-	batch := ro.buffer.Batch(ro.MetricBatchSize)
-	if len(batch) == 0 {
-		return nil
+	_, err := ro.writeBatches()
+	return err
+}
+
+// batchSize is the number of metrics of a batch and their serialized size.
+type batchSize struct {
+	count int
+	bytes int64
+}
+
+// writeBatches takes up to MaxParallelWrites batches from the buffer and
+// writes them at the same time, it returns the number of metrics written.
+// The buffer has a single batch out at a time, so the batches are taken as
+// one; when a write fails only the metrics of the failed batches are returned
+// to the buffer.
+func (ro *RunningOutput) writeBatches() (int, error) {
+	parallel := ro.Config.MaxParallelWrites
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	taken := ro.buffer.Batch(ro.MetricBatchSize * parallel)
+	if len(taken) == 0 {
+		return 0, nil
+	}
+
+	sizes := ro.batchSizes(taken, parallel)
+	n := 0
+	for _, size := range sizes {
+		n += size.count
+	}
+	if n < len(taken) {
+		// The buffers return the same metrics first, so the batch is
+		// returned and taken again with the metrics that fit.
+		ro.buffer.Reject(taken)
+		taken = ro.buffer.Batch(n)
+	}
+
+	if err := ro.connectOnWrite(); err != nil {
+		ro.buffer.Reject(taken)
+		return 0, err
+	}
+
+	errs := make([]error, len(sizes))
+	var wg sync.WaitGroup
+	first := 0
+	for i, size := range sizes {
+		batch := taken[first : first+size.count]
+		first += size.count
+
+		ro.throttle(size)
+		if len(sizes) == 1 {
+			errs[i] = ro.write(batch)
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = ro.write(batch)
+		}(i)
+	}
+	wg.Wait()
+
+	first = 0
+	for i, size := range sizes {
+		batch := taken[first : first+size.count]
+		first += size.count
+
+		if rerr, ok := errs[i].(*internal.RejectedError); ok {
+			ro.rejected(batch, rerr)
+			errs[i] = nil
+		}
+	}
+
+	// Only the metrics of the failed batches are kept to be written again.
+	var failed error
+	written := make([]bool, len(taken))
+	first = 0
+	for i, size := range sizes {
+		if errs[i] != nil {
+			if failed == nil {
+				failed = errs[i]
+			}
+		} else {
+			for j := first; j < first+size.count; j++ {
+				written[j] = true
+			}
+		}
+		first += size.count
+	}
+
+	if failed == nil {
+		ro.buffer.Accept(taken)
+		return len(taken), nil
+	}
+	if len(sizes) == 1 {
+		ro.buffer.Reject(taken)
+		return 0, failed
 	}
+	ro.buffer.AcceptPartial(taken, written)
+	return 0, failed
+}
+
+// batchSizes splits the metrics into at most max batches of at most
+// MetricBatchSize metrics and MetricBatchBytes bytes.  A batch always has at
+// least one metric.  The metrics are only serialized when a limit in bytes
+// is set.
+func (ro *RunningOutput) batchSizes(metrics []telegraf.Metric, max int) []batchSize {
+	measure := ro.Config.MetricBatchBytes > 0 || ro.byteThrottle != nil
+	if measure {
+		ro.serializerMu.Lock()
+		defer ro.serializerMu.Unlock()
+	}
+
+	sizes := make([]batchSize, 0, max)
+	var cur batchSize
+	for _, metric := range metrics {
+		var n int64
+		if measure {
+			octets, err := ro.serializer.Serialize(metric)
+			if err != nil {
+				// The output reports metrics it can not serialize, they
+				// are counted as empty.
+				octets = nil
+			}
+			n = int64(len(octets))
+		}
 
-	err := ro.write(batch)
-	if err != nil {
-		ro.buffer.Reject(batch)
-		return err
+		full := cur.count == ro.MetricBatchSize ||
+			(ro.Config.MetricBatchBytes > 0 && cur.count > 0 &&
+				cur.bytes+n > ro.Config.MetricBatchBytes)
+		if full {
+			sizes = append(sizes, cur)
+			if len(sizes) == max {
+				return sizes
+			}
+			cur = batchSize{}
+		}
+		cur.count++
+		cur.bytes += n
 	}
-	ro.buffer.Accept(batch)
+	return append(sizes, cur)
+}
+
+// rejected writes the metrics of the batch rejected by the output to the
+// dead letter file.  They are dropped either way, a rejected batch would fail
+// again.
+func (ro *RunningOutput) rejected(batch []telegraf.Metric, err *internal.RejectedError) {
+	metrics := err.Metrics
+	if metrics == nil {
+		metrics = batch
+	}
+	ro.MetricsRejected.Incr(int64(len(metrics)))
+
+	if ro.deadLetters == nil {
+		log.Printf("E! [outputs.%s] Dropping %d rejected metrics: %s",
+			ro.Name, len(metrics), err.Reason)
+		return
+	}
+	if werr := ro.deadLetters.Write(metrics, err.Reason); werr != nil {
+		log.Printf("E! [outputs.%s] Dropping %d rejected metrics, writing to dead letter file failed: %v: %s",
+			ro.Name, len(metrics), werr, err.Reason)
+		return
+	}
+	log.Printf("W! [outputs.%s] Wrote %d rejected metrics to dead letter file: %s",
+		ro.Name, len(metrics), err.Reason)
+}
 
+// throttle waits until the batch can be written within the rate limits.
+func (ro *RunningOutput) throttle(size batchSize) {
+	if ro.batchThrottle != nil {
+		ro.batchThrottle.Wait(1)
+	}
+	if ro.byteThrottle != nil {
+		ro.byteThrottle.Wait(float64(size.bytes))
+	}
+}
+
+// connectOnWrite connects an output that failed to connect on startup with
+// the retry behavior.
+func (ro *RunningOutput) connectOnWrite() error {
+	if ro.Config.StartupErrorBehavior != StartupErrorBehaviorRetry {
+		return nil
+	}
+
+	ro.stateMu.Lock()
+	connected := ro.connected
+	ro.stateMu.Unlock()
+
+	if !connected {
+		if err := ro.Connect(); err != nil {
+			return fmt.Errorf("output is not connected: %v", err)
+		}
+		log.Printf("I! [outputs.%s] Connected after failing to connect on startup",
+			ro.Name)
+	}
 	return nil
 }
 
@@ -186,6 +553,15 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	elapsed := time.Since(start)
 	ro.WriteTime.Incr(elapsed.Nanoseconds())
 
+	// A rejected batch is invalid data, the output itself did not fail.
+	writeErr := err
+	if _, ok := err.(*internal.RejectedError); ok {
+		writeErr = nil
+	}
+	ro.stateMu.Lock()
+	ro.writeErr = writeErr
+	ro.stateMu.Unlock()
+
 	if err == nil {
 		log.Printf("D! [outputs.%s] wrote batch of %d metrics in %s\n",
 			ro.Name, len(metrics), elapsed)
@@ -195,6 +571,11 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 
 func (ro *RunningOutput) LogBufferStatus() {
 	nBuffer := ro.buffer.Len()
+	if b, ok := ro.buffer.(*DiskBuffer); ok {
+		log.Printf("D! [outputs.%s] buffer fullness: %d metrics, %d / %d bytes. ",
+			ro.Name, nBuffer, b.Size(), b.maxSize)
+		return
+	}
 	log.Printf("D! [outputs.%s] buffer fullness: %d / %d metrics. ",
 		ro.Name, nBuffer, ro.MetricBufferLimit)
 }
@@ -2,10 +2,17 @@ package models
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -217,6 +224,18 @@ func TestRunningOutput_TagIncludeMatch(t *testing.T) {
 }
 
 // Test that we can write metrics with simple default setup.
+func TestRunningOutput_Routed(t *testing.T) {
+	ro := NewRunningOutput("test", &mockOutput{}, &OutputConfig{
+		Name:  "file",
+		Alias: "logs",
+	}, 1000, 10000)
+
+	assert.True(t, ro.Routed("file"))
+	assert.True(t, ro.Routed("db,logs"))
+	assert.False(t, ro.Routed("db"))
+	assert.False(t, ro.Routed(""))
+}
+
 func TestRunningOutputDefault(t *testing.T) {
 	conf := &OutputConfig{
 		Filter: Filter{},
@@ -412,6 +431,267 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+// Verify that metrics in the disk buffer are written after a restart.
+func TestRunningOutputDiskBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "running_output")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		BufferDirectory: dir,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 4, 2)
+	require.NoError(t, ro.OpenBuffer())
+
+	// The buffer limit does not apply to the disk buffer
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	require.Error(t, ro.Write())
+	require.NoError(t, ro.CloseBuffer())
+
+	m = &mockOutput{}
+	ro = NewRunningOutput("test", m, conf, 4, 2)
+	require.NoError(t, ro.OpenBuffer())
+	defer ro.CloseBuffer()
+
+	require.NoError(t, ro.Write())
+	testutil.RequireMetricsEqual(t, first5, m.Metrics())
+}
+
+func TestRunningOutputBatchBytes(t *testing.T) {
+	octets, err := influx.NewSerializer().Serialize(first5[0])
+	require.NoError(t, err)
+
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		MetricBatchBytes: int64(2*len(octets) + 1),
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// Only two metrics fit in a batch
+	require.NoError(t, ro.WriteBatch())
+	assert.Len(t, m.Metrics(), 2)
+	assert.Equal(t, 3, ro.BufferLength())
+
+	// Write sends all the metrics in several batches
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 5)
+	assert.Equal(t, 0, ro.BufferLength())
+}
+
+func TestRunningOutputBatchBytesLargeMetric(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		MetricBatchBytes: 1,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// A metric larger than the limit is written alone
+	require.NoError(t, ro.WriteBatch())
+	assert.Len(t, m.Metrics(), 1)
+
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 5)
+}
+
+func TestRunningOutputParallelWrites(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:            Filter{},
+		MaxParallelWrites: 3,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 2, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+
+	// Three batches are written at once
+	require.NoError(t, ro.WriteBatch())
+	assert.Len(t, m.Metrics(), 6)
+	assert.Equal(t, 4, ro.BufferLength())
+
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 10)
+	assert.Equal(t, 0, ro.BufferLength())
+}
+
+func TestRunningOutputParallelWritesFail(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:            Filter{},
+		MaxParallelWrites: 3,
+	}
+
+	m := &mockOutput{failWrite: true}
+	ro := NewRunningOutput("test", m, conf, 2, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// All the batches are kept when a write fails
+	require.Error(t, ro.Write())
+	assert.Equal(t, 5, ro.BufferLength())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 5)
+	assert.Equal(t, 0, ro.BufferLength())
+}
+
+func TestRunningOutputParallelWritesPartialFail(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:            Filter{},
+		MaxParallelWrites: 3,
+	}
+
+	m := &mockOutput{failName: "metric3"}
+	ro := NewRunningOutput("test", m, conf, 2, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// Only the failed batch is kept
+	require.Error(t, ro.Write())
+	assert.Len(t, m.Metrics(), 3)
+	assert.Equal(t, 2, ro.BufferLength())
+
+	m.failName = ""
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 5)
+	assert.Equal(t, 0, ro.BufferLength())
+}
+
+func TestRunningOutputMaxBatchesPerSecond(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		MaxBatchesPerSecond: 20,
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1, 100)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// The first batch is written at once, the next four 50ms apart
+	start := time.Now()
+	require.NoError(t, ro.Write())
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	assert.Len(t, m.Metrics(), 5)
+}
+
+func TestRunningOutputMaxBytesPerSecond(t *testing.T) {
+	octets, err := influx.NewSerializer().Serialize(first5[0])
+	require.NoError(t, err)
+
+	conf := &OutputConfig{
+		Filter:            Filter{},
+		MaxBytesPerSecond: int64(len(octets) * 10),
+	}
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1, 100)
+
+	for _, metric := range first5[:3] {
+		ro.AddMetric(metric)
+	}
+
+	// Each batch of a metric waits about a tenth of a second for the last
+	start := time.Now()
+	require.NoError(t, ro.Write())
+	assert.True(t, time.Since(start) >= 150*time.Millisecond)
+	assert.Len(t, m.Metrics(), 3)
+}
+
+func TestRunningOutputDeadLetterFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dead_letter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conf := &OutputConfig{
+		Filter:         Filter{},
+		DeadLetterFile: filepath.Join(dir, "rejected.out"),
+	}
+
+	m := &mockOutput{rejectWrite: true}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// Rejected metrics are not retried
+	require.NoError(t, ro.Write())
+	assert.Equal(t, 0, ro.BufferLength())
+	assert.Equal(t, int64(5), ro.MetricsRejected.Get())
+
+	// The output did not fail
+	_, err = ro.Status()
+	assert.NoError(t, err)
+
+	octets, err := ioutil.ReadFile(conf.DeadLetterFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(octets)), "\n")
+	require.Len(t, lines, 6)
+	assert.True(t, strings.HasPrefix(lines[0], "# "))
+	assert.True(t, strings.HasSuffix(lines[0], " invalid data"))
+	for _, line := range lines[1:] {
+		assert.True(t, strings.HasPrefix(line, "metric"))
+	}
+}
+
+func TestRunningOutputStartupErrorRetry(t *testing.T) {
+	conf := &OutputConfig{
+		StartupErrorBehavior: StartupErrorBehaviorRetry,
+	}
+
+	m := &mockOutput{failConnect: true}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	require.Error(t, ro.Connect())
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// Metrics stay buffered until the output connects
+	require.Error(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	m.Lock()
+	m.failConnect = false
+	m.Unlock()
+
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 5)
+
+	connected, _ := ro.Status()
+	assert.True(t, connected)
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -419,9 +699,20 @@ type mockOutput struct {
 
 	// if true, mock a write failure
 	failWrite bool
+	// if true, mock the metrics being rejected
+	rejectWrite bool
+	// if true, mock a connect failure
+	failConnect bool
+	// if set, mock a write failure of the batches with a metric of this name
+	failName string
 }
 
 func (m *mockOutput) Connect() error {
+	m.Lock()
+	defer m.Unlock()
+	if m.failConnect {
+		return fmt.Errorf("Failed Connect!")
+	}
 	return nil
 }
 
@@ -443,6 +734,14 @@ func (m *mockOutput) Write(metrics []telegraf.Metric) error {
 	if m.failWrite {
 		return fmt.Errorf("Failed Write!")
 	}
+	if m.rejectWrite {
+		return &internal.RejectedError{Reason: "invalid data"}
+	}
+	for _, metric := range metrics {
+		if metric.Name() == m.failName {
+			return fmt.Errorf("Failed Write!")
+		}
+	}
 
 	if m.metrics == nil {
 		m.metrics = []telegraf.Metric{}
@@ -463,6 +762,8 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 type perfOutput struct {
 	// if true, mock a write failure
 	failWrite bool
+	// if true, mock the metrics being rejected
+	rejectWrite bool
 }
 
 func (m *perfOutput) Connect() error {
@@ -485,5 +786,8 @@ func (m *perfOutput) Write(metrics []telegraf.Metric) error {
 	if m.failWrite {
 		return fmt.Errorf("Failed Write!")
 	}
+	if m.rejectWrite {
+		return &internal.RejectedError{Reason: "invalid data"}
+	}
 	return nil
 }
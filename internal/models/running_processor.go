@@ -12,6 +12,10 @@ type RunningProcessor struct {
 	sync.Mutex
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+
+	// Fingerprint is a checksum of the plugin settings, it is used to find
+	// unchanged plugins when the configuration is reloaded.
+	Fingerprint string
 }
 
 type RunningProcessors []*RunningProcessor
@@ -62,8 +66,25 @@ func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 
 		// This metric should pass through the filter, so call the filter Apply
 		// function and append results to the output slice.
-		ret = append(ret, rp.Processor.Apply(metric)...)
+		route, routed := metric.GetTag(RouteTag)
+		out := rp.Processor.Apply(metric)
+		if routed {
+			restoreRoute(metric, route, out)
+		}
+		ret = append(ret, out...)
 	}
 
 	return ret
 }
+
+// restoreRoute sets the route of a metric on the metrics returned by the
+// processor, in case the processor renamed or removed the route tag.  Other
+// metrics returned with a route keep it, they may have been held back by the
+// processor from an earlier call.
+func restoreRoute(in telegraf.Metric, route string, out []telegraf.Metric) {
+	for _, m := range out {
+		if m == in || !m.HasTag(RouteTag) {
+			m.AddTag(RouteTag, route)
+		}
+	}
+}
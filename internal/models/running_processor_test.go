@@ -187,3 +187,39 @@ func TestRunningProcessor_Order(t *testing.T) {
 		RunningProcessors{rp1, rp2, rp3},
 		procs)
 }
+
+func TestRunningProcessor_KeepsRoute(t *testing.T) {
+	held := testutil.MustMetric("cpu",
+		map[string]string{RouteTag: "logs"},
+		map[string]interface{}{"value": 1.0},
+		time.Unix(0, 0))
+
+	// The processor renames the tags and returns a metric held back from
+	// an earlier call.
+	rp := &RunningProcessor{
+		Processor: &MockProcessor{
+			ApplyF: func(in ...telegraf.Metric) []telegraf.Metric {
+				for _, m := range in {
+					for key, value := range m.Tags() {
+						m.RemoveTag(key)
+						m.AddTag("renamed_"+key, value)
+					}
+				}
+				return append(in, held)
+			},
+		},
+		Config: &ProcessorConfig{},
+	}
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{RouteTag: "db"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+	actual := rp.Apply(m)
+	require.Len(t, actual, 2)
+
+	route, _ := actual[0].GetTag(RouteTag)
+	require.Equal(t, "db", route)
+	route, _ = actual[1].GetTag(RouteTag)
+	require.Equal(t, "logs", route)
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Policies for the metrics of new series once the series limit is reached.
+const (
+	// SeriesOverflowDrop drops the metrics.
+	SeriesOverflowDrop = "drop"
+	// SeriesOverflowHash replaces the values of the tags set by the plugin
+	// with one of a few overflow buckets chosen by the hash of the series.
+	SeriesOverflowHash = "hash"
+)
+
+// seriesOverflowBuckets is the number of series each measurement can have
+// past the limit with the hash policy.
+const seriesOverflowBuckets = 10
+
+// SeriesLimiter limits the number of distinct series, the combinations of
+// measurement and tags, of an input.
+type SeriesLimiter struct {
+	sync.Mutex
+	name     string
+	limit    int
+	overflow string
+	series   map[uint64]struct{}
+	warned   bool
+
+	SeriesDropped selfstat.Stat
+	SeriesHashed  selfstat.Stat
+}
+
+// NewSeriesLimiter returns a limiter allowing limit series, the metrics of
+// new series past the limit are handled according to the overflow policy.
+func NewSeriesLimiter(name string, limit int, overflow string) (*SeriesLimiter, error) {
+	switch overflow {
+	case "":
+		overflow = SeriesOverflowDrop
+	case SeriesOverflowDrop, SeriesOverflowHash:
+	default:
+		return nil, fmt.Errorf("invalid series_overflow %q", overflow)
+	}
+
+	return &SeriesLimiter{
+		name:     name,
+		limit:    limit,
+		overflow: overflow,
+		series:   make(map[uint64]struct{}),
+		SeriesDropped: selfstat.Register(
+			"gather",
+			"series_dropped",
+			map[string]string{"input": name},
+		),
+		SeriesHashed: selfstat.Register(
+			"gather",
+			"series_hashed",
+			map[string]string{"input": name},
+		),
+	}, nil
+}
+
+// Apply records the series of the metric and returns false if the metric
+// must be dropped.  Tags in keep are never replaced by the hash policy.
+func (l *SeriesLimiter) Apply(metric telegraf.Metric, keep ...map[string]string) bool {
+	id := metric.HashID()
+
+	l.Lock()
+	defer l.Unlock()
+
+	if _, ok := l.series[id]; ok {
+		return true
+	}
+	if len(l.series) < l.limit {
+		l.series[id] = struct{}{}
+		return true
+	}
+
+	if !l.warned {
+		log.Printf("W! [inputs.%s] Series limit of %d reached, applying series_overflow %q "+
+			"to the metrics of new series", l.name, l.limit, l.overflow)
+		l.warned = true
+	}
+
+	if l.overflow == SeriesOverflowDrop {
+		l.SeriesDropped.Incr(1)
+		return false
+	}
+
+	bucket := fmt.Sprintf("overflow_%d", id%seriesOverflowBuckets)
+	var keys []string
+	for _, tag := range metric.TagList() {
+		if !isKept(tag.Key, keep) {
+			keys = append(keys, tag.Key)
+		}
+	}
+	for _, key := range keys {
+		metric.AddTag(key, bucket)
+	}
+	l.SeriesHashed.Incr(1)
+	return true
+}
+
+func isKept(key string, keep []map[string]string) bool {
+	for _, tags := range keep {
+		if _, ok := tags[key]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package models
+
+// Startup error behaviors, they define what the agent does when an output
+// fails to connect or a service input fails to start.
+const (
+	// StartupErrorBehaviorError stops the agent with the error, it is the
+	// behavior when none is set.
+	StartupErrorBehaviorError = "error"
+	// StartupErrorBehaviorRetry keeps the agent running and retries to
+	// connect or start the plugin until it succeeds.
+	StartupErrorBehaviorRetry = "retry"
+	// StartupErrorBehaviorIgnore keeps the agent running without the
+	// plugin.
+	StartupErrorBehaviorIgnore = "ignore"
+)
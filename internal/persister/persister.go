@@ -0,0 +1,98 @@
+package persister
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Persister saves and restores the state of stateful plugins using a JSON
+// file.
+type Persister struct {
+	Filename string
+
+	plugins map[string]telegraf.StatefulPlugin
+}
+
+// NewPersister returns a Persister using the given state file.
+func NewPersister(filename string) *Persister {
+	return &Persister{
+		Filename: filename,
+		plugins:  make(map[string]telegraf.StatefulPlugin),
+	}
+}
+
+// Register adds a plugin whose state is identified by id, the id must be
+// stable across restarts.
+func (p *Persister) Register(id string, plugin telegraf.StatefulPlugin) error {
+	if _, ok := p.plugins[id]; ok {
+		return fmt.Errorf("plugin id %q is not unique", id)
+	}
+	p.plugins[id] = plugin
+	return nil
+}
+
+// Load restores the state of the registered plugins from the state file.  It
+// is not an error if the state file does not exist yet.
+func (p *Persister) Load() error {
+	data, err := ioutil.ReadFile(p.Filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var states map[string]json.RawMessage
+	if err := json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("unable to parse state file %s: %v", p.Filename, err)
+	}
+
+	for id, plugin := range p.plugins {
+		raw, ok := states[id]
+		if !ok {
+			continue
+		}
+
+		// Decode into a value of the same type the plugin uses for its state.
+		var state interface{}
+		if current := plugin.GetState(); current != nil {
+			value := reflect.New(reflect.TypeOf(current))
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return fmt.Errorf("unable to decode state of plugin %q: %v", id, err)
+			}
+			state = value.Elem().Interface()
+		} else if err := json.Unmarshal(raw, &state); err != nil {
+			return fmt.Errorf("unable to decode state of plugin %q: %v", id, err)
+		}
+
+		if err := plugin.SetState(state); err != nil {
+			return fmt.Errorf("unable to restore state of plugin %q: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// Store writes the state of all registered plugins to the state file.  The
+// file is replaced atomically so a crash does not leave a partial state.
+func (p *Persister) Store() error {
+	states := make(map[string]interface{}, len(p.plugins))
+	for id, plugin := range p.plugins {
+		states[id] = plugin.GetState()
+	}
+
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+
+	tmpfile := p.Filename + ".tmp"
+	if err := ioutil.WriteFile(tmpfile, data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmpfile, p.Filename)
+}
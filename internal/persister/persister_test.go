@@ -0,0 +1,91 @@
+package persister
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type offsets struct {
+	Offsets map[string]int64
+}
+
+type mockPlugin struct {
+	state offsets
+}
+
+func (m *mockPlugin) GetState() interface{} {
+	return m.state
+}
+
+func (m *mockPlugin) SetState(state interface{}) error {
+	m.state = state.(offsets)
+	return nil
+}
+
+type counterPlugin struct {
+	count int64
+}
+
+func (c *counterPlugin) GetState() interface{} {
+	return c.count
+}
+
+func (c *counterPlugin) SetState(state interface{}) error {
+	c.count = state.(int64)
+	return nil
+}
+
+func TestStoreLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "persister")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "state.json")
+
+	p := NewPersister(filename)
+	require.NoError(t, p.Register("tail", &mockPlugin{
+		state: offsets{Offsets: map[string]int64{"/var/log/syslog": 42}},
+	}))
+	require.NoError(t, p.Register("counter", &counterPlugin{count: 7}))
+	require.NoError(t, p.Store())
+
+	tail := &mockPlugin{}
+	counter := &counterPlugin{}
+	other := &counterPlugin{count: 1}
+	p = NewPersister(filename)
+	require.NoError(t, p.Register("tail", tail))
+	require.NoError(t, p.Register("counter", counter))
+	require.NoError(t, p.Register("other", other))
+	require.NoError(t, p.Load())
+
+	require.Equal(t, map[string]int64{"/var/log/syslog": 42}, tail.state.Offsets)
+	require.Equal(t, int64(7), counter.count)
+	require.Equal(t, int64(1), other.count)
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	p := NewPersister(filepath.Join(os.TempDir(), "telegraf-missing-state.json"))
+	require.NoError(t, p.Register("counter", &counterPlugin{}))
+	require.NoError(t, p.Load())
+}
+
+func TestLoadInvalidFile(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "state")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("{")
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	p := NewPersister(tmpfile.Name())
+	require.Error(t, p.Load())
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	p := NewPersister("state.json")
+	require.NoError(t, p.Register("counter", &counterPlugin{}))
+	require.Error(t, p.Register("counter", &counterPlugin{}))
+}
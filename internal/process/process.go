@@ -0,0 +1,281 @@
+// Package process runs long lived external processes on behalf of plugins.
+package process
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+// KillTimeout is the time a process is given to exit after its stdin is
+// closed before it is killed.
+var KillTimeout = 5 * time.Second
+
+// Process is an external process that is restarted when it exits.  The
+// process receives data on its stdin and its stdout and stderr are passed to
+// the read functions.
+type Process struct {
+	Command      []string
+	RestartDelay time.Duration
+
+	// Log is the logger of the plugin running the process.
+	Log telegraf.Logger
+
+	// ReadStdout and ReadStderr are called with the output of each started
+	// process, they must read until the end of the output.
+	ReadStdout func(io.Reader)
+	ReadStderr func(io.Reader)
+
+	// mu protects the running command, it is not held while writing so that
+	// Stop can close the stdin of a process that is not reading it.
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stopped bool
+	cancel  context.CancelFunc
+
+	// writeMu serializes the writes to stdin.
+	writeMu sync.Mutex
+
+	readers sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+// New returns a Process for the command, the first element is the program
+// and the remaining elements are its arguments.  The messages about the
+// process are logged with the logger of the plugin.
+func New(command []string, log telegraf.Logger) (*Process, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no command specified")
+	}
+
+	return &Process{
+		Command:      command,
+		Log:          log,
+		RestartDelay: 10 * time.Second,
+		ReadStdout:   discard,
+		ReadStderr:   discard,
+	}, nil
+}
+
+// Start starts the process, it is restarted after RestartDelay whenever it
+// exits until Stop is called.
+func (p *Process) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	if err := p.start(); err != nil {
+		cancel()
+		return err
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx)
+	}()
+	return nil
+}
+
+// Stop closes the stdin of the process and waits for it to exit.  The process
+// is killed if it does not exit within KillTimeout.
+func (p *Process) Stop() {
+	p.cancel()
+
+	// No process is started once stopped, so the command killed below is the
+	// last one started.
+	p.mu.Lock()
+	p.stopped = true
+	p.stdin.Close()
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(KillTimeout):
+		p.Log.Warnf("Process %s did not exit, killing it", p.Command[0])
+		p.mu.Lock()
+		cmd := p.cmd
+		p.mu.Unlock()
+		cmd.Process.Kill()
+		<-done
+	}
+}
+
+// Write writes to the stdin of the running process.
+func (p *Process) Write(b []byte) (int, error) {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	p.mu.Lock()
+	stdin := p.stdin
+	p.mu.Unlock()
+
+	if stdin == nil {
+		return 0, errors.New("process is not running")
+	}
+	return stdin.Write(b)
+}
+
+// Signal sends a signal to the running process.
+func (p *Process) Signal(sig os.Signal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil || p.cmd.Process == nil {
+		return errors.New("process is not running")
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+var errStopped = errors.New("process is stopped")
+
+// start starts the command and the goroutines reading its output.
+func (p *Process) start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return errStopped
+	}
+
+	cmd := exec.Command(p.Command[0], p.Command[1:]...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("error starting process %s: %v", p.Command[0], err)
+	}
+
+	p.cmd = cmd
+	p.stdin = stdin
+
+	p.readers.Add(2)
+	go func() {
+		defer p.readers.Done()
+		p.ReadStdout(stdout)
+	}()
+	go func() {
+		defer p.readers.Done()
+		p.ReadStderr(stderr)
+	}()
+	return nil
+}
+
+// run waits for the process to exit and restarts it until the context is
+// done.
+func (p *Process) run(ctx context.Context) {
+	for {
+		// The output must be read completely before calling Wait.
+		p.readers.Wait()
+		p.mu.Lock()
+		cmd := p.cmd
+		p.mu.Unlock()
+		err := cmd.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if err != nil {
+			p.Log.Errorf("Process %s exited: %v", p.Command[0], err)
+		} else {
+			p.Log.Errorf("Process %s exited", p.Command[0])
+		}
+
+		for {
+			p.Log.Infof("Restarting process %s in %s", p.Command[0], p.RestartDelay)
+			if err := internal.SleepContext(ctx, p.RestartDelay); err != nil {
+				return
+			}
+
+			err := p.start()
+			if err == nil {
+				break
+			}
+			if err == errStopped {
+				return
+			}
+			p.Log.Error(err)
+		}
+	}
+}
+
+// ErrLineTooLong is returned by ReadLine for lines longer than the maximum.
+var ErrLineTooLong = errors.New("line too long")
+
+// ReadLine returns the next line including its newline.  Lines longer than
+// max are read completely but skipped and ErrLineTooLong is returned, so that
+// the process is not blocked writing its output.
+func ReadLine(r *bufio.Reader, max int) ([]byte, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(chunk) > max {
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case tooLong:
+			return nil, ErrLineTooLong
+		case err == io.EOF && len(line) > 0:
+			// The last line has no newline, EOF is returned by the next call.
+			return line, nil
+		}
+		return line, err
+	}
+}
+
+// LogStderr returns a read function logging each line as an error with the
+// logger of the plugin.
+func LogStderr(log telegraf.Logger) func(io.Reader) {
+	return func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			log.Errorf("stderr: %q", scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			log.Errorf("Error reading stderr: %v", err)
+		}
+	}
+}
+
+func discard(r io.Reader) {
+	io.Copy(ioutil.Discard, r)
+}
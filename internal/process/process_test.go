@@ -0,0 +1,132 @@
+package process
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNoCommand(t *testing.T) {
+	_, err := New(nil, testutil.Logger{})
+	require.Error(t, err)
+}
+
+func TestWriteRead(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	lines := make(chan string, 1)
+	p, err := New([]string{"cat"}, testutil.Logger{})
+	require.NoError(t, err)
+	p.ReadStdout = func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}
+	require.NoError(t, p.Start())
+
+	_, err = p.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", <-lines)
+
+	p.Stop()
+}
+
+func TestRestart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	var mu sync.Mutex
+	starts := 0
+	p, err := New([]string{"sh", "-c", "echo started"}, testutil.Logger{})
+	require.NoError(t, err)
+	p.RestartDelay = 10 * time.Millisecond
+	p.ReadStdout = func(r io.Reader) {
+		discard(r)
+		mu.Lock()
+		starts++
+		mu.Unlock()
+	}
+	require.NoError(t, p.Start())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := starts
+		mu.Unlock()
+		if n >= 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	require.True(t, starts >= 3)
+	mu.Unlock()
+
+	p.Stop()
+}
+
+func TestStopKillsProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	timeout := KillTimeout
+	KillTimeout = 100 * time.Millisecond
+	defer func() { KillTimeout = timeout }()
+
+	// The process ignores stdin being closed.
+	p, err := New([]string{"sleep", "60"}, testutil.Logger{})
+	require.NoError(t, err)
+	require.NoError(t, p.Start())
+
+	start := time.Now()
+	p.Stop()
+	require.True(t, time.Since(start) < 10*time.Second)
+}
+
+func TestReadLine(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("short\n"+strings.Repeat("a", 40)+"\nlast"), 16)
+
+	line, err := ReadLine(r, 20)
+	require.NoError(t, err)
+	require.Equal(t, "short\n", string(line))
+
+	_, err = ReadLine(r, 20)
+	require.Equal(t, ErrLineTooLong, err)
+
+	line, err = ReadLine(r, 20)
+	require.NoError(t, err)
+	require.Equal(t, "last", string(line))
+
+	_, err = ReadLine(r, 20)
+	require.Equal(t, io.EOF, err)
+}
+
+// errorLogger records the error messages.
+type errorLogger struct {
+	testutil.Logger
+	errors []string
+}
+
+func (l *errorLogger) Errorf(format string, args ...interface{}) {
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+func TestLogStderr(t *testing.T) {
+	log := &errorLogger{}
+	LogStderr(log)(strings.NewReader("first\nsecond\n"))
+	require.Equal(t, []string{`stderr: "first"`, `stderr: "second"`}, log.errors)
+}
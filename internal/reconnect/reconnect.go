@@ -0,0 +1,148 @@
+// Package reconnect implements the reconnect policy shared by the service
+// inputs keeping a connection to a server, with exponential backoff, jitter
+// and a maximum number of attempts.  It is used by the amqp_consumer,
+// mqtt_consumer and cloud_pubsub inputs; listeners such as tcp_listener and
+// udp_listener accept connections instead and have nothing to reconnect.
+package reconnect
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Defaults used for the unset options of a Policy.
+const (
+	DefaultInitialDelay = time.Second
+	DefaultMaxDelay     = time.Minute
+	DefaultJitter       = 0.2
+)
+
+// ErrMaxAttempts is returned by Retry once the maximum number of attempts is
+// reached.
+var ErrMaxAttempts = errors.New("maximum reconnect attempts reached")
+
+// Policy is the reconnect policy of a plugin, plugins embed it to provide the
+// reconnect options.
+type Policy struct {
+	// ReconnectInitialDelay is the delay before the first reconnect, it
+	// doubles after each failed attempt up to ReconnectMaxDelay.
+	ReconnectInitialDelay internal.Duration `toml:"reconnect_initial_delay"`
+	ReconnectMaxDelay     internal.Duration `toml:"reconnect_max_delay"`
+	// ReconnectJitter randomizes each delay by up to this fraction of it,
+	// between 0 and 1, so many agents don't reconnect at the same time.
+	ReconnectJitter float64 `toml:"reconnect_jitter"`
+	// ReconnectMaxAttempts is the number of failed reconnects after which
+	// the plugin gives up, it retries forever if it is 0.
+	ReconnectMaxAttempts int `toml:"reconnect_max_attempts"`
+}
+
+// DefaultPolicy returns the policy used by plugins unless configured.
+func DefaultPolicy() Policy {
+	return Policy{
+		ReconnectInitialDelay: internal.Duration{Duration: DefaultInitialDelay},
+		ReconnectMaxDelay:     internal.Duration{Duration: DefaultMaxDelay},
+		ReconnectJitter:       DefaultJitter,
+	}
+}
+
+// Backoff returns the delays between the reconnects of the policy.
+func (p *Policy) Backoff() *Backoff {
+	b := &Backoff{
+		initial:     p.ReconnectInitialDelay.Duration,
+		max:         p.ReconnectMaxDelay.Duration,
+		jitter:      p.ReconnectJitter,
+		maxAttempts: p.ReconnectMaxAttempts,
+	}
+	if b.initial <= 0 {
+		b.initial = DefaultInitialDelay
+	}
+	if b.max <= 0 {
+		b.max = DefaultMaxDelay
+	}
+	if b.max < b.initial {
+		b.max = b.initial
+	}
+	if b.jitter < 0 {
+		b.jitter = 0
+	} else if b.jitter > 1 {
+		b.jitter = 1
+	}
+	return b
+}
+
+// Backoff computes the delays between reconnects, it is not safe for
+// concurrent use.
+type Backoff struct {
+	initial     time.Duration
+	max         time.Duration
+	jitter      float64
+	maxAttempts int
+
+	attempts int
+}
+
+// Next returns the delay before the next attempt, it returns false once the
+// maximum number of attempts is reached.
+func (b *Backoff) Next() (time.Duration, bool) {
+	if b.maxAttempts > 0 && b.attempts >= b.maxAttempts {
+		return 0, false
+	}
+
+	delay := b.initial
+	for i := 0; i < b.attempts && delay < b.max; i++ {
+		delay *= 2
+	}
+	if delay > b.max {
+		delay = b.max
+	}
+	b.attempts++
+
+	if b.jitter > 0 {
+		delay += time.Duration(b.jitter * (2*rand.Float64() - 1) * float64(delay))
+	}
+	return delay, true
+}
+
+// MaxDelay returns the longest delay between attempts, without jitter.
+func (b *Backoff) MaxDelay() time.Duration {
+	return b.max
+}
+
+// Attempts returns the number of attempts since the last reset.
+func (b *Backoff) Attempts() int {
+	return b.attempts
+}
+
+// Reset starts over from the initial delay, it is called once connected.
+func (b *Backoff) Reset() {
+	b.attempts = 0
+}
+
+// Retry waits for the delay of the backoff and calls connect, until connect
+// succeeds.  The errors of connect are passed to onError.  It returns
+// ErrMaxAttempts once the maximum number of attempts is reached or the error
+// of the context once it is done.
+func Retry(ctx context.Context, b *Backoff, connect func() error, onError func(err error)) error {
+	for {
+		delay, ok := b.Next()
+		if !ok {
+			return ErrMaxAttempts
+		}
+		if err := internal.SleepContext(ctx, delay); err != nil {
+			return err
+		}
+
+		err := connect()
+		if err == nil {
+			b.Reset()
+			return nil
+		}
+		if onError != nil {
+			onError(err)
+		}
+	}
+}
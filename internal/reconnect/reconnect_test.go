@@ -0,0 +1,112 @@
+package reconnect
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func newPolicy(initial, max time.Duration, jitter float64, attempts int) *Policy {
+	return &Policy{
+		ReconnectInitialDelay: internal.Duration{Duration: initial},
+		ReconnectMaxDelay:     internal.Duration{Duration: max},
+		ReconnectJitter:       jitter,
+		ReconnectMaxAttempts:  attempts,
+	}
+}
+
+func TestBackoffExponential(t *testing.T) {
+	b := newPolicy(time.Second, 10*time.Second, 0, 0).Backoff()
+
+	var delays []time.Duration
+	for i := 0; i < 6; i++ {
+		delay, ok := b.Next()
+		require.True(t, ok)
+		delays = append(delays, delay)
+	}
+	require.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		10 * time.Second, 10 * time.Second,
+	}, delays)
+
+	b.Reset()
+	delay, _ := b.Next()
+	require.Equal(t, time.Second, delay)
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := newPolicy(10*time.Second, 10*time.Second, 0.5, 0).Backoff()
+	for i := 0; i < 100; i++ {
+		delay, ok := b.Next()
+		require.True(t, ok)
+		require.True(t, delay >= 5*time.Second && delay <= 15*time.Second, delay)
+	}
+}
+
+func TestBackoffMaxAttempts(t *testing.T) {
+	b := newPolicy(time.Second, time.Minute, 0, 2).Backoff()
+	_, ok := b.Next()
+	require.True(t, ok)
+	_, ok = b.Next()
+	require.True(t, ok)
+	_, ok = b.Next()
+	require.False(t, ok)
+	require.Equal(t, 2, b.Attempts())
+}
+
+func TestBackoffDefaults(t *testing.T) {
+	b := (&Policy{}).Backoff()
+	delay, ok := b.Next()
+	require.True(t, ok)
+	require.Equal(t, DefaultInitialDelay, delay)
+}
+
+func TestRetry(t *testing.T) {
+	b := newPolicy(time.Millisecond, time.Millisecond, 0, 0).Backoff()
+
+	calls := 0
+	var errs []error
+	err := Retry(context.Background(), b, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("refused")
+		}
+		return nil
+	}, func(err error) {
+		errs = append(errs, err)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+	require.Len(t, errs, 2)
+	require.Equal(t, 0, b.Attempts())
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	b := newPolicy(time.Millisecond, time.Millisecond, 0, 2).Backoff()
+
+	calls := 0
+	err := Retry(context.Background(), b, func() error {
+		calls++
+		return errors.New("refused")
+	}, nil)
+	require.Equal(t, ErrMaxAttempts, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestRetryCanceled(t *testing.T) {
+	b := newPolicy(time.Hour, time.Hour, 0, 0).Backoff()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err := Retry(ctx, b, func() error {
+		called = true
+		return errors.New("refused")
+	}, nil)
+	require.Equal(t, context.Canceled, err)
+	require.False(t, called)
+}
@@ -0,0 +1,20 @@
+package internal
+
+import (
+	"github.com/influxdata/telegraf"
+)
+
+// RejectedError is returned by an output when metrics can never be written,
+// such as when they cannot be serialized or the destination refuses them
+// with a client error.  The metrics are not retried; the agent writes them
+// to the dead letter file of the output, if any, and drops them.
+type RejectedError struct {
+	// Metrics are the rejected metrics of the batch, the other metrics were
+	// written.  All of the batch is rejected if it is nil.
+	Metrics []telegraf.Metric
+	Reason  string
+}
+
+func (e *RejectedError) Error() string {
+	return "metrics rejected: " + e.Reason
+}
@@ -0,0 +1,255 @@
+// Package rotate implements a file writer that rotates the file by age and
+// size, keeping a limited number of archives.
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FilePerm defines the permissions that Writer will use for all
+	// the files it creates.
+	FilePerm = os.FileMode(0644)
+	// TimeFormat is the format of the time in the name of archives, it
+	// sorts in the order the archives were created.
+	TimeFormat = "2006-01-02T15-04-05.000000000"
+)
+
+// FileWriter implements the io.Writer interface and writes to the filename
+// specified.  The file is rotated at the interval and when its size exceeds
+// maxSizeInBytes, the current file is then renamed to an archive and a new
+// file is created.  If the number of archives exceeds maxArchives the oldest
+// archives are deleted, all archives are kept when maxArchives is -1.
+type FileWriter struct {
+	filename                 string
+	filenameRotationTemplate string
+	current                  *os.File
+	interval                 time.Duration
+	maxSizeInBytes           int64
+	maxArchives              int
+	compress                 bool
+	expireTime               time.Time
+	bytesWritten             int64
+	sync.Mutex
+}
+
+// Option configures a FileWriter.
+type Option func(w *FileWriter)
+
+// WithCompression compresses the archives with gzip, adding ".gz" to their
+// name.
+func WithCompression() Option {
+	return func(w *FileWriter) {
+		w.compress = true
+	}
+}
+
+// NewFileWriter creates a new file writer, the file is not rotated when both
+// the interval and maxSizeInBytes are zero.
+func NewFileWriter(filename string, interval time.Duration, maxSizeInBytes int64, maxArchives int, options ...Option) (io.WriteCloser, error) {
+	if interval == 0 && maxSizeInBytes <= 0 {
+		// No rotation needed so a basic io.Writer will do the trick
+		return openFile(filename)
+	}
+
+	w := &FileWriter{
+		filename:                 filename,
+		interval:                 interval,
+		maxSizeInBytes:           maxSizeInBytes,
+		maxArchives:              maxArchives,
+		filenameRotationTemplate: getFilenameRotationTemplate(filename),
+	}
+	for _, option := range options {
+		option(w)
+	}
+
+	if err := w.openCurrent(); err != nil {
+		return nil, err
+	}
+	if err := w.rotateIfNeeded(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func openFile(filename string) (*os.File, error) {
+	return os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, FilePerm)
+}
+
+func getFilenameRotationTemplate(filename string) string {
+	// Extract the file extension
+	fileExt := filepath.Ext(filename)
+	// Remove the file extension from the filename (if any)
+	stem := strings.TrimSuffix(filename, fileExt)
+	return stem + ".%s" + fileExt
+}
+
+// Write writes p to the current file, then checks to see if
+// rotation is necessary.
+func (w *FileWriter) Write(p []byte) (n int, err error) {
+	w.Lock()
+	defer w.Unlock()
+	if n, err = w.current.Write(p); err != nil {
+		return 0, err
+	}
+	w.bytesWritten += int64(n)
+
+	if err = w.rotateIfNeeded(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Close closes the current file.  Writer is unusable after this
+// is called.
+func (w *FileWriter) Close() error {
+	w.Lock()
+	defer w.Unlock()
+	return w.current.Close()
+}
+
+func (w *FileWriter) openCurrent() (err error) {
+	// In case ModTime() fails, we use time.Now()
+	w.expireTime = time.Now().Add(w.interval)
+	w.bytesWritten = 0
+	w.current, err = openFile(w.filename)
+
+	if err != nil {
+		return err
+	}
+
+	// Goal here is to rotate old pre-existing files.
+	// For that we use fileInfo.ModTime, instead of time.Now().
+	// Example: telegraf is restarted every 23 hours and
+	// the rotation interval is set to 24 hours.
+	// With time.now() as a reference we'd never rotate the file.
+	if fileInfo, err := w.current.Stat(); err == nil {
+		w.expireTime = fileInfo.ModTime().Add(w.interval)
+		w.bytesWritten = fileInfo.Size()
+	}
+	return nil
+}
+
+func (w *FileWriter) rotateIfNeeded() error {
+	if (w.interval > 0 && time.Now().After(w.expireTime)) ||
+		(w.maxSizeInBytes > 0 && w.bytesWritten >= w.maxSizeInBytes) {
+		if err := w.rotate(); err != nil {
+			// Ignore rotation errors and keep the log open, the rotation is
+			// tried again after the interval or once maxSizeInBytes has
+			// been written.
+			fmt.Fprintf(os.Stderr, "Unable to rotate the file %q: %v\n", w.filename, err)
+			if err := w.openCurrent(); err != nil {
+				return err
+			}
+			w.expireTime = time.Now().Add(w.interval)
+			w.bytesWritten = 0
+			return nil
+		}
+		return w.openCurrent()
+	}
+	return nil
+}
+
+func (w *FileWriter) rotate() (err error) {
+	if err = w.current.Close(); err != nil {
+		return err
+	}
+
+	rotatedFilename := fmt.Sprintf(w.filenameRotationTemplate, time.Now().Format(TimeFormat))
+	if err = os.Rename(w.filename, rotatedFilename); err != nil {
+		return err
+	}
+
+	if w.compress {
+		if err = compressFile(rotatedFilename); err != nil {
+			return err
+		}
+	}
+
+	if err = w.purgeArchivesIfNeeded(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *FileWriter) purgeArchivesIfNeeded() (err error) {
+	if w.maxArchives == -1 {
+		// Keep all archives
+		return nil
+	}
+
+	var matches []string
+	if matches, err = filepath.Glob(fmt.Sprintf(w.filenameRotationTemplate, "*")); err != nil {
+		return err
+	}
+	// Archives compressed before are purged even if compression is disabled.
+	var compressed []string
+	if compressed, err = filepath.Glob(fmt.Sprintf(w.filenameRotationTemplate, "*") + ".gz"); err != nil {
+		return err
+	}
+	// Without an extension the first pattern matches the compressed archives
+	// as well.
+	seen := make(map[string]bool, len(matches))
+	for _, filename := range matches {
+		seen[filename] = true
+	}
+	for _, filename := range compressed {
+		if !seen[filename] {
+			matches = append(matches, filename)
+		}
+	}
+
+	// If there are more archives than the configured maximum, then purge
+	// older files
+	if len(matches) > w.maxArchives {
+		// Sort files alphanumerically to delete older files first
+		sort.Strings(matches)
+		for _, filename := range matches[:len(matches)-w.maxArchives] {
+			if err = os.Remove(filename); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile replaces the file by its gzip compressed version, adding
+// ".gz" to its name.
+func compressFile(filename string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(filename+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, FilePerm)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(filename + ".gz")
+		return err
+	}
+
+	in.Close()
+	return os.Remove(filename)
+}
@@ -0,0 +1,191 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileWriter_NoRotation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationNo")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	writer, err := NewFileWriter(filepath.Join(tempDir, "test"), 0, 0, 0)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	_, err = writer.Write([]byte("Hello World"))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("Hello World 2"))
+	require.NoError(t, err)
+
+	files, _ := ioutil.ReadDir(tempDir)
+	assert.Equal(t, 1, len(files))
+}
+
+func TestFileWriter_TimeRotation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationTime")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	interval, _ := time.ParseDuration("1s")
+	writer, err := NewFileWriter(filepath.Join(tempDir, "test"), interval, 0, -1)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	_, err = writer.Write([]byte("Hello World"))
+	require.NoError(t, err)
+	time.Sleep(1 * time.Second)
+	_, err = writer.Write([]byte("Hello World 2"))
+	require.NoError(t, err)
+
+	files, _ := ioutil.ReadDir(tempDir)
+	assert.Equal(t, 2, len(files))
+}
+
+func TestFileWriter_ReopenTimeRotation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationTime")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	filePath := filepath.Join(tempDir, "test.log")
+	err = ioutil.WriteFile(filePath, []byte("Hello World"), 0644)
+	require.NoError(t, err)
+	past := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filePath, past, past))
+
+	writer, err := NewFileWriter(filePath, time.Hour, 0, -1)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	files, _ := ioutil.ReadDir(tempDir)
+	assert.Equal(t, 2, len(files))
+}
+
+func TestFileWriter_SizeRotation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationSize")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	maxSize := int64(9)
+	writer, err := NewFileWriter(filepath.Join(tempDir, "test.log"), 0, maxSize, -1)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	_, err = writer.Write([]byte("Hello World"))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("World 2"))
+	require.NoError(t, err)
+
+	files, _ := ioutil.ReadDir(tempDir)
+	assert.Equal(t, 2, len(files))
+}
+
+func TestFileWriter_DeleteArchives(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationDeleteArchives")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	maxSize := int64(5)
+	writer, err := NewFileWriter(filepath.Join(tempDir, "test.log"), 0, maxSize, 2)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	for _, msg := range []string{"First file", "Second file", "Third file", "Fourth file"} {
+		_, err = writer.Write([]byte(msg))
+		require.NoError(t, err)
+	}
+
+	files, _ := ioutil.ReadDir(tempDir)
+	require.Equal(t, 3, len(files))
+
+	var contents []string
+	for _, file := range files {
+		if file.Name() == "test.log" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(tempDir, file.Name()))
+		require.NoError(t, err)
+		contents = append(contents, string(data))
+	}
+	assert.Equal(t, []string{"Third file", "Fourth file"}, contents)
+}
+
+func TestFileWriter_CloseDoesNotRotate(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationClose")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	writer, err := NewFileWriter(filepath.Join(tempDir, "test.log"), 0, 1024, -1)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("Hello World"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	files, _ := ioutil.ReadDir(tempDir)
+	assert.Equal(t, 1, len(files))
+}
+
+func TestFileWriter_CompressArchives(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationCompressArchives")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	maxSize := int64(5)
+	writer, err := NewFileWriter(filepath.Join(tempDir, "test.log"), 0, maxSize, 2, WithCompression())
+	require.NoError(t, err)
+	defer writer.Close()
+
+	for _, msg := range []string{"First file", "Second file", "Third file"} {
+		_, err = writer.Write([]byte(msg))
+		require.NoError(t, err)
+	}
+
+	files, _ := ioutil.ReadDir(tempDir)
+	require.Equal(t, 3, len(files))
+
+	var contents []string
+	for _, file := range files {
+		if file.Name() == "test.log" {
+			continue
+		}
+		require.True(t, strings.HasSuffix(file.Name(), ".log.gz"), file.Name())
+
+		f, err := os.Open(filepath.Join(tempDir, file.Name()))
+		require.NoError(t, err)
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(gz)
+		require.NoError(t, err)
+		f.Close()
+		contents = append(contents, string(data))
+	}
+	assert.Equal(t, []string{"Second file", "Third file"}, contents)
+}
+
+func TestFileWriter_CompressArchivesNoExtension(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "RotationCompressArchivesNoExtension")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	writer, err := NewFileWriter(filepath.Join(tempDir, "metrics"), 0, 5, 1, WithCompression())
+	require.NoError(t, err)
+	defer writer.Close()
+
+	for _, msg := range []string{"First file", "Second file", "Third file"} {
+		_, err = writer.Write([]byte(msg))
+		require.NoError(t, err)
+	}
+
+	// The current file and the last archive are kept.
+	files, _ := ioutil.ReadDir(tempDir)
+	require.Equal(t, 2, len(files))
+}
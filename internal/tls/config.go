@@ -12,6 +12,7 @@ type ClientConfig struct {
 	TLSCA              string `toml:"tls_ca"`
 	TLSCert            string `toml:"tls_cert"`
 	TLSKey             string `toml:"tls_key"`
+	TLSMinVersion      string `toml:"tls_min_version"`
 	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
 
 	// Deprecated in 1.7; use TLS variables above
@@ -24,9 +25,31 @@ type ClientConfig struct {
 type ServerConfig struct {
 	TLSCert           string   `toml:"tls_cert"`
 	TLSKey            string   `toml:"tls_key"`
+	TLSMinVersion     string   `toml:"tls_min_version"`
 	TLSAllowedCACerts []string `toml:"tls_allowed_cacerts"`
 }
 
+// Inherit sets the settings not set in c from the defaults.
+func (c *ClientConfig) Inherit(defaults *ClientConfig) {
+	if c.TLSCA == "" && c.SSLCA == "" {
+		c.TLSCA = defaults.TLSCA
+	}
+	if c.TLSCert == "" && c.SSLCert == "" && c.TLSKey == "" && c.SSLKey == "" {
+		c.TLSCert = defaults.TLSCert
+		c.TLSKey = defaults.TLSKey
+	}
+	if c.TLSMinVersion == "" {
+		c.TLSMinVersion = defaults.TLSMinVersion
+	}
+}
+
+// Inherit sets the settings not set in c from the defaults.
+func (c *ServerConfig) Inherit(defaults *ServerConfig) {
+	if c.TLSMinVersion == "" {
+		c.TLSMinVersion = defaults.TLSMinVersion
+	}
+}
+
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
 // configured.
 func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
@@ -45,7 +68,8 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	// want TLS, this will require using another option to determine.  In the
 	// case of an HTTP plugin, you could use `https`.  Other plugins may need
 	// the dedicated option `TLSEnable`.
-	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify {
+	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" &&
+		c.TLSMinVersion == "" && !c.InsecureSkipVerify {
 		return nil, nil
 	}
 
@@ -54,6 +78,14 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		Renegotiation:      tls.RenegotiateNever,
 	}
 
+	if c.TLSMinVersion != "" {
+		version, err := ParseVersion(c.TLSMinVersion)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.MinVersion = version
+	}
+
 	if c.TLSCA != "" {
 		pool, err := makeCertPool([]string{c.TLSCA})
 		if err != nil {
@@ -81,6 +113,14 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 
 	tlsConfig := &tls.Config{}
 
+	if c.TLSMinVersion != "" {
+		version, err := ParseVersion(c.TLSMinVersion)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.MinVersion = version
+	}
+
 	if len(c.TLSAllowedCACerts) != 0 {
 		pool, err := makeCertPool(c.TLSAllowedCACerts)
 		if err != nil {
@@ -100,6 +140,22 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 	return tlsConfig, nil
 }
 
+// ParseVersion returns the TLS version for the version name, ie: "1.2".
+func ParseVersion(name string) (uint16, error) {
+	switch name {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q", name)
+	}
+}
+
 func makeCertPool(certFiles []string) (*x509.CertPool, error) {
 	pool := x509.NewCertPool()
 	for _, certFile := range certFiles {
@@ -1,6 +1,7 @@
 package tls_test
 
 import (
+	cryptotls "crypto/tls"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -86,6 +87,21 @@ func TestClientConfig(t *testing.T) {
 				SSLKey:  pki.ClientKeyPath(),
 			},
 		},
+		{
+			name: "min version",
+			client: tls.ClientConfig{
+				TLSMinVersion: "1.2",
+			},
+		},
+		{
+			name: "invalid min version",
+			client: tls.ClientConfig{
+				TLSCA:         pki.CACertPath(),
+				TLSMinVersion: "1.4",
+			},
+			expNil: true,
+			expErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -186,6 +202,55 @@ func TestServerConfig(t *testing.T) {
 	}
 }
 
+func TestClientConfigInherit(t *testing.T) {
+	defaults := &tls.ClientConfig{
+		TLSCA:         pki.CACertPath(),
+		TLSCert:       pki.ClientCertPath(),
+		TLSKey:        pki.ClientKeyPath(),
+		TLSMinVersion: "1.2",
+	}
+
+	client := tls.ClientConfig{}
+	client.Inherit(defaults)
+	require.Equal(t, *defaults, client)
+
+	client = tls.ClientConfig{
+		SSLCA:         "ca.pem",
+		TLSKey:        "key.pem",
+		TLSMinVersion: "1.3",
+	}
+	client.Inherit(defaults)
+	require.Equal(t, tls.ClientConfig{
+		SSLCA:         "ca.pem",
+		TLSKey:        "key.pem",
+		TLSMinVersion: "1.3",
+	}, client)
+
+	tlsConfig, err := (&tls.ClientConfig{TLSMinVersion: "1.2"}).TLSConfig()
+	require.NoError(t, err)
+	require.Equal(t, uint16(cryptotls.VersionTLS12), tlsConfig.MinVersion)
+}
+
+func TestServerConfigInherit(t *testing.T) {
+	server := tls.ServerConfig{
+		TLSCert: pki.ServerCertPath(),
+		TLSKey:  pki.ServerKeyPath(),
+	}
+	server.Inherit(&tls.ServerConfig{
+		TLSCert:       "cert.pem",
+		TLSMinVersion: "1.3",
+	})
+	require.Equal(t, tls.ServerConfig{
+		TLSCert:       pki.ServerCertPath(),
+		TLSKey:        pki.ServerKeyPath(),
+		TLSMinVersion: "1.3",
+	}, server)
+
+	tlsConfig, err := server.TLSConfig()
+	require.NoError(t, err)
+	require.Equal(t, uint16(cryptotls.VersionTLS13), tlsConfig.MinVersion)
+}
+
 func TestConnect(t *testing.T) {
 	clientConfig := tls.ClientConfig{
 		TLSCA:   pki.CACertPath(),
@@ -11,25 +11,52 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  plugins             print the available plugins with their sample configuration
+                      and deprecation as JSON, limited to the kinds selected
+                      by --inputs, --outputs and --parsers
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
   --config <file>                configuration file to load
+  --config <url>                 configuration to load from an http(s) or s3 URL,
+                                 ie, 's3://bucket/telegraf.conf?region=us-east-1'
+  --config-cache-directory <dir> cache remote configuration in this directory,
+                                 the cache is used when a source is unavailable
   --config-directory <directory> directory containing additional *.conf files
+  --config-insecure-skip-verify  skip verification of remote config server certificates
+  --config-tls-ca <file>         CA to verify remote config servers with
+  --config-tls-cert <file>       client certificate for remote config servers
+  --config-tls-key <file>        client key for remote config servers
   --debug                        turn on debug logging
+  --deprecation-list             print the deprecated plugins and options used in
+                                 the config and exit
+  --gather-timeout <duration>    abandon the gather of an input after this duration
+                                 in --test and --once mode, ie, '30s'
   --input-filter <filter>        filter the inputs to enable, separator is :
   --input-list                   print available input plugins.
+  --once                         gather metrics once from all inputs, print them out,
+                                 and exit non-zero if any input failed
   --output-filter <filter>       filter the outputs to enable, separator is :
   --output-list                  print available output plugins.
   --pidfile <file>               file to write our pid to
   --pprof-addr <address>         pprof address to listen on, don't activate pprof if empty
+  --print-config                 print the effective configuration after defaults,
+                                 environment variables and secrets are applied,
+                                 with secrets redacted, and exit
   --processor-filter <filter>    filter the processors to enable, separator is :
   --quiet                        run in quiet mode
   --sample-config                print out full sample configuration
+  --strict-config                fail on unknown or invalid plugin options, same
+                                 as strict_config in the [agent] table
   --test                         gather metrics, print them out, and exit;
                                  processors, aggregators, and outputs are not run
+  --test-format <format>         data format of the metrics printed by --test and
+                                 --once, ie, 'json'
   --usage <plugin>               print usage for a plugin, ie, 'telegraf --usage mysql'
   --version                      display the version and exit
+  --watch-config <interval>      check the config files and remote config for
+                                 changes at this interval and reload on change,
+                                 ie, '30s'
 
 Examples:
 
@@ -39,15 +66,30 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # list the available input and output plugins as JSON
+  telegraf plugins --inputs --outputs
+
+  # show the configuration telegraf loads, with secrets redacted
+  telegraf --config telegraf.conf --config-directory telegraf.d --print-config
+
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # check that the sqlserver input of a config works, ie, in CI
+  telegraf --config telegraf.conf --input-filter sqlserver --once --gather-timeout 30s
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
   # run telegraf, enabling the cpu & memory input, and influxdb output plugins
   telegraf --config telegraf.conf --input-filter cpu:mem --output-filter influxdb
 
+  # run telegraf, reloading the config when a file changes
+  telegraf --config telegraf.conf --config-directory telegraf.d --watch-config 30s
+
+  # run telegraf with a centrally managed config, checking it every 5 minutes
+  telegraf --config https://config.example.com/telegraf.conf --config-cache-directory /var/cache/telegraf --watch-config 5m
+
   # run telegraf with pprof
   telegraf --config telegraf.conf --pprof-addr localhost:6060
 `
@@ -1,63 +1,239 @@
 package logger
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"os"
-	"regexp"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/rotate"
 	"github.com/influxdata/wlog"
 )
 
-var prefixRegex = regexp.MustCompile("^[DIWE]!")
+// Level is the severity of a log message.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Log formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+var levelPrefixes = map[byte]Level{
+	'D': LevelDebug,
+	'I': LevelInfo,
+	'W': LevelWarn,
+	'E': LevelError,
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// prefix returns the prefix of the level in text logs, ie: "E!".
+func (l Level) prefix() string {
+	return strings.ToUpper(l.String()[:1]) + "!"
+}
+
+// ParseLevel returns the level of the name, one of debug, info, warn or
+// error.
+func ParseLevel(name string) (Level, error) {
+	for level, n := range levelNames {
+		if n == strings.ToLower(name) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q", name)
+}
+
+var (
+	mu     sync.Mutex
+	level  Level     = LevelInfo
+	format string    = FormatText
+	output io.Writer = os.Stderr
+)
+
+// GlobalLevel returns the level below which messages are not logged.
+func GlobalLevel() Level {
+	mu.Lock()
+	defer mu.Unlock()
+	return level
+}
+
+// Log writes a message of a plugin regardless of the global level, callers
+// are expected to check the level of the plugin.
+func Log(lvl Level, plugin string, msg string) {
+	mu.Lock()
+	defer mu.Unlock()
+	write(output, time.Now(), lvl, plugin, msg)
+}
+
+// write formats the message in the configured format, mu must be held.
+func write(w io.Writer, t time.Time, lvl Level, plugin string, msg string) {
+	msg = strings.TrimSuffix(msg, "\n")
+	ts := t.UTC().Format(time.RFC3339)
+
+	var line []byte
+	switch format {
+	case FormatJSON:
+		line, _ = json.Marshal(struct {
+			Time    string `json:"time"`
+			Level   string `json:"level"`
+			Plugin  string `json:"plugin,omitempty"`
+			Message string `json:"msg"`
+		}{ts, lvl.String(), plugin, msg})
+		line = append(line, '\n')
+	default:
+		if plugin != "" {
+			msg = "[" + plugin + "] " + msg
+		}
+		line = []byte(ts + " " + lvl.prefix() + " " + msg + "\n")
+	}
+	w.Write(line)
+}
 
 // newTelegrafWriter returns a logging-wrapped writer.
 func newTelegrafWriter(w io.Writer) io.Writer {
 	return &telegrafLog{
-		writer: wlog.NewWriter(w),
+		writer: w,
 	}
 }
 
+// telegrafLog writes the messages of the standard logger, the level is taken
+// from the prefix of the message, ie: "E! ", and defaults to info.
 type telegrafLog struct {
 	writer io.Writer
 }
 
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
-	var line []byte
-	if !prefixRegex.Match(b) {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
-	} else {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" "), b...)
+	mu.Lock()
+	defer mu.Unlock()
+
+	lvl, plugin, msg := parseMessage(b)
+	if lvl < level {
+		return len(b), nil
 	}
-	return t.writer.Write(line)
+	write(t.writer, time.Now(), lvl, plugin, msg)
+	return len(b), nil
+}
+
+// parseMessage splits a message of the standard logger into its level,
+// plugin and text.  The plugin is only split off for the JSON format, mu
+// must be held.
+func parseMessage(b []byte) (Level, string, string) {
+	lvl := LevelInfo
+	if len(b) >= 2 && b[1] == '!' {
+		if l, ok := levelPrefixes[b[0]]; ok {
+			lvl = l
+			b = bytes.TrimLeft(b[2:], " ")
+		}
+	}
+
+	if format != FormatJSON || len(b) == 0 || b[0] != '[' {
+		return lvl, "", string(b)
+	}
+	end := bytes.Index(b, []byte("] "))
+	if end < 0 {
+		return lvl, "", string(b)
+	}
+	return lvl, string(b[1:end]), string(b[end+2:])
+}
+
+// LogConfig contains the log configuration settings.
+type LogConfig struct {
+	// Debug sets the log level to debug.
+	Debug bool
+	// Quiet sets the log level to error.
+	Quiet bool
+	// Logfile directs the logging output to a file.  Empty string is
+	// interpreted as stderr.  If there is an error opening the file the
+	// logger will fallback to stderr.
+	Logfile string
+	// RotationInterval is the interval at which the logfile is rotated.
+	RotationInterval internal.Duration
+	// RotationMaxSize is the size above which the logfile is rotated.
+	RotationMaxSize internal.Size
+	// RotationMaxArchives is the number of rotated files to keep, older
+	// files are deleted.  All files are kept when -1.
+	RotationMaxArchives int
 }
 
 // SetupLogging configures the logging output.
-//   debug   will set the log level to DEBUG
-//   quiet   will set the log level to ERROR
-//   logfile will direct the logging output to a file. Empty string is
-//           interpreted as stderr. If there is an error opening the file the
-//           logger will fallback to stderr.
-func SetupLogging(debug, quiet bool, logfile string) {
+func SetupLogging(config LogConfig) {
 	log.SetFlags(0)
-	if debug {
+
+	lvl := LevelInfo
+	wlog.SetLevel(wlog.INFO)
+	if config.Debug {
+		lvl = LevelDebug
 		wlog.SetLevel(wlog.DEBUG)
 	}
-	if quiet {
+	if config.Quiet {
+		lvl = LevelError
 		wlog.SetLevel(wlog.ERROR)
 	}
 
-	var oFile *os.File
-	if logfile != "" {
-		var err error
-		if oFile, err = os.OpenFile(logfile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, os.ModeAppend|0644); err != nil {
-			log.Printf("E! Unable to open %s (%s), using stderr", logfile, err)
-			oFile = os.Stderr
+	var writer io.Writer = os.Stderr
+	if config.Logfile != "" {
+		w, err := rotate.NewFileWriter(
+			config.Logfile,
+			config.RotationInterval.Duration,
+			config.RotationMaxSize.Size,
+			config.RotationMaxArchives,
+		)
+		if err != nil {
+			log.Printf("E! Unable to open %s (%s), using stderr", config.Logfile, err)
+		} else {
+			writer = w
 		}
-	} else {
-		oFile = os.Stderr
 	}
 
-	log.SetOutput(newTelegrafWriter(oFile))
+	mu.Lock()
+	level = lvl
+	prev := output
+	output = writer
+	mu.Unlock()
+
+	log.SetOutput(newTelegrafWriter(writer))
+
+	// Close the previous logfile, ie: when the config is reloaded
+	if c, ok := prev.(io.Closer); ok && prev != writer && prev != os.Stderr {
+		c.Close()
+	}
+}
+
+// SetFormat sets the format of log messages, either text or json.
+func SetFormat(f string) error {
+	switch f {
+	case "", FormatText:
+		f = FormatText
+	case FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format %q", f)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	format = f
+	return nil
 }
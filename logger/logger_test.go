@@ -2,11 +2,14 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/influxdata/telegraf/internal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,7 +18,7 @@ func TestWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(false, false, tmpfile.Name())
+	SetupLogging(LogConfig{Logfile: tmpfile.Name()})
 	log.Printf("I! TEST")
 	log.Printf("D! TEST") // <- should be ignored
 
@@ -29,7 +32,7 @@ func TestDebugWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(true, false, tmpfile.Name())
+	SetupLogging(LogConfig{Debug: true, Logfile: tmpfile.Name()})
 	log.Printf("D! TEST")
 
 	f, err := ioutil.ReadFile(tmpfile.Name())
@@ -42,7 +45,7 @@ func TestErrorWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(false, true, tmpfile.Name())
+	SetupLogging(LogConfig{Quiet: true, Logfile: tmpfile.Name()})
 	log.Printf("E! TEST")
 	log.Printf("I! TEST") // <- should be ignored
 
@@ -56,7 +59,7 @@ func TestAddDefaultLogLevel(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(true, false, tmpfile.Name())
+	SetupLogging(LogConfig{Debug: true, Logfile: tmpfile.Name()})
 	log.Printf("TEST")
 
 	f, err := ioutil.ReadFile(tmpfile.Name())
@@ -69,7 +72,7 @@ func TestWriteToTruncatedFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(true, false, tmpfile.Name())
+	SetupLogging(LogConfig{Debug: true, Logfile: tmpfile.Name()})
 	log.Printf("TEST")
 
 	f, err := ioutil.ReadFile(tmpfile.Name())
@@ -87,6 +90,59 @@ func TestWriteToTruncatedFile(t *testing.T) {
 	assert.Equal(t, f[19:], []byte("Z I! SHOULD BE FIRST\n"))
 }
 
+func TestLogRotation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "LogRotation")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	SetupLogging(LogConfig{
+		Logfile:             filepath.Join(tempDir, "test.log"),
+		RotationMaxSize:     internal.Size{Size: 30},
+		RotationMaxArchives: -1,
+	})
+	defer SetupLogging(LogConfig{})
+
+	log.Printf("I! TEST 1") // Writes 31 bytes, will rotate
+	log.Printf("I! TEST")   // Writes 29 bytes, no rotation expected
+
+	files, _ := ioutil.ReadDir(tempDir)
+	assert.Equal(t, 2, len(files))
+}
+
+func TestWriteJSONLogToFile(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	SetupLogging(LogConfig{Logfile: tmpfile.Name()})
+	assert.NoError(t, SetFormat(FormatJSON))
+	defer SetFormat(FormatText)
+	log.Printf("W! [inputs.cpu] TEST")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	assert.NoError(t, err)
+
+	var entry map[string]string
+	assert.NoError(t, json.Unmarshal(f, &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "inputs.cpu", entry["plugin"])
+	assert.Equal(t, "TEST", entry["msg"])
+}
+
+func TestSetFormat(t *testing.T) {
+	assert.NoError(t, SetFormat(""))
+	assert.Error(t, SetFormat("xml"))
+}
+
+func TestParseLevel(t *testing.T) {
+	lvl, err := ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelWarn, lvl)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
 func BenchmarkTelegrafLogWrite(b *testing.B) {
 	var msg = []byte("test")
 	var buf bytes.Buffer
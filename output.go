@@ -13,6 +13,16 @@ type Output interface {
 	Write(metrics []Metric) error
 }
 
+// ConcurrentOutput is an Output whose Write may be called from several
+// goroutines at the same time.  Only these outputs may set
+// max_parallel_writes.
+type ConcurrentOutput interface {
+	Output
+
+	// ConcurrentWrites declares that Write is safe for concurrent use.
+	ConcurrentWrites()
+}
+
 // AggregatingOutput adds aggregating functionality to an Output.  May be used
 // if the Output only accepts a fixed set of aggregations over a time period.
 // These functions may be called concurrently to the Write function.
@@ -0,0 +1,34 @@
+package telegraf
+
+// Logger is the logger of a plugin.  Plugins that have a field named Log of
+// this type are given a logger that prefixes messages with the name of the
+// plugin and respects the log level set for the plugin.
+type Logger interface {
+	// Errorf logs an error message, patterned after log.Printf.
+	Errorf(format string, args ...interface{})
+	// Error logs an error message, patterned after log.Print.
+	Error(args ...interface{})
+	// Warnf logs a warning message, patterned after log.Printf.
+	Warnf(format string, args ...interface{})
+	// Warn logs a warning message, patterned after log.Print.
+	Warn(args ...interface{})
+	// Infof logs an information message, patterned after log.Printf.
+	Infof(format string, args ...interface{})
+	// Info logs an information message, patterned after log.Print.
+	Info(args ...interface{})
+	// Debugf logs a debug message, patterned after log.Printf.
+	Debugf(format string, args ...interface{})
+	// Debug logs a debug message, patterned after log.Print.
+	Debug(args ...interface{})
+}
+
+// DeprecationInfo contains information about a deprecated plugin or option.
+type DeprecationInfo struct {
+	// Since is the version the plugin or option was deprecated in.
+	Since string `json:"since"`
+	// RemovalIn is the version the plugin or option is planned to be removed
+	// in, empty if not yet planned.
+	RemovalIn string `json:"removal_in,omitempty"`
+	// Notice is a hint for the user, ie: the replacement.
+	Notice string `json:"notice,omitempty"`
+}
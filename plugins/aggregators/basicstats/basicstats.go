@@ -3,6 +3,8 @@ package basicstats
 import (
 	"log"
 	"math"
+	"sort"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/aggregators"
@@ -23,6 +25,15 @@ type configuredStats struct {
 	variance bool
 	stdev    bool
 	sum      bool
+
+	diff            bool
+	nonNegativeDiff bool
+	rate            bool
+	nonNegativeRate bool
+	percentChange   bool
+	iqr             bool
+	first           bool
+	last            bool
 }
 
 func NewBasicStats() *BasicStats {
@@ -44,6 +55,12 @@ type basicstats struct {
 	sum   float64
 	mean  float64
 	M2    float64 //intermedia value for variance/stdev
+
+	first     float64
+	last      float64
+	firstTime time.Time
+	lastTime  time.Time
+	values    []float64 //all values, only kept for the interquartile range
 }
 
 var sampleConfig = `
@@ -55,6 +72,17 @@ var sampleConfig = `
 
   ## Configures which basic stats to push as fields
   # stats = ["count", "min", "max", "mean", "stdev", "s2", "sum"]
+
+  ## The following stats are also available:
+  ##   first, last:       first and last value of the period by timestamp
+  ##   diff:              last value minus the first value
+  ##   non_negative_diff: diff, not pushed if negative
+  ##   rate:              diff per second between the first and last value
+  ##   non_negative_rate: rate, not pushed if negative
+  ##   percent_change:    diff in percent of the first value
+  ##   iqr:               interquartile range, all values of the period are
+  ##                      kept in memory to compute it
+  # stats = ["mean", "rate", "percent_change", "iqr"]
 `
 
 func (m *BasicStats) SampleConfig() string {
@@ -66,6 +94,9 @@ func (m *BasicStats) Description() string {
 }
 
 func (m *BasicStats) Add(in telegraf.Metric) {
+	config := getConfiguredStats(m)
+	tm := in.Time()
+
 	id := in.HashID()
 	if _, ok := m.cache[id]; !ok {
 		// hit an uncached metric, create caches for first time:
@@ -76,14 +107,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 		}
 		for _, field := range in.FieldList() {
 			if fv, ok := convert(field.Value); ok {
-				a.fields[field.Key] = basicstats{
-					count: 1,
-					min:   fv,
-					max:   fv,
-					mean:  fv,
-					sum:   fv,
-					M2:    0.0,
-				}
+				a.fields[field.Key] = newBasicStats(fv, tm, config)
 			}
 		}
 		m.cache[id] = a
@@ -92,14 +116,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 			if fv, ok := convert(field.Value); ok {
 				if _, ok := m.cache[id].fields[field.Key]; !ok {
 					// hit an uncached field of a cached metric
-					m.cache[id].fields[field.Key] = basicstats{
-						count: 1,
-						min:   fv,
-						max:   fv,
-						mean:  fv,
-						sum:   fv,
-						M2:    0.0,
-					}
+					m.cache[id].fields[field.Key] = newBasicStats(fv, tm, config)
 					continue
 				}
 
@@ -127,6 +144,19 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 				}
 				//sum compute
 				tmp.sum += fv
+				//first/last compute, by timestamp
+				if tm.Before(tmp.firstTime) {
+					tmp.first = fv
+					tmp.firstTime = tm
+				}
+				if !tm.Before(tmp.lastTime) {
+					tmp.last = fv
+					tmp.lastTime = tm
+				}
+				//values for the interquartile range
+				if config.iqr {
+					tmp.values = append(tmp.values, fv)
+				}
 				//store final data
 				m.cache[id].fields[field.Key] = tmp
 			}
@@ -134,6 +164,25 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 	}
 }
 
+func newBasicStats(fv float64, tm time.Time, config *configuredStats) basicstats {
+	stats := basicstats{
+		count:     1,
+		min:       fv,
+		max:       fv,
+		mean:      fv,
+		sum:       fv,
+		M2:        0.0,
+		first:     fv,
+		last:      fv,
+		firstTime: tm,
+		lastTime:  tm,
+	}
+	if config.iqr {
+		stats.values = []float64{fv}
+	}
+	return stats
+}
+
 func (m *BasicStats) Push(acc telegraf.Accumulator) {
 	config := getConfiguredStats(m)
 
@@ -169,6 +218,40 @@ func (m *BasicStats) Push(acc telegraf.Accumulator) {
 				}
 			}
 			//if count == 1 StdDev = infinite => so I won't send data
+
+			if config.first {
+				fields[k+"_first"] = v.first
+			}
+			if config.last {
+				fields[k+"_last"] = v.last
+			}
+
+			diff := v.last - v.first
+			if config.diff {
+				fields[k+"_diff"] = diff
+			}
+			if config.nonNegativeDiff && diff >= 0 {
+				fields[k+"_non_negative_diff"] = diff
+			}
+
+			//the rate needs two values at different times
+			if elapsed := v.lastTime.Sub(v.firstTime).Seconds(); elapsed > 0 {
+				rate := diff / elapsed
+				if config.rate {
+					fields[k+"_rate"] = rate
+				}
+				if config.nonNegativeRate && rate >= 0 {
+					fields[k+"_non_negative_rate"] = rate
+				}
+			}
+
+			if config.percentChange && v.first != 0 {
+				fields[k+"_percent_change"] = diff / math.Abs(v.first) * 100
+			}
+
+			if config.iqr {
+				fields[k+"_iqr"] = interquartileRange(v.values)
+			}
 		}
 
 		if len(fields) > 0 {
@@ -199,6 +282,22 @@ func parseStats(names []string) *configuredStats {
 			parsed.stdev = true
 		case "sum":
 			parsed.sum = true
+		case "diff":
+			parsed.diff = true
+		case "non_negative_diff":
+			parsed.nonNegativeDiff = true
+		case "rate":
+			parsed.rate = true
+		case "non_negative_rate":
+			parsed.nonNegativeRate = true
+		case "percent_change":
+			parsed.percentChange = true
+		case "iqr":
+			parsed.iqr = true
+		case "first":
+			parsed.first = true
+		case "last":
+			parsed.last = true
 
 		default:
 			log.Printf("W! Unrecognized basic stat '%s', ignoring", name)
@@ -237,6 +336,22 @@ func getConfiguredStats(m *BasicStats) *configuredStats {
 	return m.statsConfig
 }
 
+// interquartileRange returns the difference between the third and the first
+// quartiles of the values, using linear interpolation between the values.
+func interquartileRange(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return quantile(sorted, 0.75) - quantile(sorted, 0.25)
+}
+
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (pos-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
 func (m *BasicStats) Reset() {
 	m.cache = make(map[uint64]aggregate)
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
@@ -530,3 +531,116 @@ func TestBasicStatsWithDefaultStats(t *testing.T) {
 	assert.True(t, acc.HasField("m1", "a_s2"))
 	assert.False(t, acc.HasField("m1", "a_sum"))
 }
+
+func counterMetric(value float64, tm time.Time) telegraf.Metric {
+	m, _ := metric.New("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": value},
+		tm,
+	)
+	return m
+}
+
+// Test only aggregating first, last, diff, rate and percent_change
+func TestBasicStatsWithChangeStats(t *testing.T) {
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"first", "last", "diff", "non_negative_diff", "rate", "non_negative_rate", "percent_change"}
+
+	// The metrics are added out of order, first and last are by timestamp.
+	aggregator.Add(counterMetric(30, time.Unix(20, 0)))
+	aggregator.Add(counterMetric(10, time.Unix(0, 0)))
+	aggregator.Add(counterMetric(50, time.Unix(10, 0)))
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_first":             float64(10),
+		"a_last":              float64(30),
+		"a_diff":              float64(20),
+		"a_non_negative_diff": float64(20),
+		"a_rate":              float64(1),
+		"a_non_negative_rate": float64(1),
+		"a_percent_change":    float64(200),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
+// Test that the non negative stats are not pushed for a decrease
+func TestBasicStatsWithNegativeChange(t *testing.T) {
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"diff", "non_negative_diff", "rate", "non_negative_rate", "percent_change"}
+
+	aggregator.Add(counterMetric(-40, time.Unix(0, 0)))
+	aggregator.Add(counterMetric(-50, time.Unix(5, 0)))
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_diff":           float64(-10),
+		"a_rate":           float64(-2),
+		"a_percent_change": float64(-25),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
+// Test that the rate is not pushed for a single value
+func TestBasicStatsWithRateSingleValue(t *testing.T) {
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"rate", "diff"}
+
+	aggregator.Add(counterMetric(10, time.Unix(0, 0)))
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	assert.True(t, acc.HasField("m1", "a_diff"))
+	assert.False(t, acc.HasField("m1", "a_rate"))
+}
+
+// Test only aggregating the interquartile range
+func TestBasicStatsWithOnlyInterquartileRange(t *testing.T) {
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"iqr"}
+
+	for i, v := range []float64{7, 1, 3, 5, 9} {
+		aggregator.Add(counterMetric(v, time.Unix(int64(i), 0)))
+	}
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	// The quartiles of 1, 3, 5, 7, 9 are 3 and 7.
+	expectedFields := map[string]interface{}{
+		"a_iqr": float64(4),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
+// Test that the values are only kept for the interquartile range
+func TestBasicStatsValuesNotKept(t *testing.T) {
+
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"mean"}
+
+	aggregator.Add(counterMetric(1, time.Unix(0, 0)))
+	aggregator.Add(counterMetric(2, time.Unix(1, 0)))
+
+	for _, agg := range aggregator.cache {
+		assert.Nil(t, agg.fields["a"].values)
+	}
+}
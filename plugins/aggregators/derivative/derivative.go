@@ -0,0 +1,162 @@
+package derivative
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type Derivative struct {
+	Suffix      string `toml:"suffix"`
+	MaxRollOver uint   `toml:"max_roll_over"`
+
+	cache map[uint64]*aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*counter
+	// rollOver is the number of periods without update of the series.
+	rollOver uint
+}
+
+// counter holds the increase of a cumulative field during the period.
+type counter struct {
+	first    time.Time
+	last     time.Time
+	value    float64
+	increase float64
+}
+
+func NewDerivative() *Derivative {
+	d := &Derivative{
+		Suffix:      "_rate",
+		MaxRollOver: 10,
+	}
+	d.cache = make(map[uint64]*aggregate)
+	return d
+}
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Select the cumulative fields to compute the rate of with fieldpass, all
+  ## numeric fields are used by default.
+  # fieldpass = ["batch_requests_per_sec"]
+
+  ## Suffix added to the field names of the rates, the rate is the increase
+  ## per second.
+  # suffix = "_rate"
+
+  ## Number of periods without update after which the last value of a series
+  ## is forgotten.  The last value of a period is the start of the next one,
+  ## so the increase between periods is not lost.
+  # max_roll_over = 10
+`
+
+func (d *Derivative) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Derivative) Description() string {
+	return "Calculates the rate of change per second of cumulative fields."
+}
+
+func (d *Derivative) Add(in telegraf.Metric) {
+	id := in.HashID()
+	agg, ok := d.cache[id]
+	if !ok {
+		agg = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*counter),
+		}
+		d.cache[id] = agg
+	}
+	agg.rollOver = 0
+
+	tm := in.Time()
+	for _, field := range in.FieldList() {
+		value, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+
+		c, ok := agg.fields[field.Key]
+		if !ok {
+			agg.fields[field.Key] = &counter{first: tm, last: tm, value: value}
+			continue
+		}
+		if !tm.After(c.last) {
+			continue
+		}
+
+		// A decreasing value means the counter was reset, it increased from
+		// zero to its current value.
+		if value >= c.value {
+			c.increase += value - c.value
+		} else {
+			c.increase += value
+		}
+		c.value = value
+		c.last = tm
+	}
+}
+
+func (d *Derivative) Push(acc telegraf.Accumulator) {
+	for _, agg := range d.cache {
+		fields := make(map[string]interface{}, len(agg.fields))
+		for key, c := range agg.fields {
+			elapsed := c.last.Sub(c.first).Seconds()
+			if elapsed <= 0 {
+				continue
+			}
+			fields[key+d.Suffix] = c.increase / elapsed
+		}
+		if len(fields) > 0 {
+			acc.AddFields(agg.name, fields, agg.tags)
+		}
+	}
+}
+
+// Reset starts a new period at the last value of each field.  Series without
+// update for more than MaxRollOver periods are removed.
+func (d *Derivative) Reset() {
+	for id, agg := range d.cache {
+		if agg.rollOver >= d.MaxRollOver {
+			delete(d.cache, id)
+			continue
+		}
+		agg.rollOver++
+		for _, c := range agg.fields {
+			c.first = c.last
+			c.increase = 0
+		}
+	}
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("derivative", func() telegraf.Aggregator {
+		return NewDerivative()
+	})
+}
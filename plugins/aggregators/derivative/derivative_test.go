@@ -0,0 +1,116 @@
+package derivative
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Unix(1500000000, 0)
+
+func counterMetric(value interface{}, offset time.Duration) telegraf.Metric {
+	return testutil.MustMetric("sqlserver_performance",
+		map[string]string{"counter": "Batch Requests/sec"},
+		map[string]interface{}{"value": value},
+		start.Add(offset),
+	)
+}
+
+func TestRate(t *testing.T) {
+	acc := testutil.Accumulator{}
+	derivative := NewDerivative()
+
+	derivative.Add(counterMetric(int64(100), 0))
+	derivative.Add(counterMetric(int64(150), 10*time.Second))
+	derivative.Add(counterMetric(int64(300), 20*time.Second))
+	derivative.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"value_rate": 10.0,
+	}
+	expectedTags := map[string]string{
+		"counter": "Batch Requests/sec",
+	}
+	acc.AssertContainsTaggedFields(t, "sqlserver_performance", expectedFields, expectedTags)
+}
+
+func TestCounterReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	derivative := NewDerivative()
+
+	// The counter is reset after 200, it increased by 100 then by 50.
+	derivative.Add(counterMetric(uint64(100), 0))
+	derivative.Add(counterMetric(uint64(200), 10*time.Second))
+	derivative.Add(counterMetric(uint64(50), 15*time.Second))
+	derivative.Push(&acc)
+
+	acc.AssertContainsFields(t, "sqlserver_performance", map[string]interface{}{
+		"value_rate": 10.0,
+	})
+}
+
+func TestRollOver(t *testing.T) {
+	acc := testutil.Accumulator{}
+	derivative := NewDerivative()
+
+	derivative.Add(counterMetric(100.0, 0))
+	derivative.Add(counterMetric(200.0, 10*time.Second))
+	derivative.Push(&acc)
+	derivative.Reset()
+
+	// The last value of the previous period is the start of this one.
+	derivative.Add(counterMetric(500.0, 20*time.Second))
+	acc.ClearMetrics()
+	derivative.Push(&acc)
+
+	acc.AssertContainsFields(t, "sqlserver_performance", map[string]interface{}{
+		"value_rate": 30.0,
+	})
+}
+
+func TestMaxRollOver(t *testing.T) {
+	acc := testutil.Accumulator{}
+	derivative := NewDerivative()
+	derivative.MaxRollOver = 1
+
+	derivative.Add(counterMetric(100.0, 0))
+	derivative.Reset()
+	derivative.Reset()
+
+	// The series was forgotten, a single value has no rate.
+	derivative.Add(counterMetric(500.0, 20*time.Second))
+	derivative.Push(&acc)
+	require.Len(t, acc.Metrics, 0)
+}
+
+func TestSingleValue(t *testing.T) {
+	acc := testutil.Accumulator{}
+	derivative := NewDerivative()
+
+	derivative.Add(counterMetric(int64(100), 0))
+	derivative.Add(testutil.MustMetric("sqlserver_performance",
+		map[string]string{"counter": "Batch Requests/sec"},
+		map[string]interface{}{"value": "string"},
+		start.Add(10*time.Second),
+	))
+	derivative.Push(&acc)
+
+	require.Len(t, acc.Metrics, 0)
+}
+
+func TestSuffix(t *testing.T) {
+	acc := testutil.Accumulator{}
+	derivative := NewDerivative()
+	derivative.Suffix = "_per_sec"
+
+	derivative.Add(counterMetric(int64(0), 0))
+	derivative.Add(counterMetric(int64(20), 10*time.Second))
+	derivative.Push(&acc)
+
+	acc.AssertContainsFields(t, "sqlserver_performance", map[string]interface{}{
+		"value_per_sec": 2.0,
+	})
+}
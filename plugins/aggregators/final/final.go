@@ -0,0 +1,81 @@
+package final
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+`
+
+type Final struct {
+	cache map[uint64]*aggregate
+}
+
+// aggregate holds the last value of each field of a series.
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time
+}
+
+func NewFinal() telegraf.Aggregator {
+	f := &Final{}
+	f.Reset()
+	return f
+}
+
+func (f *Final) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *Final) Description() string {
+	return "Report the final metric of a series"
+}
+
+func (f *Final) Add(in telegraf.Metric) {
+	id := in.HashID()
+	agg, ok := f.cache[id]
+	if !ok {
+		agg = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]interface{}),
+		}
+		f.cache[id] = agg
+	}
+
+	// Metrics older than the last one seen do not replace its values.
+	if in.Time().Before(agg.time) {
+		return
+	}
+	agg.time = in.Time()
+	for _, field := range in.FieldList() {
+		agg.fields[field.Key+"_final"] = field.Value
+	}
+}
+
+func (f *Final) Push(acc telegraf.Accumulator) {
+	for _, agg := range f.cache {
+		acc.AddFields(agg.name, agg.fields, agg.tags, agg.time)
+	}
+}
+
+func (f *Final) Reset() {
+	f.cache = make(map[uint64]*aggregate)
+}
+
+func init() {
+	aggregators.Add("final", func() telegraf.Aggregator {
+		return NewFinal()
+	})
+}
@@ -0,0 +1,117 @@
+package final
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSimple(t *testing.T) {
+	acc := testutil.Accumulator{}
+	final := NewFinal()
+
+	tags := map[string]string{"foo": "bar"}
+	final.Add(testutil.MustMetric("m1",
+		tags,
+		map[string]interface{}{"a": int64(1)},
+		time.Unix(1530939936, 0)))
+	final.Add(testutil.MustMetric("m1",
+		tags,
+		map[string]interface{}{"a": int64(2)},
+		time.Unix(1530939937, 0)))
+	final.Add(testutil.MustMetric("m1",
+		tags,
+		map[string]interface{}{"a": int64(3)},
+		time.Unix(1530939938, 0)))
+	final.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"m1",
+			tags,
+			map[string]interface{}{
+				"a_final": int64(3),
+			},
+			time.Unix(1530939938, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestTwoTags(t *testing.T) {
+	acc := testutil.Accumulator{}
+	final := NewFinal()
+
+	tags1 := map[string]string{"foo": "bar"}
+	tags2 := map[string]string{"foo": "baz"}
+
+	final.Add(testutil.MustMetric("m1",
+		tags1,
+		map[string]interface{}{"a": int64(1)},
+		time.Unix(1530939936, 0)))
+	final.Add(testutil.MustMetric("m1",
+		tags2,
+		map[string]interface{}{"a": int64(2)},
+		time.Unix(1530939937, 0)))
+	final.Add(testutil.MustMetric("m1",
+		tags1,
+		map[string]interface{}{"a": int64(3)},
+		time.Unix(1530939938, 0)))
+	final.Push(&acc)
+
+	acc.AssertContainsTaggedFields(t, "m1", map[string]interface{}{"a_final": int64(3)}, tags1)
+	acc.AssertContainsTaggedFields(t, "m1", map[string]interface{}{"a_final": int64(2)}, tags2)
+	if len(acc.Metrics) != 2 {
+		t.Errorf("expected 2 metrics, got %d", len(acc.Metrics))
+	}
+}
+
+func TestFieldsAndOrder(t *testing.T) {
+	acc := testutil.Accumulator{}
+	final := NewFinal()
+
+	tags := map[string]string{"foo": "bar"}
+	final.Add(testutil.MustMetric("m1",
+		tags,
+		map[string]interface{}{"a": int64(1), "b": "first"},
+		time.Unix(1530939937, 0)))
+	// A late metric does not replace the last values.
+	final.Add(testutil.MustMetric("m1",
+		tags,
+		map[string]interface{}{"a": int64(0)},
+		time.Unix(1530939936, 0)))
+	final.Add(testutil.MustMetric("m1",
+		tags,
+		map[string]interface{}{"a": int64(2)},
+		time.Unix(1530939938, 0)))
+	final.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"m1",
+			tags,
+			map[string]interface{}{
+				"a_final": int64(2),
+				"b_final": "first",
+			},
+			time.Unix(1530939938, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	final := NewFinal()
+
+	final.Add(testutil.MustMetric("m1",
+		map[string]string{},
+		map[string]interface{}{"a": int64(1)},
+		time.Unix(1530939936, 0)))
+	final.Reset()
+	final.Push(&acc)
+
+	testutil.RequireMetricsEqual(t, nil, acc.GetTelegrafMetrics())
+}
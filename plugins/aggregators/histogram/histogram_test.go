@@ -12,8 +12,8 @@ import (
 )
 
 // NewTestHistogram creates new test histogram aggregation with specified config
-func NewTestHistogram(cfg []config) telegraf.Aggregator {
-	htm := &HistogramAggregator{Configs: cfg}
+func NewTestHistogram(cfg []config, reset bool) telegraf.Aggregator {
+	htm := &HistogramAggregator{Configs: cfg, ResetBuckets: reset}
 	htm.buckets = make(bucketsByMetrics)
 	htm.resetCache()
 
@@ -69,7 +69,7 @@ func BenchmarkApply(b *testing.B) {
 func TestHistogramWithPeriodAndOneField(t *testing.T) {
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false)
 
 	acc := &testutil.Accumulator{}
 
@@ -93,7 +93,7 @@ func TestHistogramWithPeriodAndAllFields(t *testing.T) {
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Buckets: []float64{0.0, 15.5, 20.0, 30.0, 40.0}})
 	cfg = append(cfg, config{Metric: "second_metric_name", Buckets: []float64{0.0, 4.0, 10.0, 23.0, 30.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false)
 
 	acc := &testutil.Accumulator{}
 
@@ -127,7 +127,7 @@ func TestHistogramDifferentPeriodsAndAllFields(t *testing.T) {
 
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false)
 
 	acc := &testutil.Accumulator{}
 	histogram.Add(firstMetric1)
@@ -152,6 +152,51 @@ func TestHistogramDifferentPeriodsAndAllFields(t *testing.T) {
 	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(2), "b_bucket": int64(1), "c_bucket": int64(1)}, bucketInf)
 }
 
+// TestHistogramWithReset tests metrics for one period and for one field, with reset between metrics adding
+func TestHistogramWithReset(t *testing.T) {
+	var cfg []config
+	cfg = append(cfg, config{Metric: "first_metric_name", Fields: []string{"a"}, Buckets: []float64{0.0, 10.0, 20.0, 30.0, 40.0}})
+	histogram := NewTestHistogram(cfg, true)
+
+	acc := &testutil.Accumulator{}
+
+	histogram.Add(firstMetric1)
+	histogram.Reset()
+	histogram.Add(firstMetric2)
+	histogram.Push(acc)
+
+	if len(acc.Metrics) != 6 {
+		assert.Fail(t, "Incorrect number of metrics")
+	}
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, "0")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(0)}, "10")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, "20")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, "30")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, "40")
+	assertContainsTaggedField(t, acc, "first_metric_name", map[string]interface{}{"a_bucket": int64(1)}, bucketInf)
+}
+
+// TestHistogramUnsignedField tests that unsigned fields are counted
+func TestHistogramUnsignedField(t *testing.T) {
+	var cfg []config
+	cfg = append(cfg, config{Metric: "http", Fields: []string{"latency_ms"}, Buckets: []float64{100.0, 500.0}})
+	histogram := NewTestHistogram(cfg, false)
+
+	m, _ := metric.New("http",
+		map[string]string{},
+		map[string]interface{}{"latency_ms": uint64(250)},
+		time.Now(),
+	)
+
+	acc := &testutil.Accumulator{}
+	histogram.Add(m)
+	histogram.Push(acc)
+
+	assertContainsTaggedField(t, acc, "http", map[string]interface{}{"latency_ms_bucket": int64(0)}, "100")
+	assertContainsTaggedField(t, acc, "http", map[string]interface{}{"latency_ms_bucket": int64(1)}, "500")
+	assertContainsTaggedField(t, acc, "http", map[string]interface{}{"latency_ms_bucket": int64(1)}, bucketInf)
+}
+
 // TestWrongBucketsOrder tests the calling panic with incorrect order of buckets
 func TestWrongBucketsOrder(t *testing.T) {
 	defer func() {
@@ -166,7 +211,7 @@ func TestWrongBucketsOrder(t *testing.T) {
 
 	var cfg []config
 	cfg = append(cfg, config{Metric: "first_metric_name", Buckets: []float64{0.0, 90.0, 20.0, 30.0, 40.0}})
-	histogram := NewTestHistogram(cfg)
+	histogram := NewTestHistogram(cfg, false)
 	histogram.Add(firstMetric2)
 }
 
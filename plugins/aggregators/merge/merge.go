@@ -0,0 +1,76 @@
+package merge
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+var sampleConfig = `
+  ## General Aggregator Arguments:
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = true
+
+  ## Round the timestamps down to a multiple of this duration before merging,
+  ## so that the fields of a series gathered at slightly different times are
+  ## merged as well.  By default only metrics with the same timestamp are
+  ## merged.
+  # round_timestamp_to = "1s"
+`
+
+type Merge struct {
+	RoundTimestampTo internal.Duration `toml:"round_timestamp_to"`
+
+	grouper *metric.SeriesGrouper
+}
+
+func NewMerge() telegraf.Aggregator {
+	m := &Merge{}
+	m.Reset()
+	return m
+}
+
+func (m *Merge) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Merge) Description() string {
+	return "Merge metrics into multifield metrics by series key"
+}
+
+func (m *Merge) Add(in telegraf.Metric) {
+	tags := in.Tags()
+	tm := in.Time()
+	if m.RoundTimestampTo.Duration > 0 {
+		tm = tm.Truncate(m.RoundTimestampTo.Duration)
+	}
+	for _, field := range in.FieldList() {
+		m.grouper.Add(in.Name(), tags, tm, field.Key, field.Value)
+	}
+}
+
+func (m *Merge) Push(acc telegraf.Accumulator) {
+	// Always use nanosecond precision to avoid rounding metrics that were
+	// produced at a precision higher than the agent default.
+	acc.SetPrecision(time.Nanosecond, 0)
+
+	for _, metric := range m.grouper.Metrics() {
+		acc.AddMetric(metric)
+	}
+}
+
+func (m *Merge) Reset() {
+	m.grouper = metric.NewSeriesGrouper()
+}
+
+func init() {
+	aggregators.Add("merge", func() telegraf.Aggregator {
+		return NewMerge()
+	})
+}
@@ -1,11 +1,15 @@
 package minmax
 
 import (
+	"time"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 )
 
 type MinMax struct {
+	Timestamps bool `toml:"timestamps"`
+
 	cache map[uint64]aggregate
 }
 
@@ -22,8 +26,10 @@ type aggregate struct {
 }
 
 type minmax struct {
-	min float64
-	max float64
+	min     float64
+	max     float64
+	minTime time.Time
+	maxTime time.Time
 }
 
 var sampleConfig = `
@@ -33,6 +39,11 @@ var sampleConfig = `
   ## If true, the original metric will be dropped by the
   ## aggregator and will not get sent to the output plugins.
   drop_original = false
+
+  ## If true, the times at which the min and max values occurred are pushed
+  ## as the "_min_time" and "_max_time" fields, in nanoseconds since the Unix
+  ## epoch.
+  # timestamps = false
 `
 
 func (m *MinMax) SampleConfig() string {
@@ -44,6 +55,7 @@ func (m *MinMax) Description() string {
 }
 
 func (m *MinMax) Add(in telegraf.Metric) {
+	tm := in.Time()
 	id := in.HashID()
 	if _, ok := m.cache[id]; !ok {
 		// hit an uncached metric, create caches for first time:
@@ -55,8 +67,10 @@ func (m *MinMax) Add(in telegraf.Metric) {
 		for k, v := range in.Fields() {
 			if fv, ok := convert(v); ok {
 				a.fields[k] = minmax{
-					min: fv,
-					max: fv,
+					min:     fv,
+					max:     fv,
+					minTime: tm,
+					maxTime: tm,
 				}
 			}
 		}
@@ -67,18 +81,22 @@ func (m *MinMax) Add(in telegraf.Metric) {
 				if _, ok := m.cache[id].fields[k]; !ok {
 					// hit an uncached field of a cached metric
 					m.cache[id].fields[k] = minmax{
-						min: fv,
-						max: fv,
+						min:     fv,
+						max:     fv,
+						minTime: tm,
+						maxTime: tm,
 					}
 					continue
 				}
 				if fv < m.cache[id].fields[k].min {
 					tmp := m.cache[id].fields[k]
 					tmp.min = fv
+					tmp.minTime = tm
 					m.cache[id].fields[k] = tmp
 				} else if fv > m.cache[id].fields[k].max {
 					tmp := m.cache[id].fields[k]
 					tmp.max = fv
+					tmp.maxTime = tm
 					m.cache[id].fields[k] = tmp
 				}
 			}
@@ -92,6 +110,10 @@ func (m *MinMax) Push(acc telegraf.Accumulator) {
 		for k, v := range aggregate.fields {
 			fields[k+"_min"] = v.min
 			fields[k+"_max"] = v.max
+			if m.Timestamps {
+				fields[k+"_min_time"] = v.minTime.UnixNano()
+				fields[k+"_max_time"] = v.maxTime.UnixNano()
+			}
 		}
 		acc.AddFields(aggregate.name, fields, aggregate.tags)
 	}
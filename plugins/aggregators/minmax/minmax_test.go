@@ -165,3 +165,32 @@ func TestMinMaxDifferentPeriods(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
 }
+
+// Test the times of the min and max values.
+func TestMinMaxTimestamps(t *testing.T) {
+	acc := testutil.Accumulator{}
+	minmax := NewMinMax().(*MinMax)
+	minmax.Timestamps = true
+
+	for i, v := range []float64{5, 9, 1, 9, 4} {
+		m, _ := metric.New("m1",
+			map[string]string{"foo": "bar"},
+			map[string]interface{}{"a": v},
+			time.Unix(int64(i), 0),
+		)
+		minmax.Add(m)
+	}
+	minmax.Push(&acc)
+
+	// The first occurrence of the max value is kept.
+	expectedFields := map[string]interface{}{
+		"a_max":      float64(9),
+		"a_min":      float64(1),
+		"a_max_time": time.Unix(1, 0).UnixNano(),
+		"a_min_time": time.Unix(2, 0).UnixNano(),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -24,6 +25,7 @@ type ActiveMQ struct {
 	Webadmin        string `json:"webadmin"`
 	ResponseTimeout internal.Duration
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -107,6 +109,13 @@ var sampleConfig = `
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
   `
 
 func (a *ActiveMQ) Description() string {
@@ -123,9 +132,15 @@ func (a *ActiveMQ) createHttpClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := a.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
 		},
 		Timeout: a.ResponseTimeout.Duration,
 	}
@@ -4,13 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"math/rand"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/reconnect"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -51,6 +50,9 @@ type AMQPConsumer struct {
 	// AMQP Auth method
 	AuthMethod string
 	tls.ClientConfig
+	reconnect.Policy
+
+	Log telegraf.Logger `toml:"-"`
 
 	deliveries map[telegraf.TrackingID]amqp.Delivery
 
@@ -147,6 +149,14 @@ func (a *AMQPConsumer) SampleConfig() string {
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Reconnect policy when the connection is lost: the delay before the
+  ## first attempt doubles after each failure up to the max delay, randomized
+  ## by the jitter fraction.  Gives up after max attempts, 0 retries forever.
+  # reconnect_initial_delay = "1s"
+  # reconnect_max_delay = "1m"
+  # reconnect_jitter = 0.2
+  # reconnect_max_attempts = 0
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -223,22 +233,27 @@ func (a *AMQPConsumer) Start(acc telegraf.Accumulator) error {
 				break
 			}
 
-			log.Printf("I! [inputs.amqp_consumer] connection closed: %s; trying to reconnect", err)
-			for {
-				msgs, err := a.connect(amqpConf)
-				if err != nil {
-					log.Printf("E! AMQP connection failed: %s", err)
-					time.Sleep(10 * time.Second)
-					continue
+			a.Log.Infof("connection closed: %s; trying to reconnect", err)
+			var msgs <-chan amqp.Delivery
+			rerr := reconnect.Retry(ctx, a.Backoff(), func() error {
+				var err error
+				msgs, err = a.connect(amqpConf)
+				return err
+			}, func(err error) {
+				a.Log.Errorf("AMQP connection failed: %s", err)
+			})
+			if rerr != nil {
+				if rerr == reconnect.ErrMaxAttempts {
+					acc.AddError(fmt.Errorf("giving up reconnecting: %v", rerr))
 				}
-
-				a.wg.Add(1)
-				go func() {
-					defer a.wg.Done()
-					a.process(ctx, msgs, acc)
-				}()
-				break
+				return
 			}
+
+			a.wg.Add(1)
+			go func() {
+				defer a.wg.Done()
+				a.process(ctx, msgs, acc)
+			}()
 		}
 	}()
 
@@ -254,14 +269,14 @@ func (a *AMQPConsumer) connect(amqpConf *amqp.Config) (<-chan amqp.Delivery, err
 	p := rand.Perm(len(brokers))
 	for _, n := range p {
 		broker := brokers[n]
-		log.Printf("D! [inputs.amqp_consumer] connecting to %q", broker)
+		a.Log.Debugf("connecting to %q", broker)
 		conn, err := amqp.DialConfig(broker, *amqpConf)
 		if err == nil {
 			a.conn = conn
-			log.Printf("D! [inputs.amqp_consumer] connected to %q", broker)
+			a.Log.Debugf("connected to %q", broker)
 			break
 		}
-		log.Printf("D! [inputs.amqp_consumer] error connecting to %q", broker)
+		a.Log.Debugf("error connecting to %q", broker)
 	}
 
 	if a.conn == nil {
@@ -434,7 +449,7 @@ func (a *AMQPConsumer) onMessage(acc telegraf.TrackingAccumulator, d amqp.Delive
 		// this message.
 		rejErr := d.Ack(false)
 		if rejErr != nil {
-			log.Printf("E! [inputs.amqp_consumer] Unable to reject message: %d: %v",
+			a.Log.Errorf("Unable to reject message: %d: %v",
 				d.DeliveryTag, rejErr)
 			a.conn.Close()
 		}
@@ -456,14 +471,14 @@ func (a *AMQPConsumer) onDelivery(track telegraf.DeliveryInfo) bool {
 	if track.Delivered() {
 		err := delivery.Ack(false)
 		if err != nil {
-			log.Printf("E! [inputs.amqp_consumer] Unable to ack written delivery: %d: %v",
+			a.Log.Errorf("Unable to ack written delivery: %d: %v",
 				delivery.DeliveryTag, err)
 			a.conn.Close()
 		}
 	} else {
 		err := delivery.Reject(false)
 		if err != nil {
-			log.Printf("E! [inputs.amqp_consumer] Unable to reject failed delivery: %d: %v",
+			a.Log.Errorf("Unable to reject failed delivery: %d: %v",
 				delivery.DeliveryTag, err)
 			a.conn.Close()
 		}
@@ -478,7 +493,7 @@ func (a *AMQPConsumer) Stop() {
 	a.wg.Wait()
 	err := a.conn.Close()
 	if err != nil && err != amqp.ErrClosed {
-		log.Printf("E! [inputs.amqp_consumer] Error closing AMQP connection: %s", err)
+		a.Log.Errorf("Error closing AMQP connection: %s", err)
 		return
 	}
 }
@@ -493,6 +508,7 @@ func init() {
 			QueueDurability:        DefaultQueueDurability,
 			PrefetchCount:          DefaultPrefetchCount,
 			MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+			Policy:                 reconnect.DefaultPolicy(),
 		}
 	})
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -23,6 +24,7 @@ type Apache struct {
 	Password        string
 	ResponseTimeout internal.Duration
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -46,6 +48,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 func (n *Apache) SampleConfig() string {
@@ -98,9 +106,15 @@ func (n *Apache) createHttpClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := n.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
 		},
 		Timeout: n.ResponseTimeout.Duration,
 	}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -49,6 +50,7 @@ type Aurora struct {
 	Username   string            `toml:"username"`
 	Password   string            `toml:"password"`
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client *http.Client
 	urls   []*url.URL
@@ -77,6 +79,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 func (a *Aurora) SampleConfig() string {
@@ -130,9 +138,14 @@ func (a *Aurora) initialize() error {
 		return err
 	}
 
+	proxy, err := a.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
+			Proxy:           proxy,
 			TLSClientConfig: tlsCfg,
 		},
 	}
@@ -275,6 +288,8 @@ func (a *Aurora) gatherScheduler(
 
 func init() {
 	inputs.Add("aurora", func() telegraf.Input {
-		return &Aurora{}
+		return &Aurora{
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
+		}
 	})
 }
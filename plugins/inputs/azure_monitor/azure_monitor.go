@@ -0,0 +1,388 @@
+package azure_monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultEndpointURL = "https://management.azure.com"
+	defaultTimeGrain   = "PT1M"
+	apiVersion         = "2018-01-01"
+
+	// maxMetricsPerRequest is the maximum number of metric names the
+	// metrics API accepts in a request.
+	maxMetricsPerRequest = 20
+)
+
+var defaultAggregations = []string{"Average", "Count", "Maximum", "Minimum", "Total"}
+
+var sampleConfig = `
+  ## Service principal credentials; if client_secret is set, tenant_id and
+  ## client_id are required. Setting only client_id selects a user-assigned
+  ## managed identity. If unset, credentials are read from the environment
+  ## or the system-assigned managed identity is used.
+  # tenant_id = ""
+  # client_id = ""
+  # client_secret = ""
+
+  ## Optionally, if in Azure US Government, China or other sovereign
+  ## cloud environment, set the Azure Resource Manager endpoint.
+  # endpoint_url = "https://management.azure.com"
+
+  ## Timeout for HTTP requests.
+  # timeout = "20s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+
+  ## Time window of the metrics requested, the latest data point of each
+  ## metric in the window is reported.  Azure Monitor publishes platform
+  ## metrics with a delay of a few minutes.
+  # time_window = "5m"
+
+  ## Time grain of the data points, as an ISO 8601 duration.
+  # time_grain = "PT1M"
+
+  ## Resources to gather the metrics of.  When no metrics are set all the
+  ## metrics of the resource are gathered, when no aggregations are set
+  ## all the aggregations are gathered.
+  [[inputs.azure_monitor.resource_target]]
+    resource_id = "/subscriptions/<subscription_id>/resourceGroups/<resource_group>/providers/Microsoft.Sql/servers/<server>/databases/<database>"
+    # metrics = ["dtu_consumption_percent", "storage_percent"]
+    # aggregations = ["Average", "Maximum"]
+`
+
+// ResourceTarget is a resource the metrics are gathered from.
+type ResourceTarget struct {
+	ResourceID   string   `toml:"resource_id"`
+	Metrics      []string `toml:"metrics"`
+	Aggregations []string `toml:"aggregations"`
+}
+
+// AzureMonitor gathers platform metrics of Azure resources from the Azure
+// Monitor metrics API.
+type AzureMonitor struct {
+	TenantID        string            `toml:"tenant_id"`
+	ClientID        string            `toml:"client_id"`
+	ClientSecret    string            `toml:"client_secret"`
+	EndpointURL     string            `toml:"endpoint_url"`
+	Timeout         internal.Duration `toml:"timeout"`
+	TimeWindow      internal.Duration `toml:"time_window"`
+	TimeGrain       string            `toml:"time_grain"`
+	ResourceTargets []*ResourceTarget `toml:"resource_target"`
+	httpconfig.ProxyConfig
+
+	auth     autorest.Authorizer
+	client   *http.Client
+	timeFunc func() time.Time
+}
+
+type localizableString struct {
+	Value string `json:"value"`
+}
+
+type metricValue struct {
+	TimeStamp time.Time `json:"timeStamp"`
+	Average   *float64  `json:"average"`
+	Count     *float64  `json:"count"`
+	Maximum   *float64  `json:"maximum"`
+	Minimum   *float64  `json:"minimum"`
+	Total     *float64  `json:"total"`
+}
+
+type timeSeries struct {
+	MetadataValues []struct {
+		Name  localizableString `json:"name"`
+		Value string            `json:"value"`
+	} `json:"metadatavalues"`
+	Data []metricValue `json:"data"`
+}
+
+type metricsResponse struct {
+	Namespace      string `json:"namespace"`
+	ResourceRegion string `json:"resourceregion"`
+	Value          []struct {
+		Name       localizableString `json:"name"`
+		Unit       string            `json:"unit"`
+		TimeSeries []timeSeries      `json:"timeseries"`
+	} `json:"value"`
+}
+
+type metricDefinitionsResponse struct {
+	Value []struct {
+		Name localizableString `json:"name"`
+	} `json:"value"`
+}
+
+// Description provides a description of the plugin
+func (a *AzureMonitor) Description() string {
+	return "Gather platform metrics of Azure resources from Azure Monitor"
+}
+
+// SampleConfig provides a sample configuration for the plugin
+func (a *AzureMonitor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *AzureMonitor) init() error {
+	if len(a.ResourceTargets) == 0 {
+		return fmt.Errorf("no resource target configured")
+	}
+	for _, target := range a.ResourceTargets {
+		if target.ResourceID == "" {
+			return fmt.Errorf("resource_id is required in resource_target")
+		}
+	}
+
+	if a.auth == nil {
+		authorizer, err := a.authorizer()
+		if err != nil {
+			return err
+		}
+		a.auth = authorizer
+	}
+
+	proxy, err := a.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+	a.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxy,
+		},
+		Timeout: a.Timeout.Duration,
+	}
+	return nil
+}
+
+// authorizer returns the authorizer for the configured credentials, falling
+// back to the environment and then the managed identity of the VM.
+func (a *AzureMonitor) authorizer() (autorest.Authorizer, error) {
+	resource := strings.TrimSuffix(a.EndpointURL, "/") + "/"
+
+	if a.ClientSecret != "" {
+		if a.TenantID == "" || a.ClientID == "" {
+			return nil, fmt.Errorf("tenant_id and client_id are required with client_secret")
+		}
+		config := auth.NewClientCredentialsConfig(a.ClientID, a.ClientSecret, a.TenantID)
+		config.Resource = resource
+		return config.Authorizer()
+	}
+
+	if a.ClientID != "" {
+		endpoint, err := adal.GetMSIVMEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		token, err := adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(
+			endpoint, resource, a.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		return autorest.NewBearerAuthorizer(token), nil
+	}
+
+	return auth.NewAuthorizerFromEnvironmentWithResource(resource)
+}
+
+// Gather requests the metrics of the resource targets in parallel
+func (a *AzureMonitor) Gather(acc telegraf.Accumulator) error {
+	if a.client == nil {
+		if err := a.init(); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range a.ResourceTargets {
+		wg.Add(1)
+		go func(target *ResourceTarget) {
+			defer wg.Done()
+			if err := a.gatherTarget(acc, target); err != nil {
+				acc.AddError(fmt.Errorf("resource %q: %v", target.ResourceID, err))
+			}
+		}(target)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (a *AzureMonitor) gatherTarget(acc telegraf.Accumulator, target *ResourceTarget) error {
+	metrics := target.Metrics
+	if len(metrics) == 0 {
+		var err error
+		if metrics, err = a.metricDefinitions(target.ResourceID); err != nil {
+			return err
+		}
+	}
+
+	aggregations := target.Aggregations
+	if len(aggregations) == 0 {
+		aggregations = defaultAggregations
+	}
+
+	now := a.timeFunc().UTC()
+	timespan := now.Add(-a.TimeWindow.Duration).Format(time.RFC3339) + "/" + now.Format(time.RFC3339)
+
+	for start := 0; start < len(metrics); start += maxMetricsPerRequest {
+		end := start + maxMetricsPerRequest
+		if end > len(metrics) {
+			end = len(metrics)
+		}
+
+		params := url.Values{}
+		params.Set("api-version", apiVersion)
+		params.Set("metricnames", strings.Join(metrics[start:end], ","))
+		params.Set("aggregation", strings.Join(aggregations, ","))
+		params.Set("timespan", timespan)
+		params.Set("interval", a.TimeGrain)
+
+		var resp metricsResponse
+		if err := a.get(target.ResourceID+"/providers/microsoft.insights/metrics", params, &resp); err != nil {
+			return err
+		}
+		addMetrics(acc, target.ResourceID, &resp)
+	}
+	return nil
+}
+
+// metricDefinitions returns the names of the metrics of the resource.
+func (a *AzureMonitor) metricDefinitions(resourceID string) ([]string, error) {
+	params := url.Values{}
+	params.Set("api-version", apiVersion)
+
+	var resp metricDefinitionsResponse
+	if err := a.get(resourceID+"/providers/microsoft.insights/metricDefinitions", params, &resp); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(resp.Value))
+	for _, def := range resp.Value {
+		names = append(names, def.Name.Value)
+	}
+	return names, nil
+}
+
+func (a *AzureMonitor) get(path string, params url.Values, v interface{}) error {
+	u := strings.TrimSuffix(a.EndpointURL, "/") + path + "?" + params.Encode()
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+
+	// Add the authorization header. WithAuthorization will automatically
+	// refresh the token if needed.
+	req, err = autorest.CreatePreparer(a.auth.WithAuthorization()).Prepare(req)
+	if err != nil {
+		return fmt.Errorf("unable to fetch authentication credentials: %v", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("request failed: [%v] %s", resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// addMetrics adds a metric for the latest data point of each time series,
+// the data points of the time grains not ended yet have no values.
+func addMetrics(acc telegraf.Accumulator, resourceID string, resp *metricsResponse) {
+	for _, m := range resp.Value {
+		for _, series := range m.TimeSeries {
+			var latest *metricValue
+			for i := range series.Data {
+				if series.Data[i].hasValue() {
+					latest = &series.Data[i]
+				}
+			}
+			if latest == nil {
+				continue
+			}
+
+			tags := map[string]string{
+				"resource_id": resourceID,
+				"name":        m.Name.Value,
+			}
+			if resp.Namespace != "" {
+				tags["namespace"] = resp.Namespace
+			}
+			if resp.ResourceRegion != "" {
+				tags["resource_region"] = resp.ResourceRegion
+			}
+			if m.Unit != "" {
+				tags["unit"] = m.Unit
+			}
+			for _, md := range series.MetadataValues {
+				tags[md.Name.Value] = md.Value
+			}
+
+			acc.AddFields("azure_monitor", latest.fields(), tags, latest.TimeStamp)
+		}
+	}
+}
+
+func (v *metricValue) hasValue() bool {
+	return v.Average != nil || v.Count != nil || v.Maximum != nil || v.Minimum != nil || v.Total != nil
+}
+
+func (v *metricValue) fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	if v.Average != nil {
+		fields["average"] = *v.Average
+	}
+	if v.Count != nil {
+		fields["count"] = *v.Count
+	}
+	if v.Maximum != nil {
+		fields["maximum"] = *v.Maximum
+	}
+	if v.Minimum != nil {
+		fields["minimum"] = *v.Minimum
+	}
+	if v.Total != nil {
+		fields["total"] = *v.Total
+	}
+	return fields
+}
+
+func init() {
+	inputs.Add("azure_monitor", func() telegraf.Input {
+		return &AzureMonitor{
+			EndpointURL: defaultEndpointURL,
+			Timeout:     internal.Duration{Duration: 20 * time.Second},
+			TimeWindow:  internal.Duration{Duration: 5 * time.Minute},
+			TimeGrain:   defaultTimeGrain,
+			timeFunc:    time.Now,
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
+		}
+	})
+}
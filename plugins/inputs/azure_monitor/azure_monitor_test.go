@@ -0,0 +1,208 @@
+package azure_monitor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const resourceID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Sql/servers/srv/databases/db"
+
+const metricsJSON = `{
+  "timespan": "2019-10-01T11:55:00Z/2019-10-01T12:00:00Z",
+  "interval": "PT1M",
+  "value": [
+    {
+      "id": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Sql/servers/srv/databases/db/providers/Microsoft.Insights/metrics/dtu_consumption_percent",
+      "type": "Microsoft.Insights/metrics",
+      "name": {"value": "dtu_consumption_percent", "localizedValue": "DTU percentage"},
+      "unit": "Percent",
+      "timeseries": [
+        {
+          "metadatavalues": [],
+          "data": [
+            {"timeStamp": "2019-10-01T11:57:00Z", "average": 10.5, "maximum": 20},
+            {"timeStamp": "2019-10-01T11:58:00Z", "average": 12.5, "maximum": 25},
+            {"timeStamp": "2019-10-01T11:59:00Z"}
+          ]
+        }
+      ]
+    },
+    {
+      "id": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Sql/servers/srv/databases/db/providers/Microsoft.Insights/metrics/storage",
+      "type": "Microsoft.Insights/metrics",
+      "name": {"value": "storage", "localizedValue": "Data space used"},
+      "unit": "Bytes",
+      "timeseries": []
+    }
+  ],
+  "namespace": "Microsoft.Sql/servers/databases",
+  "resourceregion": "westeurope"
+}`
+
+const metricDefinitionsJSON = `{
+  "value": [
+    {"name": {"value": "dtu_consumption_percent", "localizedValue": "DTU percentage"}},
+    {"name": {"value": "storage", "localizedValue": "Data space used"}}
+  ]
+}`
+
+func newAzureMonitor(u string) *AzureMonitor {
+	return &AzureMonitor{
+		EndpointURL: u,
+		Timeout:     internal.Duration{Duration: time.Second},
+		TimeWindow:  internal.Duration{Duration: 5 * time.Minute},
+		TimeGrain:   defaultTimeGrain,
+		ResourceTargets: []*ResourceTarget{
+			{ResourceID: resourceID},
+		},
+		auth: autorest.NullAuthorizer{},
+		timeFunc: func() time.Time {
+			return time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+		},
+	}
+}
+
+func TestGather(t *testing.T) {
+	var query map[string][]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case resourceID + "/providers/microsoft.insights/metricDefinitions":
+			fmt.Fprint(w, metricDefinitionsJSON)
+		case resourceID + "/providers/microsoft.insights/metrics":
+			query = r.URL.Query()
+			fmt.Fprint(w, metricsJSON)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	a := newAzureMonitor(ts.URL)
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	require.Equal(t, []string{"dtu_consumption_percent,storage"}, query["metricnames"])
+	require.Equal(t, []string{"Average,Count,Maximum,Minimum,Total"}, query["aggregation"])
+	require.Equal(t, []string{"2019-10-01T11:55:00Z/2019-10-01T12:00:00Z"}, query["timespan"])
+	require.Equal(t, []string{"PT1M"}, query["interval"])
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("azure_monitor",
+			map[string]string{
+				"resource_id":     resourceID,
+				"name":            "dtu_consumption_percent",
+				"namespace":       "Microsoft.Sql/servers/databases",
+				"resource_region": "westeurope",
+				"unit":            "Percent",
+			},
+			map[string]interface{}{
+				"average": 12.5,
+				"maximum": 25.0,
+			},
+			time.Date(2019, 10, 1, 11, 58, 0, 0, time.UTC),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestGatherMetricsInBatches(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Get("metricnames"))
+		fmt.Fprint(w, `{"value": []}`)
+	}))
+	defer ts.Close()
+
+	var metrics []string
+	for i := 0; i < 25; i++ {
+		metrics = append(metrics, fmt.Sprintf("metric%d", i))
+	}
+
+	a := newAzureMonitor(ts.URL)
+	a.ResourceTargets[0].Metrics = metrics
+	a.ResourceTargets[0].Aggregations = []string{"Average"}
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, requests, 2)
+	require.Len(t, strings.Split(requests[0], ","), maxMetricsPerRequest)
+	require.Len(t, strings.Split(requests[1], ","), 5)
+}
+
+func TestGatherDimensions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+  "value": [
+    {
+      "name": {"value": "Transactions"},
+      "unit": "Count",
+      "timeseries": [
+        {
+          "metadatavalues": [{"name": {"value": "apiname"}, "value": "GetBlob"}],
+          "data": [{"timeStamp": "2019-10-01T11:58:00Z", "total": 42}]
+        }
+      ]
+    }
+  ]
+}`)
+	}))
+	defer ts.Close()
+
+	a := newAzureMonitor(ts.URL)
+	a.ResourceTargets[0].Metrics = []string{"Transactions"}
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsTaggedFields(t, "azure_monitor",
+		map[string]interface{}{
+			"total": 42.0,
+		},
+		map[string]string{
+			"resource_id": resourceID,
+			"name":        "Transactions",
+			"unit":        "Count",
+			"apiname":     "GetBlob",
+		})
+}
+
+func TestGatherError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error": {"code": "AuthorizationFailed"}}`)
+	}))
+	defer ts.Close()
+
+	a := newAzureMonitor(ts.URL)
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Contains(t, acc.Errors[0].Error(), "AuthorizationFailed")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	a := newAzureMonitor("http://localhost")
+	a.ResourceTargets = nil
+
+	var acc testutil.Accumulator
+	require.Error(t, a.Gather(&acc))
+
+	a = newAzureMonitor("http://localhost")
+	a.auth = nil
+	a.ClientSecret = "secret"
+	require.Error(t, a.Gather(&acc))
+}
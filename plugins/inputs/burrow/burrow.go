@@ -13,6 +13,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -64,11 +65,18 @@ const configSample = `
   # ssl_cert = "/etc/telegraf/cert.pem"
   # ssl_key = "/etc/telegraf/key.pem"
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 type (
 	burrow struct {
 		tls.ClientConfig
+		httpconfig.ProxyConfig
 
 		Servers               []string
 		Username              string
@@ -220,9 +228,15 @@ func (b *burrow) createClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := b.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
 		},
 		Timeout: b.ResponseTimeout.Duration,
 	}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -264,9 +263,6 @@ func parseServerTokens(server string) map[string]string {
 }
 
 func (c *Cassandra) Start(acc telegraf.Accumulator) error {
-	log.Println("W! DEPRECATED: The cassandra plugin has been deprecated. " +
-		"Please use the jolokia2 plugin instead. " +
-		"https://github.com/influxdata/telegraf/tree/master/plugins/inputs/jolokia2")
 	return nil
 }
 
@@ -9,11 +9,11 @@ import (
 	"encoding/base64"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/reconnect"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
-	"log"
 	"time"
 )
 
@@ -21,7 +21,6 @@ type empty struct{}
 type semaphore chan empty
 
 const defaultMaxUndeliveredMessages = 1000
-const defaultRetryDelaySeconds = 5
 
 type PubSub struct {
 	sync.Mutex
@@ -39,10 +38,14 @@ type PubSub struct {
 	// Agent settings
 	MaxMessageLen            int `toml:"max_message_len"`
 	MaxUndeliveredMessages   int `toml:"max_undelivered_messages"`
-	RetryReceiveDelaySeconds int `toml:"retry_delay_seconds"`
+	RetryReceiveDelaySeconds int `toml:"retry_delay_seconds" deprecated:"1.12.0;2.0.0;use 'reconnect_initial_delay' instead"`
 
 	Base64Data bool `toml:"base64_data"`
 
+	reconnect.Policy
+
+	Log telegraf.Logger `toml:"-"`
+
 	sub     subscription
 	stubSub func() subscription
 
@@ -128,28 +131,44 @@ func (ps *PubSub) Stop() {
 	ps.wg.Wait()
 }
 
-// startReceiver is called within a goroutine and manages keeping a
+// receiveWithRetry is called within a goroutine and manages keeping a
 // subscription.Receive() up and running while the plugin has not been stopped.
 func (ps *PubSub) receiveWithRetry(parentCtx context.Context) {
-	err := ps.startReceiver(parentCtx)
-
-	for err != nil && parentCtx.Err() == nil {
-		log.Printf("E! [inputs.cloud_pubsub] Receiver for subscription %s exited with error: %v", ps.sub.ID(), err)
+	policy := ps.Policy
+	if ps.RetryReceiveDelaySeconds > 0 {
+		policy.ReconnectInitialDelay.Duration = time.Duration(ps.RetryReceiveDelaySeconds) * time.Second
+	}
+	backoff := policy.Backoff()
 
-		delay := defaultRetryDelaySeconds
-		if ps.RetryReceiveDelaySeconds > 0 {
-			delay = ps.RetryReceiveDelaySeconds
+	for {
+		start := time.Now()
+		err := ps.startReceiver(parentCtx)
+		if err == nil || parentCtx.Err() != nil {
+			return
 		}
+		ps.Log.Errorf("Receiver for subscription %s exited with error: %v", ps.sub.ID(), err)
 
-		log.Printf("I! [inputs.cloud_pubsub] Waiting %d seconds before attempting to restart receiver...", delay)
-		time.Sleep(time.Duration(delay) * time.Second)
+		// The receiver runs until it fails, one that ran for longer than the
+		// maximum delay is not failing repeatedly.
+		if time.Since(start) > backoff.MaxDelay() {
+			backoff.Reset()
+		}
+		delay, ok := backoff.Next()
+		if !ok {
+			ps.acc.AddError(fmt.Errorf("giving up restarting receiver for subscription %s: %v",
+				ps.sub.ID(), reconnect.ErrMaxAttempts))
+			return
+		}
 
-		err = ps.startReceiver(parentCtx)
+		ps.Log.Infof("Waiting %s before attempting to restart receiver...", delay)
+		if err := internal.SleepContext(parentCtx, delay); err != nil {
+			return
+		}
 	}
 }
 
 func (ps *PubSub) startReceiver(parentCtx context.Context) error {
-	log.Printf("I! [inputs.cloud_pubsub] Starting receiver for subscription %s...", ps.sub.ID())
+	ps.Log.Infof("Starting receiver for subscription %s...", ps.sub.ID())
 	cctx, ccancel := context.WithCancel(parentCtx)
 	err := ps.sub.Receive(cctx, func(ctx context.Context, msg message) {
 		if err := ps.onMessage(ctx, msg); err != nil {
@@ -159,7 +178,7 @@ func (ps *PubSub) startReceiver(parentCtx context.Context) error {
 	if err != nil {
 		ps.acc.AddError(fmt.Errorf("receiver for subscription %s exited: %v", ps.sub.ID(), err))
 	} else {
-		log.Printf("I! [inputs.cloud_pubsub] subscription pull ended (no error, most likely stopped)")
+		ps.Log.Infof("subscription pull ended (no error, most likely stopped)")
 	}
 	ccancel()
 	return err
@@ -286,6 +305,7 @@ func init() {
 	inputs.Add("cloud_pubsub", func() telegraf.Input {
 		ps := &PubSub{
 			MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+			Policy:                 reconnect.DefaultPolicy(),
 		}
 		return ps
 	})
@@ -310,11 +330,14 @@ const sampleConfig = `
   ## Application Default Credentials, which is preferred.
   # credentials_file = "path/to/my/creds.json"
 
-  ## Optional. Number of seconds to wait before attempting to restart the 
-  ## PubSub subscription receiver after an unexpected error. 
-  ## If the streaming pull for a PubSub Subscription fails (receiver),
-  ## the agent attempts to restart receiving messages after this many seconds.
-  # retry_delay_seconds = 5
+  ## Optional. Policy to restart the PubSub subscription receiver after an
+  ## unexpected error: the delay before the first attempt doubles after each
+  ## failure up to the max delay, randomized by the jitter fraction.  Gives up
+  ## after max attempts, 0 retries forever.
+  # reconnect_initial_delay = "1s"
+  # reconnect_max_delay = "1m"
+  # reconnect_jitter = 0.2
+  # reconnect_max_attempts = 0
 
   ## Optional. Maximum byte length of a message to consume.
   ## Larger messages are dropped with an error. If less than 0 or unspecified,
@@ -26,6 +26,7 @@ func TestRunParse(t *testing.T) {
 	sub.receiver = testMessagesReceive(sub)
 
 	ps := &PubSub{
+		Log:                    testutil.Logger{},
 		parser:                 testParser,
 		stubSub:                func() subscription { return sub },
 		Project:                "projectIDontMatterForTests",
@@ -69,6 +70,7 @@ func TestRunBase64(t *testing.T) {
 	sub.receiver = testMessagesReceive(sub)
 
 	ps := &PubSub{
+		Log:                    testutil.Logger{},
 		parser:                 testParser,
 		stubSub:                func() subscription { return sub },
 		Project:                "projectIDontMatterForTests",
@@ -112,6 +114,7 @@ func TestRunInvalidMessages(t *testing.T) {
 	sub.receiver = testMessagesReceive(sub)
 
 	ps := &PubSub{
+		Log:                    testutil.Logger{},
 		parser:                 testParser,
 		stubSub:                func() subscription { return sub },
 		Project:                "projectIDontMatterForTests",
@@ -158,6 +161,7 @@ func TestRunOverlongMessages(t *testing.T) {
 	sub.receiver = testMessagesReceive(sub)
 
 	ps := &PubSub{
+		Log:                    testutil.Logger{},
 		parser:                 testParser,
 		stubSub:                func() subscription { return sub },
 		Project:                "projectIDontMatterForTests",
@@ -205,6 +209,7 @@ func TestRunErrorInSubscriber(t *testing.T) {
 	sub.receiver = testMessagesError(sub, errors.New("a fake error"))
 
 	ps := &PubSub{
+		Log:                      testutil.Logger{},
 		parser:                   testParser,
 		stubSub:                  func() subscription { return sub },
 		Project:                  "projectIDontMatterForTests",
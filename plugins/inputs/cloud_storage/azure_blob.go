@@ -0,0 +1,199 @@
+package cloud_storage
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+)
+
+const (
+	azureStorageResource = "https://storage.azure.com/"
+	// azureStorageVersion is the version of the Blob service API, the
+	// bearer tokens are supported since version 2017-11-09.
+	azureStorageVersion = "2018-03-28"
+)
+
+type azureBlobStore struct {
+	endpoint  *url.URL
+	container string
+	sasToken  url.Values
+	auth      autorest.Authorizer
+	client    *http.Client
+}
+
+type listBlobsResponse struct {
+	Blobs []struct {
+		Name       string `xml:"Name"`
+		Properties struct {
+			LastModified  string `xml:"Last-Modified"`
+			ContentLength int64  `xml:"Content-Length"`
+		} `xml:"Properties"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func (c *CloudStorage) newAzureBlobStore() (*azureBlobStore, error) {
+	endpoint := c.EndpointURL
+	if endpoint == "" {
+		if c.AccountName == "" {
+			return nil, errors.New("account_name or endpoint_url is required")
+		}
+		endpoint = "https://" + c.AccountName + ".blob.core.windows.net"
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint_url: %v", err)
+	}
+
+	proxy, err := c.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &azureBlobStore{
+		endpoint:  u,
+		container: c.Bucket,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy: proxy,
+			},
+			Timeout: c.Timeout.Duration,
+		},
+	}
+
+	if c.SASToken != "" {
+		s.sasToken, err = url.ParseQuery(strings.TrimPrefix(c.SASToken, "?"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid sas_token: %v", err)
+		}
+		return s, nil
+	}
+
+	if s.auth, err = c.azureAuthorizer(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// azureAuthorizer returns the authorizer for the configured credentials,
+// falling back to the environment and then the managed identity of the VM.
+func (c *CloudStorage) azureAuthorizer() (autorest.Authorizer, error) {
+	if c.ClientSecret != "" {
+		if c.TenantID == "" || c.ClientID == "" {
+			return nil, fmt.Errorf("tenant_id and client_id are required with client_secret")
+		}
+		config := auth.NewClientCredentialsConfig(c.ClientID, c.ClientSecret, c.TenantID)
+		config.Resource = azureStorageResource
+		return config.Authorizer()
+	}
+
+	if c.ClientID != "" {
+		endpoint, err := adal.GetMSIVMEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		token, err := adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(
+			endpoint, azureStorageResource, c.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		return autorest.NewBearerAuthorizer(token), nil
+	}
+
+	return auth.NewAuthorizerFromEnvironmentWithResource(azureStorageResource)
+}
+
+func (s *azureBlobStore) List(prefix string) ([]object, error) {
+	var objects []object
+	var marker string
+	for {
+		params := url.Values{}
+		params.Set("restype", "container")
+		params.Set("comp", "list")
+		if prefix != "" {
+			params.Set("prefix", prefix)
+		}
+		if marker != "" {
+			params.Set("marker", marker)
+		}
+
+		body, err := s.get("/"+s.container, params)
+		if err != nil {
+			return nil, err
+		}
+
+		var resp listBlobsResponse
+		if err := xml.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		for _, blob := range resp.Blobs {
+			modTime, err := time.Parse(time.RFC1123, blob.Properties.LastModified)
+			if err != nil {
+				return nil, fmt.Errorf("invalid last modified time of %q: %v", blob.Name, err)
+			}
+			objects = append(objects, object{
+				Key:          blob.Name,
+				LastModified: modTime,
+				Size:         blob.Properties.ContentLength,
+			})
+		}
+
+		if resp.NextMarker == "" {
+			return objects, nil
+		}
+		marker = resp.NextMarker
+	}
+}
+
+func (s *azureBlobStore) Get(key string) ([]byte, error) {
+	return s.get("/"+s.container+"/"+key, url.Values{})
+}
+
+func (s *azureBlobStore) get(path string, params url.Values) ([]byte, error) {
+	for key, values := range s.sasToken {
+		params[key] = values
+	}
+
+	u := *s.endpoint
+	u.Path += path
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azureStorageVersion)
+
+	if s.auth != nil {
+		// Add the authorization header. WithAuthorization will
+		// automatically refresh the token if needed.
+		req, err = autorest.CreatePreparer(s.auth.WithAuthorization()).Prepare(req)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch authentication credentials: %v", err)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("request failed: [%v] %s", resp.StatusCode, body)
+	}
+	return body, nil
+}
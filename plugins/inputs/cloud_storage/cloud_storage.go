@@ -0,0 +1,333 @@
+package cloud_storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Storage service, "s3" for Amazon S3 and S3 compatible services or
+  ## "azure_blob" for Azure Blob Storage.
+  provider = "s3"
+
+  ## Name of the S3 bucket or of the Azure Blob container.
+  bucket = ""
+
+  ## Only the objects with keys starting with the prefix are listed.
+  # prefix = ""
+
+  ## Keys of the objects to read, glob patterns are supported and match the
+  ## complete key.  All objects are read by default.
+  # keys_include = ["logs/*.csv"]
+
+  ## Keys of the objects to ignore.
+  # keys_exclude = ["*.tmp"]
+
+  ## Minimum time since the last modification of an object before it is
+  ## read.
+  # object_duration_threshold = "0s"
+
+  ## Objects larger than this size in bytes are skipped, 0 is unlimited.
+  # max_object_size = 0
+
+  ## Name of a tag to add with the key of the object the metric was read from.
+  # object_tag = ""
+
+  ## Timeout for requests to the storage service.
+  # timeout = "5m"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+
+  ## Amazon S3 credentials, see the cloudwatch input for the order in which
+  ## the credentials are looked for.
+  # region = "us-east-1"
+  # access_key = ""
+  # secret_key = ""
+  # token = ""
+  # role_arn = ""
+  # profile = ""
+  # shared_credential_file = ""
+
+  ## Endpoint of the S3 compatible service, or of the Azure Blob service of
+  ## sovereign clouds or the emulator.  By default the endpoint of the
+  ## region or of the storage account is used.
+  # endpoint_url = ""
+
+  ## Azure Blob storage account and credentials.  When the SAS token is not
+  ## set the service principal is used if client_secret is set, the
+  ## user-assigned managed identity if only client_id is set, and otherwise
+  ## the credentials are read from the environment.
+  # account_name = ""
+  # sas_token = ""
+  # tenant_id = ""
+  # client_id = ""
+  # client_secret = ""
+
+  ## The data format to be read from the objects.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+// object is an object of the storage service.
+type object struct {
+	Key          string
+	LastModified time.Time
+	Size         int64
+}
+
+// objectStore lists and reads the objects of a bucket or container.
+type objectStore interface {
+	// List returns all the objects with keys starting with the prefix.
+	List(prefix string) ([]object, error)
+	// Get returns the content of the object.
+	Get(key string) ([]byte, error)
+}
+
+type CloudStorage struct {
+	Provider                string            `toml:"provider"`
+	Bucket                  string            `toml:"bucket"`
+	Prefix                  string            `toml:"prefix"`
+	KeysInclude             []string          `toml:"keys_include"`
+	KeysExclude             []string          `toml:"keys_exclude"`
+	ObjectDurationThreshold internal.Duration `toml:"object_duration_threshold"`
+	MaxObjectSize           int64             `toml:"max_object_size"`
+	ObjectTag               string            `toml:"object_tag"`
+	Timeout                 internal.Duration `toml:"timeout"`
+	EndpointURL             string            `toml:"endpoint_url"`
+	httpconfig.ProxyConfig
+
+	// Amazon S3
+	Region    string `toml:"region"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	RoleARN   string `toml:"role_arn"`
+	Profile   string `toml:"profile"`
+	Filename  string `toml:"shared_credential_file"`
+	Token     string `toml:"token"`
+
+	// Azure Blob
+	AccountName  string `toml:"account_name"`
+	SASToken     string `toml:"sas_token"`
+	TenantID     string `toml:"tenant_id"`
+	ClientID     string `toml:"client_id"`
+	ClientSecret string `toml:"client_secret"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	parserFunc parsers.ParserFunc
+
+	initialized bool
+	store       objectStore
+	include     filter.Filter
+	exclude     filter.Filter
+
+	mu sync.Mutex
+	// processed holds the last modification time of the objects read, an
+	// object is read again once modified.
+	processed map[string]time.Time
+}
+
+func (c *CloudStorage) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *CloudStorage) Description() string {
+	return "Ingests new objects of an Amazon S3 bucket or Azure Blob container"
+}
+
+func (c *CloudStorage) SetParserFunc(fn parsers.ParserFunc) {
+	c.parserFunc = fn
+}
+
+func (c *CloudStorage) init() error {
+	if c.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+
+	var err error
+	c.include, err = filter.Compile(c.KeysInclude)
+	if err != nil {
+		return fmt.Errorf("invalid keys_include: %v", err)
+	}
+	c.exclude, err = filter.Compile(c.KeysExclude)
+	if err != nil {
+		return fmt.Errorf("invalid keys_exclude: %v", err)
+	}
+
+	if c.store != nil {
+		return nil
+	}
+	switch c.Provider {
+	case "s3":
+		if c.store, err = c.newS3Store(); err != nil {
+			return err
+		}
+	case "azure_blob":
+		if c.store, err = c.newAzureBlobStore(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown provider %q", c.Provider)
+	}
+	return nil
+}
+
+func (c *CloudStorage) Gather(acc telegraf.Accumulator) error {
+	if !c.initialized {
+		if err := c.init(); err != nil {
+			return err
+		}
+		c.initialized = true
+	}
+
+	objects, err := c.store.List(c.Prefix)
+	if err != nil {
+		return err
+	}
+	// Read the oldest objects first.
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].LastModified.Before(objects[j].LastModified)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.processed == nil {
+		c.processed = make(map[string]time.Time)
+	}
+
+	// Objects no longer listed are forgotten so that the state does not
+	// grow with the deleted objects.
+	listed := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		listed[obj.Key] = true
+	}
+	for key := range c.processed {
+		if !listed[key] {
+			delete(c.processed, key)
+		}
+	}
+
+	now := time.Now()
+	for _, obj := range objects {
+		if !c.isMonitored(obj.Key) {
+			continue
+		}
+		if modTime, ok := c.processed[obj.Key]; ok && !obj.LastModified.After(modTime) {
+			continue
+		}
+		// The object may still be uploaded.
+		if now.Sub(obj.LastModified) < c.ObjectDurationThreshold.Duration {
+			continue
+		}
+		if c.MaxObjectSize > 0 && obj.Size > c.MaxObjectSize {
+			c.Log.Warnf("Skipping object %q larger than max_object_size (%d > %d)",
+				obj.Key, obj.Size, c.MaxObjectSize)
+			c.processed[obj.Key] = obj.LastModified
+			continue
+		}
+
+		buf, err := c.store.Get(obj.Key)
+		if err != nil {
+			// The object is read again in the next interval.
+			acc.AddError(fmt.Errorf("error reading object %q: %v", obj.Key, err))
+			continue
+		}
+		// Objects which can not be parsed are not read again until modified.
+		c.processed[obj.Key] = obj.LastModified
+		if err := c.ingestObject(obj.Key, buf, acc); err != nil {
+			acc.AddError(fmt.Errorf("error parsing object %q: %v", obj.Key, err))
+		}
+	}
+	return nil
+}
+
+func (c *CloudStorage) isMonitored(key string) bool {
+	// Keys ending with a slash are folders created by the consoles.
+	if key == "" || key[len(key)-1] == '/' {
+		return false
+	}
+	if c.include != nil && !c.include.Match(key) {
+		return false
+	}
+	if c.exclude != nil && c.exclude.Match(key) {
+		return false
+	}
+	return true
+}
+
+// ingestObject parses the complete object with a new parser, the metrics are
+// only added if the object can be parsed.
+func (c *CloudStorage) ingestObject(key string, buf []byte, acc telegraf.Accumulator) error {
+	parser, err := c.parserFunc()
+	if err != nil {
+		return fmt.Errorf("error creating parser: %v", err)
+	}
+	metrics, err := parser.Parse(buf)
+	if err != nil {
+		return err
+	}
+
+	for _, metric := range metrics {
+		if c.ObjectTag != "" {
+			metric.AddTag(c.ObjectTag, key)
+		}
+		acc.AddMetric(metric)
+	}
+	return nil
+}
+
+// GetState returns the last modification time of the objects read.
+func (c *CloudStorage) GetState() interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	processed := make(map[string]time.Time, len(c.processed))
+	for key, modTime := range c.processed {
+		processed[key] = modTime
+	}
+	return processed
+}
+
+// SetState restores the objects read, they are only read again once
+// modified.
+func (c *CloudStorage) SetState(state interface{}) error {
+	processed, ok := state.(map[string]time.Time)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	if processed == nil {
+		processed = make(map[string]time.Time)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.processed = processed
+	return nil
+}
+
+func init() {
+	inputs.Add("cloud_storage", func() telegraf.Input {
+		return &CloudStorage{
+			Timeout: internal.Duration{Duration: 5 * time.Minute},
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
+		}
+	})
+}
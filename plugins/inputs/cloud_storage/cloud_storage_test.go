@@ -0,0 +1,297 @@
+package cloud_storage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	objects  map[string]object
+	contents map[string]string
+	gets     []string
+	getErr   error
+}
+
+func (s *memoryStore) List(prefix string) ([]object, error) {
+	var objects []object
+	for key, obj := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+func (s *memoryStore) Get(key string) ([]byte, error) {
+	s.gets = append(s.gets, key)
+	if s.getErr != nil {
+		return nil, s.getErr
+	}
+	return []byte(s.contents[key]), nil
+}
+
+func (s *memoryStore) put(key string, content string, modTime time.Time) {
+	s.objects[key] = object{Key: key, LastModified: modTime, Size: int64(len(content))}
+	s.contents[key] = content
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		objects:  make(map[string]object),
+		contents: make(map[string]string),
+	}
+}
+
+func newCloudStorage(store objectStore) *CloudStorage {
+	c := &CloudStorage{
+		Provider: "s3",
+		Bucket:   "telegraf",
+		Log:      testutil.Logger{},
+		store:    store,
+	}
+	c.SetParserFunc(func() (parsers.Parser, error) {
+		return parsers.NewInfluxParser()
+	})
+	return c
+}
+
+func TestGatherNewObjects(t *testing.T) {
+	store := newMemoryStore()
+	modTime := time.Now().Add(-time.Hour)
+	store.put("logs/a.txt", "cpu value=1 1569931200000000000\n", modTime)
+	store.put("logs/b.txt", "cpu value=2 1569931201000000000\n", modTime.Add(time.Second))
+	store.put("logs/", "", modTime)
+
+	c := newCloudStorage(store)
+	c.ObjectTag = "object"
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Equal(t, []string{"logs/a.txt", "logs/b.txt"}, store.gets)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"value": 2.0},
+		map[string]string{"object": "logs/b.txt"})
+
+	// Objects already read are skipped until modified.
+	acc.ClearMetrics()
+	store.put("logs/a.txt", "cpu value=3 1569931202000000000\n", modTime.Add(time.Minute))
+	require.NoError(t, c.Gather(&acc))
+	require.Equal(t, []string{"logs/a.txt", "logs/b.txt", "logs/a.txt"}, store.gets)
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsFields(t, "cpu", map[string]interface{}{"value": 3.0})
+}
+
+func TestGatherFilters(t *testing.T) {
+	store := newMemoryStore()
+	modTime := time.Now().Add(-time.Hour)
+	store.put("logs/a.csv", "cpu value=1\n", modTime)
+	store.put("logs/a.tmp", "cpu value=1\n", modTime)
+	store.put("other/b.csv", "cpu value=1\n", modTime)
+	store.put("logs/new.csv", "cpu value=1\n", time.Now())
+	store.put("logs/large.csv", strings.Repeat("x", 100), modTime)
+
+	c := newCloudStorage(store)
+	c.Prefix = "logs/"
+	c.KeysExclude = []string{"*.tmp"}
+	c.ObjectDurationThreshold = internal.Duration{Duration: time.Minute}
+	c.MaxObjectSize = 50
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Equal(t, []string{"logs/a.csv"}, store.gets)
+}
+
+func TestGatherErrors(t *testing.T) {
+	store := newMemoryStore()
+	store.put("invalid.txt", "not line protocol", time.Now().Add(-time.Hour))
+	store.getErr = errors.New("connection reset")
+
+	c := newCloudStorage(store)
+
+	// Objects which can not be read are read again.
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	store.getErr = nil
+	require.NoError(t, c.Gather(&acc))
+	require.Len(t, acc.Errors, 2)
+	require.Equal(t, []string{"invalid.txt", "invalid.txt"}, store.gets)
+
+	// Objects which can not be parsed are not read again.
+	require.NoError(t, c.Gather(&acc))
+	require.Len(t, acc.Errors, 2)
+	require.Len(t, store.gets, 2)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestState(t *testing.T) {
+	store := newMemoryStore()
+	modTime := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	store.put("a.txt", "cpu value=1\n", modTime)
+	store.put("b.txt", "cpu value=2\n", modTime)
+
+	c := newCloudStorage(store)
+	require.NoError(t, c.SetState(map[string]time.Time{
+		"a.txt":       modTime,
+		"deleted.txt": modTime,
+	}))
+
+	var acc testutil.Accumulator
+	require.NoError(t, c.Gather(&acc))
+	require.Equal(t, []string{"b.txt"}, store.gets)
+
+	// Deleted objects are forgotten.
+	require.Equal(t, map[string]time.Time{
+		"a.txt": modTime,
+		"b.txt": modTime,
+	}, c.GetState())
+
+	require.Error(t, c.SetState("invalid"))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	var acc testutil.Accumulator
+
+	c := &CloudStorage{Provider: "gcs", Bucket: "telegraf"}
+	require.Error(t, c.Gather(&acc))
+
+	c = &CloudStorage{Provider: "s3"}
+	require.Error(t, c.Gather(&acc))
+
+	c = &CloudStorage{Provider: "azure_blob", Bucket: "telegraf"}
+	require.Error(t, c.Gather(&acc))
+}
+
+const listBlobs = `<?xml version="1.0" encoding="utf-8"?>
+<EnumerationResults ServiceEndpoint="https://account.blob.core.windows.net/" ContainerName="telegraf">
+  <Prefix>logs/</Prefix>
+  <Blobs>
+    <Blob>
+      <Name>logs/a.txt</Name>
+      <Properties>
+        <Last-Modified>Tue, 01 Oct 2019 12:00:00 GMT</Last-Modified>
+        <Content-Length>12</Content-Length>
+      </Properties>
+    </Blob>
+  </Blobs>
+  <NextMarker>%s</NextMarker>
+</EnumerationResults>`
+
+func TestAzureBlobStore(t *testing.T) {
+	var lists int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sig") != "secret" || r.Header.Get("x-ms-version") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/account/telegraf":
+			require.Equal(t, "list", r.URL.Query().Get("comp"))
+			require.Equal(t, "logs/", r.URL.Query().Get("prefix"))
+			lists++
+			if r.URL.Query().Get("marker") == "" {
+				fmt.Fprintf(w, listBlobs, "page2")
+			} else {
+				fmt.Fprintf(w, listBlobs, "")
+			}
+		case "/account/telegraf/logs/a.txt":
+			fmt.Fprint(w, "cpu value=1\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := &CloudStorage{
+		Provider:    "azure_blob",
+		Bucket:      "telegraf",
+		EndpointURL: ts.URL + "/account",
+		SASToken:    "?sv=2018-03-28&sig=secret",
+	}
+	store, err := c.newAzureBlobStore()
+	require.NoError(t, err)
+
+	objects, err := store.List("logs/")
+	require.NoError(t, err)
+	require.Equal(t, 2, lists)
+	require.Len(t, objects, 2)
+	require.Equal(t, object{
+		Key:          "logs/a.txt",
+		LastModified: time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+		Size:         12,
+	}, object{objects[0].Key, objects[0].LastModified.UTC(), objects[0].Size})
+
+	buf, err := store.Get("logs/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=1\n", string(buf))
+
+	_, err = store.Get("logs/missing.txt")
+	require.Error(t, err)
+}
+
+const listObjectsV2 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>telegraf</Name>
+  <Prefix>logs/</Prefix>
+  <KeyCount>1</KeyCount>
+  <MaxKeys>1000</MaxKeys>
+  <IsTruncated>false</IsTruncated>
+  <Contents>
+    <Key>logs/a.txt</Key>
+    <LastModified>2019-10-01T12:00:00.000Z</LastModified>
+    <ETag>"abc"</ETag>
+    <Size>12</Size>
+    <StorageClass>STANDARD</StorageClass>
+  </Contents>
+</ListBucketResult>`
+
+func TestS3Store(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/telegraf":
+			require.Equal(t, "2", r.URL.Query().Get("list-type"))
+			fmt.Fprint(w, listObjectsV2)
+		case "/telegraf/logs/a.txt":
+			fmt.Fprint(w, "cpu value=1\n")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	c := &CloudStorage{
+		Provider:    "s3",
+		Bucket:      "telegraf",
+		Region:      "us-east-1",
+		AccessKey:   "key",
+		SecretKey:   "secret",
+		EndpointURL: ts.URL,
+		Timeout:     internal.Duration{Duration: 5 * time.Second},
+	}
+	store, err := c.newS3Store()
+	require.NoError(t, err)
+
+	objects, err := store.List("logs/")
+	require.NoError(t, err)
+	require.Equal(t, []object{{
+		Key:          "logs/a.txt",
+		LastModified: time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC),
+		Size:         12,
+	}}, objects)
+
+	buf, err := store.Get("logs/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "cpu value=1\n", string(buf))
+}
@@ -0,0 +1,101 @@
+package cloud_storage
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	internalaws "github.com/influxdata/telegraf/internal/config/aws"
+)
+
+type s3Store struct {
+	client  *s3.S3
+	bucket  string
+	timeout func() (context.Context, context.CancelFunc)
+}
+
+func (c *CloudStorage) newS3Store() (*s3Store, error) {
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:      c.Region,
+		AccessKey:   c.AccessKey,
+		SecretKey:   c.SecretKey,
+		RoleARN:     c.RoleARN,
+		Profile:     c.Profile,
+		Filename:    c.Filename,
+		Token:       c.Token,
+		EndpointURL: c.EndpointURL,
+	}
+
+	// S3 compatible services do not support the virtual hosted-style
+	// requests with the bucket in the host name.
+	config := &aws.Config{}
+	if c.EndpointURL != "" {
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	proxy, err := c.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+	config.HTTPClient = &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxy,
+		},
+	}
+
+	timeout := c.Timeout.Duration
+	return &s3Store{
+		client: s3.New(credentialConfig.Credentials(), config),
+		bucket: c.Bucket,
+		timeout: func() (context.Context, context.CancelFunc) {
+			if timeout == 0 {
+				return context.WithCancel(context.Background())
+			}
+			return context.WithTimeout(context.Background(), timeout)
+		},
+	}, nil
+}
+
+func (s *s3Store) List(prefix string) ([]object, error) {
+	ctx, cancel := s.timeout()
+	defer cancel()
+
+	var objects []object
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}
+	err := s.client.ListObjectsV2PagesWithContext(ctx, input,
+		func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, obj := range page.Contents {
+				objects = append(objects, object{
+					Key:          aws.StringValue(obj.Key),
+					LastModified: aws.TimeValue(obj.LastModified),
+					Size:         aws.Int64Value(obj.Size),
+				})
+			}
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (s *s3Store) Get(key string) ([]byte, error) {
+	ctx, cancel := s.timeout()
+	defer cancel()
+
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return ioutil.ReadAll(out.Body)
+}
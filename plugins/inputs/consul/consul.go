@@ -6,6 +6,7 @@ import (
 
 	"github.com/hashicorp/consul/api"
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -19,6 +20,7 @@ type Consul struct {
 	Datacentre string // deprecated in 1.10; use Datacenter
 	Datacenter string
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 	TagDelimiter string
 
 	// client used to connect to Consul agnet
@@ -49,6 +51,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = true
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## Consul checks' tag splitting
   # When tags are formatted like "key:value" with ":" as a delimiter then
   # they will be splitted and reported as proper key:value in Telegraf
@@ -98,8 +106,14 @@ func (c *Consul) createAPIClient() (*api.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := c.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	config.Transport = &http.Transport{
 		TLSClientConfig: tlsCfg,
+		Proxy:           proxy,
 	}
 
 	return api.NewClient(config)
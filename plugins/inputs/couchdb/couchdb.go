@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -83,6 +84,7 @@ type (
 		Hosts         []string `toml:"hosts"`
 		BasicUsername string   `toml:"basic_username"`
 		BasicPassword string   `toml:"basic_password"`
+		httpconfig.ProxyConfig
 
 		client *http.Client
 	}
@@ -101,10 +103,30 @@ func (*CouchDB) SampleConfig() string {
   ## Use HTTP Basic Authentication.
   # basic_username = "telegraf"
   # basic_password = "p@ssw0rd"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 }
 
 func (c *CouchDB) Gather(accumulator telegraf.Accumulator) error {
+	if c.client == nil {
+		proxy, err := c.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+		c.client = &http.Client{
+			Transport: &http.Transport{
+				ResponseHeaderTimeout: time.Duration(3 * time.Second),
+				Proxy:                 proxy,
+			},
+			Timeout: time.Duration(4 * time.Second),
+		}
+	}
+
 	var wg sync.WaitGroup
 	for _, u := range c.Hosts {
 		wg.Add(1)
@@ -122,15 +144,6 @@ func (c *CouchDB) Gather(accumulator telegraf.Accumulator) error {
 }
 
 func (c *CouchDB) fetchAndInsertData(accumulator telegraf.Accumulator, host string) error {
-	if c.client == nil {
-		c.client = &http.Client{
-			Transport: &http.Transport{
-				ResponseHeaderTimeout: time.Duration(3 * time.Second),
-			},
-			Timeout: time.Duration(4 * time.Second),
-		}
-	}
-
 	req, err := http.NewRequest("GET", host, nil)
 	if err != nil {
 		return err
@@ -284,13 +297,6 @@ func (c *CouchDB) generateFields(fields map[string]interface{}, prefix string, o
 
 func init() {
 	inputs.Add("couchdb", func() telegraf.Input {
-		return &CouchDB{
-			client: &http.Client{
-				Transport: &http.Transport{
-					ResponseHeaderTimeout: time.Duration(3 * time.Second),
-				},
-				Timeout: time.Duration(4 * time.Second),
-			},
-		}
+		return &CouchDB{}
 	})
 }
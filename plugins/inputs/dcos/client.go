@@ -116,11 +116,13 @@ func NewClusterClient(
 	timeout time.Duration,
 	maxConns int,
 	tlsConfig *tls.Config,
+	proxy func(*http.Request) (*url.URL, error),
 ) *ClusterClient {
 	httpClient := &http.Client{
 		Transport: &http.Transport{
 			MaxIdleConns:    maxConns,
 			TLSClientConfig: tlsConfig,
+			Proxy:           proxy,
 		},
 		Timeout: timeout,
 	}
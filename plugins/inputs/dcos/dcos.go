@@ -13,6 +13,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -58,6 +59,7 @@ type DCOS struct {
 	MaxConnections  int
 	ResponseTimeout internal.Duration
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client Client
 	creds  Credentials
@@ -111,6 +113,12 @@ var sampleConfig = `
   ## If false, skip chain & host verification
   # insecure_skip_verify = true
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## Recommended filtering to reduce series cardinality.
   # [inputs.dcos.tagdrop]
   #   path = ["/var/lib/mesos/slave/slaves/*"]
@@ -353,6 +361,11 @@ func (d *DCOS) createClient() (Client, error) {
 		return nil, err
 	}
 
+	proxy, err := d.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	url, err := url.Parse(d.ClusterURL)
 	if err != nil {
 		return nil, err
@@ -363,6 +376,7 @@ func (d *DCOS) createClient() (Client, error) {
 		d.ResponseTimeout.Duration,
 		d.MaxConnections,
 		tlsCfg,
+		proxy,
 	)
 
 	return client, nil
@@ -0,0 +1,53 @@
+package inputs
+
+import "github.com/influxdata/telegraf"
+
+// Deprecations lists the deprecated plugins, a warning is logged when they
+// are used.
+var Deprecations = map[string]telegraf.DeprecationInfo{
+	"cassandra": {
+		Since:     "1.7.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.jolokia2' with the cassandra example configuration instead",
+	},
+	"http_listener": {
+		Since:     "1.9.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.influxdb_listener' instead",
+	},
+	"httpjson": {
+		Since:     "1.6.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.http' instead",
+	},
+	"io": {
+		Since:     "0.10.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.diskio' instead",
+	},
+	"jolokia": {
+		Since:     "1.5.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.jolokia2' instead",
+	},
+	"kafka_consumer_legacy": {
+		Since:     "1.4.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.kafka_consumer' instead, it requires Kafka 0.8 or later",
+	},
+	"snmp_legacy": {
+		Since:     "1.0.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.snmp' instead",
+	},
+	"tcp_listener": {
+		Since:     "1.3.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.socket_listener' instead",
+	},
+	"udp_listener": {
+		Since:     "1.3.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'inputs.socket_listener' instead",
+	},
+}
@@ -0,0 +1,207 @@
+package directory_monitor
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## The directory to monitor and read files from.
+  directory = ""
+
+  ## The directory to move finished files to.
+  finished_directory = ""
+
+  ## The directory to move files to upon file error.
+  ## If not provided, erroring files will stay in the monitored directory and
+  ## are read again only once modified.
+  # error_directory = ""
+
+  ## Names of the files to read, glob patterns are supported.  All files are
+  ## read by default.
+  # files_to_monitor = ["*.csv"]
+
+  ## Names of the files to ignore, such as temporary files of a transfer.
+  # files_to_ignore = [".*", "*.tmp"]
+
+  ## Minimum time since the last modification of a file before it is read,
+  ## so that files being written are not read before they are complete.
+  # directory_duration_threshold = "50ms"
+
+  ## Name of a tag to add with the name of the file the metric was read from.
+  # file_tag = ""
+
+  ## The dataformat to be read from the files.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+type DirectoryMonitor struct {
+	Directory                  string            `toml:"directory"`
+	FinishedDirectory          string            `toml:"finished_directory"`
+	ErrorDirectory             string            `toml:"error_directory"`
+	FilesToMonitor             []string          `toml:"files_to_monitor"`
+	FilesToIgnore              []string          `toml:"files_to_ignore"`
+	DirectoryDurationThreshold internal.Duration `toml:"directory_duration_threshold"`
+	FileTag                    string            `toml:"file_tag"`
+	Log                        telegraf.Logger   `toml:"-"`
+
+	parserFunc parsers.ParserFunc
+
+	initialized bool
+	monitor     filter.Filter
+	ignore      filter.Filter
+	// failed holds the modification time of the files which could not be
+	// read and are left in the directory, they are read again once modified.
+	failed map[string]time.Time
+}
+
+func (m *DirectoryMonitor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *DirectoryMonitor) Description() string {
+	return "Ingests files in a directory and then moves them to a target directory."
+}
+
+func (m *DirectoryMonitor) SetParserFunc(fn parsers.ParserFunc) {
+	m.parserFunc = fn
+}
+
+func (m *DirectoryMonitor) init() error {
+	if m.Directory == "" || m.FinishedDirectory == "" {
+		return errors.New("missing directory or finished_directory")
+	}
+
+	var err error
+	m.monitor, err = filter.Compile(m.FilesToMonitor)
+	if err != nil {
+		return fmt.Errorf("invalid files_to_monitor: %v", err)
+	}
+	m.ignore, err = filter.Compile(m.FilesToIgnore)
+	if err != nil {
+		return fmt.Errorf("invalid files_to_ignore: %v", err)
+	}
+	m.failed = make(map[string]time.Time)
+	return nil
+}
+
+func (m *DirectoryMonitor) Gather(acc telegraf.Accumulator) error {
+	if !m.initialized {
+		if err := m.init(); err != nil {
+			return err
+		}
+		m.initialized = true
+	}
+
+	files, err := ioutil.ReadDir(m.Directory)
+	if err != nil {
+		return err
+	}
+	// Read the oldest files first.
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+
+	now := time.Now()
+	for _, file := range files {
+		if !file.Mode().IsRegular() || !m.isMonitored(file.Name()) {
+			continue
+		}
+		// The file may still be written to.
+		if now.Sub(file.ModTime()) < m.DirectoryDurationThreshold.Duration {
+			continue
+		}
+
+		path := filepath.Join(m.Directory, file.Name())
+		if modTime, ok := m.failed[path]; ok && modTime.Equal(file.ModTime()) {
+			continue
+		}
+		delete(m.failed, path)
+
+		if err := m.ingestFile(path, acc); err != nil {
+			acc.AddError(fmt.Errorf("error reading file %q: %v", path, err))
+			if !m.moveFile(path, m.ErrorDirectory) {
+				m.failed[path] = file.ModTime()
+			}
+			continue
+		}
+		if !m.moveFile(path, m.FinishedDirectory) {
+			// The file must not be read again.
+			m.failed[path] = file.ModTime()
+		}
+	}
+	return nil
+}
+
+func (m *DirectoryMonitor) isMonitored(name string) bool {
+	if m.monitor != nil && !m.monitor.Match(name) {
+		return false
+	}
+	if m.ignore != nil && m.ignore.Match(name) {
+		return false
+	}
+	return true
+}
+
+// ingestFile parses the complete file with a new parser, the metrics are only
+// added if the file can be parsed.
+func (m *DirectoryMonitor) ingestFile(path string, acc telegraf.Accumulator) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	parser, err := m.parserFunc()
+	if err != nil {
+		return fmt.Errorf("error creating parser: %v", err)
+	}
+	metrics, err := parser.Parse(buf)
+	if err != nil {
+		return err
+	}
+
+	for _, metric := range metrics {
+		if m.FileTag != "" {
+			metric.AddTag(m.FileTag, filepath.Base(path))
+		}
+		acc.AddMetric(metric)
+	}
+	return nil
+}
+
+// moveFile moves the file to the directory and returns false if the file is
+// left in place, because the directory is not set or the move failed.
+func (m *DirectoryMonitor) moveFile(path string, directory string) bool {
+	if directory == "" {
+		return false
+	}
+
+	dest := filepath.Join(directory, filepath.Base(path))
+	if err := os.Rename(path, dest); err != nil {
+		m.Log.Errorf("Error moving file %q to %q: %v", path, dest, err)
+		return false
+	}
+	return true
+}
+
+func init() {
+	inputs.Add("directory_monitor", func() telegraf.Input {
+		return &DirectoryMonitor{
+			DirectoryDurationThreshold: internal.Duration{Duration: 50 * time.Millisecond},
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package directory_monitor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDirectories returns the monitored, finished and error directories.
+func newTestDirectories(t *testing.T) (string, string, string, func()) {
+	root, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+
+	dirs := []string{"monitored", "finished", "error"}
+	for i, name := range dirs {
+		dirs[i] = filepath.Join(root, name)
+		require.NoError(t, os.Mkdir(dirs[i], 0755))
+	}
+	return dirs[0], dirs[1], dirs[2], func() { os.RemoveAll(root) }
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func newDirectoryMonitor(directory, finished, errored string) *DirectoryMonitor {
+	m := &DirectoryMonitor{
+		Directory:         directory,
+		FinishedDirectory: finished,
+		ErrorDirectory:    errored,
+		Log:               testutil.Logger{},
+	}
+	m.SetParserFunc(parsers.NewInfluxParser)
+	return m
+}
+
+func TestGather(t *testing.T) {
+	monitored, finished, errored, cleanup := newTestDirectories(t)
+	defer cleanup()
+
+	writeFile(t, monitored, "metrics.influx", "cpu,host=a usage_idle=42 0\ncpu,host=b usage_idle=43 0\n")
+
+	plugin := newDirectoryMonitor(monitored, finished, errored)
+	plugin.DirectoryDurationThreshold = internal.Duration{}
+	plugin.FileTag = "filename"
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a", "filename": "metrics.influx"},
+			map[string]interface{}{"usage_idle": 42.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "b", "filename": "metrics.influx"},
+			map[string]interface{}{"usage_idle": 43.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	require.FileExists(t, filepath.Join(finished, "metrics.influx"))
+	_, err := os.Stat(filepath.Join(monitored, "metrics.influx"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestGatherCSV(t *testing.T) {
+	monitored, finished, errored, cleanup := newTestDirectories(t)
+	defer cleanup()
+
+	// The header of each file is parsed with a new parser.
+	writeFile(t, monitored, "a.csv", "host,value\na,1\n")
+	writeFile(t, monitored, "b.csv", "host,value\nb,2\n")
+
+	plugin := newDirectoryMonitor(monitored, finished, errored)
+	plugin.DirectoryDurationThreshold = internal.Duration{}
+	plugin.SetParserFunc(func() (parsers.Parser, error) {
+		return parsers.NewParser(&parsers.Config{
+			DataFormat:        "csv",
+			MetricName:        "inventory",
+			CSVHeaderRowCount: 1,
+			CSVTagColumns:     []string{"host"},
+		})
+	})
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "inventory", map[string]interface{}{"value": int64(1)}, map[string]string{"host": "a"})
+	acc.AssertContainsTaggedFields(t, "inventory", map[string]interface{}{"value": int64(2)}, map[string]string{"host": "b"})
+}
+
+func TestParseError(t *testing.T) {
+	monitored, finished, errored, cleanup := newTestDirectories(t)
+	defer cleanup()
+
+	writeFile(t, monitored, "invalid.influx", "cpu usage_idle=\n")
+
+	plugin := newDirectoryMonitor(monitored, finished, errored)
+	plugin.DirectoryDurationThreshold = internal.Duration{}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Empty(t, acc.Metrics)
+	require.FileExists(t, filepath.Join(errored, "invalid.influx"))
+}
+
+func TestParseErrorWithoutErrorDirectory(t *testing.T) {
+	monitored, finished, _, cleanup := newTestDirectories(t)
+	defer cleanup()
+
+	writeFile(t, monitored, "invalid.influx", "cpu usage_idle=\n")
+
+	plugin := newDirectoryMonitor(monitored, finished, "")
+	plugin.DirectoryDurationThreshold = internal.Duration{}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+
+	// The file is left in place and not read again until it is modified.
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.FileExists(t, filepath.Join(monitored, "invalid.influx"))
+}
+
+func TestFilters(t *testing.T) {
+	monitored, finished, errored, cleanup := newTestDirectories(t)
+	defer cleanup()
+
+	writeFile(t, monitored, "metrics.influx", "cpu usage_idle=42 0\n")
+	writeFile(t, monitored, "metrics.txt", "cpu usage_idle=43 0\n")
+	writeFile(t, monitored, "partial.influx.tmp", "cpu usage_idle=44 0\n")
+
+	plugin := newDirectoryMonitor(monitored, finished, errored)
+	plugin.DirectoryDurationThreshold = internal.Duration{}
+	plugin.FilesToMonitor = []string{"*.influx*"}
+	plugin.FilesToIgnore = []string{"*.tmp"}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Metrics, 1)
+	require.FileExists(t, filepath.Join(monitored, "metrics.txt"))
+	require.FileExists(t, filepath.Join(monitored, "partial.influx.tmp"))
+}
+
+func TestDurationThreshold(t *testing.T) {
+	monitored, finished, errored, cleanup := newTestDirectories(t)
+	defer cleanup()
+
+	writeFile(t, monitored, "metrics.influx", "cpu usage_idle=42 0\n")
+
+	plugin := newDirectoryMonitor(monitored, finished, errored)
+	plugin.DirectoryDurationThreshold = internal.Duration{Duration: time.Hour}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Metrics)
+	require.FileExists(t, filepath.Join(monitored, "metrics.influx"))
+}
+
+func TestMissingDirectory(t *testing.T) {
+	plugin := newDirectoryMonitor("", "", "")
+
+	var acc testutil.Accumulator
+	require.Error(t, plugin.Gather(&acc))
+}
@@ -0,0 +1,522 @@
+package docker_log
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Docker Endpoint
+  ##   To use TCP, set endpoint = "tcp://[ip]:[port]"
+  ##   To use environment variables (ie, docker-machine), set endpoint = "ENV"
+  # endpoint = "unix:///var/run/docker.sock"
+
+  ## When true, container logs are read from the beginning; otherwise reading
+  ## begins at the end of the log.  Containers with a known offset continue
+  ## reading after the last line read.
+  # from_beginning = false
+
+  ## Timeout for Docker API calls.
+  # timeout = "5s"
+
+  ## Containers to include and exclude. Globs accepted.
+  ## Note that an empty array for both will include all containers
+  # container_name_include = []
+  # container_name_exclude = []
+
+  ## Container states to include and exclude. Globs accepted.
+  ## When empty only containers in the "running" state will be captured.
+  # container_state_include = []
+  # container_state_exclude = []
+
+  ## docker labels to include and exclude as tags.  Globs accepted.
+  ## Note that an empty array for both will include all labels as tags
+  # docker_label_include = []
+  # docker_label_exclude = []
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Data format to parse each log line with.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+const (
+	defaultEndpoint = "unix:///var/run/docker.sock"
+)
+
+var (
+	containerStates = []string{"created", "restarting", "running", "removing", "paused", "exited", "dead"}
+)
+
+type DockerLogs struct {
+	Endpoint      string            `toml:"endpoint"`
+	FromBeginning bool              `toml:"from_beginning"`
+	Timeout       internal.Duration `toml:"timeout"`
+	LabelInclude  []string          `toml:"docker_label_include"`
+	LabelExclude  []string          `toml:"docker_label_exclude"`
+
+	ContainerInclude []string `toml:"container_name_include"`
+	ContainerExclude []string `toml:"container_name_exclude"`
+
+	ContainerStateInclude []string `toml:"container_state_include"`
+	ContainerStateExclude []string `toml:"container_state_exclude"`
+
+	tlsint.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	newEnvClient func() (Client, error)
+	newClient    func(string, *tls.Config) (Client, error)
+
+	client          Client
+	parserFunc      parsers.ParserFunc
+	labelFilter     filter.Filter
+	containerFilter filter.Filter
+	opts            types.ContainerListOptions
+	wg              sync.WaitGroup
+
+	mu sync.Mutex
+	// containers holds the cancel function of the containers being read.
+	containers map[string]context.CancelFunc
+	// offsets holds the time of the last line read of each stream of each
+	// container.
+	offsets map[string]map[string]time.Time
+}
+
+func (d *DockerLogs) Description() string {
+	return "Read and parse the logs of docker containers"
+}
+
+func (d *DockerLogs) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *DockerLogs) SetParserFunc(fn parsers.ParserFunc) {
+	d.parserFunc = fn
+}
+
+func (d *DockerLogs) Start(acc telegraf.Accumulator) error {
+	var err error
+	if d.Endpoint == "ENV" {
+		d.client, err = d.newEnvClient()
+	} else {
+		var tlsConfig *tls.Config
+		tlsConfig, err = d.ClientConfig.TLSConfig()
+		if err != nil {
+			return err
+		}
+		d.client, err = d.newClient(d.Endpoint, tlsConfig)
+	}
+	if err != nil {
+		return err
+	}
+
+	d.labelFilter, err = filter.NewIncludeExcludeFilter(d.LabelInclude, d.LabelExclude)
+	if err != nil {
+		return err
+	}
+	d.containerFilter, err = filter.NewIncludeExcludeFilter(d.ContainerInclude, d.ContainerExclude)
+	if err != nil {
+		return err
+	}
+
+	if len(d.ContainerStateInclude) == 0 && len(d.ContainerStateExclude) == 0 {
+		d.ContainerStateInclude = []string{"running"}
+	}
+	stateFilter, err := filter.NewIncludeExcludeFilter(d.ContainerStateInclude, d.ContainerStateExclude)
+	if err != nil {
+		return err
+	}
+
+	filterArgs := filters.NewArgs()
+	for _, state := range containerStates {
+		if stateFilter.Match(state) {
+			filterArgs.Add("status", state)
+		}
+	}
+	d.opts = types.ContainerListOptions{
+		Filters: filterArgs,
+	}
+	return nil
+}
+
+// Gather starts reading the logs of the containers which are not read yet.
+// The logs of a container are read until it stops.
+func (d *DockerLogs) Gather(acc telegraf.Accumulator) error {
+	// All container states were excluded
+	if d.opts.Filters.Len() == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout.Duration)
+	defer cancel()
+	containers, err := d.client.ContainerList(ctx, d.opts)
+	if err != nil {
+		return err
+	}
+	if err := d.pruneOffsets(ctx, containers); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, container := range containers {
+		if _, ok := d.containers[container.ID]; ok {
+			continue
+		}
+
+		name := d.matchedName(container.Names)
+		if name == "" {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		d.containers[container.ID] = cancel
+
+		d.wg.Add(1)
+		go func(container types.Container) {
+			defer d.wg.Done()
+			defer d.removeContainer(container.ID)
+
+			err := d.tailContainer(ctx, acc, container, name)
+			if err != nil && ctx.Err() == nil {
+				acc.AddError(fmt.Errorf("error reading logs of container %s: %v", name, err))
+			}
+		}(container)
+	}
+	return nil
+}
+
+// pruneOffsets drops the offsets of the containers that were removed.  The
+// containers not listed may only be stopped, they are looked up in the list of
+// all containers.
+func (d *DockerLogs) pruneOffsets(ctx context.Context, containers []types.Container) error {
+	listed := make(map[string]bool, len(containers))
+	for _, container := range containers {
+		listed[container.ID] = true
+	}
+
+	d.mu.Lock()
+	var unlisted []string
+	for id := range d.offsets {
+		if _, ok := d.containers[id]; !ok && !listed[id] {
+			unlisted = append(unlisted, id)
+		}
+	}
+	d.mu.Unlock()
+	if len(unlisted) == 0 {
+		return nil
+	}
+
+	all, err := d.client.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(all))
+	for _, container := range all {
+		exists[container.ID] = true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, id := range unlisted {
+		if !exists[id] {
+			delete(d.offsets, id)
+		}
+	}
+	return nil
+}
+
+func (d *DockerLogs) matchedName(names []string) string {
+	for _, name := range names {
+		trimmedName := strings.TrimPrefix(name, "/")
+		if d.containerFilter.Match(trimmedName) {
+			return trimmedName
+		}
+	}
+	return ""
+}
+
+func (d *DockerLogs) removeContainer(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.containers, id)
+}
+
+func (d *DockerLogs) tailContainer(
+	ctx context.Context,
+	acc telegraf.Accumulator,
+	container types.Container,
+	name string,
+) error {
+	imageName, imageVersion := parseImage(container.Image)
+	tags := map[string]string{
+		"container_name":    name,
+		"container_image":   imageName,
+		"container_version": imageVersion,
+	}
+	for k, label := range container.Labels {
+		if d.labelFilter.Match(k) {
+			tags[k] = label
+		}
+	}
+
+	inspectCtx, cancel := context.WithTimeout(ctx, d.Timeout.Duration)
+	defer cancel()
+	info, err := d.client.ContainerInspect(inspectCtx, container.ID)
+	if err != nil {
+		return err
+	}
+	tty := info.Config != nil && info.Config.Tty
+
+	// Parsers are not safe for concurrent use, stdout and stderr are read
+	// concurrently.
+	stdoutParser, err := d.parserFunc()
+	if err != nil {
+		return err
+	}
+	stderrParser, err := d.parserFunc()
+	if err != nil {
+		return err
+	}
+
+	opts := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Follow:     true,
+	}
+	// The logs continue after the stream read the least, the lines of the
+	// other streams are skipped up to their own offset.
+	d.mu.Lock()
+	offsets := make(map[string]time.Time, len(d.offsets[container.ID]))
+	var since time.Time
+	for stream, offset := range d.offsets[container.ID] {
+		offsets[stream] = offset
+		if since.IsZero() || offset.Before(since) {
+			since = offset
+		}
+	}
+	d.mu.Unlock()
+	if !since.IsZero() {
+		// The since option includes the lines at the given time.
+		opts.Since = since.Add(time.Nanosecond).Format(time.RFC3339Nano)
+	} else if !d.FromBeginning {
+		opts.Tail = "0"
+	}
+
+	logs, err := d.client.ContainerLogs(ctx, container.ID, opts)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	// A container with a TTY has a single raw stream, otherwise stdout and
+	// stderr are multiplexed.
+	if tty {
+		return d.tailStream(acc, stdoutParser, container.ID, tags, "tty", offsets["tty"], logs)
+	}
+
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		d.tailStream(acc, stdoutParser, container.ID, tags, "stdout", offsets["stdout"], stdoutReader)
+	}()
+	go func() {
+		defer wg.Done()
+		d.tailStream(acc, stderrParser, container.ID, tags, "stderr", offsets["stderr"], stderrReader)
+	}()
+
+	_, err = stdcopy.StdCopy(stdoutWriter, stderrWriter, logs)
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	wg.Wait()
+	return err
+}
+
+// tailStream parses each line of the stream after the offset until the end
+// of the stream.  The reader is always read to the end, so that writers to a
+// pipe are never blocked.
+func (d *DockerLogs) tailStream(
+	acc telegraf.Accumulator,
+	parser parsers.Parser,
+	containerID string,
+	baseTags map[string]string,
+	stream string,
+	offset time.Time,
+	reader io.Reader,
+) error {
+	tags := make(map[string]string, len(baseTags)+1)
+	for k, v := range baseTags {
+		tags[k] = v
+	}
+	tags["stream"] = stream
+
+	r := bufio.NewReader(reader)
+	for {
+		line, err := r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			d.parseLine(acc, parser, containerID, tags, offset, line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *DockerLogs) parseLine(
+	acc telegraf.Accumulator,
+	parser parsers.Parser,
+	containerID string,
+	tags map[string]string,
+	offset time.Time,
+	line string,
+) {
+	// Each line is prefixed with its timestamp.
+	i := strings.IndexByte(line, ' ')
+	if i < 0 {
+		i = len(line)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, line[:i])
+	if err != nil {
+		acc.AddError(fmt.Errorf("invalid timestamp in log line of container %s: %v",
+			tags["container_name"], err))
+		return
+	}
+	if !ts.After(offset) {
+		return
+	}
+	defer d.setOffset(containerID, tags["stream"], ts)
+
+	message := strings.TrimSpace(line[i:])
+	if message == "" {
+		return
+	}
+
+	metrics, err := parser.Parse([]byte(message))
+	if err != nil {
+		acc.AddError(fmt.Errorf("error parsing log line of container %s: %v",
+			tags["container_name"], err))
+		return
+	}
+	for _, metric := range metrics {
+		for k, v := range tags {
+			metric.AddTag(k, v)
+		}
+		acc.AddMetric(metric)
+	}
+}
+
+func (d *DockerLogs) setOffset(containerID, stream string, ts time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	offsets, ok := d.offsets[containerID]
+	if !ok {
+		offsets = make(map[string]time.Time)
+		d.offsets[containerID] = offsets
+	}
+	if ts.After(offsets[stream]) {
+		offsets[stream] = ts
+	}
+}
+
+func (d *DockerLogs) Stop() {
+	d.mu.Lock()
+	for _, cancel := range d.containers {
+		cancel()
+	}
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+// GetState returns the time of the last line read of each stream of each
+// container.
+func (d *DockerLogs) GetState() interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	offsets := make(map[string]map[string]time.Time, len(d.offsets))
+	for id, streams := range d.offsets {
+		offsets[id] = make(map[string]time.Time, len(streams))
+		for stream, offset := range streams {
+			offsets[id][stream] = offset
+		}
+	}
+	return offsets
+}
+
+// SetState restores the offsets, the logs of these containers continue after
+// the last line read.
+func (d *DockerLogs) SetState(state interface{}) error {
+	offsets, ok := state.(map[string]map[string]time.Time)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+	if offsets == nil {
+		offsets = make(map[string]map[string]time.Time)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.offsets = offsets
+	return nil
+}
+
+// parseImage splits the image name from its version, ie:
+// rabbitmq:3-management or docker.someco.net:4443/rabbitmq:3-management.
+func parseImage(image string) (string, string) {
+	imageName := image
+	imageVersion := "unknown"
+	i := strings.LastIndex(image, ":")
+	if i > -1 && !strings.Contains(image[i+1:], "/") {
+		imageVersion = image[i+1:]
+		imageName = image[:i]
+	}
+	return imageName, imageVersion
+}
+
+func init() {
+	inputs.Add("docker_log", func() telegraf.Input {
+		return &DockerLogs{
+			Timeout:      internal.Duration{Duration: time.Second * 5},
+			Endpoint:     defaultEndpoint,
+			newEnvClient: NewEnvClient,
+			newClient:    NewClient,
+			containers:   make(map[string]context.CancelFunc),
+			offsets:      make(map[string]map[string]time.Time),
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package docker_log
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type MockClient struct {
+	ContainerListF    func(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
+	ContainerLogsF    func(ctx context.Context, containerID string, options types.ContainerLogsOptions) (io.ReadCloser, error)
+	ContainerInspectF func(ctx context.Context, containerID string) (types.ContainerJSON, error)
+}
+
+func (c *MockClient) ContainerList(
+	ctx context.Context,
+	options types.ContainerListOptions,
+) ([]types.Container, error) {
+	return c.ContainerListF(ctx, options)
+}
+
+func (c *MockClient) ContainerLogs(
+	ctx context.Context,
+	containerID string,
+	options types.ContainerLogsOptions,
+) (io.ReadCloser, error) {
+	return c.ContainerLogsF(ctx, containerID, options)
+}
+
+func (c *MockClient) ContainerInspect(
+	ctx context.Context,
+	containerID string,
+) (types.ContainerJSON, error) {
+	return c.ContainerInspectF(ctx, containerID)
+}
+
+var redis = types.Container{
+	ID:     "deadbeef",
+	Names:  []string{"/redis"},
+	Image:  "quay.io:8080/redis:5.0",
+	Labels: map[string]string{"app": "cache"},
+}
+
+func multiplexed(stdout, stderr string) []byte {
+	var buf bytes.Buffer
+	stdcopy.NewStdWriter(&buf, stdcopy.Stdout).Write([]byte(stdout))
+	stdcopy.NewStdWriter(&buf, stdcopy.Stderr).Write([]byte(stderr))
+	return buf.Bytes()
+}
+
+func newMockClient(c types.Container, tty bool, logs []byte, options *types.ContainerLogsOptions) *MockClient {
+	return &MockClient{
+		ContainerListF: func(context.Context, types.ContainerListOptions) ([]types.Container, error) {
+			return []types.Container{c}, nil
+		},
+		ContainerLogsF: func(ctx context.Context, id string, opts types.ContainerLogsOptions) (io.ReadCloser, error) {
+			if options != nil {
+				*options = opts
+			}
+			return ioutil.NopCloser(bytes.NewReader(logs)), nil
+		},
+		ContainerInspectF: func(context.Context, string) (types.ContainerJSON, error) {
+			return types.ContainerJSON{
+				Config: &container.Config{Tty: tty},
+			}, nil
+		},
+	}
+}
+
+func newDockerLogs(client Client) *DockerLogs {
+	d := &DockerLogs{
+		Endpoint: defaultEndpoint,
+		Timeout:  internal.Duration{Duration: 5 * time.Second},
+		newClient: func(string, *tls.Config) (Client, error) {
+			return client, nil
+		},
+		Log:        testutil.Logger{},
+		containers: make(map[string]context.CancelFunc),
+		offsets:    make(map[string]map[string]time.Time),
+	}
+	d.SetParserFunc(parsers.NewInfluxParser)
+	return d
+}
+
+func gather(t *testing.T, d *DockerLogs) *testutil.Accumulator {
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	require.NoError(t, d.Gather(&acc))
+	// The logs of the mock client end, reading stops by itself.
+	d.wg.Wait()
+	d.Stop()
+	return &acc
+}
+
+func TestMultiplexedLogs(t *testing.T) {
+	logs := multiplexed(
+		"2019-10-01T12:00:00.000000001Z requests,path=/ count=1i 0\n",
+		"2019-10-01T12:00:00.000000002Z errors count=2i 0\n",
+	)
+	d := newDockerLogs(newMockClient(redis, false, logs, nil))
+	acc := gather(t, d)
+	require.Empty(t, acc.Errors)
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "requests",
+		map[string]interface{}{"count": int64(1)},
+		map[string]string{
+			"path":              "/",
+			"stream":            "stdout",
+			"container_name":    "redis",
+			"container_image":   "quay.io:8080/redis",
+			"container_version": "5.0",
+			"app":               "cache",
+		})
+	acc.AssertContainsTaggedFields(t, "errors",
+		map[string]interface{}{"count": int64(2)},
+		map[string]string{
+			"stream":            "stderr",
+			"container_name":    "redis",
+			"container_image":   "quay.io:8080/redis",
+			"container_version": "5.0",
+			"app":               "cache",
+		})
+
+	state := d.GetState().(map[string]map[string]time.Time)
+	require.Equal(t, time.Date(2019, 10, 1, 12, 0, 0, 1, time.UTC), state["deadbeef"]["stdout"].UTC())
+	require.Equal(t, time.Date(2019, 10, 1, 12, 0, 0, 2, time.UTC), state["deadbeef"]["stderr"].UTC())
+}
+
+func TestTTYLogs(t *testing.T) {
+	logs := []byte("2019-10-01T12:00:00Z requests count=1i 0\r\n")
+	d := newDockerLogs(newMockClient(redis, true, logs, nil))
+	acc := gather(t, d)
+	require.Empty(t, acc.Errors)
+
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "tty", acc.Metrics[0].Tags["stream"])
+	require.Equal(t, int64(1), acc.Metrics[0].Fields["count"])
+}
+
+func TestParseError(t *testing.T) {
+	logs := multiplexed("2019-10-01T12:00:00Z not influx\n2019-10-01T12:00:01Z requests count=1i 0\n", "")
+	d := newDockerLogs(newMockClient(redis, false, logs, nil))
+	acc := gather(t, d)
+
+	require.Len(t, acc.Errors, 1)
+	require.Len(t, acc.Metrics, 1)
+}
+
+func TestOptions(t *testing.T) {
+	var options types.ContainerLogsOptions
+	d := newDockerLogs(newMockClient(redis, false, nil, &options))
+	gather(t, d)
+	require.Equal(t, "0", options.Tail)
+	require.Equal(t, "", options.Since)
+	require.True(t, options.Timestamps)
+	require.True(t, options.Follow)
+
+	d = newDockerLogs(newMockClient(redis, false, nil, &options))
+	d.FromBeginning = true
+	gather(t, d)
+	require.Equal(t, "", options.Tail)
+}
+
+func TestRestoredOffset(t *testing.T) {
+	var options types.ContainerLogsOptions
+	d := newDockerLogs(newMockClient(redis, false, nil, &options))
+	require.NoError(t, d.SetState(map[string]map[string]time.Time{
+		"deadbeef": {"stdout": time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)},
+	}))
+	gather(t, d)
+
+	// The line at the offset was read already.
+	require.Equal(t, "2019-10-01T12:00:00.000000001Z", options.Since)
+	require.Equal(t, "", options.Tail)
+}
+
+func TestRestoredStreamOffsets(t *testing.T) {
+	logs := multiplexed(
+		"2019-10-01T12:00:02Z requests count=1i 0\n2019-10-01T12:00:03Z requests count=2i 0\n",
+		"2019-10-01T12:00:02Z errors count=3i 0\n",
+	)
+	var options types.ContainerLogsOptions
+	d := newDockerLogs(newMockClient(redis, false, logs, &options))
+	require.NoError(t, d.SetState(map[string]map[string]time.Time{
+		"deadbeef": {
+			"stdout": time.Date(2019, 10, 1, 12, 0, 2, 0, time.UTC),
+			"stderr": time.Date(2019, 10, 1, 12, 0, 1, 0, time.UTC),
+		},
+	}))
+	acc := gather(t, d)
+	require.Empty(t, acc.Errors)
+
+	// The logs continue after stderr, the stdout line read already is
+	// skipped.
+	require.Equal(t, "2019-10-01T12:00:01.000000001Z", options.Since)
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsFields(t, "requests", map[string]interface{}{"count": int64(2)})
+	acc.AssertContainsFields(t, "errors", map[string]interface{}{"count": int64(3)})
+}
+
+func TestRemovedContainerOffsets(t *testing.T) {
+	d := newDockerLogs(newMockClient(redis, false, nil, nil))
+	require.NoError(t, d.SetState(map[string]map[string]time.Time{
+		"deadbeef": {"stdout": time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)},
+		"removed":  {"stdout": time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)},
+	}))
+	gather(t, d)
+
+	state := d.GetState().(map[string]map[string]time.Time)
+	require.Contains(t, state, "deadbeef")
+	require.NotContains(t, state, "removed")
+}
+
+func TestContainerFilter(t *testing.T) {
+	logs := multiplexed("2019-10-01T12:00:00Z requests count=1i 0\n", "")
+	d := newDockerLogs(newMockClient(redis, false, logs, nil))
+	d.ContainerExclude = []string{"redis"}
+	acc := gather(t, d)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestLabelFilter(t *testing.T) {
+	logs := multiplexed("2019-10-01T12:00:00Z requests count=1i 0\n", "")
+	d := newDockerLogs(newMockClient(redis, false, logs, nil))
+	d.LabelExclude = []string{"app"}
+	acc := gather(t, d)
+	require.Len(t, acc.Metrics, 1)
+	require.NotContains(t, acc.Metrics[0].Tags, "app")
+}
+
+func TestStop(t *testing.T) {
+	client := newMockClient(redis, false, nil, nil)
+	client.ContainerLogsF = func(ctx context.Context, id string, opts types.ContainerLogsOptions) (io.ReadCloser, error) {
+		// A followed log never ends until the request is cancelled.
+		r, w := io.Pipe()
+		go func() {
+			<-ctx.Done()
+			w.CloseWithError(ctx.Err())
+		}()
+		return r, nil
+	}
+
+	d := newDockerLogs(client)
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	require.NoError(t, d.Gather(&acc))
+	// The container is read already.
+	require.NoError(t, d.Gather(&acc))
+	d.Stop()
+	require.Empty(t, acc.Errors)
+	require.Empty(t, d.containers)
+}
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		image   string
+		name    string
+		version string
+	}{
+		{"redis", "redis", "unknown"},
+		{"rabbitmq:3-management", "rabbitmq", "3-management"},
+		{"docker.someco.net:4443/rabbitmq:3-management", "docker.someco.net:4443/rabbitmq", "3-management"},
+		{"docker.someco.net:4443/rabbitmq", "docker.someco.net:4443/rabbitmq", "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			name, version := parseImage(tt.image)
+			require.Equal(t, tt.name, name)
+			require.Equal(t, tt.version, version)
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	jsonparser "github.com/influxdata/telegraf/plugins/parsers/json"
@@ -121,6 +122,12 @@ const sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 // Elasticsearch is a plugin to read stats from one or many Elasticsearch
@@ -135,6 +142,7 @@ type Elasticsearch struct {
 	ClusterStatsOnlyFromMaster bool
 	NodeStats                  []string
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client                  *http.Client
 	catMasterResponseTokens []string
@@ -238,9 +246,16 @@ func (e *Elasticsearch) createHttpClient() (*http.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	proxy, err := e.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	tr := &http.Transport{
 		ResponseHeaderTimeout: e.HttpTimeout.Duration,
 		TLSClientConfig:       tlsCfg,
+		Proxy:                 proxy,
 	}
 	client := &http.Client{
 		Transport: tr,
@@ -0,0 +1,147 @@
+package execd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon, followed by its arguments.
+  command = ["telegraf-smartctl", "-d", "/dev/sda"]
+
+  ## Define how the process is signaled on each collection interval.
+  ## Valid values are:
+  ##   "none"    : Do not signal anything. (Recommended for service inputs)
+  ##               The process must output metrics by itself.
+  ##   "STDIN"   : Send a newline on STDIN. (Recommended for gather inputs)
+  ##   "SIGHUP"  : Send a HUP signal. Not available on Windows.
+  ##   "SIGUSR1" : Send a USR1 signal. Not available on Windows.
+  ##   "SIGUSR2" : Send a USR2 signal. Not available on Windows.
+  signal = "none"
+
+  ## Delay before the process is restarted after an unexpected termination
+  restart_delay = "10s"
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+const maxLineSize = 1024 * 1024
+
+type Execd struct {
+	Command      []string
+	Signal       string
+	RestartDelay internal.Duration
+	Log          telegraf.Logger `toml:"-"`
+
+	process *process.Process
+	parser  parsers.Parser
+	acc     telegraf.Accumulator
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running input plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	switch e.Signal {
+	case "", "none", "STDIN", "SIGHUP", "SIGUSR1", "SIGUSR2":
+	default:
+		return fmt.Errorf("invalid signal %q", e.Signal)
+	}
+
+	e.acc = acc
+
+	var err error
+	e.process, err = process.New(e.Command, e.Log)
+	if err != nil {
+		return err
+	}
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdout = e.readStdout
+	e.process.ReadStderr = process.LogStderr(e.Log)
+
+	return e.process.Start()
+}
+
+func (e *Execd) Stop() {
+	e.process.Stop()
+}
+
+func (e *Execd) Gather(acc telegraf.Accumulator) error {
+	if e.process == nil {
+		return nil
+	}
+
+	switch e.Signal {
+	case "", "none":
+		return nil
+	case "STDIN":
+		if _, err := e.process.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("error writing to stdin: %v", err)
+		}
+		return nil
+	default:
+		return e.signal()
+	}
+}
+
+// readStdout parses each line written by the process as metrics.
+func (e *Execd) readStdout(r io.Reader) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	for {
+		line, err := process.ReadLine(reader, maxLineSize)
+		if err == process.ErrLineTooLong {
+			e.acc.AddError(fmt.Errorf("line exceeds %d bytes, skipping it", maxLineSize))
+			continue
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			e.Log.Errorf("Error reading stdout: %v", err)
+			return
+		}
+
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		metrics, err := e.parser.Parse(line)
+		if err != nil {
+			e.acc.AddError(fmt.Errorf("parse error: %v", err))
+			continue
+		}
+
+		for _, metric := range metrics {
+			e.acc.AddMetric(metric)
+		}
+	}
+}
+
+func init() {
+	inputs.Add("execd", func() telegraf.Input {
+		return &Execd{
+			Signal:       "none",
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
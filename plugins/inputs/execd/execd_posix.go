@@ -0,0 +1,25 @@
+// +build !windows
+
+package execd
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// signal sends the configured signal to the process.
+func (e *Execd) signal() error {
+	var err error
+	switch e.Signal {
+	case "SIGHUP":
+		err = e.process.Signal(syscall.SIGHUP)
+	case "SIGUSR1":
+		err = e.process.Signal(syscall.SIGUSR1)
+	case "SIGUSR2":
+		err = e.process.Signal(syscall.SIGUSR2)
+	}
+	if err != nil {
+		return fmt.Errorf("error signaling process: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// +build !windows
+
+package execd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalStdin(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := &Execd{
+		Command:      []string{"sh", "-c", "while read line; do echo 'counter count=1i'; done"},
+		Signal:       "STDIN",
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	require.NoError(t, e.Gather(&acc))
+	acc.Wait(1)
+	acc.AssertContainsFields(t, "counter",
+		map[string]interface{}{
+			"count": int64(1),
+		})
+}
+
+func TestSignalNone(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := &Execd{
+		Command:      []string{"sh", "-c", "echo 'counter count=2i'; cat"},
+		Signal:       "none",
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	acc.Wait(1)
+	acc.AssertContainsFields(t, "counter",
+		map[string]interface{}{
+			"count": int64(2),
+		})
+}
+
+func TestLongLine(t *testing.T) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := &Execd{
+		// Write a line longer than maxLineSize before the metric
+		Command:      []string{"sh", "-c", "head -c 1100000 /dev/zero | tr '\\0' a; echo; echo 'counter count=3i'; cat"},
+		Signal:       "none",
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	acc.Wait(1)
+	acc.AssertContainsFields(t, "counter",
+		map[string]interface{}{
+			"count": int64(3),
+		})
+	require.Len(t, acc.Errors, 1)
+}
+
+func TestInvalidSignal(t *testing.T) {
+	e := &Execd{
+		Command: []string{"cat"},
+		Signal:  "SIGKILL",
+	}
+	require.Error(t, e.Start(&testutil.Accumulator{}))
+}
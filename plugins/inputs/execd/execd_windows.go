@@ -0,0 +1,12 @@
+// +build windows
+
+package execd
+
+import (
+	"fmt"
+)
+
+// signal is not supported on Windows, only "none" and "STDIN" can be used.
+func (e *Execd) signal() error {
+	return fmt.Errorf("signal %q is not supported on Windows", e.Signal)
+}
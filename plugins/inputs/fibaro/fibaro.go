@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -22,6 +23,12 @@ const sampleConfig = `
 
   ## Amount of time allowed to complete the HTTP request
   # timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 const description = "Read devices value(s) from a Fibaro controller"
@@ -36,6 +43,8 @@ type Fibaro struct {
 
 	Timeout internal.Duration
 
+	httpconfig.ProxyConfig
+
 	client *http.Client
 }
 
@@ -120,9 +129,14 @@ func (f *Fibaro) getJSON(path string, dataStruct interface{}) error {
 func (f *Fibaro) Gather(acc telegraf.Accumulator) error {
 
 	if f.client == nil {
+		proxy, err := f.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
 		f.client = &http.Client{
 			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
+				Proxy: proxy,
 			},
 			Timeout: f.Timeout.Duration,
 		}
@@ -212,6 +226,8 @@ func (f *Fibaro) Gather(acc telegraf.Accumulator) error {
 
 func init() {
 	inputs.Add("fibaro", func() telegraf.Input {
-		return &Fibaro{}
+		return &Fibaro{
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
+		}
 	})
 }
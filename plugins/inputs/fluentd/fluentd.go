@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -28,6 +29,12 @@ const (
 	  "monitor_agent",
 	  "dummy",
   ]
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 )
 
@@ -35,7 +42,9 @@ const (
 type Fluentd struct {
 	Endpoint string
 	Exclude  []string
-	client   *http.Client
+	httpconfig.ProxyConfig
+
+	client *http.Client
 }
 
 type endpointInfo struct {
@@ -88,9 +97,14 @@ func (h *Fluentd) Gather(acc telegraf.Accumulator) error {
 	}
 
 	if h.client == nil {
+		proxy, err := h.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
 
 		tr := &http.Transport{
 			ResponseHeaderTimeout: time.Duration(3 * time.Second),
+			Proxy:                 proxy,
 		}
 
 		client := &http.Client{
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -36,6 +37,7 @@ type GrayLog struct {
 	Username string
 	Password string
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client HTTPClient
 }
@@ -109,6 +111,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 func (h *GrayLog) SampleConfig() string {
@@ -128,9 +136,16 @@ func (h *GrayLog) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+
+		proxy, err := h.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
 		tr := &http.Transport{
 			ResponseHeaderTimeout: time.Duration(3 * time.Second),
 			TLSClientConfig:       tlsCfg,
+			Proxy:                 proxy,
 		}
 		client := &http.Client{
 			Transport: tr,
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -12,9 +13,12 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 type HTTP struct {
@@ -28,7 +32,15 @@ type HTTP struct {
 	// HTTP Basic Auth Credentials
 	Username string `toml:"username"`
 	Password string `toml:"password"`
+
+	// OAuth2 Client Credentials Grant
+	ClientID     string   `toml:"client_id"`
+	ClientSecret string   `toml:"client_secret"`
+	TokenURL     string   `toml:"token_url"`
+	Scopes       []string `toml:"scopes"`
+
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	Timeout internal.Duration `toml:"timeout"`
 
@@ -55,6 +67,12 @@ var sampleConfig = `
   # username = "username"
   # password = "pa$$word"
 
+  ## OAuth2 Client Credentials Grant
+  # client_id = "clientid"
+  # client_secret = "secret"
+  # token_url = "https://indentityprovider/oauth2/v1/token"
+  # scopes = ["urn:opc:idm:__myscopes__"]
+
   ## HTTP entity-body to send with POST/PUT requests.
   # body = ""
 
@@ -69,6 +87,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+
   ## Amount of time allowed to complete the HTTP request
   # timeout = "5s"
 
@@ -97,17 +121,11 @@ func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 	}
 
 	if h.client == nil {
-		tlsCfg, err := h.ClientConfig.TLSConfig()
+		client, err := h.createClient(context.Background())
 		if err != nil {
 			return err
 		}
-		h.client = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: tlsCfg,
-				Proxy:           http.ProxyFromEnvironment,
-			},
-			Timeout: h.Timeout.Duration,
-		}
+		h.client = client
 	}
 
 	var wg sync.WaitGroup
@@ -126,6 +144,42 @@ func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+func (h *HTTP) createClient(ctx context.Context) (*http.Client, error) {
+	tlsCfg, err := h.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := h.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
+		},
+		Timeout: h.Timeout.Duration,
+	}
+
+	// The token is requested with the same client, and renewed by the
+	// returned client once it expires.
+	if h.ClientID != "" && h.ClientSecret != "" && h.TokenURL != "" {
+		oauthConfig := clientcredentials.Config{
+			ClientID:     h.ClientID,
+			ClientSecret: h.ClientSecret,
+			TokenURL:     h.TokenURL,
+			Scopes:       h.Scopes,
+		}
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+		client = oauthConfig.Client(ctx)
+		client.Timeout = h.Timeout.Duration
+	}
+
+	return client, nil
+}
+
 // SetParser takes the data_format from the config and finds the right parser for that format
 func (h *HTTP) SetParser(parser parsers.Parser) {
 	h.parser = parser
@@ -219,6 +273,9 @@ func init() {
 		return &HTTP{
 			Timeout: internal.Duration{Duration: time.Second * 5},
 			Method:  "GET",
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
 		}
 	})
 }
@@ -6,8 +6,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf/internal"
 	plugin "github.com/influxdata/telegraf/plugins/inputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
@@ -242,3 +245,114 @@ func TestBodyAndContentEncoding(t *testing.T) {
 		})
 	}
 }
+
+type TestHandlerFunc func(t *testing.T, w http.ResponseWriter, r *http.Request)
+
+func TestOAuthClientCredentialsGrant(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	var token = "2YotnFZFEjr1zCsicMWpAA"
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", ts.Listener.Addr().String()))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		plugin       *plugin.HTTP
+		tokenHandler TestHandlerFunc
+		handler      TestHandlerFunc
+	}{
+		{
+			name: "no credentials",
+			plugin: &plugin.HTTP{
+				URLs: []string{u.String() + "/metrics"},
+			},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Len(t, r.Header["Authorization"], 0)
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		{
+			name: "success",
+			plugin: &plugin.HTTP{
+				URLs:         []string{u.String() + "/metrics"},
+				ClientID:     "howdy",
+				ClientSecret: "secret",
+				TokenURL:     u.String() + "/token",
+				Scopes:       []string{"urn:opc:idm:__myscopes__"},
+			},
+			tokenHandler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				values := url.Values{}
+				values.Add("access_token", token)
+				values.Add("token_type", "bearer")
+				values.Add("expires_in", "3600")
+				w.Write([]byte(values.Encode()))
+			},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, []string{"Bearer " + token}, r.Header["Authorization"])
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/metrics":
+					tt.handler(t, w, r)
+				case "/token":
+					tt.tokenHandler(t, w, r)
+				}
+			})
+
+			parser, err := parsers.NewParser(&parsers.Config{DataFormat: "influx"})
+			require.NoError(t, err)
+			tt.plugin.SetParser(parser)
+
+			var acc testutil.Accumulator
+			err = tt.plugin.Gather(&acc)
+			require.NoError(t, err)
+			require.Empty(t, acc.Errors)
+		})
+	}
+}
+
+func TestOAuthClientTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metrics":
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+			}
+		case "/token":
+			values := url.Values{}
+			values.Add("access_token", "2YotnFZFEjr1zCsicMWpAA")
+			values.Add("token_type", "bearer")
+			values.Add("expires_in", "3600")
+			w.Write([]byte(values.Encode()))
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	h := &plugin.HTTP{
+		URLs:         []string{ts.URL + "/metrics"},
+		ClientID:     "howdy",
+		ClientSecret: "secret",
+		TokenURL:     ts.URL + "/token",
+		Timeout:      internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	parser, err := parsers.NewParser(&parsers.Config{DataFormat: "influx"})
+	require.NoError(t, err)
+	h.SetParser(parser)
+
+	// The client requesting the token keeps the timeout.
+	var acc testutil.Accumulator
+	require.NoError(t, h.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+}
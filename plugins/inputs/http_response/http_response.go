@@ -16,6 +16,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -23,7 +24,7 @@ import (
 // HTTPResponse struct
 type HTTPResponse struct {
 	Address             string
-	HTTPProxy           string `toml:"http_proxy"`
+	HTTPProxy           string `toml:"http_proxy" deprecated:"1.12.0;2.0.0;use 'http_proxy_url' instead"`
 	Body                string
 	Method              string
 	ResponseTimeout     internal.Duration
@@ -31,6 +32,7 @@ type HTTPResponse struct {
 	FollowRedirects     bool
 	ResponseStringMatch string
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	compiledStringMatch *regexp.Regexp
 	client              *http.Client
@@ -45,8 +47,11 @@ var sampleConfig = `
   ## Server address (default http://localhost)
   # address = "http://localhost"
 
-  ## Set http_proxy (telegraf uses the system wide proxy settings if it's is not set)
-  # http_proxy = "http://localhost:8888"
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 
   ## Set response_timeout (default 5 seconds)
   # response_timeout = "5s"
@@ -87,22 +92,6 @@ func (h *HTTPResponse) SampleConfig() string {
 // ErrRedirectAttempted indicates that a redirect occurred
 var ErrRedirectAttempted = errors.New("redirect")
 
-// Set the proxy. A configured proxy overwrites the system wide proxy.
-func getProxyFunc(http_proxy string) func(*http.Request) (*url.URL, error) {
-	if http_proxy == "" {
-		return http.ProxyFromEnvironment
-	}
-	proxyURL, err := url.Parse(http_proxy)
-	if err != nil {
-		return func(_ *http.Request) (*url.URL, error) {
-			return nil, errors.New("bad proxy: " + err.Error())
-		}
-	}
-	return func(r *http.Request) (*url.URL, error) {
-		return proxyURL, nil
-	}
-}
-
 // CreateHttpClient creates an http client which will timeout at the specified
 // timeout period and can follow redirects if specified
 func (h *HTTPResponse) createHttpClient() (*http.Client, error) {
@@ -110,9 +99,17 @@ func (h *HTTPResponse) createHttpClient() (*http.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Support deprecated option name
+	if h.HTTPProxyURL == "" {
+		h.HTTPProxyURL = h.HTTPProxy
+	}
+	proxy, err := h.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
 	client := &http.Client{
 		Transport: &http.Transport{
-			Proxy:             getProxyFunc(h.HTTPProxy),
+			Proxy:             proxy,
 			DisableKeepAlives: true,
 			TLSClientConfig:   tlsCfg,
 		},
@@ -320,6 +317,10 @@ func (h *HTTPResponse) Gather(acc telegraf.Accumulator) error {
 
 func init() {
 	inputs.Add("http_response", func() telegraf.Input {
-		return &HTTPResponse{}
+		return &HTTPResponse{
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
+		}
 	})
 }
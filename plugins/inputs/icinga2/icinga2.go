@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -21,6 +22,7 @@ type Icinga2 struct {
 	Password        string
 	ResponseTimeout internal.Duration
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -68,6 +70,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = true
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
   `
 
 func (i *Icinga2) Description() string {
@@ -108,9 +116,15 @@ func (i *Icinga2) createHttpClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := i.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
 		},
 		Timeout: i.ResponseTimeout.Duration,
 	}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -18,6 +19,7 @@ type InfluxDB struct {
 	URLs    []string `toml:"urls"`
 	Timeout internal.Duration
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -45,6 +47,12 @@ func (*InfluxDB) SampleConfig() string {
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## http request & header timeout
   timeout = "5s"
 `
@@ -60,10 +68,17 @@ func (i *InfluxDB) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+
+		proxy, err := i.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
 		i.client = &http.Client{
 			Transport: &http.Transport{
 				ResponseHeaderTimeout: i.Timeout.Duration,
 				TLSClientConfig:       tlsCfg,
+				Proxy:                 proxy,
 			},
 			Timeout: i.Timeout.Duration,
 		}
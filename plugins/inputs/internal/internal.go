@@ -8,6 +8,8 @@ import (
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+var goroutines = selfstat.Register("agent", "goroutines", map[string]string{})
+
 type Self struct {
 	CollectMemstats bool
 }
@@ -54,6 +56,8 @@ func (s *Self) Gather(acc telegraf.Accumulator) error {
 		acc.AddFields("internal_memstats", fields, map[string]string{})
 	}
 
+	goroutines.Set(int64(runtime.NumGoroutine()))
+
 	for _, m := range selfstat.Metrics() {
 		acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
 	}
@@ -15,6 +15,7 @@ func TestSelfPlugin(t *testing.T) {
 
 	s.Gather(acc)
 	assert.True(t, acc.HasMeasurement("internal_memstats"))
+	assert.True(t, acc.HasInt64Field("internal_agent", "goroutines"))
 
 	// test that a registered stat is incremented
 	stat := selfstat.Register("mytest", "test", map[string]string{"test": "foo"})
@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -27,6 +28,7 @@ type Jenkins struct {
 	ResponseTimeout internal.Duration
 
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 	client *client
 
 	MaxConnections    int               `toml:"max_connections"`
@@ -58,6 +60,12 @@ const sampleConfig = `
   ## Use SSL but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## Optional Max Job Build Age filter
   ## Default 1 hour, ignore builds older than max_build_age
   # max_build_age = "1h"
@@ -124,9 +132,16 @@ func (j *Jenkins) newHTTPClient() (*http.Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parse jenkins config[%s]: %v", j.URL, err)
 	}
+
+	proxy, err := j.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	return &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
 			MaxIdleConns:    j.MaxConnections,
 		},
 		Timeout: j.ResponseTimeout.Duration,
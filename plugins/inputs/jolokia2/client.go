@@ -10,6 +10,7 @@ import (
 	"path"
 	"time"
 
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 )
 
@@ -24,6 +25,7 @@ type ClientConfig struct {
 	Username        string
 	Password        string
 	ProxyConfig     *ProxyConfig
+	HTTPProxy       httpconfig.ProxyConfig
 	tls.ClientConfig
 }
 
@@ -101,9 +103,15 @@ func NewClient(url string, config *ClientConfig) (*Client, error) {
 		return nil, err
 	}
 
+	proxy, err := config.HTTPProxy.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	transport := &http.Transport{
 		ResponseHeaderTimeout: config.ResponseTimeout,
 		TLSClientConfig:       tlsConfig,
+		Proxy:                 proxy,
 	}
 
 	client := &http.Client{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 )
 
@@ -20,6 +21,7 @@ type JolokiaAgent struct {
 	ResponseTimeout internal.Duration `toml:"response_timeout"`
 
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	Metrics  []MetricConfig `toml:"metric"`
 	gatherer *Gatherer
@@ -44,6 +46,12 @@ func (ja *JolokiaAgent) SampleConfig() string {
   # tls_key  = "/var/private/client-key.pem"
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## Add metrics to read
   [[inputs.jolokia2_agent.metric]]
     name  = "java_runtime"
@@ -111,5 +119,6 @@ func (ja *JolokiaAgent) createClient(url string) (*Client, error) {
 		Password:        ja.Password,
 		ResponseTimeout: ja.ResponseTimeout.Duration,
 		ClientConfig:    ja.ClientConfig,
+		HTTPProxy:       ja.ProxyConfig,
 	})
 }
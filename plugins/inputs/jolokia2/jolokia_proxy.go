@@ -3,6 +3,7 @@ package jolokia2
 import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 )
 
@@ -20,6 +21,7 @@ type JolokiaProxy struct {
 	Password        string
 	ResponseTimeout internal.Duration `toml:"response_timeout"`
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	Metrics  []MetricConfig `toml:"metric"`
 	client   *Client
@@ -50,6 +52,12 @@ func (jp *JolokiaProxy) SampleConfig() string {
   # tls_key  = "/var/private/client-key.pem"
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## Add proxy targets to query
   # default_target_username = ""
   # default_target_password = ""
@@ -118,6 +126,7 @@ func (jp *JolokiaProxy) createClient() (*Client, error) {
 		Password:        jp.Password,
 		ResponseTimeout: jp.ResponseTimeout.Duration,
 		ClientConfig:    jp.ClientConfig,
+		HTTPProxy:       jp.ProxyConfig,
 		ProxyConfig:     proxyConfig,
 	})
 }
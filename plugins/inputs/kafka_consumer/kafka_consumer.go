@@ -3,7 +3,6 @@ package kafka_consumer
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 
@@ -22,6 +21,11 @@ const (
 type empty struct{}
 type semaphore chan empty
 
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
 type Consumer interface {
 	Errors() <-chan error
 	Messages() <-chan *sarama.ConsumerMessage
@@ -50,8 +54,16 @@ type Kafka struct {
 	cancel  context.CancelFunc
 
 	// Unconfirmed messages
+	Log telegraf.Logger `toml:"-"`
+
 	messages map[telegraf.TrackingID]*sarama.ConsumerMessage
 
+	// Messages not committed yet of each partition in the order they were
+	// received, and the messages done with but waiting for the previous
+	// messages of their partition.
+	pending map[topicPartition][]*sarama.ConsumerMessage
+	done    map[*sarama.ConsumerMessage]bool
+
 	// doNotCommitMsgs tells the parser not to call CommitUpTo on the consumer
 	// this is mostly for test purposes, but there may be a use-case for it later.
 	doNotCommitMsgs bool
@@ -149,12 +161,12 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 	}
 
 	if tlsConfig != nil {
-		log.Printf("D! TLS Enabled")
+		k.Log.Debugf("TLS Enabled")
 		config.Net.TLS.Config = tlsConfig
 		config.Net.TLS.Enable = true
 	}
 	if k.SASLUsername != "" && k.SASLPassword != "" {
-		log.Printf("D! Using SASL auth with username '%s',",
+		k.Log.Debugf("Using SASL auth with username '%s',",
 			k.SASLUsername)
 		config.Net.SASL.User = k.SASLUsername
 		config.Net.SASL.Password = k.SASLPassword
@@ -167,7 +179,7 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 	case "newest":
 		config.Consumer.Offsets.Initial = sarama.OffsetNewest
 	default:
-		log.Printf("I! WARNING: Kafka consumer invalid offset '%s', using 'oldest'",
+		k.Log.Warnf("Invalid offset '%s', using 'oldest'",
 			k.Offset)
 		config.Consumer.Offsets.Initial = sarama.OffsetOldest
 	}
@@ -181,7 +193,7 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 		)
 
 		if clusterErr != nil {
-			log.Printf("E! Error when creating Kafka Consumer, brokers: %v, topics: %v",
+			k.Log.Errorf("Error when creating Kafka Consumer, brokers: %v, topics: %v",
 				k.Brokers, k.Topics)
 			return clusterErr
 		}
@@ -198,7 +210,7 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 		k.receiver(ctx, acc)
 	}()
 
-	log.Printf("I! Started the kafka consumer service, brokers: %v, topics: %v",
+	k.Log.Infof("Started the kafka consumer service, brokers: %v, topics: %v",
 		k.Brokers, k.Topics)
 	return nil
 }
@@ -207,6 +219,8 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 // influxdb metric points.
 func (k *Kafka) receiver(ctx context.Context, ac telegraf.Accumulator) {
 	k.messages = make(map[telegraf.TrackingID]*sarama.ConsumerMessage)
+	k.pending = make(map[topicPartition][]*sarama.ConsumerMessage)
+	k.done = make(map[*sarama.ConsumerMessage]bool)
 
 	acc := ac.WithTracking(k.MaxUndeliveredMessages)
 	sem := make(semaphore, k.MaxUndeliveredMessages)
@@ -249,15 +263,44 @@ func (k *Kafka) markOffset(msg *sarama.ConsumerMessage) {
 	}
 }
 
+// release marks the message as done with, the offset of a partition is only
+// advanced over the messages done with so that a message delivered before
+// the previous messages of its partition does not commit them.
+func (k *Kafka) release(msg *sarama.ConsumerMessage) {
+	tp := topicPartition{msg.Topic, msg.Partition}
+	k.done[msg] = true
+
+	var last *sarama.ConsumerMessage
+	pending := k.pending[tp]
+	for len(pending) > 0 && k.done[pending[0]] {
+		last = pending[0]
+		delete(k.done, last)
+		pending = pending[1:]
+	}
+
+	if len(pending) == 0 {
+		delete(k.pending, tp)
+	} else {
+		k.pending[tp] = pending
+	}
+	if last != nil {
+		k.markOffset(last)
+	}
+}
+
 func (k *Kafka) onMessage(acc telegraf.TrackingAccumulator, msg *sarama.ConsumerMessage) error {
+	tp := topicPartition{msg.Topic, msg.Partition}
+	k.pending[tp] = append(k.pending[tp], msg)
+
 	if k.MaxMessageLen != 0 && len(msg.Value) > k.MaxMessageLen {
-		k.markOffset(msg)
+		k.release(msg)
 		return fmt.Errorf("Message longer than max_message_len (%d > %d)",
 			len(msg.Value), k.MaxMessageLen)
 	}
 
 	metrics, err := k.parser.Parse(msg.Value)
 	if err != nil {
+		k.release(msg)
 		return err
 	}
 	if len(k.TopicTag) > 0 {
@@ -274,13 +317,17 @@ func (k *Kafka) onMessage(acc telegraf.TrackingAccumulator, msg *sarama.Consumer
 func (k *Kafka) onDelivery(track telegraf.DeliveryInfo) {
 	msg, ok := k.messages[track.ID()]
 	if !ok {
-		log.Printf("E! [inputs.kafka_consumer] Could not mark message delivered: %d", track.ID())
+		k.Log.Errorf("Could not mark message delivered: %d", track.ID())
 		return
 	}
 
-	if track.Delivered() {
-		k.markOffset(msg)
+	// Messages not delivered are dropped by the outputs and are never
+	// retried, they must not stop the offset from advancing.
+	if !track.Delivered() {
+		k.Log.Debugf("Message was not delivered: topic %s, partition %d, offset %d",
+			msg.Topic, msg.Partition, msg.Offset)
 	}
+	k.release(msg)
 	delete(k.messages, track.ID())
 }
 
@@ -289,7 +336,7 @@ func (k *Kafka) Stop() {
 	k.wg.Wait()
 
 	if err := k.cluster.Close(); err != nil {
-		log.Printf("E! [inputs.kafka_consumer] Error closing consumer: %v", err)
+		k.Log.Errorf("Error closing consumer: %v", err)
 	}
 }
 
@@ -22,6 +22,7 @@ const (
 type TestConsumer struct {
 	errors   chan error
 	messages chan *sarama.ConsumerMessage
+	marked   []int64
 }
 
 func (c *TestConsumer) Errors() <-chan error {
@@ -33,6 +34,7 @@ func (c *TestConsumer) Messages() <-chan *sarama.ConsumerMessage {
 }
 
 func (c *TestConsumer) MarkOffset(msg *sarama.ConsumerMessage, metadata string) {
+	c.marked = append(c.marked, msg.Offset)
 }
 
 func (c *TestConsumer) Close() error {
@@ -49,6 +51,7 @@ func newTestKafka() (*Kafka, *TestConsumer) {
 		messages: make(chan *sarama.ConsumerMessage, 1000),
 	}
 	k := Kafka{
+		Log:                    testutil.Logger{},
 		cluster:                consumer,
 		ConsumerGroup:          "test",
 		Topics:                 []string{"telegraf"},
@@ -67,6 +70,7 @@ func newTestKafkaWithTopicTag() (*Kafka, *TestConsumer) {
 		messages: make(chan *sarama.ConsumerMessage, 1000),
 	}
 	k := Kafka{
+		Log:                    testutil.Logger{},
 		cluster:                consumer,
 		ConsumerGroup:          "test",
 		Topics:                 []string{"telegraf"},
@@ -200,6 +204,88 @@ func TestRunParserAndGatherJSON(t *testing.T) {
 		})
 }
 
+type trackingAccumulator struct {
+	testutil.Accumulator
+	id telegraf.TrackingID
+}
+
+func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	a.AddMetrics(group)
+	a.id++
+	return a.id
+}
+
+type deliveryInfo struct {
+	id        telegraf.TrackingID
+	delivered bool
+}
+
+func (d *deliveryInfo) ID() telegraf.TrackingID {
+	return d.id
+}
+
+func (d *deliveryInfo) Delivered() bool {
+	return d.delivered
+}
+
+// Test that offsets are only committed once the previous messages of the
+// partition are delivered
+func TestCommitInOrder(t *testing.T) {
+	k, consumer := newTestKafka()
+	k.doNotCommitMsgs = false
+	k.pending = make(map[topicPartition][]*sarama.ConsumerMessage)
+	k.done = make(map[*sarama.ConsumerMessage]bool)
+	k.parser, _ = parsers.NewInfluxParser()
+	acc := &trackingAccumulator{}
+
+	for offset := int64(0); offset < 3; offset++ {
+		msg := saramaMsg(testMsg)
+		msg.Offset = offset
+		assert.NoError(t, k.onMessage(acc, msg))
+	}
+	other := saramaMsg(testMsg)
+	other.Partition = 1
+	other.Offset = 42
+	assert.NoError(t, k.onMessage(acc, other))
+
+	// Message 2 is delivered before messages 0 and 1
+	k.onDelivery(&deliveryInfo{id: 3, delivered: true})
+	assert.Empty(t, consumer.marked)
+
+	// Partitions are committed independently
+	k.onDelivery(&deliveryInfo{id: 4, delivered: true})
+	assert.Equal(t, []int64{42}, consumer.marked)
+
+	k.onDelivery(&deliveryInfo{id: 1, delivered: true})
+	assert.Equal(t, []int64{42, 0}, consumer.marked)
+
+	// Undelivered messages do not stop the offset from advancing
+	k.onDelivery(&deliveryInfo{id: 2, delivered: false})
+	assert.Equal(t, []int64{42, 0, 2}, consumer.marked)
+	assert.Empty(t, k.pending)
+	assert.Empty(t, k.done)
+	assert.Empty(t, k.messages)
+}
+
+// Test that invalid messages are committed with the previous messages
+func TestCommitInvalidMsg(t *testing.T) {
+	k, consumer := newTestKafka()
+	k.doNotCommitMsgs = false
+	k.pending = make(map[topicPartition][]*sarama.ConsumerMessage)
+	k.done = make(map[*sarama.ConsumerMessage]bool)
+	k.parser, _ = parsers.NewInfluxParser()
+	acc := &trackingAccumulator{}
+
+	assert.NoError(t, k.onMessage(acc, saramaMsg(testMsg)))
+	msg := saramaMsg(invalidMsg)
+	msg.Offset = 1
+	assert.Error(t, k.onMessage(acc, msg))
+	assert.Empty(t, consumer.marked)
+
+	k.onDelivery(&deliveryInfo{id: 1, delivered: true})
+	assert.Equal(t, []int64{1}, consumer.marked)
+}
+
 func saramaMsg(val string) *sarama.ConsumerMessage {
 	return &sarama.ConsumerMessage{
 		Key:       nil,
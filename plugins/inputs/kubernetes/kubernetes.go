@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -27,6 +28,7 @@ type Kubernetes struct {
 	ResponseTimeout internal.Duration
 
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	RoundTripper http.RoundTripper
 }
@@ -49,6 +51,12 @@ var sampleConfig = `
   # tls_key = /path/to/keyfile
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 const (
@@ -96,6 +104,11 @@ func (k *Kubernetes) gatherSummary(baseURL string, acc telegraf.Accumulator) err
 		return err
 	}
 
+	proxy, err := k.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+
 	if k.RoundTripper == nil {
 		// Set default values
 		if k.ResponseTimeout.Duration < time.Second {
@@ -104,6 +117,7 @@ func (k *Kubernetes) gatherSummary(baseURL string, acc telegraf.Accumulator) err
 		k.RoundTripper = &http.Transport{
 			TLSHandshakeTimeout:   5 * time.Second,
 			TLSClientConfig:       tlsCfg,
+			Proxy:                 proxy,
 			ResponseHeaderTimeout: k.ResponseTimeout.Duration,
 		}
 	}
@@ -2,9 +2,11 @@ package mailchimp
 
 import (
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -14,6 +16,7 @@ type MailChimp struct {
 	ApiKey     string
 	DaysOld    int
 	CampaignId string
+	httpconfig.ProxyConfig
 }
 
 var sampleConfig = `
@@ -25,6 +28,12 @@ var sampleConfig = `
   days_old = 0
   ## Campaign ID to get, if empty gets all campaigns, this option overrides days_old
   # campaign_id = ""
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 func (m *MailChimp) SampleConfig() string {
@@ -37,7 +46,15 @@ func (m *MailChimp) Description() string {
 
 func (m *MailChimp) Gather(acc telegraf.Accumulator) error {
 	if m.api == nil {
+		proxy, err := m.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
 		m.api = NewChimpAPI(m.ApiKey)
+		m.api.Transport = &http.Transport{
+			Proxy: proxy,
+		}
 	}
 	m.api.Debug = false
 
@@ -112,6 +129,8 @@ func gatherReport(acc telegraf.Accumulator, report Report, now time.Time) {
 
 func init() {
 	inputs.Add("mailchimp", func() telegraf.Input {
-		return &MailChimp{}
+		return &MailChimp{
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
+		}
 	})
 }
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	jsonparser "github.com/influxdata/telegraf/plugins/parsers/json"
@@ -34,6 +35,7 @@ type Mesos struct {
 	SlaveCols  []string `toml:"slave_collections"`
 	//SlaveTasks bool
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	initialized bool
 	client      *http.Client
@@ -81,6 +83,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 // SampleConfig returns a sample configuration block
@@ -213,10 +221,15 @@ func (m *Mesos) createHttpClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := m.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
 			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
 		},
 		Timeout: 4 * time.Second,
 	}
@@ -600,6 +613,8 @@ func (m *Mesos) gatherMainMetrics(u *url.URL, role Role, acc telegraf.Accumulato
 
 func init() {
 	inputs.Add("mesos", func() telegraf.Input {
-		return &Mesos{}
+		return &Mesos{
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
+		}
 	})
 }
@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/eclipse/paho.mqtt.golang"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/reconnect"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -50,16 +51,23 @@ type MQTTConsumer struct {
 	PersistentSession bool
 	ClientID          string `toml:"client_id"`
 	tls.ClientConfig
+	reconnect.Policy
+
+	Log telegraf.Logger `toml:"-"`
 
 	client     mqtt.Client
 	acc        telegraf.TrackingAccumulator
-	state      ConnectionState
 	subscribed bool
 	sem        semaphore
 	messages   map[telegraf.TrackingID]bool
 
+	// mu protects the connection state and the start of reconnects, which
+	// are started by the connection lost callback of the client.
+	mu     sync.Mutex
+	state  ConnectionState
 	ctx    context.Context
 	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 var sampleConfig = `
@@ -114,6 +122,14 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Reconnect policy when the connection fails: the delay before the first
+  ## attempt doubles after each failure up to the max delay, randomized by
+  ## the jitter fraction.  Gives up after max attempts, 0 retries forever.
+  # reconnect_initial_delay = "1s"
+  # reconnect_max_delay = "1m"
+  # reconnect_jitter = 0.2
+  # reconnect_max_attempts = 0
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -134,7 +150,7 @@ func (m *MQTTConsumer) SetParser(parser parsers.Parser) {
 }
 
 func (m *MQTTConsumer) Start(acc telegraf.Accumulator) error {
-	m.state = Disconnected
+	m.setState(Disconnected)
 
 	if m.PersistentSession && m.ClientID == "" {
 		return errors.New("persistent_session requires client_id")
@@ -157,21 +173,54 @@ func (m *MQTTConsumer) Start(acc telegraf.Accumulator) error {
 	}
 
 	m.client = mqtt.NewClient(opts)
-	m.state = Connecting
-	m.connect()
+	m.setState(Connecting)
+	if err := m.connect(); err != nil {
+		m.Log.Errorf("Connecting %v failed: %v", m.Servers, err)
+		m.startReconnect()
+	}
 
 	return nil
 }
 
+// startReconnect connects again in the background, unless the plugin is
+// stopping.
+func (m *MQTTConsumer) startReconnect() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx.Err() != nil {
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.reconnect()
+	}()
+}
+
+// reconnect connects until it succeeds, the plugin is stopped or the
+// reconnect policy gives up.
+func (m *MQTTConsumer) reconnect() {
+	err := reconnect.Retry(m.ctx, m.Backoff(), func() error {
+		m.setState(Connecting)
+		m.Log.Debugf("Connecting %v", m.Servers)
+		return m.connect()
+	}, func(err error) {
+		m.Log.Errorf("Connecting %v failed: %v", m.Servers, err)
+	})
+	if err == reconnect.ErrMaxAttempts {
+		m.acc.AddError(fmt.Errorf("giving up connecting to %v: %v", m.Servers, err))
+	}
+}
+
 func (m *MQTTConsumer) connect() error {
 	if token := m.client.Connect(); token.Wait() && token.Error() != nil {
 		err := token.Error()
-		m.state = Disconnected
+		m.setState(Disconnected)
 		return err
 	}
 
-	log.Printf("I! [inputs.mqtt_consumer] Connected %v", m.Servers)
-	m.state = Connected
+	m.Log.Infof("Connected %v", m.Servers)
+	m.setState(Connected)
 	m.sem = make(semaphore, m.MaxUndeliveredMessages)
 	m.messages = make(map[telegraf.TrackingID]bool)
 
@@ -198,9 +247,21 @@ func (m *MQTTConsumer) connect() error {
 
 func (m *MQTTConsumer) onConnectionLost(c mqtt.Client, err error) {
 	m.acc.AddError(fmt.Errorf("connection lost: %v", err))
-	log.Printf("D! [inputs.mqtt_consumer] Disconnected %v", m.Servers)
-	m.state = Disconnected
-	return
+	m.Log.Debugf("Disconnected %v", m.Servers)
+	m.setState(Disconnected)
+	m.startReconnect()
+}
+
+func (m *MQTTConsumer) setState(state ConnectionState) {
+	m.mu.Lock()
+	m.state = state
+	m.mu.Unlock()
+}
+
+func (m *MQTTConsumer) getState() ConnectionState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
 }
 
 func (m *MQTTConsumer) recvMessage(c mqtt.Client, msg mqtt.Message) {
@@ -243,22 +304,21 @@ func (m *MQTTConsumer) onMessage(acc telegraf.TrackingAccumulator, msg mqtt.Mess
 }
 
 func (m *MQTTConsumer) Stop() {
-	if m.state == Connected {
-		log.Printf("D! [inputs.mqtt_consumer] Disconnecting %v", m.Servers)
+	// No reconnect is started once cancelled, so none is added while waiting.
+	m.mu.Lock()
+	m.cancel()
+	m.mu.Unlock()
+	m.wg.Wait()
+
+	if m.getState() == Connected {
+		m.Log.Debugf("Disconnecting %v", m.Servers)
 		m.client.Disconnect(200)
-		log.Printf("D! [inputs.mqtt_consumer] Disconnected %v", m.Servers)
-		m.state = Disconnected
+		m.Log.Debugf("Disconnected %v", m.Servers)
+		m.setState(Disconnected)
 	}
-	m.cancel()
 }
 
 func (m *MQTTConsumer) Gather(acc telegraf.Accumulator) error {
-	if m.state == Disconnected {
-		m.state = Connecting
-		log.Printf("D! [inputs.mqtt_consumer] Connecting %v", m.Servers)
-		m.connect()
-	}
-
 	return nil
 }
 
@@ -298,7 +358,7 @@ func (m *MQTTConsumer) createOpts() (*mqtt.ClientOptions, error) {
 	for _, server := range m.Servers {
 		// Preserve support for host:port style servers; deprecated in Telegraf 1.4.4
 		if !strings.Contains(server, "://") {
-			log.Printf("W! [inputs.mqtt_consumer] Server %q should be updated to use `scheme://host:port` format", server)
+			m.Log.Warnf("Server %q should be updated to use `scheme://host:port` format", server)
 			if tlsCfg == nil {
 				server = "tcp://" + server
 			} else {
@@ -321,6 +381,7 @@ func init() {
 		return &MQTTConsumer{
 			ConnectionTimeout:      defaultConnectionTimeout,
 			MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
+			Policy:                 reconnect.DefaultPolicy(),
 			state:                  Disconnected,
 		}
 	})
@@ -15,6 +15,7 @@ const (
 
 func newTestMQTTConsumer() *MQTTConsumer {
 	n := &MQTTConsumer{
+		Log:     testutil.Logger{},
 		Topics:  []string{"telegraf"},
 		Servers: []string{"localhost:1883"},
 	}
@@ -25,11 +26,13 @@ func newTestMQTTConsumer() *MQTTConsumer {
 // Test that default client has random ID
 func TestRandomClientID(t *testing.T) {
 	m1 := &MQTTConsumer{
+		Log:     testutil.Logger{},
 		Servers: []string{"localhost:1883"}}
 	opts, err := m1.createOpts()
 	assert.NoError(t, err)
 
 	m2 := &MQTTConsumer{
+		Log:     testutil.Logger{},
 		Servers: []string{"localhost:1883"}}
 	opts2, err2 := m2.createOpts()
 	assert.NoError(t, err2)
@@ -40,6 +43,7 @@ func TestRandomClientID(t *testing.T) {
 // Test that default client has random ID
 func TestClientID(t *testing.T) {
 	m1 := &MQTTConsumer{
+		Log:      testutil.Logger{},
 		Servers:  []string{"localhost:1883"},
 		ClientID: "telegraf-test",
 	}
@@ -47,6 +51,7 @@ func TestClientID(t *testing.T) {
 	assert.NoError(t, err)
 
 	m2 := &MQTTConsumer{
+		Log:      testutil.Logger{},
 		Servers:  []string{"localhost:1883"},
 		ClientID: "telegraf-test",
 	}
@@ -60,6 +65,7 @@ func TestClientID(t *testing.T) {
 // Test that Start() fails if client ID is not set but persistent is
 func TestPersistentClientIDFail(t *testing.T) {
 	m1 := &MQTTConsumer{
+		Log:               testutil.Logger{},
 		Servers:           []string{"localhost:1883"},
 		PersistentSession: true,
 	}
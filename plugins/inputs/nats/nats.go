@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 
 	gnatsd "github.com/nats-io/gnatsd/server"
@@ -21,6 +22,7 @@ import (
 type Nats struct {
 	Server          string
 	ResponseTimeout internal.Duration
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -31,6 +33,12 @@ var sampleConfig = `
 
   ## Maximum time to receive response
   # response_timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 func (n *Nats) SampleConfig() string {
@@ -49,7 +57,10 @@ func (n *Nats) Gather(acc telegraf.Accumulator) error {
 	url.Path = path.Join(url.Path, "varz")
 
 	if n.client == nil {
-		n.client = n.createHTTPClient()
+		n.client, err = n.createHTTPClient()
+		if err != nil {
+			return err
+		}
 	}
 	resp, err := n.client.Get(url.String())
 	if err != nil {
@@ -91,9 +102,14 @@ func (n *Nats) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (n *Nats) createHTTPClient() *http.Client {
+func (n *Nats) createHTTPClient() (*http.Client, error) {
+	proxy, err := n.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: proxy,
 	}
 	timeout := n.ResponseTimeout.Duration
 	if timeout == time.Duration(0) {
@@ -102,13 +118,14 @@ func (n *Nats) createHTTPClient() *http.Client {
 	return &http.Client{
 		Transport: transport,
 		Timeout:   timeout,
-	}
+	}, nil
 }
 
 func init() {
 	inputs.Add("nats", func() telegraf.Input {
 		return &Nats{
-			Server: "http://localhost:8222",
+			Server:      "http://localhost:8222",
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
 		}
 	})
 }
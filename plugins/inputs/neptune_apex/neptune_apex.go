@@ -15,6 +15,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -52,7 +53,9 @@ type outlet struct {
 type NeptuneApex struct {
 	Servers         []string
 	ResponseTimeout internal.Duration
-	httpClient      *http.Client
+	httpconfig.ProxyConfig
+
+	httpClient *http.Client
 }
 
 // Description implements telegraf.Input.Description
@@ -74,11 +77,31 @@ func (*NeptuneApex) SampleConfig() string {
 
   ## The response_timeout specifies how long to wait for a reply from the Apex.
   #response_timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 }
 
 // Gather implements telegraf.Input.Gather
 func (n *NeptuneApex) Gather(acc telegraf.Accumulator) error {
+	if n.httpClient == nil {
+		proxy, err := n.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
+		n.httpClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy: proxy,
+			},
+			Timeout: 5 * time.Second,
+		}
+	}
+
 	var wg sync.WaitGroup
 	for _, server := range n.Servers {
 		wg.Add(1)
@@ -292,9 +315,7 @@ func (n *NeptuneApex) sendRequest(server string) ([]byte, error) {
 func init() {
 	inputs.Add("neptune_apex", func() telegraf.Input {
 		return &NeptuneApex{
-			httpClient: &http.Client{
-				Timeout: 5 * time.Second,
-			},
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
 		}
 	})
 }
@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -21,6 +22,7 @@ type Nginx struct {
 	Urls            []string
 	ResponseTimeout internal.Duration
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	// HTTP client
 	client *http.Client
@@ -37,6 +39,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   # HTTP response timeout (default: 5s)
   response_timeout = "5s"
 `
@@ -86,6 +94,11 @@ func (n *Nginx) createHttpClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := n.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	if n.ResponseTimeout.Duration < time.Second {
 		n.ResponseTimeout.Duration = time.Second * 5
 	}
@@ -93,6 +106,7 @@ func (n *Nginx) createHttpClient() (*http.Client, error) {
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
 		},
 		Timeout: n.ResponseTimeout.Duration,
 	}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -23,6 +24,7 @@ type NginxPlus struct {
 	client *http.Client
 
 	ResponseTimeout internal.Duration
+	httpconfig.ProxyConfig
 }
 
 var sampleConfig = `
@@ -31,6 +33,12 @@ var sampleConfig = `
 
   # HTTP response timeout (default: 5s)
   response_timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 func (n *NginxPlus) SampleConfig() string {
@@ -79,9 +87,16 @@ func (n *NginxPlus) createHttpClient() (*http.Client, error) {
 		n.ResponseTimeout.Duration = time.Second * 5
 	}
 
+	proxy, err := n.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
-		Transport: &http.Transport{},
-		Timeout:   n.ResponseTimeout.Duration,
+		Transport: &http.Transport{
+			Proxy: proxy,
+		},
+		Timeout: n.ResponseTimeout.Duration,
 	}
 
 	return client, nil
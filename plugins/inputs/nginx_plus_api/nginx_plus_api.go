@@ -9,6 +9,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -20,6 +21,7 @@ type NginxPlusApi struct {
 	client *http.Client
 
 	ResponseTimeout internal.Duration
+	httpconfig.ProxyConfig
 }
 
 const (
@@ -49,6 +51,12 @@ var sampleConfig = `
 
   # HTTP response timeout (default: 5s)
   response_timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 func (n *NginxPlusApi) SampleConfig() string {
@@ -100,9 +108,16 @@ func (n *NginxPlusApi) createHttpClient() (*http.Client, error) {
 		n.ResponseTimeout.Duration = time.Second * 5
 	}
 
+	proxy, err := n.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
-		Transport: &http.Transport{},
-		Timeout:   n.ResponseTimeout.Duration,
+		Transport: &http.Transport{
+			Proxy: proxy,
+		},
+		Timeout: n.ResponseTimeout.Duration,
 	}
 
 	return client, nil
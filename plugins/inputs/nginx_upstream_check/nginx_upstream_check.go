@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"net/http"
@@ -39,6 +40,12 @@ const sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 const description = "Read nginx_upstream_check module status information (https://github.com/yaoweibin/nginx_upstream_check_module)"
@@ -54,6 +61,7 @@ type NginxUpstreamCheck struct {
 	Timeout    internal.Duration `toml:"timeout"`
 
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 	client *http.Client
 }
 
@@ -107,9 +115,15 @@ func (check *NginxUpstreamCheck) createHttpClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := check.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsConfig,
+			Proxy:           proxy,
 		},
 		Timeout: check.Timeout.Duration,
 	}
@@ -33,6 +33,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -41,6 +42,7 @@ import (
 type NSQ struct {
 	Endpoints []string
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 	httpClient *http.Client
 }
 
@@ -54,6 +56,12 @@ var sampleConfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 const (
@@ -106,8 +114,15 @@ func (n *NSQ) getHttpClient() (*http.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	proxy, err := n.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	tr := &http.Transport{
 		TLSClientConfig: tlsConfig,
+		Proxy:           proxy,
 	}
 	httpClient := &http.Client{
 		Transport: tr,
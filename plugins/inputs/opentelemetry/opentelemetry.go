@@ -0,0 +1,370 @@
+package opentelemetry
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/inputs/opentelemetry/otlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// defaultMaxMessageSize is the default maximum size of a request, in bytes.
+const defaultMaxMessageSize = 4 * 1024 * 1024
+
+const sampleConfig = `
+  ## Address and port to host the OTLP/gRPC receiver on, an empty address
+  ## disables the receiver.
+  # service_address_grpc = ":4317"
+
+  ## Address and port to host the OTLP/HTTP receiver on, an empty address
+  ## disables the receiver.  Requests are accepted on the /v1/metrics and
+  ## /v1/logs paths in the protobuf or JSON encoding.
+  # service_address_http = ":4318"
+
+  ## Maximum allowed size of a request in bytes.
+  # max_message_size = "4MB"
+
+  ## Maximum duration before timing out read of the request and write of the
+  ## response of the HTTP receiver.
+  # read_timeout = "10s"
+  # write_timeout = "10s"
+
+  ## Accept logs, each log record is added as a metric with the body and the
+  ## attributes of the record as string fields.
+  # logs = false
+
+  ## Name of the measurement of the log records.
+  # logs_measurement = "opentelemetry_logs"
+
+  ## Set one or more allowed client CA certificate file names to
+  ## enable mutually authenticated TLS connections
+  # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+
+  ## Add service certificate and key
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+`
+
+type OpenTelemetry struct {
+	ServiceAddressGRPC string            `toml:"service_address_grpc"`
+	ServiceAddressHTTP string            `toml:"service_address_http"`
+	MaxMessageSize     internal.Size     `toml:"max_message_size"`
+	ReadTimeout        internal.Duration `toml:"read_timeout"`
+	WriteTimeout       internal.Duration `toml:"write_timeout"`
+	Logs               bool              `toml:"logs"`
+	LogsMeasurement    string            `toml:"logs_measurement"`
+	tlsint.ServerConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	acc        telegraf.Accumulator
+	grpcServer *grpc.Server
+	httpServer *http.Server
+	grpcAddr   net.Addr
+	httpAddr   net.Addr
+	wg         sync.WaitGroup
+}
+
+func (o *OpenTelemetry) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenTelemetry) Description() string {
+	return "Receive metrics and logs from OpenTelemetry SDKs over OTLP/gRPC and OTLP/HTTP"
+}
+
+func (o *OpenTelemetry) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
+	if o.ServiceAddressGRPC == "" && o.ServiceAddressHTTP == "" {
+		return fmt.Errorf("service_address_grpc or service_address_http is required")
+	}
+	if o.MaxMessageSize.Size == 0 {
+		o.MaxMessageSize.Size = defaultMaxMessageSize
+	}
+	if o.ReadTimeout.Duration < time.Second {
+		o.ReadTimeout.Duration = 10 * time.Second
+	}
+	if o.WriteTimeout.Duration < time.Second {
+		o.WriteTimeout.Duration = 10 * time.Second
+	}
+	if o.LogsMeasurement == "" {
+		o.LogsMeasurement = "opentelemetry_logs"
+	}
+
+	o.acc = acc
+
+	tlsConf, err := o.ServerConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	if o.ServiceAddressGRPC != "" {
+		listener, err := net.Listen("tcp", o.ServiceAddressGRPC)
+		if err != nil {
+			return err
+		}
+		o.grpcAddr = listener.Addr()
+
+		opts := []grpc.ServerOption{grpc.MaxRecvMsgSize(int(o.MaxMessageSize.Size))}
+		if tlsConf != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+		}
+		o.grpcServer = grpc.NewServer(opts...)
+		o.grpcServer.RegisterService(&metricsServiceDesc, o)
+		if o.Logs {
+			o.grpcServer.RegisterService(&logsServiceDesc, o)
+		}
+
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			if err := o.grpcServer.Serve(listener); err != nil {
+				o.Log.Errorf("Error serving OTLP/gRPC: %v", err)
+			}
+		}()
+		o.Log.Infof("Started OTLP/gRPC receiver on %s", o.grpcAddr)
+	}
+
+	if o.ServiceAddressHTTP != "" {
+		listener, err := net.Listen("tcp", o.ServiceAddressHTTP)
+		if err != nil {
+			o.stopGRPC()
+			return err
+		}
+		o.httpAddr = listener.Addr()
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/v1/metrics", o.serveMetrics)
+		if o.Logs {
+			mux.HandleFunc("/v1/logs", o.serveLogs)
+		}
+		o.httpServer = &http.Server{
+			Handler:      mux,
+			ReadTimeout:  o.ReadTimeout.Duration,
+			WriteTimeout: o.WriteTimeout.Duration,
+			TLSConfig:    tlsConf,
+		}
+
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			var err error
+			if tlsConf != nil {
+				err = o.httpServer.ServeTLS(listener, "", "")
+			} else {
+				err = o.httpServer.Serve(listener)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				o.Log.Errorf("Error serving OTLP/HTTP: %v", err)
+			}
+		}()
+		o.Log.Infof("Started OTLP/HTTP receiver on %s", o.httpAddr)
+	}
+
+	return nil
+}
+
+func (o *OpenTelemetry) Stop() {
+	o.stopGRPC()
+	if o.httpServer != nil {
+		o.httpServer.Close()
+	}
+	o.wg.Wait()
+}
+
+func (o *OpenTelemetry) stopGRPC() {
+	if o.grpcServer != nil {
+		o.grpcServer.Stop()
+	}
+}
+
+// ExportMetrics implements the OTLP metrics service.
+func (o *OpenTelemetry) ExportMetrics(_ context.Context, req *otlp.ExportMetricsServiceRequest) (*otlp.ExportMetricsServiceResponse, error) {
+	o.addMetrics(req)
+	return &otlp.ExportMetricsServiceResponse{}, nil
+}
+
+// ExportLogs implements the OTLP logs service.
+func (o *OpenTelemetry) ExportLogs(_ context.Context, req *otlp.ExportLogsServiceRequest) (*otlp.ExportLogsServiceResponse, error) {
+	o.addLogs(req)
+	return &otlp.ExportLogsServiceResponse{}, nil
+}
+
+func (o *OpenTelemetry) addMetrics(req *otlp.ExportMetricsServiceRequest) {
+	for _, rm := range req.ResourceMetrics {
+		resourceTags := attributeTags(nil, rm.Resource.GetAttributes())
+		for _, sm := range rm.ScopeMetrics {
+			scopeTags := scopeTags(resourceTags, sm.Scope)
+			for _, m := range sm.Metrics {
+				o.addMetric(m, scopeTags)
+			}
+		}
+	}
+}
+
+func (o *OpenTelemetry) addMetric(m *otlp.Metric, tags map[string]string) {
+	switch data := m.Data.(type) {
+	case *otlp.Metric_Gauge:
+		for _, dp := range data.Gauge.GetDataPoints() {
+			if v, ok := numberValue(dp); ok {
+				o.acc.AddGauge(m.Name, map[string]interface{}{"gauge": v},
+					attributeTags(tags, dp.Attributes), timestamp(dp.TimeUnixNano))
+			}
+		}
+	case *otlp.Metric_Sum:
+		for _, dp := range data.Sum.GetDataPoints() {
+			v, ok := numberValue(dp)
+			if !ok {
+				continue
+			}
+			if data.Sum.IsMonotonic {
+				o.acc.AddCounter(m.Name, map[string]interface{}{"counter": v},
+					attributeTags(tags, dp.Attributes), timestamp(dp.TimeUnixNano))
+			} else {
+				o.acc.AddGauge(m.Name, map[string]interface{}{"gauge": v},
+					attributeTags(tags, dp.Attributes), timestamp(dp.TimeUnixNano))
+			}
+		}
+	case *otlp.Metric_Histogram:
+		for _, dp := range data.Histogram.GetDataPoints() {
+			fields := map[string]interface{}{"count": dp.Count}
+			if dp.Sum != nil {
+				fields["sum"] = *dp.Sum
+			}
+			if dp.Min != nil {
+				fields["min"] = *dp.Min
+			}
+			if dp.Max != nil {
+				fields["max"] = *dp.Max
+			}
+			// The buckets are cumulative like the Prometheus histograms, the
+			// last bucket is the overflow bucket counted by "count".
+			var cumulative uint64
+			for i, bound := range dp.ExplicitBounds {
+				if i < len(dp.BucketCounts) {
+					cumulative += dp.BucketCounts[i]
+				}
+				fields[strconv.FormatFloat(bound, 'g', -1, 64)] = cumulative
+			}
+			o.acc.AddHistogram(m.Name, fields,
+				attributeTags(tags, dp.Attributes), timestamp(dp.TimeUnixNano))
+		}
+	case *otlp.Metric_Summary:
+		for _, dp := range data.Summary.GetDataPoints() {
+			fields := map[string]interface{}{
+				"count": dp.Count,
+				"sum":   dp.Sum,
+			}
+			for _, q := range dp.QuantileValues {
+				fields[strconv.FormatFloat(q.Quantile, 'g', -1, 64)] = q.Value
+			}
+			o.acc.AddSummary(m.Name, fields,
+				attributeTags(tags, dp.Attributes), timestamp(dp.TimeUnixNano))
+		}
+	default:
+		o.Log.Debugf("Ignoring metric %q of unsupported type", m.Name)
+	}
+}
+
+func (o *OpenTelemetry) addLogs(req *otlp.ExportLogsServiceRequest) {
+	for _, rl := range req.ResourceLogs {
+		resourceTags := attributeTags(nil, rl.Resource.GetAttributes())
+		for _, sl := range rl.ScopeLogs {
+			tags := scopeTags(resourceTags, sl.Scope)
+			for _, lr := range sl.LogRecords {
+				fields := make(map[string]interface{}, len(lr.Attributes)+5)
+				for _, kv := range lr.Attributes {
+					fields[kv.Key] = formatValue(kv.Value)
+				}
+				if lr.Body != nil {
+					fields["body"] = formatValue(lr.Body)
+				}
+				if lr.SeverityText != "" {
+					fields["severity_text"] = lr.SeverityText
+				}
+				if lr.SeverityNumber != 0 {
+					fields["severity_number"] = int64(lr.SeverityNumber)
+				}
+				if len(lr.TraceId) > 0 {
+					fields["trace_id"] = hex.EncodeToString(lr.TraceId)
+				}
+				if len(lr.SpanId) > 0 {
+					fields["span_id"] = hex.EncodeToString(lr.SpanId)
+				}
+				if len(fields) == 0 {
+					continue
+				}
+
+				t := lr.TimeUnixNano
+				if t == 0 {
+					t = lr.ObservedTimeUnixNano
+				}
+				o.acc.AddFields(o.LogsMeasurement, fields, tags, timestamp(t))
+			}
+		}
+	}
+}
+
+func numberValue(dp *otlp.NumberDataPoint) (interface{}, bool) {
+	switch v := dp.Value.(type) {
+	case *otlp.NumberDataPoint_AsDouble:
+		return v.AsDouble, true
+	case *otlp.NumberDataPoint_AsInt:
+		return v.AsInt, true
+	}
+	return nil, false
+}
+
+// attributeTags returns a copy of the tags with the attributes added.
+func attributeTags(tags map[string]string, attributes []*otlp.KeyValue) map[string]string {
+	result := make(map[string]string, len(tags)+len(attributes))
+	for k, v := range tags {
+		result[k] = v
+	}
+	for _, kv := range attributes {
+		result[kv.Key] = formatValue(kv.Value)
+	}
+	return result
+}
+
+func scopeTags(tags map[string]string, scope *otlp.InstrumentationScope) map[string]string {
+	if scope.GetName() == "" {
+		return tags
+	}
+	result := attributeTags(tags, nil)
+	result["otel.scope.name"] = scope.Name
+	if scope.Version != "" {
+		result["otel.scope.version"] = scope.Version
+	}
+	return result
+}
+
+func timestamp(unixNano uint64) time.Time {
+	if unixNano == 0 {
+		return time.Now()
+	}
+	return time.Unix(0, int64(unixNano))
+}
+
+func init() {
+	inputs.Add("opentelemetry", func() telegraf.Input {
+		return &OpenTelemetry{
+			ServiceAddressGRPC: ":4317",
+			ServiceAddressHTTP: ":4318",
+		}
+	})
+}
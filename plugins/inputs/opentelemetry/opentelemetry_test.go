@@ -0,0 +1,340 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs/opentelemetry/otlp"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+var (
+	ts     = time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	tsNano = uint64(ts.UnixNano())
+)
+
+func stringAttribute(key, value string) *otlp.KeyValue {
+	return &otlp.KeyValue{
+		Key:   key,
+		Value: &otlp.AnyValue{Value: &otlp.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+// metricsRequest is an ExportMetricsServiceRequest with a metric of each
+// supported type.
+func metricsRequest() *otlp.ExportMetricsServiceRequest {
+	sum := 3.5
+	return &otlp.ExportMetricsServiceRequest{
+		ResourceMetrics: []*otlp.ResourceMetrics{{
+			Resource: &otlp.Resource{
+				Attributes: []*otlp.KeyValue{stringAttribute("service.name", "checkout")},
+			},
+			ScopeMetrics: []*otlp.ScopeMetrics{{
+				Scope: &otlp.InstrumentationScope{Name: "io.opentelemetry.runtime", Version: "1.0.0"},
+				Metrics: []*otlp.Metric{
+					{
+						Name: "memory.used",
+						Data: &otlp.Metric_Gauge{Gauge: &otlp.Gauge{
+							DataPoints: []*otlp.NumberDataPoint{{
+								Attributes:   []*otlp.KeyValue{stringAttribute("pool", "heap")},
+								TimeUnixNano: tsNano,
+								Value:        &otlp.NumberDataPoint_AsDouble{AsDouble: 42.5},
+							}},
+						}},
+					},
+					{
+						Name: "requests",
+						Data: &otlp.Metric_Sum{Sum: &otlp.Sum{
+							DataPoints: []*otlp.NumberDataPoint{{
+								TimeUnixNano: tsNano,
+								Value:        &otlp.NumberDataPoint_AsInt{AsInt: 12},
+							}},
+							AggregationTemporality: 2,
+							IsMonotonic:            true,
+						}},
+					},
+					{
+						Name: "latency",
+						Data: &otlp.Metric_Histogram{Histogram: &otlp.Histogram{
+							DataPoints: []*otlp.HistogramDataPoint{{
+								TimeUnixNano:   tsNano,
+								Count:          6,
+								Sum:            &sum,
+								BucketCounts:   []uint64{1, 2, 3},
+								ExplicitBounds: []float64{0.1, 1},
+							}},
+						}},
+					},
+					{
+						Name: "duration",
+						Data: &otlp.Metric_Summary{Summary: &otlp.Summary{
+							DataPoints: []*otlp.SummaryDataPoint{{
+								TimeUnixNano: tsNano,
+								Count:        4,
+								Sum:          2,
+								QuantileValues: []*otlp.SummaryDataPoint_ValueAtQuantile{
+									{Quantile: 0.5, Value: 0.25},
+								},
+							}},
+						}},
+					},
+				},
+			}},
+		}},
+	}
+}
+
+func marshal(t *testing.T, msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return b
+}
+
+func expectedMetrics() []telegraf.Metric {
+	tags := map[string]string{
+		"service.name":       "checkout",
+		"otel.scope.name":    "io.opentelemetry.runtime",
+		"otel.scope.version": "1.0.0",
+	}
+	withTags := func(extra map[string]string) map[string]string {
+		result := make(map[string]string)
+		for k, v := range tags {
+			result[k] = v
+		}
+		for k, v := range extra {
+			result[k] = v
+		}
+		return result
+	}
+	return []telegraf.Metric{
+		testutil.MustMetric("memory.used", withTags(map[string]string{"pool": "heap"}),
+			map[string]interface{}{"gauge": 42.5}, ts),
+		testutil.MustMetric("requests", withTags(nil),
+			map[string]interface{}{"counter": int64(12)}, ts),
+		testutil.MustMetric("latency", withTags(nil),
+			map[string]interface{}{
+				"count": uint64(6),
+				"sum":   3.5,
+				"0.1":   uint64(1),
+				"1":     uint64(3),
+			}, ts),
+		testutil.MustMetric("duration", withTags(nil),
+			map[string]interface{}{
+				"count": uint64(4),
+				"sum":   2.0,
+				"0.5":   0.25,
+			}, ts),
+	}
+}
+
+func newTestOpenTelemetry() *OpenTelemetry {
+	return &OpenTelemetry{
+		ServiceAddressGRPC: "localhost:0",
+		ServiceAddressHTTP: "localhost:0",
+		Log:                testutil.Logger{},
+	}
+}
+
+func post(t *testing.T, o *OpenTelemetry, path, contentType string, body []byte) *http.Response {
+	resp, err := http.Post(fmt.Sprintf("http://%s%s", o.httpAddr, path), contentType, bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestMetricsGRPC(t *testing.T) {
+	o := newTestOpenTelemetry()
+	acc := &testutil.Accumulator{}
+	require.NoError(t, o.Start(acc))
+	defer o.Stop()
+
+	conn, err := grpc.Dial(o.grpcAddr.String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = conn.Invoke(ctx, "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export",
+		metricsRequest(), &otlp.ExportMetricsServiceResponse{})
+	require.NoError(t, err)
+
+	testutil.RequireMetricsEqual(t, expectedMetrics(), acc.GetTelegrafMetrics())
+
+	// The logs service is not registered unless enabled.
+	err = conn.Invoke(ctx, "/opentelemetry.proto.collector.logs.v1.LogsService/Export",
+		&otlp.ExportLogsServiceRequest{}, &otlp.ExportLogsServiceResponse{})
+	require.Error(t, err)
+}
+
+func TestMetricsHTTPProtobuf(t *testing.T) {
+	o := newTestOpenTelemetry()
+	acc := &testutil.Accumulator{}
+	require.NoError(t, o.Start(acc))
+	defer o.Stop()
+
+	resp := post(t, o, "/v1/metrics", "application/x-protobuf", marshal(t, metricsRequest()))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/x-protobuf", resp.Header.Get("Content-Type"))
+	testutil.RequireMetricsEqual(t, expectedMetrics(), acc.GetTelegrafMetrics())
+
+	resp = post(t, o, "/v1/metrics", "application/x-protobuf", []byte{0x0a, 0xff})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = post(t, o, "/v1/metrics", "text/plain", marshal(t, metricsRequest()))
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	resp = post(t, o, "/v1/logs", "application/x-protobuf", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+const metricsJSON = `
+{
+  "resourceMetrics": [{
+    "resource": {
+      "attributes": [{"key": "service.name", "value": {"stringValue": "checkout"}}]
+    },
+    "scopeMetrics": [{
+      "scope": {"name": "io.opentelemetry.runtime", "version": "1.0.0"},
+      "metrics": [
+        {
+          "name": "memory.used",
+          "gauge": {"dataPoints": [{
+            "attributes": [{"key": "pool", "value": {"stringValue": "heap"}}],
+            "timeUnixNano": "1569931200000000000",
+            "asDouble": 42.5
+          }]}
+        },
+        {
+          "name": "requests",
+          "sum": {
+            "dataPoints": [{"timeUnixNano": "1569931200000000000", "asInt": "12"}],
+            "aggregationTemporality": 2,
+            "isMonotonic": true
+          }
+        },
+        {
+          "name": "latency",
+          "histogram": {"dataPoints": [{
+            "timeUnixNano": "1569931200000000000",
+            "count": "6",
+            "sum": 3.5,
+            "bucketCounts": ["1", "2", "3"],
+            "explicitBounds": [0.1, 1]
+          }]}
+        },
+        {
+          "name": "duration",
+          "summary": {"dataPoints": [{
+            "timeUnixNano": "1569931200000000000",
+            "count": "4",
+            "sum": 2,
+            "quantileValues": [{"quantile": 0.5, "value": 0.25}]
+          }]}
+        }
+      ]
+    }]
+  }]
+}`
+
+func TestMetricsHTTPJSON(t *testing.T) {
+	o := newTestOpenTelemetry()
+	acc := &testutil.Accumulator{}
+	require.NoError(t, o.Start(acc))
+	defer o.Stop()
+
+	resp := post(t, o, "/v1/metrics", "application/json", []byte(metricsJSON))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	testutil.RequireMetricsEqual(t, expectedMetrics(), acc.GetTelegrafMetrics())
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	o := newTestOpenTelemetry()
+	o.MaxMessageSize.Size = 64
+	acc := &testutil.Accumulator{}
+	require.NoError(t, o.Start(acc))
+	defer o.Stop()
+
+	resp := post(t, o, "/v1/metrics", "application/json", []byte(metricsJSON))
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestLogs(t *testing.T) {
+	o := newTestOpenTelemetry()
+	o.Logs = true
+	acc := &testutil.Accumulator{}
+	require.NoError(t, o.Start(acc))
+	defer o.Stop()
+
+	req := &otlp.ExportLogsServiceRequest{
+		ResourceLogs: []*otlp.ResourceLogs{{
+			Resource: &otlp.Resource{
+				Attributes: []*otlp.KeyValue{stringAttribute("service.name", "checkout")},
+			},
+			ScopeLogs: []*otlp.ScopeLogs{{
+				LogRecords: []*otlp.LogRecord{{
+					TimeUnixNano:   tsNano,
+					SeverityNumber: 17,
+					SeverityText:   "ERROR",
+					Body:           &otlp.AnyValue{Value: &otlp.AnyValue_StringValue{StringValue: "payment failed"}},
+					Attributes: []*otlp.KeyValue{{
+						Key:   "http.status_code",
+						Value: &otlp.AnyValue{Value: &otlp.AnyValue_IntValue{IntValue: 502}},
+					}},
+					TraceId: []byte{0x5b, 0x8e, 0xff, 0xf7},
+				}},
+			}},
+		}},
+	}
+
+	conn, err := grpc.Dial(o.grpcAddr.String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = conn.Invoke(ctx, "/opentelemetry.proto.collector.logs.v1.LogsService/Export",
+		req, &otlp.ExportLogsServiceResponse{})
+	require.NoError(t, err)
+
+	body := `{"resourceLogs": [{"scopeLogs": [{"logRecords": [{
+	  "observedTimeUnixNano": "1569931200000000000",
+	  "body": {"kvlistValue": {"values": [{"key": "user", "value": {"intValue": "7"}}]}},
+	  "spanId": "00f067aa0ba902b7"
+	}]}]}]}`
+	resp := post(t, o, "/v1/logs", "application/json; charset=utf-8", []byte(body))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("opentelemetry_logs",
+			map[string]string{"service.name": "checkout"},
+			map[string]interface{}{
+				"body":             "payment failed",
+				"severity_text":    "ERROR",
+				"severity_number":  int64(17),
+				"trace_id":         "5b8efff7",
+				"http.status_code": "502",
+			}, ts),
+		testutil.MustMetric("opentelemetry_logs",
+			map[string]string{},
+			map[string]interface{}{
+				"body":    `{"user":7}`,
+				"span_id": "00f067aa0ba902b7",
+			}, ts),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestStartRequiresAddress(t *testing.T) {
+	o := &OpenTelemetry{Log: testutil.Logger{}}
+	require.Error(t, o.Start(&testutil.Accumulator{}))
+}
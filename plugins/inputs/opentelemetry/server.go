@@ -0,0 +1,203 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf/plugins/inputs/opentelemetry/otlp"
+	"google.golang.org/grpc"
+)
+
+// metricsServer and logsServer are the OTLP collector services, the service
+// descriptions are written by hand in place of the generated code.
+type metricsServer interface {
+	ExportMetrics(context.Context, *otlp.ExportMetricsServiceRequest) (*otlp.ExportMetricsServiceResponse, error)
+}
+
+type logsServer interface {
+	ExportLogs(context.Context, *otlp.ExportLogsServiceRequest) (*otlp.ExportLogsServiceResponse, error)
+}
+
+var metricsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.metrics.v1.MetricsService",
+	HandlerType: (*metricsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    exportMetricsHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "opentelemetry/proto/collector/metrics/v1/metrics_service.proto",
+}
+
+var logsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.logs.v1.LogsService",
+	HandlerType: (*logsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    exportLogsHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "opentelemetry/proto/collector/logs/v1/logs_service.proto",
+}
+
+func exportMetricsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(otlp.ExportMetricsServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(metricsServer).ExportMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(metricsServer).ExportMetrics(ctx, req.(*otlp.ExportMetricsServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func exportLogsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(otlp.ExportLogsServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(logsServer).ExportLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/opentelemetry.proto.collector.logs.v1.LogsService/Export",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(logsServer).ExportLogs(ctx, req.(*otlp.ExportLogsServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func (o *OpenTelemetry) serveMetrics(res http.ResponseWriter, req *http.Request) {
+	var msg otlp.ExportMetricsServiceRequest
+	if contentType, ok := o.readRequest(res, req, &msg); ok {
+		o.addMetrics(&msg)
+		writeResponse(res, contentType)
+	}
+}
+
+func (o *OpenTelemetry) serveLogs(res http.ResponseWriter, req *http.Request) {
+	var msg otlp.ExportLogsServiceRequest
+	if contentType, ok := o.readRequest(res, req, &msg); ok {
+		if contentType == "application/json" {
+			if err := decodeHexIDs(&msg); err != nil {
+				http.Error(res, "invalid request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		o.addLogs(&msg)
+		writeResponse(res, contentType)
+	}
+}
+
+// readRequest decodes the body of the request into msg, on failure the error
+// response is written and false is returned.
+func (o *OpenTelemetry) readRequest(res http.ResponseWriter, req *http.Request, msg proto.Message) (string, bool) {
+	if req.Method != http.MethodPost {
+		http.Error(res, "method not allowed", http.StatusMethodNotAllowed)
+		return "", false
+	}
+
+	contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if contentType != "application/x-protobuf" && contentType != "application/json" {
+		http.Error(res, "unsupported content type", http.StatusUnsupportedMediaType)
+		return "", false
+	}
+
+	if req.ContentLength > o.MaxMessageSize.Size {
+		http.Error(res, "request too large", http.StatusRequestEntityTooLarge)
+		return "", false
+	}
+
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		r, err := gzip.NewReader(req.Body)
+		if err != nil {
+			http.Error(res, "invalid gzip encoding", http.StatusBadRequest)
+			return "", false
+		}
+		defer r.Close()
+		body = r
+	}
+
+	buf, err := ioutil.ReadAll(io.LimitReader(body, o.MaxMessageSize.Size+1))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	if int64(len(buf)) > o.MaxMessageSize.Size {
+		http.Error(res, "request too large", http.StatusRequestEntityTooLarge)
+		return "", false
+	}
+
+	if contentType == "application/json" {
+		unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+		err = unmarshaler.Unmarshal(bytes.NewReader(buf), msg)
+	} else {
+		err = proto.Unmarshal(buf, msg)
+	}
+	if err != nil {
+		o.Log.Debugf("Error decoding OTLP request: %v", err)
+		http.Error(res, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return contentType, true
+}
+
+// decodeHexIDs decodes the trace and span ids of the log records, the JSON
+// encoding of OTLP uses hex for them where jsonpb expects base64.
+func decodeHexIDs(req *otlp.ExportLogsServiceRequest) error {
+	decode := func(id []byte) ([]byte, error) {
+		if len(id) == 0 {
+			return id, nil
+		}
+		return hex.DecodeString(base64.StdEncoding.EncodeToString(id))
+	}
+
+	for _, rl := range req.ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			for _, lr := range sl.LogRecords {
+				var err error
+				if lr.TraceId, err = decode(lr.TraceId); err != nil {
+					return fmt.Errorf("invalid trace id: %v", err)
+				}
+				if lr.SpanId, err = decode(lr.SpanId); err != nil {
+					return fmt.Errorf("invalid span id: %v", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeResponse writes the empty export response in the encoding of the
+// request.
+func writeResponse(res http.ResponseWriter, contentType string) {
+	res.Header().Set("Content-Type", contentType)
+	res.WriteHeader(http.StatusOK)
+	if contentType == "application/json" {
+		res.Write([]byte("{}"))
+	}
+}
@@ -0,0 +1,85 @@
+package opentelemetry
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/influxdata/telegraf/plugins/inputs/opentelemetry/otlp"
+)
+
+// anyValue returns the value as a string, bool, int64 or float64, arrays and
+// key value lists are returned as their JSON encoding.
+func anyValue(v *otlp.AnyValue) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch x := v.Value.(type) {
+	case *otlp.AnyValue_StringValue:
+		return x.StringValue
+	case *otlp.AnyValue_BoolValue:
+		return x.BoolValue
+	case *otlp.AnyValue_IntValue:
+		return x.IntValue
+	case *otlp.AnyValue_DoubleValue:
+		return x.DoubleValue
+	case *otlp.AnyValue_ArrayValue, *otlp.AnyValue_KvlistValue:
+		b, err := json.Marshal(plainValue(v))
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	case *otlp.AnyValue_BytesValue:
+		return hex.EncodeToString(x.BytesValue)
+	}
+	return nil
+}
+
+// plainValue returns the value with arrays and key value lists converted to
+// slices and maps.
+func plainValue(v *otlp.AnyValue) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch x := v.Value.(type) {
+	case *otlp.AnyValue_ArrayValue:
+		if x.ArrayValue == nil {
+			return []interface{}{}
+		}
+		values := make([]interface{}, 0, len(x.ArrayValue.Values))
+		for _, v := range x.ArrayValue.Values {
+			values = append(values, plainValue(v))
+		}
+		return values
+	case *otlp.AnyValue_KvlistValue:
+		if x.KvlistValue == nil {
+			return map[string]interface{}{}
+		}
+		values := make(map[string]interface{}, len(x.KvlistValue.Values))
+		for _, kv := range x.KvlistValue.Values {
+			values[kv.Key] = plainValue(kv.Value)
+		}
+		return values
+	case *otlp.AnyValue_DoubleValue:
+		if math.IsNaN(x.DoubleValue) || math.IsInf(x.DoubleValue, 0) {
+			return strconv.FormatFloat(x.DoubleValue, 'g', -1, 64)
+		}
+	}
+	return anyValue(v)
+}
+
+// formatValue returns the value formatted as a string.
+func formatValue(v *otlp.AnyValue) string {
+	switch x := anyValue(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	default:
+		return fmt.Sprint(x)
+	}
+}
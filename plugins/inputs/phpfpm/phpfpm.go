@@ -13,6 +13,7 @@ import (
 	"sync"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -36,6 +37,7 @@ type poolStat map[string]metric
 
 type phpfpm struct {
 	Urls []string
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -61,6 +63,12 @@ var sampleConfig = `
   ## Example of multiple gathering from local socket and remove host
   ## urls = ["http://192.168.1.20/status", "/tmp/fpm.sock"]
   urls = ["http://localhost/status"]
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 func (r *phpfpm) SampleConfig() string {
@@ -74,6 +82,19 @@ func (r *phpfpm) Description() string {
 // Reads stats from all configured servers accumulates stats.
 // Returns one of the errors encountered while gather stats (if any).
 func (g *phpfpm) Gather(acc telegraf.Accumulator) error {
+	if g.client == nil {
+		proxy, err := g.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
+		g.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy: proxy,
+			},
+		}
+	}
+
 	if len(g.Urls) == 0 {
 		return g.gatherServer("http://127.0.0.1/status", acc)
 	}
@@ -95,11 +116,6 @@ func (g *phpfpm) Gather(acc telegraf.Accumulator) error {
 
 // Request status page to get stat raw data and import it
 func (g *phpfpm) gatherServer(addr string, acc telegraf.Accumulator) error {
-	if g.client == nil {
-		client := &http.Client{}
-		g.client = client
-	}
-
 	if strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://") {
 		return g.gatherHttp(addr, acc)
 	}
@@ -254,6 +270,8 @@ func importMetric(r io.Reader, acc telegraf.Accumulator, addr string) (poolStat,
 
 func init() {
 	inputs.Add("phpfpm", func() telegraf.Input {
-		return &phpfpm{}
+		return &phpfpm{
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
+		}
 	})
 }
@@ -53,8 +53,21 @@ type Ping struct {
 	// when `Arguments` is not empty, other options (ping_interval, timeout, etc) will be ignored
 	Arguments []string
 
+	// Method used to send the pings, "exec" runs the ping executable and
+	// "native" sends the ICMP echo requests from telegraf.
+	Method string `toml:"method"`
+
+	// Use IPv6 addresses with the native method.
+	IPv6 bool `toml:"ipv6"`
+
+	// Percentiles of the response times to calculate with the native method.
+	Percentiles []int `toml:"percentiles"`
+
 	// host ping function
 	pingHost HostPinger
+
+	// native ping function
+	nativePing NativePinger
 }
 
 func (_ *Ping) Description() string {
@@ -88,6 +101,18 @@ const sampleConfig = `
   ## Arguments for ping command
   ## when arguments is not empty, other options (ping_interval, timeout, etc) will be ignored
   # arguments = ["-c", "3"]
+
+  ## Method used for sending pings, can be either "exec" or "native".  When set
+  ## to "exec" the systems ping command will be executed.  When set to "native"
+  ## the plugin will send pings directly, without the ping executable, using
+  ## unprivileged ICMP sockets when allowed and raw sockets otherwise.
+  # method = "exec"
+
+  ## Use only IPv6 addresses when resolving hostnames with the native method.
+  # ipv6 = false
+
+  ## Percentiles of the response times to calculate with the native method.
+  # percentiles = [50, 95, 99]
 `
 
 func (_ *Ping) SampleConfig() string {
@@ -95,10 +120,20 @@ func (_ *Ping) SampleConfig() string {
 }
 
 func (p *Ping) Gather(acc telegraf.Accumulator) error {
+	switch p.Method {
+	case "", "exec", "native":
+	default:
+		return fmt.Errorf("unknown method %q", p.Method)
+	}
+
 	// Spin off a go routine for each url to ping
 	for _, url := range p.Urls {
 		p.wg.Add(1)
-		go p.pingToURL(url, acc)
+		if p.Method == "native" {
+			go p.nativePingToURL(url, acc)
+		} else {
+			go p.pingToURL(url, acc)
+		}
 	}
 
 	p.wg.Wait()
@@ -331,12 +366,14 @@ func init() {
 	inputs.Add("ping", func() telegraf.Input {
 		return &Ping{
 			pingHost:     hostPinger,
+			nativePing:   nativePing,
 			PingInterval: 1.0,
 			Count:        1,
 			Timeout:      1.0,
 			Deadline:     10,
 			Binary:       "ping",
 			Arguments:    []string{},
+			Method:       "exec",
 		}
 	})
 }
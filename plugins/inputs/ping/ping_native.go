@@ -0,0 +1,371 @@
+// +build !windows
+
+package ping
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+)
+
+// NativePinger sends the echo requests to the address and returns the
+// results, it is replaced by a mock in the tests.
+type NativePinger func(p *Ping, addr *net.IPAddr) (*pingStats, error)
+
+// pingStats are the results of the echo requests sent to a host.
+type pingStats struct {
+	sent     int
+	received int
+	// ttl is the ttl, or hop limit, of the first reply, -1 if unknown.
+	ttl  int
+	rtts []time.Duration
+}
+
+// echoID is incremented for each host pinged, with raw sockets all the
+// replies are received by every socket and are matched by the id.
+var echoID = uint32(rand.New(rand.NewSource(time.Now().UnixNano())).Intn(math.MaxUint16))
+
+func (p *Ping) nativePingToURL(u string, acc telegraf.Accumulator) {
+	defer p.wg.Done()
+	tags := map[string]string{"url": u}
+	fields := map[string]interface{}{"result_code": 0}
+
+	network := "ip4"
+	if p.IPv6 {
+		network = "ip6"
+	}
+	addr, err := net.ResolveIPAddr(network, u)
+	if err != nil {
+		acc.AddError(err)
+		fields["result_code"] = 1
+		acc.AddFields("ping", fields, tags)
+		return
+	}
+
+	stats, err := p.nativePing(p, addr)
+	if err != nil {
+		acc.AddError(fmt.Errorf("host %s: %s", u, err))
+		fields["result_code"] = 2
+		acc.AddFields("ping", fields, tags)
+		return
+	}
+
+	fields["packets_transmitted"] = stats.sent
+	fields["packets_received"] = stats.received
+	if stats.sent > 0 {
+		fields["percent_packet_loss"] = float64(stats.sent-stats.received) / float64(stats.sent) * 100.0
+	}
+	if stats.ttl >= 0 {
+		fields["ttl"] = stats.ttl
+	}
+	if len(stats.rtts) > 0 {
+		min, avg, max, stddev := rttStats(stats.rtts)
+		fields["minimum_response_ms"] = min
+		fields["average_response_ms"] = avg
+		fields["maximum_response_ms"] = max
+		fields["standard_deviation_ms"] = stddev
+		for _, perc := range p.Percentiles {
+			fields["percentile"+strconv.Itoa(perc)+"_ms"] = percentile(stats.rtts, perc)
+		}
+	}
+	acc.AddFields("ping", fields, tags)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// rttStats returns the minimum, average, maximum and standard deviation of
+// the round trip times in milliseconds.
+func rttStats(rtts []time.Duration) (float64, float64, float64, float64) {
+	min, max := rtts[0], rtts[0]
+	var sum float64
+	for _, rtt := range rtts {
+		if rtt < min {
+			min = rtt
+		}
+		if rtt > max {
+			max = rtt
+		}
+		sum += durationMs(rtt)
+	}
+	avg := sum / float64(len(rtts))
+
+	var variance float64
+	for _, rtt := range rtts {
+		variance += math.Pow(durationMs(rtt)-avg, 2)
+	}
+	stddev := math.Sqrt(variance / float64(len(rtts)))
+	return durationMs(min), avg, durationMs(max), stddev
+}
+
+// percentile returns the nearest rank percentile of the round trip times in
+// milliseconds.
+func percentile(rtts []time.Duration, perc int) float64 {
+	sorted := make([]time.Duration, len(rtts))
+	copy(sorted, rtts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	if perc <= 0 {
+		return durationMs(sorted[0])
+	}
+	rank := int(math.Ceil(float64(perc) / 100 * float64(len(sorted))))
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return durationMs(sorted[rank-1])
+}
+
+// icmpConn is an ICMP socket, either an unprivileged datagram socket or a
+// raw socket.
+type icmpConn struct {
+	*icmp.PacketConn
+	raw  bool
+	ipv6 bool
+}
+
+// listen opens an ICMP socket for the address family of addr, the
+// unprivileged datagram sockets are used if allowed.
+func (p *Ping) listen(addr *net.IPAddr) (*icmpConn, error) {
+	isIPv6 := addr.IP.To4() == nil
+
+	source, err := p.sourceAddress(isIPv6)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := []string{"udp4", "ip4:icmp"}
+	if isIPv6 {
+		networks = []string{"udp6", "ip6:ipv6-icmp"}
+	}
+
+	var conn *icmp.PacketConn
+	for i, network := range networks {
+		conn, err = icmp.ListenPacket(network, source)
+		if err == nil {
+			c := &icmpConn{PacketConn: conn, raw: i == 1, ipv6: isIPv6}
+			c.setControlMessage()
+			return c, nil
+		}
+	}
+	return nil, err
+}
+
+// setControlMessage requests the ttl of the replies, the ttl is not reported
+// if it can not be set.
+func (c *icmpConn) setControlMessage() {
+	if c.ipv6 {
+		c.IPv6PacketConn().SetControlMessage(ipv6.FlagHopLimit, true)
+	} else {
+		c.IPv4PacketConn().SetControlMessage(ipv4.FlagTTL, true)
+	}
+}
+
+func (c *icmpConn) read(buf []byte) (int, int, net.Addr, error) {
+	if c.ipv6 {
+		n, cm, src, err := c.IPv6PacketConn().ReadFrom(buf)
+		if cm != nil {
+			return n, cm.HopLimit, src, err
+		}
+		return n, -1, src, err
+	}
+	n, cm, src, err := c.IPv4PacketConn().ReadFrom(buf)
+	if cm != nil {
+		return n, cm.TTL, src, err
+	}
+	return n, -1, src, err
+}
+
+// sourceAddress returns the address to send the pings from, the interface
+// option is either an address or the name of an interface.
+func (p *Ping) sourceAddress(isIPv6 bool) (string, error) {
+	if p.Interface == "" {
+		if isIPv6 {
+			return "::", nil
+		}
+		return "0.0.0.0", nil
+	}
+	if net.ParseIP(p.Interface) != nil {
+		return p.Interface, nil
+	}
+
+	iface, err := net.InterfaceByName(p.Interface)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", err
+	}
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || (ipnet.IP.To4() == nil) != isIPv6 {
+			continue
+		}
+		if isIPv6 && ipnet.IP.IsLinkLocalUnicast() {
+			return ipnet.IP.String() + "%" + iface.Name, nil
+		}
+		return ipnet.IP.String(), nil
+	}
+	return "", fmt.Errorf("no address of interface %s for the address family", p.Interface)
+}
+
+type echoReply struct {
+	seq      int
+	ttl      int
+	received time.Time
+}
+
+// nativePing sends count echo requests to the address every ping_interval
+// and waits for the replies until the timeout after the last request or
+// until the deadline.
+func nativePing(p *Ping, addr *net.IPAddr) (*pingStats, error) {
+	conn, err := p.listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	count := p.Count
+	if count <= 0 {
+		count = 1
+	}
+	interval := time.Duration(p.PingInterval * float64(time.Second))
+	if interval <= 0 {
+		interval = time.Second
+	}
+	timeout := time.Duration(p.Timeout * float64(time.Second))
+	if timeout <= 0 {
+		timeout = time.Duration(count) * interval
+	}
+	deadline := time.Now().Add(time.Duration(count-1)*interval + timeout)
+	if p.Deadline > 0 {
+		d := time.Now().Add(time.Duration(p.Deadline) * time.Second)
+		if d.Before(deadline) {
+			deadline = d
+		}
+	}
+
+	id := int(atomic.AddUint32(&echoID, 1) & 0xffff)
+	var dst net.Addr = addr
+	proto := protocolICMP
+	var requestType, replyType icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	if conn.ipv6 {
+		proto = protocolIPv6ICMP
+		requestType, replyType = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+	}
+	if !conn.raw {
+		dst = &net.UDPAddr{IP: addr.IP, Zone: addr.Zone}
+	}
+
+	// The replies are read until the socket is closed.
+	replies := make(chan echoReply, count)
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, ttl, src, err := conn.read(buf)
+			if err != nil {
+				return
+			}
+			received := time.Now()
+
+			msg, err := icmp.ParseMessage(proto, buf[:n])
+			if err != nil || msg.Type != replyType {
+				continue
+			}
+			echo, ok := msg.Body.(*icmp.Echo)
+			if !ok || echo.Seq >= count {
+				continue
+			}
+			// The id of the datagram sockets is set by the kernel, the
+			// replies are only received by the socket of the request.
+			if conn.raw && (echo.ID != id || !sameIP(src, addr.IP)) {
+				continue
+			}
+			select {
+			case replies <- echoReply{seq: echo.Seq, ttl: ttl, received: received}:
+			default:
+			}
+		}
+	}()
+
+	stats := &pingStats{ttl: -1}
+	sent := make([]time.Time, count)
+	seen := make([]bool, count)
+
+	send := func(seq int) error {
+		msg := icmp.Message{
+			Type: requestType,
+			Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("telegraf-ping-16")},
+		}
+		b, err := msg.Marshal(nil)
+		if err != nil {
+			return err
+		}
+		sent[seq] = time.Now()
+		if _, err := conn.WriteTo(b, dst); err != nil {
+			return err
+		}
+		stats.sent++
+		return nil
+	}
+
+	if err := send(0); err != nil {
+		return nil, err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for {
+		if stats.received == count {
+			return stats, nil
+		}
+		select {
+		case <-ticker.C:
+			if stats.sent < count {
+				if err := send(stats.sent); err != nil {
+					return nil, err
+				}
+			}
+		case reply := <-replies:
+			if seen[reply.seq] || sent[reply.seq].IsZero() {
+				continue
+			}
+			seen[reply.seq] = true
+			stats.received++
+			stats.rtts = append(stats.rtts, reply.received.Sub(sent[reply.seq]))
+			if stats.ttl < 0 {
+				stats.ttl = reply.ttl
+			}
+		case <-timer.C:
+			return stats, nil
+		}
+	}
+}
+
+func sameIP(addr net.Addr, ip net.IP) bool {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.Equal(ip)
+	case *net.UDPAddr:
+		return a.IP.Equal(ip)
+	}
+	return false
+}
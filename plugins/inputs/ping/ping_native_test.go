@@ -0,0 +1,161 @@
+// +build !windows
+
+package ping
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func mockNativePinger(p *Ping, addr *net.IPAddr) (*pingStats, error) {
+	return &pingStats{
+		sent:     5,
+		received: 4,
+		ttl:      63,
+		rtts: []time.Duration{
+			10 * time.Millisecond,
+			20 * time.Millisecond,
+			30 * time.Millisecond,
+			40 * time.Millisecond,
+		},
+	}, nil
+}
+
+func TestNativePingGather(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:        []string{"127.0.0.1", "localhost"},
+		Method:      "native",
+		Percentiles: []int{50, 90},
+		nativePing:  mockNativePinger,
+	}
+
+	require.NoError(t, acc.GatherError(p.Gather))
+	fields := map[string]interface{}{
+		"packets_transmitted":   5,
+		"packets_received":      4,
+		"percent_packet_loss":   20.0,
+		"ttl":                   63,
+		"minimum_response_ms":   10.0,
+		"average_response_ms":   25.0,
+		"maximum_response_ms":   40.0,
+		"standard_deviation_ms": 11.180339887498949,
+		"percentile50_ms":       20.0,
+		"percentile90_ms":       40.0,
+		"result_code":           0,
+	}
+	acc.AssertContainsTaggedFields(t, "ping", fields, map[string]string{"url": "127.0.0.1"})
+	acc.AssertContainsTaggedFields(t, "ping", fields, map[string]string{"url": "localhost"})
+}
+
+func TestNativePingNoReplies(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:   []string{"127.0.0.1"},
+		Method: "native",
+		nativePing: func(p *Ping, addr *net.IPAddr) (*pingStats, error) {
+			return &pingStats{sent: 2, ttl: -1}, nil
+		},
+	}
+
+	require.NoError(t, acc.GatherError(p.Gather))
+	acc.AssertContainsTaggedFields(t, "ping",
+		map[string]interface{}{
+			"packets_transmitted": 2,
+			"packets_received":    0,
+			"percent_packet_loss": 100.0,
+			"result_code":         0,
+		},
+		map[string]string{"url": "127.0.0.1"})
+}
+
+func TestNativePingErrors(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:   []string{"127.0.0.1", "host.invalid"},
+		Method: "native",
+		nativePing: func(p *Ping, addr *net.IPAddr) (*pingStats, error) {
+			return nil, errors.New("socket: operation not permitted")
+		},
+	}
+
+	acc.GatherError(p.Gather)
+	require.Len(t, acc.Errors, 2)
+	acc.AssertContainsTaggedFields(t, "ping",
+		map[string]interface{}{"result_code": 2},
+		map[string]string{"url": "127.0.0.1"})
+	acc.AssertContainsTaggedFields(t, "ping",
+		map[string]interface{}{"result_code": 1},
+		map[string]string{"url": "host.invalid"})
+}
+
+func TestNativePingIPv6(t *testing.T) {
+	var resolved []string
+	p := Ping{
+		Urls:   []string{"::1"},
+		Method: "native",
+		IPv6:   true,
+		nativePing: func(p *Ping, addr *net.IPAddr) (*pingStats, error) {
+			resolved = append(resolved, addr.String())
+			return &pingStats{sent: 1, ttl: -1}, nil
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+	require.Equal(t, []string{"::1"}, resolved)
+}
+
+func TestInvalidMethod(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{Urls: []string{"127.0.0.1"}, Method: "icmp"}
+	require.Error(t, p.Gather(&acc))
+}
+
+func TestPercentile(t *testing.T) {
+	rtts := []time.Duration{
+		5 * time.Millisecond,
+		1 * time.Millisecond,
+		4 * time.Millisecond,
+		2 * time.Millisecond,
+		3 * time.Millisecond,
+	}
+	require.Equal(t, 1.0, percentile(rtts, 0))
+	require.Equal(t, 1.0, percentile(rtts, 20))
+	require.Equal(t, 3.0, percentile(rtts, 50))
+	require.Equal(t, 5.0, percentile(rtts, 99))
+	require.Equal(t, 5.0, percentile(rtts, 100))
+	// The round trip times are not sorted in place.
+	require.Equal(t, 5*time.Millisecond, rtts[0])
+}
+
+// Test pinging the loopback address, the test is skipped when ICMP sockets
+// can not be opened.
+func TestNativePingLoopback(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping network-dependent test in short mode.")
+	}
+
+	p := &Ping{
+		Count:        3,
+		PingInterval: 0.1,
+		Timeout:      1.0,
+	}
+	addr := &net.IPAddr{IP: net.ParseIP("127.0.0.1")}
+	conn, err := p.listen(addr)
+	if err != nil {
+		t.Skipf("Unable to open ICMP socket: %v", err)
+	}
+	conn.Close()
+
+	stats, err := nativePing(p, addr)
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.sent)
+	require.Equal(t, 3, stats.received)
+	require.Len(t, stats.rtts, 3)
+}
@@ -73,3 +73,25 @@ func (p *Proc) Percent(interval time.Duration) (float64, error) {
 	}
 	return cpu_perc, err
 }
+
+// ProcessTree returns the PIDs of the child processes of every process.
+func ProcessTree() (map[PID][]PID, error) {
+	pids, err := process.Pids()
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[PID][]PID)
+	for _, pid := range pids {
+		proc, err := process.NewProcess(pid)
+		if err != nil {
+			continue
+		}
+		ppid, err := proc.Ppid()
+		if err != nil || ppid == pid {
+			continue
+		}
+		tree[PID(ppid)] = append(tree[PID(ppid)], PID(pid))
+	}
+	return tree, nil
+}
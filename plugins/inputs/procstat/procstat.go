@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -17,6 +18,8 @@ import (
 var (
 	defaultPIDFinder = NewPgrep
 	defaultProcess   = NewProc
+
+	defaultProcessTree = ProcessTree
 )
 
 type PID int32
@@ -35,11 +38,24 @@ type Procstat struct {
 	PidTag      bool
 	WinService  string `toml:"win_service"`
 
+	AggregateChildren bool `toml:"aggregate_children"`
+
 	finder PIDFinder
 
 	createPIDFinder func() (PIDFinder, error)
 	procs           map[PID]Process
 	createProcess   func(PID) (Process, error)
+
+	processTree func() (map[PID][]PID, error)
+	children    map[PID]Process
+	descendants map[PID][]Process
+}
+
+// pidsTags are the PIDs found by a single lookup and their initial tags.
+type pidsTags struct {
+	pids []PID
+	tags map[string]string
+	err  error
 }
 
 var sampleConfig = `
@@ -51,14 +67,18 @@ var sampleConfig = `
   # pattern = "nginx"
   ## user as argument for pgrep (ie, pgrep -u <user>)
   # user = "nginx"
-  ## Systemd unit name
+  ## Systemd unit name, a glob pattern reports every matching unit
   # systemd_unit = "nginx.service"
-  ## CGroup name or path
+  ## CGroup name or path, a glob pattern reports every matching cgroup
   # cgroup = "systemd/system.slice/nginx.service"
 
-  ## Windows service name
+  ## Windows service name, a glob pattern reports every matching service
   # win_service = ""
 
+  ## When true the metrics of the child processes, and of their descendants,
+  ## are added to the metrics of the matched processes.
+  # aggregate_children = false
+
   ## override for process_name
   ## This is optional; default is sourced from /proc/<pid>/status
   # process_name = "bar"
@@ -106,7 +126,11 @@ func (p *Procstat) Gather(acc telegraf.Accumulator) error {
 		p.createProcess = defaultProcess
 	}
 
-	pids, tags, err := p.findPids(acc)
+	if p.processTree == nil {
+		p.processTree = defaultProcessTree
+	}
+
+	pidSets, err := p.findPidSets(acc)
 	if err != nil {
 		fields := map[string]interface{}{
 			"pid_count":   0,
@@ -121,25 +145,50 @@ func (p *Procstat) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 
-	procs, err := p.updateProcesses(pids, tags, p.procs)
-	if err != nil {
-		acc.AddError(fmt.Errorf("E! Error: procstat getting process, exe: [%s] pidfile: [%s] pattern: [%s] user: [%s] %s",
-			p.Exe, p.PidFile, p.Pattern, p.User, err.Error()))
+	procs := make(map[PID]Process, len(p.procs))
+	for _, set := range pidSets {
+		if set.err != nil {
+			acc.AddError(fmt.Errorf("E! Error: procstat finding pids, %s", set.err.Error()))
+			fields := map[string]interface{}{
+				"pid_count":   0,
+				"running":     0,
+				"result_code": 1,
+			}
+			set.tags["pid_finder"] = p.PidFinder
+			set.tags["result"] = "lookup_error"
+			acc.AddFields("procstat_lookup", fields, set.tags)
+			continue
+		}
+
+		setProcs, err := p.updateProcesses(set.pids, set.tags, p.procs)
+		if err != nil {
+			acc.AddError(fmt.Errorf("E! Error: procstat getting process, exe: [%s] pidfile: [%s] pattern: [%s] user: [%s] %s",
+				p.Exe, p.PidFile, p.Pattern, p.User, err.Error()))
+		}
+		for pid, proc := range setProcs {
+			procs[pid] = proc
+		}
+
+		fields := map[string]interface{}{
+			"pid_count":   len(set.pids),
+			"running":     len(setProcs),
+			"result_code": 0,
+		}
+		set.tags["pid_finder"] = p.PidFinder
+		set.tags["result"] = "success"
+		acc.AddFields("procstat_lookup", fields, set.tags)
 	}
 	p.procs = procs
 
-	for _, proc := range p.procs {
-		p.addMetric(proc, acc)
+	if p.AggregateChildren {
+		if err := p.updateChildren(); err != nil {
+			acc.AddError(fmt.Errorf("E! Error: procstat finding child processes, %s", err.Error()))
+		}
 	}
 
-	fields := map[string]interface{}{
-		"pid_count":   len(pids),
-		"running":     len(procs),
-		"result_code": 0,
+	for _, proc := range p.procs {
+		p.addMetric(proc, acc)
 	}
-	tags["pid_finder"] = p.PidFinder
-	tags["result"] = "success"
-	acc.AddFields("procstat_lookup", fields, tags)
 
 	return nil
 }
@@ -184,6 +233,21 @@ func (p *Procstat) addMetric(proc Process, acc telegraf.Accumulator) {
 		}
 	}
 
+	p.addProcessFields(proc, prefix, fields)
+	if p.AggregateChildren {
+		fields[prefix+"num_children"] = len(p.descendants[proc.PID()])
+		for _, child := range p.descendants[proc.PID()] {
+			childFields := make(map[string]interface{})
+			p.addProcessFields(child, prefix, childFields)
+			sumFields(fields, childFields, prefix)
+		}
+	}
+
+	acc.AddFields("procstat", fields, proc.Tags())
+}
+
+// Add the resource usage fields of a single Process
+func (p *Procstat) addProcessFields(proc Process, prefix string, fields map[string]interface{}) {
 	numThreads, err := proc.NumThreads()
 	if err == nil {
 		fields[prefix+"num_threads"] = numThreads
@@ -280,8 +344,48 @@ func (p *Procstat) addMetric(proc Process, acc telegraf.Accumulator) {
 			}
 		}
 	}
+}
 
-	acc.AddFields("procstat", fields, proc.Tags())
+// unsummedFields are the resource limit usages that are not a usage of the
+// process alone, such as its priorities, they are not summed with the
+// children.
+var unsummedFields = map[string]bool{
+	"cpu_time":          true,
+	"signals_pending":   true,
+	"nice_priority":     true,
+	"realtime_priority": true,
+}
+
+// sumFields adds the fields of a child process to the fields of its parent,
+// the fields missing from the parent and the resource limits are skipped.
+func sumFields(fields, childFields map[string]interface{}, prefix string) {
+	for k, v := range childFields {
+		if strings.HasPrefix(k, prefix+"rlimit_") || unsummedFields[strings.TrimPrefix(k, prefix)] {
+			continue
+		}
+		switch pv := fields[k].(type) {
+		case int32:
+			if cv, ok := v.(int32); ok {
+				fields[k] = pv + cv
+			}
+		case int64:
+			if cv, ok := v.(int64); ok {
+				fields[k] = pv + cv
+			}
+		case uint64:
+			if cv, ok := v.(uint64); ok {
+				fields[k] = pv + cv
+			}
+		case float32:
+			if cv, ok := v.(float32); ok {
+				fields[k] = pv + cv
+			}
+		case float64:
+			if cv, ok := v.(float64); ok {
+				fields[k] = pv + cv
+			}
+		}
+	}
 }
 
 // Update monitored Processes
@@ -317,6 +421,47 @@ func (p *Procstat) updateProcesses(pids []PID, tags map[string]string, prevInfo
 	return procs, nil
 }
 
+// Update the descendants of the monitored Processes, the descendants that
+// are monitored themselves are not included.
+func (p *Procstat) updateChildren() error {
+	p.descendants = make(map[PID][]Process, len(p.procs))
+
+	tree, err := p.processTree()
+	if err != nil {
+		p.children = nil
+		return err
+	}
+
+	children := make(map[PID]Process, len(p.children))
+	for pid := range p.procs {
+		queue := append([]PID(nil), tree[pid]...)
+		for len(queue) > 0 {
+			child := queue[0]
+			queue = queue[1:]
+			if _, ok := p.procs[child]; ok {
+				continue
+			}
+			if _, ok := children[child]; ok {
+				continue
+			}
+
+			proc, ok := p.children[child]
+			if !ok {
+				proc, err = p.createProcess(child)
+				if err != nil {
+					// The process may have ended
+					continue
+				}
+			}
+			children[child] = proc
+			p.descendants[pid] = append(p.descendants[pid], proc)
+			queue = append(queue, tree[child]...)
+		}
+	}
+	p.children = children
+	return nil
+}
+
 // Create and return PIDGatherer lazily
 func (p *Procstat) getPIDFinder() (PIDFinder, error) {
 	if p.finder == nil {
@@ -329,6 +474,37 @@ func (p *Procstat) getPIDFinder() (PIDFinder, error) {
 	return p.finder, nil
 }
 
+// Get the sets of matching PIDs, a set for every systemd unit, cgroup or
+// windows service matching a glob pattern.
+func (p *Procstat) findPidSets(acc telegraf.Accumulator) ([]pidsTags, error) {
+	if p.PidFile == "" && p.Exe == "" && p.Pattern == "" && p.User == "" {
+		switch {
+		case p.SystemdUnit != "":
+			if isGlob(p.SystemdUnit) {
+				return p.systemdUnitSets()
+			}
+		case p.CGroup != "":
+			if isGlob(p.CGroup) {
+				return p.cgroupSets()
+			}
+		case p.WinService != "":
+			if isGlob(p.WinService) {
+				return p.winServiceSets()
+			}
+		}
+	}
+
+	pids, tags, err := p.findPids(acc)
+	if err != nil {
+		return nil, err
+	}
+	return []pidsTags{{pids: pids, tags: tags}}, nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
 // Get matching PIDs and their initial tags
 func (p *Procstat) findPids(acc telegraf.Accumulator) ([]PID, map[string]string, error) {
 	var pids []PID
@@ -353,13 +529,13 @@ func (p *Procstat) findPids(acc telegraf.Accumulator) ([]PID, map[string]string,
 		pids, err = f.Uid(p.User)
 		tags = map[string]string{"user": p.User}
 	} else if p.SystemdUnit != "" {
-		pids, err = p.systemdUnitPIDs()
+		pids, err = p.systemdUnitPIDs(p.SystemdUnit)
 		tags = map[string]string{"systemd_unit": p.SystemdUnit}
 	} else if p.CGroup != "" {
-		pids, err = p.cgroupPIDs()
+		pids, err = p.cgroupPIDs(p.CGroup)
 		tags = map[string]string{"cgroup": p.CGroup}
 	} else if p.WinService != "" {
-		pids, err = p.winServicePIDs()
+		pids, err = p.winServicePIDs(p.WinService)
 		tags = map[string]string{"win_service": p.WinService}
 	} else {
 		err = fmt.Errorf("Either exe, pid_file, user, pattern, systemd_unit, cgroup, or win_service must be specified")
@@ -371,9 +547,35 @@ func (p *Procstat) findPids(acc telegraf.Accumulator) ([]PID, map[string]string,
 // execCommand is so tests can mock out exec.Command usage.
 var execCommand = exec.Command
 
-func (p *Procstat) systemdUnitPIDs() ([]PID, error) {
+func (p *Procstat) systemdUnitSets() ([]pidsTags, error) {
+	cmd := execCommand("systemctl", "list-units", "--all", "--plain", "--no-legend", p.SystemdUnit)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []pidsTags
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "\u25cf" {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		pids, err := p.systemdUnitPIDs(fields[0])
+		sets = append(sets, pidsTags{
+			pids: pids,
+			tags: map[string]string{"systemd_unit": fields[0]},
+			err:  err,
+		})
+	}
+	return sets, nil
+}
+
+func (p *Procstat) systemdUnitPIDs(unit string) ([]PID, error) {
 	var pids []PID
-	cmd := execCommand("systemctl", "show", p.SystemdUnit)
+	cmd := execCommand("systemctl", "show", unit)
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, err
@@ -398,14 +600,36 @@ func (p *Procstat) systemdUnitPIDs() ([]PID, error) {
 	return pids, nil
 }
 
-func (p *Procstat) cgroupPIDs() ([]PID, error) {
-	var pids []PID
+func cgroupPath(cgroup string) string {
+	if cgroup[0] != '/' {
+		return "/sys/fs/cgroup/" + cgroup
+	}
+	return cgroup
+}
 
-	procsPath := p.CGroup
-	if procsPath[0] != '/' {
-		procsPath = "/sys/fs/cgroup/" + procsPath
+func (p *Procstat) cgroupSets() ([]pidsTags, error) {
+	matches, err := filepath.Glob(filepath.Join(cgroupPath(p.CGroup), "cgroup.procs"))
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make([]pidsTags, 0, len(matches))
+	for _, match := range matches {
+		path := filepath.Dir(match)
+		pids, err := p.cgroupPIDs(path)
+		sets = append(sets, pidsTags{
+			pids: pids,
+			tags: map[string]string{"cgroup": path},
+			err:  err,
+		})
 	}
-	procsPath = filepath.Join(procsPath, "cgroup.procs")
+	return sets, nil
+}
+
+func (p *Procstat) cgroupPIDs(cgroup string) ([]PID, error) {
+	var pids []PID
+
+	procsPath := filepath.Join(cgroupPath(cgroup), "cgroup.procs")
 	out, err := ioutil.ReadFile(procsPath)
 	if err != nil {
 		return nil, err
@@ -424,10 +648,36 @@ func (p *Procstat) cgroupPIDs() ([]PID, error) {
 	return pids, nil
 }
 
-func (p *Procstat) winServicePIDs() ([]PID, error) {
+func (p *Procstat) winServiceSets() ([]pidsTags, error) {
+	names, err := queryWinServiceNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []pidsTags
+	for _, name := range names {
+		// Service names are case insensitive
+		ok, err := filepath.Match(strings.ToLower(p.WinService), strings.ToLower(name))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		pids, err := p.winServicePIDs(name)
+		sets = append(sets, pidsTags{
+			pids: pids,
+			tags: map[string]string{"win_service": name},
+			err:  err,
+		})
+	}
+	return sets, nil
+}
+
+func (p *Procstat) winServicePIDs(name string) ([]PID, error) {
 	var pids []PID
 
-	pid, err := queryPidWithWinServiceName(p.WinService)
+	pid, err := queryPidWithWinServiceName(name)
 	if err != nil {
 		return pids, err
 	}
@@ -54,6 +54,23 @@ ExecMainPID=11408
 		os.Exit(0)
 	}
 
+	if cmdline == "systemctl list-units --all --plain --no-legend TestGather_systemdUnitPattern@*" {
+		fmt.Printf(`TestGather_systemdUnitPattern@a.service loaded active running Test A
+TestGather_systemdUnitPattern@b.service loaded active running Test B
+`)
+		os.Exit(0)
+	}
+	if cmdline == "systemctl show TestGather_systemdUnitPattern@a.service" {
+		fmt.Printf(`MainPID=1001
+`)
+		os.Exit(0)
+	}
+	if cmdline == "systemctl show TestGather_systemdUnitPattern@b.service" {
+		fmt.Printf(`MainPID=1002
+`)
+		os.Exit(0)
+	}
+
 	fmt.Printf("command not found\n")
 	os.Exit(1)
 }
@@ -394,3 +411,146 @@ func TestProcstatLookupMetric(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, len(p.procs)+1, len(acc.Metrics))
 }
+
+func TestGather_systemdUnitPattern(t *testing.T) {
+	p := Procstat{
+		createPIDFinder: pidFinder([]PID{}, nil),
+		SystemdUnit:     "TestGather_systemdUnitPattern@*",
+	}
+	var acc testutil.Accumulator
+	sets, err := p.findPidSets(&acc)
+	require.NoError(t, err)
+	require.Equal(t, []pidsTags{
+		{
+			pids: []PID{1001},
+			tags: map[string]string{"systemd_unit": "TestGather_systemdUnitPattern@a.service"},
+		},
+		{
+			pids: []PID{1002},
+			tags: map[string]string{"systemd_unit": "TestGather_systemdUnitPattern@b.service"},
+		},
+	}, sets)
+}
+
+func TestGather_cgroupPattern(t *testing.T) {
+	//no cgroups in windows
+	if runtime.GOOS == "windows" {
+		t.Skip("no cgroups in windows")
+	}
+	td, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+	for name, procs := range map[string]string{"a.service": "1234\n", "b.service": "5678\n"} {
+		require.NoError(t, os.Mkdir(filepath.Join(td, name), 0755))
+		err = ioutil.WriteFile(filepath.Join(td, name, "cgroup.procs"), []byte(procs), 0644)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(td, "c.scope"), 0755))
+
+	p := Procstat{
+		PidFinder:       "pgrep",
+		createPIDFinder: pidFinder([]PID{}, nil),
+		createProcess:   newTestProc,
+		CGroup:          filepath.Join(td, "*.service"),
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	for _, tc := range []struct {
+		cgroup string
+		pids   int
+	}{
+		{filepath.Join(td, "a.service"), 1},
+		{filepath.Join(td, "b.service"), 1},
+	} {
+		acc.AssertContainsTaggedFields(t, "procstat_lookup",
+			map[string]interface{}{
+				"pid_count":   tc.pids,
+				"running":     tc.pids,
+				"result_code": 0,
+			},
+			map[string]string{
+				"cgroup":     tc.cgroup,
+				"pid_finder": "pgrep",
+				"result":     "success",
+			})
+	}
+}
+
+type childTestProc struct {
+	*testProc
+}
+
+func newChildTestProc(pid PID) (Process, error) {
+	return &childTestProc{
+		testProc: &testProc{pid: pid, tags: make(map[string]string)},
+	}, nil
+}
+
+func (p *childTestProc) NumThreads() (int32, error) {
+	return 2, nil
+}
+
+func (p *childTestProc) MemoryInfo() (*process.MemoryInfoStat, error) {
+	return &process.MemoryInfoStat{RSS: 1024}, nil
+}
+
+func (p *childTestProc) NumCtxSwitches() (*process.NumCtxSwitchesStat, error) {
+	return &process.NumCtxSwitchesStat{Voluntary: 3, Involuntary: 4}, nil
+}
+
+func (p *childTestProc) RlimitUsage(gatherUsage bool) ([]process.RlimitStat, error) {
+	return []process.RlimitStat{
+		{Resource: process.RLIMIT_NICE, Soft: 20, Hard: 20, Used: 10},
+		{Resource: process.RLIMIT_RTPRIO, Soft: 5, Hard: 5, Used: 1},
+	}, nil
+}
+
+func TestGather_AggregateChildren(t *testing.T) {
+	p := Procstat{
+		Pattern:           "foo",
+		PidTag:            true,
+		AggregateChildren: true,
+		createPIDFinder:   pidFinder([]PID{42, 50}, nil),
+		createProcess:     newChildTestProc,
+		processTree: func() (map[PID][]PID, error) {
+			return map[PID][]PID{
+				1:  {42, 50},
+				42: {43, 45},
+				43: {44},
+				50: {51},
+				51: {42},
+			}, nil
+		},
+	}
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	for _, tc := range []struct {
+		pid      string
+		children int
+	}{
+		{"42", 3},
+		// The monitored process 42 is not aggregated into process 50.
+		{"50", 1},
+	} {
+		var found bool
+		for _, m := range acc.Metrics {
+			if m.Measurement != "procstat" || m.Tags["pid"] != tc.pid {
+				continue
+			}
+			found = true
+			require.Equal(t, tc.children, m.Fields["num_children"])
+			require.Equal(t, int32(2*(tc.children+1)), m.Fields["num_threads"])
+			require.Equal(t, uint64(1024*(tc.children+1)), m.Fields["memory_rss"])
+			require.Equal(t, int64(3*(tc.children+1)), m.Fields["voluntary_context_switches"])
+			require.Equal(t, int64(4*(tc.children+1)), m.Fields["involuntary_context_switches"])
+
+			// The priorities and the limits are of the process alone.
+			require.Equal(t, uint64(10), m.Fields["nice_priority"])
+			require.Equal(t, uint64(1), m.Fields["realtime_priority"])
+			require.Equal(t, int32(20), m.Fields["rlimit_nice_priority_soft"])
+		}
+		require.True(t, found, "missing metric for pid %s", tc.pid)
+	}
+}
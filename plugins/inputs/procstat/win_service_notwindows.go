@@ -9,3 +9,7 @@ import (
 func queryPidWithWinServiceName(winServiceName string) (uint32, error) {
 	return 0, fmt.Errorf("os not support win_service option")
 }
+
+func queryWinServiceNames() ([]string, error) {
+	return nil, fmt.Errorf("os not support win_service option")
+}
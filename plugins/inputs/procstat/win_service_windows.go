@@ -24,6 +24,16 @@ func getService(name string) (*mgr.Service, error) {
 	return srv, nil
 }
 
+func queryWinServiceNames() ([]string, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Disconnect()
+
+	return m.ListServices()
+}
+
 func queryPidWithWinServiceName(winServiceName string) (uint32, error) {
 	srv, err := getService(winServiceName)
 	if err != nil {
@@ -14,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -37,6 +38,7 @@ type Prometheus struct {
 	ResponseTimeout internal.Duration `toml:"response_timeout"`
 
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client *http.Client
 
@@ -80,6 +82,12 @@ var sampleConfig = `
   # tls_key = /path/to/keyfile
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 func (p *Prometheus) SampleConfig() string {
@@ -195,9 +203,15 @@ func (p *Prometheus) createHTTPClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := p.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig:   tlsCfg,
+			Proxy:             proxy,
 			DisableKeepAlives: true,
 		},
 		Timeout: p.ResponseTimeout.Duration,
@@ -11,6 +11,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -39,6 +40,7 @@ type RabbitMQ struct {
 	Username string
 	Password string
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	ResponseHeaderTimeout internal.Duration `toml:"header_timeout"`
 	ClientTimeout         internal.Duration `toml:"client_timeout"`
@@ -203,6 +205,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## Optional request timeouts
   ##
   ## ResponseHeaderTimeout, if non-zero, specifies the amount of time to wait
@@ -255,9 +263,16 @@ func (r *RabbitMQ) Gather(acc telegraf.Accumulator) error {
 		if err != nil {
 			return err
 		}
+
+		proxy, err := r.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
 		tr := &http.Transport{
 			ResponseHeaderTimeout: r.ResponseHeaderTimeout.Duration,
 			TLSClientConfig:       tlsCfg,
+			Proxy:                 proxy,
 		}
 		r.Client = &http.Client{
 			Transport: tr,
@@ -12,17 +12,26 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 type Raindrops struct {
-	Urls        []string
+	Urls []string
+	httpconfig.ProxyConfig
+
 	http_client *http.Client
 }
 
 var sampleConfig = `
   ## An array of raindrops middleware URI to gather stats.
   urls = ["http://localhost:8080/_raindrops"]
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 func (r *Raindrops) SampleConfig() string {
@@ -34,6 +43,21 @@ func (r *Raindrops) Description() string {
 }
 
 func (r *Raindrops) Gather(acc telegraf.Accumulator) error {
+	if r.http_client == nil {
+		proxy, err := r.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
+		r.http_client = &http.Client{
+			Transport: &http.Transport{
+				ResponseHeaderTimeout: time.Duration(3 * time.Second),
+				Proxy:                 proxy,
+			},
+			Timeout: time.Duration(4 * time.Second),
+		}
+	}
+
 	var wg sync.WaitGroup
 
 	for _, u := range r.Urls {
@@ -177,11 +201,6 @@ func (r *Raindrops) getTags(addr *url.URL) map[string]string {
 
 func init() {
 	inputs.Add("raindrops", func() telegraf.Input {
-		return &Raindrops{http_client: &http.Client{
-			Transport: &http.Transport{
-				ResponseHeaderTimeout: time.Duration(3 * time.Second),
-			},
-			Timeout: time.Duration(4 * time.Second),
-		}}
+		return &Raindrops{}
 	})
 }
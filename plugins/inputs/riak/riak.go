@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -16,17 +17,14 @@ type Riak struct {
 	// Servers is a slice of servers as http addresses (ex. http://127.0.0.1:8098)
 	Servers []string
 
+	httpconfig.ProxyConfig
+
 	client *http.Client
 }
 
-// NewRiak return a new instance of Riak with a default http client
+// NewRiak return a new instance of Riak
 func NewRiak() *Riak {
-	tr := &http.Transport{ResponseHeaderTimeout: time.Duration(3 * time.Second)}
-	client := &http.Client{
-		Transport: tr,
-		Timeout:   time.Duration(4 * time.Second),
-	}
-	return &Riak{client: client}
+	return &Riak{}
 }
 
 // Type riakStats represents the data that is received from Riak
@@ -83,6 +81,12 @@ type riakStats struct {
 const sampleConfig = `
   # Specify a list of one or more riak http servers
   servers = ["http://localhost:8098"]
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 // Returns a sample configuration for the plugin
@@ -102,6 +106,22 @@ func (r *Riak) Gather(acc telegraf.Accumulator) error {
 		r.Servers = []string{"http://127.0.0.1:8098"}
 	}
 
+	if r.client == nil {
+		proxy, err := r.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
+		tr := &http.Transport{
+			ResponseHeaderTimeout: time.Duration(3 * time.Second),
+			Proxy:                 proxy,
+		}
+		r.client = &http.Client{
+			Transport: tr,
+			Timeout:   time.Duration(4 * time.Second),
+		}
+	}
+
 	// Range over all servers, gathering stats. Returns early in case of any error.
 	for _, s := range r.Servers {
 		acc.AddError(r.gatherServer(s, acc))
@@ -13,6 +13,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -33,6 +34,12 @@ var sampleConfig = `
   ## (optional) API version (default: "39.0")
   ##
   # version = "39.0"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 type limit struct {
@@ -51,6 +58,7 @@ type Salesforce struct {
 	ServerURL      *url.URL
 	OrganizationID string
 	Version        string
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -60,15 +68,7 @@ const defaultEnvironment = "production"
 
 // returns a new Salesforce plugin instance
 func NewSalesforce() *Salesforce {
-	tr := &http.Transport{
-		ResponseHeaderTimeout: time.Duration(5 * time.Second),
-	}
-	client := &http.Client{
-		Transport: tr,
-		Timeout:   time.Duration(10 * time.Second),
-	}
 	return &Salesforce{
-		client:      client,
 		Version:     defaultVersion,
 		Environment: defaultEnvironment}
 }
@@ -83,6 +83,22 @@ func (s *Salesforce) Description() string {
 
 // Reads limits values from Salesforce API
 func (s *Salesforce) Gather(acc telegraf.Accumulator) error {
+	if s.client == nil {
+		proxy, err := s.ProxyConfig.Proxy()
+		if err != nil {
+			return err
+		}
+
+		tr := &http.Transport{
+			ResponseHeaderTimeout: time.Duration(5 * time.Second),
+			Proxy:                 proxy,
+		}
+		s.client = &http.Client{
+			Transport: tr,
+			Timeout:   time.Duration(10 * time.Second),
+		}
+	}
+
 	limits, err := s.fetchLimits()
 	if err != nil {
 		return err
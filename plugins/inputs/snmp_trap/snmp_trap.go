@@ -0,0 +1,355 @@
+package snmp_trap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	// snmpTrapOID is the OID of the variable binding holding the OID of
+	// v2c and v3 traps.
+	snmpTrapOID = ".1.3.6.1.6.3.1.1.4.1.0"
+	// snmpTraps is the prefix of the OIDs of the generic traps.
+	snmpTraps = ".1.3.6.1.6.3.1.1.5"
+)
+
+var sampleConfig = `
+  ## Transport, local address, and port to listen on.  Transport must
+  ## be "udp://".  Omit local address to listen on all interfaces.
+  ##   example: "udp://127.0.0.1:1234"
+  # service_address = "udp://:162"
+
+  ## Timeout running snmptranslate command
+  # timeout = "5s"
+
+  ## SNMPv3 parameters of the traps received, v1 and v2c traps are always
+  ## received.
+  # sec_name = "myuser"
+  ## Values: "MD5", "SHA", ""
+  # auth_protocol = "MD5"
+  # auth_password = "pass"
+  ## Values: "noAuthNoPriv", "authNoPriv", "authPriv"
+  # sec_level = "authNoPriv"
+  ## Values: "DES", "AES", ""
+  # priv_protocol = ""
+  # priv_password = ""
+`
+
+type mibEntry struct {
+	mibName string
+	oidText string
+}
+
+type execer func(internal.Duration, string, ...string) ([]byte, error)
+
+type SnmpTrap struct {
+	ServiceAddress string            `toml:"service_address"`
+	Timeout        internal.Duration `toml:"timeout"`
+
+	// Parameters for Version 3
+	SecName      string `toml:"sec_name"`
+	SecLevel     string `toml:"sec_level"`
+	AuthProtocol string `toml:"auth_protocol"`
+	AuthPassword string `toml:"auth_password"`
+	PrivProtocol string `toml:"priv_protocol"`
+	PrivPassword string `toml:"priv_password"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	acc      telegraf.Accumulator
+	listener *gosnmp.TrapListener
+	timeFunc func() time.Time
+	execCmd  execer
+
+	cacheLock sync.Mutex
+	cache     map[string]mibEntry
+}
+
+func (s *SnmpTrap) Description() string {
+	return "Receive SNMP traps"
+}
+
+func (s *SnmpTrap) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SnmpTrap) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (s *SnmpTrap) Start(acc telegraf.Accumulator) error {
+	u, err := url.Parse(s.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("invalid service address: %s", s.ServiceAddress)
+	}
+	if u.Scheme != "udp" {
+		return fmt.Errorf("unsupported transport %q", u.Scheme)
+	}
+
+	params, err := s.params()
+	if err != nil {
+		return err
+	}
+
+	s.acc = acc
+	s.cache = make(map[string]mibEntry)
+
+	listener := gosnmp.NewTrapListener()
+	listener.OnNewTrap = s.handleTrap
+	listener.Params = params
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- listener.Listen(u.Host)
+	}()
+
+	select {
+	case <-listener.Listening():
+		s.listener = listener
+		s.Log.Infof("Listening on %s", s.ServiceAddress)
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// params returns the parameters used to decode the traps, the version of
+// each trap is read from the trap itself.
+func (s *SnmpTrap) params() (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{
+		Version: gosnmp.Version2c,
+		Timeout: s.Timeout.Duration,
+	}
+	if s.SecName == "" {
+		return params, nil
+	}
+
+	params.Version = gosnmp.Version3
+	params.SecurityModel = gosnmp.UserSecurityModel
+
+	switch strings.ToLower(s.SecLevel) {
+	case "noauthnopriv", "":
+		params.MsgFlags = gosnmp.NoAuthNoPriv
+	case "authnopriv":
+		params.MsgFlags = gosnmp.AuthNoPriv
+	case "authpriv":
+		params.MsgFlags = gosnmp.AuthPriv
+	default:
+		return nil, fmt.Errorf("invalid sec_level")
+	}
+
+	sp := &gosnmp.UsmSecurityParameters{
+		UserName:                 s.SecName,
+		AuthenticationPassphrase: s.AuthPassword,
+		PrivacyPassphrase:        s.PrivPassword,
+	}
+
+	switch strings.ToLower(s.AuthProtocol) {
+	case "md5":
+		sp.AuthenticationProtocol = gosnmp.MD5
+	case "sha":
+		sp.AuthenticationProtocol = gosnmp.SHA
+	case "":
+		sp.AuthenticationProtocol = gosnmp.NoAuth
+	default:
+		return nil, fmt.Errorf("invalid auth_protocol")
+	}
+
+	switch strings.ToLower(s.PrivProtocol) {
+	case "des":
+		sp.PrivacyProtocol = gosnmp.DES
+	case "aes":
+		sp.PrivacyProtocol = gosnmp.AES
+	case "":
+		sp.PrivacyProtocol = gosnmp.NoPriv
+	default:
+		return nil, fmt.Errorf("invalid priv_protocol")
+	}
+
+	params.SecurityParameters = sp
+	return params, nil
+}
+
+func (s *SnmpTrap) Stop() {
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+}
+
+func (s *SnmpTrap) handleTrap(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+	tm := s.timeFunc()
+	fields := map[string]interface{}{}
+	tags := map[string]string{
+		"version": packet.Version.String(),
+		"source":  addr.IP.String(),
+	}
+	if packet.Version != gosnmp.Version3 {
+		tags["community"] = packet.Community
+	}
+
+	if packet.Version == gosnmp.Version1 {
+		// Use the OID of the v2c trap equivalent to the v1 trap, see RFC 3584.
+		var trapOID string
+		if packet.GenericTrap >= 0 && packet.GenericTrap < 6 {
+			trapOID = snmpTraps + "." + strconv.Itoa(packet.GenericTrap+1)
+		} else if packet.GenericTrap == 6 {
+			trapOID = packet.Enterprise + ".0." + strconv.Itoa(packet.SpecificTrap)
+		}
+
+		if trapOID != "" {
+			if err := s.setTrapOID(tags, trapOID); err != nil {
+				s.acc.AddError(err)
+				return
+			}
+		}
+		if packet.AgentAddress != "" {
+			tags["agent_address"] = packet.AgentAddress
+		}
+		fields["sysUpTimeInstance"] = uint64(packet.Timestamp)
+	}
+
+	for _, v := range packet.Variables {
+		if v.Name == snmpTrapOID {
+			if oid, ok := v.Value.(string); ok {
+				if err := s.setTrapOID(tags, oid); err != nil {
+					s.acc.AddError(err)
+					return
+				}
+			}
+			continue
+		}
+
+		value := convert(v.Value)
+		if value == nil {
+			continue
+		}
+		if v.Type == gosnmp.ObjectIdentifier {
+			e, err := s.lookup(value.(string))
+			if err != nil {
+				s.acc.AddError(err)
+				return
+			}
+			value = e.oidText
+		}
+
+		e, err := s.lookup(v.Name)
+		if err != nil {
+			s.acc.AddError(err)
+			return
+		}
+		fields[e.oidText] = value
+	}
+
+	s.acc.AddFields("snmp_trap", fields, tags, tm)
+}
+
+func (s *SnmpTrap) setTrapOID(tags map[string]string, oid string) error {
+	e, err := s.lookup(oid)
+	if err != nil {
+		return err
+	}
+	tags["oid"] = oid
+	tags["name"] = e.oidText
+	if e.mibName != "" {
+		tags["mib"] = e.mibName
+	}
+	return nil
+}
+
+// convert returns the value as a type supported by metrics.
+func convert(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case int:
+		return int64(v)
+	case uint:
+		return uint64(v)
+	case uint64:
+		return v
+	default:
+		return nil
+	}
+}
+
+// lookup resolves the OID with snmptranslate using the MIBs installed, the
+// result is cached.
+func (s *SnmpTrap) lookup(oid string) (mibEntry, error) {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+
+	if e, ok := s.cache[oid]; ok {
+		return e, nil
+	}
+
+	e, err := s.snmptranslate(oid)
+	if err != nil {
+		return e, err
+	}
+	s.cache[oid] = e
+	return e, nil
+}
+
+func (s *SnmpTrap) snmptranslate(oid string) (mibEntry, error) {
+	e := mibEntry{oidText: oid}
+
+	out, err := s.execCmd(s.Timeout, "snmptranslate", "-Td", "-Ob", "-m", "all", oid)
+	if err, ok := err.(*exec.Error); ok && err.Err == exec.ErrNotFound {
+		// Without snmptranslate the numeric OID is used.
+		return e, nil
+	}
+	if err != nil {
+		return e, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(out))
+	if !scanner.Scan() {
+		return e, scanner.Err()
+	}
+
+	// The OID was not found in the MIBs otherwise.
+	line := scanner.Text()
+	if i := strings.Index(line, "::"); i != -1 {
+		e.mibName = line[:i]
+		e.oidText = line[i+2:]
+	}
+	return e, nil
+}
+
+func realExecCmd(Timeout internal.Duration, arg0 string, args ...string) ([]byte, error) {
+	cmd := exec.Command(arg0, args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := internal.RunTimeout(cmd, Timeout.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func init() {
+	inputs.Add("snmp_trap", func() telegraf.Input {
+		return &SnmpTrap{
+			timeFunc:       time.Now,
+			ServiceAddress: "udp://:162",
+			Timeout:        internal.Duration{Duration: 5 * time.Second},
+			execCmd:        realExecCmd,
+		}
+	})
+}
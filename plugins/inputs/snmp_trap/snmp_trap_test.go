@@ -0,0 +1,214 @@
+package snmp_trap
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/require"
+)
+
+var translations = map[string]string{
+	".1.3.6.1.2.1.1.3.0":       "DISMAN-EVENT-MIB::sysUpTimeInstance",
+	".1.3.6.1.6.3.1.1.5.1":     "SNMPv2-MIB::coldStart",
+	".1.3.6.1.6.3.1.1.5.3":     "IF-MIB::linkDown",
+	".1.3.6.1.2.1.2.2.1.1.2":   "IF-MIB::ifIndex.2",
+	".1.3.6.1.2.1.2.2.1.2.2":   "IF-MIB::ifDescr.2",
+	".1.3.6.1.4.1.8072.3.2.10": "NET-SNMP-TC::linux",
+}
+
+func fakeExecCmd(_ internal.Duration, arg0 string, args ...string) ([]byte, error) {
+	oid := args[len(args)-1]
+	if text, ok := translations[oid]; ok {
+		return []byte(text + "\nsome description\n"), nil
+	}
+	return nil, &exec.Error{Name: arg0, Err: exec.ErrNotFound}
+}
+
+func freePort(t *testing.T) uint16 {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func newSnmpTrap(port uint16) *SnmpTrap {
+	return &SnmpTrap{
+		ServiceAddress: "udp://127.0.0.1:" + strconv.Itoa(int(port)),
+		Timeout:        internal.Duration{Duration: time.Second},
+		Log:            testutil.Logger{},
+		timeFunc: func() time.Time {
+			return time.Unix(1569931200, 0)
+		},
+		execCmd: fakeExecCmd,
+	}
+}
+
+func sendTrap(t *testing.T, port uint16, version gosnmp.SnmpVersion, trap gosnmp.SnmpTrap) {
+	s := &gosnmp.GoSNMP{
+		Target:    "127.0.0.1",
+		Port:      port,
+		Community: "public",
+		Version:   version,
+		Timeout:   time.Second,
+		Retries:   1,
+	}
+	require.NoError(t, s.Connect())
+	defer s.Conn.Close()
+
+	_, err := s.SendTrap(trap)
+	require.NoError(t, err)
+}
+
+func TestReceiveTrapV2c(t *testing.T) {
+	port := freePort(t)
+	s := newSnmpTrap(port)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	sendTrap(t, port, gosnmp.Version2c, gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(4242)},
+			{Name: snmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+			{Name: ".1.3.6.1.2.1.2.2.1.1.2", Type: gosnmp.Integer, Value: 2},
+			{Name: ".1.3.6.1.2.1.2.2.1.2.2", Type: gosnmp.OctetString, Value: "eth0"},
+			{Name: ".1.3.6.1.4.1.2021.99", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.8072.3.2.10"},
+		},
+	})
+	acc.Wait(1)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("snmp_trap",
+			map[string]string{
+				"version":   "2c",
+				"source":    "127.0.0.1",
+				"community": "public",
+				"oid":       ".1.3.6.1.6.3.1.1.5.3",
+				"name":      "linkDown",
+				"mib":       "IF-MIB",
+			},
+			map[string]interface{}{
+				"sysUpTimeInstance":    uint64(4242),
+				"ifIndex.2":            int64(2),
+				"ifDescr.2":            "eth0",
+				".1.3.6.1.4.1.2021.99": "linux",
+			},
+			time.Unix(1569931200, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestReceiveTrapV1(t *testing.T) {
+	port := freePort(t)
+	s := newSnmpTrap(port)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	sendTrap(t, port, gosnmp.Version1, gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.2.2.1.1.2", Type: gosnmp.Integer, Value: 2},
+		},
+		Enterprise:   ".1.3.6.1.4.1.8072.3.2.10",
+		AgentAddress: "10.0.0.1",
+		GenericTrap:  0,
+		Timestamp:    4242,
+	})
+	acc.Wait(1)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("snmp_trap",
+			map[string]string{
+				"version":       "1",
+				"source":        "127.0.0.1",
+				"community":     "public",
+				"agent_address": "10.0.0.1",
+				"oid":           ".1.3.6.1.6.3.1.1.5.1",
+				"name":          "coldStart",
+				"mib":           "SNMPv2-MIB",
+			},
+			map[string]interface{}{
+				"sysUpTimeInstance": uint64(4242),
+				"ifIndex.2":         int64(2),
+			},
+			time.Unix(1569931200, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestEnterpriseSpecificTrapOID(t *testing.T) {
+	s := newSnmpTrap(0)
+	s.cache = make(map[string]mibEntry)
+
+	var acc testutil.Accumulator
+	s.acc = &acc
+	s.handleTrap(&gosnmp.SnmpPacket{
+		Version:   gosnmp.Version1,
+		Community: "public",
+		SnmpTrap: gosnmp.SnmpTrap{
+			Enterprise:   ".1.3.6.1.4.1.2021",
+			GenericTrap:  6,
+			SpecificTrap: 3,
+		},
+	}, &net.UDPAddr{IP: net.ParseIP("192.168.0.1")})
+
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, ".1.3.6.1.4.1.2021.0.3", acc.Metrics[0].Tags["oid"])
+	// Without a MIB the numeric OID is the name.
+	require.Equal(t, ".1.3.6.1.4.1.2021.0.3", acc.Metrics[0].Tags["name"])
+	require.NotContains(t, acc.Metrics[0].Tags, "mib")
+}
+
+func TestLookupCached(t *testing.T) {
+	var calls int
+	s := newSnmpTrap(0)
+	s.cache = make(map[string]mibEntry)
+	s.execCmd = func(d internal.Duration, arg0 string, args ...string) ([]byte, error) {
+		calls++
+		return fakeExecCmd(d, arg0, args...)
+	}
+
+	for i := 0; i < 2; i++ {
+		e, err := s.lookup(".1.3.6.1.6.3.1.1.5.1")
+		require.NoError(t, err)
+		require.Equal(t, mibEntry{mibName: "SNMPv2-MIB", oidText: "coldStart"}, e)
+	}
+	require.Equal(t, 1, calls)
+}
+
+func TestLookupError(t *testing.T) {
+	s := newSnmpTrap(0)
+	s.cache = make(map[string]mibEntry)
+	s.execCmd = func(internal.Duration, string, ...string) ([]byte, error) {
+		return nil, fmt.Errorf("timeout")
+	}
+
+	_, err := s.lookup(".1.3.6.1.6.3.1.1.5.1")
+	require.Error(t, err)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	var acc testutil.Accumulator
+
+	s := newSnmpTrap(0)
+	s.ServiceAddress = "tcp://127.0.0.1:162"
+	require.Error(t, s.Start(&acc))
+
+	s = newSnmpTrap(0)
+	s.SecName = "myuser"
+	s.AuthProtocol = "SHA512"
+	require.Error(t, s.Start(&acc))
+}
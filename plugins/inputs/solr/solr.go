@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -28,6 +29,12 @@ const sampleConfig = `
 
   ## specify a list of one or more Solr cores (default - all)
   # cores = ["main"]
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 // Solr is a plugin to read stats from one or many Solr servers
@@ -36,7 +43,9 @@ type Solr struct {
 	Servers     []string
 	HTTPTimeout internal.Duration
 	Cores       []string
-	client      *http.Client
+	httpconfig.ProxyConfig
+
+	client *http.Client
 }
 
 // AdminCoresStatus is an exported type that
@@ -137,7 +146,10 @@ func (s *Solr) Description() string {
 // Accumulator.
 func (s *Solr) Gather(acc telegraf.Accumulator) error {
 	if s.client == nil {
-		client := s.createHTTPClient()
+		client, err := s.createHTTPClient()
+		if err != nil {
+			return err
+		}
 		s.client = client
 	}
 
@@ -458,16 +470,22 @@ func (s *Solr) mbeansURL(server string, core string) string {
 	return fmt.Sprintf("%s/solr/%s%s", server, core, mbeansPath)
 }
 
-func (s *Solr) createHTTPClient() *http.Client {
+func (s *Solr) createHTTPClient() (*http.Client, error) {
+	proxy, err := s.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	tr := &http.Transport{
 		ResponseHeaderTimeout: s.HTTPTimeout.Duration,
+		Proxy:                 proxy,
 	}
 	client := &http.Client{
 		Transport: tr,
 		Timeout:   s.HTTPTimeout.Duration,
 	}
 
-	return client
+	return client, nil
 }
 
 func (s *Solr) gatherData(url string, v interface{}) error {
@@ -0,0 +1,7 @@
+// +build windows
+
+package sql
+
+// The ODBC driver is only included on Windows, where it uses the driver
+// manager of the system, elsewhere it requires cgo and unixODBC.
+import _ "github.com/alexbrainman/odbc" // odbc initialization
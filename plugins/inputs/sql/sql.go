@@ -0,0 +1,542 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/ClickHouse/clickhouse-go" // clickhouse initialization
+	_ "github.com/denisenkom/go-mssqldb"    // go-mssqldb initialization
+	_ "github.com/go-sql-driver/mysql"      // mysql initialization
+	_ "github.com/jackc/pgx/stdlib"         // pgx initialization
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## Database driver
+  ## Valid options: clickhouse (ClickHouse), mssql (Microsoft SQL Server),
+  ##                mysql (MySQL), odbc (ODBC, ie: Oracle, Windows only),
+  ##                postgres or pgx (Postgres), sqlserver (Microsoft SQL Server)
+  ## Any other driver registered with the Go database/sql package can be used
+  ## by its name.
+  driver = "mysql"
+
+  ## Data source name for connecting
+  ## The syntax and supported options depends on selected driver.
+  dsn = "username:password@tcp(mysqlserver:3307)/dbname?param=value"
+
+  ## Timeout for any operation
+  # timeout = "5s"
+
+  ## Connection time limit
+  ## By default the maximum lifetime of a connection is unlimited, i.e. the
+  ## connections will not be closed automatically. If you specify a positive
+  ## time, the connections will be closed after existing for at least that
+  ## amount of time.
+  # connection_max_life_time = "0s"
+
+  ## Connection count limits
+  ## By default the number of open connections is not limited and the number
+  ## of maximum idle connections is inferred from the number of queries
+  ## specified. If you specify a positive number for any of the two options,
+  ## connections will be closed when reaching the specified limit. The number
+  ## of idle connections will be clipped to the maximum number of connections
+  ## limit if any.
+  # connection_max_open = 0
+  # connection_max_idle = auto
+
+  [[inputs.sql.query]]
+    ## Query to perform on the server
+    query="SELECT user,state,latency,score FROM Scoreboard WHERE application > 0"
+
+    ## Name of the measurement
+    ## In case both measurement and 'measurement_column' are given, the latter
+    ## takes precedence.
+    # measurement = "sql"
+
+    ## Column name containing the name of the measurement
+    ## If given, this will take precedence over the 'measurement' setting. In
+    ## case a query result does not contain the specified column, we fall-back
+    ## to the 'measurement' setting.
+    # measurement_column = ""
+
+    ## Column name containing the time of the measurement
+    ## If omitted, the time of the query will be used.
+    # time_column = ""
+
+    ## Format of the time contained in 'time_column'
+    ## The time must be 'unix', 'unix_ms', 'unix_us', 'unix_ns', or a golang
+    ## time format.  See https://golang.org/pkg/time/#Time.Format for details.
+    ## Native time types of the database are used as is.
+    # time_format = "unix"
+
+    ## Column names containing tags
+    ## An empty include list will reject all columns and an empty exclude list
+    ## will not exclude any column. I.e. by default no columns will be
+    ## returned as tag and the tags are empty.
+    # tag_columns_include = []
+    # tag_columns_exclude = []
+
+    ## Column names containing fields (explicit types)
+    ## Convert the given columns to the corresponding type. Explicit type
+    ## conversions take precedence over the automatic (driver-based)
+    ## conversion below.
+    ## NOTE: Columns should not be specified for multiple types or the
+    ## resulting type is undefined.
+    # field_columns_float = []
+    # field_columns_int = []
+    # field_columns_uint = []
+    # field_columns_bool = []
+    # field_columns_string = []
+
+    ## Column names containing fields (automatic types)
+    ## An empty include list is equivalent to '[*]' and all returned columns
+    ## will be accepted. An empty exclude list will not exclude any column.
+    ## I.e. by default all columns will be returned as fields.
+    ## NOTE: We rely on the database driver to perform automatic datatype
+    ## conversion.
+    # field_columns_include = []
+    # field_columns_exclude = []
+`
+
+// driverAliases maps the driver names of the configuration to the names the
+// drivers are registered with.
+var driverAliases = map[string]string{
+	"postgres":   "pgx",
+	"postgresql": "pgx",
+}
+
+// Query is a query run on the database and the mapping of its columns.
+type Query struct {
+	Query               string   `toml:"query"`
+	Measurement         string   `toml:"measurement"`
+	MeasurementColumn   string   `toml:"measurement_column"`
+	TimeColumn          string   `toml:"time_column"`
+	TimeFormat          string   `toml:"time_format"`
+	TagColumnsInclude   []string `toml:"tag_columns_include"`
+	TagColumnsExclude   []string `toml:"tag_columns_exclude"`
+	FieldColumnsInclude []string `toml:"field_columns_include"`
+	FieldColumnsExclude []string `toml:"field_columns_exclude"`
+	FieldColumnsFloat   []string `toml:"field_columns_float"`
+	FieldColumnsInt     []string `toml:"field_columns_int"`
+	FieldColumnsUint    []string `toml:"field_columns_uint"`
+	FieldColumnsBool    []string `toml:"field_columns_bool"`
+	FieldColumnsString  []string `toml:"field_columns_string"`
+
+	tagFilter         filter.Filter
+	fieldFilter       filter.Filter
+	fieldFilterFloat  filter.Filter
+	fieldFilterInt    filter.Filter
+	fieldFilterUint   filter.Filter
+	fieldFilterBool   filter.Filter
+	fieldFilterString filter.Filter
+}
+
+// SQL is a telegraf plugin to gather metrics with queries on any database
+// with a Go database/sql driver.
+type SQL struct {
+	Driver             string            `toml:"driver"`
+	Dsn                string            `toml:"dsn"`
+	Timeout            internal.Duration `toml:"timeout"`
+	MaxLifetime        internal.Duration `toml:"connection_max_life_time"`
+	MaxOpenConnections int               `toml:"connection_max_open"`
+	MaxIdleConnections int               `toml:"connection_max_idle"`
+	Queries            []Query           `toml:"query"`
+
+	driverName string
+	db         *dbsql.DB
+}
+
+// SampleConfig returns the sample configuration
+func (s *SQL) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns the plugin description
+func (s *SQL) Description() string {
+	return "Read metrics from SQL queries"
+}
+
+func (s *SQL) init() error {
+	if s.Dsn == "" {
+		return fmt.Errorf("missing data source name (DSN) option")
+	}
+	if len(s.Queries) == 0 {
+		return fmt.Errorf("no queries configured")
+	}
+
+	s.driverName = s.Driver
+	if name, ok := driverAliases[s.Driver]; ok {
+		s.driverName = name
+	}
+	if !isRegistered(s.driverName) {
+		return fmt.Errorf("unknown driver %q, available drivers: %s",
+			s.Driver, strings.Join(dbsql.Drivers(), ", "))
+	}
+
+	for i := range s.Queries {
+		q := &s.Queries[i]
+		if q.Query == "" {
+			return fmt.Errorf("query %d: missing query", i+1)
+		}
+		if q.Measurement == "" {
+			q.Measurement = "sql"
+		}
+		if q.TimeFormat == "" {
+			q.TimeFormat = "unix"
+		}
+
+		var err error
+		// An empty include list rejects all columns as tags.
+		if len(q.TagColumnsInclude) > 0 {
+			if q.tagFilter, err = filter.NewIncludeExcludeFilter(q.TagColumnsInclude, q.TagColumnsExclude); err != nil {
+				return fmt.Errorf("query %d: creating tag filter failed: %v", i+1, err)
+			}
+		}
+		if q.fieldFilter, err = filter.NewIncludeExcludeFilter(q.FieldColumnsInclude, q.FieldColumnsExclude); err != nil {
+			return fmt.Errorf("query %d: creating field filter failed: %v", i+1, err)
+		}
+		if q.fieldFilterFloat, err = filter.Compile(q.FieldColumnsFloat); err != nil {
+			return fmt.Errorf("query %d: creating float field filter failed: %v", i+1, err)
+		}
+		if q.fieldFilterInt, err = filter.Compile(q.FieldColumnsInt); err != nil {
+			return fmt.Errorf("query %d: creating int field filter failed: %v", i+1, err)
+		}
+		if q.fieldFilterUint, err = filter.Compile(q.FieldColumnsUint); err != nil {
+			return fmt.Errorf("query %d: creating uint field filter failed: %v", i+1, err)
+		}
+		if q.fieldFilterBool, err = filter.Compile(q.FieldColumnsBool); err != nil {
+			return fmt.Errorf("query %d: creating bool field filter failed: %v", i+1, err)
+		}
+		if q.fieldFilterString, err = filter.Compile(q.FieldColumnsString); err != nil {
+			return fmt.Errorf("query %d: creating string field filter failed: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func isRegistered(driver string) bool {
+	drivers := dbsql.Drivers()
+	i := sort.SearchStrings(drivers, driver)
+	return i < len(drivers) && drivers[i] == driver
+}
+
+// Start checks the configuration and creates the connection pool, the
+// connections are opened when the queries are run.
+func (s *SQL) Start(_ telegraf.Accumulator) error {
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	db, err := dbsql.Open(s.driverName, s.Dsn)
+	if err != nil {
+		return err
+	}
+
+	// By default keep enough idle connections to run all queries in parallel.
+	maxIdle := s.MaxIdleConnections
+	if maxIdle == 0 {
+		maxIdle = len(s.Queries)
+	}
+	if s.MaxOpenConnections > 0 && maxIdle > s.MaxOpenConnections {
+		maxIdle = s.MaxOpenConnections
+	}
+	db.SetMaxOpenConns(s.MaxOpenConnections)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(s.MaxLifetime.Duration)
+
+	s.db = db
+	return nil
+}
+
+// Stop closes the connections to the database
+func (s *SQL) Stop() {
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+	}
+}
+
+// Gather runs the queries
+func (s *SQL) Gather(acc telegraf.Accumulator) error {
+	return s.GatherContext(context.Background(), acc)
+}
+
+// GatherContext runs the queries in parallel, running queries are cancelled
+// when the context is done.
+func (s *SQL) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+
+	for i := range s.Queries {
+		wg.Add(1)
+		go func(q *Query) {
+			defer wg.Done()
+
+			qctx := ctx
+			if s.Timeout.Duration > 0 {
+				var cancel context.CancelFunc
+				qctx, cancel = context.WithTimeout(ctx, s.Timeout.Duration)
+				defer cancel()
+			}
+			if err := s.executeQuery(qctx, acc, q, time.Now()); err != nil {
+				acc.AddError(fmt.Errorf("query %q: %v", q.Query, err))
+			}
+		}(&s.Queries[i])
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (s *SQL) executeQuery(ctx context.Context, acc telegraf.Accumulator, q *Query, tquery time.Time) error {
+	rows, err := s.db.QueryContext(ctx, q.Query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		// store the column values with their *interface{}
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return err
+		}
+
+		measurement, tags, fields, tm, err := q.parse(columns, values, tquery)
+		if err != nil {
+			return err
+		}
+		acc.AddFields(measurement, fields, tags, tm)
+	}
+	return rows.Err()
+}
+
+// parse maps the columns of a row to the measurement, tags, fields and time
+// of a metric.
+func (q *Query) parse(columns []string, values []interface{}, tquery time.Time) (string, map[string]string, map[string]interface{}, time.Time, error) {
+	measurement := q.Measurement
+	tm := tquery
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+
+	for i, name := range columns {
+		value := values[i]
+		// NULL values are skipped
+		if value == nil {
+			continue
+		}
+
+		if name == q.MeasurementColumn {
+			switch v := value.(type) {
+			case string:
+				measurement = v
+			case []byte:
+				measurement = string(v)
+			default:
+				return "", nil, nil, tm, fmt.Errorf("measurement column %q has type %T", name, value)
+			}
+			continue
+		}
+
+		if name == q.TimeColumn {
+			t, err := parseTime(value, q.TimeFormat)
+			if err != nil {
+				return "", nil, nil, tm, fmt.Errorf("time column %q: %v", name, err)
+			}
+			tm = t
+			continue
+		}
+
+		if q.tagFilter != nil && q.tagFilter.Match(name) {
+			tag, err := toString(value)
+			if err != nil {
+				return "", nil, nil, tm, fmt.Errorf("tag column %q: %v", name, err)
+			}
+			tags[name] = tag
+			continue
+		}
+
+		var field interface{}
+		var err error
+		switch {
+		case q.fieldFilterFloat != nil && q.fieldFilterFloat.Match(name):
+			field, err = toFloat(value)
+		case q.fieldFilterInt != nil && q.fieldFilterInt.Match(name):
+			field, err = toInt(value)
+		case q.fieldFilterUint != nil && q.fieldFilterUint.Match(name):
+			field, err = toUint(value)
+		case q.fieldFilterBool != nil && q.fieldFilterBool.Match(name):
+			field, err = toBool(value)
+		case q.fieldFilterString != nil && q.fieldFilterString.Match(name):
+			field, err = toString(value)
+		case q.fieldFilter.Match(name):
+			field = convert(value)
+		default:
+			continue
+		}
+		if err != nil {
+			return "", nil, nil, tm, fmt.Errorf("field column %q: %v", name, err)
+		}
+		fields[name] = field
+	}
+	return measurement, tags, fields, tm, nil
+}
+
+func parseTime(value interface{}, format string) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		return internal.ParseTimestamp(string(v), format)
+	case string:
+		return internal.ParseTimestamp(v, format)
+	case int64:
+		return internal.ParseTimestamp(strconv.FormatInt(v, 10), format)
+	case float64:
+		return internal.ParseTimestamp(v, format)
+	default:
+		return time.Time{}, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// convert returns the value of a column as a type supported by metrics, the
+// drivers return integers as int64, floats as float64, and text or decimals
+// as []byte or string.
+func convert(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UnixNano()
+	default:
+		return v
+	}
+}
+
+func toString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	default:
+		return "", fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func toInt(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func toUint(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return 0, fmt.Errorf("value %d is negative", v)
+		}
+		return uint64(v), nil
+	case float64:
+		if v < 0 {
+			return 0, fmt.Errorf("value %v is negative", v)
+		}
+		return uint64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case []byte:
+		return strconv.ParseUint(string(v), 10, 64)
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case []byte:
+		return strconv.ParseBool(string(v))
+	case string:
+		return strconv.ParseBool(v)
+	default:
+		return false, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func init() {
+	inputs.Add("sql", func() telegraf.Input {
+		return &SQL{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,313 @@
+package sql
+
+import (
+	dbsql "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// result is the result of a query of the fake driver.
+type result struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeDriver returns the results registered for the queries, the data source
+// name is ignored.
+type fakeDriver struct {
+	results map[string]result
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	r, ok := c.d.results[query]
+	if !ok {
+		return nil, fmt.Errorf("syntax error in %q", query)
+	}
+	return &fakeStmt{r}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+type fakeStmt struct {
+	r result
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return 0
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("exec is not supported")
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{r: s.r}, nil
+}
+
+type fakeRows struct {
+	r result
+	i int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.r.rows[r.i])
+	r.i++
+	return nil
+}
+
+var testDriver = &fakeDriver{
+	results: map[string]result{
+		"SELECT * FROM scoreboard": {
+			columns: []string{"user", "state", "latency", "score", "active", "created"},
+			rows: [][]driver.Value{
+				{[]byte("alice"), "running", 1.5, int64(42), true, time.Unix(1569931200, 0)},
+				{[]byte("bob"), nil, 2.5, int64(7), false, time.Unix(1569931201, 0)},
+			},
+		},
+		"SELECT * FROM events": {
+			columns: []string{"name", "host", "value", "ts"},
+			rows: [][]driver.Value{
+				{"cpu", "server01", []byte("12.5"), int64(1569931200000)},
+			},
+		},
+	},
+}
+
+func init() {
+	dbsql.Register("sqltest", testDriver)
+}
+
+func TestSQL(t *testing.T) {
+	s := &SQL{
+		Driver:  "sqltest",
+		Dsn:     "test",
+		Timeout: internal.Duration{Duration: time.Second},
+		Queries: []Query{
+			{
+				Query:             "SELECT * FROM scoreboard",
+				Measurement:       "scoreboard",
+				TimeColumn:        "created",
+				TagColumnsInclude: []string{"user", "state"},
+			},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+	require.NoError(t, s.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("scoreboard",
+			map[string]string{
+				"user":  "alice",
+				"state": "running",
+			},
+			map[string]interface{}{
+				"latency": 1.5,
+				"score":   int64(42),
+				"active":  true,
+			},
+			time.Unix(1569931200, 0),
+		),
+		testutil.MustMetric("scoreboard",
+			map[string]string{
+				"user": "bob",
+			},
+			map[string]interface{}{
+				"latency": 2.5,
+				"score":   int64(7),
+				"active":  false,
+			},
+			time.Unix(1569931201, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestSQLColumnMapping(t *testing.T) {
+	s := &SQL{
+		Driver: "sqltest",
+		Dsn:    "test",
+		Queries: []Query{
+			{
+				Query:               "SELECT * FROM events",
+				MeasurementColumn:   "name",
+				TimeColumn:          "ts",
+				TimeFormat:          "unix_ms",
+				TagColumnsInclude:   []string{"*"},
+				TagColumnsExclude:   []string{"value"},
+				FieldColumnsFloat:   []string{"value"},
+				FieldColumnsExclude: []string{"host"},
+			},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+	require.NoError(t, s.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{
+				"host": "server01",
+			},
+			map[string]interface{}{
+				"value": 12.5,
+			},
+			time.Unix(1569931200, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestSQLFieldFilter(t *testing.T) {
+	s := &SQL{
+		Driver: "sqltest",
+		Dsn:    "test",
+		Queries: []Query{
+			{
+				Query:               "SELECT * FROM scoreboard",
+				TagColumnsInclude:   []string{"user"},
+				FieldColumnsInclude: []string{"score"},
+				FieldColumnsString:  []string{"latency"},
+			},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+	require.NoError(t, s.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "sql",
+		map[string]interface{}{
+			"score":   int64(42),
+			"latency": "1.5",
+		},
+		map[string]string{
+			"user": "alice",
+		})
+}
+
+func TestSQLQueryError(t *testing.T) {
+	s := &SQL{
+		Driver: "sqltest",
+		Dsn:    "test",
+		Queries: []Query{
+			{Query: "SELECT * FROM scoreboard"},
+			{Query: "SELECT * FROM missing"},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+	require.NoError(t, s.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Len(t, acc.Metrics, 2)
+}
+
+func TestSQLInvalidConfig(t *testing.T) {
+	var acc testutil.Accumulator
+
+	s := &SQL{
+		Driver:  "unknown",
+		Dsn:     "test",
+		Queries: []Query{{Query: "SELECT 1"}},
+	}
+	require.Error(t, s.Start(&acc))
+
+	s = &SQL{
+		Driver: "sqltest",
+		Dsn:    "test",
+	}
+	require.Error(t, s.Start(&acc))
+
+	s = &SQL{
+		Driver:  "sqltest",
+		Queries: []Query{{Query: "SELECT 1"}},
+	}
+	require.Error(t, s.Start(&acc))
+}
+
+func TestDriverAliases(t *testing.T) {
+	s := &SQL{
+		Driver:  "postgres",
+		Dsn:     "host=localhost",
+		Queries: []Query{{Query: "SELECT 1"}},
+	}
+	require.NoError(t, s.init())
+	require.Equal(t, "pgx", s.driverName)
+}
+
+func TestDriverClickHouse(t *testing.T) {
+	s := &SQL{
+		Driver:  "clickhouse",
+		Dsn:     "tcp://localhost:9000",
+		Queries: []Query{{Query: "SELECT 1"}},
+	}
+	require.NoError(t, s.init())
+	require.Equal(t, "clickhouse", s.driverName)
+}
+
+func TestConvertTypes(t *testing.T) {
+	v, err := toUint([]byte("42"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), v)
+
+	_, err = toUint(int64(-1))
+	require.Error(t, err)
+
+	b, err := toBool(int64(1))
+	require.NoError(t, err)
+	require.True(t, b)
+
+	i, err := toInt("12")
+	require.NoError(t, err)
+	require.Equal(t, int64(12), i)
+
+	_, err = toFloat(time.Now())
+	require.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package sqlserver
 
 import (
+	"context"
 	"database/sql"
 	"sync"
 	"time"
@@ -150,6 +151,12 @@ func initQueries(s *SQLServer) {
 
 // Gather collect data from SQL Server
 func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
+	return s.GatherContext(context.Background(), acc)
+}
+
+// GatherContext collect data from SQL Server, running queries are cancelled
+// when the context is done.
+func (s *SQLServer) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	if !isInitialized {
 		initQueries(s)
 	}
@@ -165,7 +172,7 @@ func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
 			wg.Add(1)
 			go func(serv string, query Query) {
 				defer wg.Done()
-				acc.AddError(s.gatherServer(serv, query, acc))
+				acc.AddError(s.gatherServer(ctx, serv, query, acc))
 			}(serv, query)
 		}
 	}
@@ -174,7 +181,7 @@ func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (s *SQLServer) gatherServer(server string, query Query, acc telegraf.Accumulator) error {
+func (s *SQLServer) gatherServer(ctx context.Context, server string, query Query, acc telegraf.Accumulator) error {
 	// deferred opening
 	conn, err := sql.Open("mssql", server)
 	if err != nil {
@@ -183,7 +190,7 @@ func (s *SQLServer) gatherServer(server string, query Query, acc telegraf.Accumu
 	defer conn.Close()
 
 	// execute query
-	rows, err := conn.Query(query.Script)
+	rows, err := conn.QueryContext(ctx, query.Script)
 	if err != nil {
 		return err
 	}
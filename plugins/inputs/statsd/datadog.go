@@ -0,0 +1,151 @@
+package statsd
+
+// Parsing of the dogstatsd extensions to the statsd protocol, see
+// http://docs.datadoghq.com/guides/dogstatsd/
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	priorityNormal = "normal"
+	priorityLow    = "low"
+
+	eventInfo    = "info"
+	eventWarning = "warning"
+	eventError   = "error"
+	eventSuccess = "success"
+)
+
+var uncommenter = strings.NewReplacer("\\n", "\n")
+
+type cachedevent struct {
+	name   string
+	fields map[string]interface{}
+	tags   map[string]string
+	ts     time.Time
+}
+
+// parseEventMessage parses a datadog event, the events look like this:
+// _e{title.length,text.length}:title|text|d:date_happened|h:hostname|p:priority|t:alert_type|k:aggregation_key|s:source_type_name|#tag1,tag2
+// the lengths are the number of bytes of the title and the text.
+func (s *Statsd) parseEventMessage(now time.Time, message string) error {
+	// _e{title.length,text.length}:title|text
+	message = strings.TrimPrefix(message, "_e{")
+	i := strings.Index(message, "}:")
+	if i < 0 {
+		return errors.New("invalid event message format")
+	}
+	lengths := strings.Split(message[:i], ",")
+	if len(lengths) != 2 {
+		return errors.New("invalid event message format")
+	}
+	titleLen, err := strconv.Atoi(lengths[0])
+	if err != nil || titleLen < 0 {
+		return fmt.Errorf("invalid event title length: %q", lengths[0])
+	}
+	textLen, err := strconv.Atoi(lengths[1])
+	if err != nil || textLen < 0 {
+		return fmt.Errorf("invalid event text length: %q", lengths[1])
+	}
+
+	message = message[i+2:]
+	if titleLen+textLen+1 > len(message) || message[titleLen] != '|' {
+		return errors.New("event title and text lengths do not match the message")
+	}
+	title := message[:titleLen]
+	if title == "" {
+		return errors.New("missing event title")
+	}
+	text := message[titleLen+1 : titleLen+1+textLen]
+	message = message[titleLen+1+textLen:]
+
+	m := cachedevent{
+		name: title,
+		fields: map[string]interface{}{
+			"alert_type": eventInfo,
+			"priority":   priorityNormal,
+			"text":       uncommenter.Replace(text),
+		},
+		tags: make(map[string]string),
+		ts:   now,
+	}
+
+	if message != "" {
+		if message[0] != '|' {
+			return errors.New("invalid event message format")
+		}
+		for _, segment := range strings.Split(message[1:], "|") {
+			if len(segment) > 0 && segment[0] == '#' {
+				parseDataDogTags(m.tags, segment[1:])
+				continue
+			}
+			if len(segment) < 2 || segment[1] != ':' {
+				continue
+			}
+			value := segment[2:]
+			switch segment[0] {
+			case 'd':
+				ts, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					continue
+				}
+				m.ts = time.Unix(ts, 0)
+			case 'p':
+				switch value {
+				case priorityLow, priorityNormal:
+					m.fields["priority"] = value
+				}
+			case 'h':
+				// The host tag is set by telegraf, the source tag is used
+				// instead.
+				m.tags["source"] = value
+			case 't':
+				switch value {
+				case eventError, eventWarning, eventSuccess, eventInfo:
+					m.fields["alert_type"] = value
+				}
+			case 'k':
+				m.tags["aggregation_key"] = value
+			case 's':
+				m.fields["source_type_name"] = value
+			}
+		}
+	}
+
+	// In datadog the host tag and h: are interchangeable.
+	if host, ok := m.tags["host"]; ok {
+		delete(m.tags, "host")
+		m.tags["source"] = host
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.events = append(s.events, m)
+	return nil
+}
+
+// parseDataDogTags parses the comma separated datadog tags, tags without a
+// value are added with an empty value.
+func parseDataDogTags(tags map[string]string, message string) {
+	for _, tag := range strings.Split(message, ",") {
+		ts := strings.SplitN(tag, ":", 2)
+		var k, v string
+		switch len(ts) {
+		case 1:
+			// just a tag
+			k = ts[0]
+			v = ""
+		case 2:
+			k = ts[0]
+			v = ts[1]
+		}
+		if k != "" {
+			tags[k] = v
+		}
+	}
+}
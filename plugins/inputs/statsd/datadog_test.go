@@ -0,0 +1,104 @@
+package statsd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventGather(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		message  string
+		expected telegraf.Metric
+	}{
+		{
+			name:    "basic",
+			message: "_e{10,9}:test title|test text",
+			expected: testutil.MustMetric("test title",
+				map[string]string{},
+				map[string]interface{}{
+					"alert_type": eventInfo,
+					"priority":   priorityNormal,
+					"text":       "test text",
+				}, now),
+		},
+		{
+			name:    "all options",
+			message: "_e{10,18}:test title|test\\ntext\\nsecond|d:1569931200|p:low|h:web01|t:warning|k:deploy|s:jenkins|#env:prod,canary",
+			expected: testutil.MustMetric("test title",
+				map[string]string{
+					"source":          "web01",
+					"aggregation_key": "deploy",
+					"env":             "prod",
+					"canary":          "",
+				},
+				map[string]interface{}{
+					"alert_type":       eventWarning,
+					"priority":         priorityLow,
+					"text":             "test\ntext\nsecond",
+					"source_type_name": "jenkins",
+				}, time.Unix(1569931200, 0)),
+		},
+		{
+			name:    "host tag",
+			message: "_e{5,4}:title|text|t:error|p:urgent|#host:db01",
+			expected: testutil.MustMetric("title",
+				map[string]string{"source": "db01"},
+				map[string]interface{}{
+					"alert_type": eventError,
+					"priority":   priorityNormal,
+					"text":       "text",
+				}, now),
+		},
+		{
+			name:    "pipe in text",
+			message: "_e{5,9}:title|text|text|s:app",
+			expected: testutil.MustMetric("title",
+				map[string]string{},
+				map[string]interface{}{
+					"alert_type":       eventInfo,
+					"priority":         priorityNormal,
+					"text":             "text|text",
+					"source_type_name": "app",
+				}, now),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewTestStatsd()
+			require.NoError(t, s.parseEventMessage(now, tt.message))
+
+			acc := &testutil.Accumulator{}
+			require.NoError(t, s.Gather(acc))
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, acc.GetTelegrafMetrics())
+
+			// Events are only reported once.
+			acc.ClearMetrics()
+			require.NoError(t, s.Gather(acc))
+			require.Empty(t, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestEventError(t *testing.T) {
+	messages := []string{
+		"_e{10,9}test title|test text",
+		"_e{10}:test title|test text",
+		"_e{x,9}:test title|test text",
+		"_e{10,-1}:test title|test text",
+		"_e{10,10}:test title|test text",
+		"_e{11,8}:test title|test text",
+		"_e{0,9}:|test text",
+		"_e{10,4}:test title|test text",
+	}
+	for _, message := range messages {
+		s := NewTestStatsd()
+		require.Error(t, s.parseEventMessage(time.Now(), message), message)
+		require.Empty(t, s.events)
+	}
+}
@@ -8,6 +8,11 @@ import (
 
 const defaultPercentileLimit = 1000
 
+const (
+	percentileEngineSample = "sample"
+	percentileEngineExact  = "exact"
+)
+
 // RunningStats calculates a running mean, variance, standard deviation,
 // lower bound, upper bound, count, and can calculate estimated percentiles.
 // It is based on the incremental algorithm described here:
@@ -23,6 +28,8 @@ type RunningStats struct {
 	// randomly replacing old values, hence it is an estimated percentile.
 	perc      []float64
 	PercLimit int
+	// PercExact keeps all the values, the percentiles are then exact.
+	PercExact bool
 
 	sum float64
 
@@ -45,7 +52,11 @@ func (rs *RunningStats) AddValue(v float64) {
 		if rs.PercLimit == 0 {
 			rs.PercLimit = defaultPercentileLimit
 		}
-		rs.perc = make([]float64, 0, rs.PercLimit)
+		if rs.PercExact {
+			rs.perc = make([]float64, 0)
+		} else {
+			rs.perc = make([]float64, 0, rs.PercLimit)
+		}
 	}
 
 	// These are used for the running mean and variance
@@ -63,7 +74,7 @@ func (rs *RunningStats) AddValue(v float64) {
 		rs.lower = v
 	}
 
-	if len(rs.perc) < rs.PercLimit {
+	if rs.PercExact || len(rs.perc) < rs.PercLimit {
 		rs.perc = append(rs.perc, v)
 	} else {
 		// Reached limit, choose random index to overwrite in the percentile array
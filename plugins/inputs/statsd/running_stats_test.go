@@ -146,6 +146,23 @@ func TestRunningStats_PercentileLimit(t *testing.T) {
 	}
 }
 
+func TestRunningStats_PercentileExact(t *testing.T) {
+	rs := RunningStats{}
+	rs.PercLimit = 10
+	rs.PercExact = true
+
+	for i := 1; i <= 100; i++ {
+		rs.AddValue(float64(i))
+	}
+
+	if len(rs.perc) != 100 {
+		t.Errorf("Expected %v, got %v", 100, len(rs.perc))
+	}
+	if rs.Percentile(90) != 91 {
+		t.Errorf("Expected %v, got %v", 91, rs.Percentile(90))
+	}
+}
+
 func fuzzyEqual(a, b, epsilon float64) bool {
 	if math.Abs(a-b) > epsilon {
 		return false
@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"log"
 	"net"
 	"sort"
 	"strconv"
@@ -35,9 +34,9 @@ const (
 	MaxTCPConnections          = 250
 )
 
-var dropwarn = "E! Error: statsd message queue full. " +
+var dropwarn = "Statsd message queue full. " +
 	"We have dropped %d messages so far. " +
-	"You may want to increase allowed_pending_messages in the config\n"
+	"You may want to increase allowed_pending_messages in the config"
 
 var malformedwarn = "E! Statsd over TCP has received %d malformed packets" +
 	" thus far."
@@ -57,12 +56,16 @@ type Statsd struct {
 	// and histogram stats.
 	Percentiles     []int
 	PercentileLimit int
+	// PercentileEngine selects how the values used for the percentiles are
+	// kept, either a random sample of at most PercentileLimit values or all
+	// the values.
+	PercentileEngine string `toml:"percentile_engine"`
 
 	DeleteGauges   bool
 	DeleteCounters bool
 	DeleteSets     bool
 	DeleteTimings  bool
-	ConvertNames   bool
+	ConvertNames   bool `toml:"convert_names" deprecated:"0.12.0;2.0.0;use the metric_separator option instead"`
 
 	// MetricSeparator is the separator between parts of the metric name.
 	MetricSeparator string
@@ -70,6 +73,16 @@ type Statsd struct {
 	// statsd protocol (http://docs.datadoghq.com/guides/dogstatsd/)
 	ParseDataDogTags bool
 
+	// Parses extensions to statsd in the datadog statsd format
+	// currently supports metrics and datadog tags, events and distributions.
+	// http://docs.datadoghq.com/guides/dogstatsd/
+	DataDogExtensions bool `toml:"datadog_extensions"`
+
+	// MaxTTL is the maximum duration a metric is reported without being
+	// updated, it only applies to the metrics which are not deleted every
+	// interval.
+	MaxTTL internal.Duration `toml:"max_ttl"`
+
 	// UDPPacketSize is deprecated, it's only here for legacy support
 	// we now always create 1 max size buffer and then copy only what we need
 	// into the in channel
@@ -102,6 +115,7 @@ type Statsd struct {
 	counters map[string]cachedcounter
 	sets     map[string]cachedset
 	timings  map[string]cachedtimings
+	events   []cachedevent
 
 	// bucket -> influx templates
 	Templates []string
@@ -120,6 +134,8 @@ type Statsd struct {
 
 	graphiteParser *graphite.GraphiteParser
 
+	Log telegraf.Logger `toml:"-"`
+
 	acc telegraf.Accumulator
 
 	MaxConnections     selfstat.Stat
@@ -148,27 +164,31 @@ type metric struct {
 }
 
 type cachedset struct {
-	name   string
-	fields map[string]map[string]bool
-	tags   map[string]string
+	name      string
+	fields    map[string]map[string]bool
+	tags      map[string]string
+	expiresAt time.Time
 }
 
 type cachedgauge struct {
-	name   string
-	fields map[string]interface{}
-	tags   map[string]string
+	name      string
+	fields    map[string]interface{}
+	tags      map[string]string
+	expiresAt time.Time
 }
 
 type cachedcounter struct {
-	name   string
-	fields map[string]interface{}
-	tags   map[string]string
+	name      string
+	fields    map[string]interface{}
+	tags      map[string]string
+	expiresAt time.Time
 }
 
 type cachedtimings struct {
-	name   string
-	fields map[string]RunningStats
-	tags   map[string]string
+	name      string
+	fields    map[string]RunningStats
+	tags      map[string]string
+	expiresAt time.Time
 }
 
 func (_ *Statsd) Description() string {
@@ -208,6 +228,11 @@ const sampleConfig = `
   ## Percentiles to calculate for timing & histogram stats
   percentiles = [90]
 
+  ## Values used to calculate the percentiles, "sample" keeps a random sample
+  ## of at most percentile_limit values, "exact" keeps all the values
+  ## received in an interval and requires delete_timings.
+  # percentile_engine = "sample"
+
   ## separator to use between elements of a statsd metric
   metric_separator = "_"
 
@@ -215,6 +240,10 @@ const sampleConfig = `
   ## http://docs.datadoghq.com/guides/dogstatsd/
   parse_data_dog_tags = false
 
+  ## Parses datadog extensions to the statsd format, the datadog tags,
+  ## events and distributions.
+  datadog_extensions = false
+
   ## Statsd data translation templates, more info can be read here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/TEMPLATE_PATTERN.md
   # templates = [
@@ -229,6 +258,11 @@ const sampleConfig = `
   ## calculation of percentiles. Raising this limit increases the accuracy
   ## of percentiles but also increases the memory usage and cpu time.
   percentile_limit = 1000
+
+  ## Max duration (TTL) for each metric to stay cached/reported without being
+  ## updated, 0 keeps the metrics until restarted.  Only applies to the metric
+  ## types not deleted every interval.
+  # max_ttl = "10h"
 `
 
 func (_ *Statsd) SampleConfig() string {
@@ -240,6 +274,8 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	defer s.Unlock()
 	now := time.Now()
 
+	s.expireCachedMetrics(now)
+
 	for _, metric := range s.timings {
 		// Defining a template to parse field names for timers allows us to split
 		// out multiple fields per timer. In this case we prefix each stat with the
@@ -293,10 +329,54 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 		s.sets = make(map[string]cachedset)
 	}
 
+	for _, event := range s.events {
+		acc.AddFields(event.name, event.fields, event.tags, event.ts)
+	}
+	s.events = nil
+
 	return nil
 }
 
+// expireCachedMetrics removes the metrics which have not been updated for
+// MaxTTL.
+func (s *Statsd) expireCachedMetrics(now time.Time) {
+	if s.MaxTTL.Duration == 0 {
+		return
+	}
+
+	for key, cached := range s.gauges {
+		if now.After(cached.expiresAt) {
+			delete(s.gauges, key)
+		}
+	}
+	for key, cached := range s.sets {
+		if now.After(cached.expiresAt) {
+			delete(s.sets, key)
+		}
+	}
+	for key, cached := range s.timings {
+		if now.After(cached.expiresAt) {
+			delete(s.timings, key)
+		}
+	}
+	for key, cached := range s.counters {
+		if now.After(cached.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
+
 func (s *Statsd) Start(_ telegraf.Accumulator) error {
+	switch s.PercentileEngine {
+	case "", percentileEngineSample, percentileEngineExact:
+	default:
+		return fmt.Errorf("unknown percentile_engine %q", s.PercentileEngine)
+	}
+	// Without deleting the timings the exact values grow without bound.
+	if s.PercentileEngine == percentileEngineExact && !s.DeleteTimings {
+		return fmt.Errorf("percentile_engine %q requires delete_timings", s.PercentileEngine)
+	}
+
 	// Make data structures
 	s.gauges = make(map[string]cachedgauge)
 	s.counters = make(map[string]cachedcounter)
@@ -329,11 +409,6 @@ func (s *Statsd) Start(_ telegraf.Accumulator) error {
 		s.accept <- true
 	}
 
-	if s.ConvertNames {
-		log.Printf("I! WARNING statsd: convert_names config option is deprecated," +
-			" please use metric_separator instead")
-	}
-
 	if s.MetricSeparator == "" {
 		s.MetricSeparator = defaultSeparator
 	}
@@ -347,7 +422,7 @@ func (s *Statsd) Start(_ telegraf.Accumulator) error {
 	}
 	// Start the line parser
 	go s.parser()
-	log.Printf("I! Started the statsd service on %s\n", s.ServiceAddress)
+	s.Log.Infof("Started the statsd service on %s", s.ServiceAddress)
 	return nil
 }
 
@@ -359,10 +434,10 @@ func (s *Statsd) tcpListen() error {
 	address, _ := net.ResolveTCPAddr("tcp", s.ServiceAddress)
 	s.TCPlistener, err = net.ListenTCP("tcp", address)
 	if err != nil {
-		log.Fatalf("ERROR: ListenTCP - %s", err)
+		s.Log.Errorf("Error listening on TCP: %s", err)
 		return err
 	}
-	log.Println("I! TCP Statsd listening on: ", s.TCPlistener.Addr().String())
+	s.Log.Infof("TCP listening on %q", s.TCPlistener.Addr().String())
 	for {
 		select {
 		case <-s.done:
@@ -409,9 +484,10 @@ func (s *Statsd) udpListen() error {
 	address, _ := net.ResolveUDPAddr(s.Protocol, s.ServiceAddress)
 	s.UDPlistener, err = net.ListenUDP(s.Protocol, address)
 	if err != nil {
-		log.Fatalf("ERROR: ListenUDP - %s", err)
+		s.Log.Errorf("Error listening on UDP: %s", err)
+		return err
 	}
-	log.Println("I! Statsd UDP listener listening on: ", s.UDPlistener.LocalAddr().String())
+	s.Log.Infof("UDP listening on %q", s.UDPlistener.LocalAddr().String())
 
 	if s.ReadBufferSize > 0 {
 		s.UDPlistener.SetReadBuffer(s.ReadBufferSize)
@@ -425,7 +501,7 @@ func (s *Statsd) udpListen() error {
 		default:
 			n, _, err := s.UDPlistener.ReadFromUDP(buf)
 			if err != nil && !strings.Contains(err.Error(), "closed network") {
-				log.Printf("E! Error READ: %s\n", err.Error())
+				s.Log.Errorf("Error reading: %s", err.Error())
 				continue
 			}
 			b := s.bufPool.Get().(*bytes.Buffer)
@@ -437,7 +513,7 @@ func (s *Statsd) udpListen() error {
 			default:
 				s.drops++
 				if s.drops == 1 || s.AllowedPendingMessages == 0 || s.drops%s.AllowedPendingMessages == 0 {
-					log.Printf(dropwarn, s.drops)
+					s.Log.Errorf(dropwarn, s.drops)
 				}
 			}
 		}
@@ -458,7 +534,15 @@ func (s *Statsd) parser() error {
 			s.bufPool.Put(buf)
 			for _, line := range lines {
 				line = strings.TrimSpace(line)
-				if line != "" {
+				switch {
+				case line == "":
+				case s.DataDogExtensions && strings.HasPrefix(line, "_e"):
+					if err := s.parseEventMessage(time.Now(), line); err != nil {
+						s.Log.Errorf("parsing datadog event: %s, %s", err, line)
+					}
+				case s.DataDogExtensions && strings.HasPrefix(line, "_sc"):
+					// Service checks are not supported.
+				default:
 					s.parseStatsdLine(line)
 				}
 			}
@@ -473,7 +557,7 @@ func (s *Statsd) parseStatsdLine(line string) error {
 	defer s.Unlock()
 
 	lineTags := make(map[string]string)
-	if s.ParseDataDogTags {
+	if s.ParseDataDogTags || s.DataDogExtensions {
 		recombinedSegments := make([]string, 0)
 		// datadog tags look like this:
 		// users.online:1|c|@0.5|#country:china,environment:production
@@ -484,24 +568,7 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		for _, segment := range pipesplit {
 			if len(segment) > 0 && segment[0] == '#' {
 				// we have ourselves a tag; they are comma separated
-				tagstr := segment[1:]
-				tags := strings.Split(tagstr, ",")
-				for _, tag := range tags {
-					ts := strings.SplitN(tag, ":", 2)
-					var k, v string
-					switch len(ts) {
-					case 1:
-						// just a tag
-						k = ts[0]
-						v = ""
-					case 2:
-						k = ts[0]
-						v = ts[1]
-					}
-					if k != "" {
-						lineTags[k] = v
-					}
-				}
+				parseDataDogTags(lineTags, segment[1:])
 			} else {
 				recombinedSegments = append(recombinedSegments, segment)
 			}
@@ -512,7 +579,7 @@ func (s *Statsd) parseStatsdLine(line string) error {
 	// Validate splitting the line on ":"
 	bits := strings.Split(line, ":")
 	if len(bits) < 2 {
-		log.Printf("E! Error: splitting ':', Unable to parse metric: %s\n", line)
+		s.Log.Errorf("splitting ':', Unable to parse metric: %s", line)
 		return errors.New("Error Parsing statsd line")
 	}
 
@@ -528,22 +595,22 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		// Validate splitting the bit on "|"
 		pipesplit := strings.Split(bit, "|")
 		if len(pipesplit) < 2 {
-			log.Printf("E! Error: splitting '|', Unable to parse metric: %s\n", line)
+			s.Log.Errorf("splitting '|', Unable to parse metric: %s", line)
 			return errors.New("Error Parsing statsd line")
 		} else if len(pipesplit) > 2 {
 			sr := pipesplit[2]
-			errmsg := "E! Error: parsing sample rate, %s, it must be in format like: " +
-				"@0.1, @0.5, etc. Ignoring sample rate for line: %s\n"
+			errmsg := "Parsing sample rate, %s, it must be in format like: " +
+				"@0.1, @0.5, etc. Ignoring sample rate for line: %s"
 			if strings.Contains(sr, "@") && len(sr) > 1 {
 				samplerate, err := strconv.ParseFloat(sr[1:], 64)
 				if err != nil {
-					log.Printf(errmsg, err.Error(), line)
+					s.Log.Errorf(errmsg, err.Error(), line)
 				} else {
 					// sample rate successfully parsed
 					m.samplerate = samplerate
 				}
 			} else {
-				log.Printf(errmsg, "", line)
+				s.Log.Errorf(errmsg, "", line)
 			}
 		}
 
@@ -551,25 +618,32 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		switch pipesplit[1] {
 		case "g", "c", "s", "ms", "h":
 			m.mtype = pipesplit[1]
+		case "d":
+			// Distributions are aggregated like histograms.
+			if !s.DataDogExtensions {
+				s.Log.Errorf("Statsd Metric type %s unsupported", pipesplit[1])
+				return errors.New("Error Parsing statsd line")
+			}
+			m.mtype = pipesplit[1]
 		default:
-			log.Printf("E! Error: Statsd Metric type %s unsupported", pipesplit[1])
+			s.Log.Errorf("Statsd Metric type %s unsupported", pipesplit[1])
 			return errors.New("Error Parsing statsd line")
 		}
 
 		// Parse the value
 		if strings.HasPrefix(pipesplit[0], "-") || strings.HasPrefix(pipesplit[0], "+") {
 			if m.mtype != "g" && m.mtype != "c" {
-				log.Printf("E! Error: +- values are only supported for gauges & counters: %s\n", line)
+				s.Log.Errorf("+- values are only supported for gauges & counters: %s", line)
 				return errors.New("Error Parsing statsd line")
 			}
 			m.additive = true
 		}
 
 		switch m.mtype {
-		case "g", "ms", "h":
+		case "g", "ms", "h", "d":
 			v, err := strconv.ParseFloat(pipesplit[0], 64)
 			if err != nil {
-				log.Printf("E! Error: parsing value to float64: %s\n", line)
+				s.Log.Errorf("parsing value to float64: %s", line)
 				return errors.New("Error Parsing statsd line")
 			}
 			m.floatvalue = v
@@ -579,7 +653,7 @@ func (s *Statsd) parseStatsdLine(line string) error {
 			if err != nil {
 				v2, err2 := strconv.ParseFloat(pipesplit[0], 64)
 				if err2 != nil {
-					log.Printf("E! Error: parsing value to int64: %s\n", line)
+					s.Log.Errorf("parsing value to int64: %s", line)
 					return errors.New("Error Parsing statsd line")
 				}
 				v = int64(v2)
@@ -606,6 +680,8 @@ func (s *Statsd) parseStatsdLine(line string) error {
 			m.tags["metric_type"] = "timing"
 		case "h":
 			m.tags["metric_type"] = "histogram"
+		case "d":
+			m.tags["metric_type"] = "distribution"
 		}
 
 		if len(lineTags) > 0 {
@@ -694,8 +770,9 @@ func parseKeyValue(keyvalue string) (string, string) {
 // aggregates and caches the current value(s). It does not deal with the
 // Delete* options, because those are dealt with in the Gather function.
 func (s *Statsd) aggregate(m metric) {
+	expiresAt := time.Now().Add(s.MaxTTL.Duration)
 	switch m.mtype {
-	case "ms", "h":
+	case "ms", "h", "d":
 		// Check if the measurement exists
 		cached, ok := s.timings[m.hash]
 		if !ok {
@@ -711,6 +788,7 @@ func (s *Statsd) aggregate(m metric) {
 		if !ok {
 			field = RunningStats{
 				PercLimit: s.PercentileLimit,
+				PercExact: s.PercentileEngine == percentileEngineExact,
 			}
 		}
 		if m.samplerate > 0 {
@@ -721,61 +799,66 @@ func (s *Statsd) aggregate(m metric) {
 			field.AddValue(m.floatvalue)
 		}
 		cached.fields[m.field] = field
+		cached.expiresAt = expiresAt
 		s.timings[m.hash] = cached
 	case "c":
 		// check if the measurement exists
-		_, ok := s.counters[m.hash]
+		cached, ok := s.counters[m.hash]
 		if !ok {
-			s.counters[m.hash] = cachedcounter{
+			cached = cachedcounter{
 				name:   m.name,
 				fields: make(map[string]interface{}),
 				tags:   m.tags,
 			}
 		}
 		// check if the field exists
-		_, ok = s.counters[m.hash].fields[m.field]
+		_, ok = cached.fields[m.field]
 		if !ok {
-			s.counters[m.hash].fields[m.field] = int64(0)
+			cached.fields[m.field] = int64(0)
 		}
-		s.counters[m.hash].fields[m.field] =
-			s.counters[m.hash].fields[m.field].(int64) + m.intvalue
+		cached.fields[m.field] = cached.fields[m.field].(int64) + m.intvalue
+		cached.expiresAt = expiresAt
+		s.counters[m.hash] = cached
 	case "g":
 		// check if the measurement exists
-		_, ok := s.gauges[m.hash]
+		cached, ok := s.gauges[m.hash]
 		if !ok {
-			s.gauges[m.hash] = cachedgauge{
+			cached = cachedgauge{
 				name:   m.name,
 				fields: make(map[string]interface{}),
 				tags:   m.tags,
 			}
 		}
 		// check if the field exists
-		_, ok = s.gauges[m.hash].fields[m.field]
+		_, ok = cached.fields[m.field]
 		if !ok {
-			s.gauges[m.hash].fields[m.field] = float64(0)
+			cached.fields[m.field] = float64(0)
 		}
 		if m.additive {
-			s.gauges[m.hash].fields[m.field] =
-				s.gauges[m.hash].fields[m.field].(float64) + m.floatvalue
+			cached.fields[m.field] = cached.fields[m.field].(float64) + m.floatvalue
 		} else {
-			s.gauges[m.hash].fields[m.field] = m.floatvalue
+			cached.fields[m.field] = m.floatvalue
 		}
+		cached.expiresAt = expiresAt
+		s.gauges[m.hash] = cached
 	case "s":
 		// check if the measurement exists
-		_, ok := s.sets[m.hash]
+		cached, ok := s.sets[m.hash]
 		if !ok {
-			s.sets[m.hash] = cachedset{
+			cached = cachedset{
 				name:   m.name,
 				fields: make(map[string]map[string]bool),
 				tags:   m.tags,
 			}
 		}
 		// check if the field exists
-		_, ok = s.sets[m.hash].fields[m.field]
+		_, ok = cached.fields[m.field]
 		if !ok {
-			s.sets[m.hash].fields[m.field] = make(map[string]bool)
+			cached.fields[m.field] = make(map[string]bool)
 		}
-		s.sets[m.hash].fields[m.field][m.strvalue] = true
+		cached.fields[m.field][m.strvalue] = true
+		cached.expiresAt = expiresAt
+		s.sets[m.hash] = cached
 	}
 }
 
@@ -820,7 +903,7 @@ func (s *Statsd) handler(conn *net.TCPConn, id string) {
 			default:
 				s.drops++
 				if s.drops == 1 || s.drops%s.AllowedPendingMessages == 0 {
-					log.Printf(dropwarn, s.drops)
+					s.Log.Errorf(dropwarn, s.drops)
 				}
 			}
 		}
@@ -830,8 +913,8 @@ func (s *Statsd) handler(conn *net.TCPConn, id string) {
 // refuser refuses a TCP connection
 func (s *Statsd) refuser(conn *net.TCPConn) {
 	conn.Close()
-	log.Printf("I! Refused TCP Connection from %s", conn.RemoteAddr())
-	log.Printf("I! WARNING: Maximum TCP Connections reached, you may want to" +
+	s.Log.Infof("Refused TCP Connection from %s", conn.RemoteAddr())
+	s.Log.Warnf("Maximum TCP Connections reached, you may want to" +
 		" adjust max_tcp_connections")
 }
 
@@ -851,7 +934,7 @@ func (s *Statsd) remember(id string, conn *net.TCPConn) {
 
 func (s *Statsd) Stop() {
 	s.Lock()
-	log.Println("I! Stopping the statsd service")
+	s.Log.Infof("Stopping the statsd service")
 	close(s.done)
 	if s.isUDP() {
 		s.UDPlistener.Close()
@@ -877,7 +960,7 @@ func (s *Statsd) Stop() {
 
 	s.Lock()
 	close(s.in)
-	log.Println("I! Stopped Statsd listener service on ", s.ServiceAddress)
+	s.Log.Infof("Stopped listener service on %q", s.ServiceAddress)
 	s.Unlock()
 }
 
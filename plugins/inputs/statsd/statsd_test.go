@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,6 +21,7 @@ const (
 func newTestTcpListener() (*Statsd, chan *bytes.Buffer) {
 	in := make(chan *bytes.Buffer, 1500)
 	listener := &Statsd{
+		Log:                    testutil.Logger{},
 		Protocol:               "tcp",
 		ServiceAddress:         "localhost:8125",
 		AllowedPendingMessages: 10000,
@@ -31,7 +33,7 @@ func newTestTcpListener() (*Statsd, chan *bytes.Buffer) {
 }
 
 func NewTestStatsd() *Statsd {
-	s := Statsd{}
+	s := Statsd{Log: testutil.Logger{}}
 
 	// Make data structures
 	s.done = make(chan struct{})
@@ -46,9 +48,22 @@ func NewTestStatsd() *Statsd {
 	return &s
 }
 
+func TestExactPercentilesRequireDeleteTimings(t *testing.T) {
+	listener := Statsd{
+		Log:              testutil.Logger{},
+		Protocol:         "udp",
+		ServiceAddress:   "localhost:0",
+		PercentileEngine: "exact",
+	}
+
+	acc := &testutil.Accumulator{}
+	require.Error(t, listener.Start(acc))
+}
+
 // Test that MaxTCPConections is respected
 func TestConcurrentConns(t *testing.T) {
 	listener := Statsd{
+		Log:                    testutil.Logger{},
 		Protocol:               "tcp",
 		ServiceAddress:         "localhost:8125",
 		AllowedPendingMessages: 10000,
@@ -79,6 +94,7 @@ func TestConcurrentConns(t *testing.T) {
 // Test that MaxTCPConections is respected when max==1
 func TestConcurrentConns1(t *testing.T) {
 	listener := Statsd{
+		Log:                    testutil.Logger{},
 		Protocol:               "tcp",
 		ServiceAddress:         "localhost:8125",
 		AllowedPendingMessages: 10000,
@@ -107,6 +123,7 @@ func TestConcurrentConns1(t *testing.T) {
 // Test that MaxTCPConections is respected
 func TestCloseConcurrentConns(t *testing.T) {
 	listener := Statsd{
+		Log:                    testutil.Logger{},
 		Protocol:               "tcp",
 		ServiceAddress:         "localhost:8125",
 		AllowedPendingMessages: 10000,
@@ -128,6 +145,7 @@ func TestCloseConcurrentConns(t *testing.T) {
 // benchmark how long it takes to accept & process 100,000 metrics:
 func BenchmarkUDP(b *testing.B) {
 	listener := Statsd{
+		Log:                    testutil.Logger{},
 		Protocol:               "udp",
 		ServiceAddress:         "localhost:8125",
 		AllowedPendingMessages: 250000,
@@ -158,6 +176,7 @@ func BenchmarkUDP(b *testing.B) {
 // benchmark how long it takes to accept & process 100,000 metrics:
 func BenchmarkTCP(b *testing.B) {
 	listener := Statsd{
+		Log:                    testutil.Logger{},
 		Protocol:               "tcp",
 		ServiceAddress:         "localhost:8125",
 		AllowedPendingMessages: 250000,
@@ -1433,6 +1452,118 @@ func TestParse_Gauges_Delete(t *testing.T) {
 	}
 }
 
+// Tests that metrics not updated for max_ttl are removed
+func TestParse_MaxTTL(t *testing.T) {
+	s := NewTestStatsd()
+	s.MaxTTL = internal.Duration{Duration: time.Hour}
+	acc := &testutil.Accumulator{}
+
+	lines := []string{
+		"current.users:100|g",
+		"requests:1|c",
+		"unique.users:100|s",
+		"response.time:10|ms",
+	}
+	for _, line := range lines {
+		require.NoError(t, s.parseStatsdLine(line))
+	}
+
+	require.NoError(t, s.Gather(acc))
+	require.Len(t, acc.Metrics, 4)
+	require.Len(t, s.gauges, 1)
+
+	// Metrics updated again are kept.
+	require.NoError(t, s.parseStatsdLine("current.users:50|g"))
+	for _, cache := range []interface{}{s.counters, s.sets, s.timings} {
+		switch cache := cache.(type) {
+		case map[string]cachedcounter:
+			for k, v := range cache {
+				v.expiresAt = time.Now().Add(-time.Second)
+				cache[k] = v
+			}
+		case map[string]cachedset:
+			for k, v := range cache {
+				v.expiresAt = time.Now().Add(-time.Second)
+				cache[k] = v
+			}
+		case map[string]cachedtimings:
+			for k, v := range cache {
+				v.expiresAt = time.Now().Add(-time.Second)
+				cache[k] = v
+			}
+		}
+	}
+
+	acc.ClearMetrics()
+	require.NoError(t, s.Gather(acc))
+	require.Len(t, acc.Metrics, 1)
+	require.NoError(t, test_validate_gauge("current_users", 50, s.gauges))
+	require.Empty(t, s.counters)
+	require.Empty(t, s.sets)
+	require.Empty(t, s.timings)
+}
+
+// Tests the datadog distributions
+func TestParse_Distributions(t *testing.T) {
+	s := NewTestStatsd()
+	s.Percentiles = []int{50}
+	acc := &testutil.Accumulator{}
+
+	// Distributions are only supported with the datadog extensions.
+	require.Error(t, s.parseStatsdLine("request.latency:1|d"))
+
+	s.DataDogExtensions = true
+	lines := []string{
+		"request.latency:1|d|#endpoint:/users",
+		"request.latency:2|d|#endpoint:/users",
+		"request.latency:3|d|@0.5|#endpoint:/users",
+	}
+	for _, line := range lines {
+		require.NoError(t, s.parseStatsdLine(line))
+	}
+
+	require.NoError(t, s.Gather(acc))
+	m, ok := acc.Get("request_latency")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{
+		"endpoint":    "/users",
+		"metric_type": "distribution",
+	}, m.Tags)
+	require.Equal(t, int64(4), m.Fields["count"])
+	require.Equal(t, float64(9), m.Fields["sum"])
+	require.Equal(t, float64(1), m.Fields["lower"])
+	require.Equal(t, float64(3), m.Fields["upper"])
+	require.Equal(t, float64(3), m.Fields["50_percentile"])
+	require.InDelta(t, 2.25, m.Fields["mean"], 1e-9)
+	require.InDelta(t, 0.829156, m.Fields["stddev"], 1e-6)
+}
+
+// Tests that the datadog events and service checks are handled by the parser
+func TestParse_DataDogExtensions(t *testing.T) {
+	s := NewTestStatsd()
+	s.DataDogExtensions = true
+	acc := &testutil.Accumulator{}
+
+	s.wg.Add(1)
+	go s.parser()
+	// The channel is unbuffered, the lines are parsed before the parser
+	// returns.
+	s.in <- bytes.NewBufferString("_e{5,4}:title|text\n_sc|app.health|0\nrequests:1|c|#env:prod\n")
+	close(s.done)
+	s.wg.Wait()
+
+	require.NoError(t, s.Gather(acc))
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsFields(t, "title", map[string]interface{}{
+		"alert_type": "info",
+		"priority":   "normal",
+		"text":       "text",
+	})
+	acc.AssertContainsTaggedFields(t, "requests",
+		map[string]interface{}{"value": int64(1)},
+		map[string]string{"env": "prod", "metric_type": "counter"})
+}
+
 // Tests the delete_sets option
 func TestParse_Sets_Delete(t *testing.T) {
 	s := NewTestStatsd()
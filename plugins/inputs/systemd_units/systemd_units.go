@@ -0,0 +1,385 @@
+package systemd_units
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// SystemdUnits is a telegraf plugin to gather the state of systemd units and
+// follow the journal
+type SystemdUnits struct {
+	Timeout         internal.Duration `toml:"timeout"`
+	UnitType        string            `toml:"unittype"`
+	Journal         bool              `toml:"journal"`
+	JournalMatches  []string          `toml:"journal_matches"`
+	JournalPriority string            `toml:"journal_priority"`
+
+	systemctl      systemctl
+	journalCommand func(ctx context.Context, args ...string) *exec.Cmd
+
+	mu sync.Mutex
+	// cursor is the cursor of the last journal entry read.
+	cursor  string
+	running bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type systemctl func(Timeout internal.Duration, args ...string) (*bytes.Buffer, error)
+
+const measurement = "systemd_units"
+
+var defaultTimeout = internal.Duration{Duration: time.Second}
+
+var loadMap = map[string]int{
+	"loaded":      0,
+	"stub":        1,
+	"not-found":   2,
+	"bad-setting": 3,
+	"error":       4,
+	"merged":      5,
+	"masked":      6,
+}
+
+var activeMap = map[string]int{
+	"active":       0,
+	"reloading":    1,
+	"inactive":     2,
+	"failed":       3,
+	"activating":   4,
+	"deactivating": 5,
+}
+
+// Description returns a short description of the plugin
+func (s *SystemdUnits) Description() string {
+	return "Gather the state of systemd units and follow the journal"
+}
+
+// SampleConfig returns sample configuration options.
+func (s *SystemdUnits) SampleConfig() string {
+	return `
+  ## Set timeout for systemctl execution
+  # timeout = "1s"
+
+  ## Filter for a specific unit type, default is "service", other possible
+  ## values are "socket", "target", "device", "mount", "automount", "swap",
+  ## "timer", "path", "slice" and "scope".  The restarts are only reported
+  ## for services.
+  # unittype = "service"
+
+  ## Follow the journal, each journal entry is a metric.
+  # journal = false
+
+  ## Matches selecting the journal entries, see journalctl(1).
+  # journal_matches = ["_SYSTEMD_UNIT=sshd.service"]
+
+  ## Only follow the journal entries of this priority or more important,
+  ## ie: "err" or "warning".
+  # journal_priority = ""
+`
+}
+
+// Gather reports the state of the units, and starts following the journal
+// when it is not followed yet.
+func (s *SystemdUnits) Gather(acc telegraf.Accumulator) error {
+	if s.Journal {
+		s.mu.Lock()
+		running := s.running
+		s.mu.Unlock()
+		if !running {
+			if err := s.followJournal(acc); err != nil {
+				acc.AddError(fmt.Errorf("error following journal: %v", err))
+			}
+		}
+	}
+
+	out, err := s.systemctl(s.Timeout, "list-units", "--all", "--plain", "--no-legend",
+		"--type="+s.UnitType)
+	if err != nil {
+		return err
+	}
+
+	units := make(map[string]map[string]interface{})
+	tags := make(map[string]map[string]string)
+	var names []string
+
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		data := strings.Fields(line)
+		if len(data) < 4 {
+			acc.AddError(fmt.Errorf("Error parsing line (expected at least 4 fields): %s", line))
+			continue
+		}
+		name, load, active, sub := data[0], data[1], data[2], data[3]
+
+		fields := make(map[string]interface{})
+		if code, ok := loadMap[load]; ok {
+			fields["load_code"] = code
+		}
+		if code, ok := activeMap[active]; ok {
+			fields["active_code"] = code
+		}
+		units[name] = fields
+		tags[name] = map[string]string{
+			"name":   name,
+			"load":   load,
+			"active": active,
+			"sub":    sub,
+		}
+		names = append(names, name)
+	}
+
+	if s.UnitType == "service" && len(names) > 0 {
+		if err := s.gatherRestarts(names, units); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	for _, name := range names {
+		acc.AddFields(measurement, units[name], tags[name])
+	}
+	return nil
+}
+
+// gatherRestarts adds the number of automatic restarts of the services, the
+// property is not available with systemd before version 235.
+func (s *SystemdUnits) gatherRestarts(names []string, units map[string]map[string]interface{}) error {
+	args := append([]string{"show", "--property=Id,NRestarts"}, names...)
+	out, err := s.systemctl(s.Timeout, args...)
+	if err != nil {
+		return err
+	}
+
+	// The properties of each unit are separated by an empty line.
+	var id string
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) != 2 {
+			id = ""
+			continue
+		}
+		switch parts[0] {
+		case "Id":
+			id = parts[1]
+		case "NRestarts":
+			restarts, err := strconv.ParseUint(parts[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			if fields, ok := units[id]; ok {
+				fields["restarts"] = restarts
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SystemdUnits) journalArgs() []string {
+	args := []string{"--follow", "--output=json"}
+
+	s.mu.Lock()
+	cursor := s.cursor
+	s.mu.Unlock()
+	if cursor != "" {
+		args = append(args, "--no-tail", "--after-cursor="+cursor)
+	} else {
+		args = append(args, "--lines=0")
+	}
+
+	if s.JournalPriority != "" {
+		args = append(args, "--priority="+s.JournalPriority)
+	}
+	return append(args, s.JournalMatches...)
+}
+
+func (s *SystemdUnits) followJournal(acc telegraf.Accumulator) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := s.journalCommand(ctx, s.journalArgs()...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return err
+	}
+
+	s.mu.Lock()
+	s.running = true
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		err := s.readJournal(stdout, acc)
+		// The output is read to the end before waiting for the command.
+		if waitErr := cmd.Wait(); err == nil {
+			err = waitErr
+		}
+		if err != nil && ctx.Err() == nil {
+			acc.AddError(fmt.Errorf("journalctl failed: %v: %s", err,
+				strings.TrimSpace(stderr.String())))
+		}
+		cancel()
+
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+	return nil
+}
+
+// readJournal adds a metric for each JSON journal entry until the end of
+// the output.
+func (s *SystemdUnits) readJournal(r io.Reader, acc telegraf.Accumulator) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := s.addEntry(line, acc); err != nil {
+				acc.AddError(fmt.Errorf("error parsing journal entry: %v", err))
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *SystemdUnits) addEntry(line []byte, acc telegraf.Accumulator) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return err
+	}
+
+	// Binary values are encoded as arrays, only string values are used.
+	entry := make(map[string]string, len(raw))
+	for key, value := range raw {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			entry[key] = s
+		}
+	}
+
+	tags := make(map[string]string)
+	if unit, ok := entry["_SYSTEMD_UNIT"]; ok {
+		tags["unit"] = unit
+	}
+	if identifier, ok := entry["SYSLOG_IDENTIFIER"]; ok {
+		tags["identifier"] = identifier
+	}
+
+	fields := map[string]interface{}{
+		"message": entry["MESSAGE"],
+	}
+	if priority, err := strconv.ParseInt(entry["PRIORITY"], 10, 64); err == nil {
+		fields["priority"] = priority
+	}
+	if pid, err := strconv.ParseInt(entry["_PID"], 10, 64); err == nil {
+		fields["pid"] = pid
+	}
+
+	tm := time.Now()
+	if usec, err := strconv.ParseInt(entry["__REALTIME_TIMESTAMP"], 10, 64); err == nil {
+		tm = time.Unix(0, usec*int64(time.Microsecond))
+	}
+
+	acc.AddFields("systemd_journal", fields, tags, tm)
+
+	if cursor, ok := entry["__CURSOR"]; ok {
+		s.mu.Lock()
+		s.cursor = cursor
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+func (s *SystemdUnits) Start(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (s *SystemdUnits) Stop() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// GetState returns the cursor of the last journal entry read.
+func (s *SystemdUnits) GetState() interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursor
+}
+
+// SetState restores the cursor, the journal is followed after that entry.
+func (s *SystemdUnits) SetState(state interface{}) error {
+	cursor, ok := state.(string)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursor = cursor
+	return nil
+}
+
+func runSystemctl(Timeout internal.Duration, args ...string) (*bytes.Buffer, error) {
+	// is systemctl available ?
+	systemctlPath, err := exec.LookPath("systemctl")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(systemctlPath, args...)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err = internal.RunTimeout(cmd, Timeout.Duration)
+	if err != nil {
+		return &out, fmt.Errorf("error running systemctl %s: %s", args[0], err)
+	}
+
+	return &out, nil
+}
+
+func journalCommand(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "journalctl", args...)
+}
+
+func init() {
+	inputs.Add("systemd_units", func() telegraf.Input {
+		return &SystemdUnits{
+			systemctl:      runSystemctl,
+			journalCommand: journalCommand,
+			Timeout:        defaultTimeout,
+			UnitType:       "service",
+		}
+	})
+}
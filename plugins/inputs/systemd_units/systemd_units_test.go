@@ -0,0 +1,229 @@
+package systemd_units
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const listUnits = `sshd.service      loaded    active   running SSH server
+nginx.service     loaded    failed   failed  A high performance web server
+missing.service   not-found inactive dead    missing.service
+`
+
+const showUnits = `Id=sshd.service
+NRestarts=0
+
+Id=nginx.service
+NRestarts=3
+
+Id=missing.service
+NRestarts=0
+`
+
+func newSystemctl(outputs map[string]string) systemctl {
+	return func(Timeout internal.Duration, args ...string) (*bytes.Buffer, error) {
+		out, ok := outputs[args[0]]
+		if !ok {
+			return nil, fmt.Errorf("unexpected systemctl %s", args[0])
+		}
+		return bytes.NewBufferString(out), nil
+	}
+}
+
+func TestSystemdUnits(t *testing.T) {
+	s := &SystemdUnits{
+		UnitType: "service",
+		systemctl: newSystemctl(map[string]string{
+			"list-units": listUnits,
+			"show":       showUnits,
+		}),
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 3)
+
+	acc.AssertContainsTaggedFields(t, "systemd_units",
+		map[string]interface{}{
+			"load_code":   0,
+			"active_code": 0,
+			"restarts":    uint64(0),
+		},
+		map[string]string{
+			"name":   "sshd.service",
+			"load":   "loaded",
+			"active": "active",
+			"sub":    "running",
+		})
+	acc.AssertContainsTaggedFields(t, "systemd_units",
+		map[string]interface{}{
+			"load_code":   0,
+			"active_code": 3,
+			"restarts":    uint64(3),
+		},
+		map[string]string{
+			"name":   "nginx.service",
+			"load":   "loaded",
+			"active": "failed",
+			"sub":    "failed",
+		})
+	acc.AssertContainsTaggedFields(t, "systemd_units",
+		map[string]interface{}{
+			"load_code":   2,
+			"active_code": 2,
+			"restarts":    uint64(0),
+		},
+		map[string]string{
+			"name":   "missing.service",
+			"load":   "not-found",
+			"active": "inactive",
+			"sub":    "dead",
+		})
+}
+
+func TestSystemdUnitsWithoutRestarts(t *testing.T) {
+	// Restarts are only available for services.
+	s := &SystemdUnits{
+		UnitType: "socket",
+		systemctl: newSystemctl(map[string]string{
+			"list-units": "dbus.socket loaded active running D-Bus System Message Bus Socket\n",
+		}),
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsFields(t, "systemd_units", map[string]interface{}{
+		"load_code":   0,
+		"active_code": 0,
+	})
+}
+
+func TestSystemdUnitsInvalidLine(t *testing.T) {
+	s := &SystemdUnits{
+		UnitType: "service",
+		systemctl: newSystemctl(map[string]string{
+			"list-units": "invalid\n",
+		}),
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestSystemdUnitsError(t *testing.T) {
+	s := &SystemdUnits{
+		UnitType:  "service",
+		systemctl: newSystemctl(map[string]string{}),
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, s.Gather(&acc))
+}
+
+const journal = `{"__CURSOR":"s=1;i=1","__REALTIME_TIMESTAMP":"1569931200000001","PRIORITY":"6","_PID":"42","_SYSTEMD_UNIT":"sshd.service","SYSLOG_IDENTIFIER":"sshd","MESSAGE":"Server listening on 0.0.0.0 port 22."}
+{"__CURSOR":"s=1;i=2","__REALTIME_TIMESTAMP":"1569931200000002","PRIORITY":"3","SYSLOG_IDENTIFIER":"kernel","MESSAGE":[98,105,110]}
+`
+
+func TestReadJournal(t *testing.T) {
+	s := &SystemdUnits{}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.readJournal(strings.NewReader(journal), &acc))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 2)
+
+	acc.AssertContainsTaggedFields(t, "systemd_journal",
+		map[string]interface{}{
+			"message":  "Server listening on 0.0.0.0 port 22.",
+			"priority": int64(6),
+			"pid":      int64(42),
+		},
+		map[string]string{
+			"unit":       "sshd.service",
+			"identifier": "sshd",
+		})
+	require.Equal(t, time.Unix(1569931200, 1000), acc.Metrics[0].Time)
+
+	// Binary messages are not strings.
+	acc.AssertContainsTaggedFields(t, "systemd_journal",
+		map[string]interface{}{
+			"message":  "",
+			"priority": int64(3),
+		},
+		map[string]string{
+			"identifier": "kernel",
+		})
+
+	require.Equal(t, "s=1;i=2", s.GetState())
+}
+
+func TestReadJournalInvalidEntry(t *testing.T) {
+	s := &SystemdUnits{}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.readJournal(strings.NewReader("-- No entries --\n"), &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestJournalArgs(t *testing.T) {
+	s := &SystemdUnits{
+		JournalPriority: "err",
+		JournalMatches:  []string{"_SYSTEMD_UNIT=sshd.service"},
+	}
+	require.Equal(t, []string{
+		"--follow", "--output=json", "--lines=0", "--priority=err", "_SYSTEMD_UNIT=sshd.service",
+	}, s.journalArgs())
+
+	// The journal is followed after the restored cursor.
+	require.NoError(t, s.SetState("s=1;i=2"))
+	require.Equal(t, []string{
+		"--follow", "--output=json", "--no-tail", "--after-cursor=s=1;i=2", "--priority=err",
+		"_SYSTEMD_UNIT=sshd.service",
+	}, s.journalArgs())
+}
+
+func TestFollowJournal(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	var args []string
+	s := &SystemdUnits{
+		UnitType: "service",
+		Journal:  true,
+		systemctl: newSystemctl(map[string]string{
+			"list-units": listUnits,
+			"show":       showUnits,
+		}),
+		journalCommand: func(ctx context.Context, a ...string) *exec.Cmd {
+			args = a
+			cmd := exec.CommandContext(ctx, "cat")
+			cmd.Stdin = strings.NewReader(journal)
+			return cmd
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	require.NoError(t, s.Gather(&acc))
+	acc.Wait(5)
+	s.Stop()
+
+	require.Empty(t, acc.Errors)
+	require.Contains(t, args, "--follow")
+	require.Equal(t, "s=1;i=2", s.GetState())
+}
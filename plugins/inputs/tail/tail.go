@@ -3,8 +3,9 @@
 package tail
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"os"
 	"strings"
 	"sync"
 
@@ -20,23 +21,51 @@ const (
 	defaultWatchMethod = "inotify"
 )
 
+type empty struct{}
+type semaphore chan empty
+
 type Tail struct {
-	Files         []string
-	FromBeginning bool
-	Pipe          bool
-	WatchMethod   string
+	Files               []string
+	FromBeginning       bool
+	Pipe                bool
+	WatchMethod         string
+	MaxUndeliveredLines int `toml:"max_undelivered_lines"`
+
+	Log telegraf.Logger `toml:"-"`
 
 	tailers    map[string]*tail.Tail
+	restored   map[string]int64
 	parserFunc parsers.ParserFunc
 	wg         sync.WaitGroup
-	acc        telegraf.Accumulator
+	acc        telegraf.TrackingAccumulator
+	sem        semaphore
+	ctx        context.Context
+	cancel     context.CancelFunc
+
+	// trackMu protects the offsets of the lines handed to the accumulator.
+	// The offset of a file only advances past a line once the line and all
+	// lines before it are delivered.
+	trackMu sync.Mutex
+	offsets map[string]int64
+	pending map[string][]*trackedLine
+	lines   map[telegraf.TrackingID]*trackedLine
 
 	sync.Mutex
 }
 
+// trackedLine is a line of a file waiting for its metrics to be delivered.
+type trackedLine struct {
+	file   string
+	offset int64 // offset of the end of the line
+	done   bool
+}
+
 func NewTail() *Tail {
 	return &Tail{
 		FromBeginning: false,
+		offsets:       make(map[string]int64),
+		pending:       make(map[string][]*trackedLine),
+		lines:         make(map[telegraf.TrackingID]*trackedLine),
 	}
 }
 
@@ -59,6 +88,12 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## Maximum lines to read from the files before their metrics are written by
+  ## the outputs.  When set, the offsets saved in the agent statefile only
+  ## include delivered lines, so lines not yet written when Telegraf stops
+  ## are read again on restart.  When 0, lines count as delivered once read.
+  # max_undelivered_lines = 0
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -78,23 +113,161 @@ func (t *Tail) Gather(acc telegraf.Accumulator) error {
 	t.Lock()
 	defer t.Unlock()
 
-	return t.tailNewFiles(true)
+	return t.tailNewFiles(t.ctx, true)
 }
 
 func (t *Tail) Start(acc telegraf.Accumulator) error {
 	t.Lock()
 	defer t.Unlock()
 
-	t.acc = acc
+	if t.MaxUndeliveredLines > 0 {
+		t.acc = acc.WithTracking(t.MaxUndeliveredLines)
+		t.sem = make(semaphore, t.MaxUndeliveredLines)
+	} else {
+		t.acc = &untrackedAccumulator{acc}
+	}
 	t.tailers = make(map[string]*tail.Tail)
 
-	return t.tailNewFiles(t.FromBeginning)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.ctx, t.cancel = ctx, cancel
+
+	if t.sem != nil {
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case track := <-t.acc.Delivered():
+					t.onDelivery(track)
+				}
+			}
+		}()
+	}
+
+	err := t.tailNewFiles(ctx, t.FromBeginning)
+
+	// Restored offsets only apply to the files tailed on startup.
+	t.restored = nil
+	return err
+}
+
+// GetState returns the offset of the delivered lines of each tailed file.
+func (t *Tail) GetState() interface{} {
+	t.Lock()
+	defer t.Unlock()
+
+	// Lines delivered while the outputs were flushed after the plugin was
+	// stopped are still waiting in the delivery channel.
+	if t.sem != nil {
+	drain:
+		for {
+			select {
+			case track := <-t.acc.Delivered():
+				t.onDelivery(track)
+			default:
+				break drain
+			}
+		}
+	}
+
+	t.trackMu.Lock()
+	defer t.trackMu.Unlock()
+
+	offsets := make(map[string]int64, len(t.offsets))
+	for file, offset := range t.offsets {
+		offsets[file] = offset
+	}
+	return offsets
+}
+
+// SetState restores the read offsets, files with an offset continue reading
+// at that offset when the plugin is started.
+func (t *Tail) SetState(state interface{}) error {
+	offsets, ok := state.(map[string]int64)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.restored = offsets
+	return nil
 }
 
-func (t *Tail) tailNewFiles(fromBeginning bool) error {
-	var seek *tail.SeekInfo
+// onDelivery marks the line of the metrics as done.  Rejected metrics were
+// dropped on purpose, reading their line again would not change that, so
+// their line is done as well.
+func (t *Tail) onDelivery(track telegraf.DeliveryInfo) {
+	<-t.sem
+
+	t.trackMu.Lock()
+	defer t.trackMu.Unlock()
+
+	line, ok := t.lines[track.ID()]
+	if !ok {
+		t.Log.Errorf("Could not mark line delivered: %d", track.ID())
+		return
+	}
+	delete(t.lines, track.ID())
+	line.done = true
+	t.advance(line.file)
+}
+
+// addLine hands the metrics of a line to the accumulator, the line is done
+// right away when there are no metrics to deliver.  It returns false if the
+// plugin was stopped while waiting for a line to be delivered.
+func (t *Tail) addLine(ctx context.Context, file string, offset int64, metrics []telegraf.Metric) bool {
+	line := &trackedLine{file: file, offset: offset}
+	if len(metrics) == 0 {
+		line.done = true
+	} else if t.sem != nil {
+		select {
+		case <-ctx.Done():
+			return false
+		case t.sem <- empty{}:
+		}
+	}
+
+	t.trackMu.Lock()
+	defer t.trackMu.Unlock()
+
+	if !line.done {
+		id := t.acc.AddTrackingMetricGroup(metrics)
+		if t.sem != nil {
+			t.lines[id] = line
+		} else {
+			line.done = true
+		}
+	}
+	t.pending[file] = append(t.pending[file], line)
+	t.advance(file)
+	return true
+}
+
+// advance moves the offset of the file past the lines done, up to the first
+// line still waiting to be delivered.
+func (t *Tail) advance(file string) {
+	lines := t.pending[file]
+	for len(lines) > 0 && lines[0].done {
+		if !t.Pipe {
+			t.offsets[file] = lines[0].offset
+		}
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		delete(t.pending, file)
+		return
+	}
+	t.pending[file] = lines
+}
+
+func (t *Tail) tailNewFiles(ctx context.Context, fromBeginning bool) error {
+	var seekEnd *tail.SeekInfo
 	if !t.Pipe && !fromBeginning {
-		seek = &tail.SeekInfo{
+		seekEnd = &tail.SeekInfo{
 			Whence: 2,
 			Offset: 0,
 		}
@@ -117,6 +290,21 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 				continue
 			}
 
+			seek := seekEnd
+			var offset int64
+			if restored, ok := t.restored[file]; ok && !t.Pipe {
+				t.Log.Debugf("using offset %d for file: %v", restored, file)
+				seek = &tail.SeekInfo{
+					Whence: 0,
+					Offset: restored,
+				}
+				offset = restored
+			} else if seek != nil {
+				if info, err := os.Stat(file); err == nil {
+					offset = info.Size()
+				}
+			}
+
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
@@ -132,7 +320,7 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 				continue
 			}
 
-			log.Printf("D! [inputs.tail] tail added for file: %v", file)
+			t.Log.Debugf("tail added for file: %v", file)
 
 			parser, err := t.parserFunc()
 			if err != nil {
@@ -143,9 +331,17 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 			t.wg.Add(1)
 			go func() {
 				defer t.wg.Done()
-				t.receiver(parser, tailer)
+				t.receiver(ctx, parser, tailer, offset)
 			}()
 			t.tailers[tailer.Filename] = tailer
+
+			if !t.Pipe {
+				t.trackMu.Lock()
+				if _, ok := t.offsets[tailer.Filename]; !ok {
+					t.offsets[tailer.Filename] = offset
+				}
+				t.trackMu.Unlock()
+			}
 		}
 	}
 	return nil
@@ -178,13 +374,16 @@ func parseLine(parser parsers.Parser, line string, firstLine bool) ([]telegraf.M
 
 // Receiver is launched as a goroutine to continuously watch a tailed logfile
 // for changes, parse any incoming msgs, and add to the accumulator.
-func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
+// The offset is the offset in the file at which the tailer starts reading.
+func (t *Tail) receiver(ctx context.Context, parser parsers.Parser, tailer *tail.Tail, offset int64) {
 	var firstLine = true
 	for line := range tailer.Lines {
 		if line.Err != nil {
 			t.acc.AddError(fmt.Errorf("error tailing file %s, Error: %s", tailer.Filename, line.Err))
 			continue
 		}
+		offset = nextOffset(tailer, offset, line.Text)
+
 		// Fix up files with Windows line endings.
 		text := strings.TrimRight(line.Text, "\r")
 
@@ -192,17 +391,20 @@ func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
 		if err != nil {
 			t.acc.AddError(fmt.Errorf("malformed log line in %s: [%s], Error: %s",
 				tailer.Filename, line.Text, err))
-			continue
+			metrics = nil
+		} else {
+			firstLine = false
 		}
-		firstLine = false
 
 		for _, metric := range metrics {
 			metric.AddTag("path", tailer.Filename)
-			t.acc.AddMetric(metric)
+		}
+		if !t.addLine(ctx, tailer.Filename, offset, metrics) {
+			break
 		}
 	}
 
-	log.Printf("D! [inputs.tail] tail removed for file: %v", tailer.Filename)
+	t.Log.Debugf("tail removed for file: %v", tailer.Filename)
 
 	if err := tailer.Err(); err != nil {
 		t.acc.AddError(fmt.Errorf("E! Error tailing file %s, Error: %s\n",
@@ -214,6 +416,7 @@ func (t *Tail) Stop() {
 	t.Lock()
 	defer t.Unlock()
 
+	t.cancel()
 	for _, tailer := range t.tailers {
 		err := tailer.Stop()
 		if err != nil {
@@ -225,6 +428,42 @@ func (t *Tail) Stop() {
 		tailer.Cleanup()
 	}
 	t.wg.Wait()
+
+	t.tailers = make(map[string]*tail.Tail)
+}
+
+// nextOffset returns the offset of the end of the line read after the
+// offset.  The tailer reads ahead, so its position is at least the end of
+// the line; a lower position means the file was truncated or reopened after
+// rotation and the line is taken to be the first of the new file.
+func nextOffset(tailer *tail.Tail, offset int64, text string) int64 {
+	offset += int64(len(text)) + 1
+	if pos, err := tailer.Tell(); err == nil && pos < offset {
+		offset = int64(len(text)) + 1
+	}
+	return offset
+}
+
+// untrackedAccumulator treats the metrics as delivered as soon as they are
+// added.
+type untrackedAccumulator struct {
+	telegraf.Accumulator
+}
+
+func (a *untrackedAccumulator) AddTrackingMetric(m telegraf.Metric) telegraf.TrackingID {
+	a.AddMetric(m)
+	return 0
+}
+
+func (a *untrackedAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	for _, m := range group {
+		a.AddMetric(m)
+	}
+	return 0
+}
+
+func (a *untrackedAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return nil
 }
 
 func (t *Tail) SetParserFunc(fn parsers.ParserFunc) {
@@ -28,6 +28,7 @@ func TestTailFromBeginning(t *testing.T) {
 	require.NoError(t, err)
 
 	tt := NewTail()
+	tt.Log = testutil.Logger{}
 	tt.FromBeginning = true
 	tt.Files = []string{tmpfile.Name()}
 	tt.SetParserFunc(parsers.NewInfluxParser)
@@ -61,6 +62,7 @@ func TestTailFromEnd(t *testing.T) {
 	require.NoError(t, err)
 
 	tt := NewTail()
+	tt.Log = testutil.Logger{}
 	tt.Files = []string{tmpfile.Name()}
 	tt.SetParserFunc(parsers.NewInfluxParser)
 	defer tt.Stop()
@@ -97,6 +99,7 @@ func TestTailBadLine(t *testing.T) {
 	defer os.Remove(tmpfile.Name())
 
 	tt := NewTail()
+	tt.Log = testutil.Logger{}
 	tt.FromBeginning = true
 	tt.Files = []string{tmpfile.Name()}
 	tt.SetParserFunc(parsers.NewInfluxParser)
@@ -122,6 +125,7 @@ func TestTailDosLineendings(t *testing.T) {
 	require.NoError(t, err)
 
 	tt := NewTail()
+	tt.Log = testutil.Logger{}
 	tt.FromBeginning = true
 	tt.Files = []string{tmpfile.Name()}
 	tt.SetParserFunc(parsers.NewInfluxParser)
@@ -160,6 +164,7 @@ cpu,42
 	require.NoError(t, err)
 
 	plugin := NewTail()
+	plugin.Log = testutil.Logger{}
 	plugin.FromBeginning = true
 	plugin.Files = []string{tmpfile.Name()}
 	plugin.SetParserFunc(func() (parsers.Parser, error) {
@@ -217,6 +222,7 @@ func TestMultipleMetricsOnFirstLine(t *testing.T) {
 	require.NoError(t, err)
 
 	plugin := NewTail()
+	plugin.Log = testutil.Logger{}
 	plugin.FromBeginning = true
 	plugin.Files = []string{tmpfile.Name()}
 	plugin.SetParserFunc(func() (parsers.Parser, error) {
@@ -256,3 +262,118 @@ func TestMultipleMetricsOnFirstLine(t *testing.T) {
 	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(),
 		testutil.IgnoreTime())
 }
+
+func TestTailResumeFromState(t *testing.T) {
+	if os.Getenv("CIRCLE_PROJECT_REPONAME") != "" {
+		t.Skip("Skipping CI testing due to race conditions")
+	}
+
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	defer tmpfile.Close()
+	_, err = tmpfile.WriteString("cpu,mytag=foo usage_idle=100\n")
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.Log = testutil.Logger{}
+	tt.FromBeginning = true
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	tt.Stop()
+
+	state := tt.GetState()
+	require.Equal(t, map[string]int64{tmpfile.Name(): 29}, state)
+
+	// Lines written while stopped are read when resuming from the state.
+	_, err = tmpfile.WriteString("cpu,mytag=bar usage_idle=50\n")
+	require.NoError(t, err)
+
+	tt = NewTail()
+	tt.Log = testutil.Logger{}
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	require.NoError(t, tt.SetState(state))
+	defer tt.Stop()
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{
+			"usage_idle": float64(50),
+		},
+		map[string]string{
+			"mytag": "bar",
+			"path":  tmpfile.Name(),
+		})
+}
+
+type delivery struct {
+	id telegraf.TrackingID
+}
+
+func (d *delivery) ID() telegraf.TrackingID { return d.id }
+func (d *delivery) Delivered() bool         { return true }
+
+// trackingAccumulator records the tracking ids of the groups, the test
+// reports their delivery.
+type trackingAccumulator struct {
+	testutil.Accumulator
+	ids       chan telegraf.TrackingID
+	delivered chan telegraf.DeliveryInfo
+}
+
+func (a *trackingAccumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	return a
+}
+
+func (a *trackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	id := a.Accumulator.AddTrackingMetricGroup(group)
+	a.ids <- id
+	return id
+}
+
+func (a *trackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
+func TestTailDeliveredOffsets(t *testing.T) {
+	if os.Getenv("CIRCLE_PROJECT_REPONAME") != "" {
+		t.Skip("Skipping CI testing due to race conditions")
+	}
+
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	defer tmpfile.Close()
+	_, err = tmpfile.WriteString("cpu,mytag=foo usage_idle=100\ncpu,mytag=bar usage_idle=50\n")
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.Log = testutil.Logger{}
+	tt.FromBeginning = true
+	tt.MaxUndeliveredLines = 10
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc := &trackingAccumulator{
+		ids:       make(chan telegraf.TrackingID, 2),
+		delivered: make(chan telegraf.DeliveryInfo, 2),
+	}
+	require.NoError(t, tt.Start(acc))
+	first, second := <-acc.ids, <-acc.ids
+	tt.Stop()
+
+	// The offset does not move past the first line until it is delivered.
+	acc.delivered <- &delivery{id: second}
+	require.Equal(t, map[string]int64{tmpfile.Name(): 0}, tt.GetState())
+
+	acc.delivered <- &delivery{id: first}
+	require.Equal(t, map[string]int64{tmpfile.Name(): 57}, tt.GetState())
+}
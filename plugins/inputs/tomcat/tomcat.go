@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -65,6 +66,7 @@ type Tomcat struct {
 	Password string
 	Timeout  internal.Duration
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	client  *http.Client
 	request *http.Request
@@ -87,6 +89,12 @@ var sampleconfig = `
   # tls_key = "/etc/telegraf/key.pem"
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
 `
 
 func (s *Tomcat) Description() string {
@@ -193,9 +201,15 @@ func (s *Tomcat) createHttpClient() (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := s.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsConfig,
+			Proxy:           proxy,
 		},
 		Timeout: s.Timeout.Duration,
 	}
@@ -0,0 +1,96 @@
+package win_eventlog
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// Event is the System part of the XML rendering of an event, the rendering
+// does not contain the message of the event.
+type Event struct {
+	Provider struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"System>Provider"`
+	EventID     uint32 `xml:"System>EventID"`
+	Version     uint8  `xml:"System>Version"`
+	Level       uint8  `xml:"System>Level"`
+	Task        uint16 `xml:"System>Task"`
+	Opcode      uint8  `xml:"System>Opcode"`
+	Keywords    string `xml:"System>Keywords"`
+	TimeCreated struct {
+		SystemTime string `xml:"SystemTime,attr"`
+	} `xml:"System>TimeCreated"`
+	EventRecordID uint64 `xml:"System>EventRecordID"`
+	Execution     struct {
+		ProcessID uint32 `xml:"ProcessID,attr"`
+		ThreadID  uint32 `xml:"ThreadID,attr"`
+	} `xml:"System>Execution"`
+	Channel  string `xml:"System>Channel"`
+	Computer string `xml:"System>Computer"`
+	Security struct {
+		UserID string `xml:"UserID,attr"`
+	} `xml:"System>Security"`
+
+	// Message is the message of the event formatted by its provider.
+	Message string `xml:"-"`
+}
+
+var levelText = map[uint8]string{
+	0: "Information", // LogAlways
+	1: "Critical",
+	2: "Error",
+	3: "Warning",
+	4: "Information",
+	5: "Verbose",
+}
+
+func parseEvent(data []byte) (*Event, error) {
+	var event Event
+	if err := xml.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// toMetric returns the tags, fields and time of the metric of the event.
+func (e *Event) toMetric(onlyFirstLineOfMessage bool) (map[string]string, map[string]interface{}, time.Time) {
+	tags := map[string]string{
+		"eventlog_name": e.Channel,
+		"source":        e.Provider.Name,
+		"computer":      e.Computer,
+	}
+	if text, ok := levelText[e.Level]; ok {
+		tags["level_text"] = text
+	}
+
+	message := strings.TrimSpace(e.Message)
+	if onlyFirstLineOfMessage {
+		if i := strings.IndexAny(message, "\r\n"); i >= 0 {
+			message = message[:i]
+		}
+	}
+
+	fields := map[string]interface{}{
+		"event_id":   int64(e.EventID),
+		"level":      int64(e.Level),
+		"task":       int64(e.Task),
+		"opcode":     int64(e.Opcode),
+		"record_id":  int64(e.EventRecordID),
+		"process_id": int64(e.Execution.ProcessID),
+		"thread_id":  int64(e.Execution.ThreadID),
+		"message":    message,
+	}
+	if e.Keywords != "" {
+		fields["keywords"] = e.Keywords
+	}
+	if e.Security.UserID != "" {
+		fields["user_id"] = e.Security.UserID
+	}
+
+	tm, err := time.Parse(time.RFC3339Nano, e.TimeCreated.SystemTime)
+	if err != nil {
+		tm = time.Now()
+	}
+	return tags, fields, tm
+}
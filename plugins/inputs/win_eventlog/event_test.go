@@ -0,0 +1,81 @@
+package win_eventlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const eventXML = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'>
+  <System>
+    <Provider Name='MsiInstaller'/>
+    <EventID Qualifiers='0'>11707</EventID>
+    <Version>0</Version>
+    <Level>4</Level>
+    <Task>0</Task>
+    <Opcode>0</Opcode>
+    <Keywords>0x80000000000000</Keywords>
+    <TimeCreated SystemTime='2019-10-01T12:00:00.1234567Z'/>
+    <EventRecordID>4242</EventRecordID>
+    <Correlation/>
+    <Execution ProcessID='1234' ThreadID='5678'/>
+    <Channel>Application</Channel>
+    <Computer>DESKTOP-1</Computer>
+    <Security UserID='S-1-5-18'/>
+  </System>
+  <EventData>
+    <Data>Product: Telegraf -- Installation completed successfully.</Data>
+  </EventData>
+</Event>`
+
+func TestParseEvent(t *testing.T) {
+	event, err := parseEvent([]byte(eventXML))
+	require.NoError(t, err)
+	event.Message = "Product: Telegraf -- Installation completed successfully.\r\n\r\nDetails follow."
+
+	tags, fields, tm := event.toMetric(true)
+	require.Equal(t, map[string]string{
+		"eventlog_name": "Application",
+		"source":        "MsiInstaller",
+		"computer":      "DESKTOP-1",
+		"level_text":    "Information",
+	}, tags)
+	require.Equal(t, map[string]interface{}{
+		"event_id":   int64(11707),
+		"level":      int64(4),
+		"task":       int64(0),
+		"opcode":     int64(0),
+		"record_id":  int64(4242),
+		"process_id": int64(1234),
+		"thread_id":  int64(5678),
+		"keywords":   "0x80000000000000",
+		"user_id":    "S-1-5-18",
+		"message":    "Product: Telegraf -- Installation completed successfully.",
+	}, fields)
+	require.Equal(t, time.Date(2019, 10, 1, 12, 0, 0, 123456700, time.UTC), tm.UTC())
+}
+
+func TestFullMessage(t *testing.T) {
+	event, err := parseEvent([]byte(eventXML))
+	require.NoError(t, err)
+	event.Message = "Summary\r\nDetails\r\n"
+
+	_, fields, _ := event.toMetric(false)
+	require.Equal(t, "Summary\r\nDetails", fields["message"])
+}
+
+func TestLevelText(t *testing.T) {
+	event := &Event{Level: 2}
+	tags, _, _ := event.toMetric(true)
+	require.Equal(t, "Error", tags["level_text"])
+
+	event = &Event{Level: 42}
+	tags, _, _ = event.toMetric(true)
+	require.NotContains(t, tags, "level_text")
+}
+
+func TestParseInvalidEvent(t *testing.T) {
+	_, err := parseEvent([]byte("<Event><System>"))
+	require.Error(t, err)
+}
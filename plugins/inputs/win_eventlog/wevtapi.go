@@ -0,0 +1,226 @@
+// +build windows
+
+package win_eventlog
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// EvtHandle is a handle of the Windows Event Log API.
+type EvtHandle uintptr
+
+// Flags of EvtSubscribe
+const (
+	EvtSubscribeToFutureEvents      = 1
+	EvtSubscribeStartAtOldestRecord = 2
+	EvtSubscribeStartAfterBookmark  = 3
+)
+
+// Flags of EvtRender
+const (
+	EvtRenderEventXml = 1
+	EvtRenderBookmark = 2
+)
+
+// Flags of EvtFormatMessage
+const (
+	EvtFormatMessageEvent = 1
+)
+
+// Errors returned by the Windows Event Log API
+const (
+	ERROR_INSUFFICIENT_BUFFER syscall.Errno = 122
+	ERROR_NO_MORE_ITEMS       syscall.Errno = 259
+	ERROR_INVALID_OPERATION   syscall.Errno = 4317
+)
+
+var (
+	// Library
+	libwevtapi = windows.NewLazySystemDLL("wevtapi.dll")
+
+	// Functions
+	procEvtSubscribe             = libwevtapi.NewProc("EvtSubscribe")
+	procEvtNext                  = libwevtapi.NewProc("EvtNext")
+	procEvtRender                = libwevtapi.NewProc("EvtRender")
+	procEvtFormatMessage         = libwevtapi.NewProc("EvtFormatMessage")
+	procEvtOpenPublisherMetadata = libwevtapi.NewProc("EvtOpenPublisherMetadata")
+	procEvtCreateBookmark        = libwevtapi.NewProc("EvtCreateBookmark")
+	procEvtUpdateBookmark        = libwevtapi.NewProc("EvtUpdateBookmark")
+	procEvtClose                 = libwevtapi.NewProc("EvtClose")
+)
+
+// EvtSubscribe creates a pull subscription, the channel path is empty when the
+// query is a structured XML query.
+func EvtSubscribe(signalEvent windows.Handle, channelPath, query string, bookmark EvtHandle, flags uint32) (EvtHandle, error) {
+	var channelPtr *uint16
+	if channelPath != "" {
+		var err error
+		channelPtr, err = syscall.UTF16PtrFromString(channelPath)
+		if err != nil {
+			return 0, err
+		}
+	}
+	queryPtr, err := syscall.UTF16PtrFromString(query)
+	if err != nil {
+		return 0, err
+	}
+
+	r, _, err := procEvtSubscribe.Call(
+		0, // local session
+		uintptr(signalEvent),
+		uintptr(unsafe.Pointer(channelPtr)),
+		uintptr(unsafe.Pointer(queryPtr)),
+		uintptr(bookmark),
+		0, // context
+		0, // callback, the subscription is pulled
+		uintptr(flags))
+	if r == 0 {
+		return 0, err
+	}
+	return EvtHandle(r), nil
+}
+
+// EvtNext returns the next events of the subscription, it fails with
+// ERROR_NO_MORE_ITEMS once all events are returned.
+func EvtNext(subscription EvtHandle, events []EvtHandle) (int, error) {
+	var returned uint32
+	r, _, err := procEvtNext.Call(
+		uintptr(subscription),
+		uintptr(len(events)),
+		uintptr(unsafe.Pointer(&events[0])),
+		0, // timeout
+		0,
+		uintptr(unsafe.Pointer(&returned)))
+	if r == 0 {
+		return 0, err
+	}
+	return int(returned), nil
+}
+
+// EvtRender renders the event or bookmark as XML.
+func EvtRender(handle EvtHandle, flags uint32) (string, error) {
+	var used, count uint32
+	r, _, err := procEvtRender.Call(
+		0,
+		uintptr(handle),
+		uintptr(flags),
+		0,
+		0,
+		uintptr(unsafe.Pointer(&used)),
+		uintptr(unsafe.Pointer(&count)))
+	if r != 0 {
+		return "", nil
+	}
+	if err != ERROR_INSUFFICIENT_BUFFER {
+		return "", err
+	}
+
+	// The size of the buffer is in bytes.
+	buf := make([]uint16, used/2+1)
+	r, _, err = procEvtRender.Call(
+		0,
+		uintptr(handle),
+		uintptr(flags),
+		uintptr(len(buf)*2),
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(&used)),
+		uintptr(unsafe.Pointer(&count)))
+	if r == 0 {
+		return "", err
+	}
+	return syscall.UTF16ToString(buf), nil
+}
+
+// EvtFormatMessage returns the message of the event.
+func EvtFormatMessage(publisher EvtHandle, event EvtHandle) (string, error) {
+	var used uint32
+	r, _, err := procEvtFormatMessage.Call(
+		uintptr(publisher),
+		uintptr(event),
+		0,
+		0,
+		0,
+		EvtFormatMessageEvent,
+		0,
+		0,
+		uintptr(unsafe.Pointer(&used)))
+	if r != 0 {
+		return "", nil
+	}
+	if err != ERROR_INSUFFICIENT_BUFFER {
+		return "", err
+	}
+
+	// The size of the buffer is in characters.
+	buf := make([]uint16, used)
+	r, _, err = procEvtFormatMessage.Call(
+		uintptr(publisher),
+		uintptr(event),
+		0,
+		0,
+		0,
+		EvtFormatMessageEvent,
+		uintptr(len(buf)),
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(&used)))
+	if r == 0 {
+		return "", err
+	}
+	return syscall.UTF16ToString(buf), nil
+}
+
+func EvtOpenPublisherMetadata(publisher string) (EvtHandle, error) {
+	publisherPtr, err := syscall.UTF16PtrFromString(publisher)
+	if err != nil {
+		return 0, err
+	}
+
+	r, _, err := procEvtOpenPublisherMetadata.Call(
+		0,
+		uintptr(unsafe.Pointer(publisherPtr)),
+		0, // log file path
+		0, // locale of the user
+		0)
+	if r == 0 {
+		return 0, err
+	}
+	return EvtHandle(r), nil
+}
+
+// EvtCreateBookmark creates a bookmark from its XML rendering, or a new
+// bookmark when the XML is empty.
+func EvtCreateBookmark(bookmarkXML string) (EvtHandle, error) {
+	var xmlPtr *uint16
+	if bookmarkXML != "" {
+		var err error
+		xmlPtr, err = syscall.UTF16PtrFromString(bookmarkXML)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	r, _, err := procEvtCreateBookmark.Call(uintptr(unsafe.Pointer(xmlPtr)))
+	if r == 0 {
+		return 0, err
+	}
+	return EvtHandle(r), nil
+}
+
+func EvtUpdateBookmark(bookmark EvtHandle, event EvtHandle) error {
+	r, _, err := procEvtUpdateBookmark.Call(uintptr(bookmark), uintptr(event))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func EvtClose(handle EvtHandle) error {
+	r, _, err := procEvtClose.Call(uintptr(handle))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
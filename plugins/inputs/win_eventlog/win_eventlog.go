@@ -0,0 +1,259 @@
+// +build windows
+
+package win_eventlog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"golang.org/x/sys/windows"
+)
+
+var sampleConfig = `
+  ## Name of the channel to subscribe to, ie: Application, System, Security
+  ## or Microsoft-Windows-Sysmon/Operational.  Not used with a structured
+  ## query, which selects its own channels.
+  eventlog_name = "Application"
+
+  ## XPath query to select the events, all events are selected by default.
+  ## Structured XML queries are supported to select events of several
+  ## channels, see the README for an example.
+  # xpath_query = "Event/System[EventID=999]"
+  # xpath_query = "*[System[(Level=1 or Level=2 or Level=3)]]"
+  xpath_query = "*"
+
+  ## When true, events already present in the channel are read; otherwise
+  ## only new events are read.  Ignored once the last event read is saved in
+  ## the agent statefile.
+  # from_beginning = false
+
+  ## Only keep the first line of the message of the events, the first line
+  ## is often a summary.
+  # only_first_line_of_message = true
+
+  ## Maximum number of events requested from the Event Log API at a time.
+  # batch_size = 100
+`
+
+var description = "Input plugin to collect Windows Event Log messages"
+
+// WinEventLog subscribes to the events of a channel.
+type WinEventLog struct {
+	EventlogName           string          `toml:"eventlog_name"`
+	Query                  string          `toml:"xpath_query"`
+	FromBeginning          bool            `toml:"from_beginning"`
+	OnlyFirstLineOfMessage bool            `toml:"only_first_line_of_message"`
+	BatchSize              uint32          `toml:"batch_size"`
+	Log                    telegraf.Logger `toml:"-"`
+
+	sync.Mutex
+	signal       windows.Handle
+	subscription EvtHandle
+	bookmark     EvtHandle
+	// bookmarkXML is the bookmark restored on startup, or the last one
+	// rendered once the plugin is stopped.
+	bookmarkXML string
+	// bookmarked is true once the bookmark points to an event.
+	bookmarked bool
+	publishers map[string]EvtHandle
+}
+
+func (w *WinEventLog) Description() string {
+	return description
+}
+
+func (w *WinEventLog) SampleConfig() string {
+	return sampleConfig
+}
+
+func (w *WinEventLog) Start(acc telegraf.Accumulator) error {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.BatchSize == 0 {
+		return fmt.Errorf("batch_size must be greater than zero")
+	}
+
+	channel := w.EventlogName
+	if strings.HasPrefix(strings.TrimSpace(w.Query), "<QueryList") {
+		channel = ""
+	}
+
+	var flags uint32 = EvtSubscribeToFutureEvents
+	w.bookmarked = w.bookmarkXML != ""
+	if w.bookmarked {
+		flags = EvtSubscribeStartAfterBookmark
+	} else if w.FromBeginning {
+		flags = EvtSubscribeStartAtOldestRecord
+	}
+
+	var err error
+	w.bookmark, err = EvtCreateBookmark(w.bookmarkXML)
+	if err != nil {
+		return fmt.Errorf("creating bookmark failed: %v", err)
+	}
+
+	// The signal event is required by pull subscriptions, the events are
+	// pulled on each gather instead of waiting for it.
+	w.signal, err = windows.CreateEvent(nil, 1, 1, nil)
+	if err != nil {
+		w.close()
+		return err
+	}
+
+	var bookmark EvtHandle
+	if flags == EvtSubscribeStartAfterBookmark {
+		bookmark = w.bookmark
+	}
+	w.subscription, err = EvtSubscribe(w.signal, channel, w.Query, bookmark, flags)
+	if err != nil {
+		w.close()
+		return fmt.Errorf("subscribing to %q failed: %v", w.EventlogName, err)
+	}
+
+	w.publishers = make(map[string]EvtHandle)
+	return nil
+}
+
+// Gather reads the events received since the last gather.
+func (w *WinEventLog) Gather(acc telegraf.Accumulator) error {
+	w.Lock()
+	defer w.Unlock()
+
+	events := make([]EvtHandle, w.BatchSize)
+	for {
+		n, err := EvtNext(w.subscription, events)
+		if err == ERROR_NO_MORE_ITEMS || err == ERROR_INVALID_OPERATION {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading events failed: %v", err)
+		}
+
+		for _, event := range events[:n] {
+			if err := w.addEvent(acc, event); err != nil {
+				acc.AddError(err)
+			}
+			EvtClose(event)
+		}
+	}
+}
+
+func (w *WinEventLog) addEvent(acc telegraf.Accumulator, handle EvtHandle) error {
+	data, err := EvtRender(handle, EvtRenderEventXml)
+	if err != nil {
+		return fmt.Errorf("rendering event failed: %v", err)
+	}
+	event, err := parseEvent([]byte(data))
+	if err != nil {
+		return fmt.Errorf("parsing event failed: %v", err)
+	}
+
+	// Events of providers without message files have no message.
+	if publisher := w.publisher(event.Provider.Name); publisher != 0 {
+		event.Message, err = EvtFormatMessage(publisher, handle)
+		if err != nil {
+			w.Log.Debugf("Formatting message of event %d of %q failed: %v",
+				event.EventRecordID, event.Provider.Name, err)
+		}
+	}
+
+	tags, fields, tm := event.toMetric(w.OnlyFirstLineOfMessage)
+	acc.AddFields("win_eventlog", fields, tags, tm)
+
+	if err := EvtUpdateBookmark(w.bookmark, handle); err != nil {
+		return fmt.Errorf("updating bookmark failed: %v", err)
+	}
+	w.bookmarked = true
+	return nil
+}
+
+// publisher returns the metadata of the provider, the metadata is opened
+// once per provider.
+func (w *WinEventLog) publisher(name string) EvtHandle {
+	if publisher, ok := w.publishers[name]; ok {
+		return publisher
+	}
+
+	publisher, err := EvtOpenPublisherMetadata(name)
+	if err != nil {
+		w.Log.Debugf("Opening metadata of %q failed: %v", name, err)
+	}
+	w.publishers[name] = publisher
+	return publisher
+}
+
+func (w *WinEventLog) Stop() {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.bookmark != 0 && w.bookmarked {
+		if bookmarkXML, err := EvtRender(w.bookmark, EvtRenderBookmark); err == nil {
+			w.bookmarkXML = bookmarkXML
+		}
+	}
+	w.close()
+}
+
+func (w *WinEventLog) close() {
+	for _, publisher := range w.publishers {
+		if publisher != 0 {
+			EvtClose(publisher)
+		}
+	}
+	w.publishers = nil
+
+	if w.subscription != 0 {
+		EvtClose(w.subscription)
+		w.subscription = 0
+	}
+	if w.signal != 0 {
+		windows.CloseHandle(w.signal)
+		w.signal = 0
+	}
+	if w.bookmark != 0 {
+		EvtClose(w.bookmark)
+		w.bookmark = 0
+	}
+}
+
+// GetState returns the XML rendering of the bookmark of the last event read.
+func (w *WinEventLog) GetState() interface{} {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.bookmark != 0 && w.bookmarked {
+		if bookmarkXML, err := EvtRender(w.bookmark, EvtRenderBookmark); err == nil {
+			w.bookmarkXML = bookmarkXML
+		}
+	}
+	return w.bookmarkXML
+}
+
+// SetState restores the bookmark, reading continues after the last event
+// read when the plugin is started.
+func (w *WinEventLog) SetState(state interface{}) error {
+	bookmarkXML, ok := state.(string)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	w.bookmarkXML = bookmarkXML
+	return nil
+}
+
+func init() {
+	inputs.Add("win_eventlog", func() telegraf.Input {
+		return &WinEventLog{
+			EventlogName:           "Application",
+			Query:                  "*",
+			OnlyFirstLineOfMessage: true,
+			BatchSize:              100,
+		}
+	})
+}
@@ -28,6 +28,11 @@ var sampleConfig = `
   # and in case of localized Windows, counter paths will be also localized. It also returns instance indexes in instance names.
   # If false, wildcards (not partial) in instance names will still be expanded, but instance indexes will not be returned in instance names.
   #UseWildcardsExpansion = false
+  # When running on a localized version of Windows and with UseWildcardsExpansion = true, Windows will
+  # localize the names of performance counters. When LocalizeWildcardsExpansion is set to false, the
+  # expanded instances are reported with the English object and counter names of the configuration.
+  # Wildcards can then be used in instance names only.
+  #LocalizeWildcardsExpansion = true
   # Period after which counters will be reread from configuration and wildcards in counter paths expanded
   CountersRefreshInterval="1m"
 
@@ -83,6 +88,9 @@ type Win_PerfCounters struct {
 	Object                  []perfobject
 	CountersRefreshInterval internal.Duration
 	UseWildcardsExpansion   bool
+	// LocalizeWildcardsExpansion selects whether the localized names of the
+	// expanded counters are reported.
+	LocalizeWildcardsExpansion bool
 
 	lastRefreshed time.Time
 	counters      []*counter
@@ -199,6 +207,12 @@ func (m *Win_PerfCounters) AddItem(counterPath string, objectName string, instan
 
 	if m.UseWildcardsExpansion {
 		origInstance := instance
+		origObjectName := objectName
+		origCounterName := counterName
+		if !m.LocalizeWildcardsExpansion && strings.Contains(counterName, "*") {
+			return fmt.Errorf("wildcards can't be used in counter names with LocalizeWildcardsExpansion=false: %s", counterPath)
+		}
+
 		counterPath, err = m.query.GetCounterPath(counterHandle)
 		if err != nil {
 			return err
@@ -209,9 +223,6 @@ func (m *Win_PerfCounters) AddItem(counterPath string, objectName string, instan
 		}
 
 		for _, counterPath := range counters {
-			var err error
-			counterHandle, err := m.query.AddCounterToQuery(counterPath)
-
 			objectName, instance, counterName, err = extractCounterInfoFromCounterPath(counterPath)
 			if err != nil {
 				return err
@@ -221,6 +232,28 @@ func (m *Win_PerfCounters) AddItem(counterPath string, objectName string, instan
 				continue
 			}
 
+			if !m.LocalizeWildcardsExpansion {
+				// The expanded paths are localized, only their instance is
+				// kept and the English names of the configuration are used.
+				objectName, counterName = origObjectName, origCounterName
+				if instance == "" {
+					counterPath = "\\" + objectName + "\\" + counterName
+				} else {
+					counterPath = "\\" + objectName + "(" + instance + ")\\" + counterName
+				}
+				if m.query.IsVistaOrNewer() {
+					counterHandle, err = m.query.AddEnglishCounterToQuery(counterPath)
+				} else {
+					counterHandle, err = m.query.AddCounterToQuery(counterPath)
+				}
+			} else {
+				counterHandle, err = m.query.AddCounterToQuery(counterPath)
+			}
+			if err != nil {
+				// The instance may have ended after the expansion
+				continue
+			}
+
 			newItem := &counter{counterPath, objectName, counterName, instance, measurement,
 				includeTotal, counterHandle}
 			m.counters = append(m.counters, newItem)
@@ -403,6 +436,6 @@ func isKnownCounterDataError(err error) bool {
 
 func init() {
 	inputs.Add("win_perf_counters", func() telegraf.Input {
-		return &Win_PerfCounters{query: &PerformanceQueryImpl{}, CountersRefreshInterval: internal.Duration{Duration: time.Second * 60}}
+		return &Win_PerfCounters{query: &PerformanceQueryImpl{}, CountersRefreshInterval: internal.Duration{Duration: time.Second * 60}, LocalizeWildcardsExpansion: true}
 	})
 }
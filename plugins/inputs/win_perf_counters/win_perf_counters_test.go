@@ -265,7 +265,7 @@ func TestAddItemSimple(t *testing.T) {
 func TestAddItemInvalidCountPath(t *testing.T) {
 	var err error
 	cps1 := []string{"\\O\\C"}
-	m := Win_PerfCounters{PrintValid: false, Object: nil, UseWildcardsExpansion: true, query: &FakePerformanceQuery{
+	m := Win_PerfCounters{PrintValid: false, Object: nil, UseWildcardsExpansion: true, LocalizeWildcardsExpansion: true, query: &FakePerformanceQuery{
 		counters: createCounterMap(cps1, []float64{1.1}, []uint32{0}),
 		expandPaths: map[string][]string{
 			cps1[0]: {"\\O/C"},
@@ -413,7 +413,7 @@ func TestParseConfigTotalExpansion(t *testing.T) {
 	var err error
 	perfObjects := createPerfObject("m", "O", []string{"*"}, []string{"*"}, true, true)
 	cps1 := []string{"\\O(I1)\\C1", "\\O(I1)\\C2", "\\O(_Total)\\C1", "\\O(_Total)\\C2"}
-	m := Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, Object: perfObjects, query: &FakePerformanceQuery{
+	m := Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, LocalizeWildcardsExpansion: true, Object: perfObjects, query: &FakePerformanceQuery{
 		counters: createCounterMap(append(cps1, "\\O(*)\\*"), []float64{1.1, 1.2, 1.3, 1.4, 0}, []uint32{0, 0, 0, 0, 0}),
 		expandPaths: map[string][]string{
 			"\\O(*)\\*": cps1,
@@ -430,7 +430,7 @@ func TestParseConfigTotalExpansion(t *testing.T) {
 
 	perfObjects[0].IncludeTotal = false
 
-	m = Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, Object: perfObjects, query: &FakePerformanceQuery{
+	m = Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, LocalizeWildcardsExpansion: true, Object: perfObjects, query: &FakePerformanceQuery{
 		counters: createCounterMap(append(cps1, "\\O(*)\\*"), []float64{1.1, 1.2, 1.3, 1.4, 0}, []uint32{0, 0, 0, 0, 0}),
 		expandPaths: map[string][]string{
 			"\\O(*)\\*": cps1,
@@ -450,7 +450,7 @@ func TestParseConfigExpand(t *testing.T) {
 	var err error
 	perfObjects := createPerfObject("m", "O", []string{"*"}, []string{"*"}, false, false)
 	cps1 := []string{"\\O(I1)\\C1", "\\O(I1)\\C2", "\\O(I2)\\C1", "\\O(I2)\\C2"}
-	m := Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, Object: perfObjects, query: &FakePerformanceQuery{
+	m := Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, LocalizeWildcardsExpansion: true, Object: perfObjects, query: &FakePerformanceQuery{
 		counters: createCounterMap(append(cps1, "\\O(*)\\*"), []float64{1.1, 1.2, 1.3, 1.4, 0}, []uint32{0, 0, 0, 0, 0}),
 		expandPaths: map[string][]string{
 			"\\O(*)\\*": cps1,
@@ -666,7 +666,7 @@ func TestGatherRefreshingWithExpansion(t *testing.T) {
 		},
 		vistaAndNewer: true,
 	}
-	m := Win_PerfCounters{PrintValid: false, Object: perfObjects, UseWildcardsExpansion: true, query: fpm, CountersRefreshInterval: internal.Duration{Duration: time.Second * 10}}
+	m := Win_PerfCounters{PrintValid: false, Object: perfObjects, UseWildcardsExpansion: true, LocalizeWildcardsExpansion: true, query: fpm, CountersRefreshInterval: internal.Duration{Duration: time.Second * 10}}
 	var acc1 testutil.Accumulator
 	err = m.Gather(&acc1)
 	assert.Len(t, m.counters, 4)
@@ -911,6 +911,71 @@ func TestGatherTotalNoExpansion(t *testing.T) {
 	acc2.AssertDoesNotContainsTaggedFields(t, measurement, fields2, tags2)
 }
 
+func TestGatherLocalizedExpansion(t *testing.T) {
+	var err error
+	measurement := "m"
+	// The expansion of the English path returns the localized paths.
+	cpsEnglish := []string{"\\O(I1)\\C", "\\O(I2)\\C"}
+	cpsLocalized := []string{"\\LO(I1)\\LC", "\\LO(I2)\\LC"}
+	newQuery := func() *FakePerformanceQuery {
+		return &FakePerformanceQuery{
+			counters: createCounterMap(append(append([]string{"\\O(*)\\C"}, cpsEnglish...), cpsLocalized...),
+				[]float64{0, 1.1, 1.2, 2.1, 2.2}, []uint32{0, 0, 0, 0, 0}),
+			expandPaths: map[string][]string{
+				"\\O(*)\\C": cpsLocalized,
+			},
+			vistaAndNewer: true,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		localize   bool
+		objectName string
+		field      string
+		values     []float32
+	}{
+		{name: "localized", localize: true, objectName: "LO", field: "LC", values: []float32{2.1, 2.2}},
+		{name: "english", localize: false, objectName: "O", field: "C", values: []float32{1.1, 1.2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			perfObjects := createPerfObject(measurement, "O", []string{"*"}, []string{"C"}, true, false)
+			m := Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, LocalizeWildcardsExpansion: test.localize, Object: perfObjects, query: newQuery()}
+			var acc testutil.Accumulator
+			err = m.Gather(&acc)
+			require.NoError(t, err)
+			assert.Len(t, m.counters, 2)
+			assert.Len(t, acc.Metrics, 2)
+			for i, instance := range []string{"I1", "I2"} {
+				acc.AssertContainsTaggedFields(t, measurement,
+					map[string]interface{}{test.field: test.values[i]},
+					map[string]string{"instance": instance, "objectname": test.objectName})
+			}
+		})
+	}
+}
+
+func TestParseConfigNoLocalizedCounterWildcard(t *testing.T) {
+	var err error
+	perfObjects := createPerfObject("m", "O", []string{"*"}, []string{"*"}, true, false)
+	cps1 := []string{"\\O(I1)\\C1", "\\O(I1)\\C2"}
+	m := Win_PerfCounters{PrintValid: false, UseWildcardsExpansion: true, LocalizeWildcardsExpansion: false, Object: perfObjects, query: &FakePerformanceQuery{
+		counters: createCounterMap(append(cps1, "\\O(*)\\*"), []float64{1.1, 1.2, 0}, []uint32{0, 0, 0}),
+		expandPaths: map[string][]string{
+			"\\O(*)\\*": cps1,
+		},
+		vistaAndNewer: true,
+	}}
+	err = m.query.Open()
+	require.NoError(t, err)
+	err = m.ParseConfig()
+	require.Error(t, err)
+	assert.Len(t, m.counters, 0)
+	err = m.query.Close()
+	require.NoError(t, err)
+}
+
 // list of nul terminated strings from WinAPI
 var unicodeStringListWithEnglishChars = []uint16{0x5c, 0x5c, 0x54, 0x34, 0x38, 0x30, 0x5c, 0x50, 0x68, 0x79, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x44, 0x69, 0x73, 0x6b, 0x28, 0x30, 0x20, 0x43, 0x3a, 0x29, 0x5c, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x20, 0x44, 0x69, 0x73, 0x6b, 0x20, 0x51, 0x75, 0x65, 0x75, 0x65, 0x20, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x0, 0x5c, 0x5c, 0x54, 0x34, 0x38, 0x30, 0x5c, 0x50, 0x68, 0x79, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x44, 0x69, 0x73, 0x6b, 0x28, 0x5f, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x29, 0x5c, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x20, 0x44, 0x69, 0x73, 0x6b, 0x20, 0x51, 0x75, 0x65, 0x75, 0x65, 0x20, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x0, 0x0}
 var unicodeStringListWithCzechChars = []uint16{0x5c, 0x5c, 0x54, 0x34, 0x38, 0x30, 0x5c, 0x46, 0x79, 0x7a, 0x69, 0x63, 0x6b, 0xfd, 0x20, 0x64, 0x69, 0x73, 0x6b, 0x28, 0x30, 0x20, 0x43, 0x3a, 0x29, 0x5c, 0x41, 0x6b, 0x74, 0x75, 0xe1, 0x6c, 0x6e, 0xed, 0x20, 0x64, 0xe9, 0x6c, 0x6b, 0x61, 0x20, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x79, 0x20, 0x64, 0x69, 0x73, 0x6b, 0x75, 0x0, 0x5c, 0x5c, 0x54, 0x34, 0x38, 0x30, 0x5c, 0x46, 0x79, 0x7a, 0x69, 0x63, 0x6b, 0xfd, 0x20, 0x64, 0x69, 0x73, 0x6b, 0x28, 0x5f, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x29, 0x5c, 0x41, 0x6b, 0x74, 0x75, 0xe1, 0x6c, 0x6e, 0xed, 0x20, 0x64, 0xe9, 0x6c, 0x6b, 0x61, 0x20, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x79, 0x20, 0x64, 0x69, 0x73, 0x6b, 0x75, 0x0, 0x0}
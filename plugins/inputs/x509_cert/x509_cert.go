@@ -4,7 +4,6 @@ package x509_cert
 import (
 	"crypto/tls"
 	"crypto/x509"
-	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
@@ -26,13 +25,11 @@ const sampleConfig = `
   ## Timeout for SSL connection
   # timeout = "5s"
 
-  ## Optional TLS Config
+  ## Optional TLS Config, the certificates are verified against tls_ca or
+  ## against the system roots when unset.
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
-
-  ## Use TLS but skip chain & host verification
-  # insecure_skip_verify = false
 `
 const description = "Reads metrics from a SSL certificate"
 
@@ -41,6 +38,8 @@ type X509Cert struct {
 	Sources []string          `toml:"sources"`
 	Timeout internal.Duration `toml:"timeout"`
 	_tls.ClientConfig
+
+	tlsCfg *tls.Config
 }
 
 // Description returns description of the plugin.
@@ -53,16 +52,7 @@ func (c *X509Cert) SampleConfig() string {
 	return sampleConfig
 }
 
-func (c *X509Cert) getCert(location string, timeout time.Duration) ([]*x509.Certificate, error) {
-	if strings.HasPrefix(location, "/") {
-		location = "file://" + location
-	}
-
-	u, err := url.Parse(location)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse cert location - %s\n", err.Error())
-	}
-
+func (c *X509Cert) getCert(u *url.URL, timeout time.Duration) ([]*x509.Certificate, error) {
 	switch u.Scheme {
 	case "https":
 		u.Scheme = "tcp"
@@ -70,21 +60,17 @@ func (c *X509Cert) getCert(location string, timeout time.Duration) ([]*x509.Cert
 	case "udp", "udp4", "udp6":
 		fallthrough
 	case "tcp", "tcp4", "tcp6":
-		tlsCfg, err := c.ClientConfig.TLSConfig()
-		if err != nil {
-			return nil, err
-		}
-
 		ipConn, err := net.DialTimeout(u.Scheme, u.Host, timeout)
 		if err != nil {
 			return nil, err
 		}
 		defer ipConn.Close()
 
-		if tlsCfg == nil {
-			tlsCfg = &tls.Config{}
-		}
+		// The certificates are retrieved even if invalid, they are verified
+		// after the handshake.
+		tlsCfg := c.tlsCfg.Clone()
 		tlsCfg.ServerName = u.Hostname()
+		tlsCfg.InsecureSkipVerify = true
 		conn := tls.Client(ipConn, tlsCfg)
 		defer conn.Close()
 
@@ -102,19 +88,30 @@ func (c *X509Cert) getCert(location string, timeout time.Duration) ([]*x509.Cert
 			return nil, err
 		}
 
-		block, _ := pem.Decode(content)
-		if block == nil {
-			return nil, fmt.Errorf("failed to parse certificate PEM")
+		var certs []*x509.Certificate
+		for {
+			block, rest := pem.Decode(content)
+			if block == nil {
+				break
+			}
+			content = rest
+
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
 		}
-
-		cert, err := x509.ParseCertificate(block.Bytes)
-		if err != nil {
-			return nil, err
+		if len(certs) == 0 {
+			return nil, fmt.Errorf("failed to parse certificate PEM")
 		}
 
-		return []*x509.Certificate{cert}, nil
+		return certs, nil
 	default:
-		return nil, fmt.Errorf("unsuported scheme '%s' in location %s\n", u.Scheme, location)
+		return nil, fmt.Errorf("unsuported scheme '%s' in location %s\n", u.Scheme, u.String())
 	}
 }
 
@@ -125,19 +122,23 @@ func getFields(cert *x509.Certificate, now time.Time) map[string]interface{} {
 	enddate := cert.NotAfter.Unix()
 
 	fields := map[string]interface{}{
-		"age":       age,
-		"expiry":    expiry,
-		"startdate": startdate,
-		"enddate":   enddate,
+		"age":         age,
+		"expiry":      expiry,
+		"expiry_days": expiry / 86400,
+		"startdate":   startdate,
+		"enddate":     enddate,
+		"san_count":   len(cert.DNSNames) + len(cert.EmailAddresses) + len(cert.IPAddresses) + len(cert.URIs),
 	}
 
 	return fields
 }
 
-func getTags(subject pkix.Name, location string) map[string]string {
+func getTags(cert *x509.Certificate, location string) map[string]string {
+	subject := cert.Subject
 	tags := map[string]string{
-		"source":      location,
-		"common_name": subject.CommonName,
+		"source":             location,
+		"common_name":        subject.CommonName,
+		"issuer_common_name": cert.Issuer.CommonName,
 	}
 
 	if len(subject.Organization) > 0 {
@@ -155,23 +156,88 @@ func getTags(subject pkix.Name, location string) map[string]string {
 	if len(subject.Locality) > 0 {
 		tags["locality"] = subject.Locality[0]
 	}
+	if len(cert.Issuer.Organization) > 0 {
+		tags["issuer_organization"] = cert.Issuer.Organization[0]
+	}
 
 	return tags
 }
 
+// verifyCert verifies the certificate against the roots, the remaining
+// certificates of the chain are used as intermediates.  The host name is
+// only verified when not empty.
+func (c *X509Cert) verifyCert(cert *x509.Certificate, chain []*x509.Certificate, dnsName string) error {
+	opts := x509.VerifyOptions{
+		DNSName:       dnsName,
+		Roots:         c.tlsCfg.RootCAs,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, intermediate := range chain {
+		if intermediate != cert {
+			opts.Intermediates.AddCert(intermediate)
+		}
+	}
+
+	_, err := cert.Verify(opts)
+	return err
+}
+
+func parseLocation(location string) (*url.URL, error) {
+	if strings.HasPrefix(location, "/") {
+		location = "file://" + location
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cert location - %s\n", err.Error())
+	}
+	return u, nil
+}
+
 // Gather adds metrics into the accumulator.
 func (c *X509Cert) Gather(acc telegraf.Accumulator) error {
+	if c.tlsCfg == nil {
+		tlsCfg, err := c.ClientConfig.TLSConfig()
+		if err != nil {
+			return err
+		}
+		if tlsCfg == nil {
+			tlsCfg = &tls.Config{}
+		}
+		c.tlsCfg = tlsCfg
+	}
+
 	now := time.Now()
 
 	for _, location := range c.Sources {
-		certs, err := c.getCert(location, c.Timeout.Duration*time.Second)
+		u, err := parseLocation(location)
+		if err != nil {
+			acc.AddError(fmt.Errorf("cannot get SSL cert '%s': %s", location, err.Error()))
+			continue
+		}
+
+		certs, err := c.getCert(u, c.Timeout.Duration*time.Second)
 		if err != nil {
 			acc.AddError(fmt.Errorf("cannot get SSL cert '%s': %s", location, err.Error()))
 		}
 
-		for _, cert := range certs {
+		for i, cert := range certs {
 			fields := getFields(cert, now)
-			tags := getTags(cert.Subject, location)
+			tags := getTags(cert, location)
+
+			// The host name is verified for the server certificate only.
+			var dnsName string
+			if i == 0 && u.Scheme != "file" {
+				dnsName = u.Hostname()
+			}
+			if err := c.verifyCert(cert, certs, dnsName); err != nil {
+				tags["verification"] = "invalid"
+				fields["verification_code"] = 1
+				fields["verification_error"] = err.Error()
+			} else {
+				tags["verification"] = "valid"
+				fields["verification_code"] = 0
+			}
 
 			acc.AddFields("x509_cert", fields, tags)
 		}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 	"testing"
 	"time"
@@ -272,3 +273,93 @@ func TestGatherCert(t *testing.T) {
 
 	assert.True(t, acc.HasMeasurement("x509_cert"))
 }
+
+func TestGatherVerification(t *testing.T) {
+	f, err := ioutil.TempFile("", "x509_cert")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.Write([]byte(pki.ReadServerCert() + pki.ReadCACert()))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	pair, err := tls.X509KeyPair([]byte(pki.ReadServerCert()), []byte(pki.ReadServerKey()))
+	require.NoError(t, err)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{pair}})
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		source       string
+		tlsCA        string
+		verification []string
+	}{
+		{
+			name:         "file with ca",
+			source:       f.Name(),
+			tlsCA:        pki.CACertPath(),
+			verification: []string{"valid", "valid"},
+		},
+		{
+			name:         "file without ca",
+			source:       f.Name(),
+			verification: []string{"invalid", "invalid"},
+		},
+		{
+			name:         "server with ca",
+			source:       "tcp://127.0.0.1:" + port,
+			tlsCA:        pki.CACertPath(),
+			verification: []string{"valid"},
+		},
+		{
+			name:         "server without ca",
+			source:       "tcp://127.0.0.1:" + port,
+			verification: []string{"invalid"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sc := X509Cert{
+				Sources: []string{test.source},
+				Timeout: internal.Duration{Duration: 5},
+			}
+			sc.TLSCA = test.tlsCA
+
+			acc := testutil.Accumulator{}
+			require.NoError(t, sc.Gather(&acc))
+			require.Empty(t, acc.Errors)
+			require.Len(t, acc.Metrics, len(test.verification))
+
+			for i, verification := range test.verification {
+				m := acc.Metrics[i]
+				require.Equal(t, verification, m.Tags["verification"])
+				require.Equal(t, "Telegraf Test CA", m.Tags["issuer_common_name"])
+				if verification == "valid" {
+					require.Equal(t, 0, m.Fields["verification_code"])
+					require.NotContains(t, m.Fields, "verification_error")
+				} else {
+					require.Equal(t, 1, m.Fields["verification_code"])
+					require.Contains(t, m.Fields, "verification_error")
+				}
+			}
+
+			server := acc.Metrics[0]
+			require.Equal(t, "server.localdomain", server.Tags["common_name"])
+			require.Equal(t, 2, server.Fields["san_count"])
+			require.Equal(t, server.Fields["expiry"].(int)/86400, server.Fields["expiry_days"])
+		})
+	}
+}
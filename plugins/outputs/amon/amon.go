@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -17,6 +18,7 @@ type Amon struct {
 	ServerKey    string
 	AmonInstance string
 	Timeout      internal.Duration
+	httpconfig.ProxyConfig
 
 	client *http.Client
 }
@@ -30,6 +32,12 @@ var sampleConfig = `
 
   ## Connection timeout.
   # timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 type TimeSeries struct {
@@ -47,9 +55,14 @@ func (a *Amon) Connect() error {
 	if a.ServerKey == "" || a.AmonInstance == "" {
 		return fmt.Errorf("serverkey and amon_instance are required fields for amon output")
 	}
+	proxy, err := a.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+
 	a.client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: proxy,
 		},
 		Timeout: a.Timeout.Duration,
 	}
@@ -155,6 +168,8 @@ func (a *Amon) Close() error {
 
 func init() {
 	outputs.Add("amon", func() telegraf.Output {
-		return &Amon{}
+		return &Amon{
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
+		}
 	})
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/metrictemplate"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -55,13 +56,15 @@ type AMQP struct {
 	Headers            map[string]string `toml:"headers"`
 	Timeout            internal.Duration `toml:"timeout"`
 	UseBatchFormat     bool              `toml:"use_batch_format"`
+	UseConfirms        bool              `toml:"use_confirms"`
 	tls.ClientConfig
 
-	serializer   serializers.Serializer
-	connect      func(*ClientConfig) (Client, error)
-	client       Client
-	config       *ClientConfig
-	sentMessages int
+	serializer         serializers.Serializer
+	connect            func(*ClientConfig) (Client, error)
+	client             Client
+	config             *ClientConfig
+	sentMessages       int
+	routingKeyTemplate *metrictemplate.Template
 }
 
 type Client interface {
@@ -91,7 +94,7 @@ var sampleConfig = `
   # exchange_type = "topic"
 
   ## If true, exchange will be passively declared.
-  # exchange_declare_passive = false
+  # exchange_passive = false
 
   ## Exchange durability can be either "transient" or "durable".
   # exchange_durability = "durable"
@@ -114,9 +117,13 @@ var sampleConfig = `
   # routing_tag = "host"
 
   ## Static routing key.  Used when no routing_tag is set or as a fallback
-  ## when the tag specified in routing tag is not found.
+  ## when the tag specified in routing tag is not found.  The routing key is
+  ## a template, the notation {{.Tag "tag_name"}} is replaced by the value of
+  ## the tag, or an empty string if the metric does not have the tag, and
+  ## {{.Name}} by the name of the metric.
   # routing_key = ""
   # routing_key = "telegraf"
+  # routing_key = 'telegraf.{{.Tag "host"}}'
 
   ## Delivery Mode controls if a published message is persistent.
   ##   One of "transient" or "persistent".
@@ -138,6 +145,11 @@ var sampleConfig = `
   ## timeout (not recommended).
   # timeout = "5s"
 
+  ## If true, the channel is put in confirm mode and each message must be
+  ## acknowledged by the broker within the timeout, otherwise the write fails
+  ## and the metrics are sent again on the next flush.
+  # use_confirms = false
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -170,6 +182,10 @@ func (q *AMQP) SetSerializer(serializer serializers.Serializer) {
 }
 
 func (q *AMQP) Connect() error {
+	if err := q.parseRoutingKey(); err != nil {
+		return err
+	}
+
 	if q.config == nil {
 		config, err := q.makeClientConfig()
 		if err != nil {
@@ -201,7 +217,25 @@ func (q *AMQP) routingKey(metric telegraf.Metric) string {
 			return key
 		}
 	}
-	return q.RoutingKey
+	if q.routingKeyTemplate == nil {
+		return q.RoutingKey
+	}
+	key, err := q.routingKeyTemplate.Render(metric)
+	if err != nil {
+		log.Printf("E! [outputs.amqp] Error executing routing key template: %v", err)
+		return q.RoutingKey
+	}
+	return key
+}
+
+// parseRoutingKey parses the routing key template.
+func (q *AMQP) parseRoutingKey() error {
+	tmpl, err := metrictemplate.New("routing_key", q.RoutingKey)
+	if err != nil {
+		return fmt.Errorf("invalid routing_key template: %v", err)
+	}
+	q.routingKeyTemplate = tmpl
+	return nil
 }
 
 func (q *AMQP) Write(metrics []telegraf.Metric) error {
@@ -300,6 +334,7 @@ func (q *AMQP) makeClientConfig() (*ClientConfig, error) {
 		exchangeType:    q.ExchangeType,
 		exchangePassive: q.ExchangePassive,
 		timeout:         q.Timeout.Duration,
+		confirms:        q.UseConfirms,
 	}
 
 	switch q.ExchangeDurability {
@@ -4,7 +4,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/require"
 )
@@ -138,6 +141,19 @@ func TestConnect(t *testing.T) {
 				require.NoError(t, err)
 			},
 		},
+		{
+			name: "use confirms",
+			output: &AMQP{
+				UseConfirms: true,
+				connect: func(config *ClientConfig) (Client, error) {
+					return NewMockClient(), nil
+				},
+			},
+			errFunc: func(t *testing.T, output *AMQP, err error) {
+				require.True(t, output.config.confirms)
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "url support",
 			output: &AMQP{
@@ -160,3 +176,59 @@ func TestConnect(t *testing.T) {
 		})
 	}
 }
+
+func TestRoutingKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		output *AMQP
+		keys   []string
+	}{
+		{
+			name: "routing tag with fallback",
+			output: &AMQP{
+				RoutingTag: "host",
+				RoutingKey: "telegraf",
+			},
+			keys: []string{"server01", "telegraf"},
+		},
+		{
+			name: "routing key template",
+			output: &AMQP{
+				RoutingKey: `telegraf.{{.Tag "host"}}.{{ .Tag "region" }}`,
+			},
+			keys: []string{"telegraf.server01.us-east-1", "telegraf..us-west-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			client := &MockClient{
+				PublishF: func(key string, body []byte) error {
+					keys = append(keys, key)
+					return nil
+				},
+			}
+			require.NoError(t, tt.output.parseRoutingKey())
+			tt.output.ExchangeType = DefaultExchangeType
+			tt.output.serializer = influx.NewSerializer()
+			tt.output.client = client
+
+			metrics := []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"host": "server01", "region": "us-east-1"},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"region": "us-west-1"},
+					map[string]interface{}{"value": 42.0},
+					time.Unix(0, 0),
+				),
+			}
+			require.NoError(t, tt.output.Write(metrics))
+			require.ElementsMatch(t, tt.keys, keys)
+		})
+	}
+}
@@ -24,12 +24,14 @@ type ClientConfig struct {
 	tlsConfig         *tls.Config
 	timeout           time.Duration
 	auth              []amqp.Authentication
+	confirms          bool
 }
 
 type client struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	config  *ClientConfig
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	config   *ClientConfig
+	confirms chan amqp.Confirmation
 }
 
 // Connect opens a connection to one of the brokers at random
@@ -68,6 +70,14 @@ func Connect(config *ClientConfig) (*client, error) {
 	}
 	client.channel = channel
 
+	if config.confirms {
+		err = channel.Confirm(false)
+		if err != nil {
+			return nil, fmt.Errorf("error setting confirm mode: %v", err)
+		}
+		client.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	}
+
 	err = client.DeclareExchange()
 	if err != nil {
 		return nil, err
@@ -77,6 +87,11 @@ func Connect(config *ClientConfig) (*client, error) {
 }
 
 func (c *client) DeclareExchange() error {
+	// The default exchange is always available and cannot be declared.
+	if c.config.exchange == "" {
+		return nil
+	}
+
 	var err error
 	if c.config.exchangePassive {
 		err = c.channel.ExchangeDeclarePassive(
@@ -106,9 +121,9 @@ func (c *client) DeclareExchange() error {
 }
 
 func (c *client) Publish(key string, body []byte) error {
-	// Note that since the channel is not in confirm mode, the absence of
-	// an error does not indicate successful delivery.
-	return c.channel.Publish(
+	// Note that unless the channel is in confirm mode, the absence of an
+	// error does not indicate successful delivery.
+	err := c.channel.Publish(
 		c.config.exchange, // exchange
 		key,               // routing key
 		false,             // mandatory
@@ -119,6 +134,31 @@ func (c *client) Publish(key string, body []byte) error {
 			Body:         body,
 			DeliveryMode: c.config.deliveryMode,
 		})
+	if err != nil || c.confirms == nil {
+		return err
+	}
+
+	var timeout <-chan time.Time
+	if c.config.timeout > 0 {
+		timer := time.NewTimer(c.config.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case confirm, ok := <-c.confirms:
+		if !ok {
+			return amqp.ErrClosed
+		}
+		if !confirm.Ack {
+			return fmt.Errorf("message %d was not acknowledged by the broker", confirm.DeliveryTag)
+		}
+		return nil
+	case <-timeout:
+		// A late confirm would be taken for the one of the next message.
+		c.Close()
+		return errors.New("timeout waiting for publisher confirm")
+	}
 }
 
 func (c *client) Close() error {
@@ -15,9 +15,11 @@ import (
 	"time"
 
 	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/selfstat"
@@ -32,6 +34,10 @@ type AzureMonitor struct {
 	Region              string
 	ResourceID          string `toml:"resource_id"`
 	EndpointUrl         string `toml:"endpoint_url"`
+	TenantID            string `toml:"tenant_id"`
+	ClientID            string `toml:"client_id"`
+	ClientSecret        string `toml:"client_secret"`
+	httpconfig.ProxyConfig
 
 	url    string
 	auth   autorest.Authorizer
@@ -98,6 +104,20 @@ var sampleConfig = `
   ## cloud environment, set appropriate REST endpoint for receiving
   ## metrics. (Note: region may be unused in this context)
   # endpoint_url = "https://monitoring.core.usgovcloudapi.net"
+
+  ## Service principal credentials; if client_secret is set, tenant_id and
+  ## client_id are required. Setting only client_id selects a user-assigned
+  ## managed identity. If unset, credentials are read from the environment
+  ## or the system-assigned managed identity is used.
+  # tenant_id = ""
+  # client_id = ""
+  # client_secret = ""
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 // Description provides a description of the plugin
@@ -118,9 +138,14 @@ func (a *AzureMonitor) Connect() error {
 		a.Timeout.Duration = defaultRequestTimeout
 	}
 
+	proxy, err := a.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+
 	a.client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: proxy,
 		},
 		Timeout: a.Timeout.Duration,
 	}
@@ -129,7 +154,6 @@ func (a *AzureMonitor) Connect() error {
 		a.NamespacePrefix = defaultNamespacePrefix
 	}
 
-	var err error
 	var region string
 	var resourceID string
 	var endpointUrl string
@@ -165,9 +189,9 @@ func (a *AzureMonitor) Connect() error {
 
 	log.Printf("D! Writing to Azure Monitor URL: %s", a.url)
 
-	a.auth, err = auth.NewAuthorizerFromEnvironmentWithResource(defaultAuthResource)
+	a.auth, err = a.authorizer()
 	if err != nil {
-		return nil
+		return err
 	}
 
 	a.Reset()
@@ -229,6 +253,34 @@ func vmInstanceMetadata(c *http.Client) (string, string, error) {
 	return region, resourceID, nil
 }
 
+// authorizer returns the authorizer for the configured credentials, falling
+// back to the environment and then the managed identity of the VM.
+func (a *AzureMonitor) authorizer() (autorest.Authorizer, error) {
+	if a.ClientSecret != "" {
+		if a.TenantID == "" || a.ClientID == "" {
+			return nil, fmt.Errorf("tenant_id and client_id are required with client_secret")
+		}
+		config := auth.NewClientCredentialsConfig(a.ClientID, a.ClientSecret, a.TenantID)
+		config.Resource = defaultAuthResource
+		return config.Authorizer()
+	}
+
+	if a.ClientID != "" {
+		endpoint, err := adal.GetMSIVMEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		token, err := adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(
+			endpoint, defaultAuthResource, a.ClientID)
+		if err != nil {
+			return nil, err
+		}
+		return autorest.NewBearerAuthorizer(token), nil
+	}
+
+	return auth.NewAuthorizerFromEnvironmentWithResource(defaultAuthResource)
+}
+
 // Close shuts down an any active connections
 func (a *AzureMonitor) Close() error {
 	a.client = nil
@@ -630,7 +682,8 @@ func (a *AzureMonitor) Reset() {
 func init() {
 	outputs.Add("azure_monitor", func() telegraf.Output {
 		return &AzureMonitor{
-			timeFunc: time.Now,
+			timeFunc:    time.Now,
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
 		}
 	})
 }
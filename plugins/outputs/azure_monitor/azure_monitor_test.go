@@ -359,3 +359,13 @@ func TestWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestConnectIncompleteCredentials(t *testing.T) {
+	plugin := &AzureMonitor{
+		Region:       "test",
+		ResourceID:   "/test",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+	require.Error(t, plugin.Connect())
+}
@@ -28,7 +28,9 @@ type CloudWatch struct {
 	Namespace string `toml:"namespace"` // CloudWatch Metrics Namespace
 	svc       *cloudwatch.CloudWatch
 
-	WriteStatistics bool `toml:"write_statistics"`
+	HighResolutionMetrics bool     `toml:"high_resolution_metrics"`
+	WriteStatistics       bool     `toml:"write_statistics"`
+	DimensionTags         []string `toml:"dimension_tags"`
 }
 
 type statisticType int
@@ -47,11 +49,12 @@ type cloudwatchField interface {
 }
 
 type statisticField struct {
-	metricName string
-	fieldName  string
-	tags       map[string]string
-	values     map[statisticType]float64
-	timestamp  time.Time
+	metricName        string
+	fieldName         string
+	tags              map[string]string
+	values            map[statisticType]float64
+	timestamp         time.Time
+	storageResolution int64
 }
 
 func (f *statisticField) addValue(sType statisticType, value float64) {
@@ -81,6 +84,7 @@ func (f *statisticField) buildDatum() []*cloudwatch.MetricDatum {
 				Sum:         aws.Float64(sum),
 				SampleCount: aws.Float64(count),
 			},
+			StorageResolution: aws.Int64(f.storageResolution),
 		}
 
 		datums = append(datums, datum)
@@ -89,9 +93,10 @@ func (f *statisticField) buildDatum() []*cloudwatch.MetricDatum {
 		// If we don't have all required fields, we build each field as independent datum
 		for sType, value := range f.values {
 			datum := &cloudwatch.MetricDatum{
-				Value:      aws.Float64(value),
-				Dimensions: BuildDimensions(f.tags),
-				Timestamp:  aws.Time(f.timestamp),
+				Value:             aws.Float64(value),
+				Dimensions:        BuildDimensions(f.tags),
+				Timestamp:         aws.Time(f.timestamp),
+				StorageResolution: aws.Int64(f.storageResolution),
 			}
 
 			switch sType {
@@ -126,11 +131,12 @@ func (f *statisticField) hasAllFields() bool {
 }
 
 type valueField struct {
-	metricName string
-	fieldName  string
-	tags       map[string]string
-	value      float64
-	timestamp  time.Time
+	metricName        string
+	fieldName         string
+	tags              map[string]string
+	value             float64
+	timestamp         time.Time
+	storageResolution int64
 }
 
 func (f *valueField) addValue(sType statisticType, value float64) {
@@ -143,10 +149,11 @@ func (f *valueField) buildDatum() []*cloudwatch.MetricDatum {
 
 	return []*cloudwatch.MetricDatum{
 		{
-			MetricName: aws.String(strings.Join([]string{f.metricName, f.fieldName}, "_")),
-			Value:      aws.Float64(f.value),
-			Dimensions: BuildDimensions(f.tags),
-			Timestamp:  aws.Time(f.timestamp),
+			MetricName:        aws.String(strings.Join([]string{f.metricName, f.fieldName}, "_")),
+			Value:             aws.Float64(f.value),
+			Dimensions:        BuildDimensions(f.tags),
+			Timestamp:         aws.Time(f.timestamp),
+			StorageResolution: aws.Int64(f.storageResolution),
 		},
 	}
 }
@@ -186,6 +193,15 @@ var sampleConfig = `
   ## You could use basicstats aggregator to calculate those fields. If not all statistic 
   ## fields are available, all fields would still be sent as raw metrics. 
   # write_statistics = false
+
+  ## Enable high resolution metrics of 1 second (if not enabled, standard
+  ## resolution are of 60 seconds precision)
+  # high_resolution_metrics = false
+
+  ## Tags to send as dimensions; each unique combination of dimensions is a
+  ## separate CloudWatch metric, so limiting them reduces cost. If empty, up
+  ## to 10 tags are sent, always including "host".
+  # dimension_tags = []
 `
 
 func (c *CloudWatch) SampleConfig() string {
@@ -220,7 +236,10 @@ func (c *CloudWatch) Write(metrics []telegraf.Metric) error {
 
 	var datums []*cloudwatch.MetricDatum
 	for _, m := range metrics {
-		d := BuildMetricDatum(c.WriteStatistics, m)
+		if len(c.DimensionTags) > 0 {
+			m = filterDimensionTags(m, c.DimensionTags)
+		}
+		d := BuildMetricDatum(c.WriteStatistics, c.HighResolutionMetrics, m)
 		datums = append(datums, d...)
 	}
 
@@ -278,10 +297,14 @@ func PartitionDatums(size int, datums []*cloudwatch.MetricDatum) [][]*cloudwatch
 // Make a MetricDatum from telegraf.Metric. It would check if all required fields of
 // cloudwatch.StatisticSet are available. If so, it would build MetricDatum from statistic values.
 // Otherwise, fields would still been built independently.
-func BuildMetricDatum(buildStatistic bool, point telegraf.Metric) []*cloudwatch.MetricDatum {
+func BuildMetricDatum(buildStatistic bool, highResolutionMetrics bool, point telegraf.Metric) []*cloudwatch.MetricDatum {
 
 	fields := make(map[string]cloudwatchField)
 	tags := point.Tags()
+	storageResolution := int64(60)
+	if highResolutionMetrics {
+		storageResolution = 1
+	}
 
 	for k, v := range point.Fields() {
 
@@ -297,11 +320,12 @@ func BuildMetricDatum(buildStatistic bool, point telegraf.Metric) []*cloudwatch.
 		// If statistic metric is not enabled or non-statistic type, just take current field as a value field.
 		if !buildStatistic || sType == statisticTypeNone {
 			fields[k] = &valueField{
-				metricName: point.Name(),
-				fieldName:  k,
-				tags:       tags,
-				timestamp:  point.Time(),
-				value:      val,
+				metricName:        point.Name(),
+				fieldName:         k,
+				tags:              tags,
+				timestamp:         point.Time(),
+				value:             val,
+				storageResolution: storageResolution,
 			}
 			continue
 		}
@@ -317,6 +341,7 @@ func BuildMetricDatum(buildStatistic bool, point telegraf.Metric) []*cloudwatch.
 				values: map[statisticType]float64{
 					sType: val,
 				},
+				storageResolution: storageResolution,
 			}
 		} else {
 			// Add new statistic value to this field
@@ -375,6 +400,27 @@ func BuildDimensions(mTags map[string]string) []*cloudwatch.Dimension {
 	return dimensions
 }
 
+// filterDimensionTags returns a copy of the metric with only the tags that
+// should become dimensions.
+func filterDimensionTags(m telegraf.Metric, keep []string) telegraf.Metric {
+	m = m.Copy()
+	for k := range m.Tags() {
+		if !choice(k, keep) {
+			m.RemoveTag(k)
+		}
+	}
+	return m
+}
+
+func choice(s string, list []string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func getStatisticType(name string) (sType statisticType, fieldName string) {
 	switch {
 	case strings.HasSuffix(name, "_max"):
@@ -75,11 +75,11 @@ func TestBuildMetricDatums(t *testing.T) {
 		testutil.TestMetric(float64(1.174272e+108)), // largest should be 1.174271e+108
 	}
 	for _, point := range validMetrics {
-		datums := BuildMetricDatum(false, point)
+		datums := BuildMetricDatum(false, false, point)
 		assert.Equal(1, len(datums), fmt.Sprintf("Valid point should create a Datum {value: %v}", point))
 	}
 	for _, point := range invalidMetrics {
-		datums := BuildMetricDatum(false, point)
+		datums := BuildMetricDatum(false, false, point)
 		assert.Equal(0, len(datums), fmt.Sprintf("Valid point should not create a Datum {value: %v}", point))
 	}
 
@@ -89,7 +89,7 @@ func TestBuildMetricDatums(t *testing.T) {
 		map[string]interface{}{"value_max": float64(10), "value_min": float64(0), "value_sum": float64(100), "value_count": float64(20)},
 		time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
 	)
-	datums := BuildMetricDatum(true, statisticMetric)
+	datums := BuildMetricDatum(true, false, statisticMetric)
 	assert.Equal(1, len(datums), fmt.Sprintf("Valid point should create a Datum {value: %v}", statisticMetric))
 
 	multiFieldsMetric, _ := metric.New(
@@ -98,7 +98,7 @@ func TestBuildMetricDatums(t *testing.T) {
 		map[string]interface{}{"valueA": float64(10), "valueB": float64(0), "valueC": float64(100), "valueD": float64(20)},
 		time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
 	)
-	datums = BuildMetricDatum(true, multiFieldsMetric)
+	datums = BuildMetricDatum(true, false, multiFieldsMetric)
 	assert.Equal(4, len(datums), fmt.Sprintf("Each field should create a Datum {value: %v}", multiFieldsMetric))
 
 	multiStatisticMetric, _ := metric.New(
@@ -112,7 +112,7 @@ func TestBuildMetricDatums(t *testing.T) {
 		},
 		time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
 	)
-	datums = BuildMetricDatum(true, multiStatisticMetric)
+	datums = BuildMetricDatum(true, false, multiStatisticMetric)
 	assert.Equal(7, len(datums), fmt.Sprintf("Valid point should create a Datum {value: %v}", multiStatisticMetric))
 }
 
@@ -129,7 +129,7 @@ func TestBuildMetricDatums_SkipEmptyTags(t *testing.T) {
 		time.Unix(0, 0),
 	)
 
-	datums := BuildMetricDatum(true, input)
+	datums := BuildMetricDatum(true, false, input)
 	require.Len(t, datums[0].Dimensions, 1)
 }
 
@@ -153,3 +153,47 @@ func TestPartitionDatums(t *testing.T) {
 	assert.Equal([][]*cloudwatch.MetricDatum{twoDatum}, PartitionDatums(2, twoDatum))
 	assert.Equal([][]*cloudwatch.MetricDatum{twoDatum, oneDatum}, PartitionDatums(2, threeDatum))
 }
+
+func TestBuildMetricDatums_HighResolution(t *testing.T) {
+	input := testutil.MustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(42),
+		},
+		time.Unix(0, 0),
+	)
+
+	datums := BuildMetricDatum(false, false, input)
+	require.Len(t, datums, 1)
+	require.Equal(t, int64(60), *datums[0].StorageResolution)
+
+	datums = BuildMetricDatum(false, true, input)
+	require.Len(t, datums, 1)
+	require.Equal(t, int64(1), *datums[0].StorageResolution)
+}
+
+func TestFilterDimensionTags(t *testing.T) {
+	input := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host":   "example.org",
+			"cpu":    "cpu0",
+			"region": "us-east-1",
+		},
+		map[string]interface{}{
+			"value": int64(42),
+		},
+		time.Unix(0, 0),
+	)
+
+	datums := BuildMetricDatum(false, false, filterDimensionTags(input, []string{"cpu"}))
+	require.Len(t, datums, 1)
+	require.Equal(t, []*cloudwatch.Dimension{
+		{
+			Name:  aws.String("cpu"),
+			Value: aws.String("cpu0"),
+		},
+	}, datums[0].Dimensions)
+	require.Len(t, input.Tags(), 3)
+}
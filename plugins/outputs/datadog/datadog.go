@@ -11,12 +11,14 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
 type Datadog struct {
 	Apikey  string
 	Timeout internal.Duration
+	httpconfig.ProxyConfig
 
 	URL    string `toml:"url"`
 	client *http.Client
@@ -31,6 +33,12 @@ var sampleConfig = `
 
   ## Connection timeout.
   # timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 type TimeSeries struct {
@@ -53,9 +61,14 @@ func (d *Datadog) Connect() error {
 		return fmt.Errorf("apikey is a required field for datadog output")
 	}
 
+	proxy, err := d.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+
 	d.client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: proxy,
 		},
 		Timeout: d.Timeout.Duration,
 	}
@@ -199,7 +212,8 @@ func (d *Datadog) Close() error {
 func init() {
 	outputs.Add("datadog", func() telegraf.Output {
 		return &Datadog{
-			URL: datadog_api,
+			URL:         datadog_api,
+			ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
 		}
 	})
 }
@@ -0,0 +1,13 @@
+package outputs
+
+import "github.com/influxdata/telegraf"
+
+// Deprecations lists the deprecated plugins, a warning is logged when they
+// are used.
+var Deprecations = map[string]telegraf.DeprecationInfo{
+	"riemann_legacy": {
+		Since:     "1.3.0",
+		RemovalIn: "2.0.0",
+		Notice:    "use 'outputs.riemann' instead",
+	},
+}
@@ -3,14 +3,15 @@ package elasticsearch
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"gopkg.in/olivere/elastic.v5"
@@ -29,7 +30,14 @@ type Elasticsearch struct {
 	ManageTemplate      bool
 	TemplateName        string
 	OverwriteTemplate   bool
+	ILMPolicyName       string        `toml:"ilm_policy_name"`
+	ILMPolicy           string        `toml:"ilm_policy"`
+	OverwriteILMPolicy  bool          `toml:"overwrite_ilm_policy"`
+	MaxBulkSize         internal.Size `toml:"max_bulk_size"`
 	tls.ClientConfig
+	httpconfig.ProxyConfig
+
+	Log telegraf.Logger `toml:"-"`
 
 	Client *elastic.Client
 }
@@ -62,8 +70,8 @@ var sampleConfig = `
   # %H - hour (00..23)
   # %V - week of the year (ISO week) (01..53)
   ## Additionally, you can specify a tag name using the notation {{tag_name}}
-  ## which will be used as part of the index name. If the tag does not exist,
-  ## the default tag value will be used.
+  ## or {{.Tag "tag_name"}} which will be used as part of the index name. If
+  ## the tag does not exist, the default tag value will be used.
   # index_name = "telegraf-{{host}}-%Y.%m.%d"
   # default_tag_value = "none"
   index_name = "telegraf-%Y.%m.%d" # required.
@@ -75,6 +83,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = false
+
   ## Template Config
   ## Set to true if you want telegraf to manage its index template.
   ## If enabled it will create a recommended index template for telegraf indexes
@@ -83,6 +97,21 @@ var sampleConfig = `
   template_name = "telegraf"
   ## Set to true if you want telegraf to overwrite an existing template
   overwrite_template = false
+
+  ## Index Lifecycle Management (Elasticsearch 6.6+)
+  ## Name of the ILM policy set on the telegraf indexes by the template.
+  # ilm_policy_name = ""
+  ## Body of the policy, created if it does not exist.  If empty the policy
+  ## must already exist.
+  # ilm_policy = '''
+  #   {"policy": {"phases": {"delete": {"min_age": "30d", "actions": {"delete": {}}}}}}
+  # '''
+  ## Set to true if you want telegraf to overwrite an existing policy
+  # overwrite_ilm_policy = false
+
+  ## Maximum size of a bulk request, larger writes are sent in several
+  ## requests.  Unlimited if not set.
+  # max_bulk_size = "5MB"
 `
 
 func (a *Elasticsearch) Connect() error {
@@ -99,8 +128,14 @@ func (a *Elasticsearch) Connect() error {
 	if err != nil {
 		return err
 	}
+	proxy, err := a.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+
 	tr := &http.Transport{
 		TLSClientConfig: tlsCfg,
+		Proxy:           proxy,
 	}
 
 	httpclient := &http.Client{
@@ -125,7 +160,7 @@ func (a *Elasticsearch) Connect() error {
 		clientOptions = append(clientOptions,
 			elastic.SetHealthcheck(false),
 		)
-		log.Printf("D! Elasticsearch output: disabling health check")
+		a.Log.Debugf("Disabling health check")
 	}
 
 	client, err := elastic.NewClient(clientOptions...)
@@ -142,15 +177,33 @@ func (a *Elasticsearch) Connect() error {
 	}
 
 	// quit if ES version is not supported
-	i, err := strconv.Atoi(strings.Split(esVersion, ".")[0])
+	version := strings.Split(esVersion, ".")
+	i, err := strconv.Atoi(version[0])
 	if err != nil || i < 5 {
 		return fmt.Errorf("Elasticsearch version not supported: %s", esVersion)
 	}
 
-	log.Println("I! Elasticsearch version: " + esVersion)
+	a.Log.Infof("Elasticsearch version: %s", esVersion)
 
 	a.Client = client
 
+	if a.ILMPolicyName != "" {
+		minor := 0
+		if len(version) > 1 {
+			minor, _ = strconv.Atoi(version[1])
+		}
+		if i < 6 || (i == 6 && minor < 6) {
+			return fmt.Errorf("Elasticsearch version %s does not support index lifecycle management", esVersion)
+		}
+
+		if a.ILMPolicy != "" {
+			err := a.manageILMPolicy(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	if a.ManageTemplate {
 		err := a.manageTemplate(ctx)
 		if err != nil {
@@ -171,6 +224,14 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 	bulkRequest := a.Client.Bulk()
 
 	for _, metric := range metrics {
+		if a.MaxBulkSize.Size > 0 && bulkRequest.NumberOfActions() > 0 &&
+			bulkRequest.EstimatedSizeInBytes() >= a.MaxBulkSize.Size {
+			if err := a.sendBulk(bulkRequest); err != nil {
+				return err
+			}
+			bulkRequest = a.Client.Bulk()
+		}
+
 		var name = metric.Name()
 
 		// index name has to be re-evaluated each time for telegraf
@@ -191,6 +252,10 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 
 	}
 
+	return a.sendBulk(bulkRequest)
+}
+
+func (a *Elasticsearch) sendBulk(bulkRequest *elastic.BulkService) error {
 	ctx, cancel := context.WithTimeout(context.Background(), a.Timeout.Duration)
 	defer cancel()
 
@@ -202,13 +267,35 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 
 	if res.Errors {
 		for id, err := range res.Failed() {
-			log.Printf("E! Elasticsearch indexing failure, id: %d, error: %s, caused by: %s, %s", id, err.Error.Reason, err.Error.CausedBy["reason"], err.Error.CausedBy["type"])
+			a.Log.Errorf("Elasticsearch indexing failure, id: %d, error: %s, caused by: %s, %s", id, err.Error.Reason, err.Error.CausedBy["reason"], err.Error.CausedBy["type"])
 		}
 		return fmt.Errorf("W! Elasticsearch failed to index %d metrics", len(res.Failed()))
 	}
 
 	return nil
+}
+
+func (a *Elasticsearch) manageILMPolicy(ctx context.Context) error {
+	path := "/_ilm/policy/" + url.PathEscape(a.ILMPolicyName)
 
+	if !a.OverwriteILMPolicy {
+		res, err := a.Client.PerformRequest(ctx, "GET", path, nil, nil, http.StatusNotFound)
+		if err != nil {
+			return fmt.Errorf("Elasticsearch ILM policy check failed, policy name: %s, error: %s", a.ILMPolicyName, err)
+		}
+		if res.StatusCode == http.StatusOK {
+			a.Log.Debugf("Found existing Elasticsearch ILM policy %s. Skipping policy management", a.ILMPolicyName)
+			return nil
+		}
+	}
+
+	_, err := a.Client.PerformRequest(ctx, "PUT", path, nil, a.ILMPolicy)
+	if err != nil {
+		return fmt.Errorf("Elasticsearch failed to create ILM policy %s : %s", a.ILMPolicyName, err)
+	}
+
+	a.Log.Debugf("Elasticsearch ILM policy %s created or updated", a.ILMPolicyName)
+	return nil
 }
 
 func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
@@ -236,6 +323,12 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 		return fmt.Errorf("Template cannot be created for dynamic index names without an index prefix")
 	}
 
+	var lifecycle string
+	if a.ILMPolicyName != "" {
+		lifecycle = fmt.Sprintf(`,
+						"lifecycle.name": %q`, a.ILMPolicyName)
+	}
+
 	if (a.OverwriteTemplate) || (!templateExists) || (templatePattern != "") {
 		// Create or update the template
 		tmpl := fmt.Sprintf(`
@@ -244,7 +337,7 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 				"settings": {
 					"index": {
 						"refresh_interval": "10s",
-						"mapping.total_fields.limit": 5000
+						"mapping.total_fields.limit": 5000%s
 					}
 				},
 				"mappings" : {
@@ -294,18 +387,18 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 						]
 					}
 				}
-			}`, templatePattern+"*")
+			}`, templatePattern+"*", lifecycle)
 		_, errCreateTemplate := a.Client.IndexPutTemplate(a.TemplateName).BodyString(tmpl).Do(ctx)
 
 		if errCreateTemplate != nil {
 			return fmt.Errorf("Elasticsearch failed to create index template %s : %s", a.TemplateName, errCreateTemplate)
 		}
 
-		log.Printf("D! Elasticsearch template %s created or updated\n", a.TemplateName)
+		a.Log.Debugf("Elasticsearch template %s created or updated", a.TemplateName)
 
 	} else {
 
-		log.Println("D! Found existing Elasticsearch template. Skipping template management")
+		a.Log.Debugf("Found existing Elasticsearch template. Skipping template management")
 
 	}
 	return nil
@@ -330,7 +423,7 @@ func (a *Elasticsearch) GetTagKeys(indexName string) (string, []string) {
 			)
 
 			indexName = tagReplacer.Replace(indexName)
-			tagKeys = append(tagKeys, (strings.TrimSpace(tagName)))
+			tagKeys = append(tagKeys, templateTagKey(tagName))
 
 			startTag = strings.Index(indexName, "{{")
 		}
@@ -339,6 +432,18 @@ func (a *Elasticsearch) GetTagKeys(indexName string) (string, []string) {
 	return indexName, tagKeys
 }
 
+// templateTagKey returns the tag of a {{tag_name}} or {{.Tag "tag_name"}}
+// index name placeholder.
+func templateTagKey(placeholder string) string {
+	key := strings.TrimSpace(placeholder)
+	if strings.HasPrefix(key, ".Tag") {
+		if tag, err := strconv.Unquote(strings.TrimSpace(key[len(".Tag"):])); err == nil {
+			return tag
+		}
+	}
+	return key
+}
+
 func (a *Elasticsearch) GetIndexName(indexName string, eventTime time.Time, tagKeys []string, metricTags map[string]string) string {
 	if strings.Contains(indexName, "%") {
 		var dateReplacer = strings.NewReplacer(
@@ -359,7 +464,7 @@ func (a *Elasticsearch) GetIndexName(indexName string, eventTime time.Time, tagK
 		if value, ok := metricTags[key]; ok {
 			tagValues = append(tagValues, value)
 		} else {
-			log.Printf("D! Tag '%s' not found, using '%s' on index name instead\n", key, a.DefaultTagValue)
+			a.Log.Debugf("Tag '%s' not found, using '%s' on index name instead", key, a.DefaultTagValue)
 			tagValues = append(tagValues, a.DefaultTagValue)
 		}
 	}
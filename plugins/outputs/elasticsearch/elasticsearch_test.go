@@ -2,7 +2,12 @@ package elasticsearch
 
 import (
 	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +24,7 @@ func TestConnectAndWrite(t *testing.T) {
 	urls := []string{"http://" + testutil.GetLocalHost() + ":9200"}
 
 	e := &Elasticsearch{
+		Log:                 testutil.Logger{},
 		URLs:                urls,
 		IndexName:           "test-%Y.%m.%d",
 		Timeout:             internal.Duration{Duration: time.Second * 5},
@@ -48,6 +54,7 @@ func TestTemplateManagementEmptyTemplate(t *testing.T) {
 	ctx := context.Background()
 
 	e := &Elasticsearch{
+		Log:               testutil.Logger{},
 		URLs:              urls,
 		IndexName:         "test-%Y.%m.%d",
 		Timeout:           internal.Duration{Duration: time.Second * 5},
@@ -69,6 +76,7 @@ func TestTemplateManagement(t *testing.T) {
 	urls := []string{"http://" + testutil.GetLocalHost() + ":9200"}
 
 	e := &Elasticsearch{
+		Log:               testutil.Logger{},
 		URLs:              urls,
 		IndexName:         "test-%Y.%m.%d",
 		Timeout:           internal.Duration{Duration: time.Second * 5},
@@ -95,6 +103,7 @@ func TestTemplateInvalidIndexPattern(t *testing.T) {
 	urls := []string{"http://" + testutil.GetLocalHost() + ":9200"}
 
 	e := &Elasticsearch{
+		Log:               testutil.Logger{},
 		URLs:              urls,
 		IndexName:         "{{host}}-%Y.%m.%d",
 		Timeout:           internal.Duration{Duration: time.Second * 5},
@@ -109,6 +118,7 @@ func TestTemplateInvalidIndexPattern(t *testing.T) {
 
 func TestGetTagKeys(t *testing.T) {
 	e := &Elasticsearch{
+		Log:             testutil.Logger{},
 		DefaultTagValue: "none",
 	}
 
@@ -153,6 +163,10 @@ func TestGetTagKeys(t *testing.T) {
 			"indexname-{{tag1}}-{{tag2}}-{{tag3}}-%y-%m",
 			"indexname-%s-%s-%s-%y-%m",
 			[]string{"tag1", "tag2", "tag3"},
+		}, {
+			`indexname-{{.Tag "tag1"}}-{{ tag2 }}-%y-%m`,
+			"indexname-%s-%s-%y-%m",
+			[]string{"tag1", "tag2"},
 		},
 	}
 	for _, test := range tests {
@@ -169,6 +183,7 @@ func TestGetTagKeys(t *testing.T) {
 
 func TestGetIndexName(t *testing.T) {
 	e := &Elasticsearch{
+		Log:             testutil.Logger{},
 		DefaultTagValue: "none",
 	}
 
@@ -257,3 +272,126 @@ func TestGetIndexName(t *testing.T) {
 		}
 	}
 }
+
+// fakeElasticsearch records the requests of the client, answering with
+// the given version.
+type fakeElasticsearch struct {
+	sync.Mutex
+	version  string
+	policies map[string]string
+	template string
+	bulks    int
+}
+
+func (f *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/":
+		w.Write([]byte(`{"version":{"number":"` + f.version + `"}}`))
+	case strings.HasPrefix(r.URL.Path, "/_ilm/policy/"):
+		name := strings.TrimPrefix(r.URL.Path, "/_ilm/policy/")
+		if r.Method == "PUT" {
+			f.policies[name] = string(body)
+			w.Write([]byte(`{"acknowledged":true}`))
+			return
+		}
+		if _, ok := f.policies[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	case strings.HasPrefix(r.URL.Path, "/_template/"):
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.template = string(body)
+		w.Write([]byte(`{"acknowledged":true}`))
+	case r.URL.Path == "/_bulk":
+		f.bulks++
+		w.Write([]byte(`{"took":1,"errors":false,"items":[]}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeElasticsearch(version string) *fakeElasticsearch {
+	return &fakeElasticsearch{
+		version:  version,
+		policies: make(map[string]string),
+	}
+}
+
+func TestConnectILMPolicy(t *testing.T) {
+	es := newFakeElasticsearch("6.8.0")
+	ts := httptest.NewServer(es)
+	defer ts.Close()
+
+	e := &Elasticsearch{
+		Log:            testutil.Logger{},
+		URLs:           []string{ts.URL},
+		IndexName:      "test-%Y.%m.%d",
+		Timeout:        internal.Duration{Duration: time.Second * 5},
+		ManageTemplate: true,
+		TemplateName:   "telegraf",
+		ILMPolicyName:  "telegraf-policy",
+		ILMPolicy:      `{"policy":{"phases":{"delete":{"min_age":"30d","actions":{"delete":{}}}}}}`,
+	}
+
+	require.NoError(t, e.Connect())
+	require.Equal(t, e.ILMPolicy, es.policies["telegraf-policy"])
+	require.Contains(t, es.template, `"lifecycle.name": "telegraf-policy"`)
+
+	// An existing policy is kept
+	e.ILMPolicy = `{"policy":{}}`
+	require.NoError(t, e.Connect())
+	require.NotEqual(t, e.ILMPolicy, es.policies["telegraf-policy"])
+
+	e.OverwriteILMPolicy = true
+	require.NoError(t, e.Connect())
+	require.Equal(t, e.ILMPolicy, es.policies["telegraf-policy"])
+}
+
+func TestConnectILMPolicyUnsupported(t *testing.T) {
+	ts := httptest.NewServer(newFakeElasticsearch("6.5.4"))
+	defer ts.Close()
+
+	e := &Elasticsearch{
+		Log:           testutil.Logger{},
+		URLs:          []string{ts.URL},
+		IndexName:     "test-%Y.%m.%d",
+		Timeout:       internal.Duration{Duration: time.Second * 5},
+		ILMPolicyName: "telegraf-policy",
+	}
+
+	require.Error(t, e.Connect())
+}
+
+func TestWriteMaxBulkSize(t *testing.T) {
+	es := newFakeElasticsearch("6.8.0")
+	ts := httptest.NewServer(es)
+	defer ts.Close()
+
+	e := &Elasticsearch{
+		Log:         testutil.Logger{},
+		URLs:        []string{ts.URL},
+		IndexName:   "test-{{.Tag \"tag1\"}}-%Y.%m.%d",
+		Timeout:     internal.Duration{Duration: time.Second * 5},
+		MaxBulkSize: internal.Size{Size: 1},
+	}
+	require.NoError(t, e.Connect())
+
+	metrics := testutil.MockMetrics()
+	metrics = append(metrics, metrics...)
+	require.NoError(t, e.Write(metrics))
+	require.Equal(t, len(metrics), es.bulks)
+
+	e.MaxBulkSize = internal.Size{}
+	require.NoError(t, e.Write(metrics))
+	require.Equal(t, len(metrics)+1, es.bulks)
+}
@@ -0,0 +1,123 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const maxStderrBytes = 512
+
+const sampleConfig = `
+  ## Command to ingest metrics via stdin.
+  command = ["tee", "-a", "/dev/null"]
+
+  ## Timeout for command to complete.
+  # timeout = "5s"
+
+  ## Data format to output.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  # data_format = "influx"
+`
+
+// Exec defines the exec output plugin.
+type Exec struct {
+	Command []string          `toml:"command"`
+	Timeout internal.Duration `toml:"timeout"`
+	Log     telegraf.Logger   `toml:"-"`
+
+	runner     Runner
+	serializer serializers.Serializer
+}
+
+func (e *Exec) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Exec) Description() string {
+	return "Send metrics to command as input over stdin"
+}
+
+func (e *Exec) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Exec) Connect() error {
+	if len(e.Command) == 0 {
+		return fmt.Errorf("no command configured")
+	}
+	return nil
+}
+
+func (e *Exec) Close() error {
+	return nil
+}
+
+// Write serializes the metrics as a batch and runs the command with the
+// batch on its stdin.
+func (e *Exec) Write(metrics []telegraf.Metric) error {
+	octets, err := e.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return err
+	}
+	if len(octets) == 0 {
+		return nil
+	}
+
+	return e.runner.Run(e.Timeout.Duration, e.Command, bytes.NewReader(octets))
+}
+
+// Runner runs a command with the given input.
+type Runner interface {
+	Run(time.Duration, []string, io.Reader) error
+}
+
+// CommandRunner runs a command, killing it after the timeout.
+type CommandRunner struct{}
+
+// Run runs the command with the input on its stdin.  If the command fails,
+// the error contains the beginning of its stderr.
+func (c *CommandRunner) Run(timeout time.Duration, command []string, input io.Reader) error {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = input
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := internal.RunTimeout(cmd, timeout)
+	if err != nil {
+		if err == internal.TimeoutErr {
+			return fmt.Errorf("%q timed out and was killed", command)
+		}
+
+		s := stderr.Bytes()
+		if len(s) > maxStderrBytes {
+			s = s[:maxStderrBytes]
+		}
+		s = bytes.TrimSpace(s)
+		if len(s) > 0 {
+			return fmt.Errorf("%q failed with error: %v: %s", command, err, s)
+		}
+		return fmt.Errorf("%q failed with error: %v", command, err)
+	}
+
+	return nil
+}
+
+func init() {
+	outputs.Add("exec", func() telegraf.Output {
+		return &Exec{
+			runner:  &CommandRunner{},
+			Timeout: internal.Duration{Duration: time.Second * 5},
+		}
+	})
+}
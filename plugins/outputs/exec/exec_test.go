@@ -0,0 +1,105 @@
+// +build !windows
+
+package exec
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExec(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	output := filepath.Join(tmpdir, "output")
+
+	tests := []struct {
+		name    string
+		command []string
+		err     bool
+		metrics []telegraf.Metric
+	}{
+		{
+			name:    "test success",
+			command: []string{"tee", "-a", output},
+			err:     false,
+			metrics: testutil.MockMetrics(),
+		},
+		{
+			name:    "test doesn't accept stdin",
+			command: []string{"sleep", "5s"},
+			err:     true,
+			metrics: testutil.MockMetrics(),
+		},
+		{
+			name:    "test command not found",
+			command: []string{"/no/exist", "-h"},
+			err:     true,
+			metrics: testutil.MockMetrics(),
+		},
+		{
+			name:    "test command fails",
+			command: []string{"sh", "-c", "echo failure >&2; exit 1"},
+			err:     true,
+			metrics: testutil.MockMetrics(),
+		},
+		{
+			name:    "test no metrics output",
+			command: []string{"/no/exist", "-h"},
+			err:     false,
+			metrics: []telegraf.Metric{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &Exec{
+				Command:    tt.command,
+				Timeout:    internal.Duration{Duration: time.Second},
+				runner:     &CommandRunner{},
+				serializer: influx.NewSerializer(),
+			}
+			require.NoError(t, e.Connect())
+
+			err := e.Write(tt.metrics)
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	octets, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	expected, err := influx.NewSerializer().SerializeBatch(testutil.MockMetrics())
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(octets))
+}
+
+func TestExecStderr(t *testing.T) {
+	e := &Exec{
+		Command:    []string{"sh", "-c", "cat > /dev/null; echo failure >&2; exit 1"},
+		Timeout:    internal.Duration{Duration: time.Second},
+		runner:     &CommandRunner{},
+		serializer: influx.NewSerializer(),
+	}
+
+	err := e.Write(testutil.MockMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failure")
+}
+
+func TestConnectNoCommand(t *testing.T) {
+	e := &Exec{}
+	require.Error(t, e.Connect())
+}
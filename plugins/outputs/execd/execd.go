@@ -0,0 +1,103 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon, followed by its arguments.
+  command = ["my-telegraf-output", "--some-flag", "value"]
+
+  ## Delay before the process is restarted after an unexpected termination
+  restart_delay = "10s"
+
+  ## Data format to export.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command      []string
+	RestartDelay internal.Duration
+	Log          telegraf.Logger `toml:"-"`
+
+	process    *process.Process
+	serializer serializers.Serializer
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running output plugin"
+}
+
+func (e *Execd) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Execd) Connect() error {
+	var err error
+	e.process, err = process.New(e.Command, e.Log)
+	if err != nil {
+		return err
+	}
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdout = e.logStdout
+	e.process.ReadStderr = process.LogStderr(e.Log)
+
+	return e.process.Start()
+}
+
+func (e *Execd) Close() error {
+	if e.process != nil {
+		e.process.Stop()
+	}
+	return nil
+}
+
+func (e *Execd) Write(metrics []telegraf.Metric) error {
+	for _, metric := range metrics {
+		octets, err := e.serializer.Serialize(metric)
+		if err != nil {
+			e.Log.Errorf("Could not serialize metric: %v", err)
+			continue
+		}
+
+		if _, err := e.process.Write(octets); err != nil {
+			return fmt.Errorf("error writing to process: %v", err)
+		}
+	}
+	return nil
+}
+
+// logStdout logs each line written by the process.
+func (e *Execd) logStdout(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		e.Log.Infof("stdout: %q", scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %v", err)
+	}
+}
+
+func init() {
+	outputs.Add("execd", func() telegraf.Output {
+		return &Execd{
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
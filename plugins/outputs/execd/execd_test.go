@@ -0,0 +1,45 @@
+// +build !windows
+
+package execd
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "execd")
+	require.NoError(t, err)
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	e := &Execd{
+		Command:      []string{"sh", "-c", "cat > " + tmpfile.Name()},
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	e.SetSerializer(influx.NewSerializer())
+	require.NoError(t, e.Connect())
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"usage_idle": 42.0},
+			time.Unix(0, 0),
+		),
+	}
+	require.NoError(t, e.Write(metrics))
+	require.NoError(t, e.Close())
+
+	data, err := ioutil.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	require.Equal(t, "cpu,host=localhost usage_idle=42 0\n", string(data))
+}
@@ -6,12 +6,18 @@ import (
 	"os"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/rotate"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type File struct {
-	Files []string
+	Files               []string          `toml:"files"`
+	RotationInterval    internal.Duration `toml:"rotation_interval"`
+	RotationMaxSize     internal.Size     `toml:"rotation_max_size"`
+	RotationMaxArchives int               `toml:"rotation_max_archives"`
+	RotationCompress    bool              `toml:"rotation_compress"`
 
 	writers []io.Writer
 	closers []io.Closer
@@ -23,6 +29,21 @@ var sampleConfig = `
   ## Files to write to, "stdout" is a specially handled file.
   files = ["stdout", "/tmp/metrics.out"]
 
+  ## The file will be rotated after the time interval specified.  When set
+  ## to 0 no time based rotation is performed.
+  # rotation_interval = "0h"
+
+  ## The file will be rotated when it becomes larger than the specified
+  ## size.  When set to 0 no size based rotation is performed.
+  # rotation_max_size = "0MB"
+
+  ## Maximum number of rotated archives to keep, any older archives are
+  ## deleted.  If set to -1, no archives are removed.
+  # rotation_max_archives = 5
+
+  ## Compress the rotated archives with gzip, adding ".gz" to their name.
+  # rotation_compress = false
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -43,14 +64,17 @@ func (f *File) Connect() error {
 		if file == "stdout" {
 			f.writers = append(f.writers, os.Stdout)
 		} else {
-			var of *os.File
-			var err error
-			if _, err := os.Stat(file); os.IsNotExist(err) {
-				of, err = os.Create(file)
-			} else {
-				of, err = os.OpenFile(file, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
+			var options []rotate.Option
+			if f.RotationCompress {
+				options = append(options, rotate.WithCompression())
 			}
-
+			of, err := rotate.NewFileWriter(
+				file,
+				f.RotationInterval.Duration,
+				f.RotationMaxSize.Size,
+				f.RotationMaxArchives,
+				options...,
+			)
 			if err != nil {
 				return err
 			}
@@ -102,6 +126,8 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 
 func init() {
 	outputs.Add("file", func() telegraf.Output {
-		return &File{}
+		return &File{
+			RotationMaxArchives: 5,
+		}
 	})
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -204,3 +205,34 @@ func validateFile(fname, expS string, t *testing.T) {
 	}
 	assert.Equal(t, expS, string(buf))
 }
+
+func TestFileRotation(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "FileRotation")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:               []string{filepath.Join(tempDir, "metrics.out")},
+		RotationMaxSize:     internal.Size{Size: 10},
+		RotationMaxArchives: 1,
+		RotationCompress:    true,
+		serializer:          s,
+	}
+
+	err = f.Connect()
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		err = f.Write(testutil.MockMetrics())
+		assert.NoError(t, err)
+	}
+
+	err = f.Close()
+	assert.NoError(t, err)
+
+	matches, err := filepath.Glob(filepath.Join(tempDir, "metrics.*.out.gz"))
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	validateFile(filepath.Join(tempDir, "metrics.out"), "", t)
+}
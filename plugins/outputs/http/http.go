@@ -3,15 +3,21 @@ package http
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/influxdata/telegraf/internal/metrictemplate"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -23,6 +29,15 @@ var sampleConfig = `
   ## URL is the address to send metrics to
   url = "http://127.0.0.1:8080/metric"
 
+  ## The URL and the values of the headers can be templates, with the
+  ## following data, the metrics are sent in a request per distinct URL and
+  ## headers:
+  ##   {{.Name}}        - measurement name
+  ##   {{.Tag "host"}}  - value of a tag, empty if not set
+  ##   {{.BatchSize}}   - number of metrics in the request
+  ## Use the urlquery function to escape the values in the URL.
+  ##   ex: url = 'http://127.0.0.1:8080/{{.Name}}?host={{.Tag "host" | urlquery}}'
+
   ## Timeout for HTTP message
   # timeout = "5s"
 
@@ -46,6 +61,12 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+
   ## Data format to output.
   ## Each data format has it's own unique set of configuration options, read
   ## more about them here:
@@ -60,6 +81,10 @@ var sampleConfig = `
   ## HTTP Content-Encoding for write request body, can be set to "gzip" to
   ## compress body or "identity" to apply no encoding.
   # content_encoding = "identity"
+
+  ## Header to set to an idempotency key of each request, the key is the
+  ## same when the request is sent again after a failure.
+  # idempotency_header = "Idempotency-Key"
 `
 
 const (
@@ -69,23 +94,46 @@ const (
 )
 
 type HTTP struct {
-	URL             string            `toml:"url"`
-	Timeout         internal.Duration `toml:"timeout"`
-	Method          string            `toml:"method"`
-	Username        string            `toml:"username"`
-	Password        string            `toml:"password"`
-	Headers         map[string]string `toml:"headers"`
-	ClientID        string            `toml:"client_id"`
-	ClientSecret    string            `toml:"client_secret"`
-	TokenURL        string            `toml:"token_url"`
-	Scopes          []string          `toml:"scopes"`
-	ContentEncoding string            `toml:"content_encoding"`
+	URL               string            `toml:"url"`
+	Timeout           internal.Duration `toml:"timeout"`
+	Method            string            `toml:"method"`
+	Username          string            `toml:"username"`
+	Password          string            `toml:"password"`
+	Headers           map[string]string `toml:"headers"`
+	ClientID          string            `toml:"client_id"`
+	ClientSecret      string            `toml:"client_secret"`
+	TokenURL          string            `toml:"token_url"`
+	Scopes            []string          `toml:"scopes"`
+	ContentEncoding   string            `toml:"content_encoding"`
+	IdempotencyHeader string            `toml:"idempotency_header"`
 	tls.ClientConfig
+	httpconfig.ProxyConfig
+
+	client          *http.Client
+	serializer      serializers.Serializer
+	serializerMu    sync.Mutex
+	urlTemplate     *metrictemplate.Template
+	headerTemplates map[string]*metrictemplate.Template
+}
 
-	client     *http.Client
-	serializer serializers.Serializer
+// templateData is the data of the URL and header templates, the metric data
+// is of the first metric of the request.
+type templateData struct {
+	metrictemplate.Metric
+	BatchSize int
 }
 
+// request is the URL and headers of a request and its metrics.
+type request struct {
+	url     string
+	headers map[string]string
+	metrics []telegraf.Metric
+}
+
+// ConcurrentWrites allows max_parallel_writes, the serializer is the only
+// state shared by the writes.
+func (h *HTTP) ConcurrentWrites() {}
+
 func (h *HTTP) SetSerializer(serializer serializers.Serializer) {
 	h.serializer = serializer
 }
@@ -96,10 +144,15 @@ func (h *HTTP) createClient(ctx context.Context) (*http.Client, error) {
 		return nil, err
 	}
 
+	proxy, err := h.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: tlsCfg,
-			Proxy:           http.ProxyFromEnvironment,
+			Proxy:           proxy,
 		},
 		Timeout: h.Timeout.Duration,
 	}
@@ -131,6 +184,25 @@ func (h *HTTP) Connect() error {
 		h.Timeout.Duration = defaultClientTimeout
 	}
 
+	if strings.Contains(h.URL, "{{") {
+		tmpl, err := metrictemplate.New("url", h.URL)
+		if err != nil {
+			return fmt.Errorf("invalid url template: %v", err)
+		}
+		h.urlTemplate = tmpl
+	}
+	h.headerTemplates = make(map[string]*metrictemplate.Template)
+	for k, v := range h.Headers {
+		if !strings.Contains(v, "{{") {
+			continue
+		}
+		tmpl, err := metrictemplate.New(k, v)
+		if err != nil {
+			return fmt.Errorf("invalid template for header %q: %v", k, err)
+		}
+		h.headerTemplates[k] = tmpl
+	}
+
 	ctx := context.Background()
 	client, err := h.createClient(ctx)
 	if err != nil {
@@ -155,19 +227,136 @@ func (h *HTTP) SampleConfig() string {
 }
 
 func (h *HTTP) Write(metrics []telegraf.Metric) error {
-	reqBody, err := h.serializer.SerializeBatch(metrics)
+	requests, err := h.requests(metrics)
 	if err != nil {
 		return err
 	}
 
-	if err := h.write(reqBody); err != nil {
-		return err
+	// The metrics of a request that can never be sent are reported after
+	// the other requests are sent.
+	var rejected []telegraf.Metric
+	var reasons []string
+	for _, r := range requests {
+		h.serializerMu.Lock()
+		reqBody, err := h.serializer.SerializeBatch(r.metrics)
+		h.serializerMu.Unlock()
+		if err != nil {
+			rejected = append(rejected, r.metrics...)
+			reasons = append(reasons, err.Error())
+			continue
+		}
+
+		if err := h.write(r, reqBody); err != nil {
+			if rerr, ok := err.(*internal.RejectedError); ok {
+				rejected = append(rejected, r.metrics...)
+				reasons = append(reasons, rerr.Reason)
+				continue
+			}
+			return err
+		}
 	}
 
+	if len(rejected) > 0 {
+		return &internal.RejectedError{
+			Metrics: rejected,
+			Reason:  strings.Join(reasons, "; "),
+		}
+	}
 	return nil
 }
 
-func (h *HTTP) write(reqBody []byte) error {
+// requests groups the metrics by the URL and headers rendered from their
+// templates, keeping the order of the metrics.
+func (h *HTTP) requests(metrics []telegraf.Metric) ([]*request, error) {
+	if h.urlTemplate == nil && len(h.headerTemplates) == 0 {
+		r, err := h.render(metrics)
+		if err != nil {
+			return nil, err
+		}
+		return []*request{r}, nil
+	}
+
+	var keys []string
+	groups := make(map[string][]telegraf.Metric)
+	for _, m := range metrics {
+		r, err := h.render([]telegraf.Metric{m})
+		if err != nil {
+			return nil, err
+		}
+
+		headers := make([]string, 0, len(r.headers))
+		for k, v := range r.headers {
+			headers = append(headers, k+": "+v)
+		}
+		sort.Strings(headers)
+		key := r.url + "\n" + strings.Join(headers, "\n")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], m)
+	}
+
+	requests := make([]*request, 0, len(keys))
+	for _, key := range keys {
+		r, err := h.render(groups[key])
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+// render returns the request of the metrics, with the URL and headers
+// rendered from their templates.
+func (h *HTTP) render(metrics []telegraf.Metric) (*request, error) {
+	r := &request{
+		url:     h.URL,
+		headers: make(map[string]string, len(h.Headers)),
+		metrics: metrics,
+	}
+	for k, v := range h.Headers {
+		r.headers[k] = v
+	}
+	if h.urlTemplate == nil && len(h.headerTemplates) == 0 {
+		return r, nil
+	}
+
+	data := &templateData{
+		BatchSize: len(metrics),
+	}
+	if len(metrics) > 0 {
+		data.Metric = metrictemplate.NewMetric(metrics[0])
+	}
+
+	if h.urlTemplate != nil {
+		url, err := h.urlTemplate.Execute(data)
+		if err != nil {
+			return nil, fmt.Errorf("executing url template: %v", err)
+		}
+		r.url = url
+	}
+	for k, tmpl := range h.headerTemplates {
+		value, err := tmpl.Execute(data)
+		if err != nil {
+			return nil, fmt.Errorf("executing template of header %q: %v", k, err)
+		}
+		r.headers[k] = value
+	}
+	return r, nil
+}
+
+// idempotencyKey returns the key of a request, derived from its URL and body
+// so a request sent again has the same key.
+func idempotencyKey(url string, reqBody []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(url))
+	hash.Write([]byte{0})
+	hash.Write(reqBody)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (h *HTTP) write(r *request, reqBody []byte) error {
 	var reqBodyBuffer io.Reader = bytes.NewBuffer(reqBody)
 
 	var err error
@@ -178,7 +367,7 @@ func (h *HTTP) write(reqBody []byte) error {
 		}
 	}
 
-	req, err := http.NewRequest(h.Method, h.URL, reqBodyBuffer)
+	req, err := http.NewRequest(h.Method, r.url, reqBodyBuffer)
 	if err != nil {
 		return err
 	}
@@ -192,9 +381,12 @@ func (h *HTTP) write(reqBody []byte) error {
 	if h.ContentEncoding == "gzip" {
 		req.Header.Set("Content-Encoding", "gzip")
 	}
-	for k, v := range h.Headers {
+	for k, v := range r.headers {
 		req.Header.Set(k, v)
 	}
+	if h.IdempotencyHeader != "" {
+		req.Header.Set(h.IdempotencyHeader, idempotencyKey(r.url, reqBody))
+	}
 
 	resp, err := h.client.Do(req)
 	if err != nil {
@@ -204,7 +396,14 @@ func (h *HTTP) write(reqBody []byte) error {
 	_, err = ioutil.ReadAll(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("when writing to [%s] received status code: %d", h.URL, resp.StatusCode)
+		err := fmt.Errorf("when writing to [%s] received status code: %d", r.url, resp.StatusCode)
+		switch resp.StatusCode {
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			// The data is invalid and would be refused again, unlike a
+			// request too large which may be accepted with fewer metrics.
+			return &internal.RejectedError{Reason: err.Error()}
+		}
+		return err
 	}
 
 	return nil
@@ -215,6 +414,9 @@ func init() {
 		return &HTTP{
 			Timeout: internal.Duration{Duration: defaultClientTimeout},
 			Method:  defaultMethod,
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
 		}
 	})
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -164,6 +165,30 @@ func TestStatusCode(t *testing.T) {
 				require.Error(t, err)
 			},
 		},
+		{
+			name: "400 status rejects the metrics",
+			plugin: &HTTP{
+				URL: u.String(),
+			},
+			statusCode: http.StatusBadRequest,
+			errFunc: func(t *testing.T, err error) {
+				rerr, ok := err.(*internal.RejectedError)
+				require.True(t, ok)
+				require.Len(t, rerr.Metrics, 1)
+			},
+		},
+		{
+			name: "413 status is retried",
+			plugin: &HTTP{
+				URL: u.String(),
+			},
+			statusCode: http.StatusRequestEntityTooLarge,
+			errFunc: func(t *testing.T, err error) {
+				require.Error(t, err)
+				_, ok := err.(*internal.RejectedError)
+				require.False(t, ok)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -453,3 +478,108 @@ func TestDefaultUserAgent(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestTemplates(t *testing.T) {
+	type received struct {
+		path   string
+		query  string
+		header string
+		body   string
+	}
+	var requests []received
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests = append(requests, received{
+			path:   r.URL.Path,
+			query:  r.URL.RawQuery,
+			header: r.Header.Get("X-Batch"),
+			body:   string(body),
+		})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plugin := &HTTP{
+		URL: ts.URL + `/{{.Name}}?env={{.Tag "env" | urlquery}}`,
+		Headers: map[string]string{
+			"X-Batch": `{{.Tag "env"}}-{{.BatchSize}}`,
+		},
+	}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Connect())
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"env": "a b"},
+			map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"env": "prod"},
+			map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"env": "a b"},
+			map[string]interface{}{"value": 3.0}, time.Unix(0, 0)),
+		testutil.MustMetric("mem", map[string]string{},
+			map[string]interface{}{"value": 4.0}, time.Unix(0, 0)),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Equal(t, []received{
+		{
+			path:   "/cpu",
+			query:  "env=a+b",
+			header: "a b-2",
+			body:   "cpu,env=a\\ b value=1 0\ncpu,env=a\\ b value=3 0\n",
+		},
+		{
+			path:   "/cpu",
+			query:  "env=prod",
+			header: "prod-1",
+			body:   "cpu,env=prod value=2 0\n",
+		},
+		{
+			path:   "/mem",
+			query:  "env=",
+			header: "-1",
+			body:   "mem value=4 0\n",
+		},
+	}, requests)
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	plugin := &HTTP{
+		URL: "http://127.0.0.1:8080/{{.Name",
+	}
+	require.Error(t, plugin.Connect())
+
+	plugin = &HTTP{
+		URL:     "http://127.0.0.1:8080/metric",
+		Headers: map[string]string{"X-Name": "{{.Name"},
+	}
+	require.Error(t, plugin.Connect())
+}
+
+func TestIdempotencyHeader(t *testing.T) {
+	var keys []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plugin := &HTTP{
+		URL:               ts.URL,
+		IdempotencyHeader: "Idempotency-Key",
+		ContentEncoding:   "gzip",
+	}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Connect())
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{getMetric()}))
+	require.NoError(t, plugin.Write([]telegraf.Metric{getMetric()}))
+	other := testutil.MustMetric("cpu", map[string]string{},
+		map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{other}))
+
+	require.Len(t, keys, 3)
+	require.Len(t, keys[0], 64)
+	require.Equal(t, keys[0], keys[1])
+	require.NotEqual(t, keys[0], keys[2])
+}
@@ -89,7 +89,7 @@ type HTTPConfig struct {
 	Username             string
 	Password             string
 	TLSConfig            *tls.Config
-	Proxy                *url.URL
+	Proxy                func(*http.Request) (*url.URL, error)
 	Headers              map[string]string
 	ContentEncoding      string
 	Database             string
@@ -134,10 +134,9 @@ func NewHTTPClient(config HTTPConfig) (*httpClient, error) {
 		config.Headers[k] = v
 	}
 
-	var proxy func(*http.Request) (*url.URL, error)
-	if config.Proxy != nil {
-		proxy = http.ProxyURL(config.Proxy)
-	} else {
+	// The proxy set by the environment is used unless one is given.
+	proxy := config.Proxy
+	if proxy == nil {
 		proxy = http.ProxyFromEnvironment
 	}
 
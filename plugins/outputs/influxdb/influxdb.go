@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -42,12 +44,13 @@ type InfluxDB struct {
 	WriteConsistency     string
 	Timeout              internal.Duration
 	UDPPayload           internal.Size     `toml:"udp_payload"`
-	HTTPProxy            string            `toml:"http_proxy"`
+	HTTPProxy            string            `toml:"http_proxy" deprecated:"1.12.0;2.0.0;use 'http_proxy_url' instead"`
 	HTTPHeaders          map[string]string `toml:"http_headers"`
 	ContentEncoding      string            `toml:"content_encoding"`
 	SkipDatabaseCreation bool              `toml:"skip_database_creation"`
 	InfluxUintSupport    bool              `toml:"influx_uint_support"`
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	Precision string // precision deprecated in 1.0; value is ignored
 
@@ -109,9 +112,11 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
-  ## HTTP Proxy override, if unset values the standard proxy environment
-  ## variables are consulted to determine which proxy, if any, should be used.
-  # http_proxy = "http://corporate.proxy:3128"
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://corporate.proxy:3128"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 
   ## Additional HTTP headers
   # http_headers = {"X-Special-Header" = "Special-Value"}
@@ -145,20 +150,25 @@ func (i *InfluxDB) Connect() error {
 		i.serializer.SetFieldTypeSupport(influx.UintSupport)
 	}
 
+	// Support deprecated option name
+	if i.HTTPProxyURL == "" {
+		i.HTTPProxyURL = i.HTTPProxy
+	}
+	proxy, err := i.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+	if proxy == nil {
+		// The client uses the system proxy unless told otherwise.
+		proxy = noProxy
+	}
+
 	for _, u := range urls {
 		parts, err := url.Parse(u)
 		if err != nil {
 			return fmt.Errorf("error parsing url [%q]: %v", u, err)
 		}
 
-		var proxy *url.URL
-		if len(i.HTTPProxy) > 0 {
-			proxy, err = url.Parse(i.HTTPProxy)
-			if err != nil {
-				return fmt.Errorf("error parsing proxy_url [%s]: %v", i.HTTPProxy, err)
-			}
-		}
-
 		switch parts.Scheme {
 		case "udp", "udp4", "udp6":
 			c, err := i.udpClient(parts)
@@ -240,7 +250,7 @@ func (i *InfluxDB) udpClient(url *url.URL) (Client, error) {
 	return c, nil
 }
 
-func (i *InfluxDB) httpClient(ctx context.Context, url *url.URL, proxy *url.URL) (Client, error) {
+func (i *InfluxDB) httpClient(ctx context.Context, url *url.URL, proxy func(*http.Request) (*url.URL, error)) (Client, error) {
 	tlsConfig, err := i.ClientConfig.TLSConfig()
 	if err != nil {
 		return nil, err
@@ -280,10 +290,18 @@ func (i *InfluxDB) httpClient(ctx context.Context, url *url.URL, proxy *url.URL)
 	return c, nil
 }
 
+// noProxy is the proxy function connecting directly.
+func noProxy(*http.Request) (*url.URL, error) {
+	return nil, nil
+}
+
 func init() {
 	outputs.Add("influxdb", func() telegraf.Output {
 		return &InfluxDB{
 			Timeout: internal.Duration{Duration: time.Second * 5},
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
 			CreateHTTPClientF: func(config *HTTPConfig) (Client, error) {
 				return NewHTTPClient(*config)
 			},
@@ -133,7 +133,9 @@ func TestConnectHTTPConfig(t *testing.T) {
 	require.Equal(t, output.Timeout.Duration, actual.Timeout)
 	require.Equal(t, output.Username, actual.Username)
 	require.Equal(t, output.Password, actual.Password)
-	require.Equal(t, output.HTTPProxy, actual.Proxy.String())
+	proxy, err := actual.Proxy(&http.Request{URL: actual.URL})
+	require.NoError(t, err)
+	require.Equal(t, output.HTTPProxy, proxy.String())
 	require.Equal(t, output.HTTPHeaders, actual.Headers)
 	require.Equal(t, output.ContentEncoding, actual.ContentEncoding)
 	require.Equal(t, output.Database, actual.Database)
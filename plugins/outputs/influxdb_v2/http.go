@@ -7,12 +7,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -35,7 +35,7 @@ func (e APIError) Error() string {
 
 const (
 	defaultRequestTimeout = time.Second * 5
-	defaultMaxWait        = 10 // seconds
+	defaultMaxRetryWait   = time.Second * 10
 	defaultDatabase       = "telegraf"
 )
 
@@ -47,10 +47,11 @@ type HTTPConfig struct {
 	BucketTag       string
 	Timeout         time.Duration
 	Headers         map[string]string
-	Proxy           *url.URL
+	Proxy           func(*http.Request) (*url.URL, error)
 	UserAgent       string
 	ContentEncoding string
 	TLSConfig       *tls.Config
+	MaxRetryWait    time.Duration
 
 	Serializer *influx.Serializer
 }
@@ -62,11 +63,13 @@ type httpClient struct {
 	Organization    string
 	Bucket          string
 	BucketTag       string
+	MaxRetryWait    time.Duration
 
 	client     *http.Client
 	serializer *influx.Serializer
 	url        *url.URL
 	retryTime  time.Time
+	retryCount int
 }
 
 func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
@@ -79,6 +82,11 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		timeout = defaultRequestTimeout
 	}
 
+	maxRetryWait := config.MaxRetryWait
+	if maxRetryWait == 0 {
+		maxRetryWait = defaultMaxRetryWait
+	}
+
 	userAgent := config.UserAgent
 	if userAgent == "" {
 		userAgent = "Telegraf/" + internal.Version()
@@ -91,10 +99,9 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		headers[k] = v
 	}
 
-	var proxy func(*http.Request) (*url.URL, error)
-	if config.Proxy != nil {
-		proxy = http.ProxyURL(config.Proxy)
-	} else {
+	// The proxy set by the environment is used unless one is given.
+	proxy := config.Proxy
+	if proxy == nil {
 		proxy = http.ProxyFromEnvironment
 	}
 
@@ -137,6 +144,7 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		Organization:    config.Organization,
 		Bucket:          config.Bucket,
 		BucketTag:       config.BucketTag,
+		MaxRetryWait:    maxRetryWait,
 	}
 	return client, nil
 }
@@ -168,31 +176,44 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 		return errors.New("Retry time has not elapsed")
 	}
 
-	batches := make(map[string][]telegraf.Metric)
 	if c.BucketTag == "" {
-		err := c.writeBatch(ctx, c.Bucket, metrics)
-		if err != nil {
-			return err
+		return c.writeBatch(ctx, c.Bucket, metrics)
+	}
+
+	batches := make(map[string][]telegraf.Metric)
+	for _, metric := range metrics {
+		bucket, ok := metric.GetTag(c.BucketTag)
+		if !ok {
+			bucket = c.Bucket
 		}
-	} else {
-		for _, metric := range metrics {
-			bucket, ok := metric.GetTag(c.BucketTag)
-			if !ok {
-				bucket = c.Bucket
-			}
 
-			if _, ok := batches[bucket]; !ok {
-				batches[bucket] = make([]telegraf.Metric, 0)
-			}
+		if _, ok := batches[bucket]; !ok {
+			batches[bucket] = make([]telegraf.Metric, 0)
+		}
 
-			batches[bucket] = append(batches[bucket], metric)
+		batches[bucket] = append(batches[bucket], metric)
+	}
+
+	// The metrics of a bucket that refuses them are reported after the other
+	// buckets are written.
+	var rejected []telegraf.Metric
+	var reasons []string
+	for bucket, batch := range batches {
+		err := c.writeBatch(ctx, bucket, batch)
+		if rerr, ok := err.(*internal.RejectedError); ok {
+			rejected = append(rejected, batch...)
+			reasons = append(reasons, rerr.Reason)
+			continue
 		}
+		if err != nil {
+			return err
+		}
+	}
 
-		for bucket, batch := range batches {
-			err := c.writeBatch(ctx, bucket, batch)
-			if err != nil {
-				return err
-			}
+	if len(rejected) > 0 {
+		return &internal.RejectedError{
+			Metrics: rejected,
+			Reason:  strings.Join(reasons, "; "),
 		}
 	}
 	return nil
@@ -217,6 +238,7 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNoContent {
+		c.retryCount = 0
 		return nil
 	}
 
@@ -230,19 +252,11 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 	switch resp.StatusCode {
 	case http.StatusBadRequest, http.StatusUnauthorized,
 		http.StatusForbidden, http.StatusRequestEntityTooLarge:
-		log.Printf("E! [outputs.influxdb_v2] Failed to write metric: %s\n", desc)
-		return nil
+		return &internal.RejectedError{Reason: fmt.Sprintf("failed to write metric: %s", desc)}
 	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
-		retryAfter := resp.Header.Get("Retry-After")
-		retry, err := strconv.Atoi(retryAfter)
-		if err != nil {
-			retry = 0
-		}
-		if retry > defaultMaxWait {
-			retry = defaultMaxWait
-		}
-		c.retryTime = time.Now().Add(time.Duration(retry) * time.Second)
-		return fmt.Errorf("Waiting %ds for server before sending metric again", retry)
+		retry := c.getRetryDuration(resp.Header)
+		c.retryTime = time.Now().Add(retry)
+		return fmt.Errorf("Waiting %s for server before sending metric again", retry)
 	}
 
 	// This is only until platform spec is fully implemented. As of the
@@ -258,6 +272,36 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 	}
 }
 
+// getRetryDuration returns how long to wait before writing again after the
+// server refused a write because it is busy.  The Retry-After header is used
+// when present, in seconds or as a date, otherwise the wait starts at one
+// second and doubles with each consecutive refusal.  The wait is at most
+// MaxRetryWait.
+func (c *httpClient) getRetryDuration(headers http.Header) time.Duration {
+	c.retryCount++
+
+	var retry time.Duration
+	retryAfter := headers.Get("Retry-After")
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		retry = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(retryAfter); err == nil {
+		retry = time.Until(t)
+	} else {
+		retry = time.Second
+		for i := 1; i < c.retryCount && retry < c.MaxRetryWait; i++ {
+			retry *= 2
+		}
+	}
+
+	if retry < 0 {
+		retry = 0
+	}
+	if retry > c.MaxRetryWait {
+		retry = c.MaxRetryWait
+	}
+	return retry
+}
+
 func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request, error) {
 	var err error
 	if c.ContentEncoding == "gzip" {
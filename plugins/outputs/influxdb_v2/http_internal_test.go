@@ -1,8 +1,10 @@
 package influxdb_v2
 
 import (
+	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -45,3 +47,28 @@ func TestMakeWriteURL(t *testing.T) {
 		}
 	}
 }
+
+func TestGetRetryDuration(t *testing.T) {
+	c := &httpClient{MaxRetryWait: 10 * time.Second}
+
+	headers := http.Header{}
+	headers.Set("Retry-After", "3")
+	require.Equal(t, 3*time.Second, c.getRetryDuration(headers))
+
+	headers.Set("Retry-After", "60")
+	require.Equal(t, 10*time.Second, c.getRetryDuration(headers))
+
+	headers.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	require.Equal(t, time.Duration(0), c.getRetryDuration(headers))
+
+	headers.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.Equal(t, 10*time.Second, c.getRetryDuration(headers))
+
+	// Without Retry-After the wait doubles with each refusal.
+	c.retryCount = 0
+	require.Equal(t, 1*time.Second, c.getRetryDuration(http.Header{}))
+	require.Equal(t, 2*time.Second, c.getRetryDuration(http.Header{}))
+	require.Equal(t, 4*time.Second, c.getRetryDuration(http.Header{}))
+	require.Equal(t, 8*time.Second, c.getRetryDuration(http.Header{}))
+	require.Equal(t, 10*time.Second, c.getRetryDuration(http.Header{}))
+}
@@ -1,10 +1,17 @@
 package influxdb_v2_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	influxdb "github.com/influxdata/telegraf/plugins/outputs/influxdb_v2"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,3 +54,122 @@ func TestNewHTTPClient(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteRetryAfter(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "Token secret", r.Header.Get("Authorization"))
+		require.Equal(t, "bucket=telegraf&org=influx", r.URL.RawQuery)
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL:          genURL(ts.URL),
+		Token:        "secret",
+		Organization: "influx",
+		Bucket:       "telegraf",
+	})
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	ctx := context.Background()
+	require.Error(t, client.Write(ctx, metrics))
+	require.NoError(t, client.Write(ctx, metrics))
+	require.Equal(t, 2, requests)
+}
+
+func TestWriteRetryTimeNotElapsed(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL:          genURL(ts.URL),
+		Bucket:       "telegraf",
+		MaxRetryWait: time.Minute,
+	})
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	ctx := context.Background()
+	require.Error(t, client.Write(ctx, metrics))
+	require.Error(t, client.Write(ctx, metrics))
+	require.Equal(t, 1, requests)
+}
+
+func TestWriteBucketTagRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("bucket") == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL:       genURL(ts.URL),
+		Bucket:    "telegraf",
+		BucketTag: "bucket",
+	})
+	require.NoError(t, err)
+
+	bad := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"bucket": "bad",
+		},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+		bad,
+	}
+
+	// Only the metrics of the refused bucket are rejected
+	err = client.Write(context.Background(), metrics)
+	rerr, ok := err.(*internal.RejectedError)
+	require.True(t, ok)
+	require.Equal(t, []telegraf.Metric{bad}, rerr.Metrics)
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -48,9 +50,11 @@ var sampleConfig = `
   ## Additional HTTP headers
   # http_headers = {"X-Special-Header" = "Special-Value"}
 
-  ## HTTP Proxy override, if unset values the standard proxy environment
-  ## variables are consulted to determine which proxy, if any, should be used.
-  # http_proxy = "http://corporate.proxy:3128"
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://corporate.proxy:3128"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 
   ## HTTP User-Agent
   # user_agent = "telegraf"
@@ -62,6 +66,11 @@ var sampleConfig = `
   ## Enable or disable uint support for writing uints influxdb 2.0.
   # influx_uint_support = false
 
+  ## Longest wait before writing again when the server is too busy to accept
+  ## writes (status 429 or 503).  The Retry-After of the response is used
+  ## when present, otherwise the wait doubles from 1s with each refusal.
+  # max_retry_wait = "10s"
+
   ## Optional TLS Config for use on HTTP connections.
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -84,11 +93,13 @@ type InfluxDB struct {
 	BucketTag       string            `toml:"bucket_tag"`
 	Timeout         internal.Duration `toml:"timeout"`
 	HTTPHeaders     map[string]string `toml:"http_headers"`
-	HTTPProxy       string            `toml:"http_proxy"`
+	HTTPProxy       string            `toml:"http_proxy" deprecated:"1.12.0;2.0.0;use 'http_proxy_url' instead"`
 	UserAgent       string            `toml:"user_agent"`
 	ContentEncoding string            `toml:"content_encoding"`
 	UintSupport     bool              `toml:"influx_uint_support"`
+	MaxRetryWait    internal.Duration `toml:"max_retry_wait"`
 	tls.ClientConfig
+	httpconfig.ProxyConfig
 
 	clients    []Client
 	serializer *influx.Serializer
@@ -106,20 +117,25 @@ func (i *InfluxDB) Connect() error {
 		i.serializer.SetFieldTypeSupport(influx.UintSupport)
 	}
 
+	// Support deprecated option name
+	if i.HTTPProxyURL == "" {
+		i.HTTPProxyURL = i.HTTPProxy
+	}
+	proxy, err := i.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+	if proxy == nil {
+		// The client uses the system proxy unless told otherwise.
+		proxy = noProxy
+	}
+
 	for _, u := range i.URLs {
 		parts, err := url.Parse(u)
 		if err != nil {
 			return fmt.Errorf("error parsing url [%q]: %v", u, err)
 		}
 
-		var proxy *url.URL
-		if len(i.HTTPProxy) > 0 {
-			proxy, err = url.Parse(i.HTTPProxy)
-			if err != nil {
-				return fmt.Errorf("error parsing proxy_url [%s]: %v", i.HTTPProxy, err)
-			}
-		}
-
 		switch parts.Scheme {
 		case "http", "https", "unix":
 			c, err := i.getHTTPClient(ctx, parts, proxy)
@@ -161,6 +177,10 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 		if err == nil {
 			return nil
 		}
+		if _, ok := err.(*internal.RejectedError); ok {
+			// The other addresses would refuse the metrics as well.
+			return err
+		}
 
 		log.Printf("E! [outputs.influxdb] when writing to [%s]: %v", client.URL(), err)
 	}
@@ -168,7 +188,7 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	return errors.New("could not write any address")
 }
 
-func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.URL) (Client, error) {
+func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy func(*http.Request) (*url.URL, error)) (Client, error) {
 	tlsConfig, err := i.ClientConfig.TLSConfig()
 	if err != nil {
 		return nil, err
@@ -186,6 +206,7 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 		UserAgent:       i.UserAgent,
 		ContentEncoding: i.ContentEncoding,
 		TLSConfig:       tlsConfig,
+		MaxRetryWait:    i.MaxRetryWait.Duration,
 		Serializer:      i.serializer,
 	}
 
@@ -197,11 +218,19 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 	return c, nil
 }
 
+// noProxy is the proxy function connecting directly.
+func noProxy(*http.Request) (*url.URL, error) {
+	return nil, nil
+}
+
 func init() {
 	outputs.Add("influxdb_v2", func() telegraf.Output {
 		return &InfluxDB{
 			Timeout:         internal.Duration{Duration: time.Second * 5},
 			ContentEncoding: "gzip",
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
 		}
 	})
 }
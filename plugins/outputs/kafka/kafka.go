@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/metrictemplate"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -25,10 +26,13 @@ type (
 	Kafka struct {
 		Brokers          []string
 		Topic            string
+		TopicTag         string      `toml:"topic_tag"`
+		ExcludeTopicTag  bool        `toml:"exclude_topic_tag"`
 		ClientID         string      `toml:"client_id"`
 		TopicSuffix      TopicSuffix `toml:"topic_suffix"`
 		RoutingTag       string      `toml:"routing_tag"`
 		RoutingKey       string      `toml:"routing_key"`
+		HeaderTags       []string    `toml:"header_tags"`
 		CompressionCodec int
 		RequiredAcks     int
 		MaxRetry         int
@@ -51,8 +55,9 @@ type (
 		// SASL Password
 		SASLPassword string `toml:"sasl_password"`
 
-		tlsConfig tls.Config
-		producer  sarama.SyncProducer
+		tlsConfig     tls.Config
+		producer      sarama.SyncProducer
+		topicTemplate *metrictemplate.Template
 
 		serializer serializers.Serializer
 	}
@@ -66,9 +71,19 @@ type (
 var sampleConfig = `
   ## URLs of kafka brokers
   brokers = ["localhost:9092"]
-  ## Kafka topic for producer messages
+  ## Kafka topic for producer messages.  The topic is a template, the
+  ## notation {{.Tag "tag_name"}} is replaced by the value of the tag, or an
+  ## empty string if the metric does not have the tag, and {{.Name}} by the
+  ## name of the metric.
+  ##   ex: topic = 'telegraf-{{.Tag "env"}}'
   topic = "telegraf"
 
+  ## Telegraf tag to use as the topic, the topic option is used when the
+  ## metric does not have the tag.  Set exclude_topic_tag to remove the tag
+  ## from the sent metrics.
+  # topic_tag = ""
+  # exclude_topic_tag = false
+
   ## Optional Client id
   # client_id = "Telegraf"
 
@@ -116,6 +131,10 @@ var sampleConfig = `
   ##       routing_key = "telegraf"
   # routing_key = ""
 
+  ## Telegraf tags to set as headers of the messages, the header keys are the
+  ## tag keys.  Requires version to be at least "0.11.0.0".
+  # header_tags = []
+
   ## CompressionCodec represents the various compression codecs recognized by
   ## Kafka in messages.
   ##  0 : No compression
@@ -175,14 +194,39 @@ func ValidateTopicSuffixMethod(method string) error {
 	return fmt.Errorf("Unknown topic suffix method provided: %s", method)
 }
 
+// parseTopic parses the topic template.
+func (k *Kafka) parseTopic() error {
+	tmpl, err := metrictemplate.New("topic", k.Topic)
+	if err != nil {
+		return fmt.Errorf("invalid topic template: %v", err)
+	}
+	k.topicTemplate = tmpl
+	return nil
+}
+
 func (k *Kafka) GetTopicName(metric telegraf.Metric) string {
+	topic := k.Topic
+	if k.topicTemplate != nil {
+		var err error
+		topic, err = k.topicTemplate.Render(metric)
+		if err != nil {
+			log.Printf("E! [outputs.kafka] Error executing topic template: %v", err)
+			topic = k.Topic
+		}
+	}
+	if k.TopicTag != "" {
+		if value, ok := metric.GetTag(k.TopicTag); ok {
+			topic = value
+		}
+	}
+
 	var topicName string
 	switch k.TopicSuffix.Method {
 	case "measurement":
-		topicName = k.Topic + k.TopicSuffix.Separator + metric.Name()
+		topicName = topic + k.TopicSuffix.Separator + metric.Name()
 	case "tags":
 		var topicNameComponents []string
-		topicNameComponents = append(topicNameComponents, k.Topic)
+		topicNameComponents = append(topicNameComponents, topic)
 		for _, tag := range k.TopicSuffix.Keys {
 			tagValue := metric.Tags()[tag]
 			if tagValue != "" {
@@ -191,7 +235,7 @@ func (k *Kafka) GetTopicName(metric telegraf.Metric) string {
 		}
 		topicName = strings.Join(topicNameComponents, k.TopicSuffix.Separator)
 	default:
-		topicName = k.Topic
+		topicName = topic
 	}
 	return topicName
 }
@@ -205,6 +249,9 @@ func (k *Kafka) Connect() error {
 	if err != nil {
 		return err
 	}
+	if err := k.parseTopic(); err != nil {
+		return err
+	}
 	config := sarama.NewConfig()
 
 	if k.Version != "" {
@@ -215,6 +262,10 @@ func (k *Kafka) Connect() error {
 		config.Version = version
 	}
 
+	if len(k.HeaderTags) > 0 && !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		return fmt.Errorf("header_tags requires version to be at least \"0.11.0.0\"")
+	}
+
 	if k.ClientID != "" {
 		config.ClientID = k.ClientID
 	} else {
@@ -292,19 +343,35 @@ func (k *Kafka) routingKey(metric telegraf.Metric) string {
 func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	msgs := make([]*sarama.ProducerMessage, 0, len(metrics))
 	for _, metric := range metrics {
-		buf, err := k.serializer.Serialize(metric)
-		if err != nil {
-			return err
-		}
-
 		m := &sarama.ProducerMessage{
 			Topic: k.GetTopicName(metric),
-			Value: sarama.ByteEncoder(buf),
 		}
 		key := k.routingKey(metric)
 		if key != "" {
 			m.Key = sarama.StringEncoder(key)
 		}
+		for _, tag := range k.HeaderTags {
+			if value, ok := metric.GetTag(tag); ok {
+				m.Headers = append(m.Headers, sarama.RecordHeader{
+					Key:   []byte(tag),
+					Value: []byte(value),
+				})
+			}
+		}
+
+		// The metrics are sent again if the write fails, remove the tag
+		// from a copy.
+		if k.ExcludeTopicTag && metric.HasTag(k.TopicTag) {
+			metric = metric.Copy()
+			metric.RemoveTag(k.TopicTag)
+		}
+
+		buf, err := k.serializer.Serialize(metric)
+		if err != nil {
+			return err
+		}
+		m.Value = sarama.ByteEncoder(buf)
+
 		msgs = append(msgs, m)
 	}
 
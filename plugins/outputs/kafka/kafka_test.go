@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Shopify/sarama"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -155,3 +156,122 @@ func TestRoutingKey(t *testing.T) {
 		})
 	}
 }
+
+func TestTopicName(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"env":   "prod",
+			"topic": "custom",
+		},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+
+	tests := []struct {
+		name     string
+		kafka    *Kafka
+		expected string
+	}{
+		{
+			name:     "template",
+			kafka:    &Kafka{Topic: `telegraf-{{.Tag "env"}}`},
+			expected: "telegraf-prod",
+		},
+		{
+			name:     "template with missing tag",
+			kafka:    &Kafka{Topic: `telegraf-{{ .Tag "dc" }}`},
+			expected: "telegraf-",
+		},
+		{
+			name:     "topic tag",
+			kafka:    &Kafka{Topic: "telegraf", TopicTag: "topic"},
+			expected: "custom",
+		},
+		{
+			name:     "missing topic tag",
+			kafka:    &Kafka{Topic: "telegraf", TopicTag: "dc"},
+			expected: "telegraf",
+		},
+		{
+			name: "topic tag with suffix",
+			kafka: &Kafka{
+				Topic:       "telegraf",
+				TopicTag:    "topic",
+				TopicSuffix: TopicSuffix{Method: "measurement", Separator: "_"},
+			},
+			expected: "custom_cpu",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.kafka.parseTopic())
+			require.Equal(t, tt.expected, tt.kafka.GetTopicName(m))
+		})
+	}
+}
+
+// fakeProducer records the messages sent.
+type fakeProducer struct {
+	sarama.SyncProducer
+	msgs []*sarama.ProducerMessage
+}
+
+func (p *fakeProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func TestWriteTopicTagAndHeaders(t *testing.T) {
+	s, err := serializers.NewInfluxSerializer()
+	require.NoError(t, err)
+
+	producer := &fakeProducer{}
+	k := &Kafka{
+		Topic:           "telegraf",
+		TopicTag:        "topic",
+		ExcludeTopicTag: true,
+		RoutingTag:      "topic",
+		HeaderTags:      []string{"env", "dc"},
+		producer:        producer,
+		serializer:      s,
+	}
+
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"env":   "prod",
+			"topic": "custom",
+		},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, k.Write([]telegraf.Metric{m}))
+
+	require.Len(t, producer.msgs, 1)
+	msg := producer.msgs[0]
+	require.Equal(t, "custom", msg.Topic)
+	require.Equal(t, sarama.StringEncoder("custom"), msg.Key)
+	require.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("env"), Value: []byte("prod")},
+	}, msg.Headers)
+	require.Equal(t, sarama.ByteEncoder("cpu,env=prod value=42 0\n"), msg.Value)
+
+	// The metric to send again on failure keeps the tag.
+	require.True(t, m.HasTag("topic"))
+}
+
+func TestConnectHeaderTagsVersion(t *testing.T) {
+	k := &Kafka{
+		Topic:      "telegraf",
+		HeaderTags: []string{"env"},
+	}
+	require.Error(t, k.Connect())
+
+	k.Version = "0.10.2.0"
+	require.Error(t, k.Connect())
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 )
@@ -23,6 +24,7 @@ type Librato struct {
 	SourceTag string // Deprecated, keeping for backward-compatibility
 	Timeout   internal.Duration
 	Template  string
+	httpconfig.ProxyConfig
 
 	APIUrl string
 	client *http.Client
@@ -47,6 +49,11 @@ var sampleConfig = `
   ## This template is used in librato's source (not metric's name)
   template = "host"
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
 `
 
 // LMetrics is the default struct for Librato's API fromat
@@ -67,8 +74,9 @@ const libratoAPI = "https://metrics-api.librato.com/v1/metrics"
 // NewLibrato is the main constructor for librato output plugins
 func NewLibrato(apiURL string) *Librato {
 	return &Librato{
-		APIUrl:   apiURL,
-		Template: "host",
+		APIUrl:      apiURL,
+		Template:    "host",
+		ProxyConfig: httpconfig.ProxyConfig{UseSystemProxy: true},
 	}
 }
 
@@ -79,9 +87,14 @@ func (l *Librato) Connect() error {
 		return fmt.Errorf(
 			"api_user and api_token are required fields for librato output")
 	}
+	proxy, err := l.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+
 	l.client = &http.Client{
 		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
+			Proxy: proxy,
 		},
 		Timeout: l.Timeout.Duration,
 	}
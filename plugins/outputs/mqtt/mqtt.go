@@ -9,6 +9,7 @@ import (
 	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/metrictemplate"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -22,6 +23,11 @@ var sampleConfig = `
   ##   ex: prefix/web01.example.com/mem
   topic_prefix = "telegraf"
 
+  ## Template of the topic, overriding the topic_prefix format. Available are
+  ## .TopicPrefix, .Hostname (the host tag), .Name and .Tag "tag_name".
+  ##   ex: topic = "telegraf/{{.Hostname}}/{{.Name}}"
+  # topic = ""
+
   ## QoS policy for messages
   ##   0 = at most once
   ##   1 = at least once
@@ -67,14 +73,16 @@ type MQTT struct {
 	Database    string
 	Timeout     internal.Duration
 	TopicPrefix string
+	Topic       string `toml:"topic"`
 	QoS         int    `toml:"qos"`
 	ClientID    string `toml:"client_id"`
 	tls.ClientConfig
 	BatchMessage bool `toml:"batch"`
 	Retain       bool `toml:"retain"`
 
-	client paho.Client
-	opts   *paho.ClientOptions
+	client        paho.Client
+	opts          *paho.ClientOptions
+	topicTemplate *metrictemplate.Template
 
 	serializer serializers.Serializer
 
@@ -89,6 +97,11 @@ func (m *MQTT) Connect() error {
 		return fmt.Errorf("MQTT Output, invalid QoS value: %d", m.QoS)
 	}
 
+	err = m.parseTopic()
+	if err != nil {
+		return err
+	}
+
 	m.opts, err = m.createOpts()
 	if err != nil {
 		return err
@@ -127,26 +140,20 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 	if len(metrics) == 0 {
 		return nil
 	}
-	hostname, ok := metrics[0].Tags()["host"]
-	if !ok {
-		hostname = ""
-	}
 
 	metricsmap := make(map[string][]telegraf.Metric)
+	var topics []string
 
 	for _, metric := range metrics {
-		var t []string
-		if m.TopicPrefix != "" {
-			t = append(t, m.TopicPrefix)
-		}
-		if hostname != "" {
-			t = append(t, hostname)
+		topic, err := m.topic(metric)
+		if err != nil {
+			return err
 		}
 
-		t = append(t, metric.Name())
-		topic := strings.Join(t, "/")
-
 		if m.BatchMessage {
+			if _, ok := metricsmap[topic]; !ok {
+				topics = append(topics, topic)
+			}
 			metricsmap[topic] = append(metricsmap[topic], metric)
 		} else {
 			buf, err := m.serializer.Serialize(metric)
@@ -162,7 +169,7 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
-	for key := range metricsmap {
+	for _, key := range topics {
 		buf, err := m.serializer.SerializeBatch(metricsmap[key])
 
 		if err != nil {
@@ -177,9 +184,59 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// parseTopic parses the topic template, if set.
+func (m *MQTT) parseTopic() error {
+	if m.Topic == "" {
+		return nil
+	}
+	tmpl, err := metrictemplate.New("topic", m.Topic)
+	if err != nil {
+		return fmt.Errorf("MQTT Output, invalid topic template: %v", err)
+	}
+	m.topicTemplate = tmpl
+	return nil
+}
+
+// topicData is the data of the topic template.
+type topicData struct {
+	metrictemplate.Metric
+	TopicPrefix string
+	Hostname    string
+}
+
+// topic returns the topic to publish the metric to.
+func (m *MQTT) topic(metric telegraf.Metric) (string, error) {
+	hostname, _ := metric.GetTag("host")
+
+	if m.topicTemplate != nil {
+		data := &topicData{
+			Metric:      metrictemplate.NewMetric(metric),
+			TopicPrefix: m.TopicPrefix,
+			Hostname:    hostname,
+		}
+		topic, err := m.topicTemplate.Execute(data)
+		if err != nil {
+			return "", fmt.Errorf("MQTT Output, executing topic template: %v", err)
+		}
+		return topic, nil
+	}
+
+	var t []string
+	if m.TopicPrefix != "" {
+		t = append(t, m.TopicPrefix)
+	}
+	if hostname != "" {
+		t = append(t, hostname)
+	}
+	t = append(t, metric.Name())
+	return strings.Join(t, "/"), nil
+}
+
 func (m *MQTT) publish(topic string, body []byte) error {
 	token := m.client.Publish(topic, byte(m.QoS), m.Retain, body)
-	token.WaitTimeout(m.Timeout.Duration)
+	if !token.WaitTimeout(m.Timeout.Duration) {
+		return fmt.Errorf("timeout publishing to topic %q", topic)
+	}
 	if token.Error() != nil {
 		return token.Error()
 	}
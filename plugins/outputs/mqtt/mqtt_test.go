@@ -2,7 +2,10 @@ package mqtt
 
 import (
 	"testing"
+	"time"
 
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
 
@@ -29,3 +32,109 @@ func TestConnectAndWrite(t *testing.T) {
 	err = m.Write(testutil.MockMetrics())
 	require.NoError(t, err)
 }
+
+type fakeToken struct {
+	paho.Token
+}
+
+func (t *fakeToken) Wait() bool                     { return true }
+func (t *fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (t *fakeToken) Error() error                   { return nil }
+
+type message struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  string
+}
+
+// fakeClient records the published messages.
+type fakeClient struct {
+	paho.Client
+	messages []message
+}
+
+func (c *fakeClient) Publish(topic string, qos byte, retained bool, payload interface{}) paho.Token {
+	c.messages = append(c.messages, message{topic, qos, retained, string(payload.([]byte))})
+	return &fakeToken{}
+}
+
+func getMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "server01", "cpu": "cpu0"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"mem",
+			map[string]string{"host": "server02"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "server01", "cpu": "cpu1"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		),
+	}
+}
+
+func TestWriteTopics(t *testing.T) {
+	s, _ := serializers.NewInfluxSerializer()
+	tests := []struct {
+		name   string
+		plugin *MQTT
+		topics []string
+	}{
+		{
+			name:   "topic prefix",
+			plugin: &MQTT{TopicPrefix: "telegraf"},
+			topics: []string{"telegraf/server01/cpu", "telegraf/server02/mem", "telegraf/server01/cpu"},
+		},
+		{
+			name: "topic template",
+			plugin: &MQTT{
+				TopicPrefix: "telegraf",
+				Topic:       `{{.TopicPrefix}}/{{.Name}}/{{.Hostname}}/{{.Tag "cpu"}}`,
+			},
+			topics: []string{"telegraf/cpu/server01/cpu0", "telegraf/mem/server02/", "telegraf/cpu/server01/cpu1"},
+		},
+		{
+			name: "batch",
+			plugin: &MQTT{
+				Topic:        "telegraf/{{.Hostname}}",
+				BatchMessage: true,
+				Retain:       true,
+				QoS:          1,
+			},
+			topics: []string{"telegraf/server01", "telegraf/server02"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{}
+			tt.plugin.Timeout.Duration = time.Second
+			tt.plugin.serializer = s
+			tt.plugin.client = client
+			require.NoError(t, tt.plugin.parseTopic())
+
+			require.NoError(t, tt.plugin.Write(getMetrics()))
+
+			var topics []string
+			for _, msg := range client.messages {
+				topics = append(topics, msg.topic)
+				require.Equal(t, byte(tt.plugin.QoS), msg.qos)
+				require.Equal(t, tt.plugin.Retain, msg.retained)
+			}
+			require.Equal(t, tt.topics, topics)
+		})
+	}
+}
+
+func TestInvalidTopicTemplate(t *testing.T) {
+	m := &MQTT{Topic: "telegraf/{{.Name"}
+	require.Error(t, m.parseTopic())
+}
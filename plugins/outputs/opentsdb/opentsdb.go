@@ -2,8 +2,8 @@ package opentsdb
 
 import (
 	"fmt"
-	"log"
 	"net"
+	"net/http"
 	"net/url"
 	"regexp"
 	"sort"
@@ -11,6 +11,8 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -32,12 +34,20 @@ type OpenTSDB struct {
 	Host string
 	Port int
 
-	HttpBatchSize int // deprecated httpBatchSize form in 1.8
-	HttpPath      string
+	HttpBatchSize       int // deprecated httpBatchSize form in 1.8
+	HttpMaxBodySize     internal.Size
+	HttpPath            string
+	HttpContentEncoding string
 
 	Debug bool
 
 	Separator string
+
+	httpconfig.ProxyConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client *http.Client
 }
 
 var sampleConfig = `
@@ -56,10 +66,24 @@ var sampleConfig = `
   ## Not used with telnet API.
   http_batch_size = 50
 
+  ## Maximum size of the JSON body of the Http requests, before compression.
+  ## Requests are split to stay below it, such as below the
+  ## tsd.http.request.max_chunk of OpenTSDB.  There is no limit if unset.
+  # http_max_body_size = "4KiB"
+
+  ## Content encoding of the Http requests, "gzip" or "identity".
+  # http_content_encoding = "gzip"
+
   ## URI Path for Http requests to OpenTSDB.
   ## Used in cases where OpenTSDB is located behind a reverse proxy.
   http_path = "/api/put"
 
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+
   ## Debug true - Prints OpenTSDB communication
   debug = false
 
@@ -79,6 +103,12 @@ func ToLineFormat(tags map[string]string) string {
 }
 
 func (o *OpenTSDB) Connect() error {
+	switch o.HttpContentEncoding {
+	case "", "gzip", "identity":
+	default:
+		return fmt.Errorf("invalid http_content_encoding: %q", o.HttpContentEncoding)
+	}
+
 	if !strings.HasPrefix(o.Host, "http") && !strings.HasPrefix(o.Host, "tcp") {
 		o.Host = "tcp://" + o.Host
 	}
@@ -88,6 +118,16 @@ func (o *OpenTSDB) Connect() error {
 		return fmt.Errorf("Error in parsing host url: %s", err.Error())
 	}
 
+	proxy, err := o.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+	o.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy: proxy,
+		},
+	}
+
 	uri := fmt.Sprintf("%s:%d", u.Host, o.Port)
 	tcpAddr, err := net.ResolveTCPAddr("tcp", uri)
 	if err != nil {
@@ -122,13 +162,17 @@ func (o *OpenTSDB) Write(metrics []telegraf.Metric) error {
 
 func (o *OpenTSDB) WriteHttp(metrics []telegraf.Metric, u *url.URL) error {
 	http := openTSDBHttp{
-		Host:      u.Host,
-		Port:      o.Port,
-		Scheme:    u.Scheme,
-		User:      u.User,
-		BatchSize: o.HttpBatchSize,
-		Path:      o.HttpPath,
-		Debug:     o.Debug,
+		Host:        u.Host,
+		Port:        o.Port,
+		Scheme:      u.Scheme,
+		User:        u.User,
+		BatchSize:   o.HttpBatchSize,
+		MaxBodySize: o.HttpMaxBodySize.Size,
+		Path:        o.HttpPath,
+		Gzip:        o.HttpContentEncoding != "identity",
+		Debug:       o.Debug,
+		Log:         o.Log,
+		Client:      o.client,
 	}
 
 	for _, m := range metrics {
@@ -141,7 +185,7 @@ func (o *OpenTSDB) WriteHttp(metrics []telegraf.Metric, u *url.URL) error {
 			case uint64:
 			case float64:
 			default:
-				log.Printf("D! OpenTSDB does not support metric value: [%s] of type [%T].\n", value, value)
+				o.Log.Debugf("OpenTSDB does not support metric value: [%s] of type [%T].", value, value)
 				continue
 			}
 
@@ -153,7 +197,7 @@ func (o *OpenTSDB) WriteHttp(metrics []telegraf.Metric, u *url.URL) error {
 				Value:     value,
 			}
 
-			if err := http.sendDataPoint(metric); err != nil {
+			if err := http.sendDataPoint(metric, m); err != nil {
 				return err
 			}
 		}
@@ -163,7 +207,7 @@ func (o *OpenTSDB) WriteHttp(metrics []telegraf.Metric, u *url.URL) error {
 		return err
 	}
 
-	return nil
+	return http.result()
 }
 
 func (o *OpenTSDB) WriteTelnet(metrics []telegraf.Metric, u *url.URL) error {
@@ -186,13 +230,13 @@ func (o *OpenTSDB) WriteTelnet(metrics []telegraf.Metric, u *url.URL) error {
 			case uint64:
 			case float64:
 			default:
-				log.Printf("D! OpenTSDB does not support metric value: [%s] of type [%T].\n", value, value)
+				o.Log.Debugf("OpenTSDB does not support metric value: [%s] of type [%T].", value, value)
 				continue
 			}
 
 			metricValue, buildError := buildValue(value)
 			if buildError != nil {
-				log.Printf("E! OpenTSDB: %s\n", buildError.Error())
+				o.Log.Errorf("OpenTSDB: %s", buildError.Error())
 				continue
 			}
 
@@ -270,8 +314,12 @@ func sanitize(value string) string {
 func init() {
 	outputs.Add("opentsdb", func() telegraf.Output {
 		return &OpenTSDB{
-			HttpPath:  defaultHttpPath,
-			Separator: defaultSeperator,
+			HttpPath:            defaultHttpPath,
+			HttpContentEncoding: "gzip",
+			Separator:           defaultSeperator,
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
 		}
 	})
 }
@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 )
 
 type HttpMetric struct {
@@ -20,123 +24,112 @@ type HttpMetric struct {
 	Tags      map[string]string `json:"tags"`
 }
 
-type openTSDBHttp struct {
-	Host      string
-	Port      int
-	Scheme    string
-	User      *url.Userinfo
-	BatchSize int
-	Path      string
-	Debug     bool
-
-	metricCounter int
-	body          requestBody
-}
-
-type requestBody struct {
-	b bytes.Buffer
-	g *gzip.Writer
-
-	dbgB bytes.Buffer
-
-	w   io.Writer
-	enc *json.Encoder
-
-	empty bool
+// putResponse is the response of /api/put with the details parameter.
+type putResponse struct {
+	Failed  int `json:"failed"`
+	Success int `json:"success"`
+	Errors  []struct {
+		Datapoint HttpMetric `json:"datapoint"`
+		Error     string     `json:"error"`
+	} `json:"errors"`
 }
 
-func (r *requestBody) reset(debug bool) {
-	r.b.Reset()
-	r.dbgB.Reset()
-
-	if r.g == nil {
-		r.g = gzip.NewWriter(&r.b)
-	} else {
-		r.g.Reset(&r.b)
-	}
-
-	if debug {
-		r.w = io.MultiWriter(r.g, &r.dbgB)
-	} else {
-		r.w = r.g
-	}
-
-	r.enc = json.NewEncoder(r.w)
-
-	io.WriteString(r.w, "[")
-
-	r.empty = true
+type openTSDBHttp struct {
+	Host        string
+	Port        int
+	Scheme      string
+	User        *url.Userinfo
+	BatchSize   int
+	MaxBodySize int64
+	Path        string
+	Gzip        bool
+	Debug       bool
+	Log         telegraf.Logger
+	Client      *http.Client
+
+	// body is the JSON array of the data points of the next request, their
+	// metrics are in owners.
+	body   bytes.Buffer
+	points []*HttpMetric
+	owners []telegraf.Metric
+
+	rejected []telegraf.Metric
+	reasons  []string
 }
 
-func (r *requestBody) addMetric(metric *HttpMetric) error {
-	if !r.empty {
-		io.WriteString(r.w, ",")
-	}
-
-	if err := r.enc.Encode(metric); err != nil {
+// sendDataPoint adds the data point of the metric to the request, sending
+// the request first if the point would exceed its size.
+func (o *openTSDBHttp) sendDataPoint(metric *HttpMetric, owner telegraf.Metric) error {
+	point, err := json.Marshal(metric)
+	if err != nil {
 		return fmt.Errorf("Metric serialization error %s", err.Error())
 	}
 
-	r.empty = false
-
-	return nil
-}
-
-func (r *requestBody) close() error {
-	io.WriteString(r.w, "]")
-
-	if err := r.g.Close(); err != nil {
-		return fmt.Errorf("Error when closing gzip writer: %s", err.Error())
-	}
-
-	return nil
-}
-
-func (o *openTSDBHttp) sendDataPoint(metric *HttpMetric) error {
-	if o.metricCounter == 0 {
-		o.body.reset(o.Debug)
-	}
-
-	if err := o.body.addMetric(metric); err != nil {
-		return err
-	}
-
-	o.metricCounter++
-	if o.metricCounter == o.BatchSize {
+	// Each point is preceded by a bracket or a comma and the array is
+	// closed by a bracket.
+	size := int64(o.body.Len() + len(point) + 2)
+	if len(o.points) > 0 && o.MaxBodySize > 0 && size > o.MaxBodySize {
 		if err := o.flush(); err != nil {
 			return err
 		}
+	}
 
-		o.metricCounter = 0
+	if len(o.points) == 0 {
+		o.body.WriteString("[")
+	} else {
+		o.body.WriteString(",")
 	}
+	o.body.Write(point)
+	o.points = append(o.points, metric)
+	o.owners = append(o.owners, owner)
 
+	if len(o.points) == o.BatchSize {
+		return o.flush()
+	}
 	return nil
 }
 
+// flush sends the request.  The data points OpenTSDB refuses are logged and
+// their metrics kept to be reported by result.
 func (o *openTSDBHttp) flush() error {
-	if o.metricCounter == 0 {
+	if len(o.points) == 0 {
 		return nil
 	}
+	o.body.WriteString("]")
 
-	o.body.close()
+	defer func() {
+		o.body.Reset()
+		o.points = o.points[:0]
+		o.owners = o.owners[:0]
+	}()
 
 	u := url.URL{
-		Scheme: o.Scheme,
-		User:   o.User,
-		Host:   fmt.Sprintf("%s:%d", o.Host, o.Port),
-		Path:   o.Path,
-	}
-
-	if o.Debug {
-		u.RawQuery = "details"
+		Scheme:   o.Scheme,
+		User:     o.User,
+		Host:     fmt.Sprintf("%s:%d", o.Host, o.Port),
+		Path:     o.Path,
+		RawQuery: "details",
+	}
+
+	var body io.Reader = bytes.NewReader(o.body.Bytes())
+	if o.Gzip {
+		var b bytes.Buffer
+		g := gzip.NewWriter(&b)
+		g.Write(o.body.Bytes())
+		if err := g.Close(); err != nil {
+			return fmt.Errorf("Error when closing gzip writer: %s", err.Error())
+		}
+		body = &b
 	}
 
-	req, err := http.NewRequest("POST", u.String(), &o.body.b)
+	req, err := http.NewRequest("POST", u.String(), body)
 	if err != nil {
 		return fmt.Errorf("Error when building request: %s", err.Error())
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Content-Encoding", "gzip")
+	if o.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
 
 	if o.Debug {
 		dump, err := httputil.DumpRequestOut(req, false)
@@ -145,10 +138,10 @@ func (o *openTSDBHttp) flush() error {
 		}
 
 		fmt.Printf("Sending metrics:\n%s", dump)
-		fmt.Printf("Body:\n%s\n\n", o.body.dbgB.String())
+		fmt.Printf("Body:\n%s\n\n", o.body.String())
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := o.Client.Do(req)
 	if err != nil {
 		return fmt.Errorf("Error when sending metrics: %s", err.Error())
 	}
@@ -161,20 +154,72 @@ func (o *openTSDBHttp) flush() error {
 		}
 
 		fmt.Printf("Received response\n%s\n\n", dump)
-	} else {
-		// Important so http client reuse connection for next request if need be.
-		io.Copy(ioutil.Discard, resp.Body)
 	}
+	respBody, _ := ioutil.ReadAll(resp.Body)
 
-	if resp.StatusCode/100 != 2 {
-		if resp.StatusCode/100 == 4 {
-			log.Printf("E! Received %d status code. Dropping metrics to avoid overflowing buffer.",
-				resp.StatusCode)
-		} else {
-			return fmt.Errorf("Error when sending metrics. Received status %d",
-				resp.StatusCode)
+	switch resp.StatusCode / 100 {
+	case 2:
+		return nil
+	case 4:
+		o.reject(resp.StatusCode, respBody)
+		return nil
+	}
+	return fmt.Errorf("Error when sending metrics. Received status %d",
+		resp.StatusCode)
+}
+
+// reject keeps the metrics of the data points refused by OpenTSDB.  Without
+// details in the response all the data points of the request are refused.
+func (o *openTSDBHttp) reject(status int, body []byte) {
+	var details putResponse
+	if err := json.Unmarshal(body, &details); err != nil || len(details.Errors) == 0 {
+		o.Log.Errorf("Received %d status code, %d data points failed",
+			status, len(o.points))
+		o.rejected = append(o.rejected, o.owners...)
+		o.reasons = append(o.reasons, fmt.Sprintf("received status code %d", status))
+		return
+	}
+
+	for _, e := range details.Errors {
+		o.Log.Errorf("Data point %s %d %v failed: %s",
+			e.Datapoint.Metric, e.Datapoint.Timestamp, e.Datapoint.Tags, e.Error)
+		if owner := o.owner(&e.Datapoint); owner != nil {
+			o.rejected = append(o.rejected, owner)
 		}
 	}
+	o.reasons = append(o.reasons, fmt.Sprintf("%d data points failed: %s",
+		details.Failed, details.Errors[0].Error))
+}
 
+// owner returns the metric of the data point of the request, nil if it is
+// not found.
+func (o *openTSDBHttp) owner(point *HttpMetric) telegraf.Metric {
+	for i, p := range o.points {
+		if p.Metric == point.Metric && p.Timestamp == point.Timestamp &&
+			reflect.DeepEqual(p.Tags, point.Tags) {
+			return o.owners[i]
+		}
+	}
 	return nil
 }
+
+// result returns the metrics refused by OpenTSDB as a rejected error, nil if
+// all were written.
+func (o *openTSDBHttp) result() error {
+	if len(o.rejected) == 0 {
+		return nil
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(o.rejected))
+	seen := make(map[telegraf.Metric]bool, len(o.rejected))
+	for _, m := range o.rejected {
+		if !seen[m] {
+			seen[m] = true
+			metrics = append(metrics, m)
+		}
+	}
+	return &internal.RejectedError{
+		Metrics: metrics,
+		Reason:  strings.Join(o.reasons, "; "),
+	}
+}
@@ -1,7 +1,10 @@
 package opentsdb
 
 import (
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -125,6 +129,110 @@ func TestSanitize(t *testing.T) {
 	}
 }
 
+// newHttpOpenTSDB returns an output writing to the test server.
+func newHttpOpenTSDB(t *testing.T, ts *httptest.Server) *OpenTSDB {
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	h, p, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(p)
+	require.NoError(t, err)
+
+	return &OpenTSDB{
+		Log:                 testutil.Logger{},
+		Host:                "http://" + h,
+		Port:                port,
+		HttpPath:            "/api/put",
+		HttpContentEncoding: "gzip",
+		Separator:           "_",
+	}
+}
+
+// readPoints returns the data points of a request.
+func readPoints(t *testing.T, r *http.Request) []HttpMetric {
+	require.Equal(t, "details", r.URL.RawQuery)
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body = gz
+	}
+
+	var points []HttpMetric
+	require.NoError(t, json.NewDecoder(body).Decode(&points))
+	return points
+}
+
+func TestWriteHttpMaxBodySize(t *testing.T) {
+	var sizes []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sizes = append(sizes, len(readPoints(t, r)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	o := newHttpOpenTSDB(t, ts)
+	o.HttpContentEncoding = "identity"
+	o.HttpMaxBodySize.Size = 250
+	require.NoError(t, o.Connect())
+
+	metrics := make([]telegraf.Metric, 5)
+	for i := range metrics {
+		metrics[i] = testutil.TestMetric(1.0, fmt.Sprintf("metric%d", i))
+	}
+
+	// A data point takes about 100 bytes, two fit in a request
+	require.NoError(t, o.Write(metrics))
+	require.Equal(t, []int{2, 2, 1}, sizes)
+}
+
+func TestWriteHttpFailedDataPoints(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		points := readPoints(t, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"failed":1,"success":%d,"errors":[{"datapoint":{"metric":%q,"timestamp":%d,"value":1,"tags":{"tag1":"value1"}},"error":"Unable to parse value"}]}`,
+			len(points)-1, points[1].Metric, points[1].Timestamp)
+	}))
+	defer ts.Close()
+
+	o := newHttpOpenTSDB(t, ts)
+	require.NoError(t, o.Connect())
+
+	metrics := []telegraf.Metric{
+		testutil.TestMetric(1.0, "good"),
+		testutil.TestMetric(2.0, "bad"),
+	}
+
+	// Only the metric of the failed data point is rejected
+	err := o.Write(metrics)
+	rerr, ok := err.(*internal.RejectedError)
+	require.True(t, ok)
+	require.Equal(t, []telegraf.Metric{metrics[1]}, rerr.Metrics)
+	require.Contains(t, rerr.Reason, "Unable to parse value")
+}
+
+func TestWriteHttpServerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	o := newHttpOpenTSDB(t, ts)
+	require.NoError(t, o.Connect())
+
+	err := o.Write([]telegraf.Metric{testutil.TestMetric(1.0)})
+	require.Error(t, err)
+	_, ok := err.(*internal.RejectedError)
+	require.False(t, ok)
+}
+
+func TestConnectInvalidContentEncoding(t *testing.T) {
+	o := &OpenTSDB{HttpContentEncoding: "br", Log: testutil.Logger{}}
+	require.Error(t, o.Connect())
+}
+
 func BenchmarkHttpSend(b *testing.B) {
 	const BatchSize = 50
 	const MetricsCount = 4 * BatchSize
@@ -152,11 +260,13 @@ func BenchmarkHttpSend(b *testing.B) {
 	}
 
 	o := &OpenTSDB{
+		Log:           testutil.Logger{},
 		Host:          ts.URL,
 		Port:          port,
 		Prefix:        "",
 		HttpBatchSize: BatchSize,
 		HttpPath:      "/api/put",
+		client:        &http.Client{},
 	}
 
 	b.ResetTimer()
@@ -84,7 +84,7 @@ var sampleConfig = `
   ## Address to listen on
   listen = ":9273"
 
-  ## Use HTTP Basic Authentication.
+  ## Use HTTP Basic Authentication, enabled when basic_username is set.
   # basic_username = "Foo"
   # basic_password = "Bar"
 
@@ -95,7 +95,8 @@ var sampleConfig = `
   ## Path to publish the metrics on.
   # path = "/metrics"
 
-  ## Expiration interval for each metric. 0 == no expiration
+  ## Expiration interval for each metric, series not written for this long
+  ## are removed from the output. 0 == no expiration
   # expiration_interval = "60s"
 
   ## Collectors to enable, valid entries are "gocollector" and "process".
@@ -114,13 +115,16 @@ var sampleConfig = `
   ## enable mutually authenticated TLS connections
   # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
 
+  ## Minimal TLS version accepted by the server.
+  # tls_min_version = "1.2"
+
   ## Export metric collection time.
   # export_timestamp = false
 `
 
 func (p *PrometheusClient) auth(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if p.BasicUsername != "" && p.BasicPassword != "" {
+		if p.BasicUsername != "" {
 			w.Header().Set("WWW-Authenticate", `Basic realm="Restricted"`)
 
 			username, password, ok := r.BasicAuth()
@@ -365,6 +369,13 @@ func CreateSampleID(tags map[string]string) SampleID {
 }
 
 func addSample(fam *MetricFamily, sample *Sample, sampleID SampleID) {
+	// The labels of a replaced sample are not used anymore unless the new
+	// sample has them.
+	if old, ok := fam.Samples[sampleID]; ok {
+		for k := range old.Labels {
+			fam.LabelSet[k]--
+		}
+	}
 
 	for k := range sample.Labels {
 		fam.LabelSet[k]++
@@ -406,6 +417,10 @@ func (p *PrometheusClient) Write(metrics []telegraf.Metric) error {
 	p.Lock()
 	defer p.Unlock()
 
+	// Expire on write too so the series do not accumulate when the endpoint
+	// is not scraped.
+	p.Expire()
+
 	now := p.now()
 
 	for _, point := range sorted(metrics) {
@@ -1,6 +1,8 @@
 package prometheus_client
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -691,3 +693,110 @@ func setupPrometheus() (*PrometheusClient, *prometheus_input.Prometheus, error)
 
 	return pTesting, p, nil
 }
+
+func TestWrite_ReplaceSampleLabels(t *testing.T) {
+	client := NewClient()
+
+	p1 := testutil.MustMetric(
+		"foo",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"value": 1.0, "status": "ok"},
+		time.Unix(0, 0))
+	err := client.Write([]telegraf.Metric{p1})
+	require.NoError(t, err)
+
+	p2 := testutil.MustMetric(
+		"foo",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"value": 2.0},
+		time.Unix(1, 0))
+	err = client.Write([]telegraf.Metric{p2})
+	require.NoError(t, err)
+
+	fam, ok := client.fam["foo"]
+	require.True(t, ok)
+	require.Equal(t, 1, len(fam.Samples))
+	require.Equal(t, map[string]int{"host": 1, "status": 0}, fam.LabelSet)
+}
+
+func TestWrite_Expire(t *testing.T) {
+	client := NewClient()
+
+	p1 := testutil.MustMetric(
+		"foo",
+		make(map[string]string),
+		map[string]interface{}{"value": 1.0},
+		time.Unix(0, 0))
+	setUnixTime(client, 0)
+	err := client.Write([]telegraf.Metric{p1})
+	require.NoError(t, err)
+
+	p2 := testutil.MustMetric(
+		"bar",
+		make(map[string]string),
+		map[string]interface{}{"value": 2.0},
+		time.Unix(0, 0))
+	setUnixTime(client, 61)
+	err = client.Write([]telegraf.Metric{p2})
+	require.NoError(t, err)
+
+	_, ok := client.fam["foo"]
+	require.False(t, ok)
+	_, ok = client.fam["bar"]
+	require.True(t, ok)
+}
+
+func TestAuth(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name     string
+		client   *PrometheusClient
+		username string
+		password string
+		expected int
+	}{
+		{
+			name:     "no auth",
+			client:   &PrometheusClient{},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "valid credentials",
+			client:   &PrometheusClient{BasicUsername: "foo", BasicPassword: "bar"},
+			username: "foo",
+			password: "bar",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "invalid credentials",
+			client:   &PrometheusClient{BasicUsername: "foo", BasicPassword: "bar"},
+			username: "foo",
+			password: "baz",
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "username without password",
+			client:   &PrometheusClient{BasicUsername: "foo"},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "ip not in range",
+			client:   &PrometheusClient{IPRange: []string{"10.0.0.0/8"}},
+			expected: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.username != "" {
+				req.SetBasicAuth(tt.username, tt.password)
+			}
+			rec := httptest.NewRecorder()
+			tt.client.auth(handler).ServeHTTP(rec, req)
+			require.Equal(t, tt.expected, rec.Code)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package riemann_legacy
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"sort"
 	"strings"
@@ -12,8 +11,6 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
-const deprecationMsg = "E! Error: this Riemann output plugin will be deprecated in a future release, see https://github.com/influxdata/telegraf/issues/1878 for more details & discussion."
-
 type Riemann struct {
 	URL       string
 	Transport string
@@ -32,7 +29,6 @@ var sampleConfig = `
 `
 
 func (r *Riemann) Connect() error {
-	log.Printf(deprecationMsg)
 	c, err := raidman.Dial(r.Transport, r.URL)
 
 	if err != nil {
@@ -62,7 +58,6 @@ func (r *Riemann) Description() string {
 }
 
 func (r *Riemann) Write(metrics []telegraf.Metric) error {
-	log.Printf(deprecationMsg)
 	if len(metrics) == 0 {
 		return nil
 	}
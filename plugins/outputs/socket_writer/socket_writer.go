@@ -1,9 +1,10 @@
 package socket_writer
 
 import (
+	"bytes"
 	"fmt"
-	"log"
 	"net"
+	"strconv"
 	"strings"
 
 	"crypto/tls"
@@ -18,8 +19,11 @@ import (
 type SocketWriter struct {
 	Address         string
 	KeepAlivePeriod *internal.Duration
+	Framing         string
 	tlsint.ClientConfig
 
+	Log telegraf.Logger `toml:"-"`
+
 	serializers.Serializer
 
 	net.Conn
@@ -56,6 +60,13 @@ func (sw *SocketWriter) SampleConfig() string {
   ## Defaults to the OS configuration.
   # keep_alive_period = "5m"
 
+  ## Framing of the serialized metrics.
+  ##   none:           as generated by the data format
+  ##   newline:        each metric is terminated by a single newline
+  ##   octet-counting: each metric is prefixed by its length, as in RFC 6587
+  ##                   for syslog receivers
+  # framing = "none"
+
   ## Data format to generate.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -69,6 +80,12 @@ func (sw *SocketWriter) SetSerializer(s serializers.Serializer) {
 }
 
 func (sw *SocketWriter) Connect() error {
+	switch sw.Framing {
+	case "", "none", "newline", "octet-counting":
+	default:
+		return fmt.Errorf("unknown framing %q", sw.Framing)
+	}
+
 	spl := strings.SplitN(sw.Address, "://", 2)
 	if len(spl) != 2 {
 		return fmt.Errorf("invalid address: %s", sw.Address)
@@ -90,7 +107,7 @@ func (sw *SocketWriter) Connect() error {
 	}
 
 	if err := sw.setKeepAlive(c); err != nil {
-		log.Printf("unable to configure keep alive (%s): %s", sw.Address, err)
+		sw.Log.Warnf("Unable to configure keep alive (%s): %s", sw.Address, err)
 	}
 
 	sw.Conn = c
@@ -115,7 +132,10 @@ func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
 }
 
 // Write writes the given metrics to the destination.
-// If an error is encountered, it is up to the caller to retry the same write again later.
+// On a permanent error the connection is reopened and the metrics not yet
+// written are sent again once.  If that fails too, it is up to the caller to
+// retry the same write again later.
+// Metrics that cannot be serialized are rejected.
 // Not parallel safe.
 func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 	if sw.Conn == nil {
@@ -125,27 +145,56 @@ func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
-	for _, m := range metrics {
-		bs, err := sw.Serialize(m)
+	var rejected []telegraf.Metric
+	var reason string
+	reconnected := false
+	for i := 0; i < len(metrics); i++ {
+		bs, err := sw.Serialize(metrics[i])
 		if err != nil {
-			//TODO log & keep going with remaining metrics
-			return err
+			sw.Log.Errorf("Could not serialize metric: %v", err)
+			rejected = append(rejected, metrics[i])
+			reason = err.Error()
+			continue
 		}
-		if _, err := sw.Conn.Write(bs); err != nil {
-			//TODO log & keep going with remaining strings
-			if err, ok := err.(net.Error); !ok || !err.Temporary() {
-				// permanent error. close the connection
-				sw.Close()
-				sw.Conn = nil
+		if _, err := sw.Conn.Write(sw.frame(bs)); err != nil {
+			if err, ok := err.(net.Error); ok && err.Temporary() {
+				return err
+			}
+			// permanent error. close the connection
+			sw.Close()
+			if reconnected {
 				return fmt.Errorf("closing connection: %v", err)
 			}
-			return err
+			if cerr := sw.Connect(); cerr != nil {
+				return fmt.Errorf("closing connection: %v; reconnecting: %v", err, cerr)
+			}
+			sw.Log.Infof("Reconnected to %s after write error: %v", sw.Address, err)
+			reconnected = true
+			i--
 		}
 	}
 
+	if len(rejected) > 0 {
+		return &internal.RejectedError{Metrics: rejected, Reason: reason}
+	}
 	return nil
 }
 
+// frame returns the serialized metric with the framing of the writer.
+func (sw *SocketWriter) frame(bs []byte) []byte {
+	switch sw.Framing {
+	case "newline":
+		return append(bytes.TrimRight(bs, "\n"), '\n')
+	case "octet-counting":
+		msg := bytes.TrimRight(bs, "\n")
+		out := make([]byte, 0, len(msg)+8)
+		out = strconv.AppendInt(out, int64(len(msg)), 10)
+		out = append(out, ' ')
+		return append(out, msg...)
+	}
+	return bs
+}
+
 // Close closes the connection. Noop if already closed.
 func (sw *SocketWriter) Close() error {
 	if sw.Conn == nil {
@@ -3,6 +3,7 @@ package socket_writer
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
@@ -21,6 +22,7 @@ func TestSocketWriter_tcp(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "tcp://" + listener.Addr().String()
 
 	err = sw.Connect()
@@ -37,6 +39,7 @@ func TestSocketWriter_udp(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "udp://" + listener.LocalAddr().String()
 
 	err = sw.Connect()
@@ -55,6 +58,7 @@ func TestSocketWriter_unix(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "unix://" + sock
 
 	err = sw.Connect()
@@ -76,6 +80,7 @@ func TestSocketWriter_unixgram(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "unixgram://" + sock
 
 	err = sw.Connect()
@@ -137,6 +142,7 @@ func TestSocketWriter_Write_err(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "tcp://" + listener.Addr().String()
 
 	err = sw.Connect()
@@ -149,8 +155,10 @@ func TestSocketWriter_Write_err(t *testing.T) {
 
 	metrics := []telegraf.Metric{testutil.TestMetric(1, "testerr")}
 
-	// close the socket to generate an error
+	// close the socket and the listener to generate an error the writer
+	// cannot recover from by reconnecting
 	lconn.Close()
+	listener.Close()
 	sw.Conn.Close()
 	err = sw.Write(metrics)
 	require.Error(t, err)
@@ -162,6 +170,7 @@ func TestSocketWriter_Write_reconnect(t *testing.T) {
 	require.NoError(t, err)
 
 	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
 	sw.Address = "tcp://" + listener.Addr().String()
 
 	err = sw.Connect()
@@ -195,3 +204,114 @@ func TestSocketWriter_Write_reconnect(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, string(mbsout), string(buf[:n]))
 }
+
+func TestSocketWriter_Write_retry(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
+	sw.Address = "tcp://" + listener.Addr().String()
+
+	err = sw.Connect()
+	require.NoError(t, err)
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	lconn.Close()
+
+	// close the socket to generate a permanent error
+	sw.Conn.Close()
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	var lerr error
+	go func() {
+		lconn, lerr = listener.Accept()
+		wg.Done()
+	}()
+
+	metrics := []telegraf.Metric{testutil.TestMetric(1, "testretry")}
+	err = sw.Write(metrics)
+	require.NoError(t, err)
+	require.NotNil(t, sw.Conn)
+
+	wg.Wait()
+	require.NoError(t, lerr)
+
+	mbsout, _ := sw.Serialize(metrics[0])
+	scnr := bufio.NewScanner(lconn)
+	require.True(t, scnr.Scan())
+	assert.Equal(t, string(mbsout), scnr.Text()+"\n")
+}
+
+func TestSocketWriter_framing(t *testing.T) {
+	tests := []struct {
+		framing  string
+		expected func(mbs []byte) string
+	}{
+		{
+			framing:  "none",
+			expected: func(mbs []byte) string { return string(mbs) },
+		},
+		{
+			framing:  "newline",
+			expected: func(mbs []byte) string { return string(mbs) },
+		},
+		{
+			framing: "octet-counting",
+			expected: func(mbs []byte) string {
+				msg := bytes.TrimRight(mbs, "\n")
+				return fmt.Sprintf("%d %s", len(msg), msg)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.framing, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer listener.Close()
+
+			sw := newSocketWriter()
+			sw.Log = testutil.Logger{}
+			sw.Address = "tcp://" + listener.Addr().String()
+			sw.Framing = tt.framing
+
+			err = sw.Connect()
+			require.NoError(t, err)
+			defer sw.Close()
+
+			lconn, err := listener.Accept()
+			require.NoError(t, err)
+			defer lconn.Close()
+
+			metrics := []telegraf.Metric{
+				testutil.TestMetric(1, "test"),
+				testutil.TestMetric(2, "test"),
+			}
+			err = sw.Write(metrics)
+			require.NoError(t, err)
+			sw.Close()
+
+			var expected string
+			for _, m := range metrics {
+				mbs, _ := sw.Serialize(m)
+				expected += tt.expected(mbs)
+			}
+			actual, err := ioutil.ReadAll(lconn)
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(actual))
+		})
+	}
+}
+
+func TestSocketWriter_invalidFraming(t *testing.T) {
+	sw := newSocketWriter()
+	sw.Log = testutil.Logger{}
+	sw.Address = "tcp://127.0.0.1:0"
+	sw.Framing = "length"
+
+	err := sw.Connect()
+	require.Error(t, err)
+}
@@ -0,0 +1,460 @@
+package splunk_hec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/influxdata/telegraf/internal/metrictemplate"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+var sampleConfig = `
+  ## URL of the HTTP Event Collector endpoint.
+  url = "https://localhost:8088/services/collector"
+
+  ## HEC token, sent in the Authorization header.
+  token = "00000000-0000-0000-0000-000000000000"
+
+  ## Send each field as a metric of the metrics index ("metric"), or each
+  ## metric as an event with its tags and fields ("event").
+  # mode = "metric"
+
+  ## Metadata of the sent events, they are templates where the notation
+  ## {{.Tag "tag_name"}} is replaced by the value of the tag, tags used this
+  ## way are not sent as dimensions, and {{.Name}} by the name of the metric.
+  ## The defaults of the token are used for empty values.
+  # host = '{{.Tag "host"}}'
+  # index = ""
+  # source = ""
+  # sourcetype = ""
+
+  ## HTTP Content-Encoding for write request body, can be set to "gzip" to
+  ## compress body or "identity" to apply no encoding.
+  # content_encoding = "identity"
+
+  ## Maximum size of a request body before encoding, larger writes are sent
+  ## in several requests.
+  # max_payload_size = "1MB"
+
+  ## Number of retries of a request the server is too busy to accept
+  ## (status 429 or 503), and the longest wait before a retry.  The server's
+  ## Retry-After is used when present, otherwise the wait doubles from 1s.
+  # max_retries = 3
+  # max_retry_wait = "10s"
+
+  ## Timeout for HTTP requests.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+`
+
+const (
+	defaultURL            = "https://localhost:8088/services/collector"
+	defaultHost           = `{{.Tag "host"}}`
+	defaultMaxPayloadSize = 1024 * 1024
+	defaultMaxRetries     = 3
+	defaultMaxRetryWait   = 10 * time.Second
+	defaultTimeout        = 5 * time.Second
+
+	modeMetric = "metric"
+	modeEvent  = "event"
+)
+
+type SplunkHEC struct {
+	URL             string            `toml:"url"`
+	Token           string            `toml:"token"`
+	Mode            string            `toml:"mode"`
+	Host            string            `toml:"host"`
+	Index           string            `toml:"index"`
+	Source          string            `toml:"source"`
+	SourceType      string            `toml:"sourcetype"`
+	ContentEncoding string            `toml:"content_encoding"`
+	MaxPayloadSize  internal.Size     `toml:"max_payload_size"`
+	MaxRetries      int               `toml:"max_retries"`
+	MaxRetryWait    internal.Duration `toml:"max_retry_wait"`
+	Timeout         internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+	httpconfig.ProxyConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	client     *http.Client
+	host       *metrictemplate.Template
+	index      *metrictemplate.Template
+	source     *metrictemplate.Template
+	sourceType *metrictemplate.Template
+	// templateTags are the tags used by the templates.
+	templateTags map[string]bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// hecEvent is an event of the HEC JSON format, Event is "metric" for the
+// metrics index.
+type hecEvent struct {
+	Time       float64                `json:"time"`
+	Host       string                 `json:"host,omitempty"`
+	Index      string                 `json:"index,omitempty"`
+	Source     string                 `json:"source,omitempty"`
+	SourceType string                 `json:"sourcetype,omitempty"`
+	Event      interface{}            `json:"event"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+}
+
+// hecResponse is the body of the HEC responses.
+type hecResponse struct {
+	Text string `json:"text"`
+	Code int    `json:"code"`
+}
+
+// retryError is returned by post for requests that can be retried after
+// the wait.
+type retryError struct {
+	err  error
+	wait time.Duration
+}
+
+func (e *retryError) Error() string {
+	return e.err.Error()
+}
+
+func (s *SplunkHEC) Description() string {
+	return "Send metrics to a Splunk HTTP Event Collector"
+}
+
+func (s *SplunkHEC) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SplunkHEC) Connect() error {
+	if s.Token == "" {
+		return fmt.Errorf("token is required")
+	}
+	if s.URL == "" {
+		s.URL = defaultURL
+	}
+	switch s.Mode {
+	case "":
+		s.Mode = modeMetric
+	case modeMetric, modeEvent:
+	default:
+		return fmt.Errorf("invalid mode %q, must be %q or %q", s.Mode, modeMetric, modeEvent)
+	}
+	if s.MaxPayloadSize.Size <= 0 {
+		s.MaxPayloadSize.Size = defaultMaxPayloadSize
+	}
+	if s.MaxRetries < 0 {
+		s.MaxRetries = 0
+	}
+	if s.MaxRetryWait.Duration <= 0 {
+		s.MaxRetryWait.Duration = defaultMaxRetryWait
+	}
+	if s.Timeout.Duration == 0 {
+		s.Timeout.Duration = defaultTimeout
+	}
+
+	s.templateTags = make(map[string]bool)
+	for _, t := range []struct {
+		name string
+		text string
+		tmpl **metrictemplate.Template
+	}{
+		{"host", s.Host, &s.host},
+		{"index", s.Index, &s.index},
+		{"source", s.Source, &s.source},
+		{"sourcetype", s.SourceType, &s.sourceType},
+	} {
+		tmpl, err := metrictemplate.New(t.name, t.text)
+		if err != nil {
+			return fmt.Errorf("invalid %s template: %v", t.name, err)
+		}
+		for _, key := range tmpl.Tags() {
+			s.templateTags[key] = true
+		}
+		*t.tmpl = tmpl
+	}
+
+	tlsCfg, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	proxy, err := s.ProxyConfig.Proxy()
+	if err != nil {
+		return err
+	}
+	s.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           proxy,
+		},
+		Timeout: s.Timeout.Duration,
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return nil
+}
+
+func (s *SplunkHEC) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+// Write sends the metrics in requests of at most max_payload_size bytes.  If
+// a request fails the metrics of the previous requests are sent again with
+// the next write.  The metrics of the requests refused by Splunk, and those
+// that cannot be serialized, are reported as rejected.
+func (s *SplunkHEC) Write(metrics []telegraf.Metric) error {
+	var payload bytes.Buffer
+	var pending, rejected []telegraf.Metric
+	var reasons []string
+
+	send := func() error {
+		err := s.send(payload.Bytes())
+		if rerr, ok := err.(*internal.RejectedError); ok {
+			rejected = append(rejected, pending...)
+			reasons = append(reasons, rerr.Reason)
+			err = nil
+		}
+		payload.Reset()
+		pending = nil
+		return err
+	}
+
+	for _, m := range metrics {
+		for _, event := range s.events(m) {
+			b, err := json.Marshal(event)
+			if err != nil {
+				rejected = append(rejected, m)
+				reasons = append(reasons, fmt.Sprintf("could not serialize metric %q: %v", m.Name(), err))
+				break
+			}
+
+			if payload.Len() > 0 && int64(payload.Len()+len(b)) > s.MaxPayloadSize.Size {
+				if err := send(); err != nil {
+					return err
+				}
+			}
+			payload.Write(b)
+			if len(pending) == 0 || pending[len(pending)-1] != m {
+				pending = append(pending, m)
+			}
+		}
+	}
+
+	if payload.Len() > 0 {
+		if err := send(); err != nil {
+			return err
+		}
+	}
+
+	if len(rejected) > 0 {
+		return &internal.RejectedError{
+			Metrics: rejected,
+			Reason:  strings.Join(reasons, "; "),
+		}
+	}
+	return nil
+}
+
+// render renders the metadata template for the metric, the default of the
+// token is used if it fails.
+func (s *SplunkHEC) render(t *metrictemplate.Template, m telegraf.Metric) string {
+	value, err := t.Render(m)
+	if err != nil {
+		s.Log.Errorf("Error executing template: %v", err)
+		return ""
+	}
+	return value
+}
+
+// events returns the HEC events of the metric.
+func (s *SplunkHEC) events(m telegraf.Metric) []*hecEvent {
+	dimensions := make(map[string]interface{})
+	for _, tag := range m.TagList() {
+		if !s.templateTags[tag.Key] {
+			dimensions[tag.Key] = tag.Value
+		}
+	}
+
+	base := hecEvent{
+		// Splunk takes the time as float seconds since epoch.
+		Time:       float64(m.Time().UnixNano()) / float64(time.Second),
+		Host:       s.render(s.host, m),
+		Index:      s.render(s.index, m),
+		Source:     s.render(s.source, m),
+		SourceType: s.render(s.sourceType, m),
+	}
+
+	if s.Mode == modeEvent {
+		event := base
+		event.Event = map[string]interface{}{
+			"name":   m.Name(),
+			"tags":   dimensions,
+			"fields": m.Fields(),
+		}
+		return []*hecEvent{&event}
+	}
+
+	events := make([]*hecEvent, 0, len(m.FieldList()))
+	for _, field := range m.FieldList() {
+		value, ok := metricValue(field.Value)
+		if !ok {
+			s.Log.Debugf("Skipping field %q of %q with non-numeric value", field.Key, m.Name())
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(dimensions)+2)
+		for k, v := range dimensions {
+			fields[k] = v
+		}
+		fields["metric_name"] = m.Name() + "." + field.Key
+		fields["_value"] = value
+
+		event := base
+		event.Event = "metric"
+		event.Fields = fields
+		events = append(events, &event)
+	}
+	return events
+}
+
+// metricValue returns the value for the metrics index, which only supports
+// finite numbers.
+func metricValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return v, true
+	case int64, uint64:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// send posts the payload, retrying while the server is too busy.
+func (s *SplunkHEC) send(payload []byte) error {
+	for retries := 0; ; retries++ {
+		err := s.post(payload)
+		rerr, ok := err.(*retryError)
+		if !ok || retries >= s.MaxRetries {
+			return err
+		}
+
+		wait := rerr.wait
+		if wait <= 0 {
+			wait = time.Second << uint(retries)
+		}
+		if wait > s.MaxRetryWait.Duration {
+			wait = s.MaxRetryWait.Duration
+		}
+		s.Log.Warnf("%v, retrying in %s", err, wait)
+		if err := internal.SleepContext(s.ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *SplunkHEC) post(payload []byte) error {
+	var body io.Reader = bytes.NewReader(payload)
+
+	var err error
+	if s.ContentEncoding == "gzip" {
+		body, err = internal.CompressWithGzip(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(s.ctx)
+
+	req.Header.Set("Authorization", "Splunk "+s.Token)
+	req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
+	req.Header.Set("Content-Type", "application/json")
+	if s.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	desc := resp.Status
+	hecResp := &hecResponse{}
+	if err := json.Unmarshal(respBody, hecResp); err == nil && hecResp.Text != "" {
+		desc = fmt.Sprintf("%s (code %d)", hecResp.Text, hecResp.Code)
+	}
+	err = fmt.Errorf("when writing to [%s] received status code %d: %s", s.URL, resp.StatusCode, desc)
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		// The data is rejected and would be again.
+		return &internal.RejectedError{Reason: err.Error()}
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &retryError{err: err, wait: time.Duration(retryAfter) * time.Second}
+	}
+	return err
+}
+
+func init() {
+	outputs.Add("splunk_hec", func() telegraf.Output {
+		return &SplunkHEC{
+			URL:            defaultURL,
+			Mode:           modeMetric,
+			Host:           defaultHost,
+			MaxPayloadSize: internal.Size{Size: defaultMaxPayloadSize},
+			MaxRetries:     defaultMaxRetries,
+			MaxRetryWait:   internal.Duration{Duration: defaultMaxRetryWait},
+			Timeout:        internal.Duration{Duration: defaultTimeout},
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package splunk_hec
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// hecServer records the events it receives and replies with the queued
+// status codes, then 200.
+type hecServer struct {
+	sync.Mutex
+	requests [][]map[string]interface{}
+	statuses []int
+	headers  http.Header
+}
+
+func (h *hecServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+	h.headers = r.Header
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body = gz
+	}
+
+	events := []map[string]interface{}{}
+	dec := json.NewDecoder(bufio.NewReader(body))
+	for dec.More() {
+		event := map[string]interface{}{}
+		if err := dec.Decode(&event); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		events = append(events, event)
+	}
+
+	if len(h.statuses) > 0 {
+		status := h.statuses[0]
+		h.statuses = h.statuses[1:]
+		if status != http.StatusOK {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"text":"Server is busy","code":9}`))
+			return
+		}
+	}
+	h.requests = append(h.requests, events)
+	w.Write([]byte(`{"text":"Success","code":0}`))
+}
+
+func newPlugin(url string) *SplunkHEC {
+	return &SplunkHEC{
+		URL:          url,
+		Token:        "secret",
+		Host:         defaultHost,
+		MaxRetries:   defaultMaxRetries,
+		MaxRetryWait: internal.Duration{Duration: time.Millisecond},
+		Log:          testutil.Logger{},
+	}
+}
+
+func getMetric() telegraf.Metric {
+	return testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host": "server01",
+			"cpu":  "cpu0",
+			"env":  "prod",
+		},
+		map[string]interface{}{
+			"usage_idle": 42.0,
+			"running":    true,
+			"state":      "ok",
+		},
+		time.Unix(1500000000, 500000000),
+	)
+}
+
+func TestWriteMetric(t *testing.T) {
+	hec := &hecServer{}
+	ts := httptest.NewServer(hec)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.Index = `metrics_{{.Tag "env"}}`
+	plugin.SourceType = "telegraf"
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{getMetric()}))
+
+	require.Equal(t, "Splunk secret", hec.headers.Get("Authorization"))
+	require.Len(t, hec.requests, 1)
+	require.Equal(t, []map[string]interface{}{
+		{
+			"time":       1500000000.5,
+			"host":       "server01",
+			"index":      "metrics_prod",
+			"sourcetype": "telegraf",
+			"event":      "metric",
+			"fields": map[string]interface{}{
+				"metric_name": "cpu.usage_idle",
+				"_value":      42.0,
+				"cpu":         "cpu0",
+			},
+		},
+		{
+			"time":       1500000000.5,
+			"host":       "server01",
+			"index":      "metrics_prod",
+			"sourcetype": "telegraf",
+			"event":      "metric",
+			"fields": map[string]interface{}{
+				"metric_name": "cpu.running",
+				"_value":      1.0,
+				"cpu":         "cpu0",
+			},
+		},
+	}, hec.requests[0])
+}
+
+func TestWriteEvent(t *testing.T) {
+	hec := &hecServer{}
+	ts := httptest.NewServer(hec)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.Mode = modeEvent
+	plugin.ContentEncoding = "gzip"
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{getMetric()}))
+
+	require.Len(t, hec.requests, 1)
+	require.Equal(t, []map[string]interface{}{
+		{
+			"time": 1500000000.5,
+			"host": "server01",
+			"event": map[string]interface{}{
+				"name": "cpu",
+				"tags": map[string]interface{}{
+					"cpu": "cpu0",
+					"env": "prod",
+				},
+				"fields": map[string]interface{}{
+					"usage_idle": 42.0,
+					"running":    true,
+					"state":      "ok",
+				},
+			},
+		},
+	}, hec.requests[0])
+}
+
+func TestWriteMaxPayloadSize(t *testing.T) {
+	hec := &hecServer{}
+	ts := httptest.NewServer(hec)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.Mode = modeEvent
+	plugin.MaxPayloadSize = internal.Size{Size: 300}
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{getMetric(), getMetric(), getMetric()}
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Len(t, hec.requests, 3)
+	for _, events := range hec.requests {
+		require.Len(t, events, 1)
+	}
+}
+
+func TestWriteRetry(t *testing.T) {
+	hec := &hecServer{
+		statuses: []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}
+	ts := httptest.NewServer(hec)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	require.NoError(t, plugin.Write([]telegraf.Metric{getMetric()}))
+	require.Len(t, hec.requests, 1)
+}
+
+func TestWriteRetryExhausted(t *testing.T) {
+	hec := &hecServer{
+		statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	}
+	ts := httptest.NewServer(hec)
+	defer ts.Close()
+
+	plugin := newPlugin(ts.URL)
+	plugin.MaxRetries = 1
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	require.Error(t, plugin.Write([]telegraf.Metric{getMetric()}))
+	require.Len(t, hec.requests, 0)
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		err      bool
+		rejected bool
+	}{
+		{
+			name:     "invalid data is rejected",
+			status:   http.StatusBadRequest,
+			err:      true,
+			rejected: true,
+		},
+		{
+			name:   "invalid token is an error",
+			status: http.StatusForbidden,
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hec := &hecServer{statuses: []int{tt.status}}
+			ts := httptest.NewServer(hec)
+			defer ts.Close()
+
+			plugin := newPlugin(ts.URL)
+			require.NoError(t, plugin.Connect())
+			defer plugin.Close()
+
+			err := plugin.Write([]telegraf.Metric{getMetric()})
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			rerr, ok := err.(*internal.RejectedError)
+			require.Equal(t, tt.rejected, ok)
+			if ok {
+				require.Len(t, rerr.Metrics, 1)
+			}
+			require.Len(t, hec.requests, 0)
+		})
+	}
+}
+
+func TestConnectErrors(t *testing.T) {
+	plugin := newPlugin("")
+	plugin.Token = ""
+	require.Error(t, plugin.Connect())
+
+	plugin = newPlugin("")
+	plugin.Mode = "log"
+	require.Error(t, plugin.Connect())
+}
@@ -0,0 +1,255 @@
+package syslog
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	framingOctetCounting  = "octet-counting"
+	framingNonTransparent = "non-transparent"
+)
+
+var trailers = map[string]string{
+	"LF":  "\n",
+	"NUL": "\x00",
+}
+
+var sampleConfig = `
+  ## URL to connect to
+  ## ex: address = "tcp://127.0.0.1:8094"
+  ## ex: address = "tcp4://127.0.0.1:8094"
+  ## ex: address = "tcp6://127.0.0.1:8094"
+  ## ex: address = "tcp6://[2001:db8::1]:8094"
+  ## ex: address = "udp://127.0.0.1:8094"
+  ## ex: address = "udp4://127.0.0.1:8094"
+  ## ex: address = "udp6://127.0.0.1:8094"
+  address = "tcp://127.0.0.1:8094"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Period between keep alive probes.
+  ## Only applies to TCP sockets.
+  ## 0 disables keep alive probes.
+  ## Defaults to the OS configuration.
+  # keep_alive_period = "5m"
+
+  ## The framing technique with which it is expected that messages are
+  ## transported (default = "octet-counting").  Whether the messages come
+  ## using the octet-counting (RFC5425#section-4.3.1, RFC6587#section-3.4.1),
+  ## or the non-transparent framing technique (RFC6587#section-3.4.2).  Must
+  ## be one of "octet-counting", "non-transparent".  Only applies to TCP
+  ## sockets.
+  # framing = "octet-counting"
+
+  ## The trailer to be expected in case of non-transparent framing (default = "LF").
+  ## Must be one of "LF", or "NUL".
+  # trailer = "LF"
+
+  ## SD-PARAMs settings
+  ## Syslog messages can contain key/value pairs within zero or more
+  ## structured data sections.  For each unrecognized metric tag/field a
+  ## SD-PARAMS is created.
+  ##
+  ## Example:
+  ##   [[outputs.syslog]]
+  ##     sdparam_separator = "_"
+  ##     default_sdid = "default@32473"
+  ##     sdids = ["foo@123", "bar@456"]
+  ##
+  ##   input => xyzzy,x=y foo@123_value=42,bar@456_value2=84,something_else=1
+  ##   output (structured data only) => [foo@123 value=42][bar@456 value2=84][default@32473 something_else=1 x=y]
+
+  ## SD-PARAMs separator between the sdid and tag/field key (default = "_")
+  # sdparam_separator = "_"
+
+  ## Default sdid used for tags/fields that don't contain a prefix defined in
+  ## the explicit sdids setting below. If no default is specified, no SD-PARAMs
+  ## will be used for unrecognized field.
+  # default_sdid = "default@32473"
+
+  ## List of explicit prefixes to extract from tag/field keys and use as the
+  ## SDID, if they match (see above example for more details):
+  # sdids = ["foo@123", "bar@456"]
+
+  ## Default severity value. Severity and Facility are used to calculate the
+  ## message PRI value (RFC5424#section-6.2.1).  Used when no metric field
+  ## with key "severity_code" is defined.  If unset, 5 (notice) is the default
+  # default_severity_code = 5
+
+  ## Default facility value. Facility and Severity are used to calculate the
+  ## message PRI value (RFC5424#section-6.2.1).  Used when no metric field with
+  ## key "facility_code" is defined.  If unset, 1 (user-level) is the default
+  # default_facility_code = 1
+
+  ## Default APP-NAME value (RFC5424#section-6.2.5)
+  ## Used when no metric tag with key "appname" is defined.
+  ## If unset, "Telegraf" is the default
+  # default_appname = "Telegraf"
+`
+
+// Syslog writes metrics as RFC5424 syslog messages.
+type Syslog struct {
+	Address             string             `toml:"address"`
+	KeepAlivePeriod     *internal.Duration `toml:"keep_alive_period"`
+	DefaultSdid         string             `toml:"default_sdid"`
+	DefaultSeverityCode uint8              `toml:"default_severity_code"`
+	DefaultFacilityCode uint8              `toml:"default_facility_code"`
+	DefaultAppname      string             `toml:"default_appname"`
+	Sdids               []string           `toml:"sdids"`
+	Separator           string             `toml:"sdparam_separator"`
+	Framing             string             `toml:"framing"`
+	Trailer             string             `toml:"trailer"`
+	tlsint.ClientConfig
+
+	Log telegraf.Logger `toml:"-"`
+
+	net.Conn
+	mapper *SyslogMapper
+}
+
+func (s *Syslog) Description() string {
+	return "Configuration for Syslog server to send metrics to"
+}
+
+func (s *Syslog) SampleConfig() string {
+	return sampleConfig
+}
+
+// Connect validates the configuration and opens the connection.
+func (s *Syslog) Connect() error {
+	if s.DefaultSeverityCode > 7 {
+		return fmt.Errorf("invalid default_severity_code: %d", s.DefaultSeverityCode)
+	}
+	if s.DefaultFacilityCode > 23 {
+		return fmt.Errorf("invalid default_facility_code: %d", s.DefaultFacilityCode)
+	}
+	switch s.Framing {
+	case framingOctetCounting, framingNonTransparent:
+	default:
+		return fmt.Errorf("invalid framing: %q", s.Framing)
+	}
+	if _, ok := trailers[s.Trailer]; !ok {
+		return fmt.Errorf("invalid trailer: %q", s.Trailer)
+	}
+
+	s.mapper = newSyslogMapper(s)
+
+	spl := strings.SplitN(s.Address, "://", 2)
+	if len(spl) != 2 {
+		return fmt.Errorf("invalid address: %s", s.Address)
+	}
+
+	tlsCfg, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	var c net.Conn
+	if tlsCfg == nil {
+		c, err = net.Dial(spl[0], spl[1])
+	} else {
+		c, err = tls.Dial(spl[0], spl[1], tlsCfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.setKeepAlive(c); err != nil {
+		s.Log.Warnf("Unable to configure keep alive (%s): %s", s.Address, err)
+	}
+
+	s.Conn = c
+	return nil
+}
+
+func (s *Syslog) setKeepAlive(c net.Conn) error {
+	if s.KeepAlivePeriod == nil {
+		return nil
+	}
+	tcpc, ok := c.(*net.TCPConn)
+	if !ok {
+		return fmt.Errorf("cannot set keep alive on a %s socket", strings.SplitN(s.Address, "://", 2)[0])
+	}
+	if s.KeepAlivePeriod.Duration == 0 {
+		return tcpc.SetKeepAlive(false)
+	}
+	if err := tcpc.SetKeepAlive(true); err != nil {
+		return err
+	}
+	return tcpc.SetKeepAlivePeriod(s.KeepAlivePeriod.Duration)
+}
+
+// Close closes the connection. Noop if already closed.
+func (s *Syslog) Close() error {
+	if s.Conn == nil {
+		return nil
+	}
+	err := s.Conn.Close()
+	s.Conn = nil
+	return err
+}
+
+// Write writes a syslog message per metric.
+func (s *Syslog) Write(metrics []telegraf.Metric) error {
+	if s.Conn == nil {
+		// previous write failed with permanent error and socket was closed.
+		if err := s.Connect(); err != nil {
+			return err
+		}
+	}
+
+	for _, metric := range metrics {
+		msg := s.mapper.MapMetricToSyslogMessage(metric)
+		if _, err := s.Conn.Write(s.frame(msg.String())); err != nil {
+			if err, ok := err.(net.Error); !ok || !err.Temporary() {
+				// permanent error. close the connection
+				s.Close()
+				s.Conn = nil
+				return fmt.Errorf("closing connection: %v", err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// frame returns the message framed for the transport; datagrams carry a
+// single message and are not framed.
+func (s *Syslog) frame(msg string) []byte {
+	if _, ok := s.Conn.(net.PacketConn); ok {
+		return []byte(msg)
+	}
+	if s.Framing == framingNonTransparent {
+		return []byte(msg + trailers[s.Trailer])
+	}
+	return []byte(strconv.Itoa(len(msg)) + " " + msg)
+}
+
+func newSyslog() *Syslog {
+	return &Syslog{
+		Framing:             framingOctetCounting,
+		Trailer:             "LF",
+		Separator:           "_",
+		DefaultSeverityCode: uint8(5), // notice
+		DefaultFacilityCode: uint8(1), // user-level
+		DefaultAppname:      "Telegraf",
+	}
+}
+
+func init() {
+	outputs.Add("syslog", func() telegraf.Output { return newSyslog() })
+}
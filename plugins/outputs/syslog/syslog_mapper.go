@@ -0,0 +1,272 @@
+package syslog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	maxHostnameLen = 255
+	maxAppnameLen  = 48
+	maxProcIDLen   = 128
+	maxMsgIDLen    = 32
+	maxSdNameLen   = 32
+)
+
+// SyslogMessage is a RFC5424 syslog message.
+type SyslogMessage struct {
+	Priority       uint8
+	Timestamp      time.Time
+	Hostname       string
+	Appname        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Message        string
+}
+
+// String returns the message in the RFC5424 format.
+func (sm *SyslogMessage) String() string {
+	var b strings.Builder
+	b.WriteString("<")
+	b.WriteString(strconv.Itoa(int(sm.Priority)))
+	b.WriteString(">1 ")
+	if sm.Timestamp.IsZero() {
+		b.WriteString("-")
+	} else {
+		b.WriteString(sm.Timestamp.Format("2006-01-02T15:04:05.999999Z07:00"))
+	}
+	b.WriteString(" ")
+	b.WriteString(header(sm.Hostname, maxHostnameLen))
+	b.WriteString(" ")
+	b.WriteString(header(sm.Appname, maxAppnameLen))
+	b.WriteString(" ")
+	b.WriteString(header(sm.ProcID, maxProcIDLen))
+	b.WriteString(" ")
+	b.WriteString(header(sm.MsgID, maxMsgIDLen))
+	b.WriteString(" ")
+
+	ids := make([]string, 0, len(sm.StructuredData))
+	for id := range sm.StructuredData {
+		if sdName(id) != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		b.WriteString("-")
+	}
+	for _, id := range ids {
+		params := sm.StructuredData[id]
+		keys := make([]string, 0, len(params))
+		for k := range params {
+			if sdName(k) != "" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		b.WriteString("[")
+		b.WriteString(sdName(id))
+		for _, k := range keys {
+			b.WriteString(" ")
+			b.WriteString(sdName(k))
+			b.WriteString(`="`)
+			b.WriteString(escapeParamValue(params[k]))
+			b.WriteString(`"`)
+		}
+		b.WriteString("]")
+	}
+
+	if sm.Message != "" {
+		b.WriteString(" ")
+		b.WriteString(sm.Message)
+	}
+	return b.String()
+}
+
+// header returns the value of a header field restricted to printable
+// US-ASCII characters and the maximum length, or the NILVALUE.
+func header(s string, max int) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > max {
+		s = s[:max]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// sdName returns a valid SD-ID or PARAM-NAME, dropping the characters not
+// allowed by RFC5424.
+func sdName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > maxSdNameLen {
+		s = s[:maxSdNameLen]
+	}
+	return s
+}
+
+var paramValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func escapeParamValue(s string) string {
+	return paramValueEscaper.Replace(s)
+}
+
+// SyslogMapper maps metrics to syslog messages.
+type SyslogMapper struct {
+	DefaultSdid     string
+	DefaultSeverity uint8
+	DefaultFacility uint8
+	DefaultAppname  string
+	Sdids           []string
+	Separator       string
+}
+
+func newSyslogMapper(s *Syslog) *SyslogMapper {
+	return &SyslogMapper{
+		DefaultSdid:     s.DefaultSdid,
+		DefaultSeverity: s.DefaultSeverityCode,
+		DefaultFacility: s.DefaultFacilityCode,
+		DefaultAppname:  s.DefaultAppname,
+		Sdids:           s.Sdids,
+		Separator:       s.Separator,
+	}
+}
+
+// MapMetricToSyslogMessage maps a metric to a syslog message.  The tags and
+// fields with a recognized key set the header and message, the others become
+// SD-PARAMs of the sdid they are prefixed with or of the default sdid.
+func (sl *SyslogMapper) MapMetricToSyslogMessage(metric telegraf.Metric) *SyslogMessage {
+	msg := &SyslogMessage{
+		Timestamp:      metric.Time(),
+		Appname:        sl.DefaultAppname,
+		StructuredData: make(map[string]map[string]string),
+	}
+	severity := sl.DefaultSeverity
+	facility := sl.DefaultFacility
+
+	var hostname, source string
+	for _, tag := range metric.TagList() {
+		switch tag.Key {
+		case "appname":
+			msg.Appname = tag.Value
+		case "hostname":
+			hostname = tag.Value
+		case "source":
+			source = tag.Value
+		case "host":
+			if hostname == "" {
+				hostname = tag.Value
+			}
+		default:
+			sl.addParam(msg, tag.Key, tag.Value)
+		}
+	}
+	// The source tag is set by the syslog input to the address of the sender.
+	if hostname == "" {
+		hostname = source
+	}
+	msg.Hostname = hostname
+
+	for _, field := range metric.FieldList() {
+		switch field.Key {
+		case "msgid":
+			msg.MsgID = formatValue(field.Value)
+		case "procid":
+			msg.ProcID = formatValue(field.Value)
+		case "msg":
+			msg.Message = formatValue(field.Value)
+		case "severity_code":
+			if v, ok := code(field.Value, 7); ok {
+				severity = v
+			}
+		case "facility_code":
+			if v, ok := code(field.Value, 23); ok {
+				facility = v
+			}
+		default:
+			sl.addParam(msg, field.Key, formatValue(field.Value))
+		}
+	}
+
+	msg.Priority = facility*8 + severity
+	return msg
+}
+
+func (sl *SyslogMapper) addParam(msg *SyslogMessage, key, value string) {
+	sdid, name := sl.sdid(key)
+	if sdid == "" {
+		return
+	}
+	if _, ok := msg.StructuredData[sdid]; !ok {
+		msg.StructuredData[sdid] = make(map[string]string)
+	}
+	msg.StructuredData[sdid][name] = value
+}
+
+// sdid returns the sdid and parameter name of a tag or field key.
+func (sl *SyslogMapper) sdid(key string) (string, string) {
+	for _, sdid := range sl.Sdids {
+		prefix := sdid + sl.Separator
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			return sdid, key[len(prefix):]
+		}
+	}
+	return sl.DefaultSdid, key
+}
+
+func code(v interface{}, max uint64) (uint8, bool) {
+	var c uint64
+	switch v := v.(type) {
+	case int64:
+		if v < 0 {
+			return 0, false
+		}
+		c = uint64(v)
+	case uint64:
+		c = v
+	case float64:
+		if v < 0 {
+			return 0, false
+		}
+		c = uint64(v)
+	default:
+		return 0, false
+	}
+	if c > max {
+		return 0, false
+	}
+	return uint8(c), true
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package syslog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogMapperWithDefaults(t *testing.T) {
+	s := newSyslog()
+	s.DefaultSdid = "default@32473"
+	mapper := newSyslogMapper(s)
+
+	m := testutil.MustMetric(
+		"testmetric",
+		map[string]string{
+			"host": "testhost",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg := mapper.MapMetricToSyslogMessage(m)
+	require.Equal(t, "<13>1 2010-11-10T23:30:00Z testhost Telegraf - - -", msg.String())
+}
+
+func TestSyslogMapperWithHeaderFields(t *testing.T) {
+	s := newSyslog()
+	mapper := newSyslogMapper(s)
+
+	m := testutil.MustMetric(
+		"testmetric",
+		map[string]string{
+			"appname":  "testapp",
+			"hostname": "testhost",
+			"host":     "otherhost",
+		},
+		map[string]interface{}{
+			"severity_code": int64(3),
+			"facility_code": int64(3),
+			"msgid":         "ID12345",
+			"procid":        int64(25),
+			"msg":           "Test message",
+		},
+		time.Date(2010, time.November, 10, 23, 30, 0, 123456000, time.UTC),
+	)
+
+	msg := mapper.MapMetricToSyslogMessage(m)
+	require.Equal(t, "<27>1 2010-11-10T23:30:00.123456Z testhost testapp 25 ID12345 - Test message", msg.String())
+}
+
+func TestSyslogMapperWithStructuredData(t *testing.T) {
+	s := newSyslog()
+	s.DefaultSdid = "default@32473"
+	s.Sdids = []string{"foo@123", "bar@456"}
+	mapper := newSyslogMapper(s)
+
+	m := testutil.MustMetric(
+		"testmetric",
+		map[string]string{
+			"x":       "y",
+			"foo@123": "z",
+		},
+		map[string]interface{}{
+			"foo@123_value":  int64(42),
+			"bar@456_value2": 84.5,
+			"something_else": true,
+			"quoted":         `a "b" [c]`,
+		},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg := mapper.MapMetricToSyslogMessage(m)
+	require.Equal(t,
+		`<13>1 2010-11-10T23:30:00Z - Telegraf - - `+
+			`[bar@456 value2="84.5"]`+
+			`[default@32473 foo@123="z" quoted="a \"b\" [c\]" something_else="true" x="y"]`+
+			`[foo@123 value="42"]`,
+		msg.String())
+}
+
+func TestSyslogMapperWithoutDefaultSdid(t *testing.T) {
+	s := newSyslog()
+	s.Sdids = []string{"foo@123"}
+	mapper := newSyslogMapper(s)
+
+	m := testutil.MustMetric(
+		"testmetric",
+		map[string]string{},
+		map[string]interface{}{
+			"foo@123_value": int64(42),
+			"other":         int64(1),
+		},
+		time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+	)
+
+	msg := mapper.MapMetricToSyslogMessage(m)
+	require.Equal(t, `<13>1 2010-11-10T23:30:00Z - Telegraf - - [foo@123 value="42"]`, msg.String())
+}
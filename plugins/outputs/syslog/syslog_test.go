@@ -0,0 +1,151 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func getMetrics() []telegraf.Metric {
+	return []telegraf.Metric{
+		testutil.MustMetric(
+			"testmetric",
+			map[string]string{"host": "testhost"},
+			map[string]interface{}{"msg": "first"},
+			time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+		),
+		testutil.MustMetric(
+			"testmetric",
+			map[string]string{"host": "testhost"},
+			map[string]interface{}{"msg": "second"},
+			time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC),
+		),
+	}
+}
+
+func TestSyslogWriteOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	require.NoError(t, s.Write(getMetrics()))
+
+	expected := "56 <13>1 2010-11-10T23:30:00Z testhost Telegraf - - - first" +
+		"57 <13>1 2010-11-10T23:30:00Z testhost Telegraf - - - second"
+	buf := make([]byte, len(expected))
+	_, err = io.ReadFull(lconn, buf)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(buf))
+}
+
+func TestSyslogWriteNonTransparent(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.Framing = framingNonTransparent
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	require.NoError(t, s.Write(getMetrics()))
+
+	reader := bufio.NewReader(lconn)
+	for _, msg := range []string{"first", "second"} {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		require.Equal(t, "<13>1 2010-11-10T23:30:00Z testhost Telegraf - - - "+msg+"\n", line)
+	}
+}
+
+func TestSyslogWriteUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "udp://" + listener.LocalAddr().String()
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	require.NoError(t, s.Write(getMetrics()))
+
+	buf := make([]byte, 256)
+	for _, msg := range []string{"first", "second"} {
+		n, _, err := listener.ReadFrom(buf)
+		require.NoError(t, err)
+		require.Equal(t, "<13>1 2010-11-10T23:30:00Z testhost Telegraf - - - "+msg, string(buf[:n]))
+	}
+}
+
+func TestSyslogWriteReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+	s.Framing = framingNonTransparent
+	s.Log = testutil.Logger{}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	lconn.Close()
+
+	// Writing to the closed connection eventually fails and closes it.
+	for i := 0; i < 10 && s.Conn != nil; i++ {
+		s.Write(getMetrics())
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Nil(t, s.Conn)
+
+	require.NoError(t, s.Write(getMetrics()))
+	lconn, err = listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	line, err := bufio.NewReader(lconn).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "<13>1 2010-11-10T23:30:00Z testhost Telegraf - - - first\n", line)
+}
+
+func TestSyslogConnectErrors(t *testing.T) {
+	s := newSyslog()
+	s.Address = "127.0.0.1:514"
+	require.Error(t, s.Connect())
+
+	s = newSyslog()
+	s.Address = "tcp://127.0.0.1:514"
+	s.Framing = "none"
+	require.Error(t, s.Connect())
+
+	s = newSyslog()
+	s.Address = "tcp://127.0.0.1:514"
+	s.DefaultSeverityCode = 8
+	require.Error(t, s.Connect())
+}
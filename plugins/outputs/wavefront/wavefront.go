@@ -2,11 +2,12 @@ package wavefront
 
 import (
 	"fmt"
-	"log"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	wavefront "github.com/wavefronthq/wavefront-sdk-go/senders"
 )
@@ -23,11 +24,19 @@ type Wavefront struct {
 	ConvertBool     bool
 	UseRegex        bool
 	SourceOverride  []string
-	StringToNumber  map[string][]map[string]float64
+	DeltaCounters   []string
+	TruncateTags    bool
+	StringToNumber  map[string][]map[string]float64 `toml:"string_to_number" deprecated:"1.9.0;2.0.0;use the enum processor instead"`
 
-	sender wavefront.Sender
+	Log telegraf.Logger `toml:"-"`
+
+	sender        wavefront.Sender
+	deltaCounters filter.Filter
 }
 
+// maxTagLength is the maximum length of the key and value of a point tag.
+const maxTagLength = 254
+
 // catch many of the invalid chars that could appear in a metric or tag name
 var sanitizedChars = strings.NewReplacer(
 	"!", "-", "@", "-", "#", "-", "$", "-", "%", "-", "^", "-", "&", "-",
@@ -81,6 +90,17 @@ var sampleConfig = `
   ## whether to convert boolean values to numeric values, with false -> 0.0 and true -> 1.0. default is true
   #convert_bool = true
 
+  ## Metric names, after the prefix and conversions, sent as delta counters.
+  ## Wavefront sums the values of a delta counter, such as the counts of
+  ## requests reported by many hosts, and assigns their timestamp.  Globs
+  ## are supported.
+  #delta_counters = ["http.requests.count"]
+
+  ## Truncate the values of point tags so that their key and value fit in
+  ## the 254 characters allowed by Wavefront, instead of the point being
+  ## refused. default is false
+  #truncate_tags = false
+
   ## Define a mapping, namespaced by metric prefix, from string values to numeric values
   ##   deprecated in 1.9; use the enum processor plugin
   #[[outputs.wavefront.string_to_number.elasticsearch]]
@@ -99,12 +119,8 @@ type MetricPoint struct {
 
 func (w *Wavefront) Connect() error {
 
-	if len(w.StringToNumber) > 0 {
-		log.Print("W! [outputs.wavefront] The string_to_number option is deprecated; please use the enum processor instead")
-	}
-
 	if w.Url != "" {
-		log.Printf("D! [outputs.wavefront] connecting over http/https using Url: %s", w.Url)
+		w.Log.Debugf("Connecting over http/https using Url: %s", w.Url)
 		sender, err := wavefront.NewDirectSender(&wavefront.DirectConfiguration{
 			Server:               w.Url,
 			Token:                w.Token,
@@ -115,7 +131,7 @@ func (w *Wavefront) Connect() error {
 		}
 		w.sender = sender
 	} else {
-		log.Printf("D! Output [wavefront] connecting over tcp using Host: %s and Port: %d", w.Host, w.Port)
+		w.Log.Debugf("Connecting over tcp using Host: %s and Port: %d", w.Host, w.Port)
 		sender, err := wavefront.NewProxySender(&wavefront.ProxyConfiguration{
 			Host:                 w.Host,
 			MetricsPort:          w.Port,
@@ -127,6 +143,12 @@ func (w *Wavefront) Connect() error {
 		w.sender = sender
 	}
 
+	deltaCounters, err := filter.Compile(w.DeltaCounters)
+	if err != nil {
+		return fmt.Errorf("Wavefront: invalid delta_counters: %v", err)
+	}
+	w.deltaCounters = deltaCounters
+
 	if w.ConvertPaths && w.MetricSeparator == "_" {
 		w.ConvertPaths = false
 	}
@@ -140,7 +162,12 @@ func (w *Wavefront) Write(metrics []telegraf.Metric) error {
 
 	for _, m := range metrics {
 		for _, point := range buildMetrics(m, w) {
-			err := w.sender.SendMetric(point.Metric, point.Value, point.Timestamp, point.Source, point.Tags)
+			var err error
+			if w.deltaCounters != nil && w.deltaCounters.Match(point.Metric) {
+				err = w.sender.SendDeltaCounter(point.Metric, point.Value, point.Source, point.Tags)
+			} else {
+				err = w.sender.SendMetric(point.Metric, point.Value, point.Timestamp, point.Source, point.Tags)
+			}
 			if err != nil {
 				return fmt.Errorf("Wavefront sending error: %s", err.Error())
 			}
@@ -178,7 +205,7 @@ func buildMetrics(m telegraf.Metric, w *Wavefront) []*MetricPoint {
 
 		metricValue, buildError := buildValue(value, metric.Metric, w)
 		if buildError != nil {
-			log.Printf("D! [outputs.wavefront] %s\n", buildError.Error())
+			w.Log.Debugf("%s", buildError.Error())
 			continue
 		}
 		metric.Value = metricValue
@@ -242,12 +269,30 @@ func buildTags(mTags map[string]string, w *Wavefront) (string, map[string]string
 			key = sanitizedChars.Replace(k)
 		}
 		val := tagValueReplacer.Replace(v)
+		if w.TruncateTags && len(key)+len(val) > maxTagLength {
+			if len(key) >= maxTagLength {
+				w.Log.Debugf("Dropping tag %q, its key is too long", key)
+				continue
+			}
+			val = truncate(val, maxTagLength-len(key))
+		}
 		tags[key] = val
 	}
 
 	return source, tags
 }
 
+// truncate shortens s to at most n bytes without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
 func buildValue(v interface{}, name string, w *Wavefront) (float64, error) {
 	switch p := v.(type) {
 	case bool:
@@ -4,15 +4,18 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
+	wavefront "github.com/wavefronthq/wavefront-sdk-go/senders"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 // default config used by Tests
 func defaultWavefront() *Wavefront {
 	return &Wavefront{
+		Log:             testutil.Logger{},
 		Host:            "localhost",
 		Port:            2878,
 		Prefix:          "testWF.",
@@ -210,6 +213,92 @@ func TestBuildTagsWithSource(t *testing.T) {
 	}
 }
 
+func TestBuildTagsTruncate(t *testing.T) {
+	w := defaultWavefront()
+	w.TruncateTags = true
+
+	long := strings.Repeat("a", 300)
+	_, tags := buildTags(map[string]string{"dc": long, long: "value"}, w)
+
+	expected := map[string]string{"dc": strings.Repeat("a", maxTagLength-2)}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("\nexpected\t%+v\nreceived\t%+v\n", expected, tags)
+	}
+}
+
+func TestBuildTagsTruncateRunes(t *testing.T) {
+	w := defaultWavefront()
+	w.TruncateTags = true
+
+	// The limit falls in the middle of a two byte character.
+	long := "a" + strings.Repeat("é", 150)
+	_, tags := buildTags(map[string]string{"dc": long}, w)
+
+	expected := map[string]string{"dc": "a" + strings.Repeat("é", 125)}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("\nexpected\t%+v\nreceived\t%+v\n", expected, tags)
+	}
+	if !utf8.ValidString(tags["dc"]) {
+		t.Errorf("truncated tag is not valid UTF-8: %q", tags["dc"])
+	}
+}
+
+// fakeSender records the points sent, the other methods are not used.
+type fakeSender struct {
+	wavefront.Sender
+	metrics []string
+	deltas  []string
+}
+
+func (s *fakeSender) SendMetric(name string, value float64, ts int64, source string, tags map[string]string) error {
+	s.metrics = append(s.metrics, name)
+	return nil
+}
+
+func (s *fakeSender) SendDeltaCounter(name string, value float64, source string, tags map[string]string) error {
+	s.deltas = append(s.deltas, name)
+	return nil
+}
+
+func TestWriteDeltaCounters(t *testing.T) {
+	w := defaultWavefront()
+	w.Url = "http://localhost:2878"
+	w.Token = "DUMMY_TOKEN"
+	w.Prefix = ""
+	w.DeltaCounters = []string{"http.requests.*"}
+	if err := w.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	w.sender.Close()
+	sender := &fakeSender{}
+	w.sender = sender
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"http_requests",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"count": int64(42)},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric(
+			"cpu",
+			map[string]string{"host": "server01"},
+			map[string]interface{}{"usage_idle": 91.5},
+			time.Unix(0, 0),
+		),
+	}
+	if err := w.Write(metrics); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(sender.deltas, []string{"http.requests.count"}) {
+		t.Errorf("\nexpected delta counters\t%+v\nreceived\t%+v\n", []string{"http.requests.count"}, sender.deltas)
+	}
+	if !reflect.DeepEqual(sender.metrics, []string{"cpu.usage.idle"}) {
+		t.Errorf("\nexpected metrics\t%+v\nreceived\t%+v\n", []string{"cpu.usage.idle"}, sender.metrics)
+	}
+}
+
 func TestBuildValue(t *testing.T) {
 	w := defaultWavefront()
 
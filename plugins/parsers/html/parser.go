@@ -0,0 +1,229 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"golang.org/x/net/html"
+)
+
+// Parser extracts the rows of HTML tables into metrics, one metric per row.
+type Parser struct {
+	MetricName string
+	// TableSelector is a CSS selector choosing the tables to parse.
+	TableSelector string
+	// ColumnNames overrides the column names read from the table header.
+	ColumnNames     []string
+	TagColumns      []string
+	TimestampColumn string
+	TimestampFormat string
+	DefaultTags     map[string]string
+	TimeFunc        func() time.Time
+
+	selector selector
+}
+
+// Compile validates the configuration and compiles the table selector, it
+// must be called before parsing.
+func (p *Parser) Compile() error {
+	if p.TableSelector == "" {
+		p.TableSelector = "table"
+	}
+
+	sel, err := compileSelector(p.TableSelector)
+	if err != nil {
+		return err
+	}
+	p.selector = sel
+
+	if p.TimestampColumn != "" && p.TimestampFormat == "" {
+		return fmt.Errorf("timestamp format must be specified with a timestamp column")
+	}
+
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+	return nil
+}
+
+// Parse parses a HTML document, rows from all tables matching the selector
+// are returned.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	doc, err := html.Parse(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, table := range p.selector.selectAll(doc) {
+		if table.Data != "table" {
+			return nil, fmt.Errorf("selector %q matched non-table element %q", p.TableSelector, table.Data)
+		}
+
+		m, err := p.parseTable(table)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m...)
+	}
+	return metrics, nil
+}
+
+// ParseLine parses the line as a HTML document and returns the first row.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("can not parse the line: %s, for data format: html", line)
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags sets the tags added to all parsed metrics.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseTable(table *html.Node) ([]telegraf.Metric, error) {
+	columns := p.ColumnNames
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, row := range rows(table) {
+		cells, header := cells(row)
+		if len(cells) == 0 {
+			continue
+		}
+
+		if header {
+			if len(p.ColumnNames) == 0 {
+				columns = cells
+			}
+			continue
+		}
+
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("table has no header row and no column names are set")
+		}
+
+		m, err := p.parseRow(columns, cells)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseRow(columns []string, cells []string) (telegraf.Metric, error) {
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	timestamp := p.TimeFunc()
+
+	for i, column := range columns {
+		if i >= len(cells) {
+			break
+		}
+		value := cells[i]
+		if column == "" || value == "" {
+			continue
+		}
+
+		if column == p.TimestampColumn {
+			ts, err := internal.ParseTimestamp(value, p.TimestampFormat)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse timestamp %q: %v", value, err)
+			}
+			timestamp = ts
+			continue
+		}
+
+		if contains(p.TagColumns, column) {
+			tags[column] = value
+			continue
+		}
+
+		if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[column] = iValue
+		} else if fValue, err := strconv.ParseFloat(value, 64); err == nil {
+			fields[column] = fValue
+		} else if bValue, err := strconv.ParseBool(value); err == nil {
+			fields[column] = bValue
+		} else {
+			fields[column] = value
+		}
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	return metric.New(p.MetricName, tags, fields, timestamp)
+}
+
+// rows returns the rows of the table, excluding rows of nested tables.
+func rows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.Data {
+			case "tr":
+				rows = append(rows, c)
+			case "thead", "tbody", "tfoot":
+				walk(c)
+			}
+		}
+	}
+	walk(table)
+	return rows
+}
+
+// cells returns the text of each cell in the row and if the row only
+// contains header cells.
+func cells(row *html.Node) ([]string, bool) {
+	var cells []string
+	header := true
+	for c := row.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || (c.Data != "td" && c.Data != "th") {
+			continue
+		}
+		if c.Data == "td" {
+			header = false
+		}
+		cells = append(cells, text(c))
+	}
+	return cells, header
+}
+
+// text returns the text content of the node with whitespace collapsed.
+func text(n *html.Node) string {
+	var buf bytes.Buffer
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			buf.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.Data == "br":
+			buf.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
@@ -0,0 +1,228 @@
+package html
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const statusPage = `
+<html>
+<body>
+  <table id="summary">
+    <tr><th>uptime</th></tr>
+    <tr><td>42</td></tr>
+  </table>
+  <table class="status large">
+    <thead>
+      <tr><th>Server</th><th>Requests</th><th>Load</th><th>Healthy</th></tr>
+    </thead>
+    <tbody>
+      <tr><td>web01</td><td>1200</td><td>0.5</td><td>true</td></tr>
+      <tr><td><a href="/web02">web02</a></td><td> 800 </td><td>1.25</td><td>false</td></tr>
+    </tbody>
+  </table>
+</body>
+</html>
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser *Parser
+		input  string
+		want   []telegraf.Metric
+	}{
+		{
+			name: "class selector",
+			parser: &Parser{
+				MetricName:    "html",
+				TableSelector: "table.status",
+				TagColumns:    []string{"Server"},
+			},
+			input: statusPage,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"html",
+					map[string]string{"Server": "web01"},
+					map[string]interface{}{
+						"Requests": int64(1200),
+						"Load":     0.5,
+						"Healthy":  true,
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"html",
+					map[string]string{"Server": "web02"},
+					map[string]interface{}{
+						"Requests": int64(800),
+						"Load":     1.25,
+						"Healthy":  false,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "id selector",
+			parser: &Parser{
+				MetricName:    "html",
+				TableSelector: "body > #summary",
+			},
+			input: statusPage,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"html",
+					map[string]string{},
+					map[string]interface{}{
+						"uptime": int64(42),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "column names override header",
+			parser: &Parser{
+				MetricName:    "html",
+				TableSelector: "table:nth-of-type(2)",
+				ColumnNames:   []string{"server", "requests"},
+				TagColumns:    []string{"server"},
+			},
+			input: statusPage,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"html",
+					map[string]string{"server": "web01"},
+					map[string]interface{}{
+						"requests": int64(1200),
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"html",
+					map[string]string{"server": "web02"},
+					map[string]interface{}{
+						"requests": int64(800),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "timestamp column",
+			parser: &Parser{
+				MetricName:      "html",
+				ColumnNames:     []string{"time", "value"},
+				TimestampColumn: "time",
+				TimestampFormat: "2006-01-02 15:04:05",
+			},
+			input: `<table><tr><td>2019-03-04 05:06:07</td><td>42</td></tr></table>`,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"html",
+					map[string]string{},
+					map[string]interface{}{
+						"value": int64(42),
+					},
+					time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "no matching table",
+			parser: &Parser{
+				MetricName:    "html",
+				TableSelector: "table#missing",
+			},
+			input: statusPage,
+			want:  []telegraf.Metric{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.TimeFunc = func() time.Time { return time.Unix(0, 0) }
+			require.NoError(t, tt.parser.Compile())
+
+			metrics, err := tt.parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.want, metrics)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser *Parser
+		input  string
+	}{
+		{
+			name:   "no header",
+			parser: &Parser{},
+			input:  `<table><tr><td>42</td></tr></table>`,
+		},
+		{
+			name:   "non table element",
+			parser: &Parser{TableSelector: "body"},
+			input:  `<table><tr><th>a</th></tr></table>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.parser.Compile())
+			_, err := tt.parser.Parse([]byte(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCompileSelectorQuotedValue(t *testing.T) {
+	sel, err := compileSelector(`div[title="a > b"] > table[summary='x y']`)
+	require.NoError(t, err)
+	require.Len(t, sel, 2)
+	require.Equal(t, "a > b", *sel[0].attrs["title"])
+	require.Equal(t, "x y", *sel[1].attrs["summary"])
+	require.True(t, sel[1].child)
+}
+
+func TestCompileSelector(t *testing.T) {
+	valid := []string{
+		"table",
+		"#status",
+		"div.content table.status",
+		"div > table",
+		"table[border=1]",
+		`table[data-name="x"]`,
+		"table:nth-of-type(3)",
+		"*.status",
+		`table[title="a b"]`,
+		`div[title='a > b'] > table`,
+		`table[title="a]b"]`,
+	}
+	for _, s := range valid {
+		_, err := compileSelector(s)
+		require.NoError(t, err, s)
+	}
+
+	invalid := []string{
+		"",
+		"> table",
+		"div >",
+		"table[border",
+		"table:first-child",
+		"table:nth-of-type(0)",
+		"table.",
+		"table[border]x",
+		"table:nth-of-type(1)x",
+		`table[title="a b]`,
+	}
+	for _, s := range invalid {
+		_, err := compileSelector(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,283 @@
+package html
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// compound is a sequence of simple selectors that all apply to one element,
+// ie: table#status.large
+type compound struct {
+	tag       string
+	id        string
+	classes   []string
+	attrs     map[string]*string
+	nthOfType int
+	// child is set when the compound must be a direct child of the previous
+	// compound instead of a descendant.
+	child bool
+}
+
+// selector is a small subset of CSS selectors, supporting type, id, class,
+// attribute and :nth-of-type() selectors joined by the descendant and child
+// combinators.
+type selector []compound
+
+func compileSelector(s string) (selector, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %v", s, err)
+	}
+
+	var sel selector
+	child := false
+	for _, token := range tokens {
+		if token == ">" {
+			if len(sel) == 0 || child {
+				return nil, fmt.Errorf("unexpected '>' in selector %q", s)
+			}
+			child = true
+			continue
+		}
+
+		c, err := compileCompound(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", s, err)
+		}
+		c.child = child
+		child = false
+		sel = append(sel, c)
+	}
+
+	if len(sel) == 0 || child {
+		return nil, fmt.Errorf("invalid selector %q", s)
+	}
+	return sel, nil
+}
+
+// tokenize splits the selector into compounds and '>' combinators, the
+// whitespace and '>' within quoted attribute values are kept.
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	var quote rune
+	flush := func() {
+		if token.Len() > 0 {
+			tokens = append(tokens, token.String())
+			token.Reset()
+		}
+	}
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			token.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			token.WriteRune(r)
+		case r == '>':
+			flush()
+			tokens = append(tokens, ">")
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			token.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	flush()
+	return tokens, nil
+}
+
+// attrEnd returns the index of the ']' closing the attribute selector at the
+// start of s, or -1.
+func attrEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func compileCompound(s string) (compound, error) {
+	c := compound{attrs: make(map[string]*string)}
+
+	i := strings.IndexAny(s, "#.[:")
+	if i < 0 {
+		i = len(s)
+	}
+	c.tag = strings.ToLower(s[:i])
+	if c.tag == "*" {
+		c.tag = ""
+	}
+	s = s[i:]
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '#', '.':
+			end := strings.IndexAny(s[1:], "#.[:") + 1
+			if end == 0 {
+				end = len(s)
+			}
+			name := s[1:end]
+			if name == "" {
+				return c, fmt.Errorf("empty name after %q", s[0])
+			}
+			if s[0] == '#' {
+				c.id = name
+			} else {
+				c.classes = append(c.classes, name)
+			}
+			s = s[end:]
+		case '[':
+			end := attrEnd(s)
+			if end < 0 {
+				return c, fmt.Errorf("unterminated attribute selector")
+			}
+			attr := s[1:end]
+			if eq := strings.IndexByte(attr, '='); eq >= 0 {
+				value := strings.Trim(attr[eq+1:], `"'`)
+				c.attrs[strings.ToLower(attr[:eq])] = &value
+			} else {
+				c.attrs[strings.ToLower(attr)] = nil
+			}
+			s = s[end+1:]
+		case ':':
+			const prefix = ":nth-of-type("
+			end := strings.IndexByte(s, ')')
+			if !strings.HasPrefix(s, prefix) || end < 0 {
+				return c, fmt.Errorf("unsupported pseudo-class in %q", s)
+			}
+			n, err := strconv.Atoi(s[len(prefix):end])
+			if err != nil || n < 1 {
+				return c, fmt.Errorf("invalid :nth-of-type() argument")
+			}
+			c.nthOfType = n
+			s = s[end+1:]
+		default:
+			return c, fmt.Errorf("unexpected character %q", s[0])
+		}
+	}
+	return c, nil
+}
+
+func (c *compound) match(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" && attr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(attr(n, "class"))
+		for _, class := range c.classes {
+			if !contains(classes, class) {
+				return false
+			}
+		}
+	}
+	for key, value := range c.attrs {
+		v, ok := lookupAttr(n, key)
+		if !ok || (value != nil && v != *value) {
+			return false
+		}
+	}
+	if c.nthOfType > 0 {
+		pos := 1
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			if s.Type == html.ElementNode && s.Data == n.Data {
+				pos++
+			}
+		}
+		if pos != c.nthOfType {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports if the element matches the whole selector, the last compound
+// applies to the element and the others to its ancestors.
+func (s selector) match(n *html.Node) bool {
+	return s.matchAt(n, len(s)-1)
+}
+
+func (s selector) matchAt(n *html.Node, i int) bool {
+	if !s[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	for p := n.Parent; p != nil; p = p.Parent {
+		if s.matchAt(p, i-1) {
+			return true
+		}
+		if s[i].child {
+			return false
+		}
+	}
+	return false
+}
+
+// selectAll returns all elements below root matching the selector in
+// document order.
+func (s selector) selectAll(root *html.Node) []*html.Node {
+	var nodes []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if s.match(n) {
+			nodes = append(nodes, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	return nodes
+}
+
+func lookupAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := lookupAttr(n, key)
+	return v
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+package regex
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Group types that can be assigned to a named capture group.
+const (
+	Int         = "int"
+	Float       = "float"
+	Bool        = "bool"
+	String      = "string"
+	Tag         = "tag"
+	Drop        = "drop"
+	Measurement = "measurement"
+)
+
+// Parser parses line delimited data using regular expressions with named
+// capture groups.  Each named group becomes a field unless it is listed in
+// Types as something else.
+type Parser struct {
+	MetricName string
+	// Patterns are tried in order, the first matching pattern is used.
+	Patterns []string
+	// Types maps capture group names to one of the group types.  Groups
+	// without an entry have their type inferred from the matched text.
+	Types map[string]string
+	// TimestampGroup is the name of the group holding the metric timestamp.
+	TimestampGroup string
+	// TimestampFormat is either a Go time layout or one of unix, unix_ms,
+	// unix_us or unix_ns.
+	TimestampFormat string
+	Timezone        string
+	DefaultTags     map[string]string
+	TimeFunc        func() time.Time
+
+	regexps []*regexp.Regexp
+}
+
+// Compile validates the configuration and compiles the patterns, it must be
+// called before parsing.
+func (p *Parser) Compile() error {
+	if len(p.Patterns) == 0 {
+		return fmt.Errorf("at least one regex pattern is required")
+	}
+
+	for name, typ := range p.Types {
+		switch typ {
+		case Int, Float, Bool, String, Tag, Drop, Measurement:
+		default:
+			return fmt.Errorf("invalid type %q for group %q", typ, name)
+		}
+	}
+
+	if p.TimestampGroup != "" && p.TimestampFormat == "" {
+		return fmt.Errorf("timestamp format must be specified with a timestamp group")
+	}
+
+	if p.Timezone == "" {
+		p.Timezone = "UTC"
+	}
+
+	if p.TimeFunc == nil {
+		p.TimeFunc = time.Now
+	}
+
+	p.regexps = make([]*regexp.Regexp, 0, len(p.Patterns))
+	for _, pattern := range p.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+
+		named := false
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				named = true
+				break
+			}
+		}
+		if !named {
+			return fmt.Errorf("pattern %q has no named capture groups", pattern)
+		}
+
+		p.regexps = append(p.regexps, re)
+	}
+	return nil
+}
+
+// Parse parses each line in buf, lines not matching any pattern are skipped.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		m, err := p.ParseLine(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+
+		if m == nil {
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
+
+// ParseLine parses a single line, a nil metric is returned if the line does
+// not match any pattern.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	for _, re := range p.regexps {
+		values := re.FindStringSubmatch(line)
+		if values == nil {
+			continue
+		}
+		return p.parseMatch(re, values)
+	}
+	return nil, nil
+}
+
+func (p *Parser) parseMatch(re *regexp.Regexp, values []string) (telegraf.Metric, error) {
+	name := p.MetricName
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	timestamp := p.TimeFunc()
+
+	for i, group := range re.SubexpNames() {
+		if group == "" || values[i] == "" {
+			continue
+		}
+		value := values[i]
+
+		if group == p.TimestampGroup {
+			ts, err := internal.ParseTimestampWithLocation(value, p.TimestampFormat, p.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse timestamp %q: %v", value, err)
+			}
+			timestamp = ts
+			continue
+		}
+
+		var err error
+		switch p.Types[group] {
+		case Int:
+			fields[group], err = strconv.ParseInt(value, 10, 64)
+		case Float:
+			fields[group], err = strconv.ParseFloat(value, 64)
+		case Bool:
+			fields[group], err = strconv.ParseBool(value)
+		case String:
+			fields[group] = value
+		case Tag:
+			tags[group] = value
+		case Drop:
+		case Measurement:
+			name = value
+		default:
+			fields[group] = convert(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert group %q: %v", group, err)
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	return metric.New(name, tags, fields, timestamp)
+}
+
+// SetDefaultTags sets the tags added to all parsed metrics.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// convert attempts to infer the type of the value.
+func convert(value string) interface{} {
+	if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return iValue
+	} else if fValue, err := strconv.ParseFloat(value, 64); err == nil {
+		return fValue
+	} else if bValue, err := strconv.ParseBool(value); err == nil {
+		return bValue
+	}
+	return value
+}
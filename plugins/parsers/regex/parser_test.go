@@ -0,0 +1,235 @@
+package regex
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser *Parser
+	}{
+		{
+			name:   "no patterns",
+			parser: &Parser{},
+		},
+		{
+			name:   "invalid pattern",
+			parser: &Parser{Patterns: []string{`(?P<a>`}},
+		},
+		{
+			name:   "no named groups",
+			parser: &Parser{Patterns: []string{`(\d+)`}},
+		},
+		{
+			name: "invalid type",
+			parser: &Parser{
+				Patterns: []string{`(?P<a>\d+)`},
+				Types:    map[string]string{"a": "duration"},
+			},
+		},
+		{
+			name: "timestamp group without format",
+			parser: &Parser{
+				Patterns:       []string{`(?P<a>\d+)`},
+				TimestampGroup: "a",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.parser.Compile())
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		parser  *Parser
+		input   string
+		want    []telegraf.Metric
+		wantErr bool
+	}{
+		{
+			name: "inferred types",
+			parser: &Parser{
+				MetricName: "regex",
+				Patterns:   []string{`^(?P<method>\w+) (?P<status>\d+) (?P<duration>[\d.]+) (?P<cached>\w+)$`},
+			},
+			input: "GET 200 0.25 true\n",
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"regex",
+					map[string]string{},
+					map[string]interface{}{
+						"method":   "GET",
+						"status":   int64(200),
+						"duration": 0.25,
+						"cached":   true,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "explicit types",
+			parser: &Parser{
+				MetricName: "regex",
+				Patterns:   []string{`^(?P<host>\S+) (?P<code>\d+) (?P<value>\d+) (?P<junk>\S+) (?P<name>\S+)$`},
+				Types: map[string]string{
+					"host":  Tag,
+					"code":  String,
+					"value": Float,
+					"junk":  Drop,
+					"name":  Measurement,
+				},
+			},
+			input: "server01 404 42 xyz http",
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"http",
+					map[string]string{
+						"host": "server01",
+					},
+					map[string]interface{}{
+						"code":  "404",
+						"value": float64(42),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "timestamp",
+			parser: &Parser{
+				MetricName:      "regex",
+				Patterns:        []string{`^(?P<ts>\S+) value=(?P<value>\d+)$`},
+				TimestampGroup:  "ts",
+				TimestampFormat: "2006-01-02T15:04:05Z07:00",
+			},
+			input: "2019-03-04T05:06:07Z value=42",
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"regex",
+					map[string]string{},
+					map[string]interface{}{
+						"value": int64(42),
+					},
+					time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "unix timestamp",
+			parser: &Parser{
+				MetricName:      "regex",
+				Patterns:        []string{`^(?P<ts>\d+) value=(?P<value>\d+)$`},
+				TimestampGroup:  "ts",
+				TimestampFormat: "unix",
+			},
+			input: "1551675967 value=42",
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"regex",
+					map[string]string{},
+					map[string]interface{}{
+						"value": int64(42),
+					},
+					time.Unix(1551675967, 0),
+				),
+			},
+		},
+		{
+			name: "first matching pattern wins and unmatched lines are skipped",
+			parser: &Parser{
+				MetricName: "regex",
+				Patterns: []string{
+					`^a=(?P<a>\d+)$`,
+					`^b=(?P<b>\d+)$`,
+				},
+			},
+			input: "a=1\nnope\nb=2\n",
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"regex",
+					map[string]string{},
+					map[string]interface{}{
+						"a": int64(1),
+					},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric(
+					"regex",
+					map[string]string{},
+					map[string]interface{}{
+						"b": int64(2),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "default tags",
+			parser: &Parser{
+				MetricName:  "regex",
+				Patterns:    []string{`^(?P<value>\d+)$`},
+				DefaultTags: map[string]string{"source": "test"},
+			},
+			input: "42",
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"regex",
+					map[string]string{
+						"source": "test",
+					},
+					map[string]interface{}{
+						"value": int64(42),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "conversion error",
+			parser: &Parser{
+				MetricName: "regex",
+				Patterns:   []string{`^(?P<value>\w+)$`},
+				Types:      map[string]string{"value": Int},
+			},
+			input:   "abc",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.parser.TimeFunc = func() time.Time { return time.Unix(0, 0) }
+			require.NoError(t, tt.parser.Compile())
+
+			metrics, err := tt.parser.Parse([]byte(tt.input))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.want, metrics)
+		})
+	}
+}
+
+func TestParseLineNoMatch(t *testing.T) {
+	parser := &Parser{
+		MetricName: "regex",
+		Patterns:   []string{`^(?P<value>\d+)$`},
+	}
+	require.NoError(t, parser.Compile())
+
+	m, err := parser.ParseLine("abc")
+	require.NoError(t, err)
+	require.Nil(t, m)
+}
@@ -10,16 +10,36 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/dropwizard"
 	"github.com/influxdata/telegraf/plugins/parsers/graphite"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/html"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/regex"
+	"github.com/influxdata/telegraf/plugins/parsers/units"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 	"github.com/influxdata/telegraf/plugins/parsers/wavefront"
 )
 
 type ParserFunc func() (Parser, error)
 
+// DataFormats are the supported values of data_format with a description.
+var DataFormats = map[string]string{
+	"collectd":   "Collectd binary network protocol",
+	"csv":        "Comma separated values",
+	"dropwizard": "Dropwizard metrics registry in JSON",
+	"graphite":   "Graphite plaintext protocol",
+	"grok":       "Lines matched by grok patterns",
+	"html":       "HTML tables",
+	"influx":     "InfluxDB line protocol",
+	"json":       "JSON objects and arrays",
+	"logfmt":     "Logfmt key value pairs",
+	"nagios":     "Nagios plugin output",
+	"regex":      "Lines matched by regular expressions",
+	"value":      "Single values, ie: 45 or \"booyah\"",
+	"wavefront":  "Wavefront data format",
+}
+
 // ParserInput is an interface for input plugins that are able to parse
 // arbitrary data formats.
 type ParserInput interface {
@@ -141,6 +161,24 @@ type Config struct {
 	CSVTimestampColumn   string   `toml:"csv_timestamp_column"`
 	CSVTimestampFormat   string   `toml:"csv_timestamp_format"`
 	CSVTrimSpace         bool     `toml:"csv_trim_space"`
+
+	//regex configuration
+	RegexPatterns        []string          `toml:"regex_patterns"`
+	RegexTypes           map[string]string `toml:"regex_types"`
+	RegexTimestampGroup  string            `toml:"regex_timestamp_group"`
+	RegexTimestampFormat string            `toml:"regex_timestamp_format"`
+	RegexTimezone        string            `toml:"regex_timezone"`
+
+	//html configuration
+	HTMLTableSelector   string   `toml:"html_table_selector"`
+	HTMLColumnNames     []string `toml:"html_column_names"`
+	HTMLTagColumns      []string `toml:"html_tag_columns"`
+	HTMLTimestampColumn string   `toml:"html_timestamp_column"`
+	HTMLTimestampFormat string   `toml:"html_timestamp_format"`
+
+	// UnitFields is a list of glob patterns of string fields that will be
+	// converted from values with a unit suffix, ie: 10ms, to numbers.
+	UnitFields []string `toml:"unit_fields"`
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -213,9 +251,29 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags)
 	case "logfmt":
 		parser, err = NewLogFmtParser(config.MetricName, config.DefaultTags)
+	case "regex":
+		parser, err = newRegexParser(config.MetricName,
+			config.RegexPatterns,
+			config.RegexTypes,
+			config.RegexTimestampGroup,
+			config.RegexTimestampFormat,
+			config.RegexTimezone,
+			config.DefaultTags)
+	case "html":
+		parser, err = newHTMLParser(config.MetricName,
+			config.HTMLTableSelector,
+			config.HTMLColumnNames,
+			config.HTMLTagColumns,
+			config.HTMLTimestampColumn,
+			config.HTMLTimestampFormat,
+			config.DefaultTags)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
+
+	if err == nil && len(config.UnitFields) > 0 {
+		parser, err = units.NewParser(parser, config.UnitFields)
+	}
 	return parser, err
 }
 
@@ -295,6 +353,50 @@ func newGrokParser(metricName string,
 	return &parser, err
 }
 
+func newRegexParser(metricName string,
+	patterns []string,
+	types map[string]string,
+	timestampGroup string,
+	timestampFormat string,
+	timezone string,
+	defaultTags map[string]string) (Parser, error) {
+	parser := &regex.Parser{
+		MetricName:      metricName,
+		Patterns:        patterns,
+		Types:           types,
+		TimestampGroup:  timestampGroup,
+		TimestampFormat: timestampFormat,
+		Timezone:        timezone,
+		DefaultTags:     defaultTags,
+		TimeFunc:        time.Now,
+	}
+
+	err := parser.Compile()
+	return parser, err
+}
+
+func newHTMLParser(metricName string,
+	tableSelector string,
+	columnNames []string,
+	tagColumns []string,
+	timestampColumn string,
+	timestampFormat string,
+	defaultTags map[string]string) (Parser, error) {
+	parser := &html.Parser{
+		MetricName:      metricName,
+		TableSelector:   tableSelector,
+		ColumnNames:     columnNames,
+		TagColumns:      tagColumns,
+		TimestampColumn: timestampColumn,
+		TimestampFormat: timestampFormat,
+		DefaultTags:     defaultTags,
+		TimeFunc:        time.Now,
+	}
+
+	err := parser.Compile()
+	return parser, err
+}
+
 func NewNagiosParser() (Parser, error) {
 	return &nagios.NagiosParser{}, nil
 }
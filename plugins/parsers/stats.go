@@ -0,0 +1,80 @@
+package parsers
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// statsParser wraps a Parser and records statistics about the parse results,
+// the statistics are collected by the internal input.
+type statsParser struct {
+	Parser
+
+	LinesParsed   selfstat.Stat
+	MetricsParsed selfstat.Stat
+	ParseErrors   selfstat.Stat
+	LastErrorTime selfstat.Stat
+}
+
+// NewStatsParser wraps the parser to record statistics tagged with the name
+// and alias of the input and the data format.  Instances of the same input
+// using the same data format are told apart by their alias.
+func NewStatsParser(parser Parser, input string, alias string, dataFormat string) Parser {
+	tags := map[string]string{"input": input, "data_format": dataFormat}
+	if alias != "" {
+		tags["alias"] = alias
+	}
+	return &statsParser{
+		Parser:        parser,
+		LinesParsed:   selfstat.Register("parser", "lines_parsed", tags),
+		MetricsParsed: selfstat.Register("parser", "metrics_parsed", tags),
+		ParseErrors:   selfstat.Register("parser", "parse_errors", tags),
+		LastErrorTime: selfstat.Register("parser", "last_error_time_ns", tags),
+	}
+}
+
+func (p *statsParser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	p.LinesParsed.Incr(countLines(buf))
+
+	metrics, err := p.Parser.Parse(buf)
+	if err != nil {
+		p.recordError()
+		return nil, err
+	}
+
+	p.MetricsParsed.Incr(int64(len(metrics)))
+	return metrics, nil
+}
+
+func (p *statsParser) ParseLine(line string) (telegraf.Metric, error) {
+	p.LinesParsed.Incr(1)
+
+	m, err := p.Parser.ParseLine(line)
+	if err != nil {
+		p.recordError()
+		return nil, err
+	}
+
+	if m != nil {
+		p.MetricsParsed.Incr(1)
+	}
+	return m, nil
+}
+
+func (p *statsParser) recordError() {
+	p.ParseErrors.Incr(1)
+	p.LastErrorTime.Set(time.Now().UnixNano())
+}
+
+// countLines returns the number of lines in the buffer, including a final
+// line without a trailing newline.
+func countLines(buf []byte) int64 {
+	n := int64(bytes.Count(buf, []byte{'\n'}))
+	if len(buf) > 0 && buf[len(buf)-1] != '\n' {
+		n++
+	}
+	return n
+}
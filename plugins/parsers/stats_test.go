@@ -0,0 +1,48 @@
+package parsers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsParser(t *testing.T) {
+	parser, err := NewInfluxParser()
+	require.NoError(t, err)
+
+	p := NewStatsParser(parser, "test_stats", "", "influx").(*statsParser)
+
+	metrics, err := p.Parse([]byte("cpu value=42\nmem value=42\n"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	_, err = p.ParseLine("cpu value=")
+	require.Error(t, err)
+
+	require.Equal(t, int64(3), p.LinesParsed.Get())
+	require.Equal(t, int64(2), p.MetricsParsed.Get())
+	require.Equal(t, int64(1), p.ParseErrors.Get())
+	require.NotZero(t, p.LastErrorTime.Get())
+}
+
+func TestStatsParserAlias(t *testing.T) {
+	parser, err := NewInfluxParser()
+	require.NoError(t, err)
+
+	p1 := NewStatsParser(parser, "test_stats_alias", "first", "influx").(*statsParser)
+	p2 := NewStatsParser(parser, "test_stats_alias", "second", "influx").(*statsParser)
+
+	_, err = p1.Parse([]byte("cpu value=42\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, int64(1), p1.LinesParsed.Get())
+	require.Equal(t, int64(0), p2.LinesParsed.Get())
+	require.Equal(t, "first", p1.LinesParsed.Tags()["alias"])
+}
+
+func TestCountLines(t *testing.T) {
+	require.Equal(t, int64(0), countLines([]byte("")))
+	require.Equal(t, int64(1), countLines([]byte("a")))
+	require.Equal(t, int64(1), countLines([]byte("a\n")))
+	require.Equal(t, int64(2), countLines([]byte("a\nb")))
+}
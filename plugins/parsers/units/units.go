@@ -0,0 +1,140 @@
+package units
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// TagSuffix is appended to the field name to create the unit tag key.
+const TagSuffix = "_unit"
+
+// valueRe splits a value into its number and unit suffix.
+var valueRe = regexp.MustCompile(`^\s*([-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?)\s*([a-zA-Zµ%]+)\s*$`)
+
+type unit struct {
+	factor float64
+	name   string
+}
+
+// suffixes maps known unit suffixes to the factor converting to the base unit.
+var suffixes = map[string]unit{
+	// durations, normalized to seconds
+	"ns":  {1e-9, "s"},
+	"us":  {1e-6, "s"},
+	"µs":  {1e-6, "s"},
+	"ms":  {1e-3, "s"},
+	"s":   {1, "s"},
+	"min": {60, "s"},
+	"h":   {3600, "s"},
+	"d":   {86400, "s"},
+
+	// sizes, normalized to bytes
+	"B":   {1, "B"},
+	"kB":  {1e3, "B"},
+	"KB":  {1e3, "B"},
+	"MB":  {1e6, "B"},
+	"GB":  {1e9, "B"},
+	"TB":  {1e12, "B"},
+	"PB":  {1e15, "B"},
+	"KiB": {1 << 10, "B"},
+	"MiB": {1 << 20, "B"},
+	"GiB": {1 << 30, "B"},
+	"TiB": {1 << 40, "B"},
+	"PiB": {1 << 50, "B"},
+
+	// ratios
+	"%": {1, "percent"},
+}
+
+// Convert parses a value with a unit suffix such as "10ms", "2.5GiB" or "75%"
+// and returns the value in the base unit along with the name of the base unit.
+func Convert(value string) (float64, string, bool) {
+	match := valueRe.FindStringSubmatch(value)
+	if match == nil {
+		return 0, "", false
+	}
+
+	u, ok := suffixes[match[2]]
+	if !ok {
+		return 0, "", false
+	}
+
+	v, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return v * u.factor, u.name, true
+}
+
+type parser interface {
+	Parse(buf []byte) ([]telegraf.Metric, error)
+	ParseLine(line string) (telegraf.Metric, error)
+	SetDefaultTags(tags map[string]string)
+}
+
+// Parser wraps another parser and converts string fields with a unit suffix
+// into float fields in the base unit, the base unit is added as a tag named
+// after the field.
+type Parser struct {
+	parser
+	filter filter.Filter
+}
+
+// NewParser wraps the parser, only fields matching the fields glob patterns
+// are converted.
+func NewParser(p parser, fields []string) (*Parser, error) {
+	f, err := filter.Compile(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Parser{
+		parser: p,
+		filter: f,
+	}, nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics, err := p.parser.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range metrics {
+		p.convert(m)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	m, err := p.parser.ParseLine(line)
+	if err != nil {
+		return nil, err
+	}
+
+	if m != nil {
+		p.convert(m)
+	}
+	return m, nil
+}
+
+func (p *Parser) convert(m telegraf.Metric) {
+	for _, field := range m.FieldList() {
+		value, ok := field.Value.(string)
+		if !ok || !p.filter.Match(field.Key) {
+			continue
+		}
+
+		v, name, ok := Convert(value)
+		if !ok {
+			continue
+		}
+
+		m.AddField(field.Key, v)
+		m.AddTag(field.Key+TagSuffix, name)
+	}
+}
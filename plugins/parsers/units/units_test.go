@@ -0,0 +1,106 @@
+package units
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		input string
+		value float64
+		unit  string
+		ok    bool
+	}{
+		{input: "10ms", value: 0.01, unit: "s", ok: true},
+		{input: "250us", value: 0.00025, unit: "s", ok: true},
+		{input: "1.5h", value: 5400, unit: "s", ok: true},
+		{input: "2.5GiB", value: 2.5 * (1 << 30), unit: "B", ok: true},
+		{input: "3 MB", value: 3e6, unit: "B", ok: true},
+		{input: "75%", value: 75, unit: "percent", ok: true},
+		{input: "-1.5e2ms", value: -0.15, unit: "s", ok: true},
+		{input: "42", ok: false},
+		{input: "10 parsecs", ok: false},
+		{input: "ms", ok: false},
+		{input: "GET", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			value, unit, ok := Convert(tt.input)
+			require.Equal(t, tt.ok, ok)
+			require.InDelta(t, tt.value, value, 1e-9)
+			require.Equal(t, tt.unit, unit)
+		})
+	}
+}
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		input  string
+		want   []telegraf.Metric
+	}{
+		{
+			name:   "all fields",
+			fields: []string{"*"},
+			input:  `method=GET connect=4ms size=2KiB status=200`,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"logfmt",
+					map[string]string{
+						"connect_unit": "s",
+						"size_unit":    "B",
+					},
+					map[string]interface{}{
+						"method":  "GET",
+						"connect": 0.004,
+						"size":    float64(2048),
+						"status":  int64(200),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:   "selected fields",
+			fields: []string{"connect"},
+			input:  `connect=4ms size=2KiB`,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"logfmt",
+					map[string]string{
+						"connect_unit": "s",
+					},
+					map[string]interface{}{
+						"connect": 0.004,
+						"size":    "2KiB",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lp := logfmt.NewParser("logfmt", nil)
+			lp.Now = func() time.Time { return time.Unix(0, 0) }
+
+			parser, err := NewParser(lp, tt.fields)
+			require.NoError(t, err)
+
+			metrics, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.want, metrics)
+
+			m, err := parser.ParseLine(tt.input)
+			require.NoError(t, err)
+			testutil.RequireMetricEqual(t, tt.want[0], m)
+		})
+	}
+}
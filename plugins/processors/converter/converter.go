@@ -5,9 +5,11 @@ import (
 	"log"
 	"math"
 	"strconv"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
@@ -24,6 +26,11 @@ var sampleConfig = `
     boolean = []
     float = []
 
+    ## Keys to use as the metric timestamp, parsed with timestamp_format:
+    ## "unix", "unix_ms", "unix_us", "unix_ns" or a Go time layout.
+    timestamp = []
+    timestamp_format = "unix"
+
   ## Fields to convert
   ##
   ## The table key determines the target type, and the array of key-values
@@ -36,6 +43,11 @@ var sampleConfig = `
     unsigned = []
     boolean = []
     float = []
+
+    ## Keys to use as the metric timestamp, parsed with timestamp_format:
+    ## "unix", "unix_ms", "unix_us", "unix_ns" or a Go time layout.
+    timestamp = []
+    timestamp_format = "unix"
 `
 
 type Conversion struct {
@@ -45,6 +57,9 @@ type Conversion struct {
 	Unsigned []string `toml:"unsigned"`
 	Boolean  []string `toml:"boolean"`
 	Float    []string `toml:"float"`
+
+	Timestamp       []string `toml:"timestamp"`
+	TimestampFormat string   `toml:"timestamp_format"`
 }
 
 type Converter struct {
@@ -57,12 +72,13 @@ type Converter struct {
 }
 
 type ConversionFilter struct {
-	Tag      filter.Filter
-	String   filter.Filter
-	Integer  filter.Filter
-	Unsigned filter.Filter
-	Boolean  filter.Filter
-	Float    filter.Filter
+	Tag       filter.Filter
+	String    filter.Filter
+	Integer   filter.Filter
+	Unsigned  filter.Filter
+	Boolean   filter.Filter
+	Float     filter.Filter
+	Timestamp filter.Filter
 }
 
 func (p *Converter) SampleConfig() string {
@@ -147,6 +163,11 @@ func compileFilter(conv *Conversion) (*ConversionFilter, error) {
 		return nil, err
 	}
 
+	cf.Timestamp, err = filter.Compile(conv.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
 	return cf, nil
 }
 
@@ -173,6 +194,7 @@ func (p *Converter) convertTags(metric telegraf.Metric) {
 
 			metric.RemoveTag(key)
 			metric.AddField(key, v)
+			continue
 		}
 
 		if p.tagConversions.Unsigned != nil && p.tagConversions.Unsigned.Match(key) {
@@ -213,6 +235,19 @@ func (p *Converter) convertTags(metric telegraf.Metric) {
 			metric.AddField(key, v)
 			continue
 		}
+
+		if p.tagConversions.Timestamp != nil && p.tagConversions.Timestamp.Match(key) {
+			v, ok := toTimestamp(value, p.Tags.TimestampFormat)
+			if !ok {
+				metric.RemoveTag(key)
+				logPrintf("error converting to timestamp [%T]: %v\n", value, value)
+				continue
+			}
+
+			metric.RemoveTag(key)
+			metric.SetTime(v)
+			continue
+		}
 	}
 }
 
@@ -300,6 +335,19 @@ func (p *Converter) convertFields(metric telegraf.Metric) {
 			metric.AddField(key, v)
 			continue
 		}
+
+		if p.fieldConversions.Timestamp != nil && p.fieldConversions.Timestamp.Match(key) {
+			v, ok := toTimestamp(value, p.Fields.TimestampFormat)
+			if !ok {
+				metric.RemoveField(key)
+				logPrintf("error converting to timestamp [%T]: %v\n", value, value)
+				continue
+			}
+
+			metric.RemoveField(key)
+			metric.SetTime(v)
+			continue
+		}
 	}
 }
 
@@ -435,6 +483,25 @@ func toString(v interface{}) (string, bool) {
 	return "", false
 }
 
+// toTimestamp parses a unix epoch, of the precision of the format, or a time
+// in the layout of the format.
+func toTimestamp(v interface{}, format string) (time.Time, bool) {
+	if format == "" {
+		format = "unix"
+	}
+
+	switch value := v.(type) {
+	case int64, uint64:
+		v, _ = toString(value)
+	case string, float64:
+	default:
+		return time.Time{}, false
+	}
+
+	result, err := internal.ParseTimestamp(v, format)
+	return result, err == nil
+}
+
 // math.Round was not added until Go 1.10, can be removed when support for Go
 // 1.9 is dropped.
 func Round(x float64) float64 {
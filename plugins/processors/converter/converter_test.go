@@ -476,6 +476,125 @@ func TestConverter(t *testing.T) {
 				),
 			),
 		},
+		{
+			name: "from field to timestamp",
+			converter: &Converter{
+				Fields: &Conversion{
+					Timestamp:       []string{"time"},
+					TimestampFormat: "unix_ms",
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time":  "1550000000123",
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(1550000000, 123000000).UTC(),
+				),
+			),
+		},
+		{
+			name: "from integer field to timestamp",
+			converter: &Converter{
+				Fields: &Conversion{
+					Timestamp: []string{"time"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time":  int64(1550000000),
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(1550000000, 0).UTC(),
+				),
+			),
+		},
+		{
+			name: "from tag to timestamp with layout",
+			converter: &Converter{
+				Tags: &Conversion{
+					Timestamp:       []string{"date"},
+					TimestampFormat: "2006-01-02T15:04:05Z07:00",
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"date": "2019-02-12T19:33:20Z",
+					},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(1550000000, 0).UTC(),
+				),
+			),
+		},
+		{
+			name: "invalid timestamp is dropped",
+			converter: &Converter{
+				Fields: &Conversion{
+					Timestamp: []string{"time"},
+				},
+			},
+			input: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"time":  "yesterday",
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			expected: Metric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
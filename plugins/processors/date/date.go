@@ -0,0 +1,113 @@
+package date
+
+import (
+	"errors"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## New tag to create
+  tag_key = "month"
+
+  ## New field to create (cannot set both field_key and tag_key)
+  # field_key = "month"
+
+  ## Date format string, must be a representation of the Go "reference time"
+  ## which is "Mon Jan 2 15:04:05 -0700 MST 2006", ie: "Mon" for the weekday
+  ## or "15" for the hour.
+  date_format = "Jan"
+
+  ## If destination is a field, date format can also be one of
+  ## "unix", "unix_ms", "unix_us", or "unix_ns", which will insert an integer field.
+  # date_format = "unix"
+
+  ## Offset duration added to the time of the metric before formatting it.
+  # date_offset = "0s"
+
+  ## Timezone to use when creating the tag or field using a reference time
+  ## string.  This can be set to one of "UTC", "Local", or to a location name
+  ## in the IANA Time Zone database.
+  ##   example: timezone = "America/Los_Angeles"
+  # timezone = "UTC"
+`
+
+type Date struct {
+	TagKey     string            `toml:"tag_key"`
+	FieldKey   string            `toml:"field_key"`
+	DateFormat string            `toml:"date_format"`
+	DateOffset internal.Duration `toml:"date_offset"`
+	Timezone   string            `toml:"timezone"`
+	Log        telegraf.Logger   `toml:"-"`
+
+	initialized bool
+	err         error
+	location    *time.Location
+}
+
+func (d *Date) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Date) Description() string {
+	return "Add a tag or field with the date of the metric in a chosen format"
+}
+
+func (d *Date) init() error {
+	if d.TagKey != "" && d.FieldKey != "" {
+		return errors.New("only one of field_key or tag_key can be specified")
+	}
+	if d.TagKey == "" && d.FieldKey == "" {
+		return errors.New("field_key or tag_key required")
+	}
+
+	// LoadLocation returns UTC if timezone is the empty string.
+	var err error
+	d.location, err = time.LoadLocation(d.Timezone)
+	return err
+}
+
+func (d *Date) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !d.initialized {
+		d.initialized = true
+		d.err = d.init()
+		if d.err != nil {
+			d.Log.Errorf("Invalid configuration, metrics are not processed: %v", d.err)
+		}
+	}
+	if d.err != nil {
+		return in
+	}
+
+	for _, point := range in {
+		tm := point.Time().In(d.location).Add(d.DateOffset.Duration)
+		if d.TagKey != "" {
+			point.AddTag(d.TagKey, tm.Format(d.DateFormat))
+			continue
+		}
+
+		switch d.DateFormat {
+		case "unix":
+			point.AddField(d.FieldKey, tm.Unix())
+		case "unix_ms":
+			point.AddField(d.FieldKey, tm.UnixNano()/int64(time.Millisecond))
+		case "unix_us":
+			point.AddField(d.FieldKey, tm.UnixNano()/int64(time.Microsecond))
+		case "unix_ns":
+			point.AddField(d.FieldKey, tm.UnixNano())
+		default:
+			point.AddField(d.FieldKey, tm.Format(d.DateFormat))
+		}
+	}
+
+	return in
+}
+
+func init() {
+	processors.Add("date", func() telegraf.Processor {
+		return &Date{}
+	})
+}
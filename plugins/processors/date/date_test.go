@@ -0,0 +1,110 @@
+package date
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tm time.Time) telegraf.Metric {
+	return testutil.MustMetric("foo",
+		map[string]string{},
+		map[string]interface{}{"value": int64(1)},
+		tm,
+	)
+}
+
+func TestMonthTag(t *testing.T) {
+	d := &Date{
+		TagKey:     "month",
+		DateFormat: "Jan",
+		Log:        testutil.Logger{},
+	}
+
+	processed := d.Apply(
+		newMetric(time.Date(2019, time.February, 12, 0, 0, 0, 0, time.UTC)),
+		newMetric(time.Date(2019, time.March, 12, 0, 0, 0, 0, time.UTC)),
+	)
+
+	require.Equal(t, map[string]string{"month": "Feb"}, processed[0].Tags())
+	require.Equal(t, map[string]string{"month": "Mar"}, processed[1].Tags())
+}
+
+func TestTimezoneAndOffset(t *testing.T) {
+	d := &Date{
+		TagKey:     "weekday_hour",
+		DateFormat: "Mon 15",
+		DateOffset: internal.Duration{Duration: time.Hour},
+		Timezone:   "America/New_York",
+		Log:        testutil.Logger{},
+	}
+
+	// Monday 2:30 UTC is Sunday 21:30 in New York, 22:30 with the offset.
+	processed := d.Apply(newMetric(time.Date(2019, time.February, 11, 2, 30, 0, 0, time.UTC)))
+
+	require.Equal(t, map[string]string{"weekday_hour": "Sun 22"}, processed[0].Tags())
+}
+
+func TestField(t *testing.T) {
+	tm := time.Date(2019, time.February, 12, 13, 14, 15, 16000000, time.UTC)
+	tests := []struct {
+		format   string
+		expected interface{}
+	}{
+		{format: "15", expected: "13"},
+		{format: "unix", expected: tm.Unix()},
+		{format: "unix_ms", expected: tm.UnixNano() / 1000000},
+		{format: "unix_us", expected: tm.UnixNano() / 1000},
+		{format: "unix_ns", expected: tm.UnixNano()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			d := &Date{
+				FieldKey:   "date",
+				DateFormat: tt.format,
+				Log:        testutil.Logger{},
+			}
+
+			processed := d.Apply(newMetric(tm))
+
+			value, ok := processed[0].GetField("date")
+			require.True(t, ok)
+			require.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		date *Date
+	}{
+		{
+			name: "no key",
+			date: &Date{DateFormat: "Jan"},
+		},
+		{
+			name: "both keys",
+			date: &Date{TagKey: "month", FieldKey: "month", DateFormat: "Jan"},
+		},
+		{
+			name: "unknown timezone",
+			date: &Date{TagKey: "month", DateFormat: "Jan", Timezone: "Mars/Olympus"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.date.Log = testutil.Logger{}
+
+			m := newMetric(time.Unix(0, 0))
+			processed := tt.date.Apply(m)
+
+			require.Error(t, tt.date.err)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{newMetric(time.Unix(0, 0))}, processed)
+		})
+	}
+}
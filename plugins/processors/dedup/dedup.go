@@ -0,0 +1,113 @@
+package dedup
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Maximum time to suppress output
+  dedup_interval = "600s"
+`
+
+type Dedup struct {
+	DedupInterval internal.Duration `toml:"dedup_interval"`
+
+	flushTime time.Time
+	cache     map[uint64]telegraf.Metric
+}
+
+func (d *Dedup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Dedup) Description() string {
+	return "Filter metrics with repeating field values"
+}
+
+// Apply drops the metrics whose field values are the same as the last metric
+// of the series passed through, unless it is older than the dedup interval.
+func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	out := metrics[:0]
+	for _, metric := range metrics {
+		id := metric.HashID()
+		cached, ok := d.cache[id]
+		if !ok || time.Since(cached.Time()) >= d.DedupInterval.Duration {
+			d.save(id, metric)
+			out = append(out, metric)
+			continue
+		}
+
+		changed := false
+		added := false
+		sametime := metric.Time().Equal(cached.Time())
+		for _, field := range metric.FieldList() {
+			value, ok := cached.GetField(field.Key)
+			if !ok {
+				// An input can produce several metrics of a series with
+				// the same timestamp, ie: of different value types.  Their
+				// fields are merged into the cached metric.
+				if sametime {
+					cached.AddField(field.Key, field.Value)
+					added = true
+				} else {
+					changed = true
+				}
+				continue
+			}
+			if value != field.Value {
+				changed = true
+				break
+			}
+		}
+
+		switch {
+		case changed:
+			d.save(id, metric)
+			out = append(out, metric)
+		case added:
+			out = append(out, metric)
+		default:
+			metric.Drop()
+		}
+	}
+
+	d.cleanup()
+	return out
+}
+
+// save caches a copy of the metric, its delivery is not tracked.
+func (d *Dedup) save(id uint64, metric telegraf.Metric) {
+	if d.cache == nil {
+		d.cache = make(map[uint64]telegraf.Metric)
+	}
+	cached := metric.Copy()
+	cached.Accept()
+	d.cache[id] = cached
+}
+
+// cleanup removes the expired series from the cache, at most once per dedup
+// interval.
+func (d *Dedup) cleanup() {
+	if time.Since(d.flushTime) < d.DedupInterval.Duration {
+		return
+	}
+	d.flushTime = time.Now()
+
+	for id, metric := range d.cache {
+		if time.Since(metric.Time()) >= d.DedupInterval.Duration {
+			delete(d.cache, id)
+		}
+	}
+}
+
+func init() {
+	processors.Add("dedup", func() telegraf.Processor {
+		return &Dedup{
+			DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+		}
+	})
+}
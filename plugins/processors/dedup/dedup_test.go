@@ -0,0 +1,130 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newDedup() *Dedup {
+	return &Dedup{
+		DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+	}
+}
+
+func newMetric(value int64, tm time.Time) telegraf.Metric {
+	return testutil.MustMetric("sqlserver_properties",
+		map[string]string{"sql_instance": "db01"},
+		map[string]interface{}{"cpu_count": value},
+		tm,
+	)
+}
+
+func TestSuppressUnchanged(t *testing.T) {
+	d := newDedup()
+	now := time.Now()
+
+	out := d.Apply(newMetric(4, now.Add(-2*time.Minute)))
+	require.Len(t, out, 1)
+
+	out = d.Apply(newMetric(4, now.Add(-1*time.Minute)))
+	require.Len(t, out, 0)
+
+	out = d.Apply(newMetric(4, now))
+	require.Len(t, out, 0)
+}
+
+func TestPassChanged(t *testing.T) {
+	d := newDedup()
+	now := time.Now()
+
+	out := d.Apply(newMetric(4, now.Add(-2*time.Minute)))
+	require.Len(t, out, 1)
+
+	out = d.Apply(newMetric(8, now.Add(-1*time.Minute)))
+	require.Len(t, out, 1)
+
+	out = d.Apply(newMetric(8, now))
+	require.Len(t, out, 0)
+}
+
+func TestPassAfterInterval(t *testing.T) {
+	d := newDedup()
+	now := time.Now()
+
+	out := d.Apply(newMetric(4, now.Add(-15*time.Minute)))
+	require.Len(t, out, 1)
+
+	// The cached point is older than the interval.
+	out = d.Apply(newMetric(4, now))
+	require.Len(t, out, 1)
+
+	out = d.Apply(newMetric(4, now))
+	require.Len(t, out, 0)
+}
+
+func TestSeriesAreIndependent(t *testing.T) {
+	d := newDedup()
+	now := time.Now()
+
+	other := testutil.MustMetric("sqlserver_properties",
+		map[string]string{"sql_instance": "db02"},
+		map[string]interface{}{"cpu_count": int64(4)},
+		now,
+	)
+
+	out := d.Apply(newMetric(4, now), other)
+	require.Len(t, out, 2)
+
+	out = d.Apply(newMetric(4, now), other.Copy())
+	require.Len(t, out, 0)
+}
+
+func TestNewField(t *testing.T) {
+	d := newDedup()
+	now := time.Now()
+
+	out := d.Apply(newMetric(4, now.Add(-1*time.Minute)))
+	require.Len(t, out, 1)
+
+	m := newMetric(4, now)
+	m.AddField("memory", int64(1024))
+	out = d.Apply(m)
+	require.Len(t, out, 1)
+}
+
+func TestMergeSameTimestamp(t *testing.T) {
+	d := newDedup()
+	now := time.Now()
+
+	a := newMetric(4, now)
+	b := testutil.MustMetric("sqlserver_properties",
+		map[string]string{"sql_instance": "db01"},
+		map[string]interface{}{"memory": int64(1024)},
+		now,
+	)
+
+	out := d.Apply(a, b)
+	require.Len(t, out, 2)
+
+	// Both fields are cached for the series.
+	m := newMetric(4, now.Add(time.Second))
+	m.AddField("memory", int64(1024))
+	out = d.Apply(m)
+	require.Len(t, out, 0)
+}
+
+func TestCleanup(t *testing.T) {
+	d := newDedup()
+	now := time.Now()
+
+	d.Apply(newMetric(4, now.Add(-15*time.Minute)))
+	require.Len(t, d.cache, 0)
+
+	d.Apply(newMetric(4, now))
+	require.Len(t, d.cache, 1)
+}
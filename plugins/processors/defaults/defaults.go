@@ -0,0 +1,83 @@
+package defaults
+
+import (
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Ensures a set of fields always exists on your metric(s) with their
+  ## respective default value.
+  ## For any given field pair (key = default), if it's not set, a field
+  ## is set on the metric with the specified default.
+  ##
+  ## A field is considered not set if it is nil on the incoming metric;
+  ## or it is not nil but its value is an empty string or is a string
+  ## of one or more spaces.
+  ##   <target-field> = <value>
+  # [processors.defaults.fields]
+  #   field_1 = "bar"
+  #   time_idle = 0
+  #   is_error = true
+
+  ## Ensures a set of tags always exists on your metric(s), a tag is
+  ## considered not set if it is missing or its value is blank.
+  ##   <target-tag> = <value>
+  # [processors.defaults.tags]
+  #   region = "unknown"
+`
+
+// Defaults is a processor for ensuring certain fields and tags always exist
+// on your Metrics with at least a default value.
+type Defaults struct {
+	DefaultFieldsSets map[string]interface{} `toml:"fields"`
+	DefaultTagsSets   map[string]string      `toml:"tags"`
+}
+
+func (def *Defaults) SampleConfig() string {
+	return sampleConfig
+}
+
+func (def *Defaults) Description() string {
+	return "Defaults sets default value(s) for specified fields and tags that are not set on incoming metrics."
+}
+
+// Apply contains the main implementation of this processor.
+// For each metric in 'inputMetrics', it goes over each default pair.
+// If the field or tag in the pair does not exist on the metric, the
+// associated default is added.
+// If the field or tag was found, then, if its value is the empty string or
+// one or more spaces, it is replaced by the associated default.
+func (def *Defaults) Apply(inputMetrics ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range inputMetrics {
+		for defField, defValue := range def.DefaultFieldsSets {
+			if maybeCurrent, isSet := metric.GetField(defField); !isSet {
+				metric.AddField(defField, defValue)
+			} else if trimmed, isStr := maybeTrimmedString(maybeCurrent); isStr && trimmed == "" {
+				metric.RemoveField(defField)
+				metric.AddField(defField, defValue)
+			}
+		}
+		for defTag, defValue := range def.DefaultTagsSets {
+			if current, isSet := metric.GetTag(defTag); !isSet || strings.TrimSpace(current) == "" {
+				metric.AddTag(defTag, defValue)
+			}
+		}
+	}
+	return inputMetrics
+}
+
+func maybeTrimmedString(v interface{}) (string, bool) {
+	if value, ok := v.(string); ok {
+		return strings.TrimSpace(value), true
+	}
+	return "", false
+}
+
+func init() {
+	processors.Add("defaults", func() telegraf.Processor {
+		return &Defaults{}
+	})
+}
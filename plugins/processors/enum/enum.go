@@ -1,6 +1,7 @@
 package enum
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/influxdata/telegraf"
@@ -12,8 +13,11 @@ var sampleConfig = `
     ## Name of the field to map
     field = "status"
 
-    ## Destination field to be used for the mapped value.  By default the source
-    ## field is used, overwriting the original value.
+    ## Name of the tag to map
+    # tag = "status"
+
+    ## Destination tag or field to be used for the mapped value.  By default the
+    ## source tag or field is used, overwriting the original value.
     # dest = "status_code"
 
     ## Default value to be used for all values not contained in the mapping
@@ -21,7 +25,8 @@ var sampleConfig = `
     ## match is found.
     # default = 0
 
-    ## Table of mappings
+    ## Table of mappings, integer, float and boolean values are matched by
+    ## their string representation, ie: "1" or "true"
     [processors.enum.mapping.value_mappings]
       green = 1
       yellow = 2
@@ -33,6 +38,7 @@ type EnumMapper struct {
 }
 
 type Mapping struct {
+	Tag           string
 	Field         string
 	Dest          string
 	Default       interface{}
@@ -56,10 +62,19 @@ func (mapper *EnumMapper) Apply(in ...telegraf.Metric) []telegraf.Metric {
 
 func (mapper *EnumMapper) applyMappings(metric telegraf.Metric) telegraf.Metric {
 	for _, mapping := range mapper.Mappings {
-		if originalValue, isPresent := metric.GetField(mapping.Field); isPresent == true {
-			if adjustedValue, isString := adjustBoolValue(originalValue).(string); isString == true {
-				if mappedValue, isMappedValuePresent := mapping.mapValue(adjustedValue); isMappedValuePresent == true {
-					writeField(metric, mapping.getDestination(), mappedValue)
+		if mapping.Field != "" {
+			if originalValue, isPresent := metric.GetField(mapping.Field); isPresent == true {
+				if adjustedValue, isString := adjustValue(originalValue).(string); isString == true {
+					if mappedValue, isMappedValuePresent := mapping.mapValue(adjustedValue); isMappedValuePresent == true {
+						writeField(metric, mapping.getDestination(), mappedValue)
+					}
+				}
+			}
+		}
+		if mapping.Tag != "" {
+			if originalValue, isPresent := metric.GetTag(mapping.Tag); isPresent == true {
+				if mappedValue, isMappedValuePresent := mapping.mapValue(originalValue); isMappedValuePresent == true {
+					writeTag(metric, mapping.getDestinationTag(), mappedValue)
 				}
 			}
 		}
@@ -67,9 +82,18 @@ func (mapper *EnumMapper) applyMappings(metric telegraf.Metric) telegraf.Metric
 	return metric
 }
 
-func adjustBoolValue(in interface{}) interface{} {
-	if mappedBool, isBool := in.(bool); isBool == true {
-		return strconv.FormatBool(mappedBool)
+// adjustValue returns the bool and numeric values as strings, to look them up
+// in the value mappings.
+func adjustValue(in interface{}) interface{} {
+	switch value := in.(type) {
+	case bool:
+		return strconv.FormatBool(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
 	}
 	return in
 }
@@ -91,11 +115,22 @@ func (mapping *Mapping) getDestination() string {
 	return mapping.Field
 }
 
+func (mapping *Mapping) getDestinationTag() string {
+	if mapping.Dest != "" {
+		return mapping.Dest
+	}
+	return mapping.Tag
+}
+
 func writeField(metric telegraf.Metric, name string, value interface{}) {
 	metric.RemoveField(name)
 	metric.AddField(name, value)
 }
 
+func writeTag(metric telegraf.Metric, name string, value interface{}) {
+	metric.AddTag(name, fmt.Sprint(value))
+}
+
 func init() {
 	processors.Add("enum", func() telegraf.Processor {
 		return &EnumMapper{}
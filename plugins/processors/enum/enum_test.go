@@ -104,3 +104,28 @@ func TestWritesToDestination(t *testing.T) {
 	assertFieldValue(t, "test", "string_value", fields)
 	assertFieldValue(t, 1, "string_code", fields)
 }
+
+func TestMapsNumericValues(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Field: "int_value", ValueMappings: map[string]interface{}{"13": "online"}}}}
+
+	fields := calculateProcessedValues(mapper, createTestMetric())
+
+	assertFieldValue(t, "online", "int_value", fields)
+}
+
+func TestMapsTagValue(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Tag: "tag", ValueMappings: map[string]interface{}{"tag_value": int64(1)}}}}
+
+	tags := mapper.Apply(createTestMetric())[0].Tags()
+
+	assert.Equal(t, "1", tags["tag"])
+}
+
+func TestWritesTagToDestination(t *testing.T) {
+	mapper := EnumMapper{Mappings: []Mapping{{Tag: "tag", Dest: "tag_code", Default: "unknown", ValueMappings: map[string]interface{}{"other": "x"}}}}
+
+	tags := mapper.Apply(createTestMetric())[0].Tags()
+
+	assert.Equal(t, "tag_value", tags["tag"])
+	assert.Equal(t, "unknown", tags["tag_code"])
+}
@@ -0,0 +1,182 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon, followed by its arguments.
+  ## The program receives metrics in the data format on stdin and writes
+  ## the processed metrics in the same data format to stdout.
+  command = ["python", "/usr/local/bin/processor.py"]
+
+  ## Delay before the process is restarted after an unexpected termination
+  restart_delay = "10s"
+
+  ## Data format used to pass the metrics to the program and to parse its
+  ## output.  Each data format has its own unique set of configuration
+  ## options, read more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+const maxLineSize = 1024 * 1024
+
+// queueSize is the number of metrics waiting to be written to the process,
+// metrics are dropped once it is full.
+const queueSize = 10000
+
+type Execd struct {
+	Command      []string
+	RestartDelay internal.Duration
+	Log          telegraf.Logger `toml:"-"`
+
+	process    *process.Process
+	serializer serializers.Serializer
+	parser     parsers.Parser
+	acc        telegraf.Accumulator
+
+	// The metrics are written by their own goroutine so that Apply does not
+	// block the agent when the process is not reading its stdin.
+	queue      chan []byte
+	writerDone chan struct{}
+	stopping   int32
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running processor plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+
+	var err error
+	e.process, err = process.New(e.Command, e.Log)
+	if err != nil {
+		return err
+	}
+	e.process.RestartDelay = e.RestartDelay.Duration
+	e.process.ReadStdout = e.readStdout
+	e.process.ReadStderr = process.LogStderr(e.Log)
+
+	if err := e.process.Start(); err != nil {
+		return err
+	}
+
+	e.queue = make(chan []byte, queueSize)
+	e.writerDone = make(chan struct{})
+	atomic.StoreInt32(&e.stopping, 0)
+	go e.writeStdin()
+	return nil
+}
+
+// Stop writes the queued metrics and stops the process.  The metrics not
+// written within the kill timeout of the process are dropped.
+func (e *Execd) Stop() {
+	close(e.queue)
+	select {
+	case <-e.writerDone:
+	case <-time.After(process.KillTimeout):
+		e.Log.Errorf("Process is not reading its input, dropping %d metrics", len(e.queue))
+	}
+
+	// Stopping the process closes its stdin which unblocks the writer.
+	atomic.StoreInt32(&e.stopping, 1)
+	e.process.Stop()
+	<-e.writerDone
+}
+
+// writeStdin writes the queued metrics to the process.
+func (e *Execd) writeStdin() {
+	defer close(e.writerDone)
+	for octets := range e.queue {
+		if atomic.LoadInt32(&e.stopping) == 1 {
+			continue
+		}
+		if _, err := e.process.Write(octets); err != nil && atomic.LoadInt32(&e.stopping) == 0 {
+			e.Log.Errorf("Error writing to process: %v", err)
+		}
+	}
+}
+
+// Apply passes the metrics to the process, the processed metrics are added
+// to the accumulator once the process writes them.
+func (e *Execd) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		octets, err := e.serializer.Serialize(metric)
+		metric.Drop()
+		if err != nil {
+			e.Log.Errorf("Could not serialize metric: %v", err)
+			continue
+		}
+
+		select {
+		case e.queue <- octets:
+		default:
+			e.Log.Errorf("Queue of the process is full, dropping metric")
+		}
+	}
+	return nil
+}
+
+// readStdout parses each line written by the process as metrics.
+func (e *Execd) readStdout(r io.Reader) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	for {
+		line, err := process.ReadLine(reader, maxLineSize)
+		if err == process.ErrLineTooLong {
+			e.acc.AddError(fmt.Errorf("line exceeds %d bytes, skipping it", maxLineSize))
+			continue
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			e.Log.Errorf("Error reading stdout: %v", err)
+			return
+		}
+
+		metrics, err := e.parser.Parse(line)
+		if err != nil {
+			e.acc.AddError(fmt.Errorf("parse error: %v", err))
+			continue
+		}
+
+		for _, metric := range metrics {
+			e.acc.AddMetric(metric)
+		}
+	}
+}
+
+func init() {
+	processors.Add("execd", func() telegraf.Processor {
+		return &Execd{
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,144 @@
+// +build !windows
+
+package execd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	e := &Execd{
+		// Duplicate each metric
+		Command:      []string{"sh", "-c", "while read line; do echo \"$line\"; echo \"$line\"; done"},
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	setInflux(t, e)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, e.Start(&acc))
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"usage_idle": 42.0},
+		time.Unix(0, 0),
+	)
+	require.Nil(t, e.Apply(m))
+
+	acc.Wait(2)
+	e.Stop()
+
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m, m}, acc.GetTelegrafMetrics())
+}
+
+func TestApplyLongLine(t *testing.T) {
+	e := &Execd{
+		// Write a line longer than maxLineSize before each metric
+		Command:      []string{"sh", "-c", "while read line; do head -c 1100000 /dev/zero | tr '\\0' a; echo; echo \"$line\"; done"},
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	setInflux(t, e)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, e.Start(&acc))
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"usage_idle": 42.0},
+		time.Unix(0, 0),
+	)
+	require.Nil(t, e.Apply(m))
+	require.Nil(t, e.Apply(m.Copy()))
+
+	acc.Wait(2)
+	e.Stop()
+
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m, m}, acc.GetTelegrafMetrics())
+	require.Len(t, acc.Errors, 2)
+}
+
+func TestApplyJSON(t *testing.T) {
+	e := &Execd{
+		// Report the format of each metric received
+		Command:      []string{"sh", "-c", "while read line; do case \"$line\" in {*) echo 'format json=true';; *) echo 'format json=false';; esac; done"},
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	serializer, err := json.NewSerializer(time.Second)
+	require.NoError(t, err)
+	e.SetSerializer(serializer)
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+	e.SetParser(parser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, e.Start(&acc))
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"usage_idle": 42.0},
+		time.Unix(0, 0),
+	)
+	require.Nil(t, e.Apply(m))
+
+	acc.Wait(1)
+	e.Stop()
+
+	acc.AssertContainsFields(t, "format",
+		map[string]interface{}{
+			"json": true,
+		})
+}
+
+func TestApplyNotReading(t *testing.T) {
+	e := &Execd{
+		// The process never reads its stdin
+		Command:      []string{"sleep", "60"},
+		RestartDelay: internal.Duration{Duration: time.Second},
+		Log:          testutil.Logger{},
+	}
+	setInflux(t, e)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, e.Start(&acc))
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"value": strings.Repeat("x", 1024)},
+		time.Unix(0, 0),
+	)
+
+	// Apply returns even though the process blocks the writer.
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*queueSize; i++ {
+			e.Apply(m.Copy())
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Apply blocked")
+	}
+
+	e.Stop()
+}
+
+func setInflux(t *testing.T, e *Execd) {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+	e.SetParser(parser)
+	e.SetSerializer(influx.NewSerializer())
+}
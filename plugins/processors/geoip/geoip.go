@@ -0,0 +1,149 @@
+package geoip
+
+import (
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+const sampleConfig = `
+  ## Paths to the MaxMind databases, the City or Country database and the
+  ## ASN database can be used together.  The tags found in each database are
+  ## added to the metric.
+  databases = ["/var/lib/GeoIP/GeoLite2-City.mmdb", "/var/lib/GeoIP/GeoLite2-ASN.mmdb"]
+
+  ## Tag containing the IP address to look up.
+  ip_tag = "client_ip"
+
+  ## Prefix of the added tags, ie: "geoip_country_code".
+  # tag_prefix = "geoip_"
+
+  ## Language of the country and city names.
+  # language = "en"
+`
+
+// record holds the values of the GeoIP2 and GeoLite2 City, Country and ASN
+// databases that are added as tags.
+type record struct {
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+// reader is implemented by maxminddb.Reader.
+type reader interface {
+	Lookup(ip net.IP, result interface{}) error
+}
+
+type GeoIP struct {
+	Databases []string        `toml:"databases"`
+	IPTag     string          `toml:"ip_tag"`
+	TagPrefix string          `toml:"tag_prefix"`
+	Language  string          `toml:"language"`
+	Log       telegraf.Logger `toml:"-"`
+
+	initialized bool
+	err         error
+	readers     []reader
+}
+
+func (g *GeoIP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GeoIP) Description() string {
+	return "Add the country, city and ASN of an IP address tag from MaxMind databases"
+}
+
+func (g *GeoIP) init() error {
+	if g.IPTag == "" {
+		return errors.New("ip_tag is required")
+	}
+	if len(g.Databases) == 0 {
+		return errors.New("at least one database is required")
+	}
+
+	// The databases are kept open, they are memory mapped.
+	for _, path := range g.Databases {
+		r, err := maxminddb.Open(path)
+		if err != nil {
+			return err
+		}
+		g.readers = append(g.readers, r)
+	}
+	return nil
+}
+
+func (g *GeoIP) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !g.initialized {
+		g.initialized = true
+		// The readers can be set by the tests.
+		if len(g.readers) == 0 {
+			g.err = g.init()
+		}
+		if g.err != nil {
+			g.Log.Errorf("Could not open databases, metrics are not processed: %v", g.err)
+		}
+	}
+	if g.err != nil {
+		return in
+	}
+
+	for _, point := range in {
+		value, ok := point.GetTag(g.IPTag)
+		if !ok {
+			continue
+		}
+		ip := net.ParseIP(value)
+		if ip == nil {
+			g.Log.Debugf("Invalid IP address %q in tag %q", value, g.IPTag)
+			continue
+		}
+
+		for _, r := range g.readers {
+			var rec record
+			if err := r.Lookup(ip, &rec); err != nil {
+				g.Log.Errorf("Could not look up %q: %v", value, err)
+				continue
+			}
+			g.addTags(point, &rec)
+		}
+	}
+
+	return in
+}
+
+func (g *GeoIP) addTags(point telegraf.Metric, rec *record) {
+	addTag := func(key, value string) {
+		if value != "" {
+			point.AddTag(g.TagPrefix+key, value)
+		}
+	}
+
+	addTag("country_code", rec.Country.ISOCode)
+	addTag("country", rec.Country.Names[g.Language])
+	addTag("city", rec.City.Names[g.Language])
+	if rec.AutonomousSystemNumber != 0 {
+		addTag("asn", strconv.FormatUint(uint64(rec.AutonomousSystemNumber), 10))
+	}
+	addTag("as_org", rec.AutonomousSystemOrganization)
+}
+
+func init() {
+	processors.Add("geoip", func() telegraf.Processor {
+		return &GeoIP{
+			TagPrefix: "geoip_",
+			Language:  "en",
+		}
+	})
+}
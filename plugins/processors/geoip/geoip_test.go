@@ -0,0 +1,114 @@
+package geoip
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type mockReader map[string]record
+
+func (r mockReader) Lookup(ip net.IP, result interface{}) error {
+	if rec, ok := r[ip.String()]; ok {
+		*result.(*record) = rec
+	}
+	return nil
+}
+
+func cityRecord(code, country, city string) record {
+	var rec record
+	rec.Country.ISOCode = code
+	rec.Country.Names = map[string]string{"en": country}
+	rec.City.Names = map[string]string{"en": city}
+	return rec
+}
+
+func asnRecord(asn uint, org string) record {
+	return record{
+		AutonomousSystemNumber:       asn,
+		AutonomousSystemOrganization: org,
+	}
+}
+
+func newMetric(tags map[string]string) telegraf.Metric {
+	return testutil.MustMetric("access_log",
+		tags,
+		map[string]interface{}{"bytes": int64(512)},
+		time.Unix(0, 0),
+	)
+}
+
+func TestApply(t *testing.T) {
+	plugin := &GeoIP{
+		IPTag:     "client_ip",
+		TagPrefix: "geoip_",
+		Language:  "en",
+		Log:       testutil.Logger{},
+		readers: []reader{
+			mockReader{"81.2.69.142": cityRecord("GB", "United Kingdom", "London")},
+			mockReader{"81.2.69.142": asnRecord(20712, "Andrews & Arnold Ltd")},
+		},
+	}
+
+	actual := plugin.Apply(
+		newMetric(map[string]string{"client_ip": "81.2.69.142"}),
+		newMetric(map[string]string{"client_ip": "10.0.0.1"}),
+		newMetric(map[string]string{"client_ip": "invalid"}),
+		newMetric(map[string]string{"host": "localhost"}),
+	)
+	expected := []telegraf.Metric{
+		newMetric(map[string]string{
+			"client_ip":          "81.2.69.142",
+			"geoip_country_code": "GB",
+			"geoip_country":      "United Kingdom",
+			"geoip_city":         "London",
+			"geoip_asn":          "20712",
+			"geoip_as_org":       "Andrews & Arnold Ltd",
+		}),
+		newMetric(map[string]string{"client_ip": "10.0.0.1"}),
+		newMetric(map[string]string{"client_ip": "invalid"}),
+		newMetric(map[string]string{"host": "localhost"}),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestLanguage(t *testing.T) {
+	rec := cityRecord("DE", "Germany", "Munich")
+	rec.Country.Names["de"] = "Deutschland"
+	rec.City.Names["de"] = "München"
+
+	plugin := &GeoIP{
+		IPTag:    "client_ip",
+		Language: "de",
+		Log:      testutil.Logger{},
+		readers:  []reader{mockReader{"2a02:810d::1": rec}},
+	}
+
+	actual := plugin.Apply(newMetric(map[string]string{"client_ip": "2a02:810d::1"}))
+	expected := []telegraf.Metric{
+		newMetric(map[string]string{
+			"client_ip":    "2a02:810d::1",
+			"country_code": "DE",
+			"country":      "Deutschland",
+			"city":         "München",
+		}),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestMissingDatabase(t *testing.T) {
+	plugin := &GeoIP{
+		Databases: []string{"/nonexistent/GeoLite2-City.mmdb"},
+		IPTag:     "client_ip",
+		Log:       testutil.Logger{},
+	}
+
+	m := newMetric(map[string]string{"client_ip": "81.2.69.142"})
+	actual := plugin.Apply(m)
+	require.Error(t, plugin.err)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, actual)
+}
@@ -0,0 +1,210 @@
+package lookup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## File containing the tags to add to the metrics, keyed by the value of
+  ## the key_tag.
+  file = "/etc/telegraf/inventory.csv"
+
+  ## Format of the file, one of "csv" or "json".
+  ##   csv:  The first row is a header with the column names, the first
+  ##         column contains the key and the others the tags to add.
+  ##   json: An object mapping each key to an object of tags to add.
+  format = "csv"
+
+  ## Tag whose value is looked up in the file.
+  key_tag = "sql_instance"
+
+  ## Interval to check the file for changes, the file is reloaded when its
+  ## modification time changed.  Set to 0s to load the file only once.
+  # reload_interval = "5m"
+
+  ## Overwrite the tags already present on the metric.
+  # overwrite = false
+`
+
+type Lookup struct {
+	File           string            `toml:"file"`
+	Format         string            `toml:"format"`
+	KeyTag         string            `toml:"key_tag"`
+	ReloadInterval internal.Duration `toml:"reload_interval"`
+	Overwrite      bool              `toml:"overwrite"`
+	Log            telegraf.Logger   `toml:"-"`
+
+	initialized bool
+	err         error
+
+	table     map[string]map[string]string
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func (l *Lookup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Lookup) Description() string {
+	return "Add tags to metrics from a lookup file keyed by a tag value"
+}
+
+func (l *Lookup) init() error {
+	if l.File == "" {
+		return errors.New("file is required")
+	}
+	if l.KeyTag == "" {
+		return errors.New("key_tag is required")
+	}
+	switch l.Format {
+	case "csv", "json":
+	default:
+		return fmt.Errorf("unknown format %q", l.Format)
+	}
+	return nil
+}
+
+func (l *Lookup) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !l.initialized {
+		l.initialized = true
+		l.err = l.init()
+		if l.err != nil {
+			l.Log.Errorf("Invalid configuration, metrics are not processed: %v", l.err)
+		} else {
+			l.reload(time.Now())
+		}
+	}
+	if l.err != nil {
+		return in
+	}
+
+	if l.ReloadInterval.Duration > 0 {
+		now := time.Now()
+		if now.Sub(l.lastCheck) >= l.ReloadInterval.Duration {
+			l.reload(now)
+		}
+	}
+
+	for _, point := range in {
+		key, ok := point.GetTag(l.KeyTag)
+		if !ok {
+			continue
+		}
+		tags, ok := l.table[key]
+		if !ok {
+			continue
+		}
+		for k, v := range tags {
+			if !l.Overwrite && point.HasTag(k) {
+				continue
+			}
+			point.AddTag(k, v)
+		}
+	}
+
+	return in
+}
+
+// reload loads the file if it was modified since it was last loaded.  If the
+// file cannot be loaded the previous table is kept.
+func (l *Lookup) reload(now time.Time) {
+	l.lastCheck = now
+
+	info, err := os.Stat(l.File)
+	if err != nil {
+		l.Log.Errorf("Could not load lookup file: %v", err)
+		return
+	}
+	if l.table != nil && info.ModTime().Equal(l.modTime) {
+		return
+	}
+
+	table, err := l.load()
+	if err != nil {
+		l.Log.Errorf("Could not load lookup file %q: %v", l.File, err)
+		return
+	}
+	l.table = table
+	l.modTime = info.ModTime()
+	l.Log.Debugf("Loaded %d keys from %q", len(table), l.File)
+}
+
+func (l *Lookup) load() (map[string]map[string]string, error) {
+	buf, err := ioutil.ReadFile(l.File)
+	if err != nil {
+		return nil, err
+	}
+
+	switch l.Format {
+	case "csv":
+		return parseCSV(buf)
+	case "json":
+		return parseJSON(buf)
+	}
+	return nil, fmt.Errorf("unknown format %q", l.Format)
+}
+
+func parseCSV(buf []byte) (map[string]map[string]string, error) {
+	records, err := csv.NewReader(strings.NewReader(string(buf))).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("missing header row")
+	}
+
+	header := records[0]
+	if len(header) < 2 {
+		return nil, errors.New("header must contain the key and at least one tag column")
+	}
+
+	table := make(map[string]map[string]string, len(records)-1)
+	for _, record := range records[1:] {
+		tags := make(map[string]string, len(record)-1)
+		for i, value := range record[1:] {
+			// Empty cells are not added, tags cannot have empty values.
+			if value == "" {
+				continue
+			}
+			tags[header[i+1]] = value
+		}
+		table[record[0]] = tags
+	}
+	return table, nil
+}
+
+func parseJSON(buf []byte) (map[string]map[string]string, error) {
+	var table map[string]map[string]string
+	if err := json.Unmarshal(buf, &table); err != nil {
+		return nil, err
+	}
+	for _, tags := range table {
+		for k, v := range tags {
+			if v == "" {
+				delete(tags, k)
+			}
+		}
+	}
+	return table, nil
+}
+
+func init() {
+	processors.Add("lookup", func() telegraf.Processor {
+		return &Lookup{
+			Format:         "csv",
+			ReloadInterval: internal.Duration{Duration: 5 * time.Minute},
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package lookup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	filename := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	return filename
+}
+
+func newMetric(tags map[string]string) telegraf.Metric {
+	return testutil.MustMetric("sqlserver",
+		tags,
+		map[string]interface{}{"value": int64(1)},
+		time.Unix(0, 0),
+	)
+}
+
+func TestApply(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name     string
+		format   string
+		content  string
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name:   "csv",
+			format: "csv",
+			content: "instance,team,cost_center\n" +
+				"db01,dba,cc-100\n" +
+				"db02,web,\n",
+			input:    newMetric(map[string]string{"sql_instance": "db01"}),
+			expected: newMetric(map[string]string{"sql_instance": "db01", "team": "dba", "cost_center": "cc-100"}),
+		},
+		{
+			name:   "csv empty cell",
+			format: "csv",
+			content: "instance,team,cost_center\n" +
+				"db01,dba,cc-100\n" +
+				"db02,web,\n",
+			input:    newMetric(map[string]string{"sql_instance": "db02"}),
+			expected: newMetric(map[string]string{"sql_instance": "db02", "team": "web"}),
+		},
+		{
+			name:     "json",
+			format:   "json",
+			content:  `{"db01": {"team": "dba", "cost_center": "cc-100"}}`,
+			input:    newMetric(map[string]string{"sql_instance": "db01"}),
+			expected: newMetric(map[string]string{"sql_instance": "db01", "team": "dba", "cost_center": "cc-100"}),
+		},
+		{
+			name:     "unknown key",
+			format:   "json",
+			content:  `{"db01": {"team": "dba"}}`,
+			input:    newMetric(map[string]string{"sql_instance": "db03"}),
+			expected: newMetric(map[string]string{"sql_instance": "db03"}),
+		},
+		{
+			name:     "missing key tag",
+			format:   "json",
+			content:  `{"db01": {"team": "dba"}}`,
+			input:    newMetric(map[string]string{"host": "db01"}),
+			expected: newMetric(map[string]string{"host": "db01"}),
+		},
+		{
+			name:     "existing tag is kept",
+			format:   "json",
+			content:  `{"db01": {"team": "dba"}}`,
+			input:    newMetric(map[string]string{"sql_instance": "db01", "team": "ops"}),
+			expected: newMetric(map[string]string{"sql_instance": "db01", "team": "ops"}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Lookup{
+				File:   writeFile(t, dir, "lookup."+tt.format, tt.content),
+				Format: tt.format,
+				KeyTag: "sql_instance",
+				Log:    testutil.Logger{},
+			}
+
+			actual := plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	plugin := &Lookup{
+		File:      writeFile(t, dir, "lookup.json", `{"db01": {"team": "dba"}}`),
+		Format:    "json",
+		KeyTag:    "sql_instance",
+		Overwrite: true,
+		Log:       testutil.Logger{},
+	}
+
+	actual := plugin.Apply(newMetric(map[string]string{"sql_instance": "db01", "team": "ops"}))
+	expected := []telegraf.Metric{
+		newMetric(map[string]string{"sql_instance": "db01", "team": "dba"}),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := writeFile(t, dir, "lookup.json", `{"db01": {"team": "dba"}}`)
+	plugin := &Lookup{
+		File:           filename,
+		Format:         "json",
+		KeyTag:         "sql_instance",
+		ReloadInterval: internal.Duration{Duration: time.Nanosecond},
+		Log:            testutil.Logger{},
+	}
+
+	actual := plugin.Apply(newMetric(map[string]string{"sql_instance": "db01"}))
+	require.Equal(t, "dba", actual[0].Tags()["team"])
+
+	writeFile(t, dir, "lookup.json", `{"db01": {"team": "ops"}}`)
+	mtime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filename, mtime, mtime))
+
+	actual = plugin.Apply(newMetric(map[string]string{"sql_instance": "db01"}))
+	require.Equal(t, "ops", actual[0].Tags()["team"])
+
+	// An invalid file keeps the previous table.
+	writeFile(t, dir, "lookup.json", `{"db01": `)
+	mtime = mtime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(filename, mtime, mtime))
+
+	actual = plugin.Apply(newMetric(map[string]string{"sql_instance": "db01"}))
+	require.Equal(t, "ops", actual[0].Tags()["team"])
+}
+
+func TestInvalidConfig(t *testing.T) {
+	plugin := &Lookup{
+		File:   "lookup.xml",
+		Format: "xml",
+		KeyTag: "sql_instance",
+		Log:    testutil.Logger{},
+	}
+
+	m := newMetric(map[string]string{"sql_instance": "db01"})
+	actual := plugin.Apply(m)
+	require.Error(t, plugin.err)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, actual)
+}
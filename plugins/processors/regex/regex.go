@@ -4,13 +4,18 @@ import (
 	"regexp"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
 type Regex struct {
-	Tags       []converter
-	Fields     []converter
-	regexCache map[string]*regexp.Regexp
+	Tags         []converter
+	Fields       []converter
+	MetricRename []converter     `toml:"metric_rename"`
+	Log          telegraf.Logger `toml:"-"`
+
+	regexCache  map[string]*regexp.Regexp
+	filterCache map[string]filter.Filter
 }
 
 type converter struct {
@@ -47,11 +52,24 @@ const sampleConfig = `
   #   pattern = ".*category=(\\w+).*"
   #   replacement = "${1}"
   #   result_key = "search_category"
+
+  ## The key may be a glob pattern to change several tags or fields
+  # [[processors.regex.fields]]
+  #   key = "*_path"
+  #   pattern = "/[0-9a-f]{8}-[0-9a-f-]{27}(/|$)"
+  #   replacement = "/{uuid}${1}"
+
+  ## Measurement names are changed in the metric_rename sub-tables, key is not
+  ## used
+  # [[processors.regex.metric_rename]]
+  #   pattern = "^(\\w+)_total$"
+  #   replacement = "${1}"
 `
 
 func NewRegex() *Regex {
 	return &Regex{
-		regexCache: make(map[string]*regexp.Regexp),
+		regexCache:  make(map[string]*regexp.Regexp),
+		filterCache: make(map[string]filter.Filter),
 	}
 }
 
@@ -65,19 +83,27 @@ func (r *Regex) Description() string {
 
 func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	for _, metric := range in {
+		for _, converter := range r.MetricRename {
+			if _, newValue := r.convert(converter, metric.Name()); newValue != "" {
+				metric.SetName(newValue)
+			}
+		}
+
 		for _, converter := range r.Tags {
-			if value, ok := metric.GetTag(converter.Key); ok {
-				if key, newValue := r.convert(converter, value); newValue != "" {
+			for _, key := range r.matchingKeys(converter, metric.TagList(), nil) {
+				value, _ := metric.GetTag(key)
+				if key, newValue := r.convert(converter.forKey(key), value); newValue != "" {
 					metric.AddTag(key, newValue)
 				}
 			}
 		}
 
 		for _, converter := range r.Fields {
-			if value, ok := metric.GetField(converter.Key); ok {
+			for _, key := range r.matchingKeys(converter, nil, metric.FieldList()) {
+				value, _ := metric.GetField(key)
 				switch value := value.(type) {
 				case string:
-					if key, newValue := r.convert(converter, value); newValue != "" {
+					if key, newValue := r.convert(converter.forKey(key), value); newValue != "" {
 						metric.AddField(key, newValue)
 					}
 				}
@@ -88,12 +114,56 @@ func (r *Regex) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	return in
 }
 
+// matchingKeys returns the keys of the tags or fields matching the key of the
+// converter, which may be a glob pattern.  The keys are collected before any
+// of them is changed.
+func (r *Regex) matchingKeys(c converter, tags []*telegraf.Tag, fields []*telegraf.Field) []string {
+	f, compiled := r.filterCache[c.Key]
+	if !compiled {
+		var err error
+		f, err = filter.Compile([]string{c.Key})
+		if err != nil {
+			r.Log.Errorf("Invalid key %q: %v", c.Key, err)
+		}
+		r.filterCache[c.Key] = f
+	}
+	if f == nil {
+		return nil
+	}
+
+	var keys []string
+	for _, tag := range tags {
+		if f.Match(tag.Key) {
+			keys = append(keys, tag.Key)
+		}
+	}
+	for _, field := range fields {
+		if f.Match(field.Key) {
+			keys = append(keys, field.Key)
+		}
+	}
+	return keys
+}
+
+// forKey returns the converter for one of the keys matching its key.
+func (c converter) forKey(key string) converter {
+	c.Key = key
+	return c
+}
+
 func (r *Regex) convert(c converter, src string) (string, string) {
 	regex, compiled := r.regexCache[c.Pattern]
 	if !compiled {
-		regex = regexp.MustCompile(c.Pattern)
+		var err error
+		regex, err = regexp.Compile(c.Pattern)
+		if err != nil {
+			r.Log.Errorf("Invalid pattern %q: %v", c.Pattern, err)
+		}
 		r.regexCache[c.Pattern] = regex
 	}
+	if regex == nil {
+		return c.Key, ""
+	}
 
 	value := ""
 	if c.ResultKey == "" || regex.MatchString(src) {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -247,6 +248,93 @@ func TestNoMatches(t *testing.T) {
 	}
 }
 
+func TestMetricRename(t *testing.T) {
+	tests := []struct {
+		message      string
+		converter    converter
+		expectedName string
+	}{
+		{
+			message: "Should change the measurement name",
+			converter: converter{
+				Pattern:     "^(\\w+)_log$",
+				Replacement: "${1}",
+			},
+			expectedName: "access",
+		},
+		{
+			message: "Should not change the measurement name if regex doesn't match",
+			converter: converter{
+				Pattern:     "^not_match$",
+				Replacement: "x",
+			},
+			expectedName: "access_log",
+		},
+	}
+
+	for _, test := range tests {
+		regex := NewRegex()
+		regex.MetricRename = []converter{
+			test.converter,
+		}
+
+		processed := regex.Apply(newM1())
+
+		assert.Equal(t, test.expectedName, processed[0].Name(), test.message)
+	}
+}
+
+func TestGlobKey(t *testing.T) {
+	regex := NewRegex()
+	regex.Tags = []converter{
+		{
+			Key:         "*",
+			Pattern:     "^(\\d)\\d\\d$",
+			Replacement: "${1}xx",
+		},
+	}
+	regex.Fields = []converter{
+		{
+			Key:         "*",
+			Pattern:     "\\?.*$",
+			Replacement: "",
+		},
+	}
+
+	processed := regex.Apply(newM2())
+
+	expectedTags := map[string]string{
+		"verb":      "GET",
+		"resp_code": "2xx",
+	}
+	expectedFields := map[string]interface{}{
+		"request":       "/api/search/",
+		"ignore_number": int64(200),
+		"ignore_bool":   true,
+	}
+	assert.Equal(t, expectedTags, processed[0].Tags())
+	assert.Equal(t, expectedFields, processed[0].Fields())
+}
+
+func TestInvalidPattern(t *testing.T) {
+	regex := NewRegex()
+	regex.Log = testutil.Logger{}
+	regex.Fields = []converter{
+		{
+			Key:         "request",
+			Pattern:     "(unclosed",
+			Replacement: "x",
+		},
+	}
+
+	processed := regex.Apply(newM1())
+
+	expectedFields := map[string]interface{}{
+		"request": "/users/42/",
+	}
+	assert.Equal(t, expectedFields, processed[0].Fields())
+}
+
 func BenchmarkConversions(b *testing.B) {
 	regex := NewRegex()
 	regex.Tags = []converter{
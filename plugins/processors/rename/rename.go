@@ -6,6 +6,26 @@ import (
 )
 
 const sampleConfig = `
+  ## Specify one sub-table per rename operation.
+  # [[processors.rename.replace]]
+  #   measurement = "network_interface_throughput"
+  #   dest = "throughput"
+
+  # [[processors.rename.replace]]
+  #   tag = "hostname"
+  #   dest = "host"
+
+  ## Many renames can be declared at once as a mapping from the old to the
+  ## new name.  These are applied after the replace operations.
+  # [processors.rename.measurements]
+  #   network_interface_throughput = "throughput"
+
+  # [processors.rename.tags]
+  #   hostname = "host"
+
+  # [processors.rename.fields]
+  #   lower = "min"
+  #   upper = "max"
 `
 
 type Replace struct {
@@ -16,7 +36,10 @@ type Replace struct {
 }
 
 type Rename struct {
-	Replaces []Replace `toml:"replace"`
+	Replaces     []Replace         `toml:"replace"`
+	Measurements map[string]string `toml:"measurements"`
+	Tags         map[string]string `toml:"tags"`
+	Fields       map[string]string `toml:"fields"`
 }
 
 func (r *Rename) SampleConfig() string {
@@ -57,11 +80,58 @@ func (r *Rename) Apply(in ...telegraf.Metric) []telegraf.Metric {
 				continue
 			}
 		}
+
+		if dest, ok := r.Measurements[point.Name()]; ok && dest != "" {
+			point.SetName(dest)
+		}
+		r.renameTags(point)
+		r.renameFields(point)
 	}
 
 	return in
 }
 
+// renameTags renames all tags found in the Tags mapping at once, so that
+// names can be swapped without renaming a tag twice.
+func (r *Rename) renameTags(point telegraf.Metric) {
+	if len(r.Tags) == 0 {
+		return
+	}
+
+	var renamed []*telegraf.Tag
+	for _, tag := range point.TagList() {
+		if dest, ok := r.Tags[tag.Key]; ok && dest != "" {
+			renamed = append(renamed, &telegraf.Tag{Key: tag.Key, Value: tag.Value})
+		}
+	}
+	for _, tag := range renamed {
+		point.RemoveTag(tag.Key)
+	}
+	for _, tag := range renamed {
+		point.AddTag(r.Tags[tag.Key], tag.Value)
+	}
+}
+
+// renameFields renames all fields found in the Fields mapping at once.
+func (r *Rename) renameFields(point telegraf.Metric) {
+	if len(r.Fields) == 0 {
+		return
+	}
+
+	var renamed []*telegraf.Field
+	for _, field := range point.FieldList() {
+		if dest, ok := r.Fields[field.Key]; ok && dest != "" {
+			renamed = append(renamed, &telegraf.Field{Key: field.Key, Value: field.Value})
+		}
+	}
+	for _, field := range renamed {
+		point.RemoveField(field.Key)
+	}
+	for _, field := range renamed {
+		point.AddField(r.Fields[field.Key], field.Value)
+	}
+}
+
 func init() {
 	processors.Add("rename", func() telegraf.Processor {
 		return &Rename{}
@@ -59,3 +59,29 @@ func TestFieldRename(t *testing.T) {
 
 	assert.Equal(t, map[string]interface{}{"time": int64(1250), "snakes": true}, results[0].Fields(), "should change field 'time_msec' to 'time'")
 }
+
+func TestMappingRename(t *testing.T) {
+	r := Rename{
+		Measurements: map[string]string{"network_interface_throughput": "throughput"},
+		Tags:         map[string]string{"hostname": "host", "dc": "region"},
+		Fields:       map[string]string{"lower": "min", "upper": "max"},
+	}
+	m := newMetric("network_interface_throughput",
+		map[string]string{"hostname": "localhost", "dc": "east-1"},
+		map[string]interface{}{"lower": int64(10), "upper": int64(1000), "mean": int64(500)})
+	results := r.Apply(m)
+
+	assert.Equal(t, "throughput", results[0].Name(), "should change name to 'throughput'")
+	assert.Equal(t, map[string]string{"host": "localhost", "region": "east-1"}, results[0].Tags())
+	assert.Equal(t, map[string]interface{}{"min": int64(10), "max": int64(1000), "mean": int64(500)}, results[0].Fields())
+}
+
+func TestMappingSwap(t *testing.T) {
+	r := Rename{
+		Fields: map[string]string{"min": "max", "max": "min"},
+	}
+	m := newMetric("foo", nil, map[string]interface{}{"min": int64(1000), "max": int64(10)})
+	results := r.Apply(m)
+
+	assert.Equal(t, map[string]interface{}{"min": int64(10), "max": int64(1000)}, results[0].Fields(), "should swap fields 'min' and 'max'")
+}
@@ -0,0 +1,178 @@
+package scale
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Each scaling applies the linear transformation
+  ##   output = input * factor + offset
+  ## to the numeric fields matching its field names, the output is a float.
+  ## A field is scaled by the first matching scaling only.
+  [[processors.scale.scaling]]
+    ## Field names to scale, glob patterns are supported.
+    fields = ["*_bytes"]
+
+    ## Factor and offset of the transformation, the factor defaults to 1.
+    factor = 0.000001
+    # offset = 0.0
+
+    ## Clamp the input to this range before scaling it.
+    # input_minimum = 0.0
+    # input_maximum = 1000000000.0
+
+    ## Rename the field when its name ends with old_unit, replacing it with
+    ## new_unit.  If old_unit is empty, new_unit is appended to the name.
+    old_unit = "_bytes"
+    new_unit = "_mb"
+`
+
+type Scaling struct {
+	Fields       []string `toml:"fields"`
+	Factor       *float64 `toml:"factor"`
+	Offset       float64  `toml:"offset"`
+	InputMinimum *float64 `toml:"input_minimum"`
+	InputMaximum *float64 `toml:"input_maximum"`
+	OldUnit      string   `toml:"old_unit"`
+	NewUnit      string   `toml:"new_unit"`
+
+	filter filter.Filter
+}
+
+type Scale struct {
+	Scalings []*Scaling      `toml:"scaling"`
+	Log      telegraf.Logger `toml:"-"`
+
+	initialized bool
+	err         error
+}
+
+func (s *Scale) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Scale) Description() string {
+	return "Scale numeric fields with a factor and offset and rename their unit"
+}
+
+func (s *Scale) init() error {
+	for _, scaling := range s.Scalings {
+		if len(scaling.Fields) == 0 {
+			return errors.New("fields are required")
+		}
+		if scaling.InputMinimum != nil && scaling.InputMaximum != nil &&
+			*scaling.InputMinimum > *scaling.InputMaximum {
+			return fmt.Errorf("input_minimum %v is greater than input_maximum %v",
+				*scaling.InputMinimum, *scaling.InputMaximum)
+		}
+
+		var err error
+		scaling.filter, err = filter.Compile(scaling.Fields)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Scale) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !s.initialized {
+		s.initialized = true
+		s.err = s.init()
+		if s.err != nil {
+			s.Log.Errorf("Invalid configuration, metrics are not processed: %v", s.err)
+		}
+	}
+	if s.err != nil {
+		return in
+	}
+
+	for _, point := range in {
+		// The field list is modified when renaming fields.
+		fields := make([]*telegraf.Field, 0, len(point.FieldList()))
+		for _, field := range point.FieldList() {
+			fields = append(fields, &telegraf.Field{Key: field.Key, Value: field.Value})
+		}
+
+		for _, field := range fields {
+			scaling := s.scaling(field.Key)
+			if scaling == nil {
+				continue
+			}
+
+			value, ok := toFloat(field.Value)
+			if !ok {
+				s.Log.Debugf("Field %q of %q is not numeric, not scaling it", field.Key, point.Name())
+				continue
+			}
+
+			key := scaling.rename(field.Key)
+			if key != field.Key {
+				point.RemoveField(field.Key)
+			}
+			point.AddField(key, scaling.apply(value))
+		}
+	}
+
+	return in
+}
+
+func (s *Scale) scaling(key string) *Scaling {
+	for _, scaling := range s.Scalings {
+		if scaling.filter.Match(key) {
+			return scaling
+		}
+	}
+	return nil
+}
+
+func (c *Scaling) apply(value float64) float64 {
+	if c.InputMinimum != nil && value < *c.InputMinimum {
+		value = *c.InputMinimum
+	}
+	if c.InputMaximum != nil && value > *c.InputMaximum {
+		value = *c.InputMaximum
+	}
+	factor := 1.0
+	if c.Factor != nil {
+		factor = *c.Factor
+	}
+	return value*factor + c.Offset
+}
+
+func (c *Scaling) rename(key string) string {
+	if c.NewUnit == "" && c.OldUnit == "" {
+		return key
+	}
+	if c.OldUnit == "" {
+		return key + c.NewUnit
+	}
+	if strings.HasSuffix(key, c.OldUnit) {
+		return strings.TrimSuffix(key, c.OldUnit) + c.NewUnit
+	}
+	return key
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch value := v.(type) {
+	case int64:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+func init() {
+	processors.Add("scale", func() telegraf.Processor {
+		return &Scale{}
+	})
+}
@@ -0,0 +1,225 @@
+package scale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/require"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		scalings []*Scaling
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name: "factor and unit",
+			scalings: []*Scaling{
+				{
+					Fields:  []string{"*_bytes"},
+					Factor:  float(0.000001),
+					OldUnit: "_bytes",
+					NewUnit: "_mb",
+				},
+			},
+			input: testutil.MustMetric("net",
+				map[string]string{},
+				map[string]interface{}{
+					"recv_bytes": uint64(3000000),
+					"sent_bytes": int64(1500000),
+					"packets":    int64(42),
+				},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("net",
+				map[string]string{},
+				map[string]interface{}{
+					"recv_mb": 3.0,
+					"sent_mb": 1.5,
+					"packets": int64(42),
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "offset",
+			scalings: []*Scaling{
+				{
+					Fields: []string{"temp"},
+					Factor: float(1.8),
+					Offset: 32,
+				},
+			},
+			input: testutil.MustMetric("sensor",
+				map[string]string{},
+				map[string]interface{}{"temp": 100.0},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("sensor",
+				map[string]string{},
+				map[string]interface{}{"temp": 212.0},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "default factor and appended unit",
+			scalings: []*Scaling{
+				{
+					Fields:  []string{"amount"},
+					Offset:  1,
+					NewUnit: "_total",
+				},
+			},
+			input: testutil.MustMetric("sales",
+				map[string]string{},
+				map[string]interface{}{"amount": int64(10)},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("sales",
+				map[string]string{},
+				map[string]interface{}{"amount_total": 11.0},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "clamping",
+			scalings: []*Scaling{
+				{
+					Fields:       []string{"*"},
+					Factor:       float(0.01),
+					InputMinimum: float(0),
+					InputMaximum: float(100),
+				},
+			},
+			input: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{
+					"low":  -5.0,
+					"mid":  50.0,
+					"high": 150.0,
+				},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{
+					"low":  0.0,
+					"mid":  0.5,
+					"high": 1.0,
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "first matching scaling",
+			scalings: []*Scaling{
+				{
+					Fields: []string{"price_cents"},
+					Factor: float(0.01),
+				},
+				{
+					Fields: []string{"price_*"},
+					Factor: float(100),
+				},
+			},
+			input: testutil.MustMetric("sales",
+				map[string]string{},
+				map[string]interface{}{
+					"price_cents": int64(250),
+					"price_other": int64(2),
+				},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("sales",
+				map[string]string{},
+				map[string]interface{}{
+					"price_cents": 2.5,
+					"price_other": 200.0,
+				},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "non numeric fields are unchanged",
+			scalings: []*Scaling{
+				{
+					Fields: []string{"*"},
+					Factor: float(2),
+				},
+			},
+			input: testutil.MustMetric("status",
+				map[string]string{},
+				map[string]interface{}{
+					"state": "ok",
+					"up":    true,
+				},
+				time.Unix(0, 0),
+			),
+			expected: testutil.MustMetric("status",
+				map[string]string{},
+				map[string]interface{}{
+					"state": "ok",
+					"up":    true,
+				},
+				time.Unix(0, 0),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Scale{
+				Scalings: tt.scalings,
+				Log:      testutil.Logger{},
+			}
+
+			actual := plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestInvalidRange(t *testing.T) {
+	plugin := &Scale{
+		Scalings: []*Scaling{
+			{
+				Fields:       []string{"*"},
+				InputMinimum: float(10),
+				InputMaximum: float(0),
+			},
+		},
+		Log: testutil.Logger{},
+	}
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 5.0},
+		time.Unix(0, 0),
+	)
+	actual := plugin.Apply(m)
+	require.Error(t, plugin.err)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, actual)
+}
+
+func TestConfig(t *testing.T) {
+	config := `
+[[scaling]]
+  fields = ["*_bytes"]
+  factor = 0.000001
+  input_maximum = 100.0
+`
+	plugin := &Scale{}
+	require.NoError(t, toml.Unmarshal([]byte(config), plugin))
+	require.Len(t, plugin.Scalings, 1)
+	require.Equal(t, 0.000001, *plugin.Scalings[0].Factor)
+	require.Nil(t, plugin.Scalings[0].InputMinimum)
+	require.Equal(t, 100.0, *plugin.Scalings[0].InputMaximum)
+}
@@ -0,0 +1,218 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+// dict is implemented by the tags and fields of a metric, its methods are
+// the ones of the Starlark dict that apply to them.
+type dict interface {
+	starlark.HasSetKey
+	Items() []starlark.Tuple
+	Len() int
+	remove(key starlark.Value) (starlark.Value, bool, error)
+}
+
+var dictMethods = map[string]func(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error){
+	"clear":  dictClear,
+	"get":    dictGet,
+	"items":  dictItems,
+	"keys":   dictKeys,
+	"pop":    dictPop,
+	"update": dictUpdate,
+	"values": dictValues,
+}
+
+func dictAttrNames() []string {
+	names := make([]string, 0, len(dictMethods))
+	for name := range dictMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func dictAttr(d dict, name string) (starlark.Value, error) {
+	method, ok := dictMethods[name]
+	if !ok {
+		return nil, nil
+	}
+	return starlark.NewBuiltin(name, func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		return method(d, b, args, kwargs)
+	}).BindReceiver(d), nil
+}
+
+// dictString formats the items like a Starlark dict.
+func dictString(d dict) string {
+	var buf strings.Builder
+	buf.WriteString("{")
+	for i, item := range d.Items() {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(item[0].String())
+		buf.WriteString(": ")
+		buf.WriteString(item[1].String())
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+func dictClear(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	for _, item := range d.Items() {
+		if _, _, err := d.remove(item[0]); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.None, nil
+}
+
+func dictGet(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value = nil, starlark.None
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := d.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return dflt, nil
+	}
+	return v, nil
+}
+
+func dictItems(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	list := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictKeys(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	list := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		list = append(list, item[0])
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictPop(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := d.remove(key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return v, nil
+	}
+	if dflt != nil {
+		return dflt, nil
+	}
+	return nil, fmt.Errorf("%s: missing key %s", b.Name(), key)
+}
+
+func dictUpdate(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("%s: got %d arguments, want at most 1", b.Name(), len(args))
+	}
+	if len(args) == 1 {
+		switch updates := args[0].(type) {
+		case starlark.IterableMapping:
+			for _, item := range updates.Items() {
+				if err := d.SetKey(item[0], item[1]); err != nil {
+					return nil, err
+				}
+			}
+		case starlark.Iterable:
+			iter := updates.Iterate()
+			defer iter.Done()
+			var pair starlark.Value
+			for iter.Next(&pair) {
+				kv, ok := pair.(starlark.Tuple)
+				if !ok || len(kv) != 2 {
+					return nil, fmt.Errorf("%s: element is not a pair", b.Name())
+				}
+				if err := d.SetKey(kv[0], kv[1]); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: got %s, want iterable", b.Name(), args[0].Type())
+		}
+	}
+	for _, kv := range kwargs {
+		if err := d.SetKey(kv[0], kv[1]); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.None, nil
+}
+
+func dictValues(d dict, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := d.Items()
+	list := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		list = append(list, item[1])
+	}
+	return starlark.NewList(list), nil
+}
+
+// keyIterator iterates over a copy of the keys, the dict can be modified
+// while iterating.
+type keyIterator struct {
+	keys []starlark.Value
+}
+
+func newKeyIterator(d dict) *keyIterator {
+	items := d.Items()
+	keys := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item[0])
+	}
+	return &keyIterator{keys: keys}
+}
+
+func (it *keyIterator) Next(p *starlark.Value) bool {
+	if len(it.keys) == 0 {
+		return false
+	}
+	*p = it.keys[0]
+	it.keys = it.keys[1:]
+	return true
+}
+
+func (it *keyIterator) Done() {}
+
+func keyString(key starlark.Value) (string, error) {
+	k, ok := key.(starlark.String)
+	if !ok {
+		return "", fmt.Errorf("type error: key must be a str, not %s", key.Type())
+	}
+	return string(k), nil
+}
+
+var errFrozen = errors.New("cannot modify frozen metric")
@@ -0,0 +1,149 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+// FieldDict is the fields of a metric, a dict of int, float, str or bool.
+type FieldDict struct {
+	sm *Metric
+}
+
+func (d *FieldDict) String() string {
+	return dictString(d)
+}
+
+func (d *FieldDict) Type() string {
+	return "Fields"
+}
+
+func (d *FieldDict) Freeze() {
+	d.sm.Freeze()
+}
+
+func (d *FieldDict) Truth() starlark.Bool {
+	return len(d.sm.metric.FieldList()) != 0
+}
+
+func (d *FieldDict) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+func (d *FieldDict) AttrNames() []string {
+	return dictAttrNames()
+}
+
+func (d *FieldDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+func (d *FieldDict) Len() int {
+	return len(d.sm.metric.FieldList())
+}
+
+func (d *FieldDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	k, err := keyString(key)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := d.sm.metric.GetField(k)
+	if !ok {
+		return starlark.None, false, nil
+	}
+	sv, err := asStarlarkValue(v)
+	if err != nil {
+		return nil, false, err
+	}
+	return sv, true, nil
+}
+
+func (d *FieldDict) SetKey(key, value starlark.Value) error {
+	if d.sm.frozen {
+		return errFrozen
+	}
+	k, err := keyString(key)
+	if err != nil {
+		return err
+	}
+	v, err := asGoValue(value)
+	if err != nil {
+		return err
+	}
+	d.sm.metric.AddField(k, v)
+	return nil
+}
+
+func (d *FieldDict) Items() []starlark.Tuple {
+	items := make([]starlark.Tuple, 0, len(d.sm.metric.FieldList()))
+	for _, field := range d.sm.metric.FieldList() {
+		sv, err := asStarlarkValue(field.Value)
+		if err != nil {
+			continue
+		}
+		items = append(items, starlark.Tuple{starlark.String(field.Key), sv})
+	}
+	return items
+}
+
+func (d *FieldDict) Iterate() starlark.Iterator {
+	return newKeyIterator(d)
+}
+
+func (d *FieldDict) remove(key starlark.Value) (starlark.Value, bool, error) {
+	if d.sm.frozen {
+		return nil, false, errFrozen
+	}
+	k, err := keyString(key)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := d.sm.metric.GetField(k)
+	if !ok {
+		return starlark.None, false, nil
+	}
+	sv, err := asStarlarkValue(v)
+	if err != nil {
+		return nil, false, err
+	}
+	d.sm.metric.RemoveField(k)
+	return sv, true, nil
+}
+
+func asStarlarkValue(v interface{}) (starlark.Value, error) {
+	switch v := v.(type) {
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case uint64:
+		return starlark.MakeUint64(v), nil
+	case float64:
+		return starlark.Float(v), nil
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	}
+	return nil, fmt.Errorf("unsupported field type: %T", v)
+}
+
+func asGoValue(v starlark.Value) (interface{}, error) {
+	switch v := v.(type) {
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		if u, ok := v.Uint64(); ok {
+			return u, nil
+		}
+		return nil, errors.New("value error: field value out of range")
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return string(v), nil
+	case starlark.Bool:
+		return bool(v), nil
+	}
+	return nil, fmt.Errorf("type error: field value must be an int, float, str or bool, not %s", v.Type())
+}
@@ -0,0 +1,122 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"go.starlark.net/starlark"
+)
+
+// Metric is the Starlark value of a telegraf metric.  Its name, tags, fields
+// and time can be read and changed by the script.
+type Metric struct {
+	metric telegraf.Metric
+	frozen bool
+}
+
+func (m *Metric) String() string {
+	return fmt.Sprintf("Metric(%q, tags=%s, fields=%s, time=%d)",
+		m.metric.Name(), m.Tags().String(), m.Fields().String(),
+		m.metric.Time().UnixNano())
+}
+
+func (m *Metric) Type() string {
+	return "Metric"
+}
+
+func (m *Metric) Freeze() {
+	m.frozen = true
+}
+
+func (m *Metric) Truth() starlark.Bool {
+	return true
+}
+
+func (m *Metric) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+func (m *Metric) AttrNames() []string {
+	return []string{"name", "tags", "fields", "time"}
+}
+
+func (m *Metric) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "name":
+		return starlark.String(m.metric.Name()), nil
+	case "tags":
+		return m.Tags(), nil
+	case "fields":
+		return m.Fields(), nil
+	case "time":
+		return starlark.MakeInt64(m.metric.Time().UnixNano()), nil
+	}
+	// Unknown attributes are reported by the interpreter.
+	return nil, nil
+}
+
+func (m *Metric) SetField(name string, value starlark.Value) error {
+	if m.frozen {
+		return errFrozen
+	}
+
+	switch name {
+	case "name":
+		v, ok := value.(starlark.String)
+		if !ok {
+			return fmt.Errorf("type error: name must be a str, not %s", value.Type())
+		}
+		m.metric.SetName(string(v))
+		return nil
+	case "time":
+		v, ok := value.(starlark.Int)
+		if !ok {
+			return fmt.Errorf("type error: time must be an int, not %s", value.Type())
+		}
+		ns, ok := v.Int64()
+		if !ok {
+			return errors.New("value error: time out of range")
+		}
+		m.metric.SetTime(time.Unix(0, ns))
+		return nil
+	case "tags", "fields":
+		return fmt.Errorf("cannot set %s, modify its items instead", name)
+	}
+	return starlark.NoSuchAttrError(
+		fmt.Sprintf("cannot assign to field '%s'", name))
+}
+
+func (m *Metric) Tags() *TagDict {
+	return &TagDict{m}
+}
+
+func (m *Metric) Fields() *FieldDict {
+	return &FieldDict{m}
+}
+
+// newMetric is the Metric builtin, it returns a new metric with the name,
+// no tags or fields and the current time.
+func newMetric(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name starlark.String
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &name); err != nil {
+		return nil, err
+	}
+
+	m, err := metric.New(string(name), nil, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &Metric{metric: m}, nil
+}
+
+// deepcopy is the deepcopy builtin, it returns a copy of the metric.
+func deepcopy(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var sm *Metric
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &sm); err != nil {
+		return nil, err
+	}
+	return &Metric{metric: sm.metric.Copy()}, nil
+}
@@ -0,0 +1,191 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+const sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def apply(metric):
+	return metric
+'''
+
+  ## File containing a Starlark script.
+  # script = "/usr/local/bin/myscript.star"
+`
+
+type Starlark struct {
+	Source string
+	Script string
+	Log    telegraf.Logger `toml:"-"`
+
+	initialized bool
+	err         error
+
+	thread    *starlark.Thread
+	applyFunc *starlark.Function
+	args      starlark.Tuple
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Description() string {
+	return "Process metrics using a Starlark script"
+}
+
+// Apply calls the apply function of the script with each metric.  The
+// function returns the metric, a list of metrics or None to drop it.
+func (s *Starlark) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !s.initialized {
+		s.initialized = true
+		s.err = s.compile()
+		if s.err != nil {
+			s.Log.Errorf("Could not load script, metrics are not processed: %v", s.err)
+		}
+	}
+	if s.err != nil {
+		return in
+	}
+
+	results := make([]telegraf.Metric, 0, len(in))
+	for _, metric := range in {
+		out, err := s.apply(metric)
+		if err != nil {
+			s.logError(err)
+			metric.Reject()
+			continue
+		}
+		results = append(results, out...)
+	}
+	return results
+}
+
+// compile executes the script and looks up its apply function.
+func (s *Starlark) compile() error {
+	var src interface{}
+	filename := s.Script
+	switch {
+	case s.Source != "" && s.Script != "":
+		return errors.New("both source or script cannot be set")
+	case s.Source == "" && s.Script == "":
+		return errors.New("one of source or script must be set")
+	case s.Source != "":
+		src = s.Source
+		filename = "processor.star"
+	}
+
+	s.thread = &starlark.Thread{
+		Print: func(_ *starlark.Thread, msg string) { s.Log.Debug(msg) },
+	}
+
+	// The state dict is not frozen with the globals of the script, it keeps
+	// values between the calls of apply.
+	predeclared := starlark.StringDict{
+		"Metric":   starlark.NewBuiltin("Metric", newMetric),
+		"deepcopy": starlark.NewBuiltin("deepcopy", deepcopy),
+		"state":    starlark.NewDict(0),
+	}
+
+	globals, err := starlark.ExecFile(s.thread, filename, src, predeclared)
+	if err != nil {
+		return err
+	}
+
+	apply, ok := globals["apply"]
+	if !ok {
+		return errors.New("apply is not defined")
+	}
+	s.applyFunc, ok = apply.(*starlark.Function)
+	if !ok {
+		return errors.New("apply is not a function")
+	}
+	if s.applyFunc.NumParams() != 1 {
+		return errors.New("apply function must take one parameter")
+	}
+
+	s.args = make(starlark.Tuple, 1)
+	return nil
+}
+
+// apply returns the metrics the apply function returns for the metric.  The
+// metric is dropped if it is not part of them.
+func (s *Starlark) apply(metric telegraf.Metric) ([]telegraf.Metric, error) {
+	s.args[0] = &Metric{metric: metric}
+	rv, err := starlark.Call(s.thread, s.applyFunc, s.args, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []starlark.Value
+	switch rv := rv.(type) {
+	case starlark.NoneType:
+	case *Metric:
+		values = append(values, rv)
+	case *starlark.List:
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, rv.Index(i))
+		}
+	case starlark.Tuple:
+		values = rv
+	default:
+		return nil, fmt.Errorf("invalid type returned: %s", rv.Type())
+	}
+
+	var results []telegraf.Metric
+	seen := make(map[telegraf.Metric]bool, len(values))
+	for _, v := range values {
+		m, ok := v.(*Metric)
+		if !ok {
+			return nil, fmt.Errorf("invalid type in returned list: %s", v.Type())
+		}
+		// A metric returned more than once is copied.
+		if seen[m.metric] {
+			results = append(results, m.metric.Copy())
+			continue
+		}
+		seen[m.metric] = true
+		results = append(results, m.metric)
+	}
+
+	if !seen[metric] {
+		metric.Drop()
+	}
+	return results, nil
+}
+
+func (s *Starlark) logError(err error) {
+	if err, ok := err.(*starlark.EvalError); ok {
+		for _, line := range strings.Split(err.Backtrace(), "\n") {
+			s.Log.Error(line)
+		}
+		return
+	}
+	s.Log.Error(err)
+}
+
+func init() {
+	// Enable the optional features of the language that are useful to
+	// process metrics.
+	resolve.AllowFloat = true
+	resolve.AllowLambda = true
+	resolve.AllowNestedDef = true
+	resolve.AllowSet = true
+
+	processors.Add("starlark", func() telegraf.Processor {
+		return &Starlark{}
+	})
+}
@@ -0,0 +1,343 @@
+package starlark
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name: "passthrough",
+			source: `
+def apply(metric):
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "localhost"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "localhost"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "rename and set time",
+			source: `
+def apply(metric):
+	metric.name = "processor"
+	metric.time = 42
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("processor",
+					map[string]string{},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 42),
+				),
+			},
+		},
+		{
+			name: "tags",
+			source: `
+def apply(metric):
+	if "core" in metric.tags:
+		metric.tags["cpu"] = metric.tags.pop("core")
+	metric.tags.update(env="prod")
+	for k in metric.tags:
+		if k.startswith("tmp_"):
+			metric.tags.pop(k)
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"core":  "cpu0",
+						"tmp_a": "a",
+						"tmp_b": "b",
+						"host":  "localhost",
+					},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{
+						"cpu":  "cpu0",
+						"env":  "prod",
+						"host": "localhost",
+					},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "math across fields",
+			source: `
+def apply(metric):
+	total = metric.fields["used"] + metric.fields["free"]
+	metric.fields["used_percent"] = 100.0 * metric.fields["used"] / total
+	metric.fields["total"] = total
+	metric.fields["ok"] = total > 0
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{
+						"used": int64(25),
+						"free": int64(75),
+					},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{
+						"used":         int64(25),
+						"free":         int64(75),
+						"used_percent": 25.0,
+						"total":        int64(100),
+						"ok":           true,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "conditional drop",
+			source: `
+def apply(metric):
+	if metric.fields.get("value", 0) < 0:
+		return None
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("a",
+					map[string]string{},
+					map[string]interface{}{"value": int64(-1)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("b",
+					map[string]string{},
+					map[string]interface{}{"value": int64(1)},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("b",
+					map[string]string{},
+					map[string]interface{}{"value": int64(1)},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "state",
+			source: `
+def apply(metric):
+	last = state.get("last")
+	state["last"] = metric.fields["value"]
+	if last != None:
+		metric.fields["delta"] = metric.fields["value"] - last
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{"value": int64(10)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{"value": int64(15)},
+					time.Unix(10, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{"value": int64(10)},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("counter",
+					map[string]string{},
+					map[string]interface{}{
+						"value": int64(15),
+						"delta": int64(5),
+					},
+					time.Unix(10, 0),
+				),
+			},
+		},
+		{
+			name: "new metrics",
+			source: `
+def apply(metric):
+	copy = deepcopy(metric)
+	copy.name = "copy"
+	extra = Metric("extra")
+	extra.fields["value"] = 1.5
+	extra.time = metric.time
+	return [metric, copy, extra]
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "localhost"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "localhost"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("copy",
+					map[string]string{"host": "localhost"},
+					map[string]interface{}{"time_idle": 42.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("extra",
+					map[string]string{},
+					map[string]interface{}{"value": 1.5},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "runtime error drops metric",
+			source: `
+def apply(metric):
+	metric.fields["ratio"] = metric.fields["a"] / metric.fields["b"]
+	return metric
+`,
+			input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"a": 1.0, "b": 0.0},
+					time.Unix(0, 0),
+				),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"a": 1.0, "b": 2.0},
+					time.Unix(0, 0),
+				),
+			},
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"a": 1.0, "b": 2.0, "ratio": 0.5},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Starlark{
+				Source: tt.source,
+				Log:    testutil.Logger{},
+			}
+
+			actual := plugin.Apply(tt.input...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestApplyInvalidScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{
+			name:   "syntax error",
+			source: "def apply(metric):\n\treturn metric +\n",
+		},
+		{
+			name:   "no apply function",
+			source: "def process(metric):\n\treturn metric\n",
+		},
+		{
+			name:   "apply with two parameters",
+			source: "def apply(metric, other):\n\treturn metric\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Starlark{
+				Source: tt.source,
+				Log:    testutil.Logger{},
+			}
+
+			m := testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"time_idle": 42.0},
+				time.Unix(0, 0),
+			)
+			actual := plugin.Apply(m)
+			require.Error(t, plugin.err)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, actual)
+		})
+	}
+}
+
+func TestApplyScriptFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "telegraf")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("def apply(metric):\n\tmetric.tags[\"processed\"] = \"true\"\n\treturn metric\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	plugin := &Starlark{
+		Script: f.Name(),
+		Log:    testutil.Logger{},
+	}
+
+	actual := plugin.Apply(
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(0, 0),
+		),
+	)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"processed": "true"},
+			map[string]interface{}{"time_idle": 42.0},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
@@ -0,0 +1,104 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+// TagDict is the tags of a metric, a dict of str.
+type TagDict struct {
+	sm *Metric
+}
+
+func (d *TagDict) String() string {
+	return dictString(d)
+}
+
+func (d *TagDict) Type() string {
+	return "Tags"
+}
+
+func (d *TagDict) Freeze() {
+	d.sm.Freeze()
+}
+
+func (d *TagDict) Truth() starlark.Bool {
+	return len(d.sm.metric.TagList()) != 0
+}
+
+func (d *TagDict) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+func (d *TagDict) AttrNames() []string {
+	return dictAttrNames()
+}
+
+func (d *TagDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+func (d *TagDict) Len() int {
+	return len(d.sm.metric.TagList())
+}
+
+func (d *TagDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	k, err := keyString(key)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := d.sm.metric.GetTag(k)
+	if !ok {
+		return starlark.None, false, nil
+	}
+	return starlark.String(v), true, nil
+}
+
+func (d *TagDict) SetKey(key, value starlark.Value) error {
+	if d.sm.frozen {
+		return errFrozen
+	}
+	k, err := keyString(key)
+	if err != nil {
+		return err
+	}
+	v, ok := value.(starlark.String)
+	if !ok {
+		return fmt.Errorf("type error: tag value must be a str, not %s", value.Type())
+	}
+	d.sm.metric.AddTag(k, string(v))
+	return nil
+}
+
+func (d *TagDict) Items() []starlark.Tuple {
+	items := make([]starlark.Tuple, 0, len(d.sm.metric.TagList()))
+	for _, tag := range d.sm.metric.TagList() {
+		items = append(items, starlark.Tuple{
+			starlark.String(tag.Key),
+			starlark.String(tag.Value),
+		})
+	}
+	return items
+}
+
+func (d *TagDict) Iterate() starlark.Iterator {
+	return newKeyIterator(d)
+}
+
+func (d *TagDict) remove(key starlark.Value) (starlark.Value, bool, error) {
+	if d.sm.frozen {
+		return nil, false, errFrozen
+	}
+	k, err := keyString(key)
+	if err != nil {
+		return nil, false, err
+	}
+	v, ok := d.sm.metric.GetTag(k)
+	if !ok {
+		return starlark.None, false, nil
+	}
+	d.sm.metric.RemoveTag(k)
+	return starlark.String(v), true, nil
+}
@@ -1,22 +1,28 @@
 package strings
 
 import (
+	"encoding/base64"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
 type Strings struct {
-	Lowercase  []converter `toml:"lowercase"`
-	Uppercase  []converter `toml:"uppercase"`
-	Trim       []converter `toml:"trim"`
-	TrimLeft   []converter `toml:"trim_left"`
-	TrimRight  []converter `toml:"trim_right"`
-	TrimPrefix []converter `toml:"trim_prefix"`
-	TrimSuffix []converter `toml:"trim_suffix"`
-	Replace    []converter `toml:"replace"`
+	Lowercase    []converter `toml:"lowercase"`
+	Uppercase    []converter `toml:"uppercase"`
+	Trim         []converter `toml:"trim"`
+	TrimLeft     []converter `toml:"trim_left"`
+	TrimRight    []converter `toml:"trim_right"`
+	TrimPrefix   []converter `toml:"trim_prefix"`
+	TrimSuffix   []converter `toml:"trim_suffix"`
+	Replace      []converter `toml:"replace"`
+	Left         []converter `toml:"left"`
+	Base64Decode []converter `toml:"base64decode"`
+
+	Log telegraf.Logger `toml:"-"`
 
 	converters []converter
 	init       bool
@@ -34,6 +40,7 @@ type converter struct {
 	Prefix      string
 	Old         string
 	New         string
+	Width       int
 
 	fn ConvertFunc
 }
@@ -77,6 +84,15 @@ const sampleConfig = `
   #   measurement = "*"
   #   old = ":"
   #   new = "_"
+
+  ## Trims strings based on width
+  # [[processors.strings.left]]
+  #   field = "message"
+  #   width = 10
+
+  ## Decode a base64 encoded utf-8 string
+  # [[processors.strings.base64decode]]
+  #   field = "message"
 `
 
 func (s *Strings) SampleConfig() string {
@@ -218,6 +234,31 @@ func (s *Strings) initOnce() {
 		}
 		s.converters = append(s.converters, c)
 	}
+	for _, c := range s.Left {
+		c := c
+		if c.Width <= 0 {
+			s.Log.Errorf("Skipping left converter, width must be greater than 0, got %d", c.Width)
+			continue
+		}
+		c.fn = func(s string) string {
+			if utf8.RuneCountInString(s) <= c.Width {
+				return s
+			}
+			return string([]rune(s)[:c.Width])
+		}
+		s.converters = append(s.converters, c)
+	}
+	for _, c := range s.Base64Decode {
+		c := c
+		c.fn = func(s string) string {
+			data, err := base64.StdEncoding.DecodeString(s)
+			if err != nil || !utf8.Valid(data) {
+				return s
+			}
+			return string(data)
+		}
+		s.converters = append(s.converters, c)
+	}
 
 	s.init = true
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -543,3 +544,122 @@ func TestMeasurementCharDeletion(t *testing.T) {
 	assert.Equal(t, "foofoofoo", results[1].Name(), "Should have refused to delete the whole string")
 	assert.Equal(t, "barbarbar", results[2].Name(), "Should not have changed the input")
 }
+
+func TestLeft(t *testing.T) {
+	tests := []struct {
+		name     string
+		width    int
+		value    string
+		expected string
+	}{
+		{
+			name:     "truncate",
+			width:    6,
+			value:    "/mixed/CASE/paTH/",
+			expected: "/mixed",
+		},
+		{
+			name:     "shorter than width",
+			width:    40,
+			value:    "/mixed/CASE/paTH/",
+			expected: "/mixed/CASE/paTH/",
+		},
+		{
+			name:     "multibyte characters",
+			width:    3,
+			value:    "héllo",
+			expected: "hél",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Strings{
+				Left: []converter{
+					{
+						Field: "message",
+						Width: tt.width,
+					},
+				},
+			}
+
+			m := testutil.MustMetric("cpu",
+				map[string]string{},
+				map[string]interface{}{"message": tt.value},
+				time.Unix(0, 0),
+			)
+			metrics := plugin.Apply(m)
+			fv, ok := metrics[0].GetField("message")
+			require.True(t, ok)
+			require.Equal(t, tt.expected, fv)
+		})
+	}
+}
+
+func TestLeftInvalidWidth(t *testing.T) {
+	for _, width := range []int{0, -1} {
+		plugin := &Strings{
+			Left: []converter{
+				{
+					Field: "message",
+					Width: width,
+				},
+			},
+			Log: testutil.Logger{},
+		}
+
+		m := testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"message": "/mixed/CASE/paTH/"},
+			time.Unix(0, 0),
+		)
+		metrics := plugin.Apply(m)
+		fv, ok := metrics[0].GetField("message")
+		require.True(t, ok)
+		require.Equal(t, "/mixed/CASE/paTH/", fv)
+	}
+}
+
+func TestBase64Decode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:     "base64",
+			value:    "aG93ZHk=",
+			expected: "howdy",
+		},
+		{
+			name:     "invalid base64",
+			value:    "_not_base64_",
+			expected: "_not_base64_",
+		},
+		{
+			name:     "invalid utf-8",
+			value:    "/w==",
+			expected: "/w==",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Strings{
+				Base64Decode: []converter{
+					{
+						Tag: "message",
+					},
+				},
+			}
+
+			m := testutil.MustMetric("cpu",
+				map[string]string{"message": tt.value},
+				map[string]interface{}{"value": 42.0},
+				time.Unix(0, 0),
+			)
+			metrics := plugin.Apply(m)
+			tv, ok := metrics[0].GetTag("message")
+			require.True(t, ok)
+			require.Equal(t, tt.expected, tv)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package template
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Tag to set with the output of the template.
+  tag = "topic"
+
+  ## Go template used to create the tag value.  In order to ease TOML
+  ## escaping requirements, you may wish to use single quotes around the
+  ## template string.
+  template = '{{ .Tag "hostname" }}.{{ .Tag "level" }}'
+`
+
+type TemplateProcessor struct {
+	Tag      string          `toml:"tag"`
+	Template string          `toml:"template"`
+	Log      telegraf.Logger `toml:"-"`
+
+	initialized bool
+	err         error
+	tmpl        *template.Template
+}
+
+func (r *TemplateProcessor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *TemplateProcessor) Description() string {
+	return "Uses a Go template to create a new tag"
+}
+
+// Apply sets the tag to the output of the template executed with each
+// metric.  The tag is not set if the output is empty.
+func (r *TemplateProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	if !r.initialized {
+		r.initialized = true
+		r.tmpl, r.err = template.New("template").Parse(r.Template)
+		if r.err != nil {
+			r.Log.Errorf("Could not parse template, metrics are not processed: %v", r.err)
+		}
+	}
+	if r.err != nil {
+		return in
+	}
+
+	var b strings.Builder
+	for _, metric := range in {
+		b.Reset()
+		if err := r.tmpl.Execute(&b, &TemplateMetric{metric}); err != nil {
+			r.Log.Errorf("Could not execute template: %v", err)
+			continue
+		}
+
+		if b.Len() > 0 {
+			metric.AddTag(r.Tag, b.String())
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("template", func() telegraf.Processor {
+		return &TemplateProcessor{}
+	})
+}
@@ -0,0 +1,45 @@
+package template
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// TemplateMetric is the metric given to the template, it gives read only
+// access to the metric.
+type TemplateMetric struct {
+	metric telegraf.Metric
+}
+
+// Name returns the measurement name.
+func (m *TemplateMetric) Name() string {
+	return m.metric.Name()
+}
+
+// Tag returns the value of the tag, empty if the metric does not have it.
+func (m *TemplateMetric) Tag(key string) string {
+	value, _ := m.metric.GetTag(key)
+	return value
+}
+
+// Field returns the value of the field, nil if the metric does not have it.
+func (m *TemplateMetric) Field(key string) interface{} {
+	value, _ := m.metric.GetField(key)
+	return value
+}
+
+// Time returns the timestamp.
+func (m *TemplateMetric) Time() time.Time {
+	return m.metric.Time()
+}
+
+// Tags returns the tags.
+func (m *TemplateMetric) Tags() map[string]string {
+	return m.metric.Tags()
+}
+
+// Fields returns the fields.
+func (m *TemplateMetric) Fields() map[string]interface{} {
+	return m.metric.Fields()
+}
@@ -0,0 +1,94 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		expected map[string]string
+	}{
+		{
+			name:     "tags",
+			template: `{{ .Tag "host" }}:{{ .Tag "sql_instance" }}`,
+			expected: map[string]string{
+				"host":         "server01",
+				"sql_instance": "MSSQLSERVER",
+				"instance":     "server01:MSSQLSERVER",
+			},
+		},
+		{
+			name:     "name and field",
+			template: `{{ .Name }}-{{ .Field "version" }}`,
+			expected: map[string]string{
+				"host":         "server01",
+				"sql_instance": "MSSQLSERVER",
+				"instance":     "sqlserver_server_properties-13",
+			},
+		},
+		{
+			name:     "time",
+			template: `{{ .Time.UTC.Year }}`,
+			expected: map[string]string{
+				"host":         "server01",
+				"sql_instance": "MSSQLSERVER",
+				"instance":     "2019",
+			},
+		},
+		{
+			name:     "empty output",
+			template: `{{ .Tag "missing" }}`,
+			expected: map[string]string{
+				"host":         "server01",
+				"sql_instance": "MSSQLSERVER",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &TemplateProcessor{
+				Tag:      "instance",
+				Template: tt.template,
+				Log:      testutil.Logger{},
+			}
+
+			m := testutil.MustMetric("sqlserver_server_properties",
+				map[string]string{
+					"host":         "server01",
+					"sql_instance": "MSSQLSERVER",
+				},
+				map[string]interface{}{"version": int64(13)},
+				time.Date(2019, time.February, 12, 0, 0, 0, 0, time.UTC),
+			)
+			processed := plugin.Apply(m)
+
+			require.Len(t, processed, 1)
+			require.Equal(t, tt.expected, processed[0].Tags())
+		})
+	}
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	plugin := &TemplateProcessor{
+		Tag:      "instance",
+		Template: `{{ .Tag "host" `,
+		Log:      testutil.Logger{},
+	}
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	)
+	processed := plugin.Apply(m)
+
+	require.Error(t, plugin.err)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, processed)
+}
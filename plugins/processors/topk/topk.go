@@ -2,7 +2,6 @@ package topk
 
 import (
 	"fmt"
-	"log"
 	"math"
 	"sort"
 	"time"
@@ -25,6 +24,8 @@ type TopK struct {
 	AddRankFields      []string `toml:"add_rank_fields"`
 	AddAggregateFields []string `toml:"add_aggregate_fields"`
 
+	Log telegraf.Logger `toml:"-"`
+
 	cache           map[string][]telegraf.Metric
 	tagsGlobs       filter.Filter
 	rankFieldSet    map[string]bool
@@ -134,7 +135,7 @@ func (t *TopK) Reset() {
 }
 
 func (t *TopK) Description() string {
-	return "Print all metrics that pass through this filter."
+	return "Pass through only the metrics of the top k series over a period of time"
 }
 
 func (t *TopK) generateGroupByKey(m telegraf.Metric) (string, error) {
@@ -174,7 +175,7 @@ func (t *TopK) groupBy(m telegraf.Metric) {
 	if err != nil {
 		// If we could not generate the groupkey, fail hard
 		// by dropping this and all subsequent metrics
-		log.Printf("E! [processors.topk]: could not generate group key: %v", err)
+		t.Log.Errorf("could not generate group key: %v", err)
 		return
 	}
 
@@ -269,7 +270,7 @@ func (t *TopK) push() []telegraf.Metric {
 	if err != nil {
 		// If we could not generate the aggregation
 		// function, fail hard by dropping all metrics
-		log.Printf("E! [processors.topk]: %v", err)
+		t.Log.Errorf("%v", err)
 		return []telegraf.Metric{}
 	}
 	for k, ms := range t.cache {
@@ -342,8 +343,8 @@ func (t *TopK) getAggregationFunction(aggOperation string) (func([]telegraf.Metr
 				}
 				val, ok := convert(fieldVal)
 				if !ok {
-					log.Printf("Cannot convert value '%s' from metric '%s' with tags '%s'",
-						m.Fields()[field], m.Name(), m.Tags())
+					t.Log.Warnf("Cannot convert value '%v' from metric '%s' with tags '%v'",
+						fieldVal, m.Name(), m.Tags())
 					continue
 				}
 				f(agg, val, field)
@@ -408,8 +409,8 @@ func (t *TopK) getAggregationFunction(aggOperation string) (func([]telegraf.Metr
 					}
 					val, ok := convert(fieldVal)
 					if !ok {
-						log.Printf("Cannot convert value '%s' from metric '%s' with tags '%s'",
-							m.Fields()[field], m.Name(), m.Tags())
+						t.Log.Warnf("Cannot convert value '%v' from metric '%s' with tags '%v'",
+							fieldVal, m.Name(), m.Tags())
 						continue
 					}
 					mean[field] += val
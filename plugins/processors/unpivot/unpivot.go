@@ -0,0 +1,69 @@
+package unpivot
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Tag to use for the name.
+  tag_key = "name"
+  ## Field to use for the name of the value.
+  value_key = "value"
+`
+
+type Unpivot struct {
+	TagKey   string `toml:"tag_key"`
+	ValueKey string `toml:"value_key"`
+}
+
+func (p *Unpivot) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Unpivot) Description() string {
+	return "Rotate multi field metric into several single field metrics"
+}
+
+// Apply splits each metric into a metric per field.  The key of the field is
+// set in the tag and its value in the value field.
+func (p *Unpivot) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	fieldCount := 0
+	for _, m := range metrics {
+		fieldCount += len(m.FieldList())
+	}
+
+	results := make([]telegraf.Metric, 0, fieldCount)
+	for _, m := range metrics {
+		// The field list of the metric changes as fields are removed.
+		fields := append([]*telegraf.Field(nil), m.FieldList()...)
+		if len(fields) == 0 {
+			m.Drop()
+			continue
+		}
+
+		for i, field := range fields {
+			// The last field reuses the metric.
+			out := m
+			if i < len(fields)-1 {
+				out = m.Copy()
+			}
+			for _, f := range fields {
+				out.RemoveField(f.Key)
+			}
+			out.AddTag(p.TagKey, field.Key)
+			out.AddField(p.ValueKey, field.Value)
+			results = append(results, out)
+		}
+	}
+	return results
+}
+
+func init() {
+	processors.Add("unpivot", func() telegraf.Processor {
+		return &Unpivot{
+			TagKey:   "name",
+			ValueKey: "value",
+		}
+	})
+}
@@ -0,0 +1,9 @@
+package all
+
+import (
+	_ "github.com/influxdata/telegraf/plugins/secretstores/azure_keyvault"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/env"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/file"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/keyring"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/vault"
+)
@@ -0,0 +1,137 @@
+package azure_keyvault
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const (
+	defaultAuthResource = "https://vault.azure.net"
+	apiVersion          = "7.0"
+)
+
+var sampleConfig = `
+  ## Unique identifier used to reference the store, ie: @{keyvault:password}
+  id = "keyvault"
+
+  ## URL of the key vault.  The key of a reference is the name of the secret,
+  ## a specific version can be selected using "<name>/<version>".
+  vault_url = "https://myvault.vault.azure.net"
+
+  ## Credentials are read from the environment using AZURE_TENANT_ID,
+  ## AZURE_CLIENT_ID and AZURE_CLIENT_SECRET for a service principal, when
+  ## these are not set the managed service identity of the host is used.
+
+  ## Timeout for requests to the key vault.
+  # timeout = "5s"
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+`
+
+// AzureKeyVault reads secrets from an Azure Key Vault.
+type AzureKeyVault struct {
+	VaultURL string            `toml:"vault_url"`
+	Timeout  internal.Duration `toml:"timeout"`
+	httpconfig.ProxyConfig
+
+	auth   autorest.Authorizer
+	client *http.Client
+}
+
+type secretBundle struct {
+	Value string `json:"value"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (a *AzureKeyVault) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *AzureKeyVault) Description() string {
+	return "Read secrets from an Azure Key Vault"
+}
+
+func (a *AzureKeyVault) Get(key string) (string, error) {
+	if a.VaultURL == "" {
+		return "", fmt.Errorf("vault_url is not set")
+	}
+
+	if a.auth == nil {
+		proxy, err := a.ProxyConfig.Proxy()
+		if err != nil {
+			return "", err
+		}
+
+		authorizer, err := auth.NewAuthorizerFromEnvironmentWithResource(defaultAuthResource)
+		if err != nil {
+			return "", fmt.Errorf("unable to create authorizer: %v", err)
+		}
+		a.auth = authorizer
+		a.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy: proxy,
+			},
+			Timeout: a.Timeout.Duration,
+		}
+	}
+
+	url := fmt.Sprintf("%s/secrets/%s?api-version=%s",
+		strings.TrimRight(a.VaultURL, "/"), key, apiVersion)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// WithAuthorization will automatically refresh the token if needed.
+	req, err = autorest.CreatePreparer(a.auth.WithAuthorization()).Prepare(req)
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch authentication credentials: %v", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var bundle secretBundle
+	if err := json.NewDecoder(resp.Body).Decode(&bundle); err != nil {
+		return "", fmt.Errorf("unable to decode response: %s: %v", resp.Status, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if bundle.Error != nil {
+			return "", fmt.Errorf("unable to read secret %q: %s: %s", key, resp.Status, bundle.Error.Message)
+		}
+		return "", fmt.Errorf("unable to read secret %q: %s", key, resp.Status)
+	}
+	return bundle.Value, nil
+}
+
+func init() {
+	secretstores.Add("azure_keyvault", func() telegraf.SecretStore {
+		return &AzureKeyVault{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package azure_keyvault
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, apiVersion, r.URL.Query().Get("api-version"))
+		switch r.URL.Path {
+		case "/secrets/password":
+			fmt.Fprint(w, `{"value":"hunter2","id":"https://myvault.vault.azure.net/secrets/password/1"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":"SecretNotFound","message":"Secret not found"}}`)
+		}
+	}))
+	defer ts.Close()
+
+	a := &AzureKeyVault{
+		VaultURL: ts.URL,
+		auth:     autorest.NullAuthorizer{},
+		client:   ts.Client(),
+	}
+
+	value, err := a.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+
+	_, err = a.Get("missing")
+	require.Error(t, err)
+}
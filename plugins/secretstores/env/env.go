@@ -0,0 +1,44 @@
+package env
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+var sampleConfig = `
+  ## Unique identifier used to reference the store, ie: @{env:PASSWORD}
+  id = "env"
+
+  ## Prefix added to the key to form the name of the environment variable.
+  # prefix = ""
+`
+
+// Env reads secrets from environment variables.
+type Env struct {
+	Prefix string `toml:"prefix"`
+}
+
+func (e *Env) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Env) Description() string {
+	return "Read secrets from environment variables"
+}
+
+func (e *Env) Get(key string) (string, error) {
+	value, ok := os.LookupEnv(e.Prefix + key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %q is not set", e.Prefix+key)
+	}
+	return value, nil
+}
+
+func init() {
+	secretstores.Add("env", func() telegraf.SecretStore {
+		return &Env{}
+	})
+}
@@ -0,0 +1,21 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	os.Setenv("TELEGRAF_TEST_SECRET", "hunter2")
+	defer os.Unsetenv("TELEGRAF_TEST_SECRET")
+
+	e := &Env{Prefix: "TELEGRAF_TEST_"}
+	value, err := e.Get("SECRET")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+
+	_, err = e.Get("MISSING")
+	require.Error(t, err)
+}
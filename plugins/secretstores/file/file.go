@@ -0,0 +1,66 @@
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+var sampleConfig = `
+  ## Unique identifier used to reference the store, ie: @{files:password}
+  id = "files"
+
+  ## Directory containing one file per secret, the name of the file is the
+  ## key of the secret.  This layout matches Docker and Kubernetes secrets.
+  directory = "/run/secrets"
+
+  ## Remove leading and trailing whitespace, including the final newline,
+  ## from the secret.
+  # trim_space = true
+`
+
+// File reads secrets from files in a directory.
+type File struct {
+	Directory string `toml:"directory"`
+	TrimSpace bool   `toml:"trim_space"`
+}
+
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *File) Description() string {
+	return "Read secrets from files in a directory"
+}
+
+func (f *File) Get(key string) (string, error) {
+	if f.Directory == "" {
+		return "", fmt.Errorf("directory is not set")
+	}
+
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid secret key %q", key)
+	}
+
+	value, err := ioutil.ReadFile(filepath.Join(f.Directory, key))
+	if err != nil {
+		return "", err
+	}
+
+	if f.TrimSpace {
+		return strings.TrimSpace(string(value)), nil
+	}
+	return string(value), nil
+}
+
+func init() {
+	secretstores.Add("file", func() telegraf.SecretStore {
+		return &File{
+			TrimSpace: true,
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secretstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "password"), []byte("hunter2\n"), 0600)
+	require.NoError(t, err)
+
+	f := &File{Directory: dir, TrimSpace: true}
+	value, err := f.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+
+	f.TrimSpace = false
+	value, err = f.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2\n", value)
+
+	_, err = f.Get("missing")
+	require.Error(t, err)
+
+	_, err = f.Get("../password")
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package keyring
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+var sampleConfig = `
+  ## Unique identifier used to reference the store, ie: @{keyring:password}
+  id = "keyring"
+
+  ## Service name the secrets are stored under, the key of the secret is used
+  ## as the account name.
+  service = "telegraf"
+
+  ## Timeout for querying the keyring.
+  # timeout = "5s"
+`
+
+// Keyring reads secrets from the operating system keyring.  On Linux the
+// Secret Service is queried using secret-tool, on macOS the login keychain
+// is queried using security.
+type Keyring struct {
+	Service string            `toml:"service"`
+	Timeout internal.Duration `toml:"timeout"`
+
+	// lookup returns the command printing the secret, it defaults to
+	// lookupCommand.
+	lookup func(service, key string) (*exec.Cmd, error)
+}
+
+func (k *Keyring) SampleConfig() string {
+	return sampleConfig
+}
+
+func (k *Keyring) Description() string {
+	return "Read secrets from the operating system keyring"
+}
+
+func (k *Keyring) Get(key string) (string, error) {
+	lookup := k.lookup
+	if lookup == nil {
+		lookup = lookupCommand
+	}
+	cmd, err := lookup(k.Service, key)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := internal.RunTimeout(cmd, k.Timeout.Duration); err != nil {
+		return "", fmt.Errorf("unable to read secret %q from keyring: %v: %s",
+			key, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(stdout.String(), "\n"), nil
+}
+
+// lookupCommand returns the command printing the secret from the keyring of
+// the operating system.
+func lookupCommand(service, key string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		return exec.Command("secret-tool", "lookup", "service", service, "account", key), nil
+	case "darwin":
+		return exec.Command("security", "find-generic-password", "-s", service, "-a", key, "-w"), nil
+	}
+	return nil, fmt.Errorf("keyring is not supported on %s", runtime.GOOS)
+}
+
+func init() {
+	secretstores.Add("keyring", func() telegraf.SecretStore {
+		return &Keyring{
+			Service: "telegraf",
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
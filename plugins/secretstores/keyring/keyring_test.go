@@ -0,0 +1,76 @@
+// +build !windows
+
+package keyring
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeyring returns a lookup running the script instead of the keyring
+// command, the service and key it was called with are recorded.
+func fakeKeyring(script string, service, key *string) func(string, string) (*exec.Cmd, error) {
+	return func(s, k string) (*exec.Cmd, error) {
+		*service = s
+		*key = k
+		return exec.Command("sh", "-c", script), nil
+	}
+}
+
+func TestGet(t *testing.T) {
+	var service, key string
+	k := &Keyring{
+		Service: "telegraf",
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		lookup:  fakeKeyring("echo hunter2", &service, &key),
+	}
+
+	value, err := k.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", value)
+	require.Equal(t, "telegraf", service)
+	require.Equal(t, "password", key)
+}
+
+func TestGetMissing(t *testing.T) {
+	var service, key string
+	k := &Keyring{
+		Service: "telegraf",
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		lookup:  fakeKeyring("echo 'secret not found' >&2; exit 1", &service, &key),
+	}
+
+	_, err := k.Get("missing")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "secret not found")
+}
+
+func TestGetTimeout(t *testing.T) {
+	var service, key string
+	k := &Keyring{
+		Service: "telegraf",
+		Timeout: internal.Duration{Duration: 100 * time.Millisecond},
+		lookup:  fakeKeyring("exec sleep 10", &service, &key),
+	}
+
+	_, err := k.Get("password")
+	require.Error(t, err)
+}
+
+func TestGetUnsupported(t *testing.T) {
+	k := &Keyring{
+		Service: "telegraf",
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		lookup: func(string, string) (*exec.Cmd, error) {
+			return nil, errors.New("keyring is not supported")
+		},
+	}
+
+	_, err := k.Get("password")
+	require.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+var sampleConfig = `
+  ## Unique identifier used to reference the store, ie: @{vault:password}
+  id = "vault"
+
+  ## Address of the Vault server.
+  url = "https://127.0.0.1:8200"
+
+  ## Token used to authenticate, either set directly or read from a file such
+  ## as the sink of a Vault agent.  The file is read on each request so
+  ## renewed tokens are picked up.
+  # token = ""
+  # token_file = ""
+
+  ## Vault Enterprise namespace.
+  # namespace = ""
+
+  ## Path of the secret below /v1/, the key of a reference selects the field
+  ## of the secret, ie: "secret/data/telegraf" for the KV version 2 engine
+  ## mounted at "secret".
+  path = "secret/data/telegraf"
+
+  ## Version of the KV secrets engine, either 1 or 2.
+  # kv_version = 2
+
+  ## Timeout for requests to Vault.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## HTTP proxy to use, overrides the system proxy set by the HTTP_PROXY,
+  ## HTTPS_PROXY and NO_PROXY environment variables.
+  # http_proxy_url = "http://localhost:8888"
+  ## Use the system proxy when http_proxy_url is not set.
+  # use_system_proxy = true
+`
+
+// Vault reads secrets from a HashiCorp Vault KV secrets engine.
+type Vault struct {
+	URL       string            `toml:"url"`
+	Token     string            `toml:"token"`
+	TokenFile string            `toml:"token_file"`
+	Namespace string            `toml:"namespace"`
+	Path      string            `toml:"path"`
+	KVVersion int               `toml:"kv_version"`
+	Timeout   internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+	httpconfig.ProxyConfig
+
+	client *http.Client
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+func (v *Vault) SampleConfig() string {
+	return sampleConfig
+}
+
+func (v *Vault) Description() string {
+	return "Read secrets from a HashiCorp Vault KV secrets engine"
+}
+
+func (v *Vault) Get(key string) (string, error) {
+	if v.client == nil {
+		client, err := v.createClient()
+		if err != nil {
+			return "", err
+		}
+		v.client = client
+	}
+
+	data, err := v.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found in secret %q", key, v.Path)
+	}
+
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
+
+func (v *Vault) createClient() (*http.Client, error) {
+	if v.URL == "" {
+		return nil, fmt.Errorf("url is not set")
+	}
+
+	if v.KVVersion != 1 && v.KVVersion != 2 {
+		return nil, fmt.Errorf("invalid kv_version %d", v.KVVersion)
+	}
+
+	tlsCfg, err := v.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := v.ProxyConfig.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           proxy,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: v.Timeout.Duration,
+	}, nil
+}
+
+func (v *Vault) token() (string, error) {
+	if v.TokenFile == "" {
+		return v.Token, nil
+	}
+
+	token, err := ioutil.ReadFile(v.TokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// read fetches the secret and returns its fields.
+func (v *Vault) read() (map[string]interface{}, error) {
+	url := strings.TrimRight(v.URL, "/") + "/v1/" + strings.TrimLeft(v.Path, "/")
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := v.token()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("unable to decode response from vault: %s: %v", resp.Status, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to read secret %q: %s: %s",
+			v.Path, resp.Status, strings.Join(r.Errors, ", "))
+	}
+
+	if v.KVVersion == 2 {
+		var kv struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(r.Data, &kv); err != nil {
+			return nil, err
+		}
+		r.Data = kv.Data
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(r.Data, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func init() {
+	secretstores.Add("vault", func() telegraf.SecretStore {
+		return &Vault{
+			KVVersion: 2,
+			Timeout:   internal.Duration{Duration: 5 * time.Second},
+			ProxyConfig: httpconfig.ProxyConfig{
+				UseSystemProxy: true,
+			},
+		}
+	})
+}
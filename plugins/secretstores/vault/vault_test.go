@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/telegraf":
+			fmt.Fprint(w, `{"data":{"data":{"password":"hunter2","port":5432},"metadata":{"version":1}}}`)
+		case "/v1/kv/telegraf":
+			fmt.Fprint(w, `{"data":{"password":"hunter3"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		vault   *Vault
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{
+			name:  "kv version 2",
+			vault: &Vault{URL: ts.URL, Token: "s.token", Path: "secret/data/telegraf", KVVersion: 2},
+			key:   "password",
+			value: "hunter2",
+		},
+		{
+			name:  "non string value",
+			vault: &Vault{URL: ts.URL, Token: "s.token", Path: "secret/data/telegraf", KVVersion: 2},
+			key:   "port",
+			value: "5432",
+		},
+		{
+			name:  "kv version 1",
+			vault: &Vault{URL: ts.URL, Token: "s.token", Path: "kv/telegraf", KVVersion: 1},
+			key:   "password",
+			value: "hunter3",
+		},
+		{
+			name:    "missing key",
+			vault:   &Vault{URL: ts.URL, Token: "s.token", Path: "kv/telegraf", KVVersion: 1},
+			key:     "username",
+			wantErr: true,
+		},
+		{
+			name:    "missing secret",
+			vault:   &Vault{URL: ts.URL, Token: "s.token", Path: "kv/missing", KVVersion: 1},
+			key:     "password",
+			wantErr: true,
+		},
+		{
+			name:    "permission denied",
+			vault:   &Vault{URL: ts.URL, Token: "s.wrong", Path: "kv/telegraf", KVVersion: 1},
+			key:     "password",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := tt.vault.Get(tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.value, value)
+		})
+	}
+}
@@ -3,16 +3,43 @@ package carbon2
 import (
 	"bytes"
 	"fmt"
-	"github.com/influxdata/telegraf"
 	"strconv"
 	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// FormatFieldSeparate writes the field name in the "field" intrinsic tag.
+	FormatFieldSeparate = "field_separate"
+	// FormatMetricIncludesField appends the field name to the "metric"
+	// intrinsic tag.
+	FormatMetricIncludesField = "metric_includes_field"
 )
 
 type serializer struct {
+	format   string
+	metaTags map[string]bool
 }
 
-func NewSerializer() (*serializer, error) {
-	s := &serializer{}
+// NewSerializer returns a carbon2 serializer; the tags in metaTags are
+// written as meta tags instead of intrinsic tags.
+func NewSerializer(format string, metaTags []string) (*serializer, error) {
+	switch format {
+	case "":
+		format = FormatFieldSeparate
+	case FormatFieldSeparate, FormatMetricIncludesField:
+	default:
+		return nil, fmt.Errorf("unknown carbon2 format: %s", format)
+	}
+
+	s := &serializer{
+		format:   format,
+		metaTags: make(map[string]bool, len(metaTags)),
+	}
+	for _, tag := range metaTags {
+		s.metaTags[tag] = true
+	}
 	return s, nil
 }
 
@@ -31,37 +58,66 @@ func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 func (s *serializer) createObject(metric telegraf.Metric) []byte {
 	var m bytes.Buffer
 	for fieldName, fieldValue := range metric.Fields() {
-		if isNumeric(fieldValue) {
+		value, ok := formatValue(fieldValue)
+		if !ok {
+			continue
+		}
+
+		if s.format == FormatMetricIncludesField {
 			m.WriteString("metric=")
-			m.WriteString(strings.Replace(metric.Name(), " ", "_", -1))
-			m.WriteString(" field=")
-			m.WriteString(strings.Replace(fieldName, " ", "_", -1))
-			m.WriteString(" ")
-			for _, tag := range metric.TagList() {
-				m.WriteString(strings.Replace(tag.Key, " ", "_", -1))
-				m.WriteString("=")
-				value := tag.Value
-				if len(value) == 0 {
-					value = "null"
-				}
-				m.WriteString(strings.Replace(value, " ", "_", -1))
-				m.WriteString(" ")
-			}
+			m.WriteString(sanitize(metric.Name() + "_" + fieldName))
 			m.WriteString(" ")
-			m.WriteString(fmt.Sprintf("%v", fieldValue))
+		} else {
+			m.WriteString("metric=")
+			m.WriteString(sanitize(metric.Name()))
+			m.WriteString(" field=")
+			m.WriteString(sanitize(fieldName))
 			m.WriteString(" ")
-			m.WriteString(strconv.FormatInt(metric.Time().Unix(), 10))
-			m.WriteString("\n")
 		}
+		s.writeTags(&m, metric, false)
+		// Intrinsic and meta tags are separated by two spaces.
+		m.WriteString(" ")
+		s.writeTags(&m, metric, true)
+		m.WriteString(value)
+		m.WriteString(" ")
+		m.WriteString(strconv.FormatInt(metric.Time().Unix(), 10))
+		m.WriteString("\n")
 	}
 	return m.Bytes()
 }
 
-func isNumeric(v interface{}) bool {
-	switch v.(type) {
+// writeTags writes either the intrinsic or the meta tags, each followed by a
+// space.
+func (s *serializer) writeTags(m *bytes.Buffer, metric telegraf.Metric, meta bool) {
+	for _, tag := range metric.TagList() {
+		if s.metaTags[tag.Key] != meta {
+			continue
+		}
+		m.WriteString(sanitize(tag.Key))
+		m.WriteString("=")
+		value := tag.Value
+		if len(value) == 0 {
+			value = "null"
+		}
+		m.WriteString(sanitize(value))
+		m.WriteString(" ")
+	}
+}
+
+func sanitize(value string) string {
+	return strings.Replace(value, " ", "_", -1)
+}
+
+func formatValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
 	case string:
-		return false
+		return "", false
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
 	default:
-		return true
+		return fmt.Sprintf("%v", v), true
 	}
 }
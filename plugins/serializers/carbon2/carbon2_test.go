@@ -29,7 +29,7 @@ func TestSerializeMetricFloat(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer()
+	s, _ := NewSerializer("", nil)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -48,7 +48,7 @@ func TestSerializeMetricWithEmptyStringTag(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer()
+	s, _ := NewSerializer("", nil)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -67,7 +67,7 @@ func TestSerializeWithSpaces(t *testing.T) {
 	m, err := metric.New("cpu metric", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer()
+	s, _ := NewSerializer("", nil)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -86,7 +86,7 @@ func TestSerializeMetricInt(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer()
+	s, _ := NewSerializer("", nil)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -106,7 +106,7 @@ func TestSerializeMetricString(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer()
+	s, _ := NewSerializer("", nil)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -128,7 +128,7 @@ func TestSerializeBatch(t *testing.T) {
 	)
 
 	metrics := []telegraf.Metric{m, m}
-	s, _ := NewSerializer()
+	s, _ := NewSerializer("", nil)
 	buf, err := s.SerializeBatch(metrics)
 	require.NoError(t, err)
 	expS := []byte(`metric=cpu field=value  42 0
@@ -136,3 +136,52 @@ metric=cpu field=value  42 0
 `)
 	assert.Equal(t, string(expS), string(buf))
 }
+
+func TestSerializeMetricIncludesField(t *testing.T) {
+	m := MustMetric(metric.New(
+		"cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage_idle": float64(91.5)},
+		time.Unix(1234567890, 0),
+	))
+
+	s, err := NewSerializer(FormatMetricIncludesField, nil)
+	require.NoError(t, err)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "metric=cpu_usage_idle cpu=cpu0  91.5 1234567890\n", string(buf))
+}
+
+func TestSerializeMetaTags(t *testing.T) {
+	m := MustMetric(metric.New(
+		"cpu",
+		map[string]string{"cpu": "cpu0", "host": "server01", "region": "us-east-1"},
+		map[string]interface{}{"usage_idle": float64(91.5)},
+		time.Unix(1234567890, 0),
+	))
+
+	s, err := NewSerializer("", []string{"host", "region"})
+	require.NoError(t, err)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "metric=cpu field=usage_idle cpu=cpu0  host=server01 region=us-east-1 91.5 1234567890\n", string(buf))
+}
+
+func TestSerializeBool(t *testing.T) {
+	m := MustMetric(metric.New(
+		"system",
+		map[string]string{},
+		map[string]interface{}{"up": true},
+		time.Unix(1234567890, 0),
+	))
+
+	s, _ := NewSerializer("", nil)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, "metric=system field=up  1 1234567890\n", string(buf))
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewSerializer("metric_only", nil)
+	require.Error(t, err)
+}
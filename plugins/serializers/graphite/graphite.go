@@ -10,10 +10,21 @@ import (
 	"strings"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 )
 
 const DEFAULT_TEMPLATE = "host.tags.measurement.field"
 
+const (
+	// TagSanitizeStrict replaces the characters not allowed by the plain
+	// graphite format, also in the tags.
+	TagSanitizeStrict = "strict"
+	// TagSanitizeCompatible only replaces the characters graphite does not
+	// allow in tag names and values, see
+	// https://graphite.readthedocs.io/en/latest/tags.html#carbon
+	TagSanitizeCompatible = "compatible"
+)
+
 var (
 	allowedChars = regexp.MustCompile(`[^a-zA-Z0-9-:._=\p{L}]`)
 	hypenChars   = strings.NewReplacer(
@@ -26,13 +37,58 @@ var (
 		"..", ".",
 	)
 
+	compatibleAllowedCharsName  = regexp.MustCompile(`[^ "-:\<>-\]_a-~\p{L}]`)
+	compatibleAllowedCharsValue = regexp.MustCompile(`[^ -:<-~\p{L}]`)
+	compatibleLeadingTildeDrop  = regexp.MustCompile(`^[~]*(.*)`)
+
 	fieldDeleter = strings.NewReplacer(".FIELDNAME", "", "FIELDNAME.", "")
 )
 
+// GraphiteTemplate is a template used for the metrics whose name matches
+// the filter.
+type GraphiteTemplate struct {
+	Filter filter.Filter
+	Value  string
+}
+
 type GraphiteSerializer struct {
-	Prefix     string
-	Template   string
-	TagSupport bool
+	Prefix          string
+	Template        string
+	TagSupport      bool
+	TagSanitizeMode string
+	Separator       string
+	Templates       []*GraphiteTemplate
+}
+
+// InitGraphiteTemplates parses the templates, in the "filter template"
+// format.  A template without filter replaces the default template, which is
+// returned with the filtered templates.
+func InitGraphiteTemplates(templates []string) ([]*GraphiteTemplate, string, error) {
+	var graphiteTemplates []*GraphiteTemplate
+	defaultTemplate := ""
+
+	for i, t := range templates {
+		parts := strings.Fields(t)
+		switch len(parts) {
+		case 1:
+			if defaultTemplate != "" {
+				return nil, "", fmt.Errorf("template %d: only one default template is allowed", i)
+			}
+			defaultTemplate = parts[0]
+		case 2:
+			f, err := filter.Compile([]string{parts[0]})
+			if err != nil {
+				return nil, "", fmt.Errorf("template %d: invalid filter %q: %v", i, parts[0], err)
+			}
+			graphiteTemplates = append(graphiteTemplates, &GraphiteTemplate{
+				Filter: f,
+				Value:  parts[1],
+			})
+		default:
+			return nil, "", fmt.Errorf("template %d: invalid template %q", i, t)
+		}
+	}
+	return graphiteTemplates, defaultTemplate, nil
 }
 
 func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
@@ -48,7 +104,7 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 			if fieldValue == "" {
 				continue
 			}
-			bucket := SerializeBucketNameWithTags(metric.Name(), metric.Tags(), s.Prefix, fieldName)
+			bucket := serializeBucketNameWithTags(metric.Name(), metric.Tags(), s.Prefix, s.separator(), fieldName, s.TagSanitizeMode)
 			metricString := fmt.Sprintf("%s %s %d\n",
 				// insert "field" section of template
 				bucket,
@@ -59,7 +115,15 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 			out = append(out, point...)
 		}
 	default:
-		bucket := SerializeBucketName(metric.Name(), metric.Tags(), s.Template, s.Prefix)
+		template := s.Template
+		for _, t := range s.Templates {
+			if t.Filter.Match(metric.Name()) {
+				template = t.Value
+				break
+			}
+		}
+
+		bucket := serializeBucketName(metric.Name(), metric.Tags(), template, s.Prefix, s.separator())
 		if bucket == "" {
 			return out, nil
 		}
@@ -71,7 +135,7 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 			}
 			metricString := fmt.Sprintf("%s %s %d\n",
 				// insert "field" section of template
-				sanitize(InsertField(bucket, fieldName)),
+				sanitize(insertField(bucket, fieldName, s.separator())),
 				fieldValue,
 				timestamp)
 			point := []byte(metricString)
@@ -81,6 +145,13 @@ func (s *GraphiteSerializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	return out, nil
 }
 
+func (s *GraphiteSerializer) separator() string {
+	if s.Separator == "" {
+		return "."
+	}
+	return s.Separator
+}
+
 func (s *GraphiteSerializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 	var batch bytes.Buffer
 	for _, m := range metrics {
@@ -137,6 +208,16 @@ func SerializeBucketName(
 	tags map[string]string,
 	template string,
 	prefix string,
+) string {
+	return serializeBucketName(measurement, tags, template, prefix, ".")
+}
+
+func serializeBucketName(
+	measurement string,
+	tags map[string]string,
+	template string,
+	prefix string,
+	separator string,
 ) string {
 	if template == "" {
 		template = DEFAULT_TEMPLATE
@@ -170,7 +251,7 @@ func SerializeBucketName(
 	// insert remaining tags into output name
 	for i, templatePart := range out {
 		if templatePart == "TAGS" {
-			out[i] = buildTags(tagsCopy)
+			out[i] = buildTags(tagsCopy, separator)
 			break
 		}
 	}
@@ -180,9 +261,9 @@ func SerializeBucketName(
 	}
 
 	if prefix == "" {
-		return strings.Join(out, ".")
+		return strings.Join(out, separator)
 	}
-	return prefix + "." + strings.Join(out, ".")
+	return prefix + separator + strings.Join(out, separator)
 }
 
 // SerializeBucketNameWithTags will take the given measurement name and tags and
@@ -193,6 +274,17 @@ func SerializeBucketNameWithTags(
 	tags map[string]string,
 	prefix string,
 	field string,
+) string {
+	return serializeBucketNameWithTags(measurement, tags, prefix, ".", field, TagSanitizeStrict)
+}
+
+func serializeBucketNameWithTags(
+	measurement string,
+	tags map[string]string,
+	prefix string,
+	separator string,
+	field string,
+	tagSanitizeMode string,
 ) string {
 	var out string
 	var tagsCopy []string
@@ -200,21 +292,29 @@ func SerializeBucketNameWithTags(
 		if k == "name" {
 			k = "_name"
 		}
-		tagsCopy = append(tagsCopy, sanitize(k+"="+v))
+		if tagSanitizeMode == TagSanitizeCompatible {
+			tagsCopy = append(tagsCopy, compatibleSanitize(k, v))
+		} else {
+			tagsCopy = append(tagsCopy, sanitize(k+"="+v))
+		}
 	}
 	sort.Strings(tagsCopy)
 
 	if prefix != "" {
-		out = prefix + "."
+		out = prefix + separator
 	}
 
 	out += measurement
 
 	if field != "value" {
-		out += "." + field
+		out += separator + field
 	}
 
-	out = sanitize(out)
+	if tagSanitizeMode == TagSanitizeCompatible {
+		out = compatibleAllowedCharsName.ReplaceAllLiteralString(out, "_")
+	} else {
+		out = sanitize(out)
+	}
 
 	if len(tagsCopy) > 0 {
 		out += ";" + strings.Join(tagsCopy, ";")
@@ -227,14 +327,21 @@ func SerializeBucketNameWithTags(
 // FIELDNAME portion. If fieldName == "value", it will simply delete the
 // FIELDNAME portion.
 func InsertField(bucket, fieldName string) string {
+	return insertField(bucket, fieldName, ".")
+}
+
+func insertField(bucket, fieldName, separator string) string {
 	// if the field name is "value", then dont use it
 	if fieldName == "value" {
-		return fieldDeleter.Replace(bucket)
+		if separator == "." {
+			return fieldDeleter.Replace(bucket)
+		}
+		return strings.NewReplacer(separator+"FIELDNAME", "", "FIELDNAME"+separator, "").Replace(bucket)
 	}
 	return strings.Replace(bucket, "FIELDNAME", fieldName, 1)
 }
 
-func buildTags(tags map[string]string) string {
+func buildTags(tags map[string]string, separator string) string {
 	var keys []string
 	for k := range tags {
 		keys = append(keys, k)
@@ -247,7 +354,7 @@ func buildTags(tags map[string]string) string {
 		if i == 0 {
 			tag_str += tag_value
 		} else {
-			tag_str += "." + tag_value
+			tag_str += separator + tag_value
 		}
 	}
 	return tag_str
@@ -261,3 +368,12 @@ func sanitize(value string) string {
 	// Replace any remaining illegal chars
 	return allowedChars.ReplaceAllLiteralString(value, "_")
 }
+
+// compatibleSanitize returns the tag sanitized with the rules of graphite
+// for tag names and values.
+func compatibleSanitize(name string, value string) string {
+	name = compatibleAllowedCharsName.ReplaceAllLiteralString(name, "_")
+	value = compatibleAllowedCharsValue.ReplaceAllLiteralString(value, "_")
+	value = compatibleLeadingTildeDrop.FindStringSubmatch(value)[1]
+	return name + "=" + value
+}
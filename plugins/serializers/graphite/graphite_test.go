@@ -51,9 +51,9 @@ func TestGraphiteTags(t *testing.T) {
 		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
 	)
 
-	tags1 := buildTags(m1.Tags())
-	tags2 := buildTags(m2.Tags())
-	tags3 := buildTags(m3.Tags())
+	tags1 := buildTags(m1.Tags(), ".")
+	tags2 := buildTags(m2.Tags(), ".")
+	tags3 := buildTags(m3.Tags(), ".")
 
 	assert.Equal(t, "192_168_0_1", tags1)
 	assert.Equal(t, "first.second.192_168_0_1", tags2)
@@ -952,3 +952,130 @@ func TestSerializeBatchWithTagsSupport(t *testing.T) {
 		})
 	}
 }
+
+func TestSerializeWithTemplates(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	templates, defaultTemplate, err := InitGraphiteTemplates([]string{
+		"cpu tags.measurement.host.field",
+		"disk* measurement.field",
+		"host.measurement.field",
+	})
+	require.NoError(t, err)
+	s := GraphiteSerializer{
+		Template:  defaultTemplate,
+		Templates: templates,
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"cpu", "cpu0.us-west-2.cpu.localhost.usage 42 1234567890\n"},
+		{"diskio", "diskio.usage 42 1234567890\n"},
+		{"mem", "localhost.mem.usage 42 1234567890\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := metric.New(tt.name, defaultTags, map[string]interface{}{"usage": 42}, now)
+			require.NoError(t, err)
+
+			buf, err := s.Serialize(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(buf))
+		})
+	}
+}
+
+func TestInitGraphiteTemplatesErrors(t *testing.T) {
+	_, _, err := InitGraphiteTemplates([]string{"measurement.field", "host.field"})
+	require.Error(t, err)
+
+	_, _, err = InitGraphiteTemplates([]string{"cpu measurement.field extra"})
+	require.Error(t, err)
+}
+
+func TestSerializeWithSeparator(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	m, err := metric.New("cpu", map[string]string{"host": "localhost"},
+		map[string]interface{}{"usage_busy": 8.5, "value": 1}, now)
+	require.NoError(t, err)
+
+	s := GraphiteSerializer{
+		Template:  "host.measurement.field",
+		Separator: "_",
+	}
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	mS := strings.Split(strings.TrimSpace(string(buf)), "\n")
+	sort.Strings(mS)
+	require.Equal(t, []string{
+		"localhost_cpu 1 1234567890",
+		"localhost_cpu_usage_busy 8.5 1234567890",
+	}, mS)
+
+	s = GraphiteSerializer{
+		TagSupport: true,
+		Separator:  "_",
+	}
+	buf, err = s.Serialize(m)
+	require.NoError(t, err)
+	mS = strings.Split(strings.TrimSpace(string(buf)), "\n")
+	sort.Strings(mS)
+	require.Equal(t, []string{
+		"cpu;host=localhost 1 1234567890",
+		"cpu_usage_busy;host=localhost 8.5 1234567890",
+	}, mS)
+}
+
+func TestCleanWithTagsSupportCompatibleSanitize(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	tests := []struct {
+		name        string
+		metric_name string
+		tags        map[string]string
+		fields      map[string]interface{}
+		expected    string
+	}{
+		{
+			"Base metric",
+			"cpu",
+			map[string]string{"host": "localhost"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost 8.5 1234567890\n",
+		},
+		{
+			"Dot and whitespace in tags",
+			"cpu",
+			map[string]string{"host": "localhost", "label.dot and space": "value with.dot"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost;label.dot and space=value with.dot 8.5 1234567890\n",
+		},
+		{
+			"Special characters preserved",
+			"cpu",
+			map[string]string{"host": "localhost", "tag": "/+{}@"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost;tag=/+{}@ 8.5 1234567890\n",
+		},
+		{
+			"Leading tilde and semicolon in tag value",
+			"cpu",
+			map[string]string{"host": "localhost", "tag": "~~a;b"},
+			map[string]interface{}{"usage_busy": float64(8.5)},
+			"cpu.usage_busy;host=localhost;tag=a_b 8.5 1234567890\n",
+		},
+	}
+
+	s := GraphiteSerializer{
+		TagSupport:      true,
+		TagSanitizeMode: TagSanitizeCompatible,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := metric.New(tt.metric_name, tt.tags, tt.fields, now)
+			require.NoError(t, err)
+			actual, _ := s.Serialize(m)
+			require.Equal(t, tt.expected, string(actual))
+		})
+	}
+}
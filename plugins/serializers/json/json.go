@@ -2,22 +2,77 @@ package json
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
 )
 
+// BatchFormat is the layout of a batch of metrics.
+type BatchFormat int
+
+const (
+	// BatchObject wraps the metrics of a batch in an object with a
+	// "metrics" key.
+	BatchObject BatchFormat = iota
+	// BatchArray writes the metrics of a batch as a JSON array.
+	BatchArray
+)
+
 type serializer struct {
-	TimestampUnits time.Duration
+	TimestampUnits   time.Duration
+	TimestampKey     string
+	TimestampFormat  string
+	NestingSeparator string
+	BatchFormat      BatchFormat
 }
 
 func NewSerializer(timestampUnits time.Duration) (*serializer, error) {
 	s := &serializer{
 		TimestampUnits: truncateDuration(timestampUnits),
+		TimestampKey:   "timestamp",
 	}
 	return s, nil
 }
 
+// SetTimestampKey sets the key of the timestamp, "timestamp" if empty.
+func (s *serializer) SetTimestampKey(key string) {
+	if key == "" {
+		key = "timestamp"
+	}
+	s.TimestampKey = key
+}
+
+// SetTimestampFormat sets the Go reference time layout of the timestamp; if
+// empty the timestamp is a number in the timestamp units.
+func (s *serializer) SetTimestampFormat(format string) {
+	s.TimestampFormat = format
+}
+
+// SetNestingSeparator sets the separator splitting the tag and field keys
+// into nested objects; if empty the keys are not split.
+func (s *serializer) SetNestingSeparator(separator string) {
+	s.NestingSeparator = separator
+}
+
+// SetBatchFormat sets the layout of the batches.
+func (s *serializer) SetBatchFormat(format BatchFormat) {
+	s.BatchFormat = format
+}
+
+// ParseBatchFormat returns the batch format of its name, "object" or "array".
+func ParseBatchFormat(name string) (BatchFormat, error) {
+	switch name {
+	case "", "object":
+		return BatchObject, nil
+	case "array":
+		return BatchArray, nil
+	}
+	return BatchObject, fmt.Errorf("invalid json_batch_format: %q", name)
+}
+
 func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
 	m := s.createObject(metric)
 	serialized, err := json.Marshal(m)
@@ -36,8 +91,11 @@ func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 		objects = append(objects, m)
 	}
 
-	obj := map[string]interface{}{
-		"metrics": objects,
+	var obj interface{} = objects
+	if s.BatchFormat == BatchObject {
+		obj = map[string]interface{}{
+			"metrics": objects,
+		}
 	}
 
 	serialized, err := json.Marshal(obj)
@@ -49,13 +107,74 @@ func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
 
 func (s *serializer) createObject(metric telegraf.Metric) map[string]interface{} {
 	m := make(map[string]interface{}, 4)
-	m["tags"] = metric.Tags()
-	m["fields"] = metric.Fields()
+	if s.NestingSeparator == "" {
+		m["tags"] = metric.Tags()
+		m["fields"] = metric.Fields()
+	} else {
+		tags := make(map[string]interface{}, len(metric.TagList()))
+		for _, tag := range metric.TagList() {
+			tags[tag.Key] = tag.Value
+		}
+		m["tags"] = nest(tags, s.NestingSeparator)
+		m["fields"] = nest(metric.Fields(), s.NestingSeparator)
+	}
 	m["name"] = metric.Name()
-	m["timestamp"] = metric.Time().UnixNano() / int64(s.TimestampUnits)
+	if s.TimestampFormat != "" {
+		m[s.TimestampKey] = metric.Time().UTC().Format(s.TimestampFormat)
+	} else {
+		m[s.TimestampKey] = metric.Time().UnixNano() / int64(s.TimestampUnits)
+	}
 	return m
 }
 
+// nest splits the keys on the separator into nested objects.  A key whose
+// path conflicts with a value of another key is kept as is.
+func nest(values map[string]interface{}, separator string) map[string]interface{} {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	// Shorter paths first so that a value is never replaced by an object.
+	sort.Strings(keys)
+
+	out := make(map[string]interface{}, len(values))
+	for _, k := range keys {
+		path := strings.Split(k, separator)
+		if !insert(out, path, values[k]) {
+			out[k] = values[k]
+		}
+	}
+	return out
+}
+
+// insert sets the value at the path, creating the intermediate objects, it
+// returns false if the path is taken.
+func insert(obj map[string]interface{}, path []string, value interface{}) bool {
+	for i, part := range path {
+		if i == len(path)-1 {
+			if _, ok := obj[part]; ok {
+				return false
+			}
+			obj[part] = value
+			return true
+		}
+
+		next, ok := obj[part]
+		if !ok {
+			child := make(map[string]interface{})
+			obj[part] = child
+			obj = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		obj = child
+	}
+	return false
+}
+
 func truncateDuration(units time.Duration) time.Duration {
 	// Default precision is 1s
 	if units <= 0 {
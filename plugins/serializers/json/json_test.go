@@ -193,3 +193,94 @@ func TestSerializeBatch(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []byte(`{"metrics":[{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0},{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}]}`), buf)
 }
+
+func TestSerializeBatchArray(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	metrics := []telegraf.Metric{m, m}
+	s, _ := NewSerializer(0)
+	s.SetBatchFormat(BatchArray)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, []byte(`[{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0},{"fields":{"value":42},"name":"cpu","tags":{},"timestamp":0}]`), buf)
+}
+
+func TestParseBatchFormat(t *testing.T) {
+	format, err := ParseBatchFormat("")
+	require.NoError(t, err)
+	require.Equal(t, BatchObject, format)
+
+	format, err = ParseBatchFormat("array")
+	require.NoError(t, err)
+	require.Equal(t, BatchArray, format)
+
+	_, err = ParseBatchFormat("list")
+	require.Error(t, err)
+}
+
+func TestSerializeTimestampKeyAndFormat(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(1525478795, 123456789),
+		),
+	)
+
+	s, _ := NewSerializer(0)
+	s.SetTimestampKey("time")
+	s.SetTimestampFormat(time.RFC3339Nano)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `{"fields":{"value":42},"name":"cpu","tags":{},"time":"2018-05-05T00:06:35.123456789Z"}`+"\n", string(buf))
+}
+
+func TestSerializeNested(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{
+				"host":        "server01",
+				"region.name": "us-east-1",
+				"region.zone": "a",
+			},
+			map[string]interface{}{
+				"usage.idle":   90.0,
+				"usage.user":   10.0,
+				"load":         1.5,
+				"load.average": 2.5,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	s, _ := NewSerializer(0)
+	s.SetNestingSeparator(".")
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"fields": {
+			"load": 1.5,
+			"load.average": 2.5,
+			"usage": {"idle": 90, "user": 10}
+		},
+		"name": "cpu",
+		"tags": {
+			"host": "server01",
+			"region": {"name": "us-east-1", "zone": "a"}
+		},
+		"timestamp": 0
+	}`, string(buf))
+}
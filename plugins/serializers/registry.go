@@ -12,6 +12,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/json"
 	"github.com/influxdata/telegraf/plugins/serializers/nowmetric"
 	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
+	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -44,6 +45,13 @@ type Config struct {
 	// Support tags in graphite protocol
 	GraphiteTagSupport bool
 
+	// Sanitization of the tags in graphite protocol, "strict" or
+	// "compatible"
+	GraphiteTagSanitizeMode string
+
+	// Separator of the graphite template parts
+	GraphiteSeparator string
+
 	// Maximum line length in bytes; influx format only
 	InfluxMaxLineBytes int
 
@@ -54,18 +62,54 @@ type Config struct {
 	// Support unsigned integer output; influx format only
 	InfluxUintSupport bool
 
-	// Prefix to add to all measurements, only supports Graphite
+	// Prefix to add to all measurements, only supports Graphite and
+	// Wavefront
 	Prefix string
 
 	// Template for converting telegraf metrics into Graphite
 	// only supports Graphite
 	Template string
 
+	// Templates, with a filter, for converting telegraf metrics into
+	// Graphite; only supports Graphite
+	Templates []string
+
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration
 
+	// Key of the timestamp; json format only
+	JSONTimestampKey string
+
+	// Go reference time layout of the timestamp, if not set the timestamp
+	// is a number in the timestamp units; json format only
+	JSONTimestampFormat string
+
+	// Separator splitting tag and field keys into nested objects; json
+	// format only
+	JSONNestingSeparator string
+
+	// Layout of the batches, "object" or "array"; json format only
+	JSONBatchFormat string
+
 	// Include HEC routing fields for splunkmetric output
 	HecRouting bool
+
+	// Enable Splunk MultiMetric output (Splunk 8.0+)
+	SplunkmetricMultiMetric bool
+
+	// Carbon2 metric format, "field_separate" or "metric_includes_field"
+	Carbon2Format string
+
+	// Tags written as carbon2 meta tags instead of intrinsic tags
+	Carbon2MetaTags []string
+
+	// Sanitize the names with a regex instead of a replacer; wavefront
+	// format only
+	WavefrontUseStrict bool
+
+	// Tags used as the source when there is no "source" tag; wavefront
+	// format only
+	WavefrontSourceOverride []string
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -76,15 +120,18 @@ func NewSerializer(config *Config) (Serializer, error) {
 	case "influx":
 		serializer, err = NewInfluxSerializerConfig(config)
 	case "graphite":
-		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport)
+		serializer, err = NewGraphiteSerializer(config.Prefix, config.Template, config.GraphiteTagSupport,
+			config.GraphiteTagSanitizeMode, config.GraphiteSeparator, config.Templates)
 	case "json":
-		serializer, err = NewJsonSerializer(config.TimestampUnits)
+		serializer, err = NewJsonSerializerConfig(config)
 	case "splunkmetric":
-		serializer, err = NewSplunkmetricSerializer(config.HecRouting)
+		serializer, err = NewSplunkmetricSerializer(config.HecRouting, config.SplunkmetricMultiMetric)
 	case "nowmetric":
 		serializer, err = NewNowSerializer()
 	case "carbon2":
-		serializer, err = NewCarbon2Serializer()
+		serializer, err = NewCarbon2Serializer(config.Carbon2Format, config.Carbon2MetaTags)
+	case "wavefront":
+		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -95,12 +142,33 @@ func NewJsonSerializer(timestampUnits time.Duration) (Serializer, error) {
 	return json.NewSerializer(timestampUnits)
 }
 
-func NewCarbon2Serializer() (Serializer, error) {
-	return carbon2.NewSerializer()
+func NewJsonSerializerConfig(config *Config) (Serializer, error) {
+	batchFormat, err := json.ParseBatchFormat(config.JSONBatchFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := json.NewSerializer(config.TimestampUnits)
+	if err != nil {
+		return nil, err
+	}
+	s.SetTimestampKey(config.JSONTimestampKey)
+	s.SetTimestampFormat(config.JSONTimestampFormat)
+	s.SetNestingSeparator(config.JSONNestingSeparator)
+	s.SetBatchFormat(batchFormat)
+	return s, nil
+}
+
+func NewCarbon2Serializer(carbon2_format string, carbon2_meta_tags []string) (Serializer, error) {
+	return carbon2.NewSerializer(carbon2_format, carbon2_meta_tags)
+}
+
+func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string) (Serializer, error) {
+	return wavefront.NewSerializer(prefix, useStrict, sourceOverride)
 }
 
-func NewSplunkmetricSerializer(splunkmetric_hec_routing bool) (Serializer, error) {
-	return splunkmetric.NewSerializer(splunkmetric_hec_routing)
+func NewSplunkmetricSerializer(splunkmetric_hec_routing bool, splunkmetric_multimetric bool) (Serializer, error) {
+	return splunkmetric.NewSerializer(splunkmetric_hec_routing, splunkmetric_multimetric)
 }
 
 func NewNowSerializer() (Serializer, error) {
@@ -129,10 +197,29 @@ func NewInfluxSerializer() (Serializer, error) {
 	return influx.NewSerializer(), nil
 }
 
-func NewGraphiteSerializer(prefix, template string, tag_support bool) (Serializer, error) {
+func NewGraphiteSerializer(prefix, template string, tag_support bool, tag_sanitize_mode string, separator string, templates []string) (Serializer, error) {
+	graphiteTemplates, defaultTemplate, err := graphite.InitGraphiteTemplates(templates)
+	if err != nil {
+		return nil, err
+	}
+	if defaultTemplate != "" {
+		template = defaultTemplate
+	}
+
+	switch tag_sanitize_mode {
+	case "":
+		tag_sanitize_mode = graphite.TagSanitizeStrict
+	case graphite.TagSanitizeStrict, graphite.TagSanitizeCompatible:
+	default:
+		return nil, fmt.Errorf("invalid graphite_tag_sanitize_mode: %q", tag_sanitize_mode)
+	}
+
 	return &graphite.GraphiteSerializer{
-		Prefix:     prefix,
-		Template:   template,
-		TagSupport: tag_support,
+		Prefix:          prefix,
+		Template:        template,
+		TagSupport:      tag_support,
+		TagSanitizeMode: tag_sanitize_mode,
+		Separator:       separator,
+		Templates:       graphiteTemplates,
 	}, nil
 }
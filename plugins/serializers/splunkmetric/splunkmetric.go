@@ -9,12 +9,24 @@ import (
 )
 
 type serializer struct {
-	HecRouting bool
+	HecRouting  bool
+	MultiMetric bool
 }
 
-func NewSerializer(splunkmetric_hec_routing bool) (*serializer, error) {
+// HECTimeSeries is the HEC envelope of a metric.
+type HECTimeSeries struct {
+	Time   float64                `json:"time"`
+	Event  string                 `json:"event"`
+	Host   string                 `json:"host,omitempty"`
+	Index  string                 `json:"index,omitempty"`
+	Source string                 `json:"source,omitempty"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+func NewSerializer(splunkmetric_hec_routing bool, splunkmetric_multimetric bool) (*serializer, error) {
 	s := &serializer{
-		HecRouting: splunkmetric_hec_routing,
+		HecRouting:  splunkmetric_hec_routing,
+		MultiMetric: splunkmetric_multimetric,
 	}
 	return s, nil
 }
@@ -54,18 +66,35 @@ func (s *serializer) createObject(metric telegraf.Metric) (metricGroup []byte, e
 		 ** time:       The timestamp for the metric
 		 ** All other index fields become dimensions.
 	*/
-	type HECTimeSeries struct {
-		Time   float64                `json:"time"`
-		Event  string                 `json:"event"`
-		Host   string                 `json:"host,omitempty"`
-		Index  string                 `json:"index,omitempty"`
-		Source string                 `json:"source,omitempty"`
-		Fields map[string]interface{} `json:"fields"`
+	dataGroup := HECTimeSeries{
+		Event: "metric",
+		// Convert ns to float seconds since epoch.
+		Time: float64(metric.Time().UnixNano()) / float64(1000000000),
+	}
+
+	// Break tags out into key(n)=value(t) pairs
+	dimensions := map[string]interface{}{}
+	for n, t := range metric.Tags() {
+		if n == "host" {
+			dataGroup.Host = t
+		} else if n == "index" {
+			dataGroup.Index = t
+		} else if n == "source" {
+			dataGroup.Source = t
+		} else {
+			dimensions[n] = t
+		}
 	}
 
-	dataGroup := HECTimeSeries{}
-	var metricJson []byte
+	if s.MultiMetric {
+		return s.createMulti(metric, dataGroup, dimensions)
+	}
+	return s.createSingle(metric, dataGroup, dimensions)
+}
 
+// createSingle outputs an object per field, with the field as metric_name and
+// _value.
+func (s *serializer) createSingle(metric telegraf.Metric, dataGroup HECTimeSeries, dimensions map[string]interface{}) (metricGroup []byte, err error) {
 	for _, field := range metric.FieldList() {
 
 		value, valid := verifyValue(field.Value)
@@ -76,45 +105,62 @@ func (s *serializer) createObject(metric telegraf.Metric) (metricGroup []byte, e
 		}
 
 		obj := map[string]interface{}{}
+		for n, t := range dimensions {
+			obj[n] = t
+		}
 		obj["metric_name"] = metric.Name() + "." + field.Key
 		obj["_value"] = value
 
-		dataGroup.Event = "metric"
-		// Convert ns to float seconds since epoch.
-		dataGroup.Time = float64(metric.Time().UnixNano()) / float64(1000000000)
-		dataGroup.Fields = obj
-
-		// Break tags out into key(n)=value(t) pairs
-		for n, t := range metric.Tags() {
-			if n == "host" {
-				dataGroup.Host = t
-			} else if n == "index" {
-				dataGroup.Index = t
-			} else if n == "source" {
-				dataGroup.Source = t
-			} else {
-				dataGroup.Fields[n] = t
-			}
+		metricJson, err := s.marshal(dataGroup, obj)
+		if err != nil {
+			return nil, err
 		}
+		metricGroup = append(metricGroup, metricJson...)
+	}
 
-		switch s.HecRouting {
-		case true:
-			// Output the data as a fields array and host,index,time,source overrides for the HEC.
-			metricJson, err = json.Marshal(dataGroup)
-		default:
-			// Just output the data and the time, useful for file based outuputs
-			dataGroup.Fields["time"] = dataGroup.Time
-			metricJson, err = json.Marshal(dataGroup.Fields)
-		}
+	return metricGroup, nil
+}
 
-		metricGroup = append(metricGroup, metricJson...)
+// createMulti outputs a single object for all the fields, in the multiple
+// metrics format of Splunk 8 where each field is a "metric_name:<name>" key.
+// It is only valid because the fields of a metric share its time and
+// dimensions.
+func (s *serializer) createMulti(metric telegraf.Metric, dataGroup HECTimeSeries, dimensions map[string]interface{}) (metricGroup []byte, err error) {
+	obj := map[string]interface{}{}
+	for n, t := range dimensions {
+		obj[n] = t
+	}
 
-		if err != nil {
-			return nil, err
+	values := 0
+	for _, field := range metric.FieldList() {
+
+		value, valid := verifyValue(field.Value)
+
+		if !valid {
+			log.Printf("D! Can not parse value: %v for key: %v", field.Value, field.Key)
+			continue
 		}
+
+		obj["metric_name:"+metric.Name()+"."+field.Key] = value
+		values++
 	}
 
-	return metricGroup, nil
+	if values == 0 {
+		return nil, nil
+	}
+	return s.marshal(dataGroup, obj)
+}
+
+func (s *serializer) marshal(dataGroup HECTimeSeries, obj map[string]interface{}) ([]byte, error) {
+	if s.HecRouting {
+		// Output the data as a fields array and host,index,time,source overrides for the HEC.
+		dataGroup.Fields = obj
+		return json.Marshal(dataGroup)
+	}
+
+	// Just output the data and the time, useful for file based outuputs
+	obj["time"] = dataGroup.Time
+	return json.Marshal(obj)
 }
 
 func verifyValue(v interface{}) (value interface{}, valid bool) {
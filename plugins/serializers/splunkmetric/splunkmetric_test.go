@@ -29,7 +29,7 @@ func TestSerializeMetricFloat(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer(false)
+	s, _ := NewSerializer(false, false)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -49,7 +49,7 @@ func TestSerializeMetricFloatHec(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer(true)
+	s, _ := NewSerializer(true, false)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -68,7 +68,7 @@ func TestSerializeMetricInt(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer(false)
+	s, _ := NewSerializer(false, false)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -88,7 +88,7 @@ func TestSerializeMetricIntHec(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer(true)
+	s, _ := NewSerializer(true, false)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -108,7 +108,7 @@ func TestSerializeMetricBool(t *testing.T) {
 	m, err := metric.New("docker", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer(false)
+	s, _ := NewSerializer(false, false)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -128,7 +128,7 @@ func TestSerializeMetricBoolHec(t *testing.T) {
 	m, err := metric.New("docker", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer(true)
+	s, _ := NewSerializer(true, false)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -149,7 +149,7 @@ func TestSerializeMetricString(t *testing.T) {
 	m, err := metric.New("cpu", tags, fields, now)
 	assert.NoError(t, err)
 
-	s, _ := NewSerializer(false)
+	s, _ := NewSerializer(false, false)
 	var buf []byte
 	buf, err = s.Serialize(m)
 	assert.NoError(t, err)
@@ -182,7 +182,7 @@ func TestSerializeBatch(t *testing.T) {
 	)
 
 	metrics := []telegraf.Metric{m, n}
-	s, _ := NewSerializer(false)
+	s, _ := NewSerializer(false, false)
 	buf, err := s.SerializeBatch(metrics)
 	assert.NoError(t, err)
 
@@ -213,10 +213,76 @@ func TestSerializeBatchHec(t *testing.T) {
 	)
 
 	metrics := []telegraf.Metric{m, n}
-	s, _ := NewSerializer(true)
+	s, _ := NewSerializer(true, false)
 	buf, err := s.SerializeBatch(metrics)
 	assert.NoError(t, err)
 
 	expS := `{"time":0,"event":"metric","fields":{"_value":42,"metric_name":"cpu.value"}}` + `{"time":0,"event":"metric","fields":{"_value":92,"metric_name":"cpu.value"}}`
 	assert.Equal(t, string(expS), string(buf))
 }
+
+func TestSerializeMulti(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{
+				"cpu": "cpu0",
+			},
+			map[string]interface{}{
+				"user":          42.0,
+				"system":        8.0,
+				"processorType": "ARMv7 Processor rev 4 (v7l)",
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	s, _ := NewSerializer(false, true)
+	buf, err := s.Serialize(m)
+	assert.NoError(t, err)
+
+	expS := `{"cpu":"cpu0","metric_name:cpu.system":8,"metric_name:cpu.user":42,"time":0}`
+	assert.Equal(t, string(expS), string(buf))
+}
+
+func TestSerializeMultiHec(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{
+				"cpu":  "cpu0",
+				"host": "server01",
+			},
+			map[string]interface{}{
+				"user":   42.0,
+				"system": 8.0,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	s, _ := NewSerializer(true, true)
+	buf, err := s.Serialize(m)
+	assert.NoError(t, err)
+
+	expS := `{"time":0,"event":"metric","host":"server01","fields":{"cpu":"cpu0","metric_name:cpu.system":8,"metric_name:cpu.user":42}}`
+	assert.Equal(t, string(expS), string(buf))
+}
+
+func TestSerializeMultiNoValues(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"processorType": "ARMv7 Processor rev 4 (v7l)",
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	s, _ := NewSerializer(true, true)
+	buf, err := s.Serialize(m)
+	assert.NoError(t, err)
+	assert.Empty(t, buf)
+}
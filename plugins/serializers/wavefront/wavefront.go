@@ -0,0 +1,164 @@
+package wavefront
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// catch many of the invalid chars that could appear in a metric or tag name
+var sanitizedChars = strings.NewReplacer(
+	"!", "-", "@", "-", "#", "-", "$", "-", "%", "-", "^", "-", "&", "-",
+	"*", "-", "(", "-", ")", "-", "+", "-", "`", "-", "'", "-", "\"", "-",
+	"[", "-", "]", "-", "{", "-", "}", "-", ":", "-", ";", "-", "<", "-",
+	">", "-", ",", "-", "?", "-", "/", "-", "\\", "-", "|", "-", " ", "-",
+	"=", "-",
+)
+
+// strictSanitizedChars replaces every character Wavefront does not accept in
+// metric names and tag keys.  It is more thorough than the replacer but
+// slower.
+var strictSanitizedChars = regexp.MustCompile(`[^a-zA-Z\d_.-]`)
+
+var tagValueReplacer = strings.NewReplacer("\"", "\\\"", "*", "-")
+
+type serializer struct {
+	prefix         string
+	useStrict      bool
+	sourceOverride []string
+}
+
+// NewSerializer returns a serializer of the Wavefront data format.  The
+// source of a point is its "source" tag, or the first of the sourceOverride
+// tags, or its "host" tag.
+func NewSerializer(prefix string, useStrict bool, sourceOverride []string) (*serializer, error) {
+	s := &serializer{
+		prefix:         prefix,
+		useStrict:      useStrict,
+		sourceOverride: sourceOverride,
+	}
+	return s, nil
+}
+
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	s.write(&buf, metric)
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		s.write(&buf, metric)
+	}
+	return buf.Bytes(), nil
+}
+
+// write writes a point per numeric field of the metric:
+//   "name" value timestamp source="source" "key"="value"...
+func (s *serializer) write(buf *bytes.Buffer, metric telegraf.Metric) {
+	source, tags := s.tags(metric)
+	timestamp := strconv.FormatInt(metric.Time().Unix(), 10)
+
+	for _, field := range metric.FieldList() {
+		value, ok := formatValue(field.Value)
+		if !ok {
+			continue
+		}
+
+		name := s.prefix + metric.Name()
+		if field.Key != "value" {
+			name += "." + field.Key
+		}
+
+		buf.WriteString(`"`)
+		buf.WriteString(s.sanitize(name))
+		buf.WriteString(`" `)
+		buf.WriteString(value)
+		buf.WriteString(" ")
+		buf.WriteString(timestamp)
+		buf.WriteString(` source="`)
+		buf.WriteString(tagValueReplacer.Replace(source))
+		buf.WriteString(`"`)
+		for _, tag := range tags {
+			buf.WriteString(` "`)
+			buf.WriteString(tag.Key)
+			buf.WriteString(`"="`)
+			buf.WriteString(tag.Value)
+			buf.WriteString(`"`)
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// tags returns the source of the metric and its other sanitized point tags,
+// sorted by key.  Empty tags are left out.
+func (s *serializer) tags(metric telegraf.Metric) (string, []*telegraf.Tag) {
+	tags := make(map[string]string, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		if tag.Value != "" {
+			tags[tag.Key] = tag.Value
+		}
+	}
+
+	source, ok := tags["source"]
+	if ok {
+		delete(tags, "source")
+	} else {
+		for _, key := range s.sourceOverride {
+			v, found := tags[key]
+			if !found {
+				continue
+			}
+			source = v
+			delete(tags, key)
+			// The host is kept as a point tag.
+			if host, found := tags["host"]; found {
+				tags["telegraf_host"] = host
+			}
+			break
+		}
+		if source == "" {
+			source = tags["host"]
+		}
+	}
+	delete(tags, "host")
+
+	list := make([]*telegraf.Tag, 0, len(tags))
+	for k, v := range tags {
+		list = append(list, &telegraf.Tag{
+			Key:   s.sanitize(k),
+			Value: tagValueReplacer.Replace(v),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return source, list
+}
+
+func (s *serializer) sanitize(name string) string {
+	if s.useStrict {
+		return strictSanitizedChars.ReplaceAllLiteralString(name, "-")
+	}
+	return sanitizedChars.Replace(name)
+}
+
+func formatValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
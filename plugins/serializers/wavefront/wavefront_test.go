@@ -0,0 +1,157 @@
+package wavefront
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name           string
+		prefix         string
+		useStrict      bool
+		sourceOverride []string
+		metric         telegraf.Metric
+		expected       string
+	}{
+		{
+			name: "fields",
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{
+					"host": "server01",
+					"cpu":  "cpu0",
+				},
+				map[string]interface{}{
+					"usage_idle": 91.5,
+					"count":      int64(4),
+					"running":    true,
+					"state":      "ok",
+				},
+				time.Unix(1554172800, 0),
+			),
+			expected: `"cpu.usage_idle" 91.5 1554172800 source="server01" "cpu"="cpu0"` + "\n" +
+				`"cpu.count" 4 1554172800 source="server01" "cpu"="cpu0"` + "\n" +
+				`"cpu.running" 1 1554172800 source="server01" "cpu"="cpu0"` + "\n",
+		},
+		{
+			name:   "value field and prefix",
+			prefix: "telegraf.",
+			metric: testutil.MustMetric(
+				"load",
+				map[string]string{
+					"host": "server01",
+				},
+				map[string]interface{}{
+					"value": 1.0,
+				},
+				time.Unix(1554172800, 0),
+			),
+			expected: `"telegraf.load" 1 1554172800 source="server01"` + "\n",
+		},
+		{
+			name:           "source override",
+			sourceOverride: []string{"hostname", "agent_host"},
+			metric: testutil.MustMetric(
+				"snmp",
+				map[string]string{
+					"host":       "server01",
+					"agent_host": "switch01",
+				},
+				map[string]interface{}{
+					"uptime": int64(42),
+				},
+				time.Unix(1554172800, 0),
+			),
+			expected: `"snmp.uptime" 42 1554172800 source="switch01" "telegraf_host"="server01"` + "\n",
+		},
+		{
+			name: "source tag",
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{
+					"host":   "server01",
+					"source": "server02",
+				},
+				map[string]interface{}{
+					"usage_idle": 91.5,
+				},
+				time.Unix(1554172800, 0),
+			),
+			expected: `"cpu.usage_idle" 91.5 1554172800 source="server02"` + "\n",
+		},
+		{
+			name: "sanitize",
+			metric: testutil.MustMetric(
+				"disk io",
+				map[string]string{
+					"host":       "server01",
+					"mount:path": `/var "log"`,
+					"empty":      "",
+				},
+				map[string]interface{}{
+					"free/used": 0.5,
+				},
+				time.Unix(1554172800, 0),
+			),
+			expected: `"disk-io.free-used" 0.5 1554172800 source="server01" "mount-path"="/var \"log\""` + "\n",
+		},
+		{
+			name:      "sanitize strict",
+			useStrict: true,
+			metric: testutil.MustMetric(
+				"disk~io",
+				map[string]string{
+					"host":  "server01",
+					"dev~1": "sda",
+				},
+				map[string]interface{}{
+					"free": 0.5,
+				},
+				time.Unix(1554172800, 0),
+			),
+			expected: `"disk-io.free" 0.5 1554172800 source="server01" "dev-1"="sda"` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.prefix, tt.useStrict, tt.sourceOverride)
+			require.NoError(t, err)
+
+			// The fields of a metric are not ordered.
+			buf, err := s.Serialize(tt.metric)
+			require.NoError(t, err)
+			require.ElementsMatch(t,
+				strings.SplitAfter(tt.expected, "\n"),
+				strings.SplitAfter(string(buf), "\n"))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host": "server01",
+		},
+		map[string]interface{}{
+			"usage_idle": 91.5,
+		},
+		time.Unix(1554172800, 0),
+	)
+
+	s, err := NewSerializer("", false, nil)
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{m, m})
+	require.NoError(t, err)
+	require.Equal(t,
+		`"cpu.usage_idle" 91.5 1554172800 source="server01"`+"\n"+
+			`"cpu.usage_idle" 91.5 1554172800 source="server01"`+"\n",
+		string(buf))
+}
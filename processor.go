@@ -10,3 +10,17 @@ type Processor interface {
 	// Apply the filter to the given metric.
 	Apply(in ...Metric) []Metric
 }
+
+// StreamingProcessor is a Processor that emits metrics independently of the
+// calls to Apply, ie: because it passes them through an external process.
+type StreamingProcessor interface {
+	Processor
+
+	// Start starts the processor, metrics added to the accumulator continue
+	// through the processors following it.
+	Start(acc Accumulator) error
+
+	// Stop stops the processor, pending metrics should be added to the
+	// accumulator before returning.
+	Stop()
+}
@@ -0,0 +1,14 @@
+package telegraf
+
+// SecretStore is a source of secrets that can be referenced from any string
+// in the configuration using the @{<id>:<key>} syntax.
+type SecretStore interface {
+	// SampleConfig returns the default configuration of the SecretStore
+	SampleConfig() string
+
+	// Description returns a one-sentence description on the SecretStore
+	Description() string
+
+	// Get returns the current value of the secret identified by key.
+	Get(key string) (string, error)
+}
@@ -0,0 +1,15 @@
+package telegraf
+
+// StatefulPlugin is implemented by plugins with an internal state that should
+// be kept across restarts, ie: the read position of a file.  The agent saves
+// the state to the state file on shutdown and restores it on startup.
+type StatefulPlugin interface {
+	// GetState returns the current state of the plugin, it must be possible
+	// to marshal the state as JSON.
+	GetState() interface{}
+
+	// SetState is called before the plugin is started with a state
+	// previously returned by GetState, the state has the same type as the
+	// value currently returned by GetState.
+	SetState(state interface{}) error
+}